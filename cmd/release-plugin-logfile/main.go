@@ -0,0 +1,101 @@
+// Command release-plugin-logfile is an example plugin for release. It
+// records published releases and notifications as JSON lines in a file
+// and reports the recorded releases as a version source.
+//
+// Declare it in the release configuration and use its name as a publish
+// provider or notify type:
+//
+//	plugins:
+//	  - name: logfile
+//	    command: release-plugin-logfile
+//	    args: ["-file", "releases.jsonl"]
+//	publish:
+//	  - provider: logfile
+//	    repo: example
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+	"github.com/gbrennon/release_automation_golang/pkg/plugin"
+)
+
+// Entry is one line of the log file.
+type Entry struct {
+	Kind    string                `json:"kind"`
+	Release *plugin.PublishParams `json:"release,omitempty"`
+	Event   *notify.Payload       `json:"event,omitempty"`
+}
+
+type logfile struct{ path string }
+
+func (l logfile) append(e Entry) error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(e); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (l logfile) publish(_ context.Context, p plugin.PublishParams) (plugin.PublishResult, error) {
+	if err := l.append(Entry{Kind: "release", Release: &p}); err != nil {
+		return plugin.PublishResult{}, err
+	}
+	return plugin.PublishResult{ID: p.Tag, URL: "file://" + l.path + "#" + p.Tag, Uploaded: p.Assets}, nil
+}
+
+func (l logfile) notify(_ context.Context, p notify.Payload) error {
+	return l.append(Entry{Kind: "notification", Event: &p})
+}
+
+func (l logfile) versions(context.Context) ([]string, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tags []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.path, err)
+		}
+		if e.Release != nil {
+			tags = append(tags, e.Release.Tag)
+		}
+	}
+	return tags, sc.Err()
+}
+
+func main() {
+	path := flag.String("file", "releases.jsonl", "file to append releases and notifications to")
+	flag.Parse()
+
+	l := logfile{path: *path}
+	err := plugin.Serve("logfile", "1.0.0", plugin.Handlers{
+		Publish:  l.publish,
+		Notify:   l.notify,
+		Versions: l.versions,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "release-plugin-logfile:", err)
+		os.Exit(1)
+	}
+}
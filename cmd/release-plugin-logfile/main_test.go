@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+	"github.com/gbrennon/release_automation_golang/pkg/plugin"
+)
+
+func TestLogfile(t *testing.T) {
+	l := logfile{path: filepath.Join(t.TempDir(), "releases.jsonl")}
+	ctx := context.Background()
+
+	vs, err := l.versions(ctx)
+	if err != nil || len(vs) != 0 {
+		t.Fatalf("Expected no versions before the first release, got %v, %v", vs, err)
+	}
+
+	for _, tag := range []string{"v1.0.0", "v1.1.0"} {
+		res, err := l.publish(ctx, plugin.PublishParams{Tag: tag, Assets: []string{"dist/app"}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if res.URL != "file://"+l.path+"#"+tag || !slices.Equal(res.Uploaded, []string{"dist/app"}) {
+			t.Errorf("Expected file URL and uploaded assets, got %+v", res)
+		}
+	}
+	if err := l.notify(ctx, notify.Payload{Status: notify.Success, Tag: "v1.1.0"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	vs, err = l.versions(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(vs, []string{"v1.0.0", "v1.1.0"}) {
+		t.Errorf("Expected recorded releases, got %v", vs)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/bench"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+)
+
+// hiddenCommands run like commands but are left out of the usage: they
+// serve the development of the tool rather than releases.
+var hiddenCommands = []command{
+	{"bench", "measure the analysis pipeline on synthetic histories", (*app).bench},
+}
+
+// bench measures commit parsing, version resolution and changelog
+// rendering on synthetic histories of each -commits size, with their
+// allocations, and fails with the regressions over -baseline.
+func (a *app) bench(ctx context.Context, args []string) error {
+	fs := a.flags("bench")
+	sizes := fs.String("commits", "1000,10000", "comma-separated numbers of commits since the latest release of the histories measured")
+	tags := fs.Int("tags", 200, "number of releases tagged before, prereleases included")
+	seed := fs.Uint64("seed", 1, "seed of the synthetic histories")
+	filter := fs.String("run", "", "measure only the cases whose name contains this: parse, resolve or changelog")
+	benchtime := fs.String("benchtime", "1s", "how long each case runs: a duration, or a number of runs such as 100x")
+	baseline := fs.String("baseline", "", "results saved by -save to compare with")
+	threshold := fs.Float64("threshold", 0.2, "growth of ns/op, allocs/op or B/op over -baseline that fails, as a fraction")
+	save := fs.String("save", "", "write the results as JSON to this file, as a later -baseline")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: bench takes no arguments", relerr.ErrUsage)
+	}
+	var ns []int
+	for _, s := range strings.Split(*sizes, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("%w: -commits: %q is not a positive number", relerr.ErrUsage, s)
+		}
+		ns = append(ns, n)
+	}
+	if *tags < 0 {
+		return fmt.Errorf("%w: -tags must not be negative", relerr.ErrUsage)
+	}
+	if _, _, err := bench.ParseBenchtime(*benchtime); err != nil {
+		return fmt.Errorf("%w: -benchtime: %w", relerr.ErrUsage, err)
+	}
+	var base []bench.Result
+	if *baseline != "" {
+		data, err := os.ReadFile(*baseline)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &base); err != nil {
+			return fmt.Errorf("-baseline %s: %w", *baseline, err)
+		}
+	}
+
+	for _, n := range ns {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		results, err := bench.Run(bench.NewHistory(bench.Size{Commits: n, Tags: *tags}, *seed), bench.Options{Filter: *filter, Benchtime: *benchtime})
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			fmt.Fprintf(a.stdout, "%-16s %8d %14d ns/op %12d B/op %10d allocs/op\n", r.Name, r.N, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp)
+		}
+		a.result.Benchmarks = append(a.result.Benchmarks, results...)
+	}
+
+	if *save != "" {
+		data, err := json.MarshalIndent(a.result.Benchmarks, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*save, append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	if base == nil {
+		return nil
+	}
+	a.result.Regressions = bench.Compare(a.result.Benchmarks, base, *threshold)
+	for _, r := range a.result.Regressions {
+		fmt.Fprintf(a.stdout, "regression %s\n", r)
+	}
+	if n := len(a.result.Regressions); n > 0 {
+		return relerr.Wrap(relerr.Precondition, fmt.Errorf("%d regression(s) over %s beyond %.0f%%", n, *baseline, *threshold*100))
+	}
+	fmt.Fprintf(a.stdout, "no regression over %s\n", *baseline)
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/artifacts"
+	"github.com/gbrennon/release_automation_golang/pkg/ci"
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/sbom"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+)
+
+func (a *app) build(ctx context.Context, args []string) error {
+	fs := a.flags("build")
+	cfg := a.cfg.Artifacts
+	pkg := fs.String("package", cfg.Package, "main package to build")
+	targets := fs.String("targets", strings.Join(cfg.Targets, ","), "comma-separated GOOS/GOARCH targets")
+	output := fs.String("output", cfg.Output, "output directory (default \"dist\")")
+	sbomFormat := fs.String("sbom", cfg.SBOM, "write an SBOM of each binary: cyclonedx or spdx")
+	sign := fs.String("sign", cfg.Sign, "sign SHA256SUMS with cosign or minisign")
+	key := fs.String("key", cfg.Key, "signing key for -sign")
+	provenance := fs.Bool("provenance", cfg.Provenance.Enabled, "write a SLSA provenance attestation of the binaries")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: release build [flags] <tag>", relerr.ErrUsage)
+	}
+	a.result.DryRun, a.result.Tag = *dryRun, fs.Arg(0)
+
+	cfg.Package, cfg.Output, cfg.Sign, cfg.Key = *pkg, *output, *sign, *key
+	cfg.SBOM, cfg.Provenance.Enabled = *sbomFormat, *provenance
+	cfg.Targets = nil
+	if *targets != "" {
+		cfg.Targets = strings.Split(*targets, ",")
+	}
+
+	paths, err := a.buildArtifacts(ctx, cfg, fs.Arg(0), *dryRun)
+	if err != nil {
+		return err
+	}
+	a.advance(fs.Arg(0), state.Built, *dryRun)
+	a.result.Assets = paths
+	for _, p := range paths {
+		fmt.Fprintln(a.stdout, p)
+	}
+	return nil
+}
+
+// buildArtifacts builds the binaries, SBOMs, checksums, signature and
+// provenance described by cfg for tag, returning the files to attach to the
+// release.
+func (a *app) buildArtifacts(ctx context.Context, cfg config.ArtifactsConfig, tag string, dryRun bool) ([]string, error) {
+	targets, err := artifacts.ParseTargets(cfg.Targets)
+	if err != nil {
+		return nil, err
+	}
+	b := &artifacts.Builder{
+		Package:      cfg.Package,
+		Binary:       cfg.Binary,
+		Targets:      targets,
+		Dir:          a.root,
+		Output:       cfg.Output,
+		NameTemplate: cfg.Name,
+		LDFlags:      cfg.LDFlags,
+		Reproducible: cfg.Reproducible,
+		Stdout:       a.stderr,
+		Stderr:       a.stderr,
+		DryRun:       dryRun,
+		Log:          a.stdout,
+		Logger:       a.log,
+	}
+	if cfg.SBOM != "" {
+		if b.SBOM, err = sbom.ParseFormat(cfg.SBOM); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Sign != "" {
+		if b.Signer, err = artifacts.NewSigner(cfg.Sign, cfg.Key); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Provenance.Enabled {
+		if b.Provenance, err = a.provenance(ctx, cfg.Provenance, tag); err != nil {
+			return nil, err
+		}
+	}
+	return b.Run(ctx, a.tagVersion(tag))
+}
+
+// provenance describes the build of tag for its SLSA provenance: the
+// builder is the CI provider's runner, or this host outside CI, and the
+// source is the repository at HEAD.
+func (a *app) provenance(ctx context.Context, cfg config.ProvenanceConfig, tag string) (*artifacts.Provenance, error) {
+	commit, err := a.git.Head(ctx)
+	if err != nil {
+		return nil, err
+	}
+	env, _ := ci.FromEnv()
+	builder := cmp.Or(cfg.BuilderID, env.BuilderID())
+	if builder == "" {
+		host, _ := os.Hostname()
+		builder = "local://" + cmp.Or(host, "localhost")
+	}
+	p := &artifacts.Provenance{
+		BuilderID:    builder,
+		Repo:         a.links("", "").RepoURL,
+		Ref:          "refs/tags/" + tag,
+		Commit:       commit,
+		InvocationID: env.JobURL,
+	}
+	if cfg.Sign == "cosign" {
+		p.Signer = &artifacts.Cosign{Key: cfg.Key, Bundle: true, Stderr: a.stderr}
+	}
+	return p, nil
+}
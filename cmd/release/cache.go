@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/cache"
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// providerCacheDir keeps the issues, pull requests and commit authors looked
+// up on the providers, in stateDir.
+const providerCacheDir = "cache"
+
+// envOffline enables -offline, as RELEASE_DRY_RUN enables -dry-run.
+const envOffline = "RELEASE_OFFLINE"
+
+func (a *app) cache(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("%w: cache takes a subcommand: sync", relerr.ErrUsage)
+	}
+	switch args[0] {
+	case "sync":
+		return a.cacheSync(ctx, args[1:])
+	default:
+		return fmt.Errorf("%w: unknown cache subcommand %q: must be sync", relerr.ErrUsage, args[0])
+	}
+}
+
+// cacheSync looks up the references and commit authors of the history on
+// the providers and records them in the provider cache, for changelogs and
+// release notes to be generated -offline.
+func (a *app) cacheSync(ctx context.Context, args []string) error {
+	fs := a.flags("cache sync")
+	since := fs.String("since", "", "sync the commits after this tag or revision (default: the whole history)")
+	missing := fs.Bool("missing", false, "look up only what the cache has no record of, instead of refreshing every record")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: cache sync takes no arguments", relerr.ErrUsage)
+	}
+	if a.offline {
+		return fmt.Errorf("%w: cache sync looks the references up on the providers, which -offline does not allow", relerr.ErrUsage)
+	}
+	refsOn, loginsOn := a.cfg.Changelog.References.Enabled, a.cfg.Changelog.Contributors.Lookup
+	if !refsOn && !loginsOn {
+		fmt.Fprintln(a.stdout, "nothing to sync: changelog.references.enabled and changelog.contributors.lookup are off")
+		return nil
+	}
+
+	raw, err := a.git.CommitsSince(ctx, *since)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(stateDir, providerCacheDir)
+	if *dryRun {
+		dryrun.Printf(a.stdout, "would look up the references and authors of %d commit(s) into %s", len(raw), dir)
+		return nil
+	}
+	mode := cache.Refresh
+	if *missing {
+		mode = cache.ReadThrough
+	}
+
+	var issues, authors int
+	if refsOn {
+		f := a.referenceFinder(ctx, mode)
+		if f == nil {
+			return relerr.Wrap(relerr.Config, errors.New("references: no GitHub or GitLab publish target to look issues up on"))
+		}
+		if _, err := references.Resolve(ctx, workspace.Parse(raw), f); err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		issues = f.Fetched
+	}
+	if loginsOn {
+		f := a.loginFinder(ctx, mode)
+		if f == nil {
+			return relerr.Wrap(relerr.Config, errors.New("contributors: no GitHub publish target to look up logins on"))
+		}
+		if _, err := contributors.Resolve(ctx, contributors.List(raw, a.cfg.Changelog.Contributors.Exclude...), raw, f); err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		authors = f.Fetched
+	}
+	fmt.Fprintf(a.stdout, "synced %d issue(s) and %d commit author(s) of %d commit(s) into %s\n", issues, authors, len(raw), dir)
+	return nil
+}
+
+// offlineFromEnv reports whether envOffline enables -offline.
+func offlineFromEnv() bool {
+	v, err := strconv.ParseBool(os.Getenv(envOffline))
+	return err == nil && v
+}
+
+// providerCache is the store of the provider cache.
+func (a *app) providerCache() *cache.Store {
+	return &cache.Store{Dir: filepath.Join(a.root, stateDir, providerCacheDir), Now: a.now}
+}
+
+// cacheMode is the mode of the provider cache for commands reading it:
+// offline they never reach the providers, otherwise they look everything up
+// and record it for later offline runs.
+func (a *app) cacheMode() cache.Mode {
+	if a.offline {
+		return cache.Offline
+	}
+	return cache.Refresh
+}
+
+// cacheSource names t in the caches, e.g. "github octo/app".
+func cacheSource(t config.PublishTarget) string {
+	return strings.TrimSpace(t.Provider + " " + t.Repo)
+}
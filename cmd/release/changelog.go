@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/cache"
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+func (a *app) changelog(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "compile" {
+		return a.changelogCompile(ctx, args[1:])
+	}
+	fs := a.flags("changelog")
+	opts := a.planFlags(fs)
+	file := fs.String("file", "", "prepend the section to this changelog file, or write its fragment with changelog.fragments, instead of printing it")
+	tmpl := fs.String("template", a.cfg.Changelog.Template, "text/template file replacing the built-in section template")
+	backfill := fs.Bool("backfill", false, "regenerate the whole changelog from the existing release tags; -file is rewritten")
+	format := fs.String("format", "markdown", "output format: "+strings.Join(changelog.Formats, ", "))
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	a.result.DryRun = *dryRun
+
+	renderer, err := changelogRenderer(*tmpl, *format)
+	if err != nil {
+		return err
+	}
+	if *format != "markdown" && (*file != "" || *backfill) {
+		return fmt.Errorf("%w: -file and -backfill write Markdown, not -format %s", relerr.ErrUsage, *format)
+	}
+	if *backfill {
+		return a.backfillChangelog(ctx, opts.module, *file, renderer, *dryRun)
+	}
+
+	p, err := a.newPlan(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	release := a.changelogRelease(ctx, p.Next.String(), a.now(), p.Commits, p.Raw)
+	upgrade, breaking := a.planUpgradeGuide(p)
+	if breaking && *file != "" {
+		release.UpgradeGuide = a.upgradeGuideLink(upgrade.Version, *file)
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, release); err != nil {
+		return err
+	}
+	a.result.setPlan(p)
+	a.result.Changelog = buf.String()
+
+	if *file == "" {
+		_, err := a.stdout.Write(buf.Bytes())
+		return err
+	}
+	fragments := a.cfg.Changelog.Fragments.Enabled
+	switch {
+	case fragments && *dryRun:
+		dryrun.Printf(a.stdout, "would write the %s section to %s:", p.Next, a.fragmentPath(p.Next.String()))
+		if _, err := a.stdout.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	case fragments:
+		if _, err := a.writeFragment(p.Next.String(), buf.Bytes()); err != nil {
+			return err
+		}
+	case *dryRun:
+		dryrun.Printf(a.stdout, "would prepend the %s section to %s:", p.Next, *file)
+		if _, err := a.stdout.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	default:
+		if err := changelog.PrependFile(*file, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	if breaking {
+		if _, err := a.writeUpgradeGuide(upgrade, *dryRun); err != nil {
+			return err
+		}
+	}
+	if !fragments {
+		if _, err := a.translateChangelog(ctx, *file, *dryRun); err != nil {
+			return err
+		}
+	}
+	return a.runHooks(ctx, hooks.PostChangelog, planEnv(p), *dryRun)
+}
+
+// backfillChangelog renders a section for every stable release tag of the
+// module and writes them to file, replacing its release sections and
+// completing its translations, or to their fragments with
+// changelog.fragments, along with the upgrade guides of the releases with
+// breaking changes, or to stdout when file is empty. On a maintenance
+// branch only the releases of its line are included.
+func (a *app) backfillChangelog(ctx context.Context, module, file string, renderer changelog.Renderer, dryRun bool) error {
+	m, err := a.module(module)
+	if err != nil {
+		return err
+	}
+	releases, err := workspace.History(ctx, a.git, m)
+	if err != nil {
+		return err
+	}
+	line, err := a.line(ctx)
+	if err != nil {
+		return err
+	}
+	if line != nil {
+		releases = slices.DeleteFunc(releases, func(r workspace.Release) bool { return !line.Contains(r.Version) })
+	}
+	if len(releases) == 0 {
+		return errors.New("no release tags to backfill the changelog from")
+	}
+
+	sections := make([][]byte, 0, len(releases))
+	var upgrades []changelog.Upgrade
+	for i, r := range slices.Backward(releases) {
+		release := a.changelogRelease(ctx, r.Version.String(), r.Date, r.Commits, r.Raw)
+		previous := ""
+		if i > 0 {
+			previous = releases[i-1].Version.String()
+		}
+		if u, ok := a.upgradeGuide(release.Version, previous, r.Date, r.Commits); ok && file != "" {
+			release.UpgradeGuide = a.upgradeGuideLink(u.Version, file)
+			upgrades = append(upgrades, u)
+		}
+		var buf bytes.Buffer
+		if err := renderer.Render(&buf, release); err != nil {
+			return err
+		}
+		sections = append(sections, buf.Bytes())
+	}
+
+	if file == "" {
+		rebuilt := changelog.Rebuild(nil, sections)
+		a.result.Changelog = string(rebuilt)
+		_, err := a.stdout.Write(rebuilt)
+		return err
+	}
+	if a.cfg.Changelog.Fragments.Enabled {
+		if dryRun {
+			dryrun.Printf(a.stdout, "would write %d release section(s) to %s, %s to %s", len(sections), a.fragmentsDir(), releases[0].Tag, releases[len(releases)-1].Tag)
+		}
+		for i, r := range releases {
+			if dryRun {
+				break
+			}
+			if _, err := a.writeFragment(r.Version.String(), sections[len(sections)-1-i]); err != nil {
+				return err
+			}
+		}
+		for _, u := range upgrades {
+			if _, err := a.writeUpgradeGuide(u, dryRun); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	existing, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if dryRun {
+		dryrun.Printf(a.stdout, "would rewrite %s with %d release section(s), %s to %s", file, len(sections), releases[0].Tag, releases[len(releases)-1].Tag)
+	} else if err := os.WriteFile(file, changelog.Rebuild(existing, sections), 0o644); err != nil {
+		return err
+	}
+	for _, u := range upgrades {
+		if _, err := a.writeUpgradeGuide(u, dryRun); err != nil {
+			return err
+		}
+	}
+	_, err = a.translateChangelog(ctx, file, dryRun)
+	return err
+}
+
+// changelogRelease groups cs into the changelog model of version, listing
+// the contributors to raw when changelog.contributors is enabled and
+// linking the references of cs when changelog.references is.
+func (a *app) changelogRelease(ctx context.Context, version string, date time.Time, cs []commits.Commit, raw []gitrepo.Commit) changelog.Release {
+	r := changelog.New(version, date, cs, a.changelogOptions(ctx, cs))
+	if a.cfg.Changelog.Contributors.Enabled {
+		r.Contributors = a.releaseContributors(ctx, contributors.List(raw, a.cfg.Changelog.Contributors.Exclude...), raw)
+	}
+	return r
+}
+
+// releaseContributors looks up the logins of cs on the first GitHub publish
+// target when changelog.contributors.lookup is set, through the provider
+// cache. A failed lookup is logged and leaves the contributors found so far.
+func (a *app) releaseContributors(ctx context.Context, cs []contributors.Contributor, raw []gitrepo.Commit) []contributors.Contributor {
+	cc := a.cfg.Changelog.Contributors
+	if !cc.Lookup || len(cs) == 0 {
+		return cs
+	}
+	f := a.loginFinder(ctx, a.cacheMode())
+	if f == nil {
+		a.log.Warn("contributors: no GitHub publish target to look up logins on")
+		return cs
+	}
+	resolved, err := contributors.Resolve(ctx, cs, raw, f, cc.Exclude...)
+	if err != nil {
+		a.log.Warn(fmt.Sprintf("contributors: %v", err))
+	}
+	if f.Misses > 0 {
+		a.log.Warn(fmt.Sprintf("contributors: %d commit author(s) not in the provider cache are not linked; run 'release cache sync' online", f.Misses))
+	}
+	return resolved
+}
+
+// changelogOptions returns the grouping of cs configured by commits.rules
+// and changelog.references, resolving their references on the first
+// GitHub or GitLab publish target through the provider cache. A failed
+// lookup is logged and leaves the references resolved so far.
+func (a *app) changelogOptions(ctx context.Context, cs []commits.Commit) changelog.Options {
+	cl, err := a.classifier()
+	if err != nil {
+		a.log.Warn(err.Error())
+	}
+	rc := a.cfg.Changelog.References
+	if !rc.Enabled {
+		return changelog.Options{Classifier: cl}
+	}
+	opts := changelog.Options{ByLabel: rc.GroupByLabel, Classifier: cl}
+	for _, l := range rc.Labels {
+		opts.Sections = append(opts.Sections, changelog.Section{Title: l.Title, Types: l.Labels})
+	}
+	f := a.referenceFinder(ctx, a.cacheMode())
+	if f == nil {
+		a.log.Warn("references: no GitHub or GitLab publish target to look issues up on")
+		return opts
+	}
+	refs, err := references.Resolve(ctx, cs, f)
+	if err != nil {
+		a.log.Warn(fmt.Sprintf("references: %v", err))
+	}
+	if f.Misses > 0 {
+		a.log.Warn(fmt.Sprintf("references: %d issue(s) not in the provider cache are not linked; run 'release cache sync' online", f.Misses))
+	}
+	opts.References = refs
+	return opts
+}
+
+// referenceFinder returns the first GitHub or GitLab publish target, which
+// can look up issues and pull requests, behind the provider cache in mode,
+// or nil without one. Offline, the target's publisher is not even set up.
+func (a *app) referenceFinder(ctx context.Context, mode cache.Mode) *cache.References {
+	for _, t := range a.publishTargets("", "") {
+		if t.Provider != "github" && t.Provider != "gitlab" {
+			continue
+		}
+		c := &cache.References{Store: a.providerCache(), Source: cacheSource(t), Mode: mode}
+		if mode == cache.Offline {
+			return c
+		}
+		p, err := a.publisher(ctx, t, false)
+		if err != nil {
+			a.log.Warn(fmt.Sprintf("references: %v", err))
+			return nil
+		}
+		f, ok := p.(references.Finder)
+		if !ok {
+			return nil
+		}
+		c.Finder = f
+		return c
+	}
+	return nil
+}
+
+// loginFinder returns the first GitHub publish target, which can tell the
+// logins of commit authors, behind the provider cache in mode, or nil
+// without one.
+func (a *app) loginFinder(ctx context.Context, mode cache.Mode) *cache.Logins {
+	for _, t := range a.publishTargets("", "") {
+		if t.Provider != "github" {
+			continue
+		}
+		c := &cache.Logins{Store: a.providerCache(), Source: cacheSource(t), Mode: mode}
+		if mode == cache.Offline {
+			return c
+		}
+		p, err := a.publisher(ctx, t, false)
+		if err != nil {
+			a.log.Warn(fmt.Sprintf("contributors: %v", err))
+			return nil
+		}
+		f, ok := p.(contributors.LoginFinder)
+		if !ok {
+			return nil
+		}
+		c.Finder = f
+		return c
+	}
+	return nil
+}
+
+// changelogRenderer loads the template at path, or returns the renderer of
+// format when path is empty.
+func changelogRenderer(path, format string) (changelog.Renderer, error) {
+	if path == "" {
+		r, err := changelog.NewRenderer(format)
+		if err != nil {
+			return nil, relerr.Wrap(relerr.Usage, err)
+		}
+		return r, nil
+	}
+	if format != "markdown" {
+		return nil, fmt.Errorf("%w: -template replaces the Markdown template, not -format %s", relerr.ErrUsage, format)
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return changelog.NewTemplateRenderer(string(text))
+}
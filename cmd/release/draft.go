@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+)
+
+func (a *app) draft(ctx context.Context, args []string) error {
+	fs := a.flags("draft")
+	o := a.publishFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: release draft [flags] <tag>", relerr.ErrUsage)
+	}
+	return a.runPublish(ctx, fs.Arg(0), o, publishDraft)
+}
+
+func (a *app) approve(ctx context.Context, args []string) error {
+	fs := a.flags("approve")
+	o := a.publishFlags(fs)
+	pr := fs.Int("pr", 0, "pull request whose reviews approve the release (default: the merged pull request of the tagged commit)")
+	var approvers stringsFlag
+	fs.Var(&approvers, "approver", "login that must have approved the release (repeatable; default: approval.approvers)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: release approve [flags] [tag]", relerr.ErrUsage)
+	}
+	// The artifacts of the draft are reused unless -build is given.
+	built := false
+	fs.Visit(func(f *flag.Flag) { built = built || f.Name == "build" })
+	*o.build = *o.build && built
+
+	tag := fs.Arg(0)
+	if tag == "" {
+		r, err := a.loadState()
+		if err != nil || r.State != state.Drafted {
+			return fmt.Errorf("%w: no drafted release is recorded: release approve [flags] <tag>", relerr.ErrUsage)
+		}
+		tag = r.Tag
+	}
+	if len(approvers) == 0 {
+		approvers = a.cfg.Approval.Approvers
+	}
+	if err := a.checkApproval(ctx, tag, approvers, *pr, o); err != nil {
+		return err
+	}
+	return a.runPublish(ctx, tag, o, publishApprove)
+}
+
+// checkApproval fails with ErrNotApproved unless each of approvers
+// approved the pull request of tag: pr, or else the merged pull request of
+// the tagged commit, on the first publish target that reads reviews.
+func (a *app) checkApproval(ctx context.Context, tag string, approvers []string, pr int, o *publishOptions) error {
+	if len(approvers) == 0 {
+		return nil
+	}
+	var r publish.Reviewer
+	for _, t := range a.publishTargets(o.provider, o.repo) {
+		if t.Provider != "github" && t.Provider != "gitlab" {
+			continue
+		}
+		p, err := a.publisher(ctx, t, false)
+		if err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		if rv, ok := p.(publish.Reviewer); ok {
+			r = rv
+			break
+		}
+	}
+	if r == nil {
+		return relerr.Wrap(relerr.Config, errors.New("approval: no GitHub or GitLab publish target to read the reviews on"))
+	}
+
+	if pr == 0 {
+		tags, err := a.git.Tags(ctx)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(tags, func(t gitrepo.Tag) bool { return t.Name == tag })
+		if i < 0 {
+			return fmt.Errorf("%w: no tag %s", relerr.ErrUsage, tag)
+		}
+		n, found, err := r.CommitPullRequest(ctx, tags[i].Commit)
+		if err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		if !found {
+			return fmt.Errorf("%w: %s was not merged through a pull request whose reviews approve it; name one with -pr", relerr.ErrNotApproved, tag)
+		}
+		pr = n
+	}
+	got, err := r.Approvers(ctx, pr)
+	if err != nil {
+		return relerr.Wrap(relerr.Provider, err)
+	}
+	var missing []string
+	for _, login := range approvers {
+		if !slices.ContainsFunc(got, func(g string) bool { return strings.EqualFold(g, login) }) {
+			missing = append(missing, login)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: pull request #%d of %s lacks the approval of %s", relerr.ErrNotApproved, pr, tag, strings.Join(missing, ", "))
+	}
+	fmt.Fprintf(a.stdout, "approved by %s on pull request #%d\n", strings.Join(approvers, ", "), pr)
+	return nil
+}
+
+// advanceDraft records that tag is drafted, with the assets attached to
+// its drafts; see advance.
+func (a *app) advanceDraft(tag string, assets []string, dryRun bool, urls ...string) {
+	if a.state == nil || dryRun {
+		return
+	}
+	if _, err := a.state.Advance(state.Event{Tag: tag, To: state.Drafted, Command: a.command, URLs: urls, Assets: assets}); err != nil {
+		a.log.Warn(err.Error())
+	}
+}
+
+// draftedAssets returns the assets the draft of tag recorded, for approve
+// to publish the targets without drafts with, or none when they are built
+// again. Assets gone since are left out with a warning.
+func (a *app) draftedAssets(tag string, build bool) ([]string, error) {
+	if build || a.state == nil {
+		return nil, nil
+	}
+	r, err := a.state.Load()
+	if err != nil || r == nil || r.Tag != tag {
+		return nil, err
+	}
+	var assets []string
+	for _, path := range r.Assets {
+		if _, err := os.Stat(path); err != nil {
+			a.log.Warn(fmt.Sprintf("%s, attached to the draft of %s, is gone; approve with -build or -asset to publish it on the targets without drafts", path, tag))
+			continue
+		}
+		assets = append(assets, path)
+	}
+	return assets, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/ci"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+)
+
+// env prints the values computed for the next release as variables, for
+// later steps of a pipeline to read instead of planning the release again.
+// The names are those the hooks get.
+func (a *app) env(ctx context.Context, args []string) error {
+	fs := a.flags("env")
+	opts := a.planFlags(fs)
+	format := fs.String("format", "shell", "output format: shell (export lines for eval), dotenv, or github ($GITHUB_OUTPUT and $GITHUB_ENV lines)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: env takes no arguments", relerr.ErrUsage)
+	}
+	if *format != "shell" && *format != "dotenv" && *format != "github" {
+		return fmt.Errorf("%w: unknown format %q: must be shell, dotenv or github", relerr.ErrUsage, *format)
+	}
+
+	p, err := a.newPlan(ctx, opts)
+	if err != nil {
+		return err
+	}
+	a.result.setPlan(p)
+
+	var prev string
+	if p.HasPrevious {
+		prev = p.Previous.String()
+	}
+	vars := []ci.Output{
+		{Name: hooks.EnvNextVersion, Value: p.Next.String()},
+		{Name: hooks.EnvPrevVersion, Value: prev},
+		{Name: "CHANNEL", Value: p.Channel},
+		{Name: "TAG", Value: p.Tag()},
+		{Name: "CHANGELOG_PATH", Value: a.cfg.Changelog.Path},
+	}
+	switch *format {
+	case "shell":
+		for _, v := range vars {
+			fmt.Fprintf(a.stdout, "export %s=%s\n", v.Name, shellQuote(v.Value))
+		}
+	case "dotenv":
+		for _, v := range vars {
+			fmt.Fprintf(a.stdout, "%s=%s\n", v.Name, dotenvQuote(v.Value))
+		}
+	case "github":
+		text, err := ci.FormatOutputs(vars)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(a.stdout, text)
+	}
+	return nil
+}
+
+// shellQuote quotes s for POSIX shells: single-quoted, with the single
+// quotes it holds spliced in.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// plainValue matches the dotenv values that need no quotes.
+var plainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@+-]*$`)
+
+// dotenvQuote double-quotes s unless it is plain, escaping what dotenv
+// parsers and Docker Compose expand in double quotes.
+func dotenvQuote(s string) string {
+	if plainValue.MatchString(s) {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// explanation is the audit trail of a plan printed by "next -explain".
+type explanation struct {
+	Previous    string            `json:"previous,omitempty"`
+	PreviousTag string            `json:"previous_tag,omitempty"`
+	Next        string            `json:"next"`
+	Tag         string            `json:"tag"`
+	Bump        string            `json:"bump"`
+	Forced      bool              `json:"forced,omitempty"`
+	Channel     string            `json:"channel,omitempty"`
+	Commits     []explainedCommit `json:"commits"`
+}
+
+// explainedCommit is one commit and the bump level it contributes.
+type explainedCommit struct {
+	Hash         string `json:"hash"`
+	Subject      string `json:"subject"`
+	Conventional bool   `json:"conventional"`
+	Type         string `json:"type,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	Breaking     bool   `json:"breaking,omitempty"`
+	Bump         string `json:"bump"`
+}
+
+// explain describes p. forced reports whether the level came from -bump
+// rather than from the commits.
+func explain(p workspace.Plan, forced bool) explanation {
+	e := explanation{
+		Next:    p.Next.String(),
+		Tag:     p.Tag(),
+		Bump:    p.Level.String(),
+		Forced:  forced,
+		Channel: p.Channel,
+		Commits: []explainedCommit{},
+	}
+	if p.HasPrevious {
+		e.Previous, e.PreviousTag = p.Previous.String(), p.PreviousTag
+	}
+
+	for _, rc := range p.Raw {
+		subject, _, _ := strings.Cut(rc.Message, "\n")
+		ec := explainedCommit{Hash: rc.Hash, Subject: subject, Bump: version.None.String()}
+		if c, err := commits.Parse(rc.Message); err == nil {
+			ec.Conventional = true
+			ec.Type, ec.Scope, ec.Breaking = c.Type, c.Scope, c.Breaking
+			ec.Bump = p.Classifier.Level(c).String()
+		}
+		e.Commits = append(e.Commits, ec)
+	}
+	return e
+}
+
+func (e explanation) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+func (e explanation) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMMIT\tBUMP\tSUBJECT")
+	for _, c := range e.Commits {
+		bump := c.Bump
+		if !c.Conventional {
+			bump = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", shortHash(c.Hash), bump, c.Subject)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	previous := e.PreviousTag
+	if previous == "" {
+		previous = "(no release)"
+	}
+	reason := "highest commit level"
+	if e.Forced {
+		reason = "set by -bump"
+	}
+	_, err := fmt.Fprintf(w, "\n%s -> %s (%s, %s)\n", previous, e.Tag, e.Bump, reason)
+	return err
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
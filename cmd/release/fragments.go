@@ -0,0 +1,121 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+)
+
+// defaultFragmentsDir holds the changelog fragments without a
+// changelog.fragments.dir.
+const defaultFragmentsDir = "changelog.d"
+
+// fragmentsDir returns the directory of the changelog fragments, relative
+// to the repository root.
+func (a *app) fragmentsDir() string {
+	return cmp.Or(a.cfg.Changelog.Fragments.Dir, defaultFragmentsDir)
+}
+
+// fragmentPath returns the path of the changelog fragment of version,
+// relative to the repository root.
+func (a *app) fragmentPath(version string) string {
+	return path.Join(a.fragmentsDir(), changelog.FragmentName(version))
+}
+
+// writeFragment writes section to the fragment of version and returns its
+// path, relative to the repository root.
+func (a *app) writeFragment(version string, section []byte) (string, error) {
+	file := a.fragmentPath(version)
+	target := filepath.Join(a.root, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(target, section, 0o644); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// readFragments returns the changelog fragments by version. Without a
+// fragments directory there are none.
+func (a *app) readFragments() (map[string][]byte, error) {
+	dir := filepath.Join(a.root, filepath.FromSlash(a.fragmentsDir()))
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fragments := make(map[string][]byte)
+	for _, e := range entries {
+		v, ok := changelog.FragmentVersion(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		fragments[v] = data
+	}
+	return fragments, nil
+}
+
+// changelogCompile assembles the changelog fragments, newest version
+// first, and prints the result or writes it to -file below its header,
+// completing its translations.
+func (a *app) changelogCompile(ctx context.Context, args []string) error {
+	fs := a.flags("changelog compile")
+	file := fs.String("file", "", "write the changelog to this file, keeping its header, instead of printing it")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: changelog compile takes no arguments", relerr.ErrUsage)
+	}
+	a.result.DryRun = *dryRun
+	return a.compileFragments(ctx, *file, *dryRun)
+}
+
+// compileFragments assembles the fragments into file, or prints them when
+// file is empty.
+func (a *app) compileFragments(ctx context.Context, file string, dryRun bool) error {
+	fragments, err := a.readFragments()
+	if err != nil {
+		return err
+	}
+	if len(fragments) == 0 {
+		return fmt.Errorf("no changelog fragments in %s", a.fragmentsDir())
+	}
+
+	if file == "" {
+		compiled := changelog.Assemble(nil, fragments)
+		a.result.Changelog = string(compiled)
+		_, err := a.stdout.Write(compiled)
+		return err
+	}
+	existing, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	compiled := changelog.Assemble(existing, fragments)
+	a.result.Changelog = string(compiled)
+	if dryRun {
+		dryrun.Printf(a.stdout, "would write %s with %d release section(s) from %s", file, len(fragments), a.fragmentsDir())
+	} else if err := os.WriteFile(file, compiled, 0o644); err != nil {
+		return err
+	}
+	_, err = a.translateChangelog(ctx, file, dryRun)
+	return err
+}
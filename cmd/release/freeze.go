@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/freeze"
+)
+
+// freezeWindows returns the freeze windows of the configuration.
+func (a *app) freezeWindows() ([]freeze.Window, error) {
+	var ws []freeze.Window
+	for i, fc := range a.cfg.Freeze {
+		loc, err := time.LoadLocation(fc.Timezone)
+		if err != nil {
+			return nil, relerr.Wrap(relerr.Config, fmt.Errorf("freeze[%d].timezone: %w", i, err))
+		}
+		name := fc.Name
+		if name == "" {
+			name = fmt.Sprintf("freeze[%d]", i)
+		}
+		var w freeze.Window
+		if fc.Cron != "" {
+			d, perr := time.ParseDuration(fc.Duration)
+			if perr != nil {
+				return nil, relerr.Wrap(relerr.Config, fmt.Errorf("freeze[%d].duration: %w", i, perr))
+			}
+			w, err = freeze.Recurring(name, fc.Cron, d, loc)
+		} else {
+			w, err = freeze.Range(name, fc.From, fc.Until, loc)
+		}
+		if err != nil {
+			return nil, relerr.Wrap(relerr.Config, err)
+		}
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
+
+// checkFreeze fails with freeze.ErrFrozen inside a freeze window, unless
+// force is set, which only warns.
+func (a *app) checkFreeze(force bool) error {
+	ws, err := a.freezeWindows()
+	if err != nil {
+		return err
+	}
+	err = freeze.Check(ws, a.now())
+	if err != nil && force {
+		a.log.Warn(fmt.Sprintf("%v; publishing anyway (-force)", err))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w; pass -force to publish anyway", err)
+	}
+	return nil
+}
+
+// schedule waits until no freeze window is active, then runs the release
+// command given as its arguments, if any.
+func (a *app) schedule(ctx context.Context, args []string) error {
+	fs := a.flags("schedule")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	a.result.DryRun = *dryRun
+	rest := fs.Args()
+	if len(rest) > 0 && releaseCommands[rest[0]] == nil {
+		names := slices.Sorted(maps.Keys(releaseCommands))
+		return fmt.Errorf("%w: schedule runs one of %s, not %q", relerr.ErrUsage, strings.Join(names, ", "), rest[0])
+	}
+
+	ws, err := a.freezeWindows()
+	if err != nil {
+		return err
+	}
+	now := a.now()
+	open, err := freeze.OpenAt(ws, now)
+	if err != nil {
+		return err
+	}
+	if open.After(now) {
+		w, _, _ := freeze.Frozen(ws, now)
+		when := open.Format("2006-01-02 15:04 MST")
+		if *dryRun {
+			dryrun.Printf(a.stdout, "would wait until %s, when %s ends", when, w.Name)
+		} else {
+			fmt.Fprintf(a.stdout, "releases are frozen by %s: waiting until %s\n", w.Name, when)
+			timer := time.NewTimer(open.Sub(now))
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("waiting for %s to end: %w", w.Name, ctx.Err())
+			case <-timer.C:
+			}
+		}
+	}
+
+	if len(rest) == 0 {
+		return nil
+	}
+	if *dryRun {
+		dryrun.Printf(a.stdout, "would run release %s", strings.Join(rest, " "))
+		return nil
+	}
+	return releaseCommands[rest[0]](a, ctx, rest[1:])
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"runtime"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// runHooks runs the configured hooks for stage. Hook output goes to stderr
+// so stdout stays machine-readable. Failures of post-* hooks are reported as
+// warnings; failures of pre-* hooks abort the command.
+func (a *app) runHooks(ctx context.Context, stage hooks.Stage, env hooks.Env, dryRun bool) error {
+	e, err := hooks.FromConfig(a.cfg.HooksFor(runtime.GOOS), hooks.Command{
+		Stdout: a.stderr,
+		Stderr: a.stderr,
+		Shell:  hooks.Shell(a.cfg.HookShell),
+	})
+	if err != nil {
+		return err
+	}
+	e.DryRun, e.Log, e.Logger = dryRun, a.stdout, a.log
+
+	if a.cfg.Changelog.Path != "" {
+		full := hooks.Env{hooks.EnvChangelog: a.cfg.Changelog.Path}
+		maps.Copy(full, env)
+		env = full
+	}
+	err = e.Run(ctx, stage, env)
+	if errors.Is(err, hooks.ErrPostHook) {
+		a.log.Warn(err.Error())
+		return nil
+	}
+	return relerr.Wrap(relerr.Hook, err)
+}
+
+// planEnv returns the hook environment describing p.
+func planEnv(p workspace.Plan) hooks.Env {
+	env := hooks.Env{
+		hooks.EnvNextVersion: p.Next.String(),
+		hooks.EnvTag:         p.Tag(),
+		hooks.EnvBump:        p.Level.String(),
+	}
+	if p.HasPrevious {
+		env[hooks.EnvPrevVersion] = p.Previous.String()
+	}
+	if p.Channel != "" {
+		env[hooks.EnvChannel] = p.Channel
+	}
+	return env
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// defaultInitial is the first version release init configures.
+const defaultInitial = "0.1.0"
+
+// versionFile is the file release init imports the version of a project
+// from when it has one.
+const versionFile = "VERSION"
+
+// initRepo scaffolds the configuration and the changelog of a project
+// starting to use release: the branch released is the current one, and
+// the first release is -initial unless the project already has a VERSION
+// file, whose version releases then bump. An existing changelog is kept;
+// an existing configuration is only replaced with -force.
+func (a *app) initRepo(ctx context.Context, args []string) error {
+	fs := a.flags("init")
+	file := fs.String("file", config.FileNames[0], "configuration file to write; the format follows its extension")
+	initial := fs.String("initial", defaultInitial, "version of the first release, usually 0.1.0 or 1.0.0")
+	importFrom := fs.String("import-from", "", "file holding the version already released, bumped by the first releases (default: VERSION when present)")
+	changelogPath := fs.String("changelog", a.cfg.Changelog.Path, "changelog to create")
+	force := fs.Bool("force", false, "replace an existing configuration file")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: init takes no arguments", relerr.ErrUsage)
+	}
+	a.result.DryRun = *dryRun
+
+	if _, err := os.Stat(*file); err == nil && !*force {
+		return relerr.Wrap(relerr.Precondition, fmt.Errorf("%s already exists; pass -force to replace it", *file))
+	}
+	if v, err := version.Parse(strings.TrimPrefix(*initial, "v")); err != nil || v.IsPrerelease() {
+		return fmt.Errorf("%w: -initial %q is not a stable version such as 0.1.0 or 1.0.0", relerr.ErrUsage, *initial)
+	}
+	if *importFrom == "" {
+		if _, err := os.Stat(filepath.Join(a.root, versionFile)); err == nil {
+			*importFrom = versionFile
+		}
+	}
+
+	branch, err := a.git.CurrentBranch(ctx)
+	if err != nil {
+		branch = config.Default().Branches[0]
+	}
+	s := config.Scaffold{
+		Branches:  []string{branch},
+		Tag:       config.ScaffoldTag{Prefix: config.Default().Tag.Prefix},
+		Changelog: config.ScaffoldChangelog{Path: *changelogPath},
+	}
+	if *importFrom != "" {
+		if _, err := a.importedVersion(workspace.Module{}, *importFrom); err != nil {
+			return err
+		}
+		s.Versioning.ImportFrom = *importFrom
+	} else {
+		s.Versioning.Initial = *initial
+	}
+	data, err := s.Encode(strings.TrimPrefix(filepath.Ext(*file), "."))
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", relerr.ErrUsage, *file, err)
+	}
+
+	if *dryRun {
+		dryrun.Printf(a.stdout, "would write %s:", *file)
+		if _, err := a.stdout.Write(data); err != nil {
+			return err
+		}
+	} else {
+		if err := os.WriteFile(*file, data, 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "wrote %s\n", *file)
+	}
+	return a.scaffoldChangelog(*changelogPath, *dryRun)
+}
+
+// scaffoldChangelog creates the changelog at path with its header, unless
+// there is one already.
+func (a *app) scaffoldChangelog(path string, dryRun bool) error {
+	_, err := os.Stat(path)
+	switch {
+	case err == nil:
+		fmt.Fprintf(a.stdout, "kept %s\n", path)
+		return nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	case dryRun:
+		dryrun.Printf(a.stdout, "would write %s", path)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(changelog.DefaultHeader), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "wrote %s\n", path)
+	return nil
+}
+
+// bootstrap gives p, when its module has no version tag yet, the base or
+// first version configured by versioning.import_from or versioning.initial.
+func (a *app) bootstrap(p workspace.Plan) (workspace.Plan, error) {
+	vc := a.cfg.Versioning
+	switch {
+	case p.HasPrevious:
+		return p, nil
+	case vc.ImportFrom != "":
+		v, err := a.importedVersion(p.Module, vc.ImportFrom)
+		if err != nil {
+			return p, err
+		}
+		return p.WithBase(v), nil
+	case vc.Initial != "":
+		v, err := version.Parse(strings.TrimPrefix(vc.Initial, "v"))
+		if err != nil {
+			return p, relerr.Wrap(relerr.Config, fmt.Errorf("versioning.initial: %w", err))
+		}
+		return p.WithInitial(v), nil
+	}
+	return p, nil
+}
+
+// importedVersion reads the version in file, relative to the directory of
+// m, such as "1.4.2" or "v1.4.2" alone on a line.
+func (a *app) importedVersion(m workspace.Module, file string) (version.Version, error) {
+	data, err := os.ReadFile(filepath.Join(a.root, filepath.FromSlash(m.Dir), file))
+	if err != nil {
+		return version.Version{}, relerr.Wrap(relerr.Config, fmt.Errorf("versioning.import_from: %w", err))
+	}
+	v, err := version.Parse(strings.TrimPrefix(strings.TrimSpace(string(data)), "v"))
+	if err != nil {
+		return version.Version{}, relerr.Wrap(relerr.Config, fmt.Errorf("versioning.import_from: %s: %w", file, err))
+	}
+	return v, nil
+}
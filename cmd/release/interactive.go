@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// errAborted is returned when the user declines to release.
+var errAborted = errors.New("release aborted")
+
+// isTerminal reports whether r is a terminal a user can answer prompts on.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// interactive walks through a whole release: it shows the computed
+// version and lets the user change the bump level, edit the changelog
+// section in $EDITOR and choose the publish targets, then tags, commits
+// the changelog and publishes once confirmed. On a terminal the questions
+// are asked with a TUI; when only stdin is one, as when the output is
+// piped, with line prompts. Without a terminal the flags answer every
+// question, and -yes is required to go ahead.
+func (a *app) interactive(ctx context.Context, args []string) error {
+	fs := a.flags("interactive")
+	opts := a.planFlags(fs)
+	yes := fs.Bool("yes", false, "release without asking for confirmation; required without a terminal")
+	var only, remotes stringsFlag
+	fs.Var(&remotes, "remote", "git remote to push the tag to (repeatable; default: the remotes config, or origin)")
+	fs.Var(&only, "target", "publish only to this provider, or provider:repo (repeatable; default: every target)")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !a.tty && !*yes {
+		return fmt.Errorf("%w: stdin is not a terminal; pass -yes to release with the flags as answers", relerr.ErrUsage)
+	}
+	w := &wizard{in: bufio.NewReader(a.stdin), out: a.stdout, ask: a.tty && !*yes}
+	if out, ok := a.stdout.(*os.File); w.ask && ok && isTerminal(out) {
+		w.ctx, w.tui = ctx, a.stdin
+	}
+	a.result.DryRun = *dryRun
+
+	if err := a.preflightBefore(ctx, "tag"); err != nil {
+		return err
+	}
+	p, err := a.newPlan(ctx, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "%s: %s release, %d commit(s) since %s\n", p.Tag(), p.Level, len(p.Commits), orNone(p.PreviousTag))
+	if p, err = a.chooseLevel(w, p); err != nil {
+		return err
+	}
+
+	section, err := a.editSection(ctx, w, p)
+	if err != nil {
+		return err
+	}
+
+	targets := a.publishTargets("", "")
+	selected := make([]bool, len(targets))
+	for i, t := range targets {
+		selected[i] = len(only) == 0 || slices.Contains(only, t.Provider) || slices.Contains(only, t.Provider+":"+t.Repo)
+	}
+	if err := w.toggle("Publish to", targetLabels(targets), selected); err != nil {
+		return err
+	}
+
+	tag := p.Tag()
+	var chosen []config.PublishTarget
+	for i, t := range targets {
+		if selected[i] {
+			chosen = append(chosen, t)
+		}
+	}
+	fmt.Fprintf(a.stdout, "\nRelease %s: tag and push to %s", tag, strings.Join(remoteNames(a.pushRemotes(remotes)), ", "))
+	if len(chosen) > 0 {
+		fmt.Fprintf(a.stdout, ", publish to %s", strings.Join(targetLabels(chosen), ", "))
+	}
+	fmt.Fprintln(a.stdout)
+	if w.ask {
+		if ok, err := w.confirm("Go ahead?", false); err != nil {
+			return err
+		} else if !ok {
+			return errAborted
+		}
+	}
+
+	if err := a.writeSection(ctx, p, section, *dryRun); err != nil {
+		return err
+	}
+	a.result.Changelog = string(section)
+	tagArgs := []string{"-bump", p.Level.String(), "-module", opts.module, "-channel", p.Channel, "-dry-run=" + strconv.FormatBool(*dryRun)}
+	if err := a.tag(ctx, tagArgs); err != nil {
+		return err
+	}
+	if len(chosen) == 0 {
+		fmt.Fprintf(a.stdout, "no publish target selected; run 'release publish %s' to push and publish it\n", tag)
+		return nil
+	}
+
+	notes, err := os.CreateTemp("", "release-notes-*.md")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(notes.Name())
+	if _, err := notes.Write(section); err != nil {
+		notes.Close()
+		return err
+	}
+	if err := notes.Close(); err != nil {
+		return err
+	}
+
+	// Only the chosen targets are published; the configuration is not
+	// used again once publish has run.
+	a.cfg.Publish = chosen
+	args = []string{"-notes", notes.Name(), "-dry-run=" + strconv.FormatBool(*dryRun)}
+	for _, r := range remotes {
+		args = append(args, "-remote", r)
+	}
+	return a.publish(ctx, append(args, tag))
+}
+
+// chooseLevel asks for the bump level, keeping the computed one by default.
+// Maintenance lines only offer patches, as newPlan enforces.
+func (a *app) chooseLevel(w *wizard, p workspace.Plan) (workspace.Plan, error) {
+	choices := []string{"patch", "minor", "major"}
+	if p.Line != nil {
+		choices = choices[:1]
+	}
+	answer, err := w.choose("Bump level", choices, p.Level.String())
+	if err != nil {
+		return p, err
+	}
+	l, _ := version.ParseLevel(answer)
+	if l != p.Level {
+		p = p.WithLevel(l)
+		fmt.Fprintf(w.out, "%s: %s release\n", p.Tag(), p.Level)
+	}
+	return p, nil
+}
+
+// editSection renders the changelog section of p and, when asked to,
+// opens it in the user's editor.
+func (a *app) editSection(ctx context.Context, w *wizard, p workspace.Plan) ([]byte, error) {
+	renderer, err := changelogRenderer(a.cfg.Changelog.Template, "markdown")
+	if err != nil {
+		return nil, err
+	}
+	release := a.changelogRelease(ctx, p.Next.String(), a.now(), p.Commits, p.Raw)
+	if u, ok := a.planUpgradeGuide(p); ok && a.cfg.Changelog.Path != "" {
+		release.UpgradeGuide = a.upgradeGuideLink(u.Version, a.cfg.Changelog.Path)
+	}
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, release); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(a.stdout, "\n%s\n", bytes.TrimRight(buf.Bytes(), "\n"))
+
+	edit, err := w.confirm("Edit the changelog?", false)
+	if err != nil || !edit {
+		return buf.Bytes(), err
+	}
+	return a.edit(ctx, buf.Bytes())
+}
+
+// edit opens content in $VISUAL or $EDITOR, falling back to vi, and
+// returns the saved file. The editor command may carry arguments, such as
+// "code --wait"; it runs in the hook_shell, cmd on Windows and sh elsewhere
+// by default.
+func (a *app) edit(ctx context.Context, content []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", "release-*.md")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := envOr("VISUAL", envOr("EDITOR", "vi"))
+	shell := hooks.Shell(a.cfg.HookShell)
+	if shell == "" {
+		shell = hooks.DefaultShell(runtime.GOOS)
+	}
+	args := shell.Args(editor + " " + shell.Quote(f.Name()))
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = a.stdin, a.stdout, a.stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor %s: %w", editor, err)
+	}
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(edited)) == 0 {
+		return nil, fmt.Errorf("%w: the edited text is empty", errAborted)
+	}
+	return edited, nil
+}
+
+// writeSection prepends section to the configured changelog, or writes
+// its fragment with changelog.fragments, and commits it with its
+// translations and upgrade guide, so the tag includes them. Without a
+// changelog path nothing is written.
+func (a *app) writeSection(ctx context.Context, p workspace.Plan, section []byte, dryRun bool) error {
+	path := a.cfg.Changelog.Path
+	if path == "" {
+		return nil
+	}
+	var files []string
+	switch {
+	case a.cfg.Changelog.Fragments.Enabled && dryRun:
+		dryrun.Printf(a.stdout, "would write the %s section to %s", p.Next, a.fragmentPath(p.Next.String()))
+		files = append(files, a.fragmentPath(p.Next.String()))
+	case a.cfg.Changelog.Fragments.Enabled:
+		fragment, err := a.writeFragment(p.Next.String(), section)
+		if err != nil {
+			return err
+		}
+		files = append(files, fragment)
+	default:
+		if dryRun {
+			dryrun.Printf(a.stdout, "would prepend the %s section to %s", p.Next, path)
+		} else if err := changelog.PrependFile(path, section); err != nil {
+			return err
+		}
+		translations, err := a.translateChangelog(ctx, path, dryRun)
+		if err != nil {
+			return err
+		}
+		files = append([]string{path}, translations...)
+	}
+	if u, ok := a.planUpgradeGuide(p); ok {
+		guide, err := a.writeUpgradeGuide(u, dryRun)
+		if err != nil {
+			return err
+		}
+		files = append(files, guide)
+	}
+	return a.repo(dryRun).CommitFiles(ctx, "chore(release): changelog for "+p.Tag(), files...)
+}
+
+// targetLabels names publish targets for the user.
+func targetLabels(targets []config.PublishTarget) []string {
+	labels := make([]string, len(targets))
+	for i, t := range targets {
+		labels[i] = t.Provider
+		if t.Repo != "" {
+			labels[i] += " " + t.Repo
+		}
+	}
+	return labels
+}
+
+// orNone returns s, or "the start" when it is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "the start"
+	}
+	return s
+}
+
+// wizard asks questions on a terminal, with line prompts read from in
+// unless tui is set. When ask is false every question takes its default
+// answer without prompting.
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+	ask bool
+	// tui is the terminal choose, confirm and toggle run their TUI on, until
+	// ctx is done; nil asks with line prompts.
+	tui io.Reader
+	ctx context.Context
+}
+
+// line asks for a line of text, returning def for an empty answer.
+func (w *wizard) line(prompt, def string) (string, error) {
+	if !w.ask {
+		return def, nil
+	}
+	fmt.Fprintf(w.out, "%s [%s]: ", prompt, def)
+	answer, err := w.in.ReadString('\n')
+	switch {
+	case errors.Is(err, io.EOF) && answer == "":
+		return "", errAborted
+	case err != nil && !errors.Is(err, io.EOF):
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// choose asks for one of choices, returning def for an empty answer.
+func (w *wizard) choose(prompt string, choices []string, def string) (string, error) {
+	if !w.ask {
+		return def, nil
+	}
+	if w.tui != nil {
+		return w.chooseTUI(w.ctx, prompt, choices, def)
+	}
+	for {
+		answer, err := w.line(fmt.Sprintf("%s (%s)", prompt, strings.Join(choices, ", ")), def)
+		if err != nil || slices.Contains(choices, answer) {
+			return answer, err
+		}
+		fmt.Fprintf(w.out, "%q is not one of %s\n", answer, strings.Join(choices, ", "))
+	}
+}
+
+// confirm asks a yes/no question, returning def for an empty answer.
+func (w *wizard) confirm(prompt string, def bool) (bool, error) {
+	if !w.ask {
+		return def, nil
+	}
+	if w.tui != nil {
+		answer, err := w.chooseTUI(w.ctx, prompt, []string{"yes", "no"}, map[bool]string{true: "yes", false: "no"}[def])
+		return answer == "yes", err
+	}
+	choices := map[bool]string{true: "Y/n", false: "y/N"}[def]
+	for {
+		answer, err := w.line(prompt+" ("+choices+")", "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// toggle lists items with their selection, and flips those whose numbers
+// the user enters until the answer is empty.
+func (w *wizard) toggle(prompt string, items []string, selected []bool) error {
+	if !w.ask || len(items) == 0 {
+		return nil
+	}
+	if w.tui != nil {
+		return w.toggleTUI(w.ctx, prompt, items, selected)
+	}
+	for {
+		fmt.Fprintf(w.out, "%s:\n", prompt)
+		for i, item := range items {
+			mark := " "
+			if selected[i] {
+				mark = "x"
+			}
+			fmt.Fprintf(w.out, "  %d. [%s] %s\n", i+1, mark, item)
+		}
+		answer, err := w.line("Toggle (numbers, empty to continue)", "")
+		if err != nil || answer == "" {
+			return err
+		}
+		for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' }) {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 1 || n > len(items) {
+				fmt.Fprintf(w.out, "%q is not between 1 and %d\n", field, len(items))
+				continue
+			}
+			selected[n-1] = !selected[n-1]
+		}
+	}
+}
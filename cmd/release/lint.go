@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/ci"
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// lintCommits checks the messages of a range of commits, by default those
+// since the latest tag, and fails listing every commit that breaks the
+// conventions.
+func (a *app) lintCommits(ctx context.Context, args []string) error {
+	fs := a.flags("lint-commits")
+	merges := fs.Bool("merges", false, "lint merge commits too; their subjects are usually written by git or the provider")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: release lint-commits [flags] [range]", relerr.ErrUsage)
+	}
+
+	l, err := a.linter()
+	if err != nil {
+		return err
+	}
+	revs, err := a.lintRange(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cs, err := a.git.Commits(ctx, revs)
+	if err != nil {
+		return err
+	}
+
+	linted, failed := 0, 0
+	for _, c := range cs {
+		if len(c.Parents) > 1 && !*merges {
+			continue
+		}
+		linted++
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		problems := l.Lint(c.Message)
+		a.result.Commits = append(a.result.Commits, commitResult{Hash: c.Hash, Subject: subject, Problems: problems})
+		if len(problems) == 0 {
+			continue
+		}
+		failed++
+		fmt.Fprintf(a.stdout, "✗ %s %s\n", shortHash(c.Hash), subject)
+		for _, p := range problems {
+			fmt.Fprintf(a.stdout, "    %s\n", p)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d commit(s) in %s", relerr.ErrLintFailed, failed, linted, revs)
+	}
+	fmt.Fprintf(a.stdout, "✓ %d commit(s) in %s\n", linted, revs)
+	return nil
+}
+
+// lintRange resolves the range argument: a revision range is used as it
+// is, a single revision means the commits since it, and no argument the
+// commits of the pull request under test in CI, otherwise the commits since
+// the latest tag, or the whole history without one.
+func (a *app) lintRange(ctx context.Context, arg string) (string, error) {
+	if strings.Contains(arg, "..") {
+		return arg, nil
+	}
+	if arg != "" {
+		return arg + "..HEAD", nil
+	}
+	if env, ok := ci.FromEnv(); ok && env.PullRequest > 0 && env.BaseBranch != "" {
+		return a.preflightRemote() + "/" + env.BaseBranch + "..HEAD", nil
+	}
+	m, err := a.module("")
+	if err != nil {
+		return "", err
+	}
+	tags, err := a.git.Tags(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, tag, ok := workspace.LatestTag(tags, m, false); ok {
+		return tag + "..HEAD", nil
+	}
+	return "HEAD", nil
+}
+
+// linter builds the linter configured by commits.lint.
+func (a *app) linter() (commits.Linter, error) {
+	lc := a.cfg.Commits.Lint
+	l := commits.Linter{Types: lc.Types}
+	for _, r := range lc.Rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return l, relerr.Wrap(relerr.Config, fmt.Errorf("commits.lint.rules: %w", err))
+		}
+		l.Rules = append(l.Rules, commits.Rule{Pattern: re, Message: r.Message})
+	}
+	return l, nil
+}
@@ -0,0 +1,598 @@
+// Command release drives the release automation from the command line or CI.
+//
+// Usage:
+//
+//	release [-config path] [-dry-run] [-offline] [-branch name] [-timeout d] [-verbose|-quiet] [-log-format text|json] [-output text|json|yaml] [-progress auto|plain] [-fail-on errors|warnings|none] <command> [flags]
+//
+// Commands:
+//
+//	init         scaffold the configuration and changelog of a project, setting its first version
+//	next         print the next version computed from commits since the last tag
+//	env          print the next version, tag and channel as shell, dotenv or GitHub variables
+//	changelog    render the changelog section for the next version, or compile its fragments
+//	notes        render the release notes for the next version
+//	tag          create an annotated tag for the next version
+//	build        build release binaries, checksums and signatures
+//	reproduce    rebuild the binaries of a release from its tag and compare them with those published
+//	publish      push a release tag and create the provider release
+//	draft        push a release tag and create draft provider releases
+//	approve      publish the drafted release once approved, and announce it
+//	snapshot     publish an untagged build of HEAD on the rolling nightly prerelease
+//	preview      show the release a branch would make, optionally on its pull request
+//	modules      list the modules of a monorepo with their next versions
+//	preflight    check that the repository is ready to be released
+//	status       show the recorded state of the latest release
+//	history      show the release timeline recorded in git notes
+//	resume       continue an interrupted release from its recorded state
+//	rollback     undo a release: its provider releases, tag and version-bump commit
+//	prune        delete old drafts, prereleases and snapshot assets from the providers
+//	meta         release the repositories of a manifest in the order of their needs
+//	cache        record the provider data changelogs use, for -offline runs
+//	verify       audit a published release: its tag, assets, checksums and signatures
+//	report       render a static HTML page summarizing the recent releases
+//	schedule     wait for the freeze windows to end, then run a release command
+//	train        cut and publish the release of the release train departing now
+//	interactive  walk through a release, confirming each step
+//	lint-commits check commit messages against the commit conventions
+//	serve        serve versions, changelogs and releases over HTTP and webhooks
+//
+// Settings are read from .release.yaml (or -config <path>); command flags
+// override the file.
+//
+// Interrupting the command (SIGINT or SIGTERM) or exceeding -timeout
+// cancels the git commands, hooks, builds and API requests in flight.
+//
+// With -dry-run (or RELEASE_DRY_RUN=1), commands that would tag, push, write
+// files or publish releases describe those actions instead.
+//
+// With -offline (or RELEASE_OFFLINE=1), the issues, pull requests, commit
+// authors and release pages changelogs, release notes and reports link to
+// are read from the cache in .release/cache that online runs and 'release
+// cache sync' fill, instead of the provider APIs.
+//
+// The branch released from, which selects the channel and release line, is
+// the current branch. On a detached HEAD, as in most CI checkouts, it comes
+// from the CI environment; -branch (or RELEASE_BRANCH) names it outright.
+// Commits are then pushed to it as HEAD:refs/heads/<branch>.
+//
+// The exit status tells failures apart: 1 for unclassified errors, 2 for
+// usage errors, 3 when there is nothing to release, 4 for unmet
+// preconditions (see release preflight), 5 when the tag already exists, 6
+// for configuration errors, 7 for untrusted tag signatures, 8 for provider
+// failures, 9 for failing pre-* hooks, 10 for a release published to some
+// of its targets before a provider failed, 11 when warnings were logged
+// with -fail-on warnings, 124 when -timeout expires and 130 when
+// interrupted. These statuses are a stable contract. -fail-on warnings (or
+// RELEASE_FAIL_ON=warnings) fails commands that logged warnings, and
+// -fail-on none makes nothing to release exit with 0.
+//
+// Diagnostics are logged to stderr: warnings and errors by default, debug
+// records for every git command and API request with -verbose, errors only
+// with -quiet. -log-format json (or RELEASE_LOG_FORMAT=json) writes them as
+// JSON for CI. Tokens and passwords from the environment and the
+// configuration, and those looked up in the secrets stores, are redacted.
+//
+// -output json or yaml (or RELEASE_OUTPUT) writes the result of the command
+// to stdout instead of its human output, which goes to stderr: the version
+// and tag planned, whether the tag was created and pushed, the release URLs
+// and uploaded assets, and the error and exit status of a failure.
+//
+// When stderr is a terminal, publish, draft and approve display their
+// stages — analysis, build, push, publish per target with a line per
+// upload in flight, and notify — redrawn in place, with the bytes per
+// second and time left of each upload. Elsewhere, as in CI, and with
+// -progress plain (or RELEASE_PROGRESS=plain), each stage and upload is
+// reported as a line of the human output.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/ci"
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/progress"
+	"github.com/gbrennon/release_automation_golang/pkg/ratelimit"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+	"github.com/gbrennon/release_automation_golang/pkg/secrets"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+	"github.com/gbrennon/release_automation_golang/pkg/telemetry"
+	"golang.org/x/term"
+)
+
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	git    gitrepo.Repository
+	// tty is true when stdin is a terminal the user can answer prompts on.
+	tty bool
+	// ui displays the stages of a release when stderr is a terminal; nil
+	// reports them as lines of the human output.
+	ui *progress.Terminal
+	// progress reports the stages of a release without ui; see reporter.
+	progress *progress.Log
+	// root is the repository root directory, where modules are detected.
+	root   string
+	now    func() time.Time
+	dryRun bool
+	// offline reads provider data from the provider cache only (see
+	// cacheMode).
+	offline bool
+	// branchName is the branch released from, set by -branch; empty is
+	// the current branch (see branch).
+	branchName string
+	// cfg is loaded by run unless preset (as tests do).
+	cfg *config.Config
+	// log is set up by run from the global flags; logged counts its
+	// warnings and errors.
+	log    *slog.Logger
+	logged *log.Counter
+	// failOn is the -fail-on mode, deciding which outcomes fail a command.
+	failOn string
+	// retry is built by run from the configuration for pushes and API calls.
+	retry retry.Policy
+	// limiters pace the API calls of publishers, one per API (see
+	// rateLimiter). The copies of the app serve runs commands on share them.
+	limiters *rateLimiters
+	// secrets looks up provider tokens and notify credentials; nil reads
+	// the environment.
+	secrets *secrets.Store
+	// state records the progress of releases; nil records nothing.
+	state *state.Store
+	// telemetry records metrics and traces, which exporter sends to a
+	// collector; nil records nothing (see setupTelemetry).
+	telemetry *telemetry.Recorder
+	exporter  *telemetry.Exporter
+	// command is the name of the command being run.
+	command string
+	// output is the -output format; result collects what the command did
+	// for the json and yaml formats.
+	output string
+	result *result
+
+	newPublisher publisherFactory
+	// metaRunner runs the steps of meta in other checkouts; nil runs this
+	// executable.
+	metaRunner metaRunner
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"init", "scaffold the configuration and changelog of a project, setting its first version", (*app).initRepo},
+	{"next", "print the next version computed from commits since the last tag", (*app).next},
+	{"env", "print the next version, tag and channel as shell, dotenv or GitHub variables", (*app).env},
+	{"changelog", "render the changelog section for the next version, or compile its fragments", (*app).changelog},
+	{"notes", "render the release notes for the next version", (*app).notes},
+	{"tag", "create an annotated tag for the next version", (*app).tag},
+	{"build", "build release binaries, checksums and signatures", (*app).build},
+	{"publish", "push a release tag and create the provider release", (*app).publish},
+	{"draft", "push a release tag and create draft provider releases", (*app).draft},
+	{"approve", "publish the drafted release once approved, and announce it", (*app).approve},
+	{"snapshot", "publish an untagged build of HEAD on the rolling nightly prerelease", (*app).snapshot},
+	{"preview", "show the release a branch would make, optionally on its pull request", (*app).preview},
+	{"modules", "list the modules of a monorepo with their next versions", (*app).modules},
+	{"preflight", "check that the repository is ready to be released", (*app).preflight},
+	{"status", "show the recorded state of the latest release", (*app).status},
+	{"history", "show the release timeline recorded in git notes", (*app).history},
+	{"resume", "continue an interrupted release from its recorded state", (*app).resume},
+	{"rollback", "undo a release: its provider releases, tag and version-bump commit", (*app).rollback},
+	{"prune", "delete old drafts, prereleases and snapshot assets from the providers", (*app).prune},
+	{"meta", "release the repositories of a manifest in the order of their needs", (*app).meta},
+	{"cache", "record the provider data changelogs use, for -offline runs", (*app).cache},
+	{"verify", "audit a published release: its tag, assets, checksums and signatures", (*app).verify},
+	{"reproduce", "rebuild the binaries of a release from its tag and compare them with those published", (*app).reproduce},
+	{"report", "render a static HTML page summarizing the recent releases", (*app).report},
+	{"schedule", "wait for the freeze windows to end, then run a release command", (*app).schedule},
+	{"train", "cut and publish the release of the release train departing now", (*app).train},
+	{"interactive", "walk through a release, confirming each step", (*app).interactive},
+	{"lint-commits", "check commit messages against the commit conventions", (*app).lintCommits},
+	{"serve", "serve versions, changelogs and releases over HTTP and webhooks", (*app).serve},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	repo, err := gitrepo.Open(ctx, ".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "release: %v\n", err)
+		os.Exit(1)
+	}
+
+	var (
+		ui             *progress.Terminal
+		stdout, stderr io.Writer = os.Stdout, os.Stderr
+	)
+	if width, _, err := term.GetSize(int(os.Stderr.Fd())); err == nil {
+		ui = progress.NewTerminal(os.Stderr, width)
+		stdout, stderr = ui.Output(os.Stdout), ui.Output(os.Stderr)
+	}
+
+	a := &app{
+		stdin:   os.Stdin,
+		tty:     isTerminal(os.Stdin),
+		ui:      ui,
+		stdout:  stdout,
+		stderr:  stderr,
+		git:     repo,
+		root:    repo.Dir,
+		now:     time.Now,
+		dryRun:  dryrun.FromEnv(),
+		offline: offlineFromEnv(),
+		state:   &state.Store{Dir: filepath.Join(repo.Dir, stateDir)},
+
+		newPublisher: newPublisher,
+	}
+	code := a.run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+func (a *app) run(ctx context.Context, args []string) int {
+	global := flag.NewFlagSet("release", flag.ContinueOnError)
+	global.SetOutput(a.stderr)
+	global.Usage = a.usage
+	global.BoolVar(&a.dryRun, "dry-run", a.dryRun, "describe mutating actions instead of performing them")
+	global.BoolVar(&a.offline, "offline", a.offline, "read issues, authors and release pages from the provider cache instead of the APIs")
+	configPath := global.String("config", "", "configuration file (default: .release.yaml or an alternative in the current directory)")
+	verbose := global.Bool("verbose", false, "log every git command and API request")
+	quiet := global.Bool("quiet", false, "log errors only")
+	logFormat := global.String("log-format", envOr(log.EnvFormat, string(log.Text)), "log format: text or json")
+	timeout := global.Duration("timeout", 0, "abort the command after this long, e.g. 10m (default: no limit)")
+	global.StringVar(&a.branchName, "branch", envOr("RELEASE_BRANCH", a.branchName), "branch released from, for a detached HEAD or a worktree (default: the current branch, then the CI environment)")
+	global.StringVar(&a.output, "output", envOr("RELEASE_OUTPUT", outputText), "output format: text, or json or yaml for a result on stdout")
+	progressMode := global.String("progress", envOr("RELEASE_PROGRESS", progressAuto), "progress of releases: auto, a display on a terminal stderr and lines otherwise, or plain for lines")
+	global.StringVar(&a.failOn, "fail-on", envOr("RELEASE_FAIL_ON", failOnErrors), "what fails a command: errors, warnings too, or none for nothing to release to succeed")
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	args = global.Args()
+
+	a.logged = &log.Counter{}
+	opts := log.Options{Level: slog.LevelInfo, Secrets: log.EnvSecrets(), Redactor: log.NewRedactor(), Counter: a.logged}
+	switch {
+	case *verbose && *quiet:
+		fmt.Fprintln(a.stderr, "release: -verbose and -quiet are mutually exclusive")
+		return 2
+	case *verbose:
+		opts.Level = slog.LevelDebug
+	case *quiet:
+		opts.Level = slog.LevelError
+	}
+	if a.output != outputText && a.output != outputJSON && a.output != outputYAML {
+		fmt.Fprintf(a.stderr, "release: unknown output format %q: must be text, json or yaml\n", a.output)
+		return 2
+	}
+	if a.failOn != failOnErrors && a.failOn != failOnWarnings && a.failOn != failOnNone {
+		fmt.Fprintf(a.stderr, "release: unknown -fail-on mode %q: must be errors, warnings or none\n", a.failOn)
+		return 2
+	}
+	switch *progressMode {
+	case progressAuto:
+	case progressPlain:
+		a.ui = nil
+	default:
+		fmt.Fprintf(a.stderr, "release: unknown progress mode %q: must be auto or plain\n", *progressMode)
+		return 2
+	}
+	format, err := log.ParseFormat(*logFormat)
+	if err != nil {
+		fmt.Fprintf(a.stderr, "release: %v\n", err)
+		return 2
+	}
+	opts.Format = format
+	a.log = log.New(a.stderr, opts)
+
+	if err := a.loadConfig(*configPath); err != nil {
+		a.log.Error(fmt.Sprintf("release: %v", err))
+		return exitCode(relerr.Wrap(relerr.Config, err))
+	}
+	opts.Secrets = append(opts.Secrets, configSecrets(a.cfg)...)
+	a.log = log.New(a.stderr, opts)
+	a.retry = a.retryPolicy()
+	a.secrets = secretStore(a.cfg, opts.Redactor)
+	if err := a.setupTelemetry(); err != nil {
+		a.log.Error(fmt.Sprintf("release: %v", err))
+		return exitCode(err)
+	}
+	defer a.exportTelemetry(ctx)
+	if g, ok := a.git.(*gitrepo.Git); ok {
+		g.Logger, g.Retry = a.log, a.retry
+		g.Traversal = gitrepo.Traversal(a.cfg.Commits.Traversal)
+		g.MaxCommits, g.Deepen = a.cfg.Commits.MaxCommits, a.cfg.Commits.Deepen
+		if len(a.cfg.Remotes) > 0 {
+			g.Remote = a.cfg.Remotes[0].Name
+		}
+	}
+
+	if len(args) == 0 || args[0] == "help" {
+		a.usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	for _, c := range slices.Concat(commands, hiddenCommands) {
+		if c.name != args[0] {
+			continue
+		}
+		a.command = c.name
+		err := a.runCommand(ctx, c, args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if err != nil {
+			a.log.Error(fmt.Sprintf("release %s: %v", c.name, err))
+			return exitCode(err)
+		}
+		return 0
+	}
+
+	fmt.Fprintf(a.stderr, "release: unknown command %q\n\n", args[0])
+	a.usage()
+	return 2
+}
+
+// runCommand runs c. With -output json or yaml, the command's human output
+// goes to stderr and its result, failures included, is written to stdout.
+// In a GitHub Actions step the result is also written as step outputs.
+func (a *app) runCommand(ctx context.Context, c command, args []string) error {
+	a.result = &result{Command: c.name, DryRun: a.dryRun}
+	a.progress = nil
+	stdout := a.stdout
+	if a.output != outputText {
+		a.stdout = a.stderr
+	}
+	ctx, span := a.metrics().Start(ctx, "release "+c.name, telemetry.String("command", c.name))
+	start := time.Now()
+	warnings := a.warnings()
+	err := c.run(a, ctx, args)
+	a.stdout = stdout
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	a.result.Warnings = a.warnings() - warnings
+	err = a.applyFailOn(err, a.result.Warnings)
+	a.recordCommand(c.name, time.Since(start), err)
+	span.End(err)
+	if env, ok := ci.FromEnv(); ok {
+		if werr := env.WriteOutputs(a.result.ciOutputs()); werr != nil {
+			a.log.Warn(fmt.Sprintf("could not write the step outputs: %v", werr))
+		}
+	}
+	if a.output == outputText {
+		return err
+	}
+
+	if err != nil {
+		a.result.Error, a.result.ExitCode = err.Error(), exitCode(err)
+	}
+	if werr := writeResult(a.stdout, a.output, a.result); werr != nil && err == nil {
+		return werr
+	}
+	return err
+}
+
+// exitCodes maps the kinds of pkg/errors to exit statuses, so that scripts
+// can tell failures apart. Unclassified errors exit with 1.
+var exitCodes = map[relerr.Kind]int{
+	relerr.Usage:            2,
+	relerr.NothingToRelease: 3,
+	relerr.Precondition:     4,
+	relerr.Conflict:         5,
+	relerr.Config:           6,
+	relerr.Signature:        7,
+	relerr.Provider:         8,
+	relerr.Hook:             9,
+	relerr.Partial:          10,
+	relerr.Warned:           11,
+	relerr.Timeout:          124,
+	relerr.Canceled:         130,
+}
+
+// Modes of the global -fail-on flag. Errors fails a command on errors
+// only; warnings also fails one that logged warnings, with status 11; none
+// makes nothing to release a success, which pipelines releasing on every
+// push need not tell apart from one.
+const (
+	failOnErrors   = "errors"
+	failOnWarnings = "warnings"
+	failOnNone     = "none"
+)
+
+// applyFailOn returns the outcome of a command that returned err after
+// logging warnings, as -fail-on decides.
+func (a *app) applyFailOn(err error, warnings int) error {
+	switch a.failOn {
+	case failOnWarnings:
+		if err == nil && warnings > 0 {
+			return fmt.Errorf("%w: %d warning(s), which -fail-on warnings fails", relerr.ErrWarnings, warnings)
+		}
+	case failOnNone:
+		if relerr.KindOf(err) == relerr.NothingToRelease {
+			a.log.Info(err.Error())
+			return nil
+		}
+	}
+	return err
+}
+
+// warnings returns the number of warnings logged so far.
+func (a *app) warnings() int {
+	if a.logged == nil {
+		return 0
+	}
+	return a.logged.Warnings()
+}
+
+// exitCode returns the exit status for a command failing with err.
+func exitCode(err error) int {
+	if code, ok := exitCodes[relerr.KindOf(err)]; ok {
+		return code
+	}
+	return 1
+}
+
+func (a *app) usage() {
+	fmt.Fprintln(a.stderr, "Usage: release [-config path] [-dry-run] [-offline] [-branch name] [-timeout d] [-verbose|-quiet] [-log-format text|json] [-output text|json|yaml] [-progress auto|plain] [-fail-on errors|warnings|none] <command> [flags]")
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(a.stderr, "  %-12s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Run 'release <command> -h' for command flags.")
+}
+
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("release "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	return fs
+}
+
+// loadConfig loads path, or discovers a configuration file in the current
+// directory when path is empty. A preset cfg is kept unless path is given.
+func (a *app) loadConfig(path string) error {
+	if path != "" {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		a.cfg = cfg
+		return nil
+	}
+	if a.cfg != nil {
+		return nil
+	}
+	cfg, found, err := config.Find(".")
+	if err != nil {
+		return err
+	}
+	if found != "" {
+		a.log.Debug("loaded configuration", "path", found)
+	}
+	a.cfg = cfg
+	return nil
+}
+
+// configSecrets returns the credentials in cfg that must not be logged:
+// notification passwords and webhook URLs, which embed their tokens.
+func configSecrets(cfg *config.Config) []string {
+	var secrets []string
+	for _, n := range cfg.Notify {
+		secrets = append(secrets, os.ExpandEnv(n.Password), os.ExpandEnv(n.URL))
+	}
+	return secrets
+}
+
+// retryPolicy returns the retry policy configured in a.cfg, which logs a
+// warning before each retry.
+func (a *app) retryPolicy() retry.Policy {
+	p := retry.Default()
+	rc := a.cfg.Retry
+	if rc.MaxAttempts > 0 {
+		p.MaxAttempts = rc.MaxAttempts
+	}
+	if d, err := time.ParseDuration(rc.InitialDelay); err == nil {
+		p.Initial = d
+	}
+	if d, err := time.ParseDuration(rc.MaxDelay); err == nil {
+		p.Max = d
+	}
+	p.OnRetry = func(attempt int, err error, delay time.Duration) {
+		a.log.Warn(fmt.Sprintf("attempt %d failed, retrying in %s: %v", attempt, delay, err))
+	}
+	return p
+}
+
+// rateLimiters holds the limiters of an app by API.
+type rateLimiters struct {
+	mu sync.Mutex
+	m  map[string]*ratelimit.Limiter
+}
+
+// rateLimiter returns the limiter shared by the publishers calling the API
+// of provider at base, so that the releases of every module of a monorepo,
+// and those serve cuts one request after another, draw on one budget. It
+// is configured by a.cfg.RateLimit.
+func (a *app) rateLimiter(provider, base string) *ratelimit.Limiter {
+	if provider == "homebrew" {
+		// Taps are GitHub repositories.
+		provider = "github"
+	}
+	if a.limiters == nil {
+		a.limiters = new(rateLimiters)
+	}
+	a.limiters.mu.Lock()
+	defer a.limiters.mu.Unlock()
+	key := provider + " " + base
+	if l, ok := a.limiters.m[key]; ok {
+		return l
+	}
+	l := ratelimit.New()
+	rc := a.cfg.RateLimit
+	if rc.Reserve > 0 {
+		l.Reserve = rc.Reserve
+	}
+	if d, err := time.ParseDuration(rc.MinInterval); err == nil {
+		l.MinInterval = d
+	}
+	l.Logger = a.log
+	if a.limiters.m == nil {
+		a.limiters.m = make(map[string]*ratelimit.Limiter)
+	}
+	a.limiters.m[key] = l
+	return l
+}
+
+// envOr returns the value of the environment variable key, or def when it
+// is unset or empty.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// dryRunFlag registers a per-command -dry-run flag defaulting to the global one.
+func (a *app) dryRunFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("dry-run", a.dryRun, "describe what would be done without doing it")
+}
+
+// repo returns the repository, wrapped so mutations are only described when
+// dryRun is set.
+func (a *app) repo(dryRun bool) gitrepo.Repository {
+	if dryRun {
+		return gitrepo.DryRun(a.git, a.stdout)
+	}
+	return a.git
+}
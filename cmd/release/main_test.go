@@ -0,0 +1,4749 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/artifacts"
+	"github.com/gbrennon/release_automation_golang/pkg/bench"
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	rlog "github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/notes"
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+	"github.com/gbrennon/release_automation_golang/pkg/plugin"
+	"github.com/gbrennon/release_automation_golang/pkg/policy"
+	"github.com/gbrennon/release_automation_golang/pkg/progress"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/github"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/gitlab"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/npm"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/pypi"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+	"github.com/gbrennon/release_automation_golang/pkg/telemetry"
+	"github.com/gbrennon/release_automation_golang/pkg/timeline"
+)
+
+// TestMain clears the CI environment the tests may run in, which commands
+// would otherwise detect.
+func TestMain(m *testing.M) {
+	for _, key := range []string{"GITHUB_ACTIONS", "GITHUB_OUTPUT", "GITLAB_CI", "CIRCLECI", "JENKINS_URL"} {
+		os.Unsetenv(key)
+	}
+	os.Exit(m.Run())
+}
+
+type fakeGit struct {
+	tags []string
+	// tagged maps tag names to the commits they point at.
+	tagged  map[string]string
+	commits []gitrepo.Commit
+	since   string
+	paths   []string
+	branch  string
+	signed  []string
+	trusted []string
+	created map[string]string
+	pushed  []string
+	pulled  []string
+	// pushErr maps remotes to the error Push fails with.
+	pushErr map[string]error
+	// deleted records "name" for local and "remote name" for remote tag
+	// deletions; reverted records the commits reverted.
+	deleted  []string
+	reverted []string
+	// files maps "rev:path" to the content ReadFile returns.
+	files map[string]string
+	// dirty and behind are reported by Status and Behind.
+	dirty  []string
+	behind int
+	// committed records "message: path..." for each CommitFiles call.
+	committed []string
+	// stats maps commits to the size DiffStat reports.
+	stats map[string]gitrepo.DiffStat
+	// remoteTags and remoteErr are reported by RemoteTags.
+	remoteTags []gitrepo.Tag
+	remoteErr  error
+	// byPath maps the first pathspec of CommitsSince to the commits it
+	// returns instead of commits.
+	byPath map[string][]gitrepo.Commit
+	// notes maps "ref commit" to the note Note returns and SetNote writes.
+	notes map[string]string
+}
+
+func (f *fakeGit) Tags(context.Context) ([]gitrepo.Tag, error) {
+	var tags []gitrepo.Tag
+	for _, name := range f.tags {
+		tags = append(tags, gitrepo.Tag{Name: name, Commit: f.tagged[name]})
+	}
+	return tags, nil
+}
+
+func (f *fakeGit) RemoteTags(context.Context, string) ([]gitrepo.Tag, error) {
+	return f.remoteTags, f.remoteErr
+}
+
+func (f *fakeGit) CommitsSince(_ context.Context, ref string, paths ...string) ([]gitrepo.Commit, error) {
+	f.since = ref
+	f.paths = paths
+	if len(paths) > 0 {
+		if c, ok := f.byPath[paths[0]]; ok {
+			return c, nil
+		}
+	}
+	return f.commits, nil
+}
+
+func (f *fakeGit) Commits(_ context.Context, revs string) ([]gitrepo.Commit, error) {
+	f.since = revs
+	return f.commits, nil
+}
+
+func (f *fakeGit) CurrentBranch(context.Context) (string, error) {
+	switch f.branch {
+	case "":
+		return "main", nil
+	case "HEAD":
+		return "", gitrepo.ErrDetachedHead
+	}
+	return f.branch, nil
+}
+
+func (f *fakeGit) Head(context.Context) (string, error) {
+	return "0000000000000000000000000000000000000000", nil
+}
+
+func (f *fakeGit) Status(context.Context) ([]string, error) {
+	return f.dirty, nil
+}
+
+func (f *fakeGit) Behind(context.Context, string, string) (int, error) {
+	return f.behind, nil
+}
+
+func (f *fakeGit) CreateTag(_ context.Context, name, message string) error {
+	if _, ok := f.created[name]; ok || slices.Contains(f.tags, name) {
+		return fmt.Errorf("%w: %s", gitrepo.ErrTagExists, name)
+	}
+	if f.created == nil {
+		f.created = make(map[string]string)
+	}
+	f.created[name] = message
+	return nil
+}
+
+func (f *fakeGit) CreateSignedTag(ctx context.Context, name, message string, s gitrepo.Signing) error {
+	f.signed = append(f.signed, name+" "+s.Key)
+	return f.CreateTag(ctx, name, message)
+}
+
+func (f *fakeGit) VerifyTag(_ context.Context, name string, s gitrepo.Signing) error {
+	if !slices.Contains(f.trusted, name) {
+		return fmt.Errorf("%w: %s", gitrepo.ErrBadSignature, name)
+	}
+	return nil
+}
+
+func (f *fakeGit) CommitFiles(_ context.Context, message string, paths ...string) error {
+	f.committed = append(f.committed, message+": "+strings.Join(paths, " "))
+	return nil
+}
+
+func (f *fakeGit) Push(_ context.Context, remote, ref string) error {
+	if err := f.pushErr[remote]; err != nil {
+		return err
+	}
+	f.pushed = append(f.pushed, remote+" "+ref)
+	return nil
+}
+
+func (f *fakeGit) Pull(_ context.Context, remote, branch string) error {
+	f.pulled = append(f.pulled, remote+" "+branch)
+	return nil
+}
+
+func (f *fakeGit) DeleteTag(_ context.Context, name string) error {
+	if !slices.Contains(f.tags, name) {
+		return fmt.Errorf("%w: %s", gitrepo.ErrNoSuchTag, name)
+	}
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func (f *fakeGit) DeleteRemoteTag(_ context.Context, remote, name string) error {
+	onRemote := slices.ContainsFunc(f.remoteTags, func(t gitrepo.Tag) bool { return t.Name == name })
+	if !slices.Contains(f.tags, name) && !onRemote {
+		return fmt.Errorf("%w: %s", gitrepo.ErrNoSuchTag, name)
+	}
+	f.deleted = append(f.deleted, remote+" "+name)
+	return nil
+}
+
+func (f *fakeGit) Revert(_ context.Context, commit string) error {
+	f.reverted = append(f.reverted, commit)
+	return nil
+}
+
+func (f *fakeGit) DiffStat(_ context.Context, commit string) (gitrepo.DiffStat, error) {
+	return f.stats[commit], nil
+}
+
+func (f *fakeGit) ReadFile(_ context.Context, rev, path string) ([]byte, error) {
+	content, ok := f.files[rev+":"+path]
+	if !ok {
+		return nil, fmt.Errorf("%s at %s: %w", path, rev, fs.ErrNotExist)
+	}
+	return []byte(content), nil
+}
+
+func (f *fakeGit) Note(_ context.Context, ref, commit string) ([]byte, error) {
+	note, ok := f.notes[ref+" "+commit]
+	if !ok {
+		return nil, nil
+	}
+	return []byte(note), nil
+}
+
+func (f *fakeGit) SetNote(_ context.Context, ref, commit string, note []byte) error {
+	if f.notes == nil {
+		f.notes = make(map[string]string)
+	}
+	f.notes[ref+" "+commit] = string(note)
+	return nil
+}
+
+func newTestApp(git *fakeGit) (*app, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	a := &app{
+		stdout: &stdout,
+		stderr: &stderr,
+		git:    git,
+		root:   ".",
+		now:    func() time.Time { return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC) },
+		cfg:    config.Default(),
+
+		newPublisher: func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+			if provider != "github" {
+				return newPublisher(provider, repo, o)
+			}
+			owner, name, _ := strings.Cut(repo, "/")
+			p := github.New(owner, name, "token")
+			p.DryRun, p.Log = o.DryRun, o.Log
+			return p, nil
+		},
+	}
+	return a, &stdout, &stderr
+}
+
+func TestNext(t *testing.T) {
+	git := &fakeGit{
+		tags: []string{"v1.1.0", "v1.2.3", "not-a-version", "v1.2.0"},
+		commits: []gitrepo.Commit{
+			{Hash: "aaa", Message: "fix: a bug"},
+			{Hash: "bbb", Message: "feat: a feature"},
+			{Hash: "ccc", Message: "random message"},
+		},
+	}
+	a, stdout, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.3.0\n" {
+		t.Errorf("Expected %q, got %q", "1.3.0\n", stdout.String())
+	}
+	if git.since != "v1.2.3" {
+		t.Errorf("Expected commits since v1.2.3, got %q", git.since)
+	}
+}
+
+func TestNextBootstrap(t *testing.T) {
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "aaa", Message: "fix: a bug"}}}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	a.cfg.Versioning.Initial = "1.0.0"
+
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.0.0\n" {
+		t.Errorf("Expected the initial version, got %q", stdout.String())
+	}
+
+	a.cfg.Versioning.ImportFrom = "VERSION"
+	if code := a.run(context.Background(), []string{"next"}); code != 6 {
+		t.Errorf("Expected exit code 6 without the VERSION file, got %d", code)
+	}
+	os.WriteFile(filepath.Join(a.root, "VERSION"), []byte("v2.3.1\n"), 0o644)
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "2.3.2\n" {
+		t.Errorf("Expected the imported version bumped, got %q", stdout.String())
+	}
+
+	git.tags = []string{"v0.3.0"}
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "0.3.1\n" {
+		t.Errorf("Expected the tags to win over the bootstrap, got %q", stdout.String())
+	}
+}
+
+func TestInit(t *testing.T) {
+	dir := t.TempDir()
+	file, changelogPath := filepath.Join(dir, ".release.yaml"), filepath.Join(dir, "CHANGELOG.md")
+	git := &fakeGit{branch: "trunk"}
+	a, stdout, stderr := newTestApp(git)
+	a.root = dir
+
+	if code := a.run(context.Background(), []string{"init", "-file", file, "-changelog", changelogPath, "-initial", "1.0.0", "-dry-run"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.HasPrefix(stdout.String(), "[dry-run] would write "+file+":\nbranches:\n  - trunk\n") || !strings.HasSuffix(stdout.String(), "[dry-run] would write "+changelogPath+"\n") {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("Expected no configuration written in a dry run, got %v", err)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"init", "-file", file, "-changelog", changelogPath, "-initial", "1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "wrote "+file+"\nwrote "+changelogPath+"\n" {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+	cfg, err := config.Load(file)
+	if err != nil {
+		t.Fatalf("Expected a valid configuration: %v", err)
+	}
+	if cfg.Branches[0] != "trunk" || cfg.Versioning.Initial != "1.0.0" || cfg.Changelog.Path != changelogPath {
+		t.Errorf("Unexpected configuration %+v", cfg)
+	}
+	if content, _ := os.ReadFile(changelogPath); string(content) != changelog.DefaultHeader {
+		t.Errorf("Expected a changelog header, got %q", content)
+	}
+
+	if code := a.run(context.Background(), []string{"init", "-file", file}); code != 4 {
+		t.Errorf("Expected exit code 4 over an existing configuration, got %d", code)
+	}
+
+	// A VERSION file is imported, and an existing changelog kept.
+	os.WriteFile(filepath.Join(dir, "VERSION"), []byte("1.4.2\n"), 0o644)
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"init", "-file", file, "-changelog", changelogPath, "-force"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.HasSuffix(stdout.String(), "kept "+changelogPath+"\n") {
+		t.Errorf("Expected the changelog to be kept, got %q", stdout.String())
+	}
+	if cfg, _ := config.Load(file); cfg == nil || cfg.Versioning.ImportFrom != "VERSION" || cfg.Versioning.Initial != "" {
+		t.Errorf("Expected the VERSION file imported, got %+v", cfg)
+	}
+
+	if code := a.run(context.Background(), []string{"init", "-file", file, "-force", "-initial", "1.0.0-rc.1"}); code != 2 {
+		t.Errorf("Expected exit code 2 for a prerelease -initial, got %d", code)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	git := &fakeGit{
+		tags:    []string{"v1.2.3"},
+		commits: []gitrepo.Commit{{Hash: "aaa", Message: "feat: a feature"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"env"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "export NEXT_VERSION='1.3.0'\n" +
+		"export PREV_VERSION='1.2.3'\n" +
+		"export CHANNEL=''\n" +
+		"export TAG='v1.3.0'\n" +
+		"export CHANGELOG_PATH='CHANGELOG.md'\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	a.cfg.Changelog.Path = "docs/change log.md"
+	if code := a.run(context.Background(), []string{"env", "-format", "dotenv", "-channel", "rc"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected = "NEXT_VERSION=1.3.0-rc.1\nPREV_VERSION=1.2.3\nCHANNEL=rc\nTAG=v1.3.0-rc.1\nCHANGELOG_PATH=\"docs/change log.md\"\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"env", "-format", "github", "-bump", "major"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.HasPrefix(stdout.String(), "NEXT_VERSION=2.0.0\nPREV_VERSION=1.2.3\nCHANNEL=\nTAG=v2.0.0\n") {
+		t.Errorf("Expected GitHub output lines, got %q", stdout.String())
+	}
+
+	if code := a.run(context.Background(), []string{"env", "-format", "xml"}); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown format, got %d", code)
+	}
+	if shellQuote("it's") != `'it'\''s'` {
+		t.Errorf("Expected the quote spliced in, got %s", shellQuote("it's"))
+	}
+}
+
+func TestTagTemplate(t *testing.T) {
+	git := &fakeGit{
+		tags:    []string{"v2.0.0", "release-1.2.0", "release-1.3.0-rc.1"},
+		commits: []gitrepo.Commit{{Hash: "aaa", Message: "fix: a bug"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Tag.Template = "release-{{.Version}}"
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if _, ok := git.created["release-1.2.1"]; !ok || git.since != "release-1.2.0" {
+		t.Errorf("Expected release-1.2.1 tagged after release-1.2.0, got %v after %q", git.created, git.since)
+	}
+	if a.tagVersion("release-1.2.1") != "1.2.1" {
+		t.Errorf("Expected the version of the tag, got %q", a.tagVersion("release-1.2.1"))
+	}
+	if !strings.Contains(stdout.String(), "release-1.2.1") {
+		t.Errorf("Expected the tag to be reported, got %q", stdout)
+	}
+}
+
+func TestNextExplicitBump(t *testing.T) {
+	a, stdout, _ := newTestApp(&fakeGit{tags: []string{"v1.2.3"}})
+
+	if code := a.run(context.Background(), []string{"next", "-bump", "major"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if stdout.String() != "2.0.0\n" {
+		t.Errorf("Expected %q, got %q", "2.0.0\n", stdout.String())
+	}
+}
+
+func TestNextNothingToRelease(t *testing.T) {
+	a, _, stderr := newTestApp(&fakeGit{commits: []gitrepo.Commit{{Hash: "a", Message: "docs: readme"}}})
+
+	if code := a.run(context.Background(), []string{"next"}); code != 3 {
+		t.Errorf("Expected exit code 3, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), relerr.ErrNoCommitsSinceTag.Error()) {
+		t.Errorf("Expected nothing-to-release error, got %q", stderr.String())
+	}
+}
+
+func TestNextConfigTagPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".release.yaml")
+	if err := os.WriteFile(path, []byte("tag:\n  prefix: release-\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	git := &fakeGit{
+		tags:    []string{"v9.0.0", "release-1.4.0"},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "fix: a bug"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"-config", path, "next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.4.1\n" {
+		t.Errorf("Expected %q, got %q", "1.4.1\n", stdout.String())
+	}
+	if git.since != "release-1.4.0" {
+		t.Errorf("Expected commits since release-1.4.0, got %q", git.since)
+	}
+}
+
+func TestNextCalVer(t *testing.T) {
+	git := &fakeGit{
+		tags:    []string{"v1.4.0", "v2026.3.0"},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "feat: a thing"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Versioning = config.VersioningConfig{Scheme: "calver"}
+
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "2026.3.1\n" {
+		t.Errorf("Expected %q, got %q", "2026.3.1\n", stdout.String())
+	}
+
+	stdout.Reset()
+	a.cfg.Versioning.Layout = "YY.WW.MICRO"
+	git.tags = []string{"v26.8.2"}
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "26.9.0\n" {
+		t.Errorf("Expected %q, got %q", "26.9.0\n", stdout.String())
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".release.yaml")
+	os.WriteFile(path, []byte("branches: []\nunknown: 1\n"), 0o644)
+	a, _, stderr := newTestApp(&fakeGit{})
+
+	if code := a.run(context.Background(), []string{"-config", path, "next"}); code != 6 {
+		t.Errorf("Expected exit code 6, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown") {
+		t.Errorf("Expected unknown field error, got %q", stderr.String())
+	}
+}
+
+func TestChangelogTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "section.tmpl")
+	os.WriteFile(path, []byte("v{{ .Version }}"), 0o644)
+	a, stdout, _ := newTestApp(&fakeGit{commits: []gitrepo.Commit{{Hash: "a", Message: "feat: x"}}})
+	a.cfg.Changelog.Template = path
+
+	if code := a.run(context.Background(), []string{"changelog"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if stdout.String() != "v0.1.0" {
+		t.Errorf("Expected %q, got %q", "v0.1.0", stdout.String())
+	}
+}
+
+func TestNextExplain(t *testing.T) {
+	git := &fakeGit{
+		tags: []string{"v1.2.0"},
+		commits: []gitrepo.Commit{
+			{Hash: "aaaaaaa111", Message: "fix(cli): handle empty tags"},
+			{Hash: "bbbbbbb222", Message: "feat!: drop v1 config\n\nBody."},
+			{Hash: "ccccccc333", Message: "Merge branch 'x'"},
+		},
+	}
+	a, stdout, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"next", "-explain"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "COMMIT   BUMP   SUBJECT\n" +
+		"aaaaaaa  patch  fix(cli): handle empty tags\n" +
+		"bbbbbbb  major  feat!: drop v1 config\n" +
+		"ccccccc  -      Merge branch 'x'\n" +
+		"\nv1.2.0 -> v2.0.0 (major, highest commit level)\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"next", "--explain", "--format", "json", "-bump", "patch"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	var e explanation
+	if err := json.Unmarshal(stdout.Bytes(), &e); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Next != "1.2.1" || e.Bump != "patch" || !e.Forced || e.PreviousTag != "v1.2.0" || len(e.Commits) != 3 {
+		t.Errorf("Unexpected explanation %+v", e)
+	}
+	if c := e.Commits[1]; !c.Conventional || !c.Breaking || c.Bump != "major" || c.Type != "feat" {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+	if c := e.Commits[2]; c.Conventional || c.Bump != "none" {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+}
+
+func TestCommitRules(t *testing.T) {
+	git := &fakeGit{
+		tags: []string{"v1.2.0"},
+		commits: []gitrepo.Commit{
+			{Hash: "aaaaaaa111", Message: "perf: cache lookups"},
+			{Hash: "bbbbbbb222", Message: "deps: bump yaml"},
+			{Hash: "ccccccc333", Message: "docs: readme"},
+		},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Commits.Rules = []config.CommitRule{
+		{Pattern: `^perf(\(.+\))?:`, Bump: "minor", Section: "Performance"},
+		{Pattern: `^deps:`, Bump: "patch", Hidden: true},
+	}
+
+	if code := a.run(context.Background(), []string{"next", "-explain"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "COMMIT   BUMP   SUBJECT\n" +
+		"aaaaaaa  minor  perf: cache lookups\n" +
+		"bbbbbbb  patch  deps: bump yaml\n" +
+		"ccccccc  none   docs: readme\n" +
+		"\nv1.2.0 -> v1.3.0 (minor, highest commit level)\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"changelog"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected = "## [1.3.0] - 2026-03-01\n\n### Documentation\n\n- readme (ccccccc)\n\n### Performance\n\n- cache lookups (aaaaaaa)\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestNextExplainNothingToRelease(t *testing.T) {
+	git := &fakeGit{tags: []string{"v1.2.0"}, commits: []gitrepo.Commit{{Hash: "a", Message: "docs: readme"}}}
+	a, stdout, _ := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"next", "-explain"}); code != 3 {
+		t.Fatalf("Expected exit code 3, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "a       none  docs: readme\n") {
+		t.Errorf("Expected the commits to be explained anyway, got:\n%s", stdout.String())
+	}
+
+	if code := a.run(context.Background(), []string{"next", "-explain", "-format", "xml"}); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown format, got %d", code)
+	}
+}
+
+func TestNextModule(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/mono\n"), 0o644)
+	os.MkdirAll(filepath.Join(root, "pkg", "foo"), 0o755)
+	os.WriteFile(filepath.Join(root, "pkg", "foo", "go.mod"), []byte("module example.com/mono/pkg/foo\n"), 0o644)
+
+	git := &fakeGit{
+		tags:    []string{"v3.0.0", "pkg/foo/v1.2.3"},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "fix(foo): bug"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.root = root
+
+	if code := a.run(context.Background(), []string{"tag", "-module", "pkg/foo"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "pkg/foo/v1.2.4\n" {
+		t.Errorf("Expected %q, got %q", "pkg/foo/v1.2.4\n", stdout.String())
+	}
+	if git.since != "pkg/foo/v1.2.3" || len(git.paths) != 1 || git.paths[0] != "pkg/foo" {
+		t.Errorf("Expected scoped commits since pkg/foo/v1.2.3, got %q %v", git.since, git.paths)
+	}
+
+	if code := a.run(context.Background(), []string{"next", "-module", "pkg/bar"}); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown module, got %d", code)
+	}
+}
+
+func TestModules(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/mono\n"), 0o644)
+	git := &fakeGit{tags: []string{"v1.0.0"}, commits: []gitrepo.Commit{{Hash: "a", Message: "feat: x"}}}
+	a, stdout, _ := newTestApp(git)
+	a.root = root
+
+	if code := a.run(context.Background(), []string{"modules"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	expected := "MODULE            DIR  CURRENT  NEXT    BUMP\n" +
+		"example.com/mono  .    v1.0.0   v1.1.0  minor\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestNextChannel(t *testing.T) {
+	git := &fakeGit{
+		tags:    []string{"v1.2.0", "v1.3.0-rc.1"},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "feat: x"}},
+		branch:  "release/1.3",
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Channels = []config.ChannelConfig{{Branch: "release/*", Channel: "rc"}}
+
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.3.0-rc.2\n" {
+		t.Errorf("Expected %q, got %q", "1.3.0-rc.2\n", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"next", "-channel", "beta"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.3.0-beta.1\n" {
+		t.Errorf("Expected %q, got %q", "1.3.0-beta.1\n", stdout.String())
+	}
+
+	git.branch = "main"
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.3.0\n" {
+		t.Errorf("Expected promotion to %q, got %q", "1.3.0\n", stdout.String())
+	}
+}
+
+func TestNextChannelDetachedHead(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_HEAD_REF", "")
+	t.Setenv("GITHUB_REF_NAME", "develop")
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "fix: x"}},
+		branch:  "HEAD",
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Channels = []config.ChannelConfig{{Branch: "develop", Channel: "beta"}}
+
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.2.1-beta.1\n" {
+		t.Errorf("Expected %q, got %q", "1.2.1-beta.1\n", stdout.String())
+	}
+}
+
+func TestBranchFlag(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "")
+	t.Setenv("RELEASE_BRANCH", "")
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "fix: x"}},
+		branch:  "HEAD",
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Channels = []config.ChannelConfig{{Branch: "develop", Channel: "beta"}}
+
+	if code := a.run(context.Background(), []string{"-branch", "develop", "next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.2.1-beta.1\n" {
+		t.Errorf("Expected %q, got %q", "1.2.1-beta.1\n", stdout.String())
+	}
+}
+
+func TestPublishDetachedHead(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "")
+	t.Setenv("RELEASE_BRANCH", "")
+	head := "0000000000000000000000000000000000000000"
+	git := &fakeGit{
+		tags:    []string{"v1.3.0"},
+		tagged:  map[string]string{"v1.3.0": head},
+		commits: []gitrepo.Commit{{Hash: head, Message: "chore(release): v1.3.0"}},
+		branch:  "HEAD",
+	}
+	a, _, stderr := newTestApp(git)
+
+	// Without a branch, only the tag can be pushed.
+	if code := a.run(context.Background(), []string{"publish", "v1.3.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if expected := []string{"origin v1.3.0"}; !reflect.DeepEqual(git.pushed, expected) {
+		t.Errorf("Expected %v, got %v", expected, git.pushed)
+	}
+	if !strings.Contains(stderr.String(), "pass -branch to push it to its branch") {
+		t.Errorf("Expected a warning about the detached HEAD, got %q", stderr)
+	}
+
+	git.pushed = nil
+	if code := a.run(context.Background(), []string{"-branch", "main", "publish", "v1.3.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if expected := []string{"origin HEAD:refs/heads/main", "origin v1.3.0"}; !reflect.DeepEqual(git.pushed, expected) {
+		t.Errorf("Expected %v, got %v", expected, git.pushed)
+	}
+}
+
+func TestNextLine(t *testing.T) {
+	git := &fakeGit{
+		tags:    []string{"v1.4.1", "v2.0.0"},
+		tagged:  map[string]string{"v1.4.1": "a", "v2.0.0": "elsewhere"},
+		commits: []gitrepo.Commit{{Hash: "b", Message: "fix: backport"}, {Hash: "a", Message: "fix: bug"}},
+		branch:  "release/1.x",
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Lines = []config.LineConfig{{Branch: "release/*"}}
+
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.4.2\n" || git.since != "v1.4.1" {
+		t.Errorf("Expected 1.4.2 since v1.4.1, got %q since %q", stdout.String(), git.since)
+	}
+
+	git.commits[0].Message = "feat: new thing"
+	stderr.Reset()
+	if code := a.run(context.Background(), []string{"next"}); code != 4 {
+		t.Errorf("Expected exit code 4 for a minor release, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "v1.5.0 would be a minor release of line 1.x; release it from main") {
+		t.Errorf("Expected a maintenance error, got %q", stderr.String())
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"next", "-bump", "patch"}); code != 0 || stdout.String() != "1.4.2\n" {
+		t.Errorf("Expected 1.4.2 with -bump patch, got %d %q", code, stdout.String())
+	}
+}
+
+func TestNotes(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_URL", "")
+	git := &fakeGit{
+		tags: []string{"v1.2.0"},
+		commits: []gitrepo.Commit{
+			{Hash: "abcdef1234", AuthorName: "Ada", Message: "fix: handle #12"},
+		},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+
+	if code := a.run(context.Background(), []string{"notes"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "### Bug Fixes\n\n" +
+		"- handle [#12](https://github.com/octo/app/issues/12) ([abcdef1](https://github.com/octo/app/commit/abcdef1234))\n\n" +
+		"### Contributors\n\n- Ada\n\n" +
+		"**Full Changelog**: https://github.com/octo/app/compare/v1.2.0...v1.2.1\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestNotesDependencies(t *testing.T) {
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "abcdef1234", AuthorName: "Ada", Message: "fix(deps): bump x/sync"}},
+		files: map[string]string{
+			"v1.2.0:go.mod": "module example.com/app\n\nrequire (\n\tgolang.org/x/sync v0.21.0\n\tgolang.org/x/text v0.20.0 // indirect\n)\n",
+			"HEAD:go.mod":   "module example.com/app\n\nrequire (\n\tgolang.org/x/sync v0.22.0\n\tgolang.org/x/text v0.21.0 // indirect\n)\n",
+		},
+	}
+	a, stdout, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"notes"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "### Dependency changes\n\n- Upgraded `golang.org/x/sync` from v0.21.0 to v0.22.0\n\n"
+	if !strings.Contains(stdout.String(), expected) || strings.Contains(stdout.String(), "x/text") {
+		t.Errorf("Expected only the direct dependency change, got %q", stdout)
+	}
+}
+
+func TestNotesIssues(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	t.Setenv("JIRA_USER", "")
+	t.Setenv("JIRA_TOKEN", "tok")
+	var changes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/rest/api/2/issue/PROJ-7" && r.Method == http.MethodGet:
+			io.WriteString(w, `{"key": "PROJ-7", "fields": {"summary": "Empty tags crash", "status": {"name": "Done"}}}`)
+		case r.URL.Path == "/rest/api/2/issue/PROJ-7/transitions" && r.Method == http.MethodGet:
+			io.WriteString(w, `{"transitions": [{"id": "31", "name": "Release", "to": {"name": "Released"}}]}`)
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			changes = append(changes, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "abcdef1234", AuthorName: "Ada", Message: "fix: handle empty tags\n\nFixes PROJ-7, see PROJ-404."}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Tracker = config.TrackerConfig{Provider: "jira", BaseURL: srv.URL, Projects: []string{"PROJ"}, Transition: "Released"}
+
+	if code := a.run(context.Background(), []string{"notes"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "### Resolved issues\n\n- [PROJ-7](" + srv.URL + "/browse/PROJ-7) Empty tags crash (Done)\n\n"
+	if !strings.Contains(stdout.String(), expected) || strings.Contains(stdout.String(), "PROJ-404") {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected notes to change no issue, got %q", changes)
+	}
+
+	git.tags, git.tagged = append(git.tags, "v1.2.1"), map[string]string{"v1.2.1": "abcdef1234"}
+	if code := a.run(context.Background(), []string{"publish", "v1.2.1"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if want := []string{"POST /rest/api/2/issue/PROJ-7/transitions"}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("Expected %q, got %q", want, changes)
+	}
+
+	stdout.Reset()
+	a.offline = true
+	if code := a.run(context.Background(), []string{"notes"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if strings.Contains(stdout.String(), "Resolved issues") || !strings.Contains(stderr.String(), "not looked up -offline") {
+		t.Errorf("Expected no issues offline, got %q and %q", stdout, stderr)
+	}
+}
+
+func TestNotesLinks(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_SERVER_URL", "https://gitlab.example.com")
+	t.Setenv("CI_PROJECT_PATH", "g/app")
+	a, _, _ := newTestApp(&fakeGit{})
+
+	if got := a.links("github", ""); got != (notes.Links{RepoURL: "https://gitlab.example.com/g/app", Provider: "gitlab"}) {
+		t.Errorf("Expected links from the GitLab CI project, got %v", got)
+	}
+	if got := a.links("gitlab", "https://x.test/a"); got != (notes.Links{RepoURL: "https://x.test/a", Provider: "gitlab"}) {
+		t.Errorf("Expected links from -repo-url, got %v", got)
+	}
+}
+
+func TestNotesLinksConfig(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("GITLAB_CI", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	t.Setenv("CIRCLECI", "")
+	t.Setenv("JENKINS_URL", "")
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "1234567890", Message: "feat: first feature"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Links = config.LinksConfig{Provider: "gitea", BaseURL: "https://git.example.com"}
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+
+	if code := a.run(context.Background(), []string{"notes"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "**Full Changelog**: https://git.example.com/octo/app/compare/v1.2.0...v1.3.0\n"
+	if !strings.Contains(stdout.String(), expected) {
+		t.Errorf("Expected %q in %q", expected, stdout)
+	}
+}
+
+func TestNotesLinksTemplates(t *testing.T) {
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "1234567890", Message: "feat: first feature (#5)"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Links.Templates = config.LinkTemplates{
+		Issue:   "https://tickets.example.com/{issue}",
+		Commit:  "{repo}/commit/?id={commit}",
+		Compare: "{repo}/log/?id={to}&id2={from}",
+	}
+
+	if code := a.run(context.Background(), []string{"notes", "-repo-url", "https://git.example.com/cgit/app.git"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, expected := range []string{
+		"([#5](https://tickets.example.com/5))",
+		"([1234567](https://git.example.com/cgit/app.git/commit/?id=1234567890))",
+		"https://git.example.com/cgit/app.git/log/?id=v1.3.0&id2=v1.2.0",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected %q in %q", expected, stdout)
+		}
+	}
+}
+
+func TestNotesHighlights(t *testing.T) {
+	git := &fakeGit{
+		tags: []string{"v1.2.0"},
+		commits: []gitrepo.Commit{
+			{Hash: "1111111aaa", Message: "feat: small (#3)"},
+			{Hash: "2222222bbb", Message: "feat: large (#4)"},
+		},
+		stats: map[string]gitrepo.DiffStat{"1111111aaa": {Insertions: 2}, "2222222bbb": {Insertions: 200}},
+	}
+	a, stdout, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"notes", "-highlights", "1", "-repo-url", "https://github.com/octo/app"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "### Highlights\n\n- large ([#4](https://github.com/octo/app/issues/4)) ([2222222](https://github.com/octo/app/commit/2222222bbb))\n\n### Features"
+	if !strings.HasPrefix(stdout.String(), expected) {
+		t.Errorf("Expected notes starting with %q, got %q", expected, stdout)
+	}
+}
+
+func TestNotesLinksSelfHosted(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("GITLAB_CI", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	t.Setenv("CIRCLECI", "")
+	t.Setenv("JENKINS_URL", "")
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "1234567890", Message: "feat: first feature"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Publish = []config.PublishTarget{{Provider: "gitlab", Repo: "group/app", BaseURL: "https://gitlab.example.com/api/v4"}}
+
+	if code := a.run(context.Background(), []string{"notes"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "https://gitlab.example.com/group/app/-/compare/v1.2.0...v1.3.0"
+	if !strings.Contains(stdout.String(), expected) {
+		t.Errorf("Expected %q in %q", expected, stdout)
+	}
+}
+
+func TestNotesEdit(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_URL", "")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sed -i 's/handle nil/handle nil maps/'")
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "abcdef1234", Message: "fix: handle nil"}},
+	}
+	root := t.TempDir()
+	a, stdout, stderr := newTestApp(git)
+	a.root = root
+
+	if code := a.run(context.Background(), []string{"notes", "edit"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	path := filepath.Join(root, ".release", "notes", "1.2.1.md")
+	curated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(curated), "- handle nil maps (abcdef1)") {
+		t.Errorf("Expected the edited notes stored, got %q", curated)
+	}
+	if !strings.Contains(stdout.String(), "wrote the curated notes of 1.2.1 to .release/notes/1.2.1.md\n") {
+		t.Errorf("Expected the notes written, got %q", stdout)
+	}
+
+	// Curated notes are edited again rather than regenerated.
+	t.Setenv("EDITOR", "sed -i 's/nil maps/nil maps and slices/'")
+	a, stdout, _ = newTestApp(git)
+	a.root = root
+	if code := a.run(context.Background(), []string{"notes", "edit"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if curated, _ = os.ReadFile(path); !strings.Contains(string(curated), "- handle nil maps and slices") {
+		t.Errorf("Expected the curated notes edited, got %q", curated)
+	}
+	t.Setenv("EDITOR", "true")
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"notes", "edit"}); code != 0 || stdout.String() != "the notes of 1.2.1 are unchanged\n" {
+		t.Errorf("Expected unchanged notes, got %d and %q", code, stdout)
+	}
+
+	var body string
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return publisherFunc(func(ctx context.Context, r publish.Release) (publish.Result, error) {
+			body = r.Body
+			return publish.Result{}, nil
+		}), nil
+	}
+	if code := a.run(context.Background(), []string{"publish", "v1.2.1"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if body != string(curated) {
+		t.Errorf("Expected the curated notes published, got %q", body)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"notes", "edit", "-reset"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the curated notes removed, got %v", err)
+	}
+	if stdout.String() != "removed .release/notes/1.2.1.md: 1.2.1 uses the generated notes\n" {
+		t.Errorf("Expected the removal reported, got %q", stdout)
+	}
+}
+
+func TestNewPublisherSelfHosted(t *testing.T) {
+	secret := func(name string) string { return map[string]string{"GITHUB_TOKEN": "gh", "GITLAB_TOKEN": "gl"}[name] }
+
+	p, err := newPublisher("github", "octo/app", publisherOptions{Secret: secret, BaseURL: "https://ghe.example.com", APIVersion: "2026-03-10", Auth: "token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gh := p.(*github.Publisher)
+	if gh.BaseURL != "https://ghe.example.com/api/v3" || gh.APIVersion != "2026-03-10" || gh.AuthScheme != "token" {
+		t.Errorf("Unexpected GitHub publisher %+v", gh)
+	}
+
+	t.Setenv("CI_API_V4_URL", "")
+	p, err = newPublisher("gitlab", "group/app", publisherOptions{Secret: secret, BaseURL: "https://gitlab.example.com", Auth: "bearer"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gl := p.(*gitlab.Publisher)
+	if gl.BaseURL != "https://gitlab.example.com/api/v4" || !gl.OAuth || gl.JobToken {
+		t.Errorf("Unexpected GitLab publisher %+v", gl)
+	}
+}
+
+func TestNewPublisherPackages(t *testing.T) {
+	secret := func(name string) string { return map[string]string{"NPM_TOKEN": "npm", "TWINE_PASSWORD": "pw"}[name] }
+	pkg := config.PackageConfig{Dir: "web", Registry: "https://npm.example.com/", Tag: "beta", Access: "public", Provenance: true}
+
+	p, err := newPublisher("npm", "@octo/app", publisherOptions{Secret: secret, Package: pkg})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := p.(*npm.Publisher); n.Name != "@octo/app" || n.Dir != "web" || n.Token != "npm" || n.Registry != pkg.Registry || n.Tag != "beta" || !n.Provenance {
+		t.Errorf("Unexpected npm publisher %+v", n)
+	}
+
+	p, err = newPublisher("pypi", "octo-app", publisherOptions{Secret: secret, Package: config.PackageConfig{Registry: "https://test.pypi.org/legacy/"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if py := p.(*pypi.Publisher); py.Project != "octo-app" || py.Username != pypi.TokenUsername || py.Password != "pw" || py.URL != "https://test.pypi.org/legacy/" {
+		t.Errorf("Unexpected PyPI publisher %+v", py)
+	}
+	if _, err := newPublisher("pypi", "octo-app", publisherOptions{Secret: func(string) string { return "" }}); !errors.Is(err, pypi.ErrNoToken) {
+		t.Errorf("Expected pypi.ErrNoToken, got %v", err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	a, _, _ := newTestApp(&fakeGit{})
+	a.cfg.RateLimit = config.RateLimitConfig{Reserve: 50, MinInterval: "1s"}
+
+	l := a.rateLimiter("github", "")
+	if l.Reserve != 50 || l.MinInterval != time.Second {
+		t.Errorf("Expected the configured limiter, got reserve %d and interval %s", l.Reserve, l.MinInterval)
+	}
+	if a.rateLimiter("homebrew", "") != l {
+		t.Errorf("Expected homebrew taps to share the GitHub limiter")
+	}
+	if a.rateLimiter("github", "https://ghe.example.com") == l || a.rateLimiter("gitlab", "") == l {
+		t.Errorf("Expected other APIs to have limiters of their own")
+	}
+
+	// serve runs each request on a copy of the app.
+	a, _, _ = newTestApp(&fakeGit{})
+	a.serveHandler("secret", "origin", false)
+	sub := *a
+	if sub.rateLimiter("github", "") != a.rateLimiter("github", "") {
+		t.Errorf("Expected the requests of serve to share the limiters")
+	}
+}
+
+func TestChangelogStdout(t *testing.T) {
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "1234567890", Message: "feat: first feature"}}}
+	a, stdout, _ := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"changelog"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	expected := "## [0.1.0] - 2026-03-01\n\n### Features\n\n- first feature (1234567)\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestChangelogFormat(t *testing.T) {
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "1234567890", Message: "feat(cli): first <feature>"}}}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"asciidoc", "== 0.1.0 - 2026-03-01\n\n=== Features\n\n* *cli:* first <feature> (1234567)\n"},
+		{"json", `"description": "first <feature>"`},
+		{"html", "<li><strong>cli:</strong> first &lt;feature&gt; (<code>1234567</code>)</li>"},
+	}
+	for _, tt := range tests {
+		a, stdout, _ := newTestApp(git)
+		if code := a.run(context.Background(), []string{"changelog", "-format", tt.format}); code != 0 {
+			t.Fatalf("%s: Expected exit code 0, got %d", tt.format, code)
+		}
+		if !strings.Contains(stdout.String(), tt.expected) {
+			t.Errorf("%s: Expected %q in %q", tt.format, tt.expected, stdout.String())
+		}
+	}
+
+	a, _, _ := newTestApp(git)
+	if code := a.run(context.Background(), []string{"changelog", "-format", "rst"}); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown format, got %d", code)
+	}
+	a, _, _ = newTestApp(git)
+	if code := a.run(context.Background(), []string{"changelog", "-format", "json", "-file", "CHANGELOG.md"}); code != 2 {
+		t.Errorf("Expected exit code 2 for -file with -format json, got %d", code)
+	}
+}
+
+// loginPublisher is a publisher that tells the logins of commit authors.
+type loginPublisher struct {
+	fakePublisher
+	logins map[string]string
+}
+
+func (p loginPublisher) CommitAuthor(ctx context.Context, hash string) (string, error) {
+	return p.logins[hash], nil
+}
+
+func TestChangelogContributors(t *testing.T) {
+	git := &fakeGit{commits: []gitrepo.Commit{
+		{Hash: "1234567890", AuthorName: "Ada", AuthorEmail: "ada@example.com", Message: "feat: first feature\n\nCo-authored-by: Bob <bob@example.com>"},
+		{Hash: "2345678901", AuthorName: "dependabot[bot]", AuthorEmail: "49699333+dependabot[bot]@users.noreply.github.com", Message: "fix(deps): bump x"},
+	}}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	a.cfg.Changelog.Contributors = config.ContributorsConfig{Enabled: true, Lookup: true}
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return loginPublisher{logins: map[string]string{"1234567890": "ada"}}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"changelog"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "## [0.1.0] - 2026-03-01\n\n### Features\n\n- first feature (1234567)\n\n### Bug Fixes\n\n- **deps:** bump x (2345678)\n\n" +
+		"### Contributors\n\n- [@ada](https://github.com/ada)\n- Bob\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+// issuePublisher is a publisher that looks up issues and pull requests.
+type issuePublisher struct {
+	fakePublisher
+	issues map[int]references.Reference
+}
+
+func (p issuePublisher) Issue(ctx context.Context, number int) (references.Reference, error) {
+	r, ok := p.issues[number]
+	if !ok {
+		return references.Reference{}, fmt.Errorf("issue #%d not found", number)
+	}
+	return r, nil
+}
+
+func TestChangelogReferences(t *testing.T) {
+	git := &fakeGit{commits: []gitrepo.Commit{
+		{Hash: "1234567890", Message: "chore: handle empty tags (#12)"},
+		{Hash: "2345678901", Message: "feat: add notes\n\nCloses #3"},
+	}}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	a.cfg.Changelog.References = config.ReferencesConfig{Enabled: true, GroupByLabel: true}
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return issuePublisher{issues: map[int]references.Reference{
+			12: {Number: 12, URL: "https://github.com/octo/app/pull/12", Author: "ada", Labels: []string{"bug"}, PullRequest: true},
+		}}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"changelog"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "## [0.1.0] - 2026-03-01\n\n### Bug Fixes\n\n- handle empty tags ([#12](https://github.com/octo/app/pull/12) by @ada) (1234567)\n\n" +
+		"### Other Changes\n\n- add notes (2345678)\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "issue #3 not found") {
+		t.Errorf("Expected a warning about #3, got %q", stderr.String())
+	}
+
+	// Offline, the references looked up above come from the provider cache.
+	root := a.root
+	a, stdout, stderr = newTestApp(git)
+	a.root = root
+	a.cfg.Changelog.References = config.ReferencesConfig{Enabled: true, GroupByLabel: true}
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		t.Errorf("Expected no publisher offline")
+		return nil, errors.New("offline")
+	}
+	if code := a.run(context.Background(), []string{"-offline", "changelog"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "1 issue(s) not in the provider cache") {
+		t.Errorf("Expected a warning about the uncached #3, got %q", stderr.String())
+	}
+}
+
+func TestChangelogReferencesBatched(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/graphql" {
+			t.Errorf("Expected GraphQL queries only, got %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req struct{ Query string }
+		json.NewDecoder(r.Body).Decode(&req)
+		queries = append(queries, req.Query)
+		var fields []string
+		for _, n := range []string{"3", "7", "12"} {
+			if strings.Contains(req.Query, "i"+n+": ") {
+				fields = append(fields, fmt.Sprintf(`"i%s": {"__typename": "PullRequest", "number": %s, "title": "t", "url": "https://ghe.example.com/octo/app/pull/%s", "author": {"login": "ada"}, "labels": {"nodes": []}}`, n, n, n))
+			}
+		}
+		fmt.Fprintf(w, `{"data": {"repository": {%s}}}`, strings.Join(fields, ", "))
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	git := &fakeGit{commits: []gitrepo.Commit{
+		{Hash: "1234567890", Message: "fix: handle empty tags (#12)"},
+		{Hash: "2345678901", Message: "feat: add notes\n\nCloses #3"},
+		{Hash: "3456789012", Message: "feat: add links (#7)\n\nRefs #12"},
+	}}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	a.cfg.Changelog.References.Enabled = true
+	a.cfg.RateLimit.BatchSize = 2
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app", BaseURL: server.URL + "/api/v3"}}
+	a.newPublisher = newPublisher
+
+	if code := a.run(context.Background(), []string{"changelog"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if len(queries) != 2 {
+		t.Errorf("Expected the 3 numbers looked up in 2 queries, got %d", len(queries))
+	}
+	for _, n := range []string{"3", "7", "12"} {
+		if link := "([#" + n + "](https://ghe.example.com/octo/app/pull/" + n + ") by @ada)"; !strings.Contains(stdout.String(), link) {
+			t.Errorf("Expected %q, got %q", link, stdout.String())
+		}
+	}
+}
+
+func TestCacheSync(t *testing.T) {
+	git := &fakeGit{commits: []gitrepo.Commit{
+		{Hash: "1234567890", AuthorName: "Ada", AuthorEmail: "ada@example.com", Message: "fix: handle empty tags (#12)"},
+		{Hash: "2345678901", AuthorName: "Bob", AuthorEmail: "bob@example.com", Message: "feat: add notes\n\nCloses #3"},
+	}}
+	root := t.TempDir()
+	setup := func(offline bool) (*app, *bytes.Buffer, *bytes.Buffer) {
+		a, stdout, stderr := newTestApp(git)
+		a.root = root
+		a.cfg.Changelog.References.Enabled = true
+		a.cfg.Changelog.Contributors = config.ContributorsConfig{Enabled: true, Lookup: true}
+		a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+		a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+			if offline {
+				t.Errorf("Expected no publisher offline")
+			}
+			issues := issuePublisher{issues: map[int]references.Reference{
+				3:  {Number: 3, URL: "https://github.com/octo/app/issues/3", Author: "bob"},
+				12: {Number: 12, URL: "https://github.com/octo/app/pull/12", Author: "ada", PullRequest: true},
+			}}
+			return struct {
+				issuePublisher
+				contributors.LoginFinder
+			}{issues, loginPublisher{logins: map[string]string{"1234567890": "ada", "2345678901": "bob"}}}, nil
+		}
+		return a, stdout, stderr
+	}
+
+	a, stdout, stderr := setup(false)
+	if code := a.run(context.Background(), []string{"cache", "sync", "-dry-run"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "would look up the references and authors of 2 commit(s)") {
+		t.Errorf("Expected the dry-run description, got %q", stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, stateDir, providerCacheDir)); !os.IsNotExist(err) {
+		t.Errorf("Expected no cache written on dry-run, got %v", err)
+	}
+
+	a, stdout, stderr = setup(false)
+	if code := a.run(context.Background(), []string{"cache", "sync"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if expected := "synced 2 issue(s) and 2 commit author(s) of 2 commit(s) into .release/cache\n"; stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	a, stdout, _ = setup(false)
+	a.run(context.Background(), []string{"cache", "sync", "-missing"})
+	if !strings.HasPrefix(stdout.String(), "synced 0 issue(s) and 0 commit author(s)") {
+		t.Errorf("Expected nothing looked up again with -missing, got %q", stdout.String())
+	}
+
+	a, stdout, stderr = setup(true)
+	if code := a.run(context.Background(), []string{"-offline", "changelog"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, s := range []string{"([#12](https://github.com/octo/app/pull/12) by @ada)", "[@bob](https://github.com/bob)"} {
+		if !strings.Contains(stdout.String(), s) {
+			t.Errorf("Expected %q offline, got %q", s, stdout.String())
+		}
+	}
+	if strings.Contains(stderr.String(), "provider cache") {
+		t.Errorf("Expected no cache misses, got %q", stderr.String())
+	}
+
+	a, _, _ = setup(true)
+	if code := a.run(context.Background(), []string{"-offline", "cache", "sync"}); code != 2 {
+		t.Errorf("Expected exit code 2 for a sync offline, got %d", code)
+	}
+	a, _, _ = setup(false)
+	if code := a.run(context.Background(), []string{"cache", "prune"}); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown subcommand, got %d", code)
+	}
+}
+
+func TestSecretsFromFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "github_token"), []byte("ghp_from_file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_TOKEN", "")
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "1234567890", Message: "fix: a bug"}}}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Secrets = []config.SecretSource{{Provider: "file", Dir: dir}}
+	a.root = t.TempDir()
+	a.cfg.Changelog.References.Enabled = true
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	var token string
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		token = o.Secret("GITHUB_TOKEN")
+		o.Logger.Warn("authenticating with " + token)
+		return issuePublisher{}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"changelog"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if token != "ghp_from_file" {
+		t.Errorf("Expected the token of the secrets file, got %q", token)
+	}
+	if strings.Contains(stderr.String(), "ghp_from_file") || !strings.Contains(stderr.String(), "authenticating with [REDACTED]") {
+		t.Errorf("Expected the token to be redacted, got %q", stderr.String())
+	}
+}
+
+func TestChangelogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "abc", Message: "fix: a bug"}}}
+	a, _, _ := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"changelog", "-file", path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(content), "## [0.0.1] - 2026-03-01") {
+		t.Errorf("Expected changelog section in file, got %q", content)
+	}
+}
+
+func TestChangelogUpgradeGuide(t *testing.T) {
+	git := &fakeGit{tags: []string{"v1.2.0"}, commits: []gitrepo.Commit{
+		{Hash: "bbbbbbb222", Message: "feat(api)!: drop v1 again\n\nBREAKING CHANGE: the /v1 routes are gone"},
+		{Hash: "aaaaaaa111", Message: "feat(api)!: drop v1\n\nBREAKING CHANGE: The /v1 routes are gone.\nBREAKING CHANGE: tokens must be rotated"},
+		{Hash: "9999999000", Message: "fix: a bug"},
+	}}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	a.cfg.Changelog.Upgrade.Enabled = true
+	a.cfg.Links.Repo = "octo/app"
+	path := filepath.Join(a.root, "CHANGELOG.md")
+	guide := filepath.Join(a.root, "docs", "upgrade", "2.0.0.md")
+
+	if code := a.run(context.Background(), []string{"changelog", "-file", path, "-dry-run"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "[dry-run] would write the upgrade guide of 2.0.0 to docs/upgrade/2.0.0.md") {
+		t.Errorf("Expected the upgrade guide in the dry run, got %q", stdout.String())
+	}
+	if _, err := os.Stat(guide); !os.IsNotExist(err) {
+		t.Errorf("Expected no upgrade guide written in a dry run, got %v", err)
+	}
+
+	if code := a.run(context.Background(), []string{"changelog", "-file", path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "See the [upgrade guide](docs/upgrade/2.0.0.md) for what to change.") {
+		t.Errorf("Expected the changelog to link the upgrade guide, got %q", content)
+	}
+	written, err := os.ReadFile(guide)
+	if err != nil {
+		t.Fatalf("Expected the upgrade guide to be written: %v", err)
+	}
+	expected := "# Upgrade guide: 2.0.0\n\n" +
+		"Upgrading from 1.2.0 to 2.0.0, released 2026-03-01, asks for the following changes.\n\n" +
+		"## api\n\n" +
+		"- The /v1 routes are gone. (aaaaaaa, bbbbbbb)\n" +
+		"- tokens must be rotated (aaaaaaa)\n"
+	if string(written) != expected {
+		t.Errorf("Expected %q, got %q", expected, written)
+	}
+
+	url := "https://github.com/octo/app/blob/v2.0.0/docs/upgrade/2.0.0.md"
+	body, err := a.releaseNotes("v2.0.0", "2.0.0", "", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(body, "See the [upgrade guide]("+url+")") || strings.Contains(body, "](docs/upgrade") {
+		t.Errorf("Expected the release body to link the upgrade guide on GitHub, got %q", body)
+	}
+
+	a, stdout, _ = newTestApp(git)
+	a.cfg.Changelog.Upgrade.Enabled = true
+	a.cfg.Links.Repo = "octo/app"
+	if code := a.run(context.Background(), []string{"notes"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "See the [upgrade guide]("+url+")") {
+		t.Errorf("Expected the notes to link the upgrade guide, got %q", stdout.String())
+	}
+}
+
+func TestChangelogFragments(t *testing.T) {
+	git := &fakeGit{tags: []string{"v1.2.0"}, commits: []gitrepo.Commit{{Hash: "abc", Message: "feat: a feature"}}}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	a.cfg.Changelog.Fragments.Enabled = true
+	path := filepath.Join(a.root, "CHANGELOG.md")
+	fragment := filepath.Join(a.root, "changelog.d", "1.3.0.md")
+	older := filepath.Join(a.root, "changelog.d", "1.2.0.md")
+	if err := os.MkdirAll(filepath.Dir(older), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(older, []byte("## [1.2.0] - 2026-02-01\n\n### Bug Fixes\n\n- old bug\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("# Changelog\n\nOur history.\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if code := a.run(context.Background(), []string{"changelog", "-file", path, "-dry-run"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "[dry-run] would write the 1.3.0 section to changelog.d/1.3.0.md") {
+		t.Errorf("Expected the fragment in the dry run, got %q", stdout.String())
+	}
+	if _, err := os.Stat(fragment); !os.IsNotExist(err) {
+		t.Errorf("Expected no fragment written in a dry run, got %v", err)
+	}
+
+	if code := a.run(context.Background(), []string{"changelog", "-file", path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	written, err := os.ReadFile(fragment)
+	if err != nil {
+		t.Fatalf("Expected the fragment to be written: %v", err)
+	}
+	if !strings.HasPrefix(string(written), "## [1.3.0]") || !strings.Contains(string(written), "a feature") {
+		t.Errorf("Expected the 1.3.0 section in the fragment, got %q", written)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "# Changelog\n\nOur history.\n" {
+		t.Errorf("Expected the changelog untouched, got %q", content)
+	}
+
+	body, err := a.releaseNotes("v1.3.0", "1.3.0", "", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(body, "a feature") {
+		t.Errorf("Expected the release body from the fragment, got %q", body)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"changelog", "compile"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	out := stdout.String()
+	if i, j := strings.Index(out, "## [1.3.0]"), strings.Index(out, "## [1.2.0]"); i < 0 || j < i {
+		t.Errorf("Expected the fragments newest first, got %q", out)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"changelog", "compile", "-file", path, "-dry-run"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "[dry-run] would write "+path+" with 2 release section(s) from changelog.d") {
+		t.Errorf("Expected the compiled changelog in the dry run, got %q", stdout.String())
+	}
+	if code := a.run(context.Background(), []string{"changelog", "compile", "-file", path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(content), "# Changelog\n\nOur history.\n") || !strings.Contains(string(content), "old bug") || !strings.Contains(string(content), "a feature") {
+		t.Errorf("Expected the header kept above both fragments, got %q", content)
+	}
+
+	b, _, _ := newTestApp(git)
+	b.root = t.TempDir()
+	b.cfg.Changelog.Fragments.Enabled = true
+	if code := b.run(context.Background(), []string{"changelog", "compile"}); code == 0 {
+		t.Errorf("Expected compiling without fragments to fail")
+	}
+}
+
+func TestChangelogTranslations(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CHANGELOG.md")
+	translated := filepath.Join(dir, "CHANGELOG.pt-BR.md")
+	if err := os.WriteFile(translated, []byte("# Registro de alterações\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "abc", Message: "fix: a bug"}}}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Changelog.Translations = config.TranslationsConfig{Languages: []string{"pt-BR", "de"}}
+
+	if code := a.run(context.Background(), []string{"changelog", "-file", path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, file := range []string{translated, filepath.Join(dir, "CHANGELOG.de.md")} {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(content), "## [0.0.1] - 2026-03-01") {
+			t.Errorf("Expected the section copied to %s, got %q", file, content)
+		}
+	}
+	if content, _ := os.ReadFile(translated); !strings.HasPrefix(string(content), "# Registro de alterações\n") {
+		t.Errorf("Expected the header of the translation kept, got %q", content)
+	}
+	if !strings.Contains(stderr.String(), "translated 0.0.1 into "+translated) {
+		t.Errorf("Expected the translation logged, got %q", stderr.String())
+	}
+}
+
+func TestTag(t *testing.T) {
+	git := &fakeGit{tags: []string{"v0.1.0"}, commits: []gitrepo.Commit{{Hash: "abc", Message: "fix: a bug"}}}
+	a, stdout, _ := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if stdout.String() != "v0.1.1\n" {
+		t.Errorf("Expected %q, got %q", "v0.1.1\n", stdout.String())
+	}
+	if git.created["v0.1.1"] != "chore(release): v0.1.1" {
+		t.Errorf("Expected tag v0.1.1 with default message, got %#v", git.created)
+	}
+}
+
+func TestTagMessageTemplate(t *testing.T) {
+	git := &fakeGit{
+		tags: []string{"v0.1.0"},
+		commits: []gitrepo.Commit{
+			{Hash: "b", Message: "feat: add export (#12)"},
+			{Hash: "a", Message: "fix: a bug"},
+		},
+		stats: map[string]gitrepo.DiffStat{"b": {Files: 3, Insertions: 120, Deletions: 4}},
+	}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Tag.Message = "MyApp {{.Tag}} — {{.Date}}\n\n{{.Stats.Features}} feature(s), {{.Stats.Fixes}} fix(es), +{{.Stats.Insertions}}/-{{.Stats.Deletions}}\n{{range .Highlights}}- {{.Description}}\n{{end}}"
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "MyApp v0.2.0 — 2026-03-01\n\n1 feature(s), 1 fix(es), +120/-4\n- add export (#12)"
+	if git.created["v0.2.0"] != expected {
+		t.Errorf("Expected %q, got %#v", expected, git.created)
+	}
+
+	a, _, stderr = newTestApp(&fakeGit{tags: []string{"v0.1.0"}, commits: git.commits})
+	a.cfg.Tag.Message = "{{.Tag"
+	if code := a.run(context.Background(), []string{"tag"}); code != 6 || !strings.Contains(stderr.String(), "parse title template") {
+		t.Errorf("Expected a configuration error, got %d: %s", code, stderr)
+	}
+}
+
+func TestTagSigned(t *testing.T) {
+	git := &fakeGit{tags: []string{"v1.0.0"}, commits: []gitrepo.Commit{{Hash: "a", Message: "fix: x"}}}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Tag.Sign = true
+	a.cfg.Tag.SigningKey = "ABCD1234"
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if len(git.signed) != 1 || git.signed[0] != "v1.0.1 ABCD1234" {
+		t.Errorf("Expected v1.0.1 signed with ABCD1234, got %v", git.signed)
+	}
+
+	git.signed = nil
+	if code := a.run(context.Background(), []string{"tag", "-signing-key", "other", "-bump", "minor"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if len(git.signed) != 1 || git.signed[0] != "v1.1.0 other" {
+		t.Errorf("Expected v1.1.0 signed with other, got %v", git.signed)
+	}
+}
+
+func TestTagVerifyPrevious(t *testing.T) {
+	git := &fakeGit{tags: []string{"v1.0.0"}, commits: []gitrepo.Commit{{Hash: "a", Message: "fix: x"}}}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Tag.Verify = true
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 7 {
+		t.Fatalf("Expected exit code 7, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "refusing to release on top of v1.0.0") || len(git.created) != 0 {
+		t.Errorf("Expected the unsigned v1.0.0 to block tagging, got %q %v", stderr.String(), git.created)
+	}
+
+	git.trusted = []string{"v1.0.0"}
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+}
+
+func TestTagDryRun(t *testing.T) {
+	git := &fakeGit{tags: []string{"v0.1.0"}, commits: []gitrepo.Commit{{Hash: "abc", Message: "fix: a bug"}}}
+	a, stdout, _ := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"-dry-run", "tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if len(git.created) != 0 {
+		t.Errorf("Expected no tag to be created, got %#v", git.created)
+	}
+
+	expected := "[dry-run] would create tag v0.1.1 (\"chore(release): v0.1.1\")\nv0.1.1\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestTagBumpFiles(t *testing.T) {
+	git := &fakeGit{tags: []string{"v0.1.0"}, commits: []gitrepo.Commit{{Hash: "abc", Message: "feat: x"}}}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	os.WriteFile(filepath.Join(a.root, "VERSION"), []byte("0.1.0\n"), 0o644)
+	os.WriteFile(filepath.Join(a.root, "package.json"), []byte(`{"version": "0.1.0"}`), 0o644)
+	a.cfg.VersionFiles = []config.VersionFile{{Path: "VERSION"}, {Path: "package.json", Key: "version"}}
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if got, _ := os.ReadFile(filepath.Join(a.root, "package.json")); string(got) != `{"version": "0.2.0"}` {
+		t.Errorf("Expected package.json to be bumped, got %q", got)
+	}
+	if !slices.Equal(git.committed, []string{"chore(release): v0.2.0: VERSION package.json"}) {
+		t.Errorf("Expected a release commit, got %v", git.committed)
+	}
+	if stdout.String() != "v0.2.0\n" || git.created["v0.2.0"] == "" {
+		t.Errorf("Expected v0.2.0 to be tagged, got %q %v", stdout.String(), git.created)
+	}
+
+	git.committed = nil
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"tag", "-dry-run"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "[dry-run] would update VERSION: 0.2.0 -> 0.2.0\n" +
+		"[dry-run] would update package.json: 0.2.0 -> 0.2.0\n" +
+		"[dry-run] would commit VERSION, package.json (\"chore(release): v0.2.0\")\n" +
+		"[dry-run] would create tag v0.2.0 (\"chore(release): v0.2.0\")\nv0.2.0\n"
+	if stdout.String() != expected || len(git.committed) != 0 {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	os.WriteFile(filepath.Join(a.root, "package.json"), []byte(`{"name": "app"}`), 0o644)
+	git.created = nil
+	if code := a.run(context.Background(), []string{"tag"}); code != 1 {
+		t.Errorf("Expected exit code 1 without a version in package.json, got %d", code)
+	}
+	if len(git.created) != 0 || len(git.committed) != 0 {
+		t.Errorf("Expected nothing to be committed or tagged, got %v %v", git.committed, git.created)
+	}
+	if code := a.run(context.Background(), []string{"tag", "-bump-files=false"}); code != 0 {
+		t.Errorf("Expected exit code 0 with -bump-files=false, got %d: %s", code, stderr)
+	}
+}
+
+func TestChangelogBackfill(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 12, 0, 0, 0, time.UTC) }
+	git := &fakeGit{
+		tags:   []string{"v0.1.0", "v0.2.0", "v0.2.0-rc.1"},
+		tagged: map[string]string{"v0.1.0": "b", "v0.2.0": "d", "v0.2.0-rc.1": "c"},
+		commits: []gitrepo.Commit{
+			{Hash: "e", Parents: []string{"d"}, Message: "feat: unreleased"},
+			{Hash: "d", Parents: []string{"c"}, Message: "fix: second", Date: day(4)},
+			{Hash: "c", Parents: []string{"b"}, Message: "feat: first feature", Date: day(3)},
+			{Hash: "b", Parents: []string{"a"}, Message: "fix: first", Date: day(2)},
+			{Hash: "a", Message: "chore: init", Date: day(1)},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	os.WriteFile(path, []byte("# Changelog\n\nOur history.\n\n## [0.0.1] - 2020-01-01\n\n- gone\n"), 0o644)
+	a, stdout, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"changelog", "--backfill", "-file", path, "-dry-run"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "[dry-run] would rewrite "+path+" with 2 release section(s), v0.1.0 to v0.2.0\n" {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+
+	if code := a.run(context.Background(), []string{"changelog", "--backfill", "-file", path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	content, _ := os.ReadFile(path)
+	expected := "# Changelog\n\nOur history.\n\n" +
+		"## [0.2.0] - 2025-06-04\n\n### Features\n\n- first feature (c)\n\n### Bug Fixes\n\n- second (d)\n\n" +
+		"## [0.1.0] - 2025-06-02\n\n### Bug Fixes\n\n- first (b)\n\n### Other Changes\n\n- init (a)\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+
+	git.tags = nil
+	if code := a.run(context.Background(), []string{"changelog", "-backfill"}); code != 1 {
+		t.Errorf("Expected exit code 1 without tags, got %d", code)
+	}
+}
+
+func TestChangelogFileDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "abc", Message: "fix: a bug"}}}
+	a, stdout, _ := newTestApp(git)
+	a.dryRun = true
+
+	if code := a.run(context.Background(), []string{"changelog", "-file", path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected changelog not to be written, got %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "[dry-run] would prepend the 0.0.1 section to "+path) {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+}
+
+func TestTagPreBumpHook(t *testing.T) {
+	git := &fakeGit{tags: []string{"v0.1.0"}, commits: []gitrepo.Commit{{Hash: "abc", Message: "feat: x"}}}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Hooks = map[string][]string{"pre-bump": {`echo "next=$NEXT_VERSION prev=$PREV_VERSION" >&2`}}
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stderr.String() != "next=0.2.0 prev=0.1.0\n" {
+		t.Errorf("Expected hook output, got %q", stderr.String())
+	}
+}
+
+func TestTagPreBumpHookOSOverride(t *testing.T) {
+	git := &fakeGit{tags: []string{"v0.1.0"}, commits: []gitrepo.Commit{{Hash: "abc", Message: "feat: x"}}}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Hooks = map[string][]string{"pre-bump": {"exit 1"}}
+	a.cfg.HooksOS = map[string]map[string][]string{runtime.GOOS: {"pre-bump": {`echo "$RELEASE_CHANGELOG" >&2`}}}
+	a.cfg.HookShell = "sh"
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stderr.String() != "CHANGELOG.md\n" {
+		t.Errorf("Expected the %s hook to run, got %q", runtime.GOOS, stderr.String())
+	}
+}
+
+func TestTagPreBumpHookFailureAborts(t *testing.T) {
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "abc", Message: "feat: x"}}}
+	a, _, _ := newTestApp(git)
+	a.cfg.Hooks = map[string][]string{"pre-bump": {"exit 1"}}
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 9 {
+		t.Errorf("Expected exit code 9, got %d", code)
+	}
+	if len(git.created) != 0 {
+		t.Errorf("Expected no tag after a failed pre-bump hook, got %#v", git.created)
+	}
+}
+
+func TestPublishPostHookFailureWarns(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	git := &fakeGit{}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Hooks = map[string][]string{"post-publish": {"exit 1"}}
+
+	if code := a.run(context.Background(), []string{"publish", "v1.0.0"}); code != 0 {
+		t.Errorf("Expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "warning: post hook failed") {
+		t.Errorf("Expected post hook warning, got %q", stderr.String())
+	}
+	if len(git.pushed) != 1 {
+		t.Errorf("Expected the tag to be pushed, got %#v", git.pushed)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	git := &fakeGit{}
+	a, _, _ := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"publish", "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if len(git.pushed) != 1 || git.pushed[0] != "origin v1.0.0" {
+		t.Errorf("Expected push of v1.0.0 to origin, got %#v", git.pushed)
+	}
+
+	if code := a.run(context.Background(), []string{"publish"}); code != 2 {
+		t.Errorf("Expected exit code 2 without a tag, got %d", code)
+	}
+}
+
+type fakePublisher struct{ result publish.Result }
+
+// snapshotPublisher keeps a rolling prerelease in memory.
+type snapshotPublisher struct {
+	fakePublisher
+	snapshots []publish.Release
+	commits   []string
+	assets    []string
+	deleted   []string
+}
+
+func (p *snapshotPublisher) Snapshot(_ context.Context, r publish.Release, commit string) (publish.Result, error) {
+	p.snapshots, p.commits = append(p.snapshots, r), append(p.commits, commit)
+	for _, a := range r.Assets {
+		if name := filepath.Base(a); !slices.Contains(p.assets, name) {
+			p.assets = append(p.assets, name)
+		}
+	}
+	return publish.Result{ID: "7", URL: "https://example.com/releases/" + r.Tag, Uploaded: r.Assets}, nil
+}
+
+func (p *snapshotPublisher) DeleteAssets(_ context.Context, tag string, names []string) error {
+	p.deleted = append(p.deleted, names...)
+	p.assets = slices.DeleteFunc(p.assets, func(a string) bool { return slices.Contains(names, a) })
+	return nil
+}
+
+func (p *snapshotPublisher) Fetch(_ context.Context, tag string) (publish.Published, bool, error) {
+	r := publish.Published{Tag: tag}
+	for _, a := range p.assets {
+		r.Assets = append(r.Assets, publish.RemoteAsset{Name: a})
+	}
+	return r, true, nil
+}
+
+func (p *snapshotPublisher) Download(context.Context, publish.RemoteAsset, io.Writer) error {
+	return nil
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	git := &fakeGit{
+		tags:    []string{"v1.3.0"},
+		commits: []gitrepo.Commit{{Hash: "abcdef1234", AuthorName: "Ada", Message: "feat: add cache"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	a.state = &state.Store{Dir: t.TempDir(), Now: a.now}
+	a.cfg.Snapshot.Keep = 2
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}, {Provider: "docker", Repo: "octo/app"}}
+	sp := &snapshotPublisher{assets: []string{
+		"app_1.3.1-dev.20260225.aaaaaaa.tar.gz",
+		"app_1.4.0-dev.20260228.bbbbbbb.tar.gz",
+		"SHA256SUMS",
+	}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		if provider == "docker" {
+			return fakePublisher{}, nil
+		}
+		return sp, nil
+	}
+	asset := filepath.Join(t.TempDir(), "app_1.4.0-dev.20260301.g0000000.tar.gz")
+	if err := os.WriteFile(asset, []byte("binary"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if code := a.run(context.Background(), []string{"snapshot", "-asset", asset}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "1.4.0-dev.20260301.g0000000\n" +
+		"published https://example.com/releases/nightly\n" +
+		"pruned 1 asset(s) of older snapshots from nightly\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	if len(sp.snapshots) != 1 || sp.commits[0] != "0000000000000000000000000000000000000000" {
+		t.Fatalf("Expected one snapshot of HEAD, got %v at %v", sp.snapshots, sp.commits)
+	}
+	r := sp.snapshots[0]
+	if r.Tag != "nightly" || r.Name != "nightly 1.4.0-dev.20260301.g0000000" || !r.Prerelease || !strings.Contains(r.Body, "add cache") {
+		t.Errorf("Unexpected snapshot release %#v", r)
+	}
+	if want := []string{"app_1.3.1-dev.20260225.aaaaaaa.tar.gz"}; !reflect.DeepEqual(sp.deleted, want) {
+		t.Errorf("Expected %v pruned, got %v", want, sp.deleted)
+	}
+	if !strings.Contains(stderr.String(), "docker octo/app keeps no rolling prerelease") {
+		t.Errorf("Expected a warning for docker, got %q", stderr)
+	}
+	if len(git.created) != 0 || len(git.pushed) != 0 {
+		t.Errorf("Expected no tag, got %v and pushes %v", git.created, git.pushed)
+	}
+	if rel, err := a.state.Load(); err == nil && rel != nil {
+		t.Errorf("Expected no release state, got %#v", rel)
+	}
+
+	// With nothing to release, the snapshot leads to the next patch.
+	stdout.Reset()
+	git.commits = nil
+	if code := a.run(context.Background(), []string{"snapshot", "-publish=false"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "1.3.1-dev.20260301.g0000000\n" {
+		t.Errorf("Expected the next patch snapshot, got %q", stdout.String())
+	}
+
+	if code := a.run(context.Background(), []string{"snapshot", "-keep", "0"}); code != 2 {
+		t.Errorf("Expected exit code 2 for -keep 0, got %d", code)
+	}
+}
+
+func TestPublishRemotes(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	head := "0000000000000000000000000000000000000000"
+	git := &fakeGit{
+		tags:    []string{"v1.3.0"},
+		tagged:  map[string]string{"v1.3.0": head},
+		commits: []gitrepo.Commit{{Hash: head, Message: "chore(release): v1.3.0"}},
+		pushErr: map[string]error{"mirror": errors.New("connection refused")},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Remotes = []config.Remote{{Name: "origin"}, {Name: "gitea"}, {Name: "mirror", Optional: true}}
+
+	if code := a.run(context.Background(), []string{"publish", "v1.3.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := []string{"origin main", "origin v1.3.0", "gitea main", "gitea v1.3.0"}
+	if !reflect.DeepEqual(git.pushed, expected) {
+		t.Errorf("Expected %v, got %v", expected, git.pushed)
+	}
+	if !strings.Contains(stdout.String(), "pushed main and v1.3.0 to gitea\n") {
+		t.Errorf("Expected the pushes reported, got %q", stdout)
+	}
+	if !strings.Contains(stderr.String(), "push to optional remote mirror failed: connection refused") {
+		t.Errorf("Expected a warning for the optional remote, got %q", stderr)
+	}
+	if a.result.Pushed != "origin,gitea" || len(a.result.Pushes) != 3 || a.result.Pushes[2].Error != "connection refused" {
+		t.Errorf("Expected per-remote results, got %q and %+v", a.result.Pushed, a.result.Pushes)
+	}
+
+	git.pushed, git.commits = nil, nil
+	git.pushErr = map[string]error{"gitea": errors.New("permission denied")}
+	a, stdout, stderr = newTestApp(git)
+	a.cfg.Remotes = []config.Remote{{Name: "origin"}, {Name: "gitea"}}
+	if code := a.run(context.Background(), []string{"publish", "v1.3.0"}); code == 0 {
+		t.Fatal("Expected a failed push to fail the publish")
+	}
+	if !reflect.DeepEqual(git.pushed, []string{"origin v1.3.0"}) || !strings.Contains(stdout.String(), "failed to push v1.3.0 to gitea\n") {
+		t.Errorf("Expected the tag pushed to origin only, got %v and %q", git.pushed, stdout)
+	}
+	if !strings.Contains(stderr.String(), "push to gitea: permission denied") {
+		t.Errorf("Expected the failed remote named, got %q", stderr)
+	}
+}
+
+func (f fakePublisher) Publish(ctx context.Context, r publish.Release) (publish.Result, error) {
+	return f.result, nil
+}
+
+func TestOutput(t *testing.T) {
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "feat: x"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"-output", "json", "tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	var r result
+	if err := json.Unmarshal(stdout.Bytes(), &r); err != nil {
+		t.Fatalf("Expected JSON on stdout, got %q: %v", stdout, err)
+	}
+	expected := result{Command: "tag", Version: "1.3.0", Tag: "v1.3.0", PreviousTag: "v1.2.0", Bump: "minor", TagCreated: true}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, r)
+	}
+	if !strings.Contains(stderr.String(), "v1.3.0\n") {
+		t.Errorf("Expected the human output on stderr, got %q", stderr)
+	}
+
+	stdout.Reset()
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return fakePublisher{publish.Result{ID: "1", URL: "https://x/r/1", Uploaded: []string{"dist/app"}}}, nil
+	}
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	if code := a.run(context.Background(), []string{"-output", "yaml", "publish", "-provider", "github", "-repo", "octo/app", "-notes", path, "v1.3.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	want := `command: publish
+dry_run: false
+version: 1.3.0
+tag: v1.3.0
+pushed: origin
+pushes:
+  - remote: origin
+    refs:
+      - v1.3.0
+releases:
+  - provider: github
+    repo: octo/app
+    id: "1"
+    url: https://x/r/1
+    assets:
+      - dist/app
+exit_code: 0
+`
+	if stdout.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stdout)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"-output", "json", "next", "-bump", "huge"}); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	r = result{}
+	json.Unmarshal(stdout.Bytes(), &r)
+	if r.ExitCode != 1 || !strings.Contains(r.Error, "invalid bump level") {
+		t.Errorf("Expected the failure in the result, got %+v", r)
+	}
+
+	if code := a.run(context.Background(), []string{"-output", "xml", "next"}); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown output format, got %d", code)
+	}
+}
+
+func TestStepOutputs(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "github_output")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_OUTPUT", output)
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, _, stderr := newTestApp(&fakeGit{})
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return fakePublisher{publish.Result{ID: "1", URL: "https://x/r/1"}}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-provider", "github", "-repo", "octo/app", "-notes", path, "v1.3.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	got, _ := os.ReadFile(output)
+	expected := "version=1.3.0\ntag=v1.3.0\npushed=origin\nurl=https://x/r/1\nurls=https://x/r/1\n"
+	if string(got) != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestPublishResumed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return fakePublisher{publish.Result{URL: "https://x/r/1", Existing: true, Uploaded: []string{"b"}}}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-provider", "github", "-repo", "octo/app", "-notes", path, "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "pushed v1.0.0 to origin\nresumed https://x/r/1: uploaded 1 missing asset(s)\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestPublishRetryConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, _, stderr := newTestApp(&fakeGit{})
+	a.cfg.Retry = config.RetryConfig{MaxAttempts: 2, InitialDelay: "10ms"}
+	var policy retry.Policy
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		policy = o.Retry
+		return fakePublisher{publish.Result{URL: "https://x/r/1"}}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-provider", "github", "-repo", "octo/app", "-notes", path, "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if policy.MaxAttempts != 2 || policy.Initial != 10*time.Millisecond || policy.Max != retry.Default().Max {
+		t.Errorf("Expected 2 attempts from 10ms, got %+v", policy)
+	}
+
+	policy.OnRetry(1, errors.New("502 Bad Gateway"), time.Second)
+	if !strings.Contains(stderr.String(), "warning: attempt 1 failed, retrying in 1s: 502 Bad Gateway") {
+		t.Errorf("Expected a retry warning, got %q", stderr.String())
+	}
+}
+
+func TestPublishGitHubDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	if err := os.WriteFile(path, []byte("# Changelog\n\n## [1.0.0] - 2026-03-01\n\n- first\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	git := &fakeGit{}
+	a, stdout, stderr := newTestApp(git)
+
+	code := a.run(context.Background(), []string{"publish", "-dry-run", "-provider", "github", "-repo", "octo/app", "-changelog", path, "-asset", "dist/app", "v1.0.0"})
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if len(git.pushed) != 0 {
+		t.Errorf("Expected no push in dry-run mode, got %#v", git.pushed)
+	}
+
+	expected := "[dry-run] would push v1.0.0 to origin\n" +
+		"[dry-run] would create GitHub release v1.0.0 (\"Release v1.0.0\") on octo/app\n" +
+		"[dry-run] would upload asset dist/app\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestPublishProgress(t *testing.T) {
+	defer func(d time.Duration) { progress.RedrawInterval = d }(progress.RedrawInterval)
+	progress.RedrawInterval = time.Hour
+	path := filepath.Join(t.TempDir(), "notes.md")
+	writeTestFile(t, path, "notes")
+	args := []string{"publish", "-dry-run", "-provider", "github", "-repo", "octo/app", "-notes", path, "v1.0.0"}
+
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	if code := a.run(context.Background(), args); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, stage := range []string{"analysis", "push", "publish github octo/app", "notify"} {
+		if !strings.Contains(stderr.String(), "stage "+stage+"\n") {
+			t.Errorf("Expected the %s stage logged, got %q", stage, stderr)
+		}
+	}
+	if strings.Contains(stdout.String(), "stage") {
+		t.Errorf("Expected the stages kept out of the output, got %q", stdout)
+	}
+
+	var display bytes.Buffer
+	a, stdout, stderr = newTestApp(&fakeGit{})
+	a.ui = progress.NewTerminal(&display, 80)
+	a.stdout = a.ui.Output(stdout)
+	if code := a.run(context.Background(), args); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	final := display.String()[strings.LastIndex(display.String(), "\x1b[J")+len("\x1b[J"):]
+	for _, stage := range []string{"✓ analysis", "✓ push", "✓ publish github octo/app", "✓ notify"} {
+		if !strings.Contains(final, stage) {
+			t.Errorf("Expected %q in the display, got %q", stage, final)
+		}
+	}
+	if strings.Contains(stderr.String(), "stage") || !strings.Contains(stdout.String(), "[dry-run] would push v1.0.0 to origin\n") {
+		t.Errorf("Expected the output printed above the display, got %q and %q", stdout, stderr)
+	}
+
+	if code := a.run(context.Background(), []string{"-progress", "plain", "next"}); code == 2 || a.ui != nil {
+		t.Errorf("Expected -progress plain to drop the display, got %d", code)
+	}
+	if code := a.run(context.Background(), []string{"-progress", "fancy", "next"}); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown progress mode, got %d", code)
+	}
+}
+
+func TestPublishBuildsArtifactsDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.cfg.Artifacts = config.ArtifactsConfig{Package: "./cmd/app", Targets: []string{"linux/amd64"}}
+
+	code := a.run(context.Background(), []string{"publish", "-dry-run", "-provider", "github", "-repo", "octo/app", "-notes", path, "v1.0.0"})
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "[dry-run] would build dist/app_1.0.0_linux_amd64 for linux/amd64\n" +
+		"[dry-run] would write dist/SHA256SUMS\n" +
+		"[dry-run] would push v1.0.0 to origin\n" +
+		"[dry-run] would create GitHub release v1.0.0 (\"Release v1.0.0\") on octo/app\n" +
+		"[dry-run] would upload asset dist/app_1.0.0_linux_amd64\n" +
+		"[dry-run] would upload asset dist/SHA256SUMS\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestBuildDryRun(t *testing.T) {
+	a, stdout, stderr := newTestApp(&fakeGit{})
+
+	code := a.run(context.Background(), []string{"build", "-dry-run", "-package", "./cmd/app", "-targets", "linux/amd64,windows/arm64", "-sign", "cosign", "v2.1.0"})
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "[dry-run] would build dist/app_2.1.0_linux_amd64 for linux/amd64\n" +
+		"[dry-run] would build dist/app_2.1.0_windows_arm64.exe for windows/arm64\n" +
+		"[dry-run] would write dist/SHA256SUMS\n" +
+		"[dry-run] would sign dist/SHA256SUMS with cosign\n" +
+		"dist/app_2.1.0_linux_amd64\n" +
+		"dist/app_2.1.0_windows_arm64.exe\n" +
+		"dist/SHA256SUMS\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	if code := a.run(context.Background(), []string{"build", "-package", "./cmd/app", "-targets", "linux", "v2.1.0"}); code != 1 {
+		t.Errorf("Expected exit code 1 for an invalid target, got %d", code)
+	}
+	if code := a.run(context.Background(), []string{"build", "-dry-run", "-package", "./cmd/app", "-targets", "linux/amd64", "-sbom", "swid", "v2.1.0"}); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown SBOM format, got %d", code)
+	}
+}
+
+func TestBuildProvenance(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_REPOSITORY", "octo/app")
+	t.Setenv("GITHUB_SERVER_URL", "")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "")
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.cfg.Artifacts.Provenance = config.ProvenanceConfig{Enabled: true, Sign: "cosign"}
+
+	code := a.run(context.Background(), []string{"build", "-dry-run", "-package", "./cmd/app", "-targets", "linux/amd64", "-sbom", "spdx", "v2.1.0"})
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "[dry-run] would build dist/app_2.1.0_linux_amd64 for linux/amd64\n" +
+		"[dry-run] would write dist/app_2.1.0_linux_amd64.spdx.json\n" +
+		"[dry-run] would write dist/SHA256SUMS\n" +
+		"[dry-run] would write dist/provenance.intoto.jsonl\n" +
+		"[dry-run] would sign dist/provenance.intoto.jsonl with cosign\n" +
+		"dist/app_2.1.0_linux_amd64\n" +
+		"dist/app_2.1.0_linux_amd64.spdx.json\n" +
+		"dist/SHA256SUMS\n" +
+		"dist/provenance.intoto.jsonl\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	p, err := a.provenance(context.Background(), a.cfg.Artifacts.Provenance, "v2.1.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := artifacts.Provenance{
+		BuilderID:    "https://github.com/actions/runner",
+		Repo:         "https://github.com/octo/app",
+		Ref:          "refs/tags/v2.1.0",
+		Commit:       "0000000000000000000000000000000000000000",
+		InvocationID: "https://github.com/octo/app/actions/runs/42",
+	}
+	signer, _ := p.Signer.(*artifacts.Cosign)
+	p.Signer = nil
+	if *p != want || signer == nil || !signer.Bundle {
+		t.Errorf("Expected %+v signed into a bundle, got %+v (%+v)", want, *p, signer)
+	}
+
+	a.cfg.Artifacts.Provenance.BuilderID = "https://builder.example.com"
+	if p, _ := a.provenance(context.Background(), a.cfg.Artifacts.Provenance, "v2.1.0"); p.BuilderID != "https://builder.example.com" {
+		t.Errorf("Expected the configured builder, got %s", p.BuilderID)
+	}
+}
+
+func TestPublishNotifies(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+	}))
+	defer srv.Close()
+	t.Setenv("TEST_SLACK_URL", srv.URL)
+
+	git := &fakeGit{}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Notify = []config.NotifyTarget{
+		{Type: "slack", URL: "$TEST_SLACK_URL"},
+		{Type: "discord", URL: "${TEST_SLACK_URL}/failures", On: []string{"failure"}},
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if len(bodies) != 1 || bodies[0] != `{"text":"Released v1.0.0"}` {
+		t.Errorf("Expected one success notification, got %v", bodies)
+	}
+
+	bodies = nil
+	if code := a.run(context.Background(), []string{"publish", "-provider", "bitbucket", "-repo", "x/y", "-notes", "/nonexistent", "v1.0.1"}); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if len(bodies) != 2 || !strings.Contains(bodies[1], "Release v1.0.1 failed: ") {
+		t.Errorf("Expected failure notifications, got %v", bodies)
+	}
+}
+
+func TestPublishNotifyFailureWarns(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	a, _, stderr := newTestApp(&fakeGit{})
+	a.cfg.Notify = []config.NotifyTarget{{Type: "webhook", URL: "not a url"}}
+
+	if code := a.run(context.Background(), []string{"publish", "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr.String(), "warning: notify: invalid webhook URL") {
+		t.Errorf("Expected a notification warning, got %q", stderr.String())
+	}
+}
+
+func TestPublishConfigTargets(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.cfg.Publish = []config.PublishTarget{
+		{Provider: "github", Repo: "octo/app", Assets: []string{"dist/a"}},
+		{Provider: "github", Repo: "octo/mirror"},
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-dry-run", "-notes", path, "-asset", "dist/b", "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "[dry-run] would push v1.0.0 to origin\n" +
+		"[dry-run] would create GitHub release v1.0.0 (\"Release v1.0.0\") on octo/app\n" +
+		"[dry-run] would upload asset dist/a\n" +
+		"[dry-run] would upload asset dist/b\n" +
+		"[dry-run] would create GitHub release v1.0.0 (\"Release v1.0.0\") on octo/mirror\n" +
+		"[dry-run] would upload asset dist/b\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestPublishTitle(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	git := &fakeGit{
+		tags:   []string{"v1.0.0", "v1.1.0"},
+		tagged: map[string]string{"v1.0.0": "a", "v1.1.0": "c"},
+		commits: []gitrepo.Commit{
+			{Hash: "c", Parents: []string{"b"}, Message: "fix: y"},
+			{Hash: "b", Parents: []string{"a"}, Message: "feat: x"},
+			{Hash: "a", Message: "feat: first"},
+		},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Publish = []config.PublishTarget{
+		{Provider: "github", Repo: "octo/app", Title: "MyApp v{{.Version}} — {{.Date}} ({{.Stats.Commits}} commits since {{.PreviousTag}})"},
+		{Provider: "github", Repo: "octo/mirror"},
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-dry-run", "-notes", path, "v1.1.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, want := range []string{
+		`would create GitHub release v1.1.0 ("MyApp v1.1.0 — 2026-03-01 (2 commits since v1.0.0)") on octo/app`,
+		`would create GitHub release v1.1.0 ("Release v1.1.0") on octo/mirror`,
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in %q", want, stdout)
+		}
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"publish", "-dry-run", "-notes", path, "-title", "{{.Tag}}", "v1.1.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if strings.Count(stdout.String(), `("v1.1.0")`) != 2 {
+		t.Errorf("Expected -title to name every release, got %q", stdout)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"publish", "-notes", path, "-title", "{{.Nope}}", "v1.1.0"}); code == 0 || len(git.pushed) != 0 {
+		t.Errorf("Expected a broken title to stop before pushing, got %d and %v", code, git.pushed)
+	}
+}
+
+// TestPluginHelperProcess is run as a plugin by the tests below. It
+// appends what it receives to the file named by PLUGIN_HELPER_LOG.
+func TestPluginHelperProcess(t *testing.T) {
+	log := os.Getenv("PLUGIN_HELPER_LOG")
+	if log == "" {
+		return
+	}
+	record := func(line string) error {
+		f, err := os.OpenFile(log, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = fmt.Fprintln(f, line)
+		return err
+	}
+	plugin.Serve("helper", "", plugin.Handlers{
+		Publish: func(_ context.Context, p plugin.PublishParams) (plugin.PublishResult, error) {
+			return plugin.PublishResult{URL: "plugin://" + p.Repo + "/" + p.Tag}, record("publish " + p.Repo + " " + p.Tag + " " + p.Body)
+		},
+		Notify: func(_ context.Context, p notify.Payload) error {
+			return record("notify " + string(p.Status) + " " + p.Tag)
+		},
+	})
+	os.Exit(0)
+}
+
+func TestPublishPlugin(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "plugin.log")
+	t.Setenv("PLUGIN_HELPER_LOG", log)
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.cfg.Plugins = []config.PluginConfig{{Name: "helper", Command: os.Args[0], Args: []string{"-test.run=TestPluginHelperProcess"}}}
+	a.cfg.Publish = []config.PublishTarget{{Provider: "helper", Repo: "bucket"}}
+	a.cfg.Notify = []config.NotifyTarget{{Type: "helper"}}
+
+	if code := a.run(context.Background(), []string{"publish", "-notes", path, "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "published plugin://bucket/v1.0.0\n") {
+		t.Errorf("Expected plugin release URL, got %q", stdout.String())
+	}
+	b, _ := os.ReadFile(log)
+	if expected := "publish bucket v1.0.0 notes\nnotify success v1.0.0\n"; string(b) != expected {
+		t.Errorf("Expected %q, got %q", expected, b)
+	}
+}
+
+func TestPublishPluginDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.cfg.Plugins = []config.PluginConfig{{Name: "s3", Command: "/nonexistent/plugin"}}
+	a.cfg.Notify = []config.NotifyTarget{{Type: "s3"}}
+
+	if code := a.run(context.Background(), []string{"publish", "-dry-run", "-provider", "s3", "-repo", "bucket", "-notes", path, "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "[dry-run] would push v1.0.0 to origin\n" +
+		"[dry-run] would publish v1.0.0 with plugin s3\n" +
+		"[dry-run] would notify s3: Released v1.0.0\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-provider", "s3", "-repo", "bucket", "-notes", path, "v1.0.0"}); code != 8 {
+		t.Errorf("Expected exit code 8 when the plugin cannot start, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "plugin: start /nonexistent/plugin") {
+		t.Errorf("Expected plugin start error, got %q", stderr.String())
+	}
+}
+
+func TestPublishMissingNotes(t *testing.T) {
+	a, _, stderr := newTestApp(&fakeGit{})
+
+	code := a.run(context.Background(), []string{"publish", "-dry-run", "-provider", "github", "-repo", "octo/app", "-changelog", filepath.Join(t.TempDir(), "missing.md"), "v1.0.0"})
+	if code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "read release notes") {
+		t.Errorf("Expected release notes error, got %q", stderr.String())
+	}
+}
+
+func TestPublishUnknownProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, _, stderr := newTestApp(&fakeGit{})
+
+	if code := a.run(context.Background(), []string{"publish", "-dry-run", "-provider", "bitbucket", "-notes", path, "v1.0.0"}); code != 8 {
+		t.Errorf("Expected exit code 8, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown provider") {
+		t.Errorf("Expected unknown provider error, got %q", stderr.String())
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "abc", Message: "feat: x"}}}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Hooks = map[string][]string{"pre-bump": {"true"}, "post-publish": {"exit 1"}}
+
+	if code := a.run(context.Background(), []string{"-verbose", "tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if expected := "debug: running hook stage=pre-bump hook=true\n"; stderr.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stderr.String())
+	}
+
+	stderr.Reset()
+	if code := a.run(context.Background(), []string{"-quiet", "publish", "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected warnings to be suppressed, got %q", stderr.String())
+	}
+
+	if code := a.run(context.Background(), []string{"-verbose", "-quiet", "next"}); code != 2 {
+		t.Errorf("Expected exit code 2 for -verbose with -quiet, got %d", code)
+	}
+	if code := a.run(context.Background(), []string{"-log-format", "xml", "next"}); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown log format, got %d", code)
+	}
+}
+
+func TestLogJSONRedactsSecrets(t *testing.T) {
+	t.Setenv(rlog.EnvFormat, "json")
+	t.Setenv("GITLAB_TOKEN", "glpat-s3cr3t")
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, _, stderr := newTestApp(&fakeGit{})
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return nil, fmt.Errorf("token glpat-s3cr3t rejected")
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-provider", "gitlab", "-repo", "g/p", "-notes", path, "v1.0.0"}); code != 8 {
+		t.Fatalf("Expected exit code 8, got %d", code)
+	}
+	records := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	var record map[string]any
+	if err := json.Unmarshal([]byte(records[len(records)-1]), &record); err != nil {
+		t.Fatalf("Expected JSON log records, got %q: %v", stderr.String(), err)
+	}
+	if record["level"] != "ERROR" || record["msg"] != "release publish: token [REDACTED] rejected" {
+		t.Errorf("Expected a redacted error record, got %v", record)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	git := &fakeGit{commits: []gitrepo.Commit{{Hash: "abc", Message: "feat: x"}}}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Hooks = map[string][]string{"pre-bump": {"sleep 5"}}
+
+	if code := a.run(context.Background(), []string{"-timeout", "50ms", "tag"}); code != 124 {
+		t.Fatalf("Expected exit code 124, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), context.DeadlineExceeded.Error()) {
+		t.Errorf("Expected a deadline error, got %q", stderr.String())
+	}
+	if len(git.created) != 0 {
+		t.Errorf("Expected no tag after the timeout, got %#v", git.created)
+	}
+}
+
+func TestDetectProvider(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "group/app")
+
+	provider, repo := detectProvider()
+	if provider != "gitlab" || repo != "group/app" {
+		t.Errorf("Expected gitlab group/app, got %s %s", provider, repo)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	a, _, stderr := newTestApp(&fakeGit{})
+
+	if code := a.run(context.Background(), []string{"bogus"}); code != 2 {
+		t.Errorf("Expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown command") {
+		t.Errorf("Expected unknown command message, got %q", stderr.String())
+	}
+}
+
+type fakeStatusPublisher struct {
+	fakePublisher
+	status publish.CIStatus
+}
+
+func (f fakeStatusPublisher) CIStatus(context.Context, string) (publish.CIStatus, error) {
+	return f.status, nil
+}
+
+type fakeCommentPublisher struct {
+	fakePublisher
+	comments map[int]string
+}
+
+func (f fakeCommentPublisher) UpsertComment(_ context.Context, number int, marker, body string) (publish.Comment, error) {
+	_, updated := f.comments[number]
+	if !strings.HasPrefix(body, marker) {
+		return publish.Comment{}, fmt.Errorf("body %q lacks the marker", body)
+	}
+	f.comments[number] = body
+	return publish.Comment{URL: fmt.Sprintf("https://github.com/octo/app/pull/%d#issuecomment-1", number), Updated: updated}, nil
+}
+
+func TestPreview(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "feat: add search"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"preview"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "### Release preview\n\nMerging would release **v1.3.0**, a minor release after v1.2.0.\n\n" +
+		"<details>\n<summary>Changelog</summary>\n\n## [1.3.0] - 2026-03-01\n\n### Features\n\n- add search (a)\n\n</details>\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	if code := a.run(context.Background(), []string{"preview", "-pr"}); code != 2 {
+		t.Errorf("Expected exit code 2 without a pull request, got %d", code)
+	}
+
+	git.commits = []gitrepo.Commit{{Hash: "b", Message: "docs: typo"}}
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"preview"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if expected := "### Release preview\n\nMerging would not release a new version: no commit since v1.2.0 bumps it.\n"; stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestPreviewPR(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_REPOSITORY", "octo/app")
+	t.Setenv("GITHUB_EVENT_NAME", "pull_request")
+	t.Setenv("GITHUB_REF", "refs/pull/12/merge")
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "fix: crash"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	comments := map[int]string{}
+	var targets []string
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		targets = append(targets, provider+" "+repo)
+		return fakeCommentPublisher{comments: comments}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"preview", "-pr"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.HasPrefix(comments[12], previewMarker+"\n### Release preview\n\nMerging would release **v1.2.1**") {
+		t.Errorf("Expected the preview on #12, got %q", comments)
+	}
+	if !reflect.DeepEqual(targets, []string{"github octo/app"}) {
+		t.Errorf("Expected the CI repository, got %v", targets)
+	}
+	if stdout.String() != "commented https://github.com/octo/app/pull/12#issuecomment-1\n" {
+		t.Errorf("Unexpected output %q", stdout)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"preview", "-pr"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "updated https://github.com/octo/app/pull/12#issuecomment-1\n" || len(comments) != 1 {
+		t.Errorf("Expected the comment updated, got %q and %v", stdout, comments)
+	}
+
+	a.cfg.Publish = []config.PublishTarget{{Provider: "docker", Repo: "octo/app"}}
+	if code := a.run(context.Background(), []string{"preview", "-pr", "-number", "3"}); code != 1 {
+		t.Errorf("Expected exit code 1 without a GitHub or GitLab target, got %d", code)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return fakeStatusPublisher{status: publish.CIStatus{State: publish.CISuccess}}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"preflight", "-ci"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "✓ clean working tree\n✓ release branch\n✓ up to date with origin\n✓ CI passing\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestPreflightFailures(t *testing.T) {
+	git := &fakeGit{branch: "feature/x", dirty: []string{"go.mod"}, behind: 2}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Channels = []config.ChannelConfig{{Branch: "release/*", Channel: "rc"}}
+	a.cfg.Preflight.RequiredFiles = []string{"NOTICE"}
+
+	if code := a.run(context.Background(), []string{"preflight"}); code != 4 {
+		t.Fatalf("Expected exit code 4, got %d", code)
+	}
+	if strings.Count(stdout.String(), "✗") != 4 {
+		t.Errorf("Expected 4 failed checks, got %q", stdout.String())
+	}
+	for _, want := range []string{
+		"uncommitted changes in go.mod",
+		"check out one of main, release/*",
+		"run git pull origin feature/x",
+		"required files are missing: NOTICE",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("Expected stderr to contain %q, got %q", want, stderr.String())
+		}
+	}
+
+	stdout.Reset()
+	a.run(context.Background(), []string{"-output", "json", "preflight"})
+	var r result
+	if err := json.Unmarshal(stdout.Bytes(), &r); err != nil {
+		t.Fatalf("Expected JSON on stdout, got %q: %v", stdout, err)
+	}
+	if len(r.Checks) != 4 || r.Checks[0].OK || !strings.Contains(r.Checks[0].Problem, "go.mod") || r.ExitCode != 4 {
+		t.Errorf("Expected 4 failed checks with exit code 4, got %+v", r)
+	}
+}
+
+func TestPreflightPolicy(t *testing.T) {
+	git := &fakeGit{commits: []gitrepo.Commit{
+		{Hash: "c2", Parents: []string{"c1"}, Message: "feat: export (#4)"},
+		{Hash: "c1", Parents: []string{"c0"}, Message: "fix: hotfix"},
+	}}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Preflight.AllowBehind = true
+	a.cfg.Preflight.Policy = config.PolicyConfig{ReleaseNoteTypes: []string{"feat"}, ProtectedBranches: []string{"main"}}
+
+	// Without strict, violations only warn.
+	if code := a.run(context.Background(), []string{"preflight"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "✓ release policies") || !strings.Contains(stderr.String(), "policy: direct-push: c1") {
+		t.Errorf("Expected the violations warned about, got %q and %q", stdout, stderr)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := a.run(context.Background(), []string{"-output", "json", "preflight", "-strict"}); code != 4 {
+		t.Fatalf("Expected exit code 4, got %d: %s", code, stderr)
+	}
+	var r result
+	if err := json.Unmarshal(stdout.Bytes(), &r); err != nil {
+		t.Fatalf("Expected JSON on stdout, got %q: %v", stdout, err)
+	}
+	expected := []policy.Diagnostic{
+		{Rule: policy.RuleReleaseNote, Commit: "c2", Message: `feat commit "feat: export (#4)" has no Release-Note trailer wording its changelog entry`},
+		{Rule: policy.RuleDirectPush, Commit: "c1", Message: `"fix: hotfix" was pushed to main directly instead of merged`},
+	}
+	if !reflect.DeepEqual(r.Diagnostics, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, r.Diagnostics)
+	}
+	if !strings.Contains(stderr.String(), "release policy violated 2 time(s):\n    - release-note: c2") {
+		t.Errorf("Expected the violations listed, got %q", stderr.String())
+	}
+}
+
+func TestPreflightBeforeTag(t *testing.T) {
+	git := &fakeGit{dirty: []string{"main.go"}, commits: []gitrepo.Commit{{Hash: "aaa", Message: "feat: x"}}}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Preflight = config.PreflightConfig{Before: []string{"tag"}, AllowBehind: true}
+
+	if code := a.run(context.Background(), []string{"tag"}); code != 4 {
+		t.Fatalf("Expected exit code 4, got %d", code)
+	}
+	if len(git.created) != 0 {
+		t.Errorf("Expected no tag, got %v", git.created)
+	}
+	if !strings.Contains(stderr.String(), "preflight checks failed") {
+		t.Errorf("Expected a preflight failure, got %q", stderr.String())
+	}
+
+	a.cfg.Preflight.AllowDirty = true
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+}
+
+func TestPreflightCIUnsupported(t *testing.T) {
+	a, _, stderr := newTestApp(&fakeGit{})
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return fakePublisher{}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"preflight", "-ci"}); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "provider github cannot report CI status") {
+		t.Errorf("Expected an unsupported provider error, got %q", stderr.String())
+	}
+}
+
+func TestTagProtection(t *testing.T) {
+	newGit := func() *fakeGit {
+		return &fakeGit{
+			tags:       []string{"v1.2.0"},
+			commits:    []gitrepo.Commit{{Hash: "a", Message: "feat: x"}},
+			remoteTags: []gitrepo.Tag{{Name: "v1.3.0", Commit: "1111111111", Annotated: true}},
+		}
+	}
+
+	git := newGit()
+	a, _, stderr := newTestApp(git)
+	if code := a.run(context.Background(), []string{"tag"}); code != 5 {
+		t.Fatalf("Expected exit code 5, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "tag v1.3.0 on origin points at 1111111, not 0000000") || len(git.created) != 0 {
+		t.Errorf("Expected the tag refused, got %q and %v", stderr, git.created)
+	}
+
+	git = newGit()
+	a, stdout, stderr := newTestApp(git)
+	if code := a.run(context.Background(), []string{"tag", "-force-retag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !reflect.DeepEqual(git.deleted, []string{"origin v1.3.0"}) || git.created["v1.3.0"] == "" || stdout.String() != "v1.3.0\n" {
+		t.Errorf("Expected the remote tag deleted and the tag created, got %v and %v", git.deleted, git.created)
+	}
+	if !strings.Contains(stderr.String(), "moving it (-force-retag)") {
+		t.Errorf("Expected a warning, got %q", stderr)
+	}
+
+	git = newGit()
+	git.remoteTags[0].Annotated, git.remoteTags[0].Commit = false, "0000000000000000000000000000000000000000"
+	a, _, stderr = newTestApp(git)
+	if code := a.run(context.Background(), []string{"tag"}); code != 5 || !strings.Contains(stderr.String(), "tag v1.3.0 on origin is lightweight") {
+		t.Errorf("Expected a lightweight tag refused, got %d: %q", code, stderr)
+	}
+	a, _, stderr = newTestApp(git)
+	if code := a.run(context.Background(), []string{"tag", "-bump-files"}); code != 5 || !strings.Contains(stderr.String(), "tagging a new commit would move it") {
+		t.Errorf("Expected the bump commit refused, got %d: %q", code, stderr)
+	}
+
+	git = newGit()
+	git.remoteErr = errors.New("could not read from remote repository")
+	a, _, stderr = newTestApp(git)
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr.String(), "cannot list the tags of origin, checking local tags only") {
+		t.Errorf("Expected a warning, got %q", stderr)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := map[string]struct {
+		git  *fakeGit
+		args []string
+		code int
+	}{
+		"tag exists": {
+			git:  &fakeGit{created: map[string]string{"v1.0.1": ""}, tags: []string{"v1.0.0"}, commits: []gitrepo.Commit{{Hash: "aaa", Message: "fix: x"}}},
+			args: []string{"tag"},
+			code: 5,
+		},
+		"usage":     {git: &fakeGit{}, args: []string{"build"}, code: 2},
+		"cancelled": {git: &fakeGit{}, args: []string{"next"}, code: 130},
+	}
+	for name, tt := range tests {
+		a, _, _ := newTestApp(tt.git)
+		ctx := context.Background()
+		if name == "cancelled" {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(ctx)
+			cancel()
+			a.git = cancelledGit{tt.git}
+		}
+		if code := a.run(ctx, tt.args); code != tt.code {
+			t.Errorf("%s: Expected exit code %d, got %d", name, tt.code, code)
+		}
+	}
+}
+
+func TestFailOn(t *testing.T) {
+	newGit := func() *fakeGit {
+		return &fakeGit{tags: []string{"v1.0.0"}, commits: []gitrepo.Commit{{Hash: "aaa", Message: "fix: x"}}, remoteErr: errors.New("could not read from remote repository")}
+	}
+	a, _, stderr := newTestApp(newGit())
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Errorf("Expected warnings not to fail by default, got %d: %s", code, stderr)
+	}
+	a, _, stderr = newTestApp(newGit())
+	if code := a.run(context.Background(), []string{"-fail-on", "warnings", "tag"}); code != 11 || !strings.Contains(stderr.String(), "1 warning(s)") {
+		t.Errorf("Expected exit code 11 for a warning, got %d: %q", code, stderr)
+	}
+	t.Setenv("RELEASE_FAIL_ON", "warnings")
+	a, _, _ = newTestApp(&fakeGit{tags: []string{"v1.0.0"}, commits: []gitrepo.Commit{{Hash: "aaa", Message: "fix: x"}}})
+	if code := a.run(context.Background(), []string{"next"}); code != 0 {
+		t.Errorf("Expected exit code 0 without warnings, got %d", code)
+	}
+
+	nothing := &fakeGit{commits: []gitrepo.Commit{{Hash: "a", Message: "docs: readme"}}}
+	a, _, _ = newTestApp(nothing)
+	if code := a.run(context.Background(), []string{"-fail-on", "none", "next"}); code != 0 {
+		t.Errorf("Expected nothing to release to succeed, got %d", code)
+	}
+	a, _, _ = newTestApp(nothing)
+	if code := a.run(context.Background(), []string{"-fail-on", "errors", "next"}); code != 3 {
+		t.Errorf("Expected exit code 3, got %d", code)
+	}
+	a, _, stderr = newTestApp(nothing)
+	if code := a.run(context.Background(), []string{"-fail-on", "all", "next"}); code != 2 || !strings.Contains(stderr.String(), "unknown -fail-on mode") {
+		t.Errorf("Expected an unknown mode refused, got %d: %q", code, stderr)
+	}
+}
+
+func TestPublishPartial(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	upload := errors.New("upload app.tar.gz: 502 Bad Gateway")
+	tests := map[string]struct {
+		targets []config.PublishTarget
+		code    int
+	}{
+		"failed first":        {targets: []config.PublishTarget{{Provider: "docker", Repo: "broken"}, {Provider: "docker", Repo: "ok"}}, code: 8},
+		"failed after others": {targets: []config.PublishTarget{{Provider: "docker", Repo: "ok"}, {Provider: "docker", Repo: "broken"}}, code: 10},
+		"missing assets":      {targets: []config.PublishTarget{{Provider: "docker", Repo: "created"}}, code: 10},
+	}
+	for name, tt := range tests {
+		a, _, stderr := newTestApp(&fakeGit{})
+		a.cfg.Publish = tt.targets
+		a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+			return publisherFunc(func(ctx context.Context, r publish.Release) (publish.Result, error) {
+				switch repo {
+				case "broken":
+					return publish.Result{}, upload
+				case "created":
+					return publish.Result{ID: "7", URL: "https://example.com/releases/7"}, upload
+				}
+				return publish.Result{ID: repo}, nil
+			}), nil
+		}
+		code := a.run(context.Background(), []string{"publish", "-build=false", "-notes", path, "v1.0.0"})
+		if code != tt.code {
+			t.Errorf("%s: Expected exit code %d, got %d: %s", name, tt.code, code, stderr)
+		}
+		if code == 10 && !strings.Contains(stderr.String(), "releasing v1.0.0 again resumes it") {
+			t.Errorf("%s: Expected how to resume in %q", name, stderr)
+		}
+	}
+}
+
+// cancelledGit fails every lookup with the error of its context.
+type cancelledGit struct{ *fakeGit }
+
+func (g cancelledGit) Tags(ctx context.Context) ([]gitrepo.Tag, error) {
+	return nil, fmt.Errorf("git for-each-ref: %w", ctx.Err())
+}
+
+func TestPublishDocker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.cfg.Tag.Prefix = "app/v"
+	a.cfg.Publish = []config.PublishTarget{{Provider: "docker", Repo: "ghcr.io/org/app", Source: "app:dev", Tags: []string{"{{ .Version }}", "{{ .Major }}"}}}
+	var opts publisherOptions
+	var release publish.Release
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		opts = o
+		return publisherFunc(func(ctx context.Context, r publish.Release) (publish.Result, error) {
+			release = r
+			return publish.Result{ID: repo + ":" + r.Version}, nil
+		}), nil
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-notes", path, "app/v1.2.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if opts.Source != "app:dev" || !slices.Equal(opts.Tags, []string{"{{ .Version }}", "{{ .Major }}"}) {
+		t.Errorf("Expected the target's source and tags, got %q and %q", opts.Source, opts.Tags)
+	}
+	if release.Version != "1.2.0" {
+		t.Errorf("Expected version 1.2.0, got %q", release.Version)
+	}
+	if !strings.Contains(stdout.String(), "published ghcr.io/org/app:1.2.0") {
+		t.Errorf("Expected the pushed image in %q", stdout)
+	}
+}
+
+func TestPublishHomebrew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	t.Setenv("GITHUB_REPOSITORY", "")
+	a, _, stderr := newTestApp(&fakeGit{})
+	a.cfg.Artifacts.Package = "./cmd/app"
+	a.cfg.Publish = []config.PublishTarget{
+		{Provider: "github", Repo: "octo/app"},
+		{Provider: "homebrew", Repo: "octo/homebrew-tap", Homebrew: config.HomebrewConfig{PullRequest: true}},
+	}
+	var formula config.HomebrewConfig
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		if provider == "homebrew" {
+			formula = o.Homebrew
+		}
+		return fakePublisher{}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-build=false", "-notes", path, "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := config.HomebrewConfig{
+		Name:        "app",
+		URL:         "https://github.com/octo/app/releases/download/{{ .Tag }}/{{ .Name }}",
+		PullRequest: true,
+	}
+	if formula != expected {
+		t.Errorf("Expected %+v, got %+v", expected, formula)
+	}
+}
+
+func TestInteractive(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sed -i 's/Bug Fixes/Highlights/'")
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "abc", Message: "fix: handle nil"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Changelog.Path = filepath.Join(t.TempDir(), "CHANGELOG.md")
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}, {Provider: "gitlab", Repo: "octo/app"}}
+	var published []string
+	var body string
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return publisherFunc(func(ctx context.Context, r publish.Release) (publish.Result, error) {
+			published, body = append(published, provider+" "+r.Tag), r.Body
+			return publish.Result{URL: "https://" + provider + "/" + r.Tag}, nil
+		}), nil
+	}
+	a.tty = true
+	// Bump minor, edit the changelog, deselect gitlab, then confirm.
+	a.stdin = strings.NewReader("huge\nminor\ny\n2\n\ny\n")
+
+	if code := a.run(context.Background(), []string{"interactive"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, want := range []string{
+		"v1.2.1: patch release, 1 commit(s) since v1.2.0",
+		`"huge" is not one of patch, minor, major`,
+		"v1.3.0: minor release",
+		"  2. [x] gitlab octo/app",
+		"  2. [ ] gitlab octo/app",
+		"Release v1.3.0: tag and push to origin, publish to github octo/app",
+		"published https://github/v1.3.0",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout)
+		}
+	}
+	if git.created["v1.3.0"] == "" || !slices.Equal(published, []string{"github v1.3.0"}) {
+		t.Errorf("Expected v1.3.0 tagged and published to github only, got %v and %q", git.created, published)
+	}
+	if !slices.Equal(git.committed, []string{"chore(release): changelog for v1.3.0: " + a.cfg.Changelog.Path}) {
+		t.Errorf("Expected the changelog to be committed, got %q", git.committed)
+	}
+	written, _ := os.ReadFile(a.cfg.Changelog.Path)
+	if !strings.Contains(string(written), "Highlights") || !strings.Contains(body, "Highlights") {
+		t.Errorf("Expected the edited section in the changelog and the release, got %q and %q", written, body)
+	}
+}
+
+func TestWizardTUI(t *testing.T) {
+	ask := func(keys string) (*wizard, *bytes.Buffer) {
+		var out bytes.Buffer
+		return &wizard{out: &out, ask: true, tui: strings.NewReader(keys), ctx: context.Background()}, &out
+	}
+
+	w, out := ask("\x1b[C\r")
+	if level, err := w.choose("Bump level", []string{"patch", "minor", "major"}, "patch"); err != nil || level != "minor" {
+		t.Errorf("Expected minor one step right of patch, got %q (err=%v)", level, err)
+	}
+	if !strings.Contains(out.String(), "Bump level: minor") {
+		t.Errorf("Expected the choice left on the terminal, got %q", out)
+	}
+
+	w, _ = ask("\x1b[B \r")
+	selected := []bool{true, true}
+	if err := w.toggle("Publish to", []string{"github octo/app", "gitlab octo/app"}, selected); err != nil || !slices.Equal(selected, []bool{true, false}) {
+		t.Errorf("Expected gitlab deselected, got %v (err=%v)", selected, err)
+	}
+
+	w, _ = ask("y")
+	if ok, err := w.confirm("Go ahead?", false); err != nil || !ok {
+		t.Errorf("Expected y to confirm, got %v (err=%v)", ok, err)
+	}
+	w, _ = ask("\r")
+	if ok, err := w.confirm("Go ahead?", false); err != nil || ok {
+		t.Errorf("Expected enter to take the default, got %v (err=%v)", ok, err)
+	}
+	w, _ = ask("\x1b")
+	if _, err := w.confirm("Go ahead?", true); !errors.Is(err, errAborted) {
+		t.Errorf("Expected esc to abort, got %v", err)
+	}
+}
+
+func TestInteractiveWithoutTerminal(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		commits: []gitrepo.Commit{{Hash: "abc", Message: "feat: thing"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Changelog.Path = filepath.Join(t.TempDir(), "CHANGELOG.md")
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}, {Provider: "gitlab", Repo: "octo/app"}}
+	var published []string
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return publisherFunc(func(ctx context.Context, r publish.Release) (publish.Result, error) {
+			published = append(published, provider+" "+r.Tag)
+			return publish.Result{}, nil
+		}), nil
+	}
+	a.stdin = strings.NewReader("")
+
+	if code := a.run(context.Background(), []string{"interactive"}); code != 2 {
+		t.Errorf("Expected exit code 2 without a terminal or -yes, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "pass -yes") {
+		t.Errorf("Expected advice to pass -yes, got %q", stderr)
+	}
+
+	if code := a.run(context.Background(), []string{"interactive", "-yes", "-bump", "patch", "-target", "gitlab"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if git.created["v1.2.1"] == "" || !slices.Equal(published, []string{"gitlab v1.2.1"}) {
+		t.Errorf("Expected v1.2.1 published to gitlab only, got %v and %q", git.created, published)
+	}
+	if strings.Contains(stdout.String(), "[x]") {
+		t.Errorf("Expected no prompts, got:\n%s", stdout)
+	}
+}
+
+func TestLintCommits(t *testing.T) {
+	git := &fakeGit{
+		tags: []string{"v1.2.0", "v1.3.0-rc.1"},
+		commits: []gitrepo.Commit{
+			{Hash: "aaaaaaaa1", Message: "feat: add batch", Parents: []string{"p"}},
+			{Hash: "bbbbbbbb2", Message: "Merge branch 'main' into batch", Parents: []string{"p", "q"}},
+			{Hash: "cccccccc3", Message: "Fix the bug", Parents: []string{"p"}},
+			{Hash: "dddddddd4", Message: "chore: tidy", Parents: []string{"p"}},
+		},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Commits.Lint.Types = []string{"feat", "fix"}
+
+	if code := a.run(context.Background(), []string{"lint-commits"}); code != 4 {
+		t.Errorf("Expected exit code 4, got %d", code)
+	}
+	if git.since != "v1.3.0-rc.1..HEAD" {
+		t.Errorf("Expected the commits since the latest tag, got %q", git.since)
+	}
+	expected := "✗ ccccccc Fix the bug\n    header \"Fix the bug\" has no type: expected \"<type>[(<scope>)][!]: <description>\", e.g. \"fix: Fix the bug\"\n" +
+		"✗ ddddddd chore: tidy\n    type \"chore\" is not one of feat, fix\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "2 of 3 commit(s) in v1.3.0-rc.1..HEAD") {
+		t.Errorf("Expected a summary of the failures, got %q", stderr)
+	}
+
+	git.commits = git.commits[:2]
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"lint-commits", "origin/main"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if git.since != "origin/main..HEAD" || stdout.String() != "✓ 1 commit(s) in origin/main..HEAD\n" {
+		t.Errorf("Expected 1 commit since origin/main, got %q in %q", stdout.String(), git.since)
+	}
+
+	a.cfg.Commits.Lint.Rules = []config.LintRule{{Pattern: `^[A-Z]+-\d+ `, Message: "start with the ticket"}}
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"lint-commits", "-merges", "a..b"}); code != 4 {
+		t.Errorf("Expected exit code 4, got %d", code)
+	}
+	if strings.Count(stdout.String(), "start with the ticket") != 2 || git.since != "a..b" {
+		t.Errorf("Expected both commits to break the rule in a..b, got %q in %q", stdout.String(), git.since)
+	}
+}
+
+func TestLintCommitsPullRequest(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_REF", "refs/pull/5/merge")
+	t.Setenv("GITHUB_BASE_REF", "main")
+	git := &fakeGit{tags: []string{"v1.2.0"}, commits: []gitrepo.Commit{{Hash: "a", Message: "fix: x"}}}
+	a, _, stderr := newTestApp(git)
+
+	if code := a.run(context.Background(), []string{"lint-commits"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if git.since != "origin/main..HEAD" {
+		t.Errorf("Expected the commits of the pull request, got %q", git.since)
+	}
+}
+
+// publisherFunc adapts a function to publish.Publisher.
+type publisherFunc func(ctx context.Context, r publish.Release) (publish.Result, error)
+
+func (f publisherFunc) Publish(ctx context.Context, r publish.Release) (publish.Result, error) {
+	return f(ctx, r)
+}
+
+func TestPublishConcurrency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app", Concurrency: 2}}
+	var uploads publish.Uploader
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		uploads = o.Uploads
+		return fakePublisher{}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"publish", "-notes", path, "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if uploads.Concurrency != 2 {
+		t.Errorf("Expected concurrency 2 from the config, got %d", uploads.Concurrency)
+	}
+	if code := a.run(context.Background(), []string{"publish", "-concurrency", "8", "-notes", path, "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if uploads.Concurrency != 8 {
+		t.Errorf("Expected concurrency 8 from the flag, got %d", uploads.Concurrency)
+	}
+
+	stdout.Reset()
+	uploads.Progress(publish.Progress{Asset: "dist/app", Size: 3 << 19, Elapsed: 1234 * time.Millisecond, Done: 1, Total: 2})
+	uploads.Progress(publish.Progress{Asset: "dist/app.exe", Err: errors.New("boom"), Done: 2, Total: 2})
+	expected := "uploaded dist/app (1.5 MiB in 1.2s, 1.2 MiB/s) [1/2]\nfailed to upload dist/app.exe [2/2]\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	uploads.Transfer(publish.Transfer{Asset: "dist/installer.exe", Sent: 512 << 20, Size: 2 << 30})
+	expected = "uploading dist/installer.exe: 512.0 MiB of 2.0 GiB (25%)\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestReleaseState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	git := &fakeGit{tags: []string{"v1.0.0"}, commits: []gitrepo.Commit{{Hash: "aaa", Message: "feat: x"}}}
+	a, stdout, stderr := newTestApp(git)
+	a.state = &state.Store{Dir: t.TempDir(), Now: a.now}
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return fakePublisher{publish.Result{URL: "https://x/r/2"}}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"status"}); code != 1 {
+		t.Errorf("Expected exit code 1 without a release, got %d", code)
+	}
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"status"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "v1.1.0: tagged\n" +
+		"  2026-03-01T00:00:00Z  pending → versioned (tag)\n" +
+		"  2026-03-01T00:00:00Z  versioned → tagged (tag)\n" +
+		"run 'release resume' to continue\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"resume", "-notes", path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.HasPrefix(stdout.String(), "resuming v1.1.0: publishing\npushed v1.1.0 to origin\n") {
+		t.Errorf("Expected the publish to resume, got %q", stdout.String())
+	}
+
+	r, err := a.state.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.State != state.Announced || !reflect.DeepEqual(r.URLs, []string{"https://x/r/2"}) || r.History[2].Command != "resume" {
+		t.Errorf("Expected an announced release published by resume, got %+v", r)
+	}
+
+	stdout.Reset()
+	a.run(context.Background(), []string{"resume"})
+	if stdout.String() != "v1.1.0 is already announced\n" {
+		t.Errorf("Expected nothing to resume, got %q", stdout.String())
+	}
+}
+
+func TestReleaseStateDryRun(t *testing.T) {
+	a, _, stderr := newTestApp(&fakeGit{commits: []gitrepo.Commit{{Hash: "aaa", Message: "fix: x"}}})
+	a.state = &state.Store{Dir: t.TempDir()}
+
+	if code := a.run(context.Background(), []string{"-dry-run", "tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if r, err := a.state.Load(); r != nil || err != nil {
+		t.Errorf("Expected no state in dry-run mode, got %+v, %v", r, err)
+	}
+}
+
+func TestTrain(t *testing.T) {
+	newApp := func(commits ...gitrepo.Commit) (*app, *fakeGit, *bytes.Buffer, *bytes.Buffer, *publish.Release) {
+		git := &fakeGit{tags: []string{"v1.0.0"}, commits: commits}
+		a, stdout, stderr := newTestApp(git)
+		// Sundays at midnight, as a.now is.
+		a.cfg.Train = config.TrainConfig{Start: "2026-01-04", Every: "168h"}
+		a.cfg.Publish = []config.PublishTarget{{Provider: "docker", Repo: "octo/app"}}
+		var release publish.Release
+		a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+			return publisherFunc(func(ctx context.Context, r publish.Release) (publish.Result, error) {
+				release = r
+				return publish.Result{ID: repo + ":" + r.Version}, nil
+			}), nil
+		}
+		return a, git, stdout, stderr, &release
+	}
+
+	a, git, stdout, stderr, release := newApp(gitrepo.Commit{Hash: "aaa", Message: "feat: search"})
+	if code := a.run(context.Background(), []string{"train"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if _, ok := git.created["v1.1.0"]; !ok || release.Tag != "v1.1.0" {
+		t.Errorf("Expected v1.1.0 tagged and published, got %v and %q", git.created, release.Tag)
+	}
+	if !strings.HasPrefix(stdout.String(), "train of 2026-03-01 00:00 UTC: releasing v1.1.0 with 1 commit(s)\n") {
+		t.Errorf("Expected the departure in %q", stdout)
+	}
+	if !strings.Contains(release.Body, "search") {
+		t.Errorf("Expected the rendered notes as the body, got %q", release.Body)
+	}
+
+	a, git, _, stderr, _ = newApp(gitrepo.Commit{Hash: "aaa", Message: "docs: readme"})
+	if code := a.run(context.Background(), []string{"train"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if _, ok := git.created["v1.0.1"]; !ok {
+		t.Errorf("Expected a patch release of the commits calling for none, got %v", git.created)
+	}
+
+	a, git, stdout, _, _ = newApp()
+	if code := a.run(context.Background(), []string{"train"}); code != 0 || stdout.String() != "train of 2026-03-01 00:00 UTC: nothing to ship since v1.0.0\n" {
+		t.Errorf("Expected nothing to ship, got %d: %q", code, stdout)
+	}
+
+	a, git, stdout, _, _ = newApp(gitrepo.Commit{Hash: "aaa", Message: "fix: x"})
+	a.cfg.Train.Start = "2026-01-05T10:00"
+	if code := a.run(context.Background(), []string{"train"}); code != 0 || stdout.String() != "no train departs now: the next leaves at 2026-03-02 10:00 UTC\n" || len(git.created) > 0 {
+		t.Errorf("Expected no departure, got %d: %q", code, stdout)
+	}
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"train", "-now", "-dry-run"}); code != 0 || len(git.created) > 0 || !strings.Contains(stdout.String(), "releasing v1.0.1") {
+		t.Errorf("Expected -now to depart in a dry run, got %d: %q", code, stdout)
+	}
+
+	a, git, stdout, _, _ = newApp(gitrepo.Commit{Hash: "aaa", Message: "fix: x"})
+	a.state = &state.Store{Dir: t.TempDir(), Now: a.now}
+	a.state.Advance(state.Event{Tag: "v1.0.0", To: state.Tagged, Command: "train"})
+	if code := a.run(context.Background(), []string{"train"}); code != 0 || !strings.HasPrefix(stdout.String(), "the train of 2026-03-01 00:00 UTC has left") || len(git.created) > 0 {
+		t.Errorf("Expected the train to have left, got %d: %q", code, stdout)
+	}
+
+	a, _, _, _, _ = newApp(gitrepo.Commit{Hash: "aaa", Message: "fix: x"})
+	a.cfg.Freeze = []config.FreezeWindow{{Name: "holidays", From: "2026-02-28", Until: "2026-03-02"}}
+	if code := a.run(context.Background(), []string{"train"}); code != 4 {
+		t.Errorf("Expected a frozen train to exit with 4, got %d", code)
+	}
+
+	a, _, _, _, _ = newApp()
+	a.cfg.Train = config.TrainConfig{}
+	if code := a.run(context.Background(), []string{"train"}); code != 6 {
+		t.Errorf("Expected exit code 6 without a train, got %d", code)
+	}
+}
+
+func TestBench(t *testing.T) {
+	dir := t.TempDir()
+	saved := filepath.Join(dir, "baseline.json")
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	if code := a.run(context.Background(), []string{"bench", "-commits", "50,100", "-benchtime", "1x", "-save", saved}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 6 || !strings.HasPrefix(lines[5], "changelog/100") || !strings.HasSuffix(lines[5], "allocs/op") {
+		t.Errorf("Expected a line per case and size, got %q", stdout)
+	}
+	var results []bench.Result
+	data, _ := os.ReadFile(saved)
+	if err := json.Unmarshal(data, &results); err != nil || len(results) != 6 {
+		t.Fatalf("Expected the 6 results saved, got %d (err=%v)", len(results), err)
+	}
+
+	// A baseline allocating nothing less than a tenth of what is measured.
+	for i := range results {
+		results[i].AllocsPerOp /= 10
+	}
+	data, _ = json.Marshal(results)
+	os.WriteFile(saved, data, 0o644)
+	a, stdout, _ = newTestApp(&fakeGit{})
+	if code := a.run(context.Background(), []string{"bench", "-commits", "100", "-run", "parse", "-benchtime", "1x", "-baseline", saved}); code != 4 {
+		t.Errorf("Expected exit code 4 for a regression, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "regression parse/100: allocs/op") {
+		t.Errorf("Expected the regression listed, got %q", stdout)
+	}
+
+	a, _, stderr = newTestApp(&fakeGit{})
+	if code := a.run(context.Background(), []string{"bench", "-commits", "many"}); code != 2 {
+		t.Errorf("Expected exit code 2, got %d", code)
+	}
+	if code := a.run(context.Background(), []string{"bench", "-benchtime", "soon"}); code != 2 || !strings.Contains(stderr.String(), "-benchtime") {
+		t.Errorf("Expected exit code 2 for an invalid -benchtime, got %d: %s", code, stderr)
+	}
+	a.run(context.Background(), []string{"-h"})
+	if strings.Contains(stderr.String(), "bench ") {
+		t.Errorf("Expected bench to be left out of the usage, got %q", stderr)
+	}
+}
+
+func TestServe(t *testing.T) {
+	git := &fakeGit{tags: []string{"v1.2.0"}, commits: []gitrepo.Commit{{Hash: "aaa", Message: "feat: a feature"}}}
+	a, _, stderr := newTestApp(git)
+	a.log = rlog.New(stderr, rlog.Options{})
+	h := a.serveHandler("secret", "origin", false)
+	serve := func(method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	bearer := map[string]string{"Authorization": "Bearer secret"}
+
+	if rec := serve("GET", "/v1/next", "", map[string]string{"Authorization": "Bearer wrong"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with a wrong token, got %d", rec.Code)
+	}
+	if rec := serve("GET", "/healthz", "", nil); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 from /healthz, got %d", rec.Code)
+	}
+
+	rec := serve("GET", "/v1/next?bump=major", "", bearer)
+	var res result
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected a result, got %d %q: %v", rec.Code, rec.Body, err)
+	}
+	if res.Version != "2.0.0" || res.Command != "next" {
+		t.Errorf("Expected the next major version, got %+v", res)
+	}
+
+	rec = serve("GET", "/v1/changelog", "", map[string]string{"Authorization": "Bearer secret", "Accept": "text/markdown"})
+	if !strings.HasPrefix(rec.Body.String(), "## [1.3.0]") || rec.Header().Get("Content-Type") != "text/markdown; charset=utf-8" {
+		t.Errorf("Expected the markdown section, got %q", rec.Body)
+	}
+
+	rec = serve("POST", "/v1/release", `{"dry_run": true}`, bearer)
+	if rec.Code != http.StatusOK || len(git.created) != 0 {
+		t.Errorf("Expected a dry-run release, got %d %q, created %v", rec.Code, rec.Body, git.created)
+	}
+
+	push := `{"ref": "refs/heads/feature/x"}`
+	if rec := serve("POST", "/v1/webhook", push, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + hubSignature("secret", push)}); rec.Code != http.StatusAccepted || len(git.pulled) != 0 {
+		t.Errorf("Expected the push to another branch ignored, got %d %q", rec.Code, rec.Body)
+	}
+	push = `{"ref": "refs/heads/main"}`
+	if rec := serve("POST", "/v1/release", push, map[string]string{"X-Hub-Signature-256": "sha256=" + hubSignature("secret", push)}); rec.Code != http.StatusUnauthorized || len(git.created) != 0 {
+		t.Errorf("Expected a signed webhook delivery replayed on /v1/release to be unauthorized, got %d", rec.Code)
+	}
+	if rec := serve("POST", "/v1/webhook", push, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + hubSignature("wrong", push)}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with a wrong signature, got %d", rec.Code)
+	}
+
+	merge := `{"object_attributes": {"action": "merge", "target_branch": "main"}}`
+	rec = serve("POST", "/v1/webhook", merge, map[string]string{"X-Gitlab-Event": "Merge Request Hook", "X-Gitlab-Token": "secret"})
+	var results []result
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected the release results, got %d %q: %v", rec.Code, rec.Body, err)
+	}
+	if len(results) != 3 || results[2].Command != "publish" || results[2].Pushed != "origin" {
+		t.Errorf("Expected notes, tag and publish, got %+v", results)
+	}
+	if !reflect.DeepEqual(git.pulled, []string{"origin main"}) || !reflect.DeepEqual(git.pushed, []string{"origin v1.3.0"}) {
+		t.Errorf("Expected main pulled and v1.3.0 pushed, got %v and %v", git.pulled, git.pushed)
+	}
+	if _, ok := git.created["v1.3.0"]; !ok {
+		t.Errorf("Expected v1.3.0 tagged, got %v", git.created)
+	}
+}
+
+func TestServeTagEvents(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+	}))
+	defer srv.Close()
+
+	git := &fakeGit{tags: []string{"v1.2.0"}, commits: []gitrepo.Commit{{Hash: "aaa", Message: "feat: a feature"}}}
+	a, _, stderr := newTestApp(git)
+	a.log = rlog.New(stderr, rlog.Options{})
+	a.cfg.Notify = []config.NotifyTarget{{Type: "slack", URL: srv.URL}}
+	serve := func(h http.Handler, event, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/v1/webhook", strings.NewReader(body))
+		if strings.HasSuffix(event, "Hook") {
+			req.Header.Set("X-Gitlab-Event", event)
+			req.Header.Set("X-Gitlab-Token", "secret")
+		} else {
+			req.Header.Set("X-GitHub-Event", event)
+			req.Header.Set("X-Hub-Signature-256", "sha256="+hubSignature("secret", body))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	tagPush := `{"ref": "refs/tags/v1.2.0"}`
+
+	if rec := serve(a.serveHandler("secret", "origin", false), "push", tagPush); rec.Code != http.StatusAccepted || len(git.pulled) != 0 {
+		t.Errorf("Expected the tag push ignored without -tags, got %d %q", rec.Code, rec.Body)
+	}
+
+	h := a.serveHandler("secret", "origin", true)
+	rec := serve(h, "push", tagPush)
+	var results []result
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected the publish result, got %d %q: %v", rec.Code, rec.Body, err)
+	}
+	if len(results) != 1 || results[0].Command != "publish" || results[0].Tag != "v1.2.0" {
+		t.Errorf("Expected v1.2.0 published, got %+v", results)
+	}
+	if !reflect.DeepEqual(git.pulled, []string{"origin main"}) {
+		t.Errorf("Expected main pulled with its tags, got %v", git.pulled)
+	}
+	if len(bodies) != 1 || bodies[0] != `{"text":"Released v1.2.0"}` {
+		t.Errorf("Expected one notification of v1.2.0, got %v", bodies)
+	}
+
+	for name, body := range map[string]string{
+		"not a version": `{"ref": "refs/tags/nightly"}`,
+		"deleted":       `{"ref": "refs/tags/v1.2.1", "deleted": true}`,
+	} {
+		if rec := serve(h, "push", body); rec.Code != http.StatusAccepted {
+			t.Errorf("%s: Expected the tag push ignored, got %d %q", name, rec.Code, rec.Body)
+		}
+	}
+	if rec := serve(h, "Release Hook", `{"action": "create", "tag": "v1.2.0"}`); rec.Code != http.StatusAccepted || len(bodies) != 1 {
+		t.Errorf("Expected the release the server published ignored, got %d %q", rec.Code, rec.Body)
+	}
+
+	release := `{"action": "published", "release": {"tag_name": "v1.2.1", "html_url": "https://github.com/octo/app/releases/tag/v1.2.1"}}`
+	if rec := serve(h, "release", release); rec.Code != http.StatusOK {
+		t.Errorf("Expected the release notified, got %d %q", rec.Code, rec.Body)
+	}
+	if len(bodies) != 2 || !strings.Contains(bodies[1], "v1.2.1") || !strings.Contains(bodies[1], "https://github.com/octo/app/releases/tag/v1.2.1") {
+		t.Errorf("Expected a notification of v1.2.1, got %v", bodies)
+	}
+	if len(git.pushed) != 1 {
+		t.Errorf("Expected only v1.2.0 pushed, got %v", git.pushed)
+	}
+}
+
+func TestServeTagPublishRetry(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	git := &fakeGit{tags: []string{"v1.2.0"}, pushErr: map[string]error{"origin": errors.New("connection reset")}}
+	a, _, stderr := newTestApp(git)
+	a.log = rlog.New(stderr, rlog.Options{})
+	h := a.serveHandler("secret", "origin", true)
+	push := func() int {
+		body := `{"ref": "refs/tags/v1.2.0"}`
+		req := httptest.NewRequest("POST", "/v1/webhook", strings.NewReader(body))
+		req.Header.Set("X-GitHub-Event", "push")
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hubSignature("secret", body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := push(); code < 500 {
+		t.Fatalf("Expected the publish to fail, got %d", code)
+	}
+	git.pushErr = nil
+	if code := push(); code != http.StatusOK {
+		t.Errorf("Expected the failed publish retried, got %d", code)
+	}
+	if !reflect.DeepEqual(git.pushed, []string{"origin v1.2.0"}) {
+		t.Errorf("Expected v1.2.0 pushed on the retry, got %v", git.pushed)
+	}
+	if code := push(); code != http.StatusAccepted {
+		t.Errorf("Expected the tag the server published ignored, got %d", code)
+	}
+}
+
+func hubSignature(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestServeMetrics(t *testing.T) {
+	git := &fakeGit{tags: []string{"v1.2.0"}, commits: []gitrepo.Commit{{Hash: "aaa", Message: "feat: a feature"}}}
+	a, _, stderr := newTestApp(git)
+	a.log = rlog.New(stderr, rlog.Options{})
+	rec := httptest.NewRecorder()
+	a.serveHandler("secret", "origin", false).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no metrics without telemetry, got %d", rec.Code)
+	}
+
+	a.telemetry = telemetry.NewRecorder("release")
+	h := a.serveHandler("secret", "origin", false)
+	req := httptest.NewRequest("GET", "/v1/next", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the token, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(rec, req)
+	if want := `release_command_duration_seconds_count{command="next",outcome="success"} 1`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("Expected %s, got:\n%s", want, rec.Body)
+	}
+}
+
+func TestTelemetryExport(t *testing.T) {
+	var paths []string
+	var body bytes.Buffer
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+" "+r.Header.Get("X-Team"))
+		io.Copy(&body, r.Body)
+	}))
+	defer srv.Close()
+	t.Setenv(telemetry.EnvEndpoint, srv.URL)
+	t.Setenv(telemetry.EnvHeaders, "x-team=release")
+
+	a, _, stderr := newTestApp(&fakeGit{commits: []gitrepo.Commit{{Hash: "a", Message: "docs: readme"}}})
+	a.cfg.Telemetry.ServiceName = "releaser"
+	if code := a.run(context.Background(), []string{"next"}); code != 3 {
+		t.Fatalf("Expected exit code 3, got %d: %s", code, stderr)
+	}
+	if !slices.Equal(paths, []string{"/v1/metrics release", "/v1/traces release"}) {
+		t.Errorf("Expected metrics and traces to be exported, got %v", paths)
+	}
+	for _, want := range []string{`"releaser"`, telemetry.CommandDuration, `"nothing to release"`, `"release next"`} {
+		if !strings.Contains(body.String(), want) {
+			t.Errorf("Expected %s in the export, got %s", want, body.String())
+		}
+	}
+	if strings.Contains(body.String(), telemetry.Failures) {
+		t.Errorf("Expected nothing to release not to count as a failure, got %s", body.String())
+	}
+}
+
+func TestServeNeedsToken(t *testing.T) {
+	t.Setenv(serveTokenEnv, "")
+	a, _, _ := newTestApp(&fakeGit{})
+	if code := a.run(context.Background(), []string{"serve"}); code != 2 {
+		t.Errorf("Expected exit code 2 without a token, got %d", code)
+	}
+}
+
+// retractPublisher records the releases it takes down, failing with err.
+type retractPublisher struct {
+	fakePublisher
+	retracted *[]string
+	err       error
+}
+
+func (p retractPublisher) Retract(_ context.Context, tag string, draft bool) (bool, error) {
+	if p.err != nil {
+		return false, p.err
+	}
+	*p.retracted = append(*p.retracted, fmt.Sprintf("%s draft=%v", tag, draft))
+	return true, nil
+}
+
+func TestRollback(t *testing.T) {
+	newGit := func() *fakeGit {
+		return &fakeGit{
+			tags:    []string{"v1.2.0", "v1.3.0"},
+			tagged:  map[string]string{"v1.3.0": "bbbbbbbbbb"},
+			commits: []gitrepo.Commit{{Hash: "bbbbbbbbbb", Message: "chore(release): v1.3.0"}},
+		}
+	}
+	var retracted []string
+	var retractErr error
+	newApp := func(git *fakeGit) (*app, *bytes.Buffer, *bytes.Buffer) {
+		a, stdout, stderr := newTestApp(git)
+		a.state = &state.Store{Dir: t.TempDir()}
+		a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+			return retractPublisher{retracted: &retracted, err: retractErr}, nil
+		}
+		return a, stdout, stderr
+	}
+	args := []string{"rollback", "-provider", "github", "-repo", "octo/app", "-yes", "1.3.0"}
+
+	git := newGit()
+	a, stdout, stderr := newApp(git)
+	if code := a.run(context.Background(), args); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !reflect.DeepEqual(retracted, []string{"v1.3.0 draft=false"}) {
+		t.Errorf("Expected the release deleted, got %v", retracted)
+	}
+	if !reflect.DeepEqual(git.deleted, []string{"origin v1.3.0", "v1.3.0"}) || !reflect.DeepEqual(git.reverted, []string{"bbbbbbbbbb"}) {
+		t.Errorf("Expected the tag deleted and the bump reverted, got %v and %v", git.deleted, git.reverted)
+	}
+	for _, line := range []string{"deleted the github release on octo/app\n", "deleted tag v1.3.0 from origin\n", "reverted the version-bump commit bbbbbbb\n"} {
+		if !strings.Contains(stdout.String(), line) {
+			t.Errorf("Expected %q in %q", line, stdout)
+		}
+	}
+	if r, _ := a.state.Load(); r == nil || r.State != state.RolledBack {
+		t.Errorf("Expected the release recorded as rolled back, got %+v", r)
+	}
+
+	retractErr = errors.New("API down")
+	git = newGit()
+	a, stdout, stderr = newApp(git)
+	if code := a.run(context.Background(), args); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if len(git.deleted) != 0 || !strings.Contains(stdout.String(), "not undone: delete tag v1.3.0 from origin\n") {
+		t.Errorf("Expected nothing else undone, got %v and %q", git.deleted, stdout)
+	}
+	if !strings.Contains(stderr.String(), "undone: nothing: API down") {
+		t.Errorf("Expected the failure to tell what was undone, got %q", stderr)
+	}
+
+	a, _, _ = newApp(newGit())
+	if code := a.run(context.Background(), []string{"rollback", "v1.3.0"}); code != 2 {
+		t.Errorf("Expected exit code 2 without -yes or a terminal, got %d", code)
+	}
+}
+
+func TestRollbackDryRun(t *testing.T) {
+	git := &fakeGit{tags: []string{"v1.3.0"}}
+	a, stdout, stderr := newTestApp(git)
+	if code := a.run(context.Background(), []string{"-dry-run", "rollback", "v1.3.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "Rolling back v1.3.0:\n" +
+		"  - delete tag v1.3.0 from origin\n" +
+		"  - delete tag v1.3.0\n" +
+		"[dry-run] would delete tag v1.3.0 from origin\n" +
+		"[dry-run] would delete tag v1.3.0\n"
+	if stdout.String() != expected || len(git.deleted) != 0 {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+}
+
+// prunePublisher lists releases from memory, records the deletions and
+// fails those of the tags in fail.
+type prunePublisher struct {
+	*snapshotPublisher
+	releases []publish.Listed
+	deleted  []string
+	fail     []string
+}
+
+func (p *prunePublisher) Releases(context.Context) ([]publish.Listed, error) {
+	return p.releases, nil
+}
+
+func (p *prunePublisher) DeleteRelease(_ context.Context, r publish.Listed) error {
+	if slices.Contains(p.fail, r.Tag) {
+		return fmt.Errorf("delete %s: API down", r.Tag)
+	}
+	p.deleted = append(p.deleted, r.ID)
+	return nil
+}
+
+func TestPrune(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	daysAgo := func(d int) time.Time { return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -d) }
+	newPublisher := func() *prunePublisher {
+		sp := &snapshotPublisher{}
+		return &prunePublisher{snapshotPublisher: sp, releases: []publish.Listed{
+			{ID: "1", Tag: "nightly", Prerelease: true, Created: daysAgo(90), Assets: []publish.RemoteAsset{
+				{Name: "app_1.4.0-dev.20260228.bbbbbbb.tar.gz"},
+				{Name: "app_1.4.0-dev.20260225.aaaaaaa.tar.gz"},
+			}},
+			{ID: "2", Tag: "v1.5.0", Draft: true, Created: daysAgo(1)},
+			{ID: "3", Tag: "v1.4.0-rc.2", Prerelease: true, Created: daysAgo(2)},
+			{ID: "4", Tag: "v1.4.0-rc.1", Prerelease: true, Created: daysAgo(40)},
+			{ID: "5", Tag: "v1.3.0", Created: daysAgo(50)},
+			{ID: "6", Tag: "v1.3.0-rc.1", Prerelease: true, Created: daysAgo(55)},
+			{ID: "7", Tag: "v1.2.0", Draft: true, Created: daysAgo(100)},
+			{ID: "8", Tag: "v1.1.0", Created: daysAgo(200)},
+		}}
+	}
+	pp := newPublisher()
+	git := &fakeGit{}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Snapshot.Keep = 1
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return pp, nil
+	}
+	args := []string{"prune", "-keep", "1", "-max-age", "720h", "-superseded", "-yes"}
+
+	if code := a.run(context.Background(), args); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "Pruning 4 item(s):\n" +
+		"  - prerelease release v1.4.0-rc.1 on github octo/app: beyond the 1 newest prerelease release(s) and older than 30d\n" +
+		"  - prerelease release v1.3.0-rc.1 on github octo/app: superseded by 1.3.0\n" +
+		"  - draft release v1.2.0 on github octo/app: beyond the 1 newest draft release(s) and older than 30d\n" +
+		"  - 1 asset(s) of older snapshots from nightly on github octo/app: builds beyond the 1 newest\n" +
+		"deleted prerelease release v1.4.0-rc.1 on github octo/app\n" +
+		"deleted prerelease release v1.3.0-rc.1 on github octo/app\n" +
+		"deleted draft release v1.2.0 on github octo/app\n" +
+		"deleted 1 asset(s) of older snapshots from nightly on github octo/app\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	if want := []string{"4", "6", "7"}; !reflect.DeepEqual(pp.deleted, want) {
+		t.Errorf("Expected releases %v deleted, got %v", want, pp.deleted)
+	}
+	if want := []string{"app_1.4.0-dev.20260225.aaaaaaa.tar.gz"}; !reflect.DeepEqual(pp.snapshotPublisher.deleted, want) {
+		t.Errorf("Expected assets %v deleted, got %v", want, pp.snapshotPublisher.deleted)
+	}
+	if len(a.result.Pruned) != 4 || a.result.Pruned[1].Reason != "superseded by 1.3.0" {
+		t.Errorf("Expected the deletions recorded, got %+v", a.result.Pruned)
+	}
+
+	// Without a terminal nothing is deleted unless -yes.
+	pp = newPublisher()
+	a, _, stderr = newTestApp(git)
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) { return pp, nil }
+	if code := a.run(context.Background(), []string{"prune", "-provider", "github", "-repo", "octo/app"}); code != 2 {
+		t.Fatalf("Expected exit code 2, got %d: %s", code, stderr)
+	}
+
+	// A dry run lists the deletions and leaves them to the dry-run
+	// publishers.
+	args = []string{"-dry-run", "prune", "-keep", "1", "-snapshots=false"}
+	a, stdout, stderr = newTestApp(git)
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	var dryRun bool
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		dryRun = o.DryRun
+		return newPublisher(), nil
+	}
+	if code := a.run(context.Background(), args); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !dryRun || !strings.HasPrefix(stdout.String(), "Pruning 3 item(s):\n") || strings.Contains(stdout.String(), "\ndeleted") || len(a.result.Pruned) != 3 {
+		t.Errorf("Expected a dry run listing 3 deletions, got %q and %+v", stdout, a.result.Pruned)
+	}
+
+	// A failed deletion does not stop the others.
+	pp = newPublisher()
+	pp.fail = []string{"v1.4.0-rc.1"}
+	a, stdout, stderr = newTestApp(git)
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) { return pp, nil }
+	if code := a.run(context.Background(), []string{"prune", "-keep", "1", "-snapshots=false", "-yes"}); code != 8 {
+		t.Fatalf("Expected exit code 8, got %d: %s", code, stderr)
+	}
+	if want := []string{"6", "7"}; !reflect.DeepEqual(pp.deleted, want) {
+		t.Errorf("Expected releases %v deleted, got %v", want, pp.deleted)
+	}
+	if !strings.Contains(stdout.String(), "not deleted: prerelease release v1.4.0-rc.1 on github octo/app: delete v1.4.0-rc.1: API down\n") ||
+		!strings.Contains(stderr.String(), "1 of 3 deletion(s) failed") || a.result.Pruned[0].Error == "" {
+		t.Errorf("Expected the failure reported, got %q and %q", stdout, stderr)
+	}
+}
+
+// fakeMetaRunner answers the release commands of meta with the results
+// of results and opens the repositories of repos, both keyed by the base
+// name of the checkout.
+type fakeMetaRunner struct {
+	results map[string]*result
+	repos   map[string]*fakeGit
+	ran     []string
+}
+
+func (f *fakeMetaRunner) Release(_ context.Context, dir string, args []string) (*result, error) {
+	key := filepath.Base(dir) + " " + strings.Join(args, " ")
+	f.ran = append(f.ran, key)
+	if r, ok := f.results[key]; ok {
+		return r, nil
+	}
+	return &result{Command: args[len(args)-1], ExitCode: 3, Error: "no commits since the latest tag"}, nil
+}
+
+func (f *fakeMetaRunner) Repo(_ context.Context, dir string) (gitrepo.Repository, error) {
+	return f.repos[filepath.Base(dir)], nil
+}
+
+// sequenceStatusPublisher reports states in turn, then the last one.
+type sequenceStatusPublisher struct {
+	fakePublisher
+	states *[]publish.CIState
+}
+
+func (p sequenceStatusPublisher) CIStatus(context.Context, string) (publish.CIStatus, error) {
+	s := (*p.states)[0]
+	if len(*p.states) > 1 {
+		*p.states = (*p.states)[1:]
+	}
+	return publish.CIStatus{State: s, Checks: []string{"build"}}, nil
+}
+
+func TestMeta(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"lib", "api", "web"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	gomod := filepath.Join(dir, "api", "go.mod")
+	if err := os.WriteFile(gomod, []byte("module example.com/api\n\nrequire example.com/lib v1.3.0\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(dir, "platform.yaml")
+	if err := os.WriteFile(path, []byte(`
+name: platform
+repositories:
+  - name: web
+    path: web
+    needs: [api]
+  - name: api
+    path: api
+    needs: [lib]
+    propagate:
+      - from: lib
+        path: go.mod
+        pattern: 'example.com/lib (v\S+)'
+        tag: true
+  - name: lib
+    path: lib
+    provider: github
+    repo: octo/lib
+`), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	newRunner := func() *fakeMetaRunner {
+		return &fakeMetaRunner{
+			results: map[string]*result{
+				"lib tag":                      {Command: "tag", Tag: "v1.4.0", Version: "1.4.0", PreviousTag: "v1.3.0", Bump: "minor"},
+				"lib publish v1.4.0":           {Command: "publish", Releases: []releaseResult{{Provider: "github", Repo: "octo/lib", URL: "https://github.com/octo/lib/releases/tag/v1.4.0"}}},
+				"api tag -bump patch":          {Command: "tag", Tag: "v2.0.1", Version: "2.0.1", PreviousTag: "v2.0.0", Bump: "patch"},
+				"api publish v2.0.1":           {Command: "publish"},
+				"lib -dry-run tag":             {Command: "tag", DryRun: true, Tag: "v1.4.0", Version: "1.4.0", Bump: "minor"},
+				"api -dry-run tag -bump patch": {Command: "tag", DryRun: true, Tag: "v2.0.1", Version: "2.0.1", Bump: "patch"},
+			},
+			repos: map[string]*fakeGit{
+				"lib": {tags: []string{"v1.3.0", "v1.4.0"}, tagged: map[string]string{"v1.4.0": "1111111111"}},
+				"api": {tags: []string{"v2.0.0"}},
+			},
+		}
+	}
+	runner := newRunner()
+	states := []publish.CIState{publish.CINone, publish.CIPending, publish.CISuccess}
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.metaRunner = runner
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return sequenceStatusPublisher{states: &states}, nil
+	}
+
+	if code := a.run(context.Background(), []string{"meta", "-manifest", path, "-ci-interval", "1ms"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := []string{"lib tag", "lib publish v1.4.0", "api tag", "api tag -bump patch", "api publish v2.0.1", "web tag"}
+	if !reflect.DeepEqual(runner.ran, expected) {
+		t.Errorf("Expected %q, got %q", expected, runner.ran)
+	}
+	if data, _ := os.ReadFile(gomod); !strings.Contains(string(data), "require example.com/lib v1.4.0") {
+		t.Errorf("Expected the lib version propagated, got %q", data)
+	}
+	api := runner.repos["api"]
+	if !reflect.DeepEqual(api.committed, []string{"chore(release): require lib v1.4.0: go.mod"}) || !reflect.DeepEqual(api.pushed, []string{"origin main"}) {
+		t.Errorf("Expected the propagated version committed and pushed, got %v and %v", api.committed, api.pushed)
+	}
+	for _, line := range []string{
+		"lib: released v1.4.0\nlib: waiting for CI on v1.4.0\nlib: CI passed on v1.4.0\n",
+		"api: released v2.0.1\nweb: nothing to release\n",
+	} {
+		if !strings.Contains(stdout.String(), line) {
+			t.Errorf("Expected %q in %q", line, stdout)
+		}
+	}
+	if len(a.result.Modules) != 2 || a.result.Modules[1] != (moduleResult{Name: "api", Dir: filepath.Join(dir, "api"), Current: "v2.0.0", Next: "v2.0.1", Bump: "patch"}) {
+		t.Errorf("Unexpected modules %+v", a.result.Modules)
+	}
+	if len(a.result.Releases) != 1 || a.result.Releases[0].Repo != "octo/lib" {
+		t.Errorf("Expected the lib release recorded, got %+v", a.result.Releases)
+	}
+
+	// A failing CI stops the platform release before the repositories
+	// needing the one it failed for.
+	if err := os.WriteFile(gomod, []byte("require example.com/lib v1.3.0\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runner = newRunner()
+	states = []publish.CIState{publish.CIFailure}
+	a, stdout, stderr = newTestApp(&fakeGit{})
+	a.metaRunner = runner
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return sequenceStatusPublisher{states: &states}, nil
+	}
+	if code := a.run(context.Background(), []string{"meta", "-manifest", path}); code != 4 {
+		t.Fatalf("Expected exit code 4, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr.String(), "platform release stopped at lib, released: nothing: lib: CI failed on v1.4.0: build") ||
+		!strings.Contains(stdout.String(), "not released: api, web\n") || len(runner.ran) != 2 {
+		t.Errorf("Expected the release stopped at lib, got %q, %q and %v", stdout, stderr, runner.ran)
+	}
+
+	// A dry run propagates and tags nothing, and publishes nothing.
+	runner = newRunner()
+	a, stdout, stderr = newTestApp(&fakeGit{})
+	a.metaRunner = runner
+	if code := a.run(context.Background(), []string{"-dry-run", "meta", "-manifest", path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected = []string{"lib -dry-run tag", "api -dry-run tag", "api -dry-run tag -bump patch", "web -dry-run tag"}
+	if !reflect.DeepEqual(runner.ran, expected) {
+		t.Errorf("Expected %q, got %q", expected, runner.ran)
+	}
+	for _, line := range []string{
+		"[dry-run] would run release publish v1.4.0 in " + filepath.Join(dir, "lib") + "\n",
+		"[dry-run] would update go.mod: v1.3.0 -> v1.4.0\n",
+	} {
+		if !strings.Contains(stdout.String(), line) {
+			t.Errorf("Expected %q in %q", line, stdout)
+		}
+	}
+	if data, _ := os.ReadFile(gomod); !strings.Contains(string(data), "v1.3.0") || len(runner.repos["api"].committed) != 0 {
+		t.Errorf("Expected nothing changed, got %q and %v", data, runner.repos["api"].committed)
+	}
+}
+
+func TestPublishFrozen(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	git := &fakeGit{}
+	a, _, stderr := newTestApp(git)
+	a.cfg.Freeze = []config.FreezeWindow{{Name: "weekend", Cron: "0 18 * * fri", Duration: "62h"}} // 2026-03-01 is a Sunday
+
+	if code := a.run(context.Background(), []string{"publish", "v1.0.0"}); code != 4 {
+		t.Fatalf("Expected exit code 4, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr.String(), "freeze window weekend until 2026-03-02 08:00 UTC; pass -force") || len(git.pushed) != 0 {
+		t.Errorf("Expected a refusal without a push, got %q and %v", stderr, git.pushed)
+	}
+
+	stderr.Reset()
+	if code := a.run(context.Background(), []string{"publish", "-force", "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr.String(), "publishing anyway") || len(git.pushed) != 1 {
+		t.Errorf("Expected a warning and a push, got %q and %v", stderr, git.pushed)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	git := &fakeGit{}
+	a, stdout, stderr := newTestApp(git)
+	a.cfg.Freeze = []config.FreezeWindow{{Name: "holidays", From: "2026-02-20", Until: "2026-02-28"}}
+
+	if code := a.run(context.Background(), []string{"schedule", "publish", "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if len(git.pushed) != 1 || stdout.String() != "pushed v1.0.0 to origin\n" {
+		t.Errorf("Expected publish to run at once, got %q and %v", stdout, git.pushed)
+	}
+
+	if code := a.run(context.Background(), []string{"schedule", "rollback", "v1.0.0"}); code != 2 {
+		t.Errorf("Expected exit code 2 for a command schedule does not run, got %d", code)
+	}
+}
+
+func TestScheduleWaits(t *testing.T) {
+	a, stdout, stderr := newTestApp(&fakeGit{})
+	a.cfg.Freeze = []config.FreezeWindow{{Name: "holidays", From: "2026-02-20", Until: "2026-03-01T12:00", Timezone: "UTC"}}
+
+	if code := a.run(context.Background(), []string{"-dry-run", "schedule", "publish", "v1.0.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "[dry-run] would wait until 2026-03-01 12:00 UTC, when holidays ends\n[dry-run] would run release publish v1.0.0\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+
+	a, stdout, stderr = newTestApp(&fakeGit{})
+	a.cfg.Freeze = []config.FreezeWindow{{Name: "holidays", From: "2026-02-20", Until: "2026-03-01T12:00"}}
+	if code := a.run(context.Background(), []string{"-timeout", "10ms", "schedule"}); code != 124 {
+		t.Errorf("Expected exit code 124 when the wait times out, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "releases are frozen by holidays: waiting until 2026-03-01 12:00 UTC\n" {
+		t.Errorf("Unexpected output %q", stdout)
+	}
+}
+
+// fakeFetcher is a publisher whose release of v1.2.0 has files as assets,
+// and its page at url.
+type fakeFetcher struct {
+	fakePublisher
+	commit  string
+	files   map[string]string
+	url     string
+	fetched int
+}
+
+func (f *fakeFetcher) Fetch(_ context.Context, tag string) (publish.Published, bool, error) {
+	f.fetched++
+	p := publish.Published{Tag: tag, Commit: f.commit, URL: f.url}
+	for _, name := range slices.Sorted(maps.Keys(f.files)) {
+		p.Assets = append(p.Assets, publish.RemoteAsset{Name: name, Size: int64(len(f.files[name]))})
+	}
+	return p, tag == "v1.2.0", nil
+}
+
+func (f *fakeFetcher) Download(_ context.Context, a publish.RemoteAsset, w io.Writer) error {
+	_, err := io.WriteString(w, f.files[a.Name])
+	return err
+}
+
+func TestVerify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stubs cosign with a shell script")
+	}
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "cosign"), []byte("#!/bin/sh\necho \"$@\" > \""+bin+"/args\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	head := "1111111111111111111111111111111111111111"
+	sum := sha256.Sum256([]byte("binary"))
+	files := map[string]string{
+		"app.tar.gz":     "binary",
+		"SHA256SUMS":     hex.EncodeToString(sum[:]) + "  app.tar.gz\n",
+		"SHA256SUMS.sig": "signature",
+	}
+	git := &fakeGit{
+		tags:       []string{"v1.2.0"},
+		tagged:     map[string]string{"v1.2.0": head},
+		remoteTags: []gitrepo.Tag{{Name: "v1.2.0", Commit: head}},
+	}
+	newApp := func(f *fakeFetcher) (*app, *bytes.Buffer, *bytes.Buffer) {
+		a, stdout, stderr := newTestApp(git)
+		a.cfg.Artifacts.Sign, a.cfg.Artifacts.Key = "cosign", "cosign.key"
+		a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+		a.newPublisher = func(string, string, publisherOptions) (publish.Publisher, error) { return f, nil }
+		return a, stdout, stderr
+	}
+
+	a, stdout, stderr := newApp(&fakeFetcher{commit: head, files: files})
+	if code := a.run(context.Background(), []string{"verify", "1.2.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "✓ tag v1.2.0\n✓ release v1.2.0 on github octo/app\n✓ checksums on github octo/app\n✓ signatures on github octo/app\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+	args, _ := os.ReadFile(filepath.Join(bin, "args"))
+	if !strings.HasPrefix(string(args), "verify-blob --signature ") || !strings.Contains(string(args), "--key cosign.pub ") {
+		t.Errorf("Expected cosign verify-blob with the public key, got %q", args)
+	}
+
+	tampered := maps.Clone(files)
+	tampered["app.tar.gz"] = "evil"
+	git.remoteTags = []gitrepo.Tag{{Name: "v1.2.0", Commit: "2222222222222222222222222222222222222222"}}
+	a, stdout, stderr = newApp(&fakeFetcher{commit: head, files: tampered})
+	if code := a.run(context.Background(), []string{"verify", "-signatures=false", "v1.2.0"}); code != 5 {
+		t.Fatalf("Expected exit code 5 for drift, got %d: %s", code, stderr)
+	}
+	for _, want := range []string{"v1.2.0 should point at 1111111 but points at 2222222 on origin", "SHA256SUMS does not match app.tar.gz"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("Expected %q in %q", want, stderr)
+		}
+	}
+	if strings.Contains(stdout.String(), "signatures") {
+		t.Errorf("Expected no signature check, got %q", stdout)
+	}
+
+	if code := a.run(context.Background(), []string{"verify"}); code != 2 {
+		t.Errorf("Expected exit code 2 without a version, got %d", code)
+	}
+}
+
+func TestReproduce(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("CI_PROJECT_PATH", "")
+	t.Setenv("GOWORK", "off")
+	t.Setenv("GOFLAGS", "")
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/hello\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(root, "cmd", "hello", "main.go"), "package main\n\nvar version = \"dev\"\n\nfunc main() { println(version) }\n")
+
+	head := "0000000000000000000000000000000000000000"
+	git := &fakeGit{tags: []string{"v1.2.0"}, tagged: map[string]string{"v1.2.0": head}}
+	newApp := func(f *fakeFetcher) (*app, *bytes.Buffer, *bytes.Buffer) {
+		a, stdout, stderr := newTestApp(git)
+		a.root = root
+		a.cfg.Artifacts = config.ArtifactsConfig{Package: "./cmd/hello", Targets: []string{"linux/amd64"}, LDFlags: "-X main.version={{ .Version }}", Reproducible: true}
+		a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+		a.newPublisher = func(string, string, publisherOptions) (publish.Publisher, error) { return f, nil }
+		return a, stdout, stderr
+	}
+
+	a, _, stderr := newApp(nil)
+	paths, err := a.buildArtifacts(context.Background(), a.cfg.Artifacts, "v1.2.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, stderr)
+	}
+	binary, _ := os.ReadFile(paths[0])
+	name := filepath.Base(paths[0])
+
+	a, stdout, stderr := newApp(&fakeFetcher{commit: head, files: map[string]string{name: string(binary)}})
+	if code := a.run(context.Background(), []string{"reproduce", "1.2.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "✓ release v1.2.0 on github octo/app\n✓ reproduce " + name + " on github octo/app\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+
+	a, _, stderr = newApp(&fakeFetcher{commit: head, files: map[string]string{name: "not the binary"}})
+	if code := a.run(context.Background(), []string{"reproduce", "v1.2.0"}); code != 5 {
+		t.Fatalf("Expected exit code 5, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr.String(), name+" differs from its rebuild") {
+		t.Errorf("Expected the binary not reproduced, got %q", stderr)
+	}
+
+	git.dirty = []string{"main.go"}
+	a, _, stderr = newApp(&fakeFetcher{})
+	if code := a.run(context.Background(), []string{"reproduce", "v1.2.0"}); code != 4 || !strings.Contains(stderr.String(), "uncommitted changes to main.go") {
+		t.Errorf("Expected a dirty tree refused, got %d: %s", code, stderr)
+	}
+	git.dirty, git.tagged = nil, map[string]string{"v1.2.0": "1111111111111111111111111111111111111111"}
+	a, _, stderr = newApp(&fakeFetcher{})
+	if code := a.run(context.Background(), []string{"reproduce", "v1.2.0"}); code != 4 || !strings.Contains(stderr.String(), "not the commit of v1.2.0") {
+		t.Errorf("Expected another HEAD refused, got %d: %s", code, stderr)
+	}
+	a, _, _ = newApp(&fakeFetcher{})
+	a.cfg.Artifacts = config.ArtifactsConfig{}
+	if code := a.run(context.Background(), []string{"reproduce", "v1.2.0"}); code != 6 {
+		t.Errorf("Expected exit code 6 without artifacts, got %d", code)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTagAll(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "api", "go.mod"), "module example.com/mono/api\n")
+	writeTestFile(t, filepath.Join(root, "core", "go.mod"), "module example.com/mono/core\n\nrequire example.com/mono/api v1.0.0\n")
+	writeTestFile(t, filepath.Join(root, "app", "go.mod"), "module example.com/mono/app\n\nrequire (\n\texample.com/mono/api v1.0.0\n\texample.com/mono/core v0.3.0\n)\n")
+	writeTestFile(t, filepath.Join(root, "docs", "go.mod"), "module example.com/mono/docs\n")
+	newGit := func() *fakeGit {
+		return &fakeGit{
+			tags: []string{"api/v1.0.0", "core/v0.3.0", "app/v0.1.0", "docs/v0.1.0"},
+			byPath: map[string][]gitrepo.Commit{
+				"api":  {{Hash: "a1", Message: "feat: add endpoint"}},
+				"app":  {{Hash: "a2", Message: "fix: crash"}},
+				"core": nil,
+				"docs": nil,
+			},
+		}
+	}
+
+	git := newGit()
+	a, stdout, stderr := newTestApp(git)
+	a.root = root
+	if code := a.run(context.Background(), []string{"tag", "-all", "-tidy=false"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "api/v1.1.0\ncore/v0.3.1\napp/v0.1.1\n" {
+		t.Errorf("Expected the modules tagged in dependency order, got %q", stdout)
+	}
+	expected := []string{
+		"chore(release): require example.com/mono/api v1.1.0: core/go.mod",
+		"chore(release): require example.com/mono/api v1.1.0, example.com/mono/core v0.3.1: app/go.mod",
+	}
+	if !reflect.DeepEqual(git.committed, expected) {
+		t.Errorf("Expected %v, got %v", expected, git.committed)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "app", "go.mod"))
+	if string(data) != "module example.com/mono/app\n\nrequire (\n\texample.com/mono/api v1.1.0\n\texample.com/mono/core v0.3.1\n)\n" {
+		t.Errorf("Expected the requirements of app moved, got %q", data)
+	}
+	if len(a.result.Modules) != 3 || a.result.Modules[1] != (moduleResult{Name: "example.com/mono/core", Dir: "core", Current: "core/v0.3.0", Next: "core/v0.3.1", Bump: "patch"}) {
+		t.Errorf("Expected the tagged modules in the result, got %+v", a.result.Modules)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	bin := t.TempDir()
+	writeTestFile(t, filepath.Join(bin, "go"), "#!/bin/sh\necho \"$*\" > go.sum\n")
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	writeTestFile(t, filepath.Join(root, "core", "go.mod"), "module example.com/mono/core\n\nrequire example.com/mono/api v1.0.0\n")
+	git = newGit()
+	a, _, stderr = newTestApp(git)
+	a.root = root
+	if code := a.run(context.Background(), []string{"tag", "-all"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if sum, _ := os.ReadFile(filepath.Join(root, "core", "go.sum")); string(sum) != "mod tidy\n" || git.committed[0] != expected[0]+" core/go.sum" {
+		t.Errorf("Expected go mod tidy run in core and go.sum committed, got %q and %v", sum, git.committed)
+	}
+
+	git = newGit()
+	git.byPath["api"], git.byPath["app"] = nil, nil
+	a, _, _ = newTestApp(git)
+	a.root = root
+	if code := a.run(context.Background(), []string{"tag", "-all"}); code != 3 {
+		t.Errorf("Expected exit code 3 with nothing to release, got %d", code)
+	}
+}
+
+func TestReport(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 2, d, 12, 0, 0, 0, time.UTC) }
+	git := &fakeGit{
+		tags:   []string{"v1.1.0", "v1.2.0"},
+		tagged: map[string]string{"v1.1.0": "a", "v1.2.0": "c"},
+		commits: []gitrepo.Commit{
+			{Hash: "c", Parents: []string{"b"}, Message: "fix: second", AuthorName: "Bob", AuthorEmail: "bob@example.com", Date: day(4)},
+			{Hash: "b", Parents: []string{"a"}, Message: "feat: first", AuthorName: "Ada", AuthorEmail: "ada@example.com", Date: day(3)},
+			{Hash: "a", Message: "chore: init", AuthorName: "Ada", AuthorEmail: "ada@example.com", Date: day(1)},
+		},
+	}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	f := &fakeFetcher{url: "https://github.com/octo/app/releases/tag/v1.2.0"}
+	a.newPublisher = func(string, string, publisherOptions) (publish.Publisher, error) { return f, nil }
+
+	file := filepath.Join(a.root, "releases.html")
+	if code := a.run(context.Background(), []string{"report", "-file", file, "-title", "App"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout.String() != "wrote "+file+" with 2 release(s)\n" {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+	page, _ := os.ReadFile(file)
+	for _, want := range []string{
+		"<title>App</title>",
+		`<time datetime="2026-02-04">2026-02-04</time>`,
+		`<td class="commits">2</td>`,
+		`<td class="contributors">Ada, Bob</td>`,
+		`<a href="https://github.com/octo/app/releases/tag/v1.2.0">github octo/app</a>`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("Expected the page to contain %q, got:\n%s", want, page)
+		}
+	}
+	if f.fetched != 2 {
+		t.Errorf("Expected both releases looked up, got %d lookups", f.fetched)
+	}
+	if _, err := os.Stat(filepath.Join(a.root, stateDir, reportCacheFile)); err != nil {
+		t.Errorf("Expected the pages cached, got %v", err)
+	}
+
+	// The page of v1.2.0 is cached; v1.1.0 had none and is looked up again.
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"report", "-fragment", "-limit", "1"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if f.fetched != 2 {
+		t.Errorf("Expected the cached page used, got %d lookups", f.fetched)
+	}
+	if s := stdout.String(); !strings.HasPrefix(s, `<section class="releases">`) || strings.Contains(s, "v1.1.0") || !strings.Contains(s, `id="v1.2.0"`) {
+		t.Errorf("Expected the fragment of the latest release, got:\n%s", s)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"report", "-links=false"}); code != 0 || strings.Contains(stdout.String(), "github octo/app") {
+		t.Errorf("Expected a report without links, got %d: %s", code, stdout)
+	}
+	if code := a.run(context.Background(), []string{"report", "-limit", "-1"}); code != 2 {
+		t.Errorf("Expected exit code 2 for a negative limit, got %d", code)
+	}
+
+	// Offline, the cached pages are linked and nothing is looked up.
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"-offline", "report", "-fragment"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if f.fetched != 2 || !strings.Contains(stdout.String(), `<a href="https://github.com/octo/app/releases/tag/v1.2.0">`) {
+		t.Errorf("Expected only the cached page linked offline, got %d lookups and:\n%s", f.fetched, stdout)
+	}
+	if code := a.run(context.Background(), []string{"-offline", "report", "-refresh"}); code != 2 {
+		t.Errorf("Expected exit code 2 for -refresh offline, got %d", code)
+	}
+}
+
+// draftPublisher keeps drafts and reads reviews, as the GitHub publisher
+// does.
+type draftPublisher struct {
+	published []publish.Release
+	promoted  []string
+	approvers []string
+}
+
+func (p *draftPublisher) Publish(_ context.Context, r publish.Release) (publish.Result, error) {
+	p.published = append(p.published, r)
+	return publish.Result{URL: "https://x/r/draft"}, nil
+}
+
+func (p *draftPublisher) Promote(_ context.Context, tag string) (publish.Result, bool, error) {
+	if len(p.published) == 0 {
+		return publish.Result{}, false, nil
+	}
+	p.promoted = append(p.promoted, tag)
+	return publish.Result{URL: "https://x/r/1"}, true, nil
+}
+
+func (p *draftPublisher) CommitPullRequest(_ context.Context, commit string) (int, bool, error) {
+	return 7, commit == "abc", nil
+}
+
+func (p *draftPublisher) Approvers(context.Context, int) ([]string, error) {
+	return p.approvers, nil
+}
+
+func TestDraftApprove(t *testing.T) {
+	git := &fakeGit{tags: []string{"v1.1.0"}, tagged: map[string]string{"v1.1.0": "abc"}}
+	a, stdout, stderr := newTestApp(git)
+	a.root = t.TempDir()
+	a.state = &state.Store{Dir: t.TempDir(), Now: a.now}
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}, {Provider: "gitlab", Repo: "group/app"}}
+	a.cfg.Approval.Approvers = []string{"alice", "Bob"}
+	drafts := &draftPublisher{}
+	deferred := &fakePublisher{publish.Result{URL: "https://x/gitlab/1"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		if provider == "gitlab" {
+			return deferred, nil
+		}
+		return drafts, nil
+	}
+	notes := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(notes, []byte("notes"), 0o644)
+
+	if code := a.run(context.Background(), []string{"approve", "-notes", notes, "v1.1.0"}); code != 4 {
+		t.Errorf("Expected exit code 4 without approvals, got %d: %s", code, stderr)
+	}
+
+	stderr.Reset()
+	drafts.approvers = []string{"alice", "bob"}
+	if code := a.run(context.Background(), []string{"draft", "-notes", notes, "v1.1.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "pushed v1.1.0 to origin\ndrafted https://x/r/draft\nleft gitlab group/app to approve: it keeps no drafts\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	if len(drafts.published) != 1 || !drafts.published[0].Draft {
+		t.Errorf("Expected a draft release, got %+v", drafts.published)
+	}
+	r, err := a.state.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.State != state.Drafted {
+		t.Errorf("Expected %s, got %s", state.Drafted, r.State)
+	}
+
+	drafts.approvers = []string{"alice"}
+	if code := a.run(context.Background(), []string{"approve", "-notes", notes}); code != 4 {
+		t.Errorf("Expected exit code 4 without every approval, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "pull request #7 of v1.1.0 lacks the approval of Bob") {
+		t.Errorf("Expected the missing approver named, got %q", stderr)
+	}
+	if len(drafts.promoted) != 0 {
+		t.Errorf("Expected no promotion, got %v", drafts.promoted)
+	}
+
+	stdout.Reset()
+	drafts.approvers = []string{"bob", "alice"}
+	if code := a.run(context.Background(), []string{"resume", "-notes", notes}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected = "resuming v1.1.0: approving\napproved by alice, Bob on pull request #7\npublished https://x/r/1\npublished https://x/gitlab/1\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+	if !reflect.DeepEqual(drafts.promoted, []string{"v1.1.0"}) || len(drafts.published) != 1 {
+		t.Errorf("Expected the draft promoted and not published again, got %v and %+v", drafts.promoted, drafts.published)
+	}
+	if len(git.pushed) != 1 {
+		t.Errorf("Expected the tag pushed once, by draft, got %v", git.pushed)
+	}
+	r, err = a.state.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.State != state.Announced || !reflect.DeepEqual(r.URLs, []string{"https://x/r/1", "https://x/gitlab/1"}) {
+		t.Errorf("Expected an announced release, got %+v", r)
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	git := &fakeGit{
+		tags:    []string{"v1.2.0"},
+		tagged:  map[string]string{"v1.2.0": "1111111111"},
+		commits: []gitrepo.Commit{{Hash: "abc", Message: "feat: x"}, {Hash: "def", Message: "fix: y"}},
+	}
+	a, stdout, stderr := newTestApp(git)
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if len(git.notes) != 0 {
+		t.Fatalf("Expected no notes without timeline.enabled, got %v", git.notes)
+	}
+
+	a.cfg.Timeline.Enabled = true
+	git.created = nil
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	head := "0000000000000000000000000000000000000000"
+	git.tags = append(git.tags, "v1.3.0")
+	git.tagged["v1.3.0"] = head
+
+	notesFile := filepath.Join(t.TempDir(), "notes.md")
+	writeTestFile(t, notesFile, "notes\n")
+	a.cfg.Publish = []config.PublishTarget{{Provider: "github", Repo: "octo/app"}}
+	a.newPublisher = func(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+		return publisherFunc(func(ctx context.Context, r publish.Release) (publish.Result, error) {
+			return publish.Result{ID: "7", URL: "https://github.com/octo/app/releases/tag/" + r.Tag}, nil
+		}), nil
+	}
+	git.pushed = nil
+	if code := a.run(context.Background(), []string{"publish", "-build=false", "-notes", notesFile, "v1.3.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !slices.Contains(git.pushed, "origin refs/notes/release") {
+		t.Errorf("Expected the notes pushed, got %v", git.pushed)
+	}
+	note, err := timeline.Parse([]byte(git.notes["refs/notes/release "+head]))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := note.Find("v1.3.0")
+	if r == nil || r.Version != "1.3.0" || r.Range != "v1.2.0..v1.3.0" || r.Commits != 2 || r.Bump != "minor" || r.Commit != head {
+		t.Fatalf("Expected the plan of v1.3.0 recorded, got %+v", r)
+	}
+	if len(r.Events) != 2 || r.Events[0].Step != timeline.Tagged || r.Events[1].Step != timeline.Published || r.Events[1].Command != "publish" {
+		t.Errorf("Expected tagged then published, got %+v", r.Events)
+	}
+	if len(r.Releases) != 1 || r.Releases[0].URL != "https://github.com/octo/app/releases/tag/v1.3.0" {
+		t.Errorf("Expected the GitHub release recorded, got %+v", r.Releases)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"history"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	expected := "v1.3.0 at 0000000: v1.2.0..v1.3.0, 2 commit(s), minor\n" +
+		"  2026-03-01T00:00:00Z  tagged (tag)\n" +
+		"  2026-03-01T00:00:00Z  published (publish)\n" +
+		"  github octo/app: https://github.com/octo/app/releases/tag/v1.3.0\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+
+	stdout.Reset()
+	if code := a.run(context.Background(), []string{"history", "-format", "json", "1.3.0"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	var records []timeline.Record
+	if err := json.Unmarshal(stdout.Bytes(), &records); err != nil || len(records) != 1 || records[0].Tag != "v1.3.0" {
+		t.Errorf("Expected the record of v1.3.0 as JSON, got %q (%v)", stdout, err)
+	}
+	if code := a.run(context.Background(), []string{"history", "v1.2.0"}); code != 4 {
+		t.Errorf("Expected exit code 4 for a release without a timeline, got %d", code)
+	}
+
+	a.dryRun = true
+	stdout.Reset()
+	git.tags, git.created = []string{"v1.3.0"}, nil
+	if code := a.run(context.Background(), []string{"tag"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "[dry-run] would write the note of 0000000 to refs/notes/release") {
+		t.Errorf("Expected the note described, got %q", stdout)
+	}
+}
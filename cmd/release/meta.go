@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/bumpfiles"
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/manifest"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// metaRunner runs the steps of release meta in the checkout of a
+// repository of the manifest.
+type metaRunner interface {
+	// Release runs `release -output json args...` in dir and returns the
+	// result it writes, failures included.
+	Release(ctx context.Context, dir string, args []string) (*result, error)
+	// Repo opens the git repository checked out in dir.
+	Repo(ctx context.Context, dir string) (gitrepo.Repository, error)
+}
+
+// execRunner runs this executable in the checkouts, each with its own
+// configuration, hooks and working directory. Its human output goes to
+// stderr.
+type execRunner struct{ stderr io.Writer }
+
+func (r execRunner) Release(ctx context.Context, dir string, args []string) (*result, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, exe, append([]string{"-output", outputJSON}, args...)...)
+	var out bytes.Buffer
+	cmd.Dir, cmd.Stdout, cmd.Stderr = dir, &out, r.stderr
+	err = cmd.Run()
+	var res result
+	if derr := json.Unmarshal(out.Bytes(), &res); derr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("release in %s: %w", dir, err)
+		}
+		return nil, fmt.Errorf("release in %s: reading its result: %w", dir, derr)
+	}
+	return &res, nil
+}
+
+func (execRunner) Repo(ctx context.Context, dir string) (gitrepo.Repository, error) {
+	return gitrepo.Open(ctx, dir)
+}
+
+// meta releases the repositories of a manifest, in the order of their
+// needs: in each, the versions just released of the repositories it needs
+// are written where its propagate entries say and committed and pushed,
+// then `release tag` and `release publish` run in its checkout. A
+// repository whose needs moved is released as a patch even without changes
+// of its own. Before the repositories needing one are released, the CI of
+// its new tag must pass. The first failure stops the platform release.
+func (a *app) meta(ctx context.Context, args []string) error {
+	fs := a.flags("meta")
+	path := fs.String("manifest", manifest.FileName, "manifest listing the repositories to release")
+	waitCI := fs.Bool("wait-ci", true, "wait for the CI of each release before releasing the repositories needing it")
+	ciTimeout := fs.Duration("ci-timeout", 0, "how long CI may take (default: ci.timeout of the manifest, or 30m)")
+	ciInterval := fs.Duration("ci-interval", 0, "how often the CI status is checked (default: ci.interval of the manifest, or 30s)")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: meta takes no arguments", relerr.ErrUsage)
+	}
+	a.result.DryRun = *dryRun
+
+	m, err := manifest.Load(*path)
+	if err != nil {
+		return relerr.Wrap(relerr.Config, err)
+	}
+	groups, err := m.Order()
+	if err != nil {
+		return relerr.Wrap(relerr.Config, err)
+	}
+	timeout, interval := m.CI.Durations()
+	timeout, interval = cmp.Or(*ciTimeout, timeout), cmp.Or(*ciInterval, interval)
+	runner := a.metaRunner
+	if runner == nil {
+		runner = execRunner{stderr: a.stderr}
+	}
+	name := cmp.Or(m.Name, "platform")
+
+	released := make(map[string]*result)
+	var done []string
+	for gi, group := range groups {
+		for ri, r := range group {
+			res, err := a.metaRelease(ctx, runner, r, released, *dryRun)
+			if err == nil && res != nil && *waitCI && !*dryRun && m.Needed(r.Name) {
+				err = a.waitCI(ctx, runner, r, res, timeout, interval)
+			}
+			if err != nil {
+				var left []string
+				for _, rest := range slices.Concat(group[ri+1:], slices.Concat(groups[gi+1:]...)) {
+					left = append(left, rest.Name)
+				}
+				if len(left) > 0 {
+					fmt.Fprintf(a.stdout, "not released: %s\n", strings.Join(left, ", "))
+				}
+				if len(done) == 0 {
+					done = []string{"nothing"}
+				}
+				return fmt.Errorf("%s release stopped at %s, released: %s: %w", name, r.Name, strings.Join(done, ", "), err)
+			}
+			if res != nil {
+				released[r.Name] = res
+				done = append(done, r.Name+" "+res.Tag)
+			}
+		}
+	}
+	if len(released) == 0 {
+		return relerr.ErrNoCommitsSinceTag
+	}
+	return nil
+}
+
+// metaRelease propagates the versions released to r, then tags and
+// publishes it. The result of its tag is returned, or nil when it has
+// nothing to release.
+func (a *app) metaRelease(ctx context.Context, runner metaRunner, r manifest.Repository, released map[string]*result, dryRun bool) (*result, error) {
+	propagated, err := a.propagate(ctx, runner, r, released, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.Name, err)
+	}
+	global := []string{}
+	if dryRun {
+		global = append(global, "-dry-run")
+	}
+	res, err := a.metaStep(ctx, runner, r, slices.Concat(global, []string{"tag"}))
+	if relerr.KindOf(err) == relerr.NothingToRelease && propagated {
+		res, err = a.metaStep(ctx, runner, r, slices.Concat(global, []string{"tag", "-bump", "patch"}))
+	}
+	if relerr.KindOf(err) == relerr.NothingToRelease {
+		fmt.Fprintf(a.stdout, "%s: nothing to release\n", r.Name)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.result.Modules = append(a.result.Modules, moduleResult{Name: r.Name, Dir: r.Path, Current: res.PreviousTag, Next: res.Tag, Bump: res.Bump})
+	if dryRun {
+		dryrun.Printf(a.stdout, "would run release publish %s in %s", res.Tag, r.Path)
+		return res, nil
+	}
+	published, err := a.metaStep(ctx, runner, r, []string{"publish", res.Tag})
+	if err != nil {
+		return nil, err
+	}
+	res.Releases = published.Releases
+	a.result.Releases = append(a.result.Releases, published.Releases...)
+	fmt.Fprintf(a.stdout, "%s: released %s\n", r.Name, res.Tag)
+	return res, nil
+}
+
+// metaStep runs release with args in the checkout of r. A failure keeps
+// the kind its exit status tells.
+func (a *app) metaStep(ctx context.Context, runner metaRunner, r manifest.Repository, args []string) (*result, error) {
+	res, err := runner.Release(ctx, r.Path, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.Name, err)
+	}
+	if res.ExitCode == 0 {
+		return res, nil
+	}
+	err = fmt.Errorf("%s: release %s: %s", r.Name, res.Command, cmp.Or(res.Error, fmt.Sprintf("exit status %d", res.ExitCode)))
+	for kind, code := range exitCodes {
+		if code == res.ExitCode {
+			return nil, relerr.Wrap(kind, err)
+		}
+	}
+	return nil, err
+}
+
+// propagate writes the versions released of the repositories r needs
+// where its propagate entries say, then commits and pushes the files
+// changed. It reports whether any version moved.
+func (a *app) propagate(ctx context.Context, runner metaRunner, r manifest.Repository, released map[string]*result, dryRun bool) (bool, error) {
+	b := &bumpfiles.Bumper{Dir: r.Path, DryRun: dryRun, Log: a.stdout, Logger: a.log}
+	var paths, moved []string
+	for _, p := range r.Propagate {
+		res, ok := released[p.From]
+		if !ok {
+			continue
+		}
+		value := res.Version
+		if p.Tag {
+			value = res.Tag
+		}
+		changes, err := b.Bump([]bumpfiles.File{p.File()}, value)
+		if err != nil {
+			return false, err
+		}
+		if changes[0].Old == value {
+			continue
+		}
+		if !slices.Contains(paths, p.Path) {
+			paths = append(paths, p.Path)
+		}
+		if m := p.From + " " + value; !slices.Contains(moved, m) {
+			moved = append(moved, m)
+		}
+	}
+	if len(paths) == 0 {
+		return false, nil
+	}
+
+	git, err := runner.Repo(ctx, r.Path)
+	if err != nil {
+		return false, err
+	}
+	branch, err := git.CurrentBranch(ctx)
+	if err != nil {
+		return false, fmt.Errorf("pushing the versions propagated: %w", err)
+	}
+	repo := git
+	if dryRun {
+		repo = gitrepo.DryRun(git, a.stdout)
+	}
+	if err := repo.CommitFiles(ctx, "chore(release): require "+strings.Join(moved, ", "), paths...); err != nil {
+		return false, err
+	}
+	if err := repo.Push(ctx, cmp.Or(r.Remote, "origin"), branch); err != nil {
+		return false, relerr.Wrap(relerr.Provider, err)
+	}
+	return true, nil
+}
+
+// waitCI waits for the CI of the commit of the tag just released in r to
+// pass, on the provider of r or else of its first release that reports
+// CI status. Checks that have not reported yet count as pending.
+func (a *app) waitCI(ctx context.Context, runner metaRunner, r manifest.Repository, res *result, timeout, interval time.Duration) error {
+	var checker publish.StatusChecker
+	targets := []config.PublishTarget{{Provider: r.Provider, Repo: r.Repo}}
+	if r.Provider == "" {
+		targets = nil
+		for _, rel := range res.Releases {
+			targets = append(targets, config.PublishTarget{Provider: rel.Provider, Repo: rel.Repo})
+		}
+	}
+	for _, t := range targets {
+		p, err := a.publisher(ctx, t, false)
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.Name, err)
+		}
+		if s, ok := p.(publish.StatusChecker); ok {
+			checker = s
+			break
+		}
+	}
+	if checker == nil {
+		a.log.Warn(fmt.Sprintf("%s: no provider reports the CI status of %s; not waiting for it", r.Name, res.Tag))
+		return nil
+	}
+
+	git, err := runner.Repo(ctx, r.Path)
+	if err != nil {
+		return err
+	}
+	tags, err := git.Tags(ctx)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(tags, func(t gitrepo.Tag) bool { return t.Name == res.Tag })
+	if i < 0 {
+		return fmt.Errorf("%s: tag %s not found", r.Name, res.Tag)
+	}
+	commit := tags[i].Commit
+
+	wait, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for first := true; ; first = false {
+		s, err := checker.CIStatus(wait, commit)
+		if err != nil && wait.Err() == nil {
+			return relerr.Wrap(relerr.Provider, fmt.Errorf("%s: %w", r.Name, err))
+		}
+		switch s.State {
+		case publish.CISuccess:
+			fmt.Fprintf(a.stdout, "%s: CI passed on %s\n", r.Name, res.Tag)
+			return nil
+		case publish.CIFailure:
+			return relerr.Wrap(relerr.Precondition, fmt.Errorf("%s: CI failed on %s: %s %s", r.Name, res.Tag, strings.Join(s.Checks, ", "), s.URL))
+		}
+		if first {
+			fmt.Fprintf(a.stdout, "%s: waiting for CI on %s\n", r.Name, res.Tag)
+		}
+		select {
+		case <-wait.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return relerr.Wrap(relerr.Precondition, fmt.Errorf("%s: CI of %s still %s after %v", r.Name, res.Tag, cmp.Or(s.State, publish.CIPending), timeout))
+		case <-time.After(interval):
+		}
+	}
+}
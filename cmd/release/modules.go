@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+func (a *app) modules(ctx context.Context, args []string) error {
+	fs := a.flags("modules")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ms, err := workspace.Resolve(a.root, a.cfg)
+	if err != nil {
+		return err
+	}
+
+	channel, err := a.channel(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MODULE\tDIR\tCURRENT\tNEXT\tBUMP")
+	for _, m := range ms {
+		p, err := a.analyse(ctx, m)
+		if err != nil {
+			return err
+		}
+		p = p.OnChannel(channel)
+		current, next := "-", "-"
+		if p.HasPrevious {
+			current = p.PreviousTag
+		}
+		if p.Level != version.None {
+			next = p.Tag()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", m.Name, m.Dir, current, next, p.Level)
+		mr := moduleResult{Name: m.Name, Dir: m.Dir, Current: p.PreviousTag, Bump: p.Level.String()}
+		if p.Level != version.None {
+			mr.Next = p.Tag()
+		}
+		a.result.Modules = append(a.result.Modules, mr)
+	}
+	return w.Flush()
+}
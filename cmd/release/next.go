@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/ci"
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// module resolves the -module flag. An empty key selects the whole
+// repository, tagged with the configured prefix or template.
+func (a *app) module(key string) (workspace.Module, error) {
+	if key == "" {
+		return workspace.Repository(a.cfg.Tag.Prefix).WithTemplate(a.cfg.Tag.Template)
+	}
+	ms, err := workspace.Resolve(a.root, a.cfg)
+	if err != nil {
+		return workspace.Module{}, err
+	}
+	m, ok := workspace.Find(ms, key)
+	if !ok {
+		return workspace.Module{}, fmt.Errorf("unknown module %q", key)
+	}
+	return m, nil
+}
+
+// tagVersion returns the version tag names: that of the repository's tag
+// format, or of the first module's whose format it is in, or tag without
+// the configured prefix.
+func (a *app) tagVersion(tag string) string {
+	if m, ok := a.tagModule(tag); ok {
+		v, _ := m.Version(tag)
+		return v.String()
+	}
+	return strings.TrimPrefix(tag, a.cfg.Tag.Prefix)
+}
+
+// tagModule returns the module tag names a version of: the repository,
+// or else the first module whose tag format it is in.
+func (a *app) tagModule(tag string) (workspace.Module, bool) {
+	ms, _ := workspace.Resolve(a.root, a.cfg)
+	if m, err := a.module(""); err == nil {
+		ms = append([]workspace.Module{m}, ms...)
+	}
+	for _, m := range ms {
+		if _, ok := m.Version(tag); ok {
+			return m, true
+		}
+	}
+	return workspace.Module{}, false
+}
+
+// planOptions are the flags shared by the commands that compute a plan.
+type planOptions struct {
+	// bump is "auto" to derive the level from commits, or a level name.
+	bump string
+	// module selects a monorepo module; see module.
+	module string
+	// channel overrides the prerelease channel derived from the branch.
+	channel string
+}
+
+// planFlags registers -bump, -module and -channel on fs.
+func (a *app) planFlags(fs *flag.FlagSet) *planOptions {
+	o := &planOptions{}
+	fs.StringVar(&o.bump, "bump", "auto", "bump level: auto, patch, minor or major")
+	fs.StringVar(&o.module, "module", "", "release only this module (directory or name, see 'release modules')")
+	fs.StringVar(&o.channel, "channel", "", "prerelease channel, e.g. rc (default: from the branch and the channels config)")
+	return o
+}
+
+// newPlan computes the next version of the module selected by o.
+func (a *app) newPlan(ctx context.Context, o *planOptions) (workspace.Plan, error) {
+	m, err := a.module(o.module)
+	if err != nil {
+		return workspace.Plan{}, err
+	}
+	return a.planModule(ctx, m, o)
+}
+
+// planModule computes the next version of m with the channel and bump of
+// o. It fails with ErrNoCommitsSinceTag, along with the plan, when m has
+// nothing to release.
+func (a *app) planModule(ctx context.Context, m workspace.Module, o *planOptions) (workspace.Plan, error) {
+	p, err := a.analyse(ctx, m)
+	if err != nil {
+		return p, err
+	}
+	if a.cfg.Tag.Verify && p.HasPrevious {
+		if err := a.git.VerifyTag(ctx, p.PreviousTag, a.signing()); err != nil {
+			return p, fmt.Errorf("refusing to release on top of %s: %w", p.PreviousTag, err)
+		}
+	}
+
+	channel := o.channel
+	if channel == "" {
+		if channel, err = a.channel(ctx); err != nil {
+			return p, err
+		}
+	}
+	p = p.OnChannel(channel)
+
+	if o.bump != "auto" {
+		l, err := version.ParseLevel(o.bump)
+		if err != nil {
+			return p, err
+		}
+		p = p.WithLevel(l)
+	}
+	if p.Line != nil && p.Level > version.Patch {
+		return p, fmt.Errorf("%w: %s would be a %s release of line %s; release it from %s, or pass -bump patch",
+			relerr.ErrMaintenanceBump, p.Tag(), p.Level, p.Line, strings.Join(a.cfg.Branches, " or "))
+	}
+	if p.Level == version.None {
+		return p, relerr.ErrNoCommitsSinceTag
+	}
+	return p, nil
+}
+
+// analyse plans the release of m with the configured version scheme.
+func (a *app) analyse(ctx context.Context, m workspace.Module) (workspace.Plan, error) {
+	s, err := version.ParseScheme(a.cfg.Versioning.Scheme, a.cfg.Versioning.Layout)
+	if err != nil {
+		return workspace.Plan{}, err
+	}
+	line, err := a.line(ctx)
+	if err != nil {
+		return workspace.Plan{}, err
+	}
+	var p workspace.Plan
+	if line != nil {
+		p, err = workspace.NewLinePlan(ctx, a.git, m, *line)
+	} else {
+		p, err = workspace.NewPlan(ctx, a.git, m)
+	}
+	if err != nil {
+		return p, err
+	}
+	if p, err = a.bootstrap(p); err != nil {
+		return p, err
+	}
+	cl, err := a.classifier()
+	if err != nil {
+		return p, err
+	}
+	return p.WithClassifier(cl).WithScheme(s, a.now().UTC()), nil
+}
+
+// classifier builds the commit classification rules of commits.rules.
+func (a *app) classifier() (commits.Classifier, error) {
+	var cl commits.Classifier
+	for i, r := range a.cfg.Commits.Rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return cl, relerr.Wrap(relerr.Config, fmt.Errorf("commits.rules[%d].pattern: %w", i, err))
+		}
+		rule := commits.ClassRule{Pattern: re, Section: r.Section, Hidden: r.Hidden}
+		if r.Bump != "" {
+			if rule.Level, err = version.ParseLevel(r.Bump); err != nil {
+				return cl, relerr.Wrap(relerr.Config, fmt.Errorf("commits.rules[%d].bump: %w", i, err))
+			}
+			rule.HasLevel = true
+		}
+		cl.Rules = append(cl.Rules, rule)
+	}
+	return cl, nil
+}
+
+// line returns the maintenance line configured for the current branch, or
+// nil when releasing from any other branch.
+func (a *app) line(ctx context.Context) (*version.Line, error) {
+	branch, err := a.branch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := a.cfg.Line(branch)
+	if name == "" {
+		return nil, nil
+	}
+	l, err := version.ParseLine(name)
+	if err != nil {
+		return nil, relerr.Wrap(relerr.Config, fmt.Errorf("lines: branch %s: %w", branch, err))
+	}
+	return &l, nil
+}
+
+// channel returns the prerelease channel configured for the current branch.
+// Without a branch the release is stable.
+func (a *app) channel(ctx context.Context) (string, error) {
+	branch, err := a.branch(ctx)
+	if err != nil {
+		return "", err
+	}
+	return a.cfg.Channel(branch), nil
+}
+
+// branch returns the branch released from: -branch when given, else the
+// current branch. On a detached HEAD, as CI checkouts often are, the
+// branch is taken from the CI environment, or is empty.
+func (a *app) branch(ctx context.Context) (string, error) {
+	if a.branchName != "" {
+		return a.branchName, nil
+	}
+	branch, err := a.git.CurrentBranch(ctx)
+	if errors.Is(err, gitrepo.ErrDetachedHead) {
+		return ciBranch(), nil
+	}
+	return branch, err
+}
+
+// ciBranch returns the branch being built according to the CI environment.
+func ciBranch() string {
+	env, _ := ci.FromEnv()
+	return env.Branch
+}
+
+func (a *app) next(ctx context.Context, args []string) error {
+	fs := a.flags("next")
+	opts := a.planFlags(fs)
+	explainFlag := fs.Bool("explain", false, "show the bump level each commit contributes")
+	format := fs.String("format", "text", "output format of -explain: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q: must be text or json", *format)
+	}
+
+	p, err := a.newPlan(ctx, opts)
+	if *explainFlag && (err == nil || errors.Is(err, relerr.ErrNoCommitsSinceTag)) {
+		e := explain(p, opts.bump != "auto")
+		write := e.writeTable
+		if *format == "json" {
+			write = e.writeJSON
+		}
+		if werr := write(a.stdout); werr != nil {
+			return werr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	a.result.setPlan(p)
+	fmt.Fprintln(a.stdout, p.Next)
+	return nil
+}
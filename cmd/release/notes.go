@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/ci"
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/gomod"
+	"github.com/gbrennon/release_automation_golang/pkg/notes"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+func (a *app) notes(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "edit" {
+		return a.notesEdit(ctx, args[1:])
+	}
+	fs := a.flags("notes")
+	opts := a.notesFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	p, text, err := a.renderNotes(ctx, opts)
+	if err != nil {
+		return err
+	}
+	a.result.setPlan(p)
+	a.result.Notes = string(text)
+	_, err = a.stdout.Write(text)
+	return err
+}
+
+// notesOptions are the flags rendering release notes.
+type notesOptions struct {
+	plan       *planOptions
+	template   string
+	repoURL    string
+	provider   string
+	highlights int
+}
+
+// notesFlags defines the flags of notesOptions on fs.
+func (a *app) notesFlags(fs *flag.FlagSet) *notesOptions {
+	o := &notesOptions{plan: a.planFlags(fs)}
+	fs.StringVar(&o.template, "template", "", "text/template file replacing the built-in release notes template")
+	fs.StringVar(&o.repoURL, "repo-url", "", "repository web URL used for links (default: from CI or the publish config)")
+	fs.StringVar(&o.provider, "provider", "github", "URL layout of -repo-url: github, gitlab, gitea or bitbucket")
+	fs.IntVar(&o.highlights, "highlights", 0, "lead with a Highlights section of up to this many changes: breaking ones, then the largest pull requests, then the most referenced issues")
+	return o
+}
+
+// renderNotes plans the next release and renders its generated notes.
+func (a *app) renderNotes(ctx context.Context, o *notesOptions) (workspace.Plan, []byte, error) {
+	p, err := a.newPlan(ctx, o.plan)
+	if err != nil {
+		return workspace.Plan{}, nil, err
+	}
+
+	links := a.links(o.provider, o.repoURL)
+	t, err := notesTemplate(o.template, links)
+	if err != nil {
+		return workspace.Plan{}, nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, a.notesData(ctx, p, links, o.highlights, false)); err != nil {
+		return workspace.Plan{}, nil, err
+	}
+	return p, buf.Bytes(), nil
+}
+
+// notesData returns the data the notes of p are rendered with, leading
+// with up to highlights changes. The commit stats include the size of the
+// commits, read from git, with highlights or when sized is set.
+func (a *app) notesData(ctx context.Context, p workspace.Plan, links notes.Links, highlights int, sized bool) notes.Data {
+	data := notes.NewData(notes.Metadata{
+		Version:     p.Next,
+		Tag:         p.Tag(),
+		PreviousTag: p.PreviousTag,
+		Date:        a.now(),
+	}, p.Raw, links)
+	if len(p.Classifier.Rules) > 0 {
+		data.Groups = changelog.New(p.Next.String(), data.Date, data.Commits, changelog.Options{Classifier: p.Classifier}).Groups
+	}
+	if _, ok := a.planUpgradeGuide(p); ok {
+		data.UpgradeGuideURL = links.File(p.Tag(), a.upgradeGuidePath(p.Next.String()))
+	}
+	data.Contributors = a.releaseContributors(ctx, contributors.List(p.Raw, a.cfg.Changelog.Contributors.Exclude...), p.Raw)
+	data.Dependencies = a.dependencyChanges(ctx, p)
+	data.Issues = a.resolvedIssues(ctx, p)
+	if highlights > 0 || sized {
+		sizes := a.diffStats(ctx, p.Raw)
+		data.Highlights = notes.Highlights(p.Raw, sizes, highlights)
+		data.Stats = notes.NewStats(p.Raw, sizes)
+	}
+	return data
+}
+
+// titleHighlights is the number of highlights given to tag message and
+// release title templates.
+const titleHighlights = 3
+
+// renderTitle renders the tag message or release title template text for
+// p, with the data of its release notes.
+func (a *app) renderTitle(ctx context.Context, text string, p workspace.Plan) (string, error) {
+	t, err := notes.NewTitle(text)
+	if err != nil {
+		return "", relerr.Wrap(relerr.Config, err)
+	}
+	return t.Render(a.notesData(ctx, p, a.links("github", ""), titleHighlights, true))
+}
+
+// curatedDir is the directory, relative to the repository root, holding
+// the release notes curated with `release notes edit`, one <version>.md
+// file per release.
+var curatedDir = filepath.Join(stateDir, "notes")
+
+// notesEdit opens the notes of the next release in the user's editor and
+// stores the result as its curated notes, which publish prefers to the
+// changelog. Curated notes are edited again on later runs; the generated
+// ones are only the starting point, and come back with -reset.
+func (a *app) notesEdit(ctx context.Context, args []string) error {
+	fs := a.flags("notes edit")
+	opts := a.notesFlags(fs)
+	reset := fs.Bool("reset", false, "discard the curated notes, going back to the generated ones")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: notes edit takes no arguments; pick the release with -module, -bump and -channel", relerr.ErrUsage)
+	}
+	a.result.DryRun = *dryRun
+
+	p, generated, err := a.renderNotes(ctx, opts)
+	if err != nil {
+		return err
+	}
+	a.result.setPlan(p)
+	version := p.Next.String()
+	file := filepath.Join(curatedDir, version+".md")
+	path := filepath.Join(a.root, file)
+
+	current, ok, err := a.curatedNotes(version)
+	if err != nil {
+		return err
+	}
+	if *reset {
+		if !ok {
+			fmt.Fprintf(a.stdout, "no curated notes for %s\n", version)
+			return nil
+		}
+		if *dryRun {
+			dryrun.Printf(a.stdout, "would remove %s", file)
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "removed %s: %s uses the generated notes\n", file, version)
+		return nil
+	}
+
+	if !ok {
+		current = string(generated)
+	}
+	edited, err := a.edit(ctx, []byte(current))
+	if err != nil {
+		return err
+	}
+	a.result.Notes = string(edited)
+	if string(edited) == current {
+		fmt.Fprintf(a.stdout, "the notes of %s are unchanged\n", version)
+		return nil
+	}
+	if *dryRun {
+		dryrun.Printf(a.stdout, "would write the curated notes of %s to %s", version, file)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, edited, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "wrote the curated notes of %s to %s\n", version, file)
+	return nil
+}
+
+// curatedNotes returns the notes of version curated with `release notes
+// edit`, and false when there are none.
+func (a *app) curatedNotes(version string) (string, bool, error) {
+	b, err := os.ReadFile(filepath.Join(a.root, curatedDir, version+".md"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("read curated notes: %w", err)
+	}
+	return string(b), true, nil
+}
+
+// dependencyChanges returns the changes to the direct requirements of the
+// go.mod of p's module between the previous tag and HEAD. First releases
+// and modules without a go.mod have none; failures to compare are only
+// warned about.
+func (a *app) dependencyChanges(ctx context.Context, p workspace.Plan) []notes.DependencyChange {
+	if p.PreviousTag == "" {
+		return nil
+	}
+	file := path.Join(p.Module.Dir, "go.mod")
+	read := func(rev string) ([]gomod.Require, error) {
+		data, err := a.git.ReadFile(ctx, rev, file)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return gomod.Parse(data)
+	}
+	old, err := read(p.PreviousTag)
+	var new []gomod.Require
+	if err == nil {
+		new, err = read("HEAD")
+	}
+	if err != nil {
+		a.log.Warn(fmt.Sprintf("could not compare the dependencies in %s: %v", file, err))
+		return nil
+	}
+	return gomod.Direct(gomod.Diff(old, new))
+}
+
+// diffStats returns the size of each of raw by hash. Commits whose size
+// cannot be read are left out, with a warning.
+func (a *app) diffStats(ctx context.Context, raw []gitrepo.Commit) map[string]gitrepo.DiffStat {
+	stats := make(map[string]gitrepo.DiffStat, len(raw))
+	for _, c := range raw {
+		s, err := a.git.DiffStat(ctx, c.Hash)
+		if err != nil {
+			a.log.Warn(fmt.Sprintf("highlights: could not read the size of %s: %v", c.Hash, err))
+			continue
+		}
+		stats[c.Hash] = s
+	}
+	return stats
+}
+
+// links returns the repository links for release notes: repoURL when set,
+// otherwise the repository of the links configuration, whose empty fields
+// come from the CI repository, then the first configured publish target.
+// The result is empty when no repository is known. The URL templates of
+// the links configuration apply whichever repository is found.
+func (a *app) links(provider, repoURL string) notes.Links {
+	l := a.repoLinks(provider, repoURL)
+	t := a.cfg.Links.Templates
+	l.Templates = notes.URLTemplates{Issue: t.Issue, PullRequest: t.PullRequest, Commit: t.Commit, Compare: t.Compare, File: t.File}
+	return l
+}
+
+func (a *app) repoLinks(provider, repoURL string) notes.Links {
+	if repoURL != "" {
+		return notes.Links{RepoURL: repoURL, Provider: provider}
+	}
+	lc := a.cfg.Links
+	env, ok := ci.FromEnv()
+	if lc.BaseURL == "" && lc.Repo == "" && ok && env.RepoURL() != "" {
+		return notes.Links{RepoURL: env.RepoURL(), Provider: cmp.Or(lc.Provider, env.Provider)}
+	}
+	if lc.Repo == "" && ok {
+		lc.Repo, provider = env.Repo, env.Provider
+	}
+	for _, t := range a.cfg.Publish {
+		if lc.Repo == "" && t.Repo != "" {
+			lc.Repo, provider = t.Repo, t.Provider
+			lc.BaseURL = cmp.Or(lc.BaseURL, webRoot(t.BaseURL))
+		}
+	}
+	if lc.Repo == "" {
+		return notes.Links{}
+	}
+	return notes.NewLinksAt(cmp.Or(lc.Provider, provider), lc.BaseURL, lc.Repo)
+}
+
+// notesTemplate loads the template at path, or returns the default
+// template when path is empty.
+func notesTemplate(path string, links notes.Links) (*notes.Template, error) {
+	if path == "" {
+		return notes.Default(links), nil
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return notes.New(string(text), links)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+)
+
+// notifyTimeout bounds the notifications sent after a release, which still
+// go out when the release itself was cancelled.
+const notifyTimeout = 30 * time.Second
+
+// notify sends e to the configured notifiers. Notification failures never
+// fail the release; they are reported as warnings.
+func (a *app) notify(ctx context.Context, e notify.Event, dryRun bool) {
+	if len(a.cfg.Notify) == 0 {
+		return
+	}
+	d, err := a.dispatcher(ctx)
+	if err == nil {
+		d.DryRun, d.Log, d.Logger = dryRun, a.stdout, a.log
+		err = d.Notify(ctx, e)
+	}
+	if err != nil {
+		a.log.Warn(err.Error())
+	}
+}
+
+// dispatcher builds the configured notifiers, expanding environment
+// variables in their settings; those of URLs and credentials are looked up
+// as secrets. Types naming a plugin are sent to it.
+func (a *app) dispatcher(ctx context.Context) (*notify.Dispatcher, error) {
+	d := &notify.Dispatcher{}
+	for _, t := range a.cfg.Notify {
+		var on []notify.Status
+		for _, s := range t.On {
+			on = append(on, notify.Status(s))
+		}
+		if pc, ok := a.cfg.Plugin(t.Type); ok {
+			d.Add(t.Type, pluginNotifier(pc, a.stderr), on...)
+			continue
+		}
+
+		to := make([]string, len(t.To))
+		for i, addr := range t.To {
+			to[i] = os.ExpandEnv(addr)
+		}
+		secret := a.secret(ctx)
+		n, err := notify.New(notify.Target{
+			Type:     t.Type,
+			URL:      os.Expand(t.URL, secret),
+			Addr:     os.ExpandEnv(t.SMTP),
+			From:     os.ExpandEnv(t.From),
+			To:       to,
+			Username: os.Expand(t.Username, secret),
+			Password: os.Expand(t.Password, secret),
+		})
+		if err != nil {
+			return nil, err
+		}
+		d.Add(t.Type, n, on...)
+	}
+	return d, nil
+}
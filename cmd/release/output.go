@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gbrennon/release_automation_golang/pkg/bench"
+	"github.com/gbrennon/release_automation_golang/pkg/ci"
+	"github.com/gbrennon/release_automation_golang/pkg/policy"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+	"github.com/gbrennon/release_automation_golang/pkg/timeline"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// Output formats of the global -output flag. Text is the human output;
+// json and yaml write a result to stdout once the command is done and
+// send the human output to stderr.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// Modes of the global -progress flag. Auto displays the progress of
+// releases on a terminal and reports it as lines elsewhere; plain always
+// reports lines.
+const (
+	progressAuto  = "auto"
+	progressPlain = "plain"
+)
+
+// result is the machine-readable outcome of a command. Its fields form a
+// stable schema: they are only ever added, and each command fills the ones
+// it knows about. YAML uses the JSON field names.
+type result struct {
+	Command string `json:"command"`
+	DryRun  bool   `json:"dry_run"`
+
+	// The plan: the module released and its next version.
+	Module      string `json:"module,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	PreviousTag string `json:"previous_tag,omitempty"`
+	Bump        string `json:"bump,omitempty"`
+	Channel     string `json:"channel,omitempty"`
+
+	// TagCreated is true once tag exists locally; Pushed lists the
+	// remotes it was pushed to, separated by commas, and Pushes the push
+	// to each remote, failed or not.
+	TagCreated bool         `json:"tag_created,omitempty"`
+	Pushed     string       `json:"pushed,omitempty"`
+	Pushes     []pushResult `json:"pushes,omitempty"`
+
+	Changelog string          `json:"changelog,omitempty"`
+	Notes     string          `json:"notes,omitempty"`
+	Assets    []string        `json:"assets,omitempty"`
+	Releases  []releaseResult `json:"releases,omitempty"`
+	Modules   []moduleResult  `json:"modules,omitempty"`
+	Checks    []checkResult   `json:"checks,omitempty"`
+	Commits   []commitResult  `json:"commits,omitempty"`
+	State     *state.Release  `json:"state,omitempty"`
+	// Diagnostics are the violations of the release policies found by
+	// preflight.
+	Diagnostics []policy.Diagnostic `json:"diagnostics,omitempty"`
+	// Comment is the URL of the pull request comment of preview -pr.
+	Comment string `json:"comment,omitempty"`
+	// Undone lists what rollback undid.
+	Undone []string `json:"undone,omitempty"`
+	// Pruned lists what prune deleted, or would delete in a dry run.
+	Pruned []pruneResult `json:"pruned,omitempty"`
+	// History is the release timeline read by history.
+	History []timeline.Record `json:"history,omitempty"`
+	// Benchmarks are the measurements of bench, and Regressions those
+	// grown beyond its threshold over the baseline.
+	Benchmarks  []bench.Result     `json:"benchmarks,omitempty"`
+	Regressions []bench.Regression `json:"regressions,omitempty"`
+
+	// Warnings counts the warnings the command logged.
+	Warnings int    `json:"warnings,omitempty"`
+	Error    string `json:"error,omitempty"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// releaseResult is a release created on one publish target.
+type releaseResult struct {
+	Provider string `json:"provider"`
+	Repo     string `json:"repo,omitempty"`
+	ID       string `json:"id,omitempty"`
+	URL      string `json:"url,omitempty"`
+	// Existing is true when an existing release was resumed.
+	Existing bool `json:"existing,omitempty"`
+	// Assets are the files uploaded by this run.
+	Assets []string `json:"assets,omitempty"`
+}
+
+// pushResult is the push of a release to a remote.
+type pushResult struct {
+	Remote string   `json:"remote"`
+	Refs   []string `json:"refs"`
+	// Error is set when the push failed.
+	Error string `json:"error,omitempty"`
+}
+
+// moduleResult is a row of `release modules`.
+type moduleResult struct {
+	Name    string `json:"name"`
+	Dir     string `json:"dir"`
+	Current string `json:"current,omitempty"`
+	Next    string `json:"next,omitempty"`
+	Bump    string `json:"bump"`
+}
+
+// checkResult is a preflight check and, when it failed, why.
+type checkResult struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Problem string `json:"problem,omitempty"`
+}
+
+// commitResult is a commit checked by lint-commits and its problems.
+type commitResult struct {
+	Hash     string   `json:"hash"`
+	Subject  string   `json:"subject"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// pruneResult is a release, or the assets of older snapshot builds, that
+// prune deleted, and why.
+type pruneResult struct {
+	Provider string `json:"provider"`
+	Repo     string `json:"repo,omitempty"`
+	Tag      string `json:"tag"`
+	// Kind is draft, prerelease, stable or, for assets, snapshot.
+	Kind   string   `json:"kind"`
+	Reason string   `json:"reason"`
+	URL    string   `json:"url,omitempty"`
+	Assets []string `json:"assets,omitempty"`
+	// Error is set when the deletion failed.
+	Error string `json:"error,omitempty"`
+}
+
+// setPlan records the release p plans.
+func (r *result) setPlan(p workspace.Plan) {
+	r.Module = p.Module.Name
+	r.Version = p.Next.String()
+	r.Tag = p.Tag()
+	r.PreviousTag = p.PreviousTag
+	r.Bump = p.Level.String()
+	r.Channel = p.Channel
+}
+
+// ciOutputs returns the step outputs of r: the plan, the tag and the
+// releases, newline-separated when there are several. Unset fields are
+// left out.
+func (r *result) ciOutputs() []ci.Output {
+	var urls []string
+	for _, rel := range r.Releases {
+		if rel.URL != "" {
+			urls = append(urls, rel.URL)
+		}
+	}
+	var outputs []ci.Output
+	add := func(name, value string) {
+		if value != "" {
+			outputs = append(outputs, ci.Output{Name: name, Value: value})
+		}
+	}
+	add("version", r.Version)
+	add("tag", r.Tag)
+	add("previous_tag", r.PreviousTag)
+	add("bump", r.Bump)
+	add("channel", r.Channel)
+	if r.TagCreated {
+		add("tag_created", "true")
+	}
+	add("pushed", r.Pushed)
+	if len(urls) > 0 {
+		add("url", urls[0])
+	}
+	add("urls", strings.Join(urls, "\n"))
+	add("assets", strings.Join(r.Assets, "\n"))
+	return outputs
+}
+
+// writeResult encodes r to w in format, json or yaml.
+func writeResult(w io.Writer, format string, r *result) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case outputYAML:
+		// Going through JSON keeps the field names and their order.
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		var n yaml.Node
+		if err := yaml.Unmarshal(b, &n); err != nil {
+			return err
+		}
+		blockStyle(&n)
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(&n); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unknown output format %q: must be text, json or yaml", format)
+}
+
+// blockStyle clears the flow and quoting styles JSON parses into, so that
+// n encodes as plain block YAML.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+	"github.com/gbrennon/release_automation_golang/pkg/plugin"
+	"github.com/gbrennon/release_automation_golang/pkg/providerapi"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// publisher returns the Publisher for t, whose provider is either a plugin
+// declared in the configuration or a built-in provider.
+func (a *app) publisher(ctx context.Context, t config.PublishTarget, dryRun bool) (publish.Publisher, error) {
+	if pc, ok := a.cfg.Plugin(t.Provider); ok {
+		return &pluginPublisher{cfg: pc, repo: t.Repo, stderr: a.stderr, DryRun: dryRun, Log: a.stdout}, nil
+	}
+	o := publisherOptions{
+		DryRun:    dryRun,
+		Log:       a.stdout,
+		Logger:    a.log,
+		Retry:     a.retry,
+		RateLimit: a.rateLimiter(t.Provider, t.BaseURL),
+		Uploads:   publish.Uploader{Concurrency: t.Concurrency, Progress: a.uploadProgress, Transfer: a.uploadTransfer, Interval: a.transferInterval()},
+		Lookups:   providerapi.Batches{Size: a.cfg.RateLimit.BatchSize, Concurrency: a.cfg.RateLimit.Concurrency},
+		Source:    t.Source,
+		Tags:      t.Tags,
+		Stderr:    a.stderr,
+		Homebrew:  a.homebrew(t.Homebrew),
+		Package:   t.Package,
+		Secret:    a.secret(ctx),
+
+		BaseURL:    t.BaseURL,
+		APIVersion: t.APIVersion,
+		Auth:       t.Auth,
+		HTTPClient: a.httpClient(),
+	}
+	if t.Provider == "github" {
+		// GraphQL queries draw on a budget of their own.
+		o.GraphQLRateLimit = a.rateLimiter("github graphql", t.BaseURL)
+	}
+	return a.newPublisher(t.Provider, t.Repo, o)
+}
+
+// homebrew fills in the formula name from the artifacts and the download
+// URL from the first GitHub publish target or the CI repository.
+func (a *app) homebrew(h config.HomebrewConfig) config.HomebrewConfig {
+	if h.Name == "" {
+		h.Name = a.cfg.Artifacts.Binary
+	}
+	if h.Name == "" && a.cfg.Artifacts.Package != "" {
+		h.Name = path.Base(a.cfg.Artifacts.Package)
+	}
+	if h.URL == "" {
+		for _, t := range a.publishTargets("", "") {
+			if t.Provider == "github" {
+				h.URL = cmp.Or(webRoot(t.BaseURL), "https://github.com") + "/" + t.Repo + "/releases/download/{{ .Tag }}/{{ .Name }}"
+				break
+			}
+		}
+	}
+	return h
+}
+
+// startPlugin runs the plugin declared by pc and checks that it provides
+// capability.
+func startPlugin(ctx context.Context, pc config.PluginConfig, stderr io.Writer, capability string) (*plugin.Client, error) {
+	c, err := plugin.Start(ctx, stderr, pc.Command, pc.Args...)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Has(capability) {
+		c.Close()
+		return nil, fmt.Errorf("plugin %s does not provide %s", pc.Name, capability)
+	}
+	return c, nil
+}
+
+// pluginPublisher starts its plugin for each release, so plugins only run
+// when a release actually targets them.
+type pluginPublisher struct {
+	cfg    config.PluginConfig
+	repo   string
+	stderr io.Writer
+
+	DryRun bool
+	Log    io.Writer
+}
+
+func (p *pluginPublisher) Publish(ctx context.Context, r publish.Release) (_ publish.Result, err error) {
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would publish %s with plugin %s", r.Tag, p.cfg.Name)
+		return publish.Result{}, nil
+	}
+	c, err := startPlugin(ctx, p.cfg, p.stderr, plugin.CapPublisher)
+	if err != nil {
+		return publish.Result{}, err
+	}
+	defer func() { err = errors.Join(err, c.Close()) }()
+	return c.Publisher(p.repo).Publish(ctx, r)
+}
+
+// pluginNotifier returns a Notifier that starts the plugin declared by pc
+// for each event.
+func pluginNotifier(pc config.PluginConfig, stderr io.Writer) notify.Notifier {
+	return notify.Func(func(ctx context.Context, e notify.Event) (err error) {
+		c, err := startPlugin(ctx, pc, stderr, plugin.CapNotifier)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, c.Close()) }()
+		return c.Notifier().Notify(ctx, e)
+	})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/policy"
+	"github.com/gbrennon/release_automation_golang/pkg/preflight"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+func (a *app) preflight(ctx context.Context, args []string) error {
+	fs := a.flags("preflight")
+	remote := fs.String("remote", a.preflightRemote(), "git remote the branch must be up to date with")
+	ci := fs.Bool("ci", a.cfg.Preflight.CI, "require CI to pass on HEAD (default: preflight.ci)")
+	strict := fs.Bool("strict", a.cfg.Preflight.Policy.Strict, "fail on release policy violations instead of warning (default: preflight.policy.strict)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return a.runPreflight(ctx, a.stdout, *remote, *ci, *strict)
+}
+
+// recordChecks wraps checks to record their outcome in the result.
+func (a *app) recordChecks(checks []preflight.Check) {
+	for i, c := range checks {
+		checks[i].Run = func(ctx context.Context) error {
+			err := c.Run(ctx)
+			r := checkResult{Name: c.Name, OK: err == nil}
+			if err != nil {
+				r.Problem = err.Error()
+			}
+			a.result.Checks = append(a.result.Checks, r)
+			return err
+		}
+	}
+}
+
+// preflightBefore runs the preflight checks, without output, when the
+// configuration asks for them before command.
+func (a *app) preflightBefore(ctx context.Context, command string) error {
+	if !slices.Contains(a.cfg.Preflight.Before, command) {
+		return nil
+	}
+	return a.runPreflight(ctx, nil, a.preflightRemote(), a.cfg.Preflight.CI, a.cfg.Preflight.Policy.Strict)
+}
+
+// runPreflight runs the configured checks, writing a line per check to w
+// when it is not nil. Policy violations fail them when strict.
+func (a *app) runPreflight(ctx context.Context, w io.Writer, remote string, ci, strict bool) error {
+	pc := a.cfg.Preflight
+	branch, err := a.branch(ctx)
+	if err != nil {
+		return err
+	}
+
+	var checks []preflight.Check
+	if !pc.AllowDirty {
+		checks = append(checks, preflight.CleanWorktree(a.git))
+	}
+	if !pc.AllowAnyBranch {
+		allowed := slices.Clone(a.cfg.Branches)
+		for _, ch := range a.cfg.Channels {
+			allowed = append(allowed, ch.Branch)
+		}
+		for _, l := range a.cfg.Lines {
+			allowed = append(allowed, l.Branch)
+		}
+		checks = append(checks, preflight.Branch(branch, allowed))
+	}
+	if !pc.AllowBehind {
+		checks = append(checks, preflight.UpToDate(a.git, remote, branch))
+	}
+	if ci {
+		c, err := a.ciCheck(ctx)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, c)
+	}
+	if len(pc.RequiredFiles) > 0 {
+		checks = append(checks, preflight.Files(a.root, pc.RequiredFiles))
+	}
+	p, err := a.policy()
+	if err != nil {
+		return err
+	}
+	if p.Enabled() {
+		checks = append(checks, a.policyCheck(p, branch, strict))
+	}
+	if w != nil {
+		a.recordChecks(checks)
+	}
+	return preflight.Run(ctx, w, checks)
+}
+
+// policy returns the release policies of preflight.policy.
+func (a *app) policy() (policy.Policy, error) {
+	pc := a.cfg.Preflight.Policy
+	p := policy.Policy{
+		MaxUnreleased:    pc.MaxUnreleasedCommits,
+		ReleaseNoteTypes: pc.ReleaseNoteTypes,
+		Protected:        pc.ProtectedBranches,
+	}
+	if len(p.ReleaseNoteTypes) == 0 {
+		return p, nil
+	}
+	cl, err := a.classifier()
+	p.Classifier = cl
+	return p, err
+}
+
+// policyCheck holds the commits since the latest release of the
+// repository to p, recording the violations in the result. They fail the
+// check when strict and are logged as warnings otherwise.
+func (a *app) policyCheck(p policy.Policy, branch string, strict bool) preflight.Check {
+	return preflight.Check{Name: "release policies", Run: func(ctx context.Context) error {
+		m, err := a.module("")
+		if err != nil {
+			return err
+		}
+		plan, err := a.analyse(ctx, m)
+		if err != nil && !errors.Is(err, relerr.ErrNoCommitsSinceTag) {
+			return err
+		}
+		ds := p.Evaluate(policy.Range{Branch: branch, Raw: plan.Raw, Commits: plan.Commits})
+		a.result.Diagnostics = append(a.result.Diagnostics, ds...)
+		if len(ds) == 0 {
+			return nil
+		}
+		if strict {
+			return &policy.Error{Diagnostics: ds}
+		}
+		for _, d := range ds {
+			a.log.Warn("policy: " + d.String())
+		}
+		return nil
+	}}
+}
+
+// ciCheck checks the CI status of HEAD on the first publish target.
+func (a *app) ciCheck(ctx context.Context) (preflight.Check, error) {
+	targets := a.publishTargets("", "")
+	if len(targets) == 0 {
+		return preflight.Check{}, errors.New("preflight: the CI check needs a publish target or a CI environment")
+	}
+	t := targets[0]
+	p, err := a.publisher(ctx, t, false)
+	if err != nil {
+		return preflight.Check{}, err
+	}
+	s, ok := p.(publish.StatusChecker)
+	if !ok {
+		return preflight.Check{}, fmt.Errorf("preflight: provider %s cannot report CI status", t.Provider)
+	}
+	head, err := a.git.Head(ctx)
+	if err != nil {
+		return preflight.Check{}, err
+	}
+	return preflight.CI(s, head), nil
+}
+
+// preflightRemote returns the configured preflight remote.
+func (a *app) preflightRemote() string {
+	if r := a.cfg.Preflight.Remote; r != "" {
+		return r
+	}
+	return "origin"
+}
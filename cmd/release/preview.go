@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/ci"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// previewMarker identifies the comment of `release preview -pr`, so each
+// run edits it rather than adding another.
+const previewMarker = "<!-- release-preview -->"
+
+// preview shows the version and changelog section the branch would
+// release, and with -pr keeps them up to date in a comment on its pull
+// request.
+func (a *app) preview(ctx context.Context, args []string) error {
+	fs := a.flags("preview")
+	opts := a.planFlags(fs)
+	pr := fs.Bool("pr", false, "post or update the preview as a comment on the pull request")
+	number := fs.Int("number", 0, "pull or merge request to comment on (default: from the CI environment)")
+	provider := fs.String("provider", "", "provider of the pull request: github or gitlab (default: the first publish target)")
+	repo := fs.String("repo", "", "repository (owner/repo) or project path of the pull request")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	a.result.DryRun = *dryRun
+
+	var c publish.Commenter
+	if *pr {
+		if *number == 0 {
+			env, _ := ci.FromEnv()
+			*number = env.PullRequest
+		}
+		if *number <= 0 {
+			return fmt.Errorf("%w: -pr needs -number outside a pull request build", relerr.ErrUsage)
+		}
+		var err error
+		if c, err = a.commenter(ctx, *provider, *repo, *dryRun); err != nil {
+			return err
+		}
+	}
+
+	body, err := a.previewBody(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		_, err := fmt.Fprint(a.stdout, body)
+		return err
+	}
+	comment, err := c.UpsertComment(ctx, *number, previewMarker, previewMarker+"\n"+body)
+	if err != nil {
+		return relerr.Wrap(relerr.Provider, err)
+	}
+	a.result.Comment = comment.URL
+	switch {
+	case comment.Updated:
+		fmt.Fprintf(a.stdout, "updated %s\n", comment.URL)
+	case comment.URL != "":
+		fmt.Fprintf(a.stdout, "commented %s\n", comment.URL)
+	}
+	return nil
+}
+
+// previewBody renders the Markdown preview of the plan o selects. A branch
+// without releasable commits gets a preview saying so.
+func (a *app) previewBody(ctx context.Context, o *planOptions) (string, error) {
+	p, err := a.newPlan(ctx, o)
+	if errors.Is(err, relerr.ErrNoCommitsSinceTag) {
+		since := "the first release"
+		if p.HasPrevious {
+			since = p.PreviousTag
+		}
+		return fmt.Sprintf("### Release preview\n\nMerging would not release a new version: no commit since %s bumps it.\n", since), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	a.result.setPlan(p)
+
+	renderer, err := changelogRenderer(a.cfg.Changelog.Template, "markdown")
+	if err != nil {
+		return "", err
+	}
+	var section bytes.Buffer
+	if err := renderer.Render(&section, a.changelogRelease(ctx, p.Next.String(), a.now(), p.Commits, p.Raw)); err != nil {
+		return "", err
+	}
+	a.result.Changelog = section.String()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Release preview\n\nMerging would release **%s**, a %s release", p.Tag(), p.Level)
+	if p.HasPrevious {
+		fmt.Fprintf(&b, " after %s", p.PreviousTag)
+	}
+	fmt.Fprintf(&b, ".\n\n<details>\n<summary>Changelog</summary>\n\n%s\n\n</details>\n", strings.TrimRight(section.String(), "\n"))
+	return b.String(), nil
+}
+
+// commenter returns the publisher of the first GitHub or GitLab publish
+// target, or of the flags, to comment on pull requests with.
+func (a *app) commenter(ctx context.Context, provider, repo string, dryRun bool) (publish.Commenter, error) {
+	for _, t := range a.publishTargets(provider, repo) {
+		if t.Provider != "github" && t.Provider != "gitlab" {
+			continue
+		}
+		p, err := a.publisher(ctx, t, dryRun)
+		if err != nil {
+			return nil, relerr.Wrap(relerr.Provider, err)
+		}
+		if c, ok := p.(publish.Commenter); ok {
+			return c, nil
+		}
+	}
+	return nil, errors.New("preview: commenting needs a GitHub or GitLab publish target or CI environment")
+}
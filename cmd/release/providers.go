@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/ci"
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/providerapi"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/docker"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/github"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/gitlab"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/homebrew"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/npm"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/pypi"
+	"github.com/gbrennon/release_automation_golang/pkg/ratelimit"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+// publisherFactory builds the Publisher for a provider name and repository.
+type publisherFactory func(provider, repo string, o publisherOptions) (publish.Publisher, error)
+
+// publisherOptions are the settings shared by every built-in Publisher.
+type publisherOptions struct {
+	DryRun bool
+	Log    io.Writer
+	Logger *slog.Logger
+	Retry  retry.Policy
+	// RateLimit paces the API calls of github, gitlab and homebrew, and
+	// GraphQLRateLimit the GraphQL queries of github.
+	RateLimit        *ratelimit.Limiter
+	GraphQLRateLimit *ratelimit.Limiter
+	// Uploads bounds the concurrent asset uploads and reports progress.
+	Uploads publish.Uploader
+	// Lookups batches the issues and commit authors github and gitlab look
+	// up.
+	Lookups providerapi.Batches
+	// Source and Tags are the local image and tag templates of docker.
+	Source string
+	Tags   []string
+	Stderr io.Writer
+	// Homebrew describes the formula of homebrew, with its name and URL
+	// resolved.
+	Homebrew config.HomebrewConfig
+	// Package describes the package of npm and pypi.
+	Package config.PackageConfig
+	// Secret looks up the tokens and passwords of the provider by name.
+	Secret func(name string) string
+	// BaseURL, APIVersion and Auth reach a GitHub Enterprise Server or
+	// self-hosted GitLab instance (see config.PublishTarget).
+	BaseURL    string
+	APIVersion string
+	Auth       string
+	// HTTPClient calls the APIs of github, gitlab, homebrew and pypi; nil
+	// keeps their default.
+	HTTPClient *http.Client
+}
+
+func newPublisher(provider, repo string, o publisherOptions) (publish.Publisher, error) {
+	switch provider {
+	case "github":
+		p, err := github.NewFromSecrets(repo, o.Secret)
+		if err != nil {
+			return nil, err
+		}
+		p.DryRun, p.Log, p.Logger, p.Retry, p.Uploads, p.Lookups = o.DryRun, o.Log, o.Logger, o.Retry, o.Uploads, o.Lookups
+		if o.RateLimit != nil {
+			p.RateLimit = o.RateLimit
+		}
+		if o.GraphQLRateLimit != nil {
+			p.GraphQLRateLimit = o.GraphQLRateLimit
+		}
+		if o.HTTPClient != nil {
+			p.HTTPClient = o.HTTPClient
+		}
+		if o.BaseURL != "" {
+			p.BaseURL = github.APIBaseURL(o.BaseURL)
+		}
+		if o.APIVersion != "" {
+			p.APIVersion = o.APIVersion
+		}
+		if o.Auth == "token" {
+			p.AuthScheme = "token"
+		}
+		return p, nil
+	case "gitlab":
+		p, err := gitlab.NewFromSecrets(repo, o.Secret)
+		if err != nil {
+			return nil, err
+		}
+		p.DryRun, p.Log, p.Logger, p.Retry, p.Uploads, p.Lookups = o.DryRun, o.Log, o.Logger, o.Retry, o.Uploads, o.Lookups
+		if o.RateLimit != nil {
+			p.RateLimit = o.RateLimit
+		}
+		if o.HTTPClient != nil {
+			p.HTTPClient = o.HTTPClient
+		}
+		if o.BaseURL != "" {
+			p.BaseURL = gitlab.APIBaseURL(o.BaseURL)
+		}
+		switch o.Auth {
+		case "private-token":
+			p.JobToken = false
+		case "job-token":
+			p.JobToken = true
+		case "bearer":
+			p.JobToken, p.OAuth = false, true
+		}
+		return p, nil
+	case "docker":
+		p := docker.NewFromSecrets(repo, o.Secret)
+		p.Source, p.Tags, p.Stderr = o.Source, o.Tags, o.Stderr
+		p.DryRun, p.Log, p.Logger, p.Retry = o.DryRun, o.Log, o.Logger, o.Retry
+		return p, nil
+	case "homebrew":
+		return newHomebrew(repo, o)
+	case "npm":
+		p := npm.NewFromSecrets(repo, o.Package.Dir, o.Secret)
+		p.Registry, p.Tag, p.Access, p.Provenance = o.Package.Registry, o.Package.Tag, o.Package.Access, o.Package.Provenance
+		p.DryRun, p.Log, p.Logger, p.Retry, p.Stderr = o.DryRun, o.Log, o.Logger, o.Retry, o.Stderr
+		return p, nil
+	case "pypi":
+		p, err := pypi.NewFromSecrets(repo, o.Package.Dir, o.Secret)
+		if err != nil {
+			return nil, err
+		}
+		p.URL = o.Package.Registry
+		p.DryRun, p.Log, p.Logger, p.Retry, p.Uploads = o.DryRun, o.Log, o.Logger, o.Retry, o.Uploads
+		if o.HTTPClient != nil {
+			p.HTTPClient = o.HTTPClient
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q: must be github, gitlab, docker, homebrew, npm or pypi", provider)
+}
+
+// webRoot returns the web address of the instance serving the API at
+// base, without the /api/v3 or /api/v4 of an API endpoint.
+func webRoot(base string) string {
+	base = strings.TrimRight(base, "/")
+	return strings.TrimSuffix(strings.TrimSuffix(base, "/api/v3"), "/api/v4")
+}
+
+// newHomebrew returns the publisher of the formula in the tap repo, which
+// is written with the token in homebrew.TokenEnv, or the GitHub token.
+func newHomebrew(repo string, o publisherOptions) (*homebrew.Publisher, error) {
+	var (
+		tap *github.Publisher
+		err error
+	)
+	if token := o.Secret(homebrew.TokenEnv); token != "" {
+		owner, name, ok := strings.Cut(repo, "/")
+		if !ok || owner == "" || name == "" {
+			return nil, fmt.Errorf("homebrew: invalid tap %q: expected owner/homebrew-tap", repo)
+		}
+		tap = github.New(owner, name, token)
+	} else if tap, err = github.NewFromSecrets(repo, o.Secret); err != nil {
+		return nil, err
+	}
+	tap.Logger, tap.Retry = o.Logger, o.Retry
+	if o.RateLimit != nil {
+		tap.RateLimit = o.RateLimit
+	}
+	if o.HTTPClient != nil {
+		tap.HTTPClient = o.HTTPClient
+	}
+
+	h := o.Homebrew
+	p := &homebrew.Publisher{
+		Tap: tap,
+		Formula: homebrew.Formula{
+			Name:     h.Name,
+			Desc:     h.Description,
+			Homepage: h.Homepage,
+			License:  h.License,
+			Binary:   h.Binary,
+			Test:     h.Test,
+		},
+		URL:         h.URL,
+		Path:        h.Path,
+		Branch:      h.Branch,
+		PullRequest: h.PullRequest,
+		DryRun:      o.DryRun,
+		Log:         o.Log,
+		Logger:      o.Logger,
+	}
+	if h.Template != "" {
+		data, err := os.ReadFile(h.Template)
+		if err != nil {
+			return nil, fmt.Errorf("homebrew: %w", err)
+		}
+		p.Template = string(data)
+	}
+	return p, nil
+}
+
+// detectProvider infers the provider and repository from the CI environment.
+// Both results are empty outside a supported CI system, or when it does not
+// tell which repository is built.
+func detectProvider() (provider, repo string) {
+	env, ok := ci.FromEnv()
+	if !ok || env.Repo == "" {
+		return "", ""
+	}
+	return env.Provider, env.Repo
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// defaultPruneKeep is the number of releases of each kind prune keeps
+// whatever their age.
+const defaultPruneKeep = 5
+
+// pruneStep is the deletion of one release, or of the assets of older
+// snapshot builds, planned by prune.
+type pruneStep struct {
+	result pruneResult
+	run    func(ctx context.Context) error
+}
+
+// desc describes the deletion s.
+func (s pruneStep) desc() string {
+	r := s.result
+	if len(r.Assets) > 0 {
+		return fmt.Sprintf("%d asset(s) of older snapshots from %s on %s %s", len(r.Assets), r.Tag, r.Provider, r.Repo)
+	}
+	return fmt.Sprintf("%s release %s on %s %s", r.Kind, r.Tag, r.Provider, r.Repo)
+}
+
+// prune deletes the releases the retention policy does not keep from the
+// publish targets: by default the drafts and prereleases beyond the newest
+// few and, unless -snapshots=false, the assets of the snapshot builds beyond
+// snapshot.keep from the rolling snapshot release. Stable releases are
+// kept unless -stable. Every deletion is listed with its reason before it
+// runs; a failed one is reported and the others still run.
+func (a *app) prune(ctx context.Context, args []string) error {
+	pc := a.cfg.Prune
+	defaultAge, _ := time.ParseDuration(pc.MaxAge)
+	fs := a.flags("prune")
+	module := fs.String("module", "", "prune only the releases of this module (directory or name, see 'release modules')")
+	keep := fs.Int("keep", cmp.Or(pc.Keep, defaultPruneKeep), "number of drafts, of prereleases and, with -stable, of stable releases kept whatever their age")
+	maxAge := fs.Duration("max-age", defaultAge, "also keep the releases beyond -keep younger than this, e.g. 720h; 0 keeps none")
+	stable := fs.Bool("stable", pc.Stable, "prune stable releases too; by default every one is kept")
+	superseded := fs.Bool("superseded", pc.Superseded, "prune the prereleases of versions released since, whatever -keep and -max-age say")
+	snapshots := fs.Bool("snapshots", true, "also delete the assets of the snapshot builds beyond snapshot.keep from the rolling snapshot release")
+	provider := fs.String("provider", "", "prune the releases on this provider (default: config publish targets, then CI detection)")
+	repo := fs.String("repo", "", "repository (owner/repo) or project path to prune")
+	yes := fs.Bool("yes", false, "prune without asking for confirmation; required without a terminal")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: prune takes no arguments", relerr.ErrUsage)
+	}
+	if *keep < 0 || *keep == 0 && *maxAge <= 0 {
+		return fmt.Errorf("%w: -keep must be at least 1, or 0 with a -max-age", relerr.ErrUsage)
+	}
+	a.result.DryRun = *dryRun
+	if !*dryRun && !a.tty && !*yes {
+		return fmt.Errorf("%w: stdin is not a terminal; pass -yes to prune", relerr.ErrUsage)
+	}
+
+	m, err := a.module(*module)
+	if err != nil {
+		return relerr.Wrap(relerr.Config, err)
+	}
+	snapshotTag := cmp.Or(a.cfg.Snapshot.Tag, defaultSnapshotTag)
+	retention := publish.Retention{
+		Keep:       *keep,
+		MaxAge:     *maxAge,
+		Stable:     *stable,
+		Superseded: *superseded,
+		Version:    m.Version,
+		Exclude:    []string{snapshotTag},
+		Now:        a.now(),
+	}
+	targets := a.publishTargets(*provider, *repo)
+	if len(targets) == 0 {
+		return relerr.Wrap(relerr.Config, errors.New("prune: no publish target to prune"))
+	}
+	steps, err := a.pruneSteps(ctx, targets, retention, snapshotTag, *snapshots, *dryRun)
+	if err != nil {
+		return relerr.Wrap(relerr.Provider, err)
+	}
+	if len(steps) == 0 {
+		fmt.Fprintln(a.stdout, "nothing to prune")
+		return nil
+	}
+
+	fmt.Fprintf(a.stdout, "Pruning %d item(s):\n", len(steps))
+	for _, s := range steps {
+		fmt.Fprintf(a.stdout, "  - %s: %s\n", s.desc(), s.result.Reason)
+	}
+	if a.tty && !*yes && !*dryRun {
+		w := &wizard{in: bufio.NewReader(a.stdin), out: a.stdout, ask: true}
+		if ok, err := w.confirm("Go ahead?", false); err != nil {
+			return err
+		} else if !ok {
+			return errAborted
+		}
+	}
+
+	var errs []error
+	for _, s := range steps {
+		if err := s.run(ctx); err != nil {
+			s.result.Error = err.Error()
+			errs = append(errs, err)
+			fmt.Fprintf(a.stdout, "not deleted: %s: %v\n", s.desc(), err)
+		} else if !*dryRun {
+			fmt.Fprintf(a.stdout, "deleted %s\n", s.desc())
+		}
+		a.result.Pruned = append(a.result.Pruned, s.result)
+	}
+	if len(errs) > 0 {
+		return relerr.Wrap(relerr.Provider, fmt.Errorf("prune: %d of %d deletion(s) failed: %w", len(errs), len(steps), errors.Join(errs...)))
+	}
+	return nil
+}
+
+// pruneSteps lists the releases of every target and plans the deletion of
+// those retention does not keep, then, with snapshots, of the assets of
+// older builds on the rolling release of snapshotTag. Targets that cannot
+// list their releases are left out with a warning.
+func (a *app) pruneSteps(ctx context.Context, targets []config.PublishTarget, retention publish.Retention, snapshotTag string, snapshots, dryRun bool) ([]pruneStep, error) {
+	var steps []pruneStep
+	for _, t := range targets {
+		pub, err := a.publisher(ctx, t, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		l, ok := pub.(publish.Lister)
+		if !ok {
+			a.log.Warn(fmt.Sprintf("prune: cannot list the releases on %s %s; they are left alone", t.Provider, t.Repo))
+			continue
+		}
+		releases, err := l.Releases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range retention.Prune(releases) {
+			steps = append(steps, pruneStep{
+				result: pruneResult{Provider: t.Provider, Repo: t.Repo, Tag: p.Tag, Kind: p.Kind, Reason: p.Reason, URL: p.URL},
+				run:    func(ctx context.Context) error { return l.DeleteRelease(ctx, p.Listed) },
+			})
+		}
+
+		s, ok := pub.(publish.Snapshotter)
+		if !snapshots || !ok {
+			continue
+		}
+		for _, r := range releases {
+			if r.Tag != snapshotTag {
+				continue
+			}
+			var names []string
+			for _, asset := range r.Assets {
+				names = append(names, asset.Name)
+			}
+			channel := cmp.Or(a.cfg.Snapshot.Channel, defaultSnapshotChannel)
+			keep := cmp.Or(a.cfg.Snapshot.Keep, defaultSnapshotKeep)
+			stale := publish.StaleSnapshots(names, channel, version.Version{}, keep)
+			if len(stale) == 0 {
+				break
+			}
+			steps = append(steps, pruneStep{
+				result: pruneResult{
+					Provider: t.Provider,
+					Repo:     t.Repo,
+					Tag:      snapshotTag,
+					Kind:     "snapshot",
+					Reason:   fmt.Sprintf("builds beyond the %d newest", keep),
+					URL:      r.URL,
+					Assets:   stale,
+				},
+				run: func(ctx context.Context) error { return s.DeleteAssets(ctx, snapshotTag, stale) },
+			})
+			break
+		}
+	}
+	return steps, nil
+}
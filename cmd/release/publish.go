@@ -0,0 +1,447 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+	"github.com/gbrennon/release_automation_golang/pkg/progress"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+	"github.com/gbrennon/release_automation_golang/pkg/telemetry"
+	"github.com/gbrennon/release_automation_golang/pkg/timeline"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// stringsFlag collects a repeatable string flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string     { return strings.Join(*s, ",") }
+func (s *stringsFlag) Set(v string) error { *s = append(*s, v); return nil }
+
+func (a *app) publish(ctx context.Context, args []string) error {
+	fs := a.flags("publish")
+	o := a.publishFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: release publish [flags] <tag>", relerr.ErrUsage)
+	}
+	return a.runPublish(ctx, fs.Arg(0), o, publishAll)
+}
+
+// publishOptions are the flags of publish, draft and approve.
+type publishOptions struct {
+	provider, repo              string
+	notes, changelog, title     string
+	concurrency                 int
+	build, force                *bool
+	dryRun                      *bool
+	assets, milestones, remotes stringsFlag
+}
+
+// publishFlags defines the flags of publishOptions on fs.
+func (a *app) publishFlags(fs *flag.FlagSet) *publishOptions {
+	o := &publishOptions{}
+	fs.StringVar(&o.provider, "provider", "", "create a release on this provider: github, gitlab, docker, homebrew, npm, pypi or a plugin name (default: config publish targets, then CI detection)")
+	fs.StringVar(&o.repo, "repo", "", "repository (owner/repo) or project path to release on")
+	fs.StringVar(&o.notes, "notes", "", "file containing the release body (default: the curated notes of the version, then the tag's section of -changelog)")
+	fs.StringVar(&o.changelog, "changelog", a.cfg.Changelog.Path, "changelog to extract release notes from")
+	fs.StringVar(&o.title, "title", "", "text/template naming the release, e.g. \"MyApp v{{.Version}} — {{.Date}}\" (default: the target's title, or \"Release <tag>\")")
+	fs.IntVar(&o.concurrency, "concurrency", 0, "assets to upload at once (default: the target's concurrency, or 4)")
+	o.build = fs.Bool("build", len(a.cfg.Artifacts.Targets) > 0, "build the configured artifacts and attach them (default: when artifacts are configured)")
+	o.force = fs.Bool("force", false, "publish even inside a freeze window")
+	o.dryRun = a.dryRunFlag(fs)
+	fs.Var(&o.remotes, "remote", "git remote to push the tag to (repeatable; default: the remotes config, or origin)")
+	fs.Var(&o.assets, "asset", "file to upload to the release (repeatable)")
+	fs.Var(&o.milestones, "milestone", "milestone to associate with the release, GitLab only (repeatable)")
+	return o
+}
+
+// publishPhase is the part of a release runPublish makes.
+type publishPhase int
+
+const (
+	// publishAll publishes the release on every target, as publish does.
+	publishAll publishPhase = iota
+	// publishDraft creates drafts on the targets that keep them and
+	// announces nothing, as draft does. The other targets are left to
+	// publishApprove.
+	publishDraft
+	// publishApprove publishes the drafts and the targets left, then
+	// announces the release, as approve does.
+	publishApprove
+)
+
+// runPublish pushes tag and releases it on the publish targets in phase.
+// The pre-publish hooks and the build run before the tag is pushed, by
+// publish and draft; the tracker issues are marked released, and the
+// post-publish hooks and the notifications run, once the release is
+// published, by publish and approve.
+func (a *app) runPublish(ctx context.Context, tag string, o *publishOptions, phase publishPhase) (err error) {
+	dryRun := *o.dryRun
+	assets := slices.Clone(o.assets)
+	a.result.DryRun, a.result.Tag = dryRun, tag
+	ver := a.tagVersion(tag)
+	a.result.Version = ver
+	rep := a.reporter()
+	rep.Stage("analysis", 0)
+	defer func() { rep.Close(err) }()
+	if phase != publishDraft {
+		if err := a.checkFreeze(*o.force); err != nil {
+			return err
+		}
+	}
+
+	var urls []string
+	if phase != publishDraft {
+		defer func() {
+			e := notify.Event{Status: notify.Success, Tag: tag, URLs: urls, Err: err, Time: a.now()}
+			if err != nil {
+				e.Status = notify.Failure
+			}
+			// Report a cancelled or timed-out release too, within a grace
+			// period of its own.
+			nctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+			defer cancel()
+			rep.Stage("notify", 0)
+			a.notify(nctx, e, dryRun)
+			if err == nil {
+				a.advance(tag, state.Announced, dryRun)
+			}
+		}()
+	}
+
+	env := hooks.Env{
+		hooks.EnvTag:         tag,
+		hooks.EnvNextVersion: ver,
+	}
+
+	if phase == publishApprove {
+		drafted, err := a.draftedAssets(tag, *o.build)
+		if err != nil {
+			return err
+		}
+		assets = append(assets, drafted...)
+	} else {
+		if err := a.preflightBefore(ctx, "publish"); err != nil {
+			return err
+		}
+		if err := a.runHooks(ctx, hooks.PrePublish, env, dryRun); err != nil {
+			return err
+		}
+	}
+	if *o.build {
+		rep.Stage("build", 0)
+		built, err := a.buildArtifacts(ctx, a.cfg.Artifacts, tag, dryRun)
+		if err != nil {
+			return err
+		}
+		a.advance(tag, state.Built, dryRun)
+		assets = append(assets, built...)
+		a.result.Assets = built
+	}
+	targets := a.publishTargets(o.provider, o.repo)
+	titles, err := a.releaseTitles(ctx, targets, tag, ver, o.title)
+	if err != nil {
+		return err
+	}
+	if phase != publishApprove {
+		rep.Stage("push", 0)
+		if err := a.pushRelease(ctx, tag, a.pushRemotes(o.remotes), dryRun); err != nil {
+			return err
+		}
+	}
+
+	if len(targets) == 0 {
+		if phase == publishDraft {
+			a.advanceDraft(tag, assets, dryRun)
+			a.publishTimeline(ctx, tag, ver, phase, o, dryRun)
+			return nil
+		}
+		a.advance(tag, state.Published, dryRun)
+		a.publishTimeline(ctx, tag, ver, phase, o, dryRun)
+		a.markReleased(ctx, tag, ver, dryRun)
+		return a.runHooks(ctx, hooks.PostPublish, env, dryRun)
+	}
+
+	body, err := a.releaseNotes(tag, ver, o.notes, o.changelog)
+	if err != nil {
+		return err
+	}
+
+	published := 0
+	for i, t := range targets {
+		if o.concurrency > 0 {
+			t.Concurrency = o.concurrency
+		}
+		p, err := a.publisher(ctx, t, dryRun)
+		if err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		promoter, drafts := p.(publish.Promoter)
+		if phase == publishDraft && !drafts {
+			fmt.Fprintf(a.stdout, "left %s %s to approve: it keeps no drafts\n", t.Provider, t.Repo)
+			continue
+		}
+		rep.Stage(strings.TrimSpace("publish "+t.Provider+" "+t.Repo), 0)
+
+		pctx, span := a.metrics().Start(ctx, "publish "+t.Provider, telemetry.String("provider", t.Provider), telemetry.String("repo", t.Repo))
+		var result publish.Result
+		if phase == publishApprove && drafts {
+			var found bool
+			result, found, err = promoter.Promote(pctx, tag)
+			if err == nil && !found {
+				span.End(nil)
+				return relerr.Wrap(relerr.Precondition, fmt.Errorf("%s %s has no draft release of %s: run 'release draft %s' first", t.Provider, t.Repo, tag, tag))
+			}
+		} else {
+			result, err = p.Publish(pctx, publish.Release{
+				Tag:        tag,
+				Version:    ver,
+				Name:       titles[i],
+				Body:       body,
+				Draft:      t.Draft || phase == publishDraft,
+				Prerelease: strings.Contains(tag, "-"),
+				Assets:     slices.Concat(t.Assets, assets),
+				Milestones: slices.Concat(t.Milestones, o.milestones),
+			})
+		}
+		span.End(err)
+		if err != nil {
+			return a.publishFailed(tag, t, result, published > 0, err)
+		}
+		published++
+		a.result.Releases = append(a.result.Releases, releaseResult{
+			Provider: t.Provider,
+			Repo:     t.Repo,
+			ID:       result.ID,
+			URL:      result.URL,
+			Existing: result.Existing,
+			Assets:   result.Uploaded,
+		})
+		verb := "published"
+		if phase == publishDraft {
+			verb = "drafted"
+		}
+		if result.Existing && phase == publishApprove && drafts {
+			fmt.Fprintf(a.stdout, "already published %s\n", result.URL)
+		} else if result.Existing {
+			fmt.Fprintf(a.stdout, "resumed %s: uploaded %d missing asset(s)\n", result.URL, len(result.Uploaded))
+		} else if result.URL != "" {
+			fmt.Fprintf(a.stdout, "%s %s\n", verb, result.URL)
+		} else if result.ID != "" {
+			fmt.Fprintf(a.stdout, "%s %s\n", verb, result.ID)
+		}
+		if result.URL != "" {
+			urls = append(urls, result.URL)
+		}
+	}
+	if phase == publishDraft {
+		a.advanceDraft(tag, assets, dryRun, urls...)
+		a.publishTimeline(ctx, tag, ver, phase, o, dryRun)
+		return nil
+	}
+	a.advance(tag, state.Published, dryRun, urls...)
+	a.publishTimeline(ctx, tag, ver, phase, o, dryRun)
+	a.markReleased(ctx, tag, ver, dryRun)
+	return a.runHooks(ctx, hooks.PostPublish, env, dryRun)
+}
+
+// publishFailed returns the error of target t failing to publish tag with
+// result. When the release was already pushed somewhere, on an earlier
+// target or as t's release missing assets, it is published in part, which
+// publishing again resumes.
+func (a *app) publishFailed(tag string, t config.PublishTarget, result publish.Result, earlier bool, err error) error {
+	if !earlier && result.ID == "" && result.URL == "" {
+		return relerr.Wrap(relerr.Provider, err)
+	}
+	if result.ID != "" || result.URL != "" {
+		a.result.Releases = append(a.result.Releases, releaseResult{
+			Provider: t.Provider,
+			Repo:     t.Repo,
+			ID:       result.ID,
+			URL:      result.URL,
+			Existing: result.Existing,
+			Assets:   result.Uploaded,
+		})
+	}
+	return fmt.Errorf("%w: %s: %w; releasing %s again resumes it", relerr.ErrPartialPublish, strings.TrimSpace(t.Provider+" "+t.Repo), relerr.Wrap(relerr.Provider, err), tag)
+}
+
+// publishTimeline records the drafted or published step of tag in the
+// release timeline, with the provider releases, and pushes the notes to
+// the remotes of the release.
+func (a *app) publishTimeline(ctx context.Context, tag, ver string, phase publishPhase, o *publishOptions, dryRun bool) {
+	step := timeline.Published
+	if phase == publishDraft {
+		step = timeline.Drafted
+	}
+	plan := func() workspace.Plan { return a.taggedPlan(ctx, tag, ver) }
+	if a.recordTimeline(ctx, tag, step, plan, dryRun) {
+		a.pushTimeline(ctx, a.pushRemotes(o.remotes), dryRun)
+	}
+}
+
+// reporter returns the Reporter of the stages of a release: the display
+// of ui on a terminal, or else the log for the stages and lines of the
+// human output for the uploads, kept for the rest of the command.
+func (a *app) reporter() progress.Reporter {
+	if a.ui != nil {
+		return a.ui
+	}
+	if a.progress == nil {
+		a.progress = progress.NewLog(a.stdout, a.log)
+	}
+	return a.progress
+}
+
+// transferInterval spaces the reports of the bytes sent of an upload: as
+// often as the display redraws on a terminal, as the Uploader does
+// otherwise.
+func (a *app) transferInterval() time.Duration {
+	if a.ui != nil {
+		return progress.RedrawInterval
+	}
+	return 0
+}
+
+// uploadProgress reports a finished asset upload.
+func (a *app) uploadProgress(p publish.Progress) {
+	outcome := "success"
+	if p.Err != nil {
+		outcome = "failure"
+	}
+	a.metrics().Observe(telemetry.UploadDuration, p.Elapsed.Seconds(), telemetry.String("outcome", outcome))
+	a.reporter().Item(progress.Item{Name: p.Asset, Size: p.Size, Elapsed: p.Elapsed, Err: p.Err, Done: p.Done, Total: p.Total})
+}
+
+// uploadTransfer reports the bytes sent of a large asset still uploading.
+func (a *app) uploadTransfer(t publish.Transfer) {
+	a.reporter().Transfer(progress.Transfer{Name: t.Asset, Sent: t.Sent, Size: t.Size})
+}
+
+// publishTargets resolves where to publish. Flags take precedence over the
+// configuration file, which takes precedence over CI detection.
+func (a *app) publishTargets(provider, repo string) []config.PublishTarget {
+	detectedProvider, detectedRepo := detectProvider()
+
+	if provider != "" || repo != "" {
+		if provider == "" {
+			provider = detectedProvider
+		}
+		if repo == "" {
+			repo = detectedRepo
+		}
+		return []config.PublishTarget{{Provider: provider, Repo: repo}}
+	}
+	if len(a.cfg.Publish) > 0 {
+		return a.cfg.Publish
+	}
+	if detectedProvider != "" {
+		return []config.PublishTarget{{Provider: detectedProvider, Repo: detectedRepo}}
+	}
+	return nil
+}
+
+// releaseTitles renders the name of the release of tag on each of
+// targets from the title template, or else the target's: "" for the
+// provider's default title. The templates are rendered before anything is
+// pushed, so that a broken one stops the release early.
+func (a *app) releaseTitles(ctx context.Context, targets []config.PublishTarget, tag, ver, title string) ([]string, error) {
+	titles := make([]string, len(targets))
+	var (
+		p    workspace.Plan
+		read bool
+	)
+	for i, t := range targets {
+		text := cmp.Or(title, t.Title)
+		if text == "" {
+			continue
+		}
+		if !read {
+			p, read = a.taggedPlan(ctx, tag, ver), true
+		}
+		var err error
+		if titles[i], err = a.renderTitle(ctx, text, p); err != nil {
+			return nil, err
+		}
+	}
+	return titles, nil
+}
+
+// taggedPlan returns the plan of the release tagged tag, already made: its
+// commits when it is a stable release in the history of HEAD, and only
+// its version otherwise, such as for prereleases.
+func (a *app) taggedPlan(ctx context.Context, tag, ver string) workspace.Plan {
+	v, _ := version.Parse(ver)
+	m, ok := a.tagModule(tag)
+	if !ok {
+		m = workspace.Repository(a.cfg.Tag.Prefix)
+	}
+	p := workspace.Plan{Module: m, Next: v}
+	history, err := workspace.History(ctx, a.git, m)
+	if err != nil {
+		a.log.Warn(fmt.Sprintf("could not read the commits of %s: %v", tag, err))
+		return p
+	}
+	for i, r := range history {
+		if r.Tag != tag {
+			continue
+		}
+		p.Raw, p.Commits = r.Raw, r.Commits
+		if i > 0 {
+			p.Previous, p.PreviousTag, p.HasPrevious = history[i-1].Version, history[i-1].Tag, true
+		}
+	}
+	return p
+}
+
+// releaseNotes reads the release body from notesPath. When notesPath is
+// empty, it takes the notes of version curated with `release notes edit`,
+// or else extracts the section of version, tagged tag, from its changelog
+// fragment or the changelog; either links the upgrade guide of version when there is one.
+func (a *app) releaseNotes(tag, version, notesPath, changelogPath string) (string, error) {
+	if notesPath != "" {
+		b, err := os.ReadFile(notesPath)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	if curated, ok, err := a.curatedNotes(version); err != nil {
+		return "", err
+	} else if ok {
+		return a.upgradeGuideNotes(curated, tag, version, changelogPath), nil
+	}
+
+	if a.cfg.Changelog.Fragments.Enabled {
+		if content, err := os.ReadFile(filepath.Join(a.root, filepath.FromSlash(a.fragmentPath(version)))); err == nil {
+			if section, ok := changelog.Extract(content, version); ok {
+				return a.upgradeGuideNotes(section, tag, version, changelogPath), nil
+			}
+		}
+	}
+	content, err := os.ReadFile(changelogPath)
+	if err != nil {
+		return "", fmt.Errorf("read release notes: %w", err)
+	}
+	section, ok := changelog.Extract(content, version)
+	if !ok {
+		return "", fmt.Errorf("no section for %s in %s", tag, changelogPath)
+	}
+	return a.upgradeGuideNotes(section, tag, version, changelogPath), nil
+}
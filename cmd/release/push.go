@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+// pushRemotes returns the remotes to push releases to: those named on the
+// command line, all required, else the configured ones, else origin.
+func (a *app) pushRemotes(names []string) []config.Remote {
+	if len(names) > 0 {
+		remotes := make([]config.Remote, len(names))
+		for i, name := range names {
+			remotes[i] = config.Remote{Name: name}
+		}
+		return remotes
+	}
+	if len(a.cfg.Remotes) > 0 {
+		return a.cfg.Remotes
+	}
+	return []config.Remote{{Name: "origin"}}
+}
+
+// remoteNames returns the names of remotes.
+func remoteNames(remotes []config.Remote) []string {
+	names := make([]string, len(remotes))
+	for i, r := range remotes {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// pushRelease pushes tag to every remote, with the release branch first
+// when it carries the version-bump commit of tag. Every remote is tried
+// and reported on its own: the failures of required remotes are returned
+// together, while those of optional ones are only warned about.
+func (a *app) pushRelease(ctx context.Context, tag string, remotes []config.Remote, dryRun bool) error {
+	refs := []string{tag}
+	if branch := a.releaseBranch(ctx, tag); branch != "" {
+		refs = []string{a.branchRef(ctx, branch), tag}
+	}
+	repo := a.repo(dryRun)
+
+	var (
+		pushed []string
+		errs   []error
+	)
+	for _, r := range remotes {
+		var err error
+		for _, ref := range refs {
+			if err = repo.Push(ctx, r.Name, ref); err != nil {
+				break
+			}
+		}
+		res := pushResult{Remote: r.Name, Refs: refs}
+		switch {
+		case err == nil:
+			pushed = append(pushed, r.Name)
+			if !dryRun {
+				fmt.Fprintf(a.stdout, "pushed %s to %s\n", strings.Join(refs, " and "), r.Name)
+			}
+		case r.Optional:
+			res.Error = err.Error()
+			a.log.Warn(fmt.Sprintf("push to optional remote %s failed: %v", r.Name, err))
+		default:
+			res.Error = err.Error()
+			fmt.Fprintf(a.stdout, "failed to push %s to %s\n", strings.Join(refs, " and "), r.Name)
+			errs = append(errs, fmt.Errorf("push to %s: %w", r.Name, err))
+		}
+		a.result.Pushes = append(a.result.Pushes, res)
+	}
+	if !dryRun {
+		a.result.Pushed = strings.Join(pushed, ",")
+	}
+	return errors.Join(errs...)
+}
+
+// releaseBranch returns the release branch when HEAD is the version-bump
+// commit tag points at, as `release tag -bump-files` makes, so that the
+// commit is pushed with the tag. It returns "" otherwise, and when that
+// cannot be told.
+func (a *app) releaseBranch(ctx context.Context, tag string) string {
+	head, err := a.git.Head(ctx)
+	if err != nil {
+		return ""
+	}
+	tags, err := a.git.Tags(ctx)
+	if err != nil {
+		return ""
+	}
+	i := slices.IndexFunc(tags, func(t gitrepo.Tag) bool { return t.Name == tag })
+	if i < 0 || tags[i].Commit != head {
+		return ""
+	}
+	cs, err := a.git.Commits(ctx, head+"^!")
+	if err != nil || len(cs) == 0 {
+		return ""
+	}
+	if subject, _, _ := strings.Cut(cs[0].Message, "\n"); subject != "chore(release): "+tag {
+		return ""
+	}
+	branch, err := a.branch(ctx)
+	if err != nil {
+		return ""
+	}
+	if branch == "" {
+		a.log.Warn(fmt.Sprintf("HEAD is detached: the version-bump commit of %s is only pushed with the tag; pass -branch to push it to its branch", tag))
+	}
+	return branch
+}
+
+// branchRef returns the ref pushing HEAD to branch: branch itself when it
+// is checked out, otherwise HEAD:refs/heads/<branch>, which pushes from a
+// detached HEAD or a worktree checked out elsewhere.
+func (a *app) branchRef(ctx context.Context, branch string) string {
+	if current, err := a.git.CurrentBranch(ctx); err == nil && current == branch {
+		return branch
+	}
+	return "HEAD:refs/heads/" + branch
+}
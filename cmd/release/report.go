@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/report"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// reportCacheFile keeps the release pages looked up by report, in stateDir.
+const reportCacheFile = "report-cache.json"
+
+func (a *app) report(ctx context.Context, args []string) error {
+	fs := a.flags("report")
+	module := fs.String("module", "", "report the releases of this module (directory or name, see 'release modules')")
+	file := fs.String("file", "", "write the report to this file instead of printing it")
+	limit := fs.Int("limit", 20, "number of releases reported, newest first; 0 reports all")
+	title := fs.String("title", "", "title of the page (default: <repository or module> releases)")
+	tmpl := fs.String("template", "", "html/template file replacing the built-in page; its \"releases\" template is the fragment")
+	fragment := fs.Bool("fragment", false, "render only the releases section, to embed in a docs site page")
+	links := fs.Bool("links", true, "link each release to its page on the publish targets, looked up through their APIs")
+	provider := fs.String("provider", "", "link the releases on this provider (default: config publish targets, then CI detection)")
+	repo := fs.String("repo", "", "repository (owner/repo) or project path of the releases")
+	refresh := fs.Bool("refresh", false, "look up every release page again instead of using the cached ones")
+	maxAge := fs.Duration("max-age", 7*24*time.Hour, "how long a cached release page is used before it is looked up again")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: report takes no arguments", relerr.ErrUsage)
+	}
+	if *limit < 0 {
+		return fmt.Errorf("%w: -limit must not be negative", relerr.ErrUsage)
+	}
+	if *refresh && a.offline {
+		return fmt.Errorf("%w: -refresh looks the release pages up again, which -offline does not allow", relerr.ErrUsage)
+	}
+
+	var text string
+	if *tmpl != "" {
+		data, err := os.ReadFile(*tmpl)
+		if err != nil {
+			return relerr.Wrap(relerr.Config, err)
+		}
+		text = string(data)
+	}
+	renderer, err := report.NewRenderer(text)
+	if err != nil {
+		return relerr.Wrap(relerr.Config, err)
+	}
+
+	m, err := a.module(*module)
+	if err != nil {
+		return err
+	}
+	history, err := workspace.History(ctx, a.git, m)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(a.root)
+	if abs, err := filepath.Abs(a.root); err == nil {
+		name = filepath.Base(abs)
+	}
+	if *module != "" {
+		name = m.Name
+		a.result.Module = m.Name
+	}
+	r := report.New(*title, history, *limit, a.cfg.Changelog.Contributors.Exclude...)
+	if r.Title == "" {
+		r.Title = name + " releases"
+	}
+	r.Generated = a.now().UTC()
+	if len(r.Releases) > 0 {
+		a.result.Tag, a.result.Version = r.Releases[0].Tag, r.Releases[0].Version
+	}
+
+	if *links {
+		if err := a.reportLinks(ctx, &r, *provider, *repo, *refresh, *maxAge); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if *fragment {
+		err = renderer.RenderFragment(&buf, r)
+	} else {
+		err = renderer.Render(&buf, r)
+	}
+	if err != nil {
+		return relerr.Wrap(relerr.Config, err)
+	}
+	if *file == "" {
+		_, err := a.stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*file, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "wrote %s with %d release(s)\n", *file, len(r.Releases))
+	return nil
+}
+
+// reportLinks links the releases of r to their pages on the publish
+// targets that can read releases back, through the cache in stateDir. A
+// failed lookup only warns: the report is rendered without the link.
+// Offline, only the cached pages are linked, however old.
+func (a *app) reportLinks(ctx context.Context, r *report.Report, provider, repo string, refresh bool, maxAge time.Duration) error {
+	var sources []report.Source
+	for _, t := range a.publishTargets(provider, repo) {
+		if a.offline {
+			sources = append(sources, report.Source{Name: cacheSource(t), Fetcher: offlineFetcher{}})
+			continue
+		}
+		p, err := a.publisher(ctx, t, false)
+		if err != nil {
+			a.log.Warn(fmt.Sprintf("report: %s %s: %v; its releases are not linked", t.Provider, t.Repo, err))
+			continue
+		}
+		if f, ok := p.(publish.Fetcher); ok {
+			sources = append(sources, report.Source{Name: cacheSource(t), Fetcher: f})
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	path := filepath.Join(a.root, stateDir, reportCacheFile)
+	if a.offline {
+		maxAge = 0
+	}
+	cache := &report.Cache{Path: path, MaxAge: maxAge}
+	if !refresh {
+		c, err := report.LoadCache(path, maxAge)
+		if err != nil {
+			a.log.Warn(fmt.Sprintf("%v; the release pages are looked up again", err))
+		} else {
+			cache = c
+		}
+	}
+	if err := report.AddLinks(ctx, r, sources, cache); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		a.log.Warn(err.Error())
+	}
+	return cache.Save()
+}
+
+// offlineFetcher finds no release, for -offline reports to link only the
+// cached release pages.
+type offlineFetcher struct{}
+
+func (offlineFetcher) Fetch(context.Context, string) (publish.Published, bool, error) {
+	return publish.Published{}, false, nil
+}
+
+func (offlineFetcher) Download(context.Context, publish.RemoteAsset, io.Writer) error {
+	return errors.New("release pages are not downloaded -offline")
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/artifacts"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/preflight"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/verify"
+)
+
+// reproduce rebuilds the binaries of a release from the tagged commit,
+// checked out at HEAD, with the flags of a reproducible build, and checks
+// that each is identical to the one published, naming the sources of
+// non-determinism the build information of those that differ shows.
+func (a *app) reproduce(ctx context.Context, args []string) error {
+	fs := a.flags("reproduce")
+	provider := fs.String("provider", "", "compare with the release on this provider (default: config publish targets, then CI detection)")
+	repo := fs.String("repo", "", "repository (owner/repo) or project path of the release")
+	dir := fs.String("dir", "", "directory to rebuild and download the binaries to and keep (default: a temporary directory)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: release reproduce [flags] <version|tag>", relerr.ErrUsage)
+	}
+	tag := a.verifyTag(fs.Arg(0))
+	a.result.Tag, a.result.Version = tag, a.tagVersion(tag)
+
+	ac := a.cfg.Artifacts
+	if ac.Package == "" || len(ac.Targets) == 0 {
+		return relerr.Wrap(relerr.Config, errors.New("reproduce needs the artifacts.package and artifacts.targets the release was built from"))
+	}
+	targets, err := artifacts.ParseTargets(ac.Targets)
+	if err != nil {
+		return relerr.Wrap(relerr.Config, err)
+	}
+	if err := a.checkTaggedHead(ctx, tag); err != nil {
+		return err
+	}
+
+	if *dir == "" {
+		tmp, err := os.MkdirTemp("", "release-reproduce-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		*dir = tmp
+	}
+	b := &artifacts.Builder{
+		Package:      ac.Package,
+		Binary:       ac.Binary,
+		Targets:      targets,
+		Dir:          a.root,
+		Output:       filepath.Join(*dir, "rebuilt"),
+		NameTemplate: ac.Name,
+		LDFlags:      ac.LDFlags,
+		Reproducible: true,
+		Stdout:       a.stderr,
+		Stderr:       a.stderr,
+		Logger:       a.log,
+	}
+	if !filepath.IsAbs(b.Output) {
+		b.Output, _ = filepath.Abs(b.Output)
+	}
+	rebuilt, err := b.Build(ctx, a.tagVersion(tag))
+	if err != nil {
+		return err
+	}
+
+	var checks []preflight.Check
+	pubs := a.publishTargets(*provider, *repo)
+	for i, t := range pubs {
+		p, err := a.publisher(ctx, t, false)
+		if err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		f, ok := p.(publish.Fetcher)
+		if !ok {
+			a.log.Warn(fmt.Sprintf("reproduce: provider %s cannot read releases back; skipped", t.Provider))
+			continue
+		}
+		r := &verify.Reproduction{
+			Label:   strings.TrimSpace(t.Provider + " " + t.Repo),
+			Fetcher: f,
+			Tag:     tag,
+			Dir:     filepath.Join(*dir, "published"),
+			Rebuilt: rebuilt,
+		}
+		if len(pubs) > 1 {
+			r.Dir = filepath.Join(*dir, "published", strconv.Itoa(i+1)+"-"+t.Provider)
+		}
+		checks = append(checks, r.Checks()...)
+	}
+	if len(checks) == 0 {
+		return fmt.Errorf("%w: no release to compare the rebuild with; pass -provider and -repo", relerr.ErrUsage)
+	}
+	a.recordChecks(checks)
+	return verify.Run(ctx, a.stdout, checks)
+}
+
+// checkTaggedHead requires HEAD to be the commit of tag, with no
+// uncommitted changes, for a rebuild to be built from what was released.
+func (a *app) checkTaggedHead(ctx context.Context, tag string) error {
+	tags, err := a.git.Tags(ctx)
+	if err != nil {
+		return err
+	}
+	want := ""
+	for _, t := range tags {
+		if t.Name == tag {
+			want = t.Commit
+		}
+	}
+	if want == "" {
+		return relerr.Wrap(relerr.Precondition, fmt.Errorf("%s is not a local tag; fetch the tags", tag))
+	}
+	head, err := a.git.Head(ctx)
+	if err != nil {
+		return err
+	}
+	if head != want {
+		return relerr.Wrap(relerr.Precondition, fmt.Errorf("HEAD is %s, not the commit of %s; check out the tag, e.g. in a worktree with git worktree add", shortHash(head), tag))
+	}
+	changed, err := a.git.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		return relerr.Wrap(relerr.Precondition, fmt.Errorf("the working tree has uncommitted changes to %s", strings.Join(changed, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// rollbackStep is an action undoing part of a release. run returns what it
+// undid, or "" when there was nothing to undo.
+type rollbackStep struct {
+	desc string
+	run  func(ctx context.Context) (string, error)
+}
+
+// rollback undoes a release: it deletes (or drafts out) the provider
+// releases of the tag, deletes the tag from the push remotes and locally, and
+// reverts the version-bump commit the tag points at. The steps run in that
+// order and stop at the first failure, which is reported with the steps
+// undone so far and those left.
+func (a *app) rollback(ctx context.Context, args []string) error {
+	fs := a.flags("rollback")
+	module := fs.String("module", "", "module whose version is rolled back (directory or name, see 'release modules')")
+	provider := fs.String("provider", "", "take down the release on this provider (default: config publish targets, then CI detection)")
+	repo := fs.String("repo", "", "repository (owner/repo) or project path of the release")
+	draft := fs.Bool("draft", false, "turn the releases back into drafts instead of deleting them (GitHub only)")
+	yes := fs.Bool("yes", false, "roll back without asking for confirmation; required without a terminal")
+	dryRun := a.dryRunFlag(fs)
+	var remotes stringsFlag
+	fs.Var(&remotes, "remote", "git remote to delete the tag from (repeatable; default: the remotes config, or origin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: rollback needs the version or tag to roll back", relerr.ErrUsage)
+	}
+	a.result.DryRun = *dryRun
+	if !*dryRun && !a.tty && !*yes {
+		return fmt.Errorf("%w: stdin is not a terminal; pass -yes to roll back", relerr.ErrUsage)
+	}
+
+	tag, commit, err := a.rollbackTag(ctx, *module, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	a.result.Tag, a.result.Version = tag, a.tagVersion(tag)
+	names := remoteNames(a.pushRemotes(remotes))
+	steps, err := a.rollbackSteps(ctx, tag, commit, names, *provider, *repo, *draft, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "Rolling back %s:\n", tag)
+	for _, s := range steps {
+		fmt.Fprintf(a.stdout, "  - %s\n", s.desc)
+	}
+	if a.tty && !*yes && !*dryRun {
+		w := &wizard{in: bufio.NewReader(a.stdin), out: a.stdout, ask: true}
+		if ok, err := w.confirm("Go ahead?", false); err != nil {
+			return err
+		} else if !ok {
+			return errAborted
+		}
+	}
+
+	for i, s := range steps {
+		done, err := s.run(ctx)
+		if err != nil {
+			for _, left := range steps[i:] {
+				fmt.Fprintf(a.stdout, "not undone: %s\n", left.desc)
+			}
+			undone := "nothing"
+			if len(a.result.Undone) > 0 {
+				undone = strings.Join(a.result.Undone, "; ")
+			}
+			return fmt.Errorf("rollback of %s stopped, undone: %s: %w", tag, undone, err)
+		}
+		if done != "" && !*dryRun {
+			a.result.Undone = append(a.result.Undone, done)
+			fmt.Fprintln(a.stdout, done)
+		}
+	}
+	a.advance(tag, state.RolledBack, *dryRun)
+	if commit != "" && !*dryRun {
+		fmt.Fprintln(a.stdout, "push the revert to share it, e.g. git push "+names[0]+" HEAD")
+	}
+	return nil
+}
+
+// rollbackTag returns the tag named by arg, a tag or a version of module,
+// and the version-bump commit it points at, if any.
+func (a *app) rollbackTag(ctx context.Context, module, arg string) (tag, commit string, err error) {
+	tags, err := a.git.Tags(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	i := slices.IndexFunc(tags, func(t gitrepo.Tag) bool { return t.Name == arg })
+	if i < 0 {
+		m, err := a.module(module)
+		if err != nil {
+			return "", "", err
+		}
+		if _, err := version.Parse(arg); err != nil {
+			return "", "", fmt.Errorf("%w: %s is neither a tag nor a version", relerr.ErrUsage, arg)
+		}
+		tag = m.Tag(arg)
+		i = slices.IndexFunc(tags, func(t gitrepo.Tag) bool { return t.Name == tag })
+	} else {
+		tag = arg
+	}
+	if i < 0 || tags[i].Commit == "" {
+		return tag, "", nil
+	}
+
+	// The commit of `tag -bump-files` records the version the tag points at.
+	commits, err := a.git.Commits(ctx, tags[i].Commit+"^!")
+	if err != nil {
+		return "", "", err
+	}
+	if len(commits) > 0 {
+		subject, _, _ := strings.Cut(commits[0].Message, "\n")
+		if subject == "chore(release): "+tag {
+			commit = commits[0].Hash
+		}
+	}
+	return tag, commit, nil
+}
+
+// rollbackSteps plans the rollback of tag. Releases on providers that
+// cannot take them down are left out with a warning.
+func (a *app) rollbackSteps(ctx context.Context, tag, commit string, remotes []string, provider, repo string, draft, dryRun bool) ([]rollbackStep, error) {
+	var steps []rollbackStep
+	for _, t := range a.publishTargets(provider, repo) {
+		p, err := a.publisher(ctx, t, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := p.(publish.Retractor)
+		if !ok {
+			a.log.Warn(fmt.Sprintf("cannot take down the %s release on %s: remove it by hand", t.Provider, t.Repo))
+			continue
+		}
+		action, done := "delete", "deleted"
+		if draft {
+			action, done = "turn into a draft", "turned into a draft"
+		}
+		steps = append(steps, rollbackStep{
+			desc: fmt.Sprintf("%s the %s release on %s", action, t.Provider, t.Repo),
+			run: func(ctx context.Context) (string, error) {
+				found, err := r.Retract(ctx, tag, draft)
+				if err != nil || !found {
+					return "", err
+				}
+				return fmt.Sprintf("%s the %s release on %s", done, t.Provider, t.Repo), nil
+			},
+		})
+	}
+
+	git := a.repo(dryRun)
+	for _, remote := range remotes {
+		steps = append(steps, rollbackStep{
+			desc: fmt.Sprintf("delete tag %s from %s", tag, remote),
+			run: func(ctx context.Context) (string, error) {
+				return undoneUnlessMissing(git.DeleteRemoteTag(ctx, remote, tag), fmt.Sprintf("deleted tag %s from %s", tag, remote))
+			},
+		})
+	}
+	steps = append(steps, rollbackStep{
+		desc: fmt.Sprintf("delete tag %s", tag),
+		run: func(ctx context.Context) (string, error) {
+			return undoneUnlessMissing(git.DeleteTag(ctx, tag), "deleted tag "+tag)
+		},
+	})
+	if commit != "" {
+		short := commit[:min(len(commit), 7)]
+		steps = append(steps, rollbackStep{
+			desc: fmt.Sprintf("revert the version-bump commit %s", short),
+			run: func(ctx context.Context) (string, error) {
+				if err := git.Revert(ctx, commit); err != nil {
+					return "", err
+				}
+				return "reverted the version-bump commit " + short, nil
+			},
+		})
+	}
+	return steps, nil
+}
+
+// undoneUnlessMissing returns done for a successful tag deletion, and
+// nothing for a tag that was not there, which is not a failure.
+func undoneUnlessMissing(err error, done string) (string, error) {
+	switch {
+	case errors.Is(err, gitrepo.ErrNoSuchTag):
+		return "", nil
+	case err != nil:
+		return "", err
+	}
+	return done, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"os"
+
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/secrets"
+)
+
+// secretStore returns the store of the secrets sources in cfg, followed by
+// the environment unless they list it. The values found are redacted by r.
+func secretStore(cfg *config.Config, r *log.Redactor) *secrets.Store {
+	s := &secrets.Store{OnResolve: func(v string) { r.Add(v) }}
+	env := false
+	for _, src := range cfg.Secrets {
+		switch src.Provider {
+		case "env":
+			s.Providers = append(s.Providers, secrets.Env{})
+			env = true
+		case "file":
+			s.Providers = append(s.Providers, secrets.Dir{Path: src.Dir})
+		case "vault":
+			s.Providers = append(s.Providers, &secrets.Vault{
+				Address: cmp.Or(src.Address, os.Getenv("VAULT_ADDR")),
+				Token:   os.Getenv("VAULT_TOKEN"),
+				Mount:   src.Mount,
+				Path:    src.Path,
+			})
+		case "aws":
+			s.Providers = append(s.Providers, &secrets.AWS{
+				Region:          cmp.Or(src.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")),
+				SecretID:        src.SecretID,
+				AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+				SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+				SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			})
+		}
+	}
+	if !env {
+		s.Providers = append(s.Providers, secrets.Env{})
+	}
+	return s
+}
+
+// secret returns a lookup of secrets by name, such as GITHUB_TOKEN, in the
+// configured stores. A failed lookup is logged and finds nothing.
+func (a *app) secret(ctx context.Context) func(name string) string {
+	if a.secrets == nil {
+		return os.Getenv
+	}
+	return func(name string) string {
+		v, _, err := a.secrets.Lookup(ctx, name)
+		if err != nil {
+			a.log.Warn(err.Error())
+		}
+		return v
+	}
+}
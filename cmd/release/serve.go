@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+	"github.com/gbrennon/release_automation_golang/pkg/telemetry"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// serveTokenEnv holds the token of `release serve` when -token is not given.
+const serveTokenEnv = "RELEASE_SERVE_TOKEN"
+
+// maxRequestBody bounds the request and webhook bodies read by the server.
+const maxRequestBody = 1 << 20
+
+// serve runs an HTTP server computing the next version and changelog of the
+// repository and cutting releases on request, e.g. from the webhook of a
+// merge. It stops gracefully once ctx is done.
+func (a *app) serve(ctx context.Context, args []string) error {
+	fs := a.flags("serve")
+	addr := fs.String("addr", ":8080", "address to listen on")
+	token := fs.String("token", a.secret(ctx)(serveTokenEnv), "token clients and webhooks authenticate with (default: $"+serveTokenEnv+")")
+	remote := fs.String("remote", "origin", "git remote to pull merged branches from and push tags to, unless the remotes config lists others")
+	tags := fs.Bool("tags", false, "publish the release tags pushed and notify of the releases created elsewhere, on webhook events")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: serve takes no arguments", relerr.ErrUsage)
+	}
+	if *token == "" {
+		return fmt.Errorf("%w: serve needs -token or %s", relerr.ErrUsage, serveTokenEnv)
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	if a.telemetry == nil {
+		a.telemetry = telemetry.NewRecorder(a.serviceName())
+	}
+	if a.exporter != nil {
+		interval := defaultTelemetryInterval
+		if a.cfg.Telemetry.Interval != "" {
+			interval, _ = time.ParseDuration(a.cfg.Telemetry.Interval)
+		}
+		go a.exportTelemetryEvery(ctx, interval)
+	}
+	srv := &http.Server{Handler: a.serveHandler(*token, *remote, *tags), ReadHeaderTimeout: 10 * time.Second}
+	a.log.Info(fmt.Sprintf("serving on %s", ln.Addr()))
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdown)
+}
+
+// server answers the requests of `release serve` by running the commands
+// of the CLI against its working copy.
+type server struct {
+	app    *app
+	token  string
+	remote string
+	// tags has tag pushes published and releases created notified of.
+	tags bool
+	// mu serializes the commands, which share the working copy.
+	mu sync.Mutex
+	// published holds the tags the server published, whose own tag push
+	// and release events are ignored. mu guards it.
+	published map[string]bool
+}
+
+// serveHandler routes the endpoints of `release serve`:
+//
+//	GET  /healthz       liveness, unauthenticated
+//	GET  /v1/next       the next version; ?module=, ?bump= and ?channel= as for next
+//	GET  /v1/changelog  the changelog section, as markdown when Accept asks for it
+//	POST /v1/release    tag and publish the next version
+//	POST /v1/webhook    release a branch on GitHub and GitLab push and merge events,
+//	                    and with tags publish pushed tags and notify of releases
+//	GET  /metrics       the telemetry in the Prometheus text format, when recorded
+func (a *app) serveHandler(token, remote string, tags bool) http.Handler {
+	if a.limiters == nil {
+		// Set before run copies the app, for requests to share the budget.
+		a.limiters = new(rateLimiters)
+	}
+	s := &server{app: a, token: token, remote: remote, tags: tags, published: make(map[string]bool)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("GET /v1/next", s.auth(s.plan("next"), false))
+	mux.Handle("GET /v1/changelog", s.auth(s.plan("changelog"), false))
+	mux.Handle("POST /v1/release", s.auth(http.HandlerFunc(s.release), false))
+	mux.Handle("POST /v1/webhook", s.auth(http.HandlerFunc(s.webhook), true))
+	if a.telemetry != nil {
+		mux.Handle("GET /metrics", s.auth(a.telemetry.Handler(), false))
+	}
+	return mux
+}
+
+// auth rejects the requests not carrying the token: as a bearer token, as
+// GitLab's X-Gitlab-Token, or, when signed is set, as the secret of
+// GitHub's X-Hub-Signature-256. Only webhooks take signatures: a signed
+// delivery could otherwise be replayed as is against the other endpoints.
+func (s *server) auth(next http.Handler, signed bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		if err != nil {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": err.Error()})
+			return
+		}
+		if !s.authorized(r, body, signed) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) authorized(r *http.Request, body []byte, signed bool) bool {
+	if t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return subtle.ConstantTimeCompare([]byte(t), []byte(s.token)) == 1
+	}
+	if t := r.Header.Get("X-Gitlab-Token"); t != "" {
+		return subtle.ConstantTimeCompare([]byte(t), []byte(s.token)) == 1
+	}
+	if sig, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256="); ok && signed {
+		mac := hmac.New(sha256.New, []byte(s.token))
+		mac.Write(body)
+		return hmac.Equal([]byte(sig), []byte(hex.EncodeToString(mac.Sum(nil))))
+	}
+	return false
+}
+
+// plan serves the result of a planning command, next or changelog.
+func (s *server) plan(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		res, status := s.exec(r.Context(), name, planArgs(q.Get("module"), q.Get("bump"), q.Get("channel"))...)
+		if name == "changelog" && status == http.StatusOK && strings.Contains(r.Header.Get("Accept"), "text/markdown") {
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			io.WriteString(w, res.Changelog)
+			return
+		}
+		writeJSON(w, status, res)
+	}
+}
+
+// releaseRequest is the body of POST /v1/release.
+type releaseRequest struct {
+	Module  string `json:"module"`
+	Bump    string `json:"bump"`
+	Channel string `json:"channel"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+func (s *server) release(w http.ResponseWriter, r *http.Request) {
+	var req releaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results, status := s.cut(r.Context(), planArgs(req.Module, req.Bump, req.Channel), req.DryRun)
+	writeJSON(w, status, results)
+}
+
+// cut releases the next version: it renders the release notes, tags and
+// publishes, with the curated notes of the version when there are some,
+// stopping at the first failing step. The results of the steps
+// run are returned with the status of the last one. s.mu must be held.
+func (s *server) cut(ctx context.Context, args []string, dryRun bool) ([]*result, int) {
+	notes, status := s.run(ctx, dryRun, "notes", args...)
+	results := []*result{notes}
+	if notes.ExitCode != 0 {
+		return results, status
+	}
+	tag, status := s.run(ctx, dryRun, "tag", args...)
+	results = append(results, tag)
+	if tag.ExitCode != 0 {
+		return results, status
+	}
+
+	body, curated, err := s.app.curatedNotes(tag.Version)
+	if err != nil {
+		tag.Error = err.Error()
+		return results, http.StatusInternalServerError
+	}
+	if !curated {
+		body = notes.Notes
+	}
+	f, err := os.CreateTemp("", "release-notes-*.md")
+	if err != nil {
+		tag.Error = err.Error()
+		return results, http.StatusInternalServerError
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		tag.Error = err.Error()
+		return results, http.StatusInternalServerError
+	}
+	published, status := s.publish(ctx, dryRun, tag.Tag, "-notes", f.Name())
+	return append(results, published), status
+}
+
+// publish runs publish for tag, pushing it to the server's remote unless
+// remotes are configured, and records it as published by the server once
+// it succeeds, unless in a dry run, so that a failed publish can be retried
+// by pushing the tag again. s.mu must be held.
+func (s *server) publish(ctx context.Context, dryRun bool, tag string, args ...string) (*result, int) {
+	if len(s.app.cfg.Remotes) == 0 {
+		args = append([]string{"-remote", s.remote}, args...)
+	}
+	res, status := s.run(ctx, dryRun, "publish", append(args, tag)...)
+	if res.ExitCode == 0 && !dryRun && !s.app.dryRun {
+		s.published[tag] = true
+	}
+	return res, status
+}
+
+// webhookEvent is the part of GitHub and GitLab push, merge and release
+// events the server reads.
+type webhookEvent struct {
+	// GitHub and GitLab pushes.
+	Ref string `json:"ref"`
+	// GitHub pull_request and release.
+	Action      string `json:"action"`
+	PullRequest struct {
+		Merged bool `json:"merged"`
+		Base   struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Release struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	} `json:"release"`
+	// GitLab Merge Request Hook.
+	ObjectAttributes struct {
+		Action       string `json:"action"`
+		TargetBranch string `json:"target_branch"`
+	} `json:"object_attributes"`
+	// GitLab Release Hook; its action is Action.
+	Tag string `json:"tag"`
+	URL string `json:"url"`
+}
+
+// webhookTrigger is what a webhook event asks of the server: to release
+// Branch, updated by a push or merge, to publish the pushed Tag, or to
+// notify of the release of Released, created at URL. All are empty for
+// other events and pull requests closed unmerged.
+type webhookTrigger struct {
+	Branch   string
+	Tag      string
+	Released string
+	URL      string
+}
+
+// parseWebhook returns the trigger of a GitHub or GitLab webhook event.
+func parseWebhook(r *http.Request, body []byte) (webhookTrigger, error) {
+	var e webhookEvent
+	var kind string
+	switch {
+	case r.Header.Get("X-GitHub-Event") != "":
+		kind = "github." + r.Header.Get("X-GitHub-Event")
+	case r.Header.Get("X-Gitlab-Event") != "":
+		kind = "gitlab." + r.Header.Get("X-Gitlab-Event")
+	default:
+		return webhookTrigger{}, fmt.Errorf("%w: not a GitHub or GitLab webhook", relerr.ErrUsage)
+	}
+	if err := json.Unmarshal(body, &e); err != nil {
+		return webhookTrigger{}, fmt.Errorf("%w: invalid webhook payload: %v", relerr.ErrUsage, err)
+	}
+	switch kind {
+	case "github.push", "gitlab.Push Hook", "gitlab.Tag Push Hook":
+		if branch, ok := strings.CutPrefix(e.Ref, "refs/heads/"); ok {
+			return webhookTrigger{Branch: branch}, nil
+		}
+		if tag, ok := strings.CutPrefix(e.Ref, "refs/tags/"); ok && !deletedRef(body) {
+			return webhookTrigger{Tag: tag}, nil
+		}
+	case "github.pull_request":
+		if e.Action == "closed" && e.PullRequest.Merged {
+			return webhookTrigger{Branch: e.PullRequest.Base.Ref}, nil
+		}
+	case "github.release":
+		if e.Action == "published" {
+			return webhookTrigger{Released: e.Release.TagName, URL: e.Release.HTMLURL}, nil
+		}
+	case "gitlab.Merge Request Hook":
+		if e.ObjectAttributes.Action == "merge" {
+			return webhookTrigger{Branch: e.ObjectAttributes.TargetBranch}, nil
+		}
+	case "gitlab.Release Hook":
+		if e.Action == "create" {
+			return webhookTrigger{Released: e.Tag, URL: e.URL}, nil
+		}
+	}
+	return webhookTrigger{}, nil
+}
+
+// deletedRef reports whether a push event deleted its ref: GitHub sets
+// deleted, GitLab an all-zero after.
+func deletedRef(body []byte) bool {
+	var e struct {
+		Deleted bool   `json:"deleted"`
+		After   string `json:"after"`
+	}
+	json.Unmarshal(body, &e)
+	return e.Deleted || e.After != "" && strings.Trim(e.After, "0") == ""
+}
+
+// webhook releases the branch checked out by the server when an event
+// updates it: the branch is pulled from the remote, then released as by
+// POST /v1/release. Events for other branches are acknowledged and ignored,
+// and so are tag and release events unless the server handles tags.
+func (s *server) webhook(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	trigger, err := parseWebhook(r, body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	switch {
+	case (trigger.Tag != "" || trigger.Released != "") && !s.tags:
+		writeJSON(w, http.StatusAccepted, map[string]string{"ignored": "tag events are not handled without -tags"})
+		return
+	case trigger.Tag != "":
+		s.publishTag(w, r, trigger.Tag)
+		return
+	case trigger.Released != "":
+		s.notifyRelease(w, r, trigger.Released, trigger.URL)
+		return
+	case trigger.Branch == "":
+		writeJSON(w, http.StatusAccepted, map[string]string{"ignored": "not a push or merge to a branch"})
+		return
+	}
+	branch := trigger.Branch
+	cfg := s.app.cfg
+	if !slices.Contains(cfg.Branches, branch) && cfg.Channel(branch) == "" && cfg.Line(branch) == "" {
+		writeJSON(w, http.StatusAccepted, map[string]string{"ignored": fmt.Sprintf("branch %s is not released", branch)})
+		return
+	}
+	// The lock is held from the pull to the release, for the release to
+	// be cut from the commits pulled.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, err := s.app.git.CurrentBranch(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if current != branch {
+		writeJSON(w, http.StatusAccepted, map[string]string{"ignored": fmt.Sprintf("branch %s is not checked out", branch)})
+		return
+	}
+	if err := s.app.repo(s.app.dryRun).Pull(r.Context(), s.remote, branch); err != nil {
+		s.app.log.Error(fmt.Sprintf("release serve: %v", err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	results, status := s.cut(r.Context(), nil, false)
+	writeJSON(w, status, results)
+}
+
+// publishTag publishes a release tag pushed by someone else: the checked
+// out branch is pulled from the remote with its tags, then the tag is
+// published as by `release publish`, which notifies of it. Tags that do
+// not name a version, and those the server published itself, are
+// acknowledged and ignored.
+func (s *server) publishTag(w http.ResponseWriter, r *http.Request, tag string) {
+	if _, err := version.Parse(s.app.tagVersion(tag)); err != nil {
+		writeJSON(w, http.StatusAccepted, map[string]string{"ignored": fmt.Sprintf("tag %s is not a release tag", tag)})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.published[tag] {
+		writeJSON(w, http.StatusAccepted, map[string]string{"ignored": fmt.Sprintf("tag %s was published by the server", tag)})
+		return
+	}
+	current, err := s.app.git.CurrentBranch(r.Context())
+	if err == nil {
+		err = s.app.repo(s.app.dryRun).Pull(r.Context(), s.remote, current)
+	}
+	if err != nil {
+		s.app.log.Error(fmt.Sprintf("release serve: %v", err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	res, status := s.publish(r.Context(), false, tag)
+	writeJSON(w, status, []*result{res})
+}
+
+// notifyRelease sends the notifications of a release created elsewhere,
+// such as by hand on the provider. Releases the server published, which
+// publish notified of already, are acknowledged and ignored.
+func (s *server) notifyRelease(w http.ResponseWriter, r *http.Request, tag, url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.published[tag] {
+		writeJSON(w, http.StatusAccepted, map[string]string{"ignored": fmt.Sprintf("release %s was published by the server", tag)})
+		return
+	}
+	e := notify.Event{Status: notify.Success, Tag: tag, Time: s.app.now()}
+	if url != "" {
+		e.URLs = []string{url}
+	}
+	s.app.notify(r.Context(), e, s.app.dryRun)
+	writeJSON(w, http.StatusOK, map[string]string{"notified": tag})
+}
+
+// exec runs a command for a request, one at a time.
+func (s *server) exec(ctx context.Context, name string, args ...string) (*result, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.run(ctx, false, name, args...)
+}
+
+// run runs a command as `release -output json` would, on a copy of the app
+// discarding the human output, and returns its result with the HTTP status
+// matching its exit status. The server's -dry-run applies to every command.
+// s.mu must be held.
+func (s *server) run(ctx context.Context, dryRun bool, name string, args ...string) (*result, int) {
+	sub := *s.app
+	sub.dryRun = sub.dryRun || dryRun
+	sub.stdin, sub.tty = strings.NewReader(""), false
+	sub.stdout, sub.stderr = io.Discard, io.Discard
+	sub.output, sub.command = outputText, name
+	err := sub.runCommand(ctx, command{name: name, run: releaseCommands[name]}, args)
+	res := sub.result
+	if err != nil {
+		res.Error, res.ExitCode = err.Error(), exitCode(err)
+		s.app.log.Error(fmt.Sprintf("release serve: %s: %v", name, err))
+	}
+	return res, httpStatus(res.ExitCode)
+}
+
+// releaseCommands are the commands serve and schedule run. They are listed
+// apart from commands, which those are part of.
+var releaseCommands = map[string]func(a *app, ctx context.Context, args []string) error{
+	"next":      (*app).next,
+	"changelog": (*app).changelog,
+	"notes":     (*app).notes,
+	"tag":       (*app).tag,
+	"publish":   (*app).publish,
+}
+
+// httpStatuses maps exit statuses to HTTP statuses. Nothing to release is
+// not a failure of the request: the result tells it with its exit code.
+var httpStatuses = map[int]int{
+	0:   http.StatusOK,
+	2:   http.StatusBadRequest,
+	3:   http.StatusOK,
+	4:   http.StatusPreconditionFailed,
+	5:   http.StatusConflict,
+	7:   http.StatusPreconditionFailed,
+	8:   http.StatusBadGateway,
+	10:  http.StatusBadGateway,
+	11:  http.StatusOK,
+	124: http.StatusGatewayTimeout,
+}
+
+func httpStatus(code int) int {
+	if status, ok := httpStatuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// planArgs returns the flags selecting the release planned by a request.
+func planArgs(module, bump, channel string) []string {
+	var args []string
+	if module != "" {
+		args = append(args, "-module", module)
+	}
+	if bump != "" {
+		args = append(args, "-bump", bump)
+	}
+	if channel != "" {
+		args = append(args, "-channel", channel)
+	}
+	return args
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
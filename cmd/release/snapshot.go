@@ -0,0 +1,170 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/notes"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+const (
+	// defaultSnapshotTag is the tag of the rolling snapshot prerelease.
+	defaultSnapshotTag = "nightly"
+	// defaultSnapshotChannel starts the prerelease of snapshot versions.
+	defaultSnapshotChannel = "dev"
+	// defaultSnapshotKeep is the number of snapshot builds whose assets
+	// the rolling prerelease keeps.
+	defaultSnapshotKeep = 5
+)
+
+// snapshot publishes a build of HEAD between releases: versioned after the
+// next release, such as 1.4.0-dev.20240601.abc1234, without tagging it,
+// on the rolling prerelease of the snapshot tag, which every snapshot
+// overwrites. The assets of the builds beyond the retention count are
+// deleted from it. Snapshots run no hooks and record no release state.
+func (a *app) snapshot(ctx context.Context, args []string) error {
+	sc := a.cfg.Snapshot
+	fs := a.flags("snapshot")
+	opts := &planOptions{}
+	fs.StringVar(&opts.bump, "bump", "auto", "bump level of the release the snapshot leads to: auto, patch, minor or major")
+	fs.StringVar(&opts.module, "module", "", "snapshot only this module (directory or name, see 'release modules')")
+	tag := fs.String("tag", cmp.Or(sc.Tag, defaultSnapshotTag), "tag of the rolling prerelease the snapshot replaces")
+	keep := fs.Int("keep", cmp.Or(sc.Keep, defaultSnapshotKeep), "number of snapshot builds whose assets the prerelease keeps, this one included")
+	publishSnapshot := fs.Bool("publish", true, "publish the snapshot; -publish=false only prints its version")
+	build := fs.Bool("build", len(a.cfg.Artifacts.Targets) > 0, "build the configured artifacts and attach them (default: when artifacts are configured)")
+	provider := fs.String("provider", "", "publish the snapshot on this provider (default: config publish targets, then CI detection)")
+	repo := fs.String("repo", "", "repository (owner/repo) to publish the snapshot on")
+	var assets stringsFlag
+	fs.Var(&assets, "asset", "file to upload to the prerelease (repeatable)")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: snapshot takes no arguments", relerr.ErrUsage)
+	}
+	if *keep < 1 {
+		return fmt.Errorf("%w: -keep must be at least 1", relerr.ErrUsage)
+	}
+	a.result.DryRun = *dryRun
+
+	p, err := a.newPlan(ctx, opts)
+	if errors.Is(err, relerr.ErrNoCommitsSinceTag) {
+		// Nothing to release yet: the snapshot leads to the next patch.
+		p, err = p.WithLevel(version.Patch), nil
+	}
+	if err != nil {
+		return err
+	}
+	head, err := a.git.Head(ctx)
+	if err != nil {
+		return err
+	}
+	channel := cmp.Or(sc.Channel, defaultSnapshotChannel)
+	if p.Next, err = version.Snapshot(p.Next, channel, a.now(), head); err != nil {
+		return relerr.Wrap(relerr.Config, err)
+	}
+	a.result.setPlan(p)
+	fmt.Fprintln(a.stdout, p.Next)
+	if !*publishSnapshot {
+		return nil
+	}
+	a.result.Tag = *tag
+
+	targets := a.publishTargets(*provider, *repo)
+	if len(targets) == 0 {
+		return relerr.Wrap(relerr.Config, errors.New("snapshot: no publish target to publish the snapshot on"))
+	}
+	if *build {
+		built, err := a.buildArtifacts(ctx, a.cfg.Artifacts, p.Tag(), *dryRun)
+		if err != nil {
+			return err
+		}
+		assets = append(assets, built...)
+		a.result.Assets = built
+	}
+
+	links := a.links("", "")
+	data := a.notesData(ctx, p, links, 0, false)
+	if p.PreviousTag != "" {
+		data.CompareURL = links.Compare(p.PreviousTag, head)
+	}
+	body, err := notes.Default(links).RenderString(data)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		pub, err := a.publisher(ctx, t, *dryRun)
+		if err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		s, ok := pub.(publish.Snapshotter)
+		if !ok {
+			a.log.Warn(fmt.Sprintf("snapshot: %s %s keeps no rolling prerelease; the snapshot is not published there", t.Provider, t.Repo))
+			continue
+		}
+		r := publish.Release{
+			Tag:        *tag,
+			Version:    p.Next.String(),
+			Name:       *tag + " " + p.Next.String(),
+			Body:       body,
+			Prerelease: true,
+			Assets:     slices.Concat(t.Assets, assets),
+		}
+		result, err := s.Snapshot(ctx, r, head)
+		if err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		a.result.Releases = append(a.result.Releases, releaseResult{
+			Provider: t.Provider,
+			Repo:     t.Repo,
+			ID:       result.ID,
+			URL:      result.URL,
+			Existing: result.Existing,
+			Assets:   result.Uploaded,
+		})
+		if result.URL != "" {
+			fmt.Fprintf(a.stdout, "published %s\n", result.URL)
+		}
+		a.pruneSnapshots(ctx, pub, s, *tag, channel, p.Next, *keep)
+	}
+	return nil
+}
+
+// pruneSnapshots deletes from the rolling prerelease of tag the assets of
+// the snapshot builds beyond the keep latest, current included. A failure
+// only warns: the snapshot is published.
+func (a *app) pruneSnapshots(ctx context.Context, pub publish.Publisher, s publish.Snapshotter, tag, channel string, current version.Version, keep int) {
+	f, ok := pub.(publish.Fetcher)
+	if !ok {
+		return
+	}
+	release, found, err := f.Fetch(ctx, tag)
+	if err != nil {
+		a.log.Warn(fmt.Sprintf("snapshot: could not list the assets of %s to prune: %v", tag, err))
+		return
+	}
+	if !found {
+		return
+	}
+	var names []string
+	for _, asset := range release.Assets {
+		names = append(names, asset.Name)
+	}
+	stale := publish.StaleSnapshots(names, channel, current, keep)
+	if len(stale) == 0 {
+		return
+	}
+	if err := s.DeleteAssets(ctx, tag, stale); err != nil {
+		a.log.Warn(fmt.Sprintf("snapshot: could not prune %s: %v", tag, err))
+		return
+	}
+	fmt.Fprintf(a.stdout, "pruned %d asset(s) of older snapshots from %s\n", len(stale), tag)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+)
+
+// stateDir is the directory, relative to the repository root, holding the
+// release state and audit log.
+const stateDir = ".release"
+
+// advance records that the release of tag reached to. State is a record of
+// the release, not a condition for it: failures, including transitions
+// the state machine refuses, are warnings.
+func (a *app) advance(tag string, to state.State, dryRun bool, urls ...string) {
+	if a.state == nil || dryRun {
+		return
+	}
+	if _, err := a.state.Advance(state.Event{Tag: tag, To: to, Command: a.command, URLs: urls}); err != nil {
+		a.log.Warn(err.Error())
+	}
+}
+
+func (a *app) status(ctx context.Context, args []string) error {
+	fs := a.flags("status")
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q: must be text or json", *format)
+	}
+
+	r, err := a.loadState()
+	if err != nil {
+		return err
+	}
+	a.result.State, a.result.Tag = r, r.Tag
+	if *format == "json" {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+
+	fmt.Fprintf(a.stdout, "%s: %s\n", r.Tag, r.State)
+	for _, t := range r.History {
+		fmt.Fprintf(a.stdout, "  %s  %s → %s", t.At.Format("2006-01-02T15:04:05Z07:00"), t.From, t.To)
+		if t.Command != "" {
+			fmt.Fprintf(a.stdout, " (%s)", t.Command)
+		}
+		fmt.Fprintln(a.stdout)
+	}
+	for _, u := range r.URLs {
+		fmt.Fprintf(a.stdout, "  %s\n", u)
+	}
+	if r.State != state.Announced && r.State != state.RolledBack {
+		fmt.Fprintln(a.stdout, "run 'release resume' to continue")
+	}
+	return nil
+}
+
+// resume runs the step after the recorded state: tag, publish, approve, or
+// the notifications. args are passed to the command run.
+func (a *app) resume(ctx context.Context, args []string) error {
+	r, err := a.loadState()
+	if err != nil {
+		return err
+	}
+
+	switch r.State {
+	case state.Pending, state.Versioned:
+		fmt.Fprintf(a.stdout, "resuming %s: tagging\n", r.Tag)
+		return a.tag(ctx, args)
+	case state.Tagged, state.Built:
+		fmt.Fprintf(a.stdout, "resuming %s: publishing\n", r.Tag)
+		return a.publish(ctx, append(args, r.Tag))
+	case state.Drafted:
+		fmt.Fprintf(a.stdout, "resuming %s: approving\n", r.Tag)
+		return a.approve(ctx, append(args, r.Tag))
+	case state.Published:
+		fmt.Fprintf(a.stdout, "resuming %s: announcing\n", r.Tag)
+		nctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		defer cancel()
+		a.notify(nctx, notify.Event{Status: notify.Success, Tag: r.Tag, URLs: r.URLs, Time: a.now()}, a.dryRun)
+		a.advance(r.Tag, state.Announced, a.dryRun)
+		return nil
+	}
+	fmt.Fprintf(a.stdout, "%s is already %s\n", r.Tag, r.State)
+	return nil
+}
+
+// loadState returns the recorded release, failing when there is none.
+func (a *app) loadState() (*state.Release, error) {
+	if a.state == nil {
+		return nil, errors.New("no release state is recorded")
+	}
+	r, err := a.state.Load()
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, fmt.Errorf("no release recorded in %s", a.state.Dir)
+	}
+	return r, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gbrennon/release_automation_golang/pkg/bumpfiles"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+	"github.com/gbrennon/release_automation_golang/pkg/timeline"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+func (a *app) tag(ctx context.Context, args []string) error {
+	fs := a.flags("tag")
+	opts := a.planFlags(fs)
+	message := fs.String("message", "", "tag message (default: tag.message, or \"chore(release): <tag>\")")
+	sign := fs.Bool("sign", a.cfg.Tag.Sign, "create a signed tag")
+	key := fs.String("signing-key", a.cfg.Tag.SigningKey, "GPG key ID or SSH key file to sign with (implies -sign)")
+	bump := fs.Bool("bump-files", len(a.cfg.VersionFiles) > 0, "update and commit the configured version_files before tagging (default: when version_files are configured)")
+	remote := fs.String("remote", "origin", "remote whose tags are checked before tagging; empty checks local tags only")
+	forceRetag := fs.Bool("force-retag", false, "move an existing tag of the same name: delete it locally and on -remote, then tag")
+	all := fs.Bool("all", false, "tag every module with releasable changes, in the order of their go.mod requirements (monorepos)")
+	tidy := fs.Bool("tidy", true, "with -all, run go mod tidy in the modules whose requirements are moved to the new versions")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	a.result.DryRun = *dryRun
+	if *all && (opts.module != "" || *forceRetag) {
+		return fmt.Errorf("%w: -all cannot be combined with -module or -force-retag", relerr.ErrUsage)
+	}
+
+	if err := a.preflightBefore(ctx, "tag"); err != nil {
+		return err
+	}
+	if *all {
+		return a.tagModules(ctx, moduleTagOptions{plan: opts, message: *message, sign: *sign, key: *key, remote: *remote, tidy: *tidy, dryRun: *dryRun})
+	}
+	p, err := a.newPlan(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	tag := p.Tag()
+	moveLocal, moveRemote, err := a.checkTag(ctx, tag, *remote, *bump, *forceRetag)
+	if err != nil {
+		return err
+	}
+	msg, err := a.tagMessage(ctx, *message, p)
+	if err != nil {
+		return err
+	}
+
+	a.advance(tag, state.Versioned, *dryRun)
+	if err := a.runHooks(ctx, hooks.PreBump, planEnv(p), *dryRun); err != nil {
+		return err
+	}
+	repo := a.repo(*dryRun)
+	if *bump {
+		if err := a.bumpFiles(ctx, repo, p.Next.String(), tag, *dryRun); err != nil {
+			return err
+		}
+	}
+	if moveLocal {
+		if err := repo.DeleteTag(ctx, tag); err != nil {
+			return err
+		}
+	}
+	if moveRemote {
+		if err := repo.DeleteRemoteTag(ctx, *remote, tag); err != nil {
+			return err
+		}
+	}
+	if *sign || *key != "" {
+		s := a.signing()
+		s.Key = *key
+		err = repo.CreateSignedTag(ctx, tag, msg, s)
+	} else {
+		err = repo.CreateTag(ctx, tag, msg)
+	}
+	if err != nil {
+		return err
+	}
+	a.advance(tag, state.Tagged, *dryRun)
+	a.recordTimeline(ctx, tag, timeline.Tagged, func() workspace.Plan { return p }, *dryRun)
+	a.result.setPlan(p)
+	a.result.TagCreated = !*dryRun
+
+	fmt.Fprintln(a.stdout, tag)
+	return nil
+}
+
+// tagMessage returns the message of the tag of p: message, or else
+// tag.message rendered for p, or else "chore(release): <tag>".
+func (a *app) tagMessage(ctx context.Context, message string, p workspace.Plan) (string, error) {
+	if message == "" && a.cfg.Tag.Message != "" {
+		return a.renderTitle(ctx, a.cfg.Tag.Message, p)
+	}
+	if message == "" {
+		message = "chore(release): " + p.Tag()
+	}
+	return message, nil
+}
+
+// checkTag refuses to create tag where a tag of that name exists, locally
+// or on remote, unless force is set. It then reports which of the existing
+// tags must be deleted to move the tag. With bump, the tag goes on a
+// version-bump commit yet to be made. A remote whose tags cannot be listed
+// is skipped with a warning.
+func (a *app) checkTag(ctx context.Context, tag, remote string, bump, force bool) (moveLocal, moveRemote bool, err error) {
+	var commit string
+	if !bump {
+		if commit, err = a.git.Head(ctx); err != nil {
+			return false, false, err
+		}
+	}
+	local, err := a.git.Tags(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	var remoteTags []gitrepo.Tag
+	if remote != "" {
+		if remoteTags, err = a.git.RemoteTags(ctx, remote); err != nil {
+			remoteTags = nil
+			a.log.Warn(fmt.Sprintf("cannot list the tags of %s, checking local tags only: %v", remote, err))
+		}
+	}
+
+	err = gitrepo.CheckTag(tag, commit, local, remoteTags, remote)
+	if err == nil || !force {
+		return false, false, err
+	}
+	a.log.Warn(fmt.Sprintf("%v; moving it (-force-retag)", err))
+	hasTag := func(t gitrepo.Tag) bool { return t.Name == tag }
+	return slices.ContainsFunc(local, hasTag), slices.ContainsFunc(remoteTags, hasTag), nil
+}
+
+// bumpFiles writes version into the configured version files and commits
+// them as "chore(release): <tag>".
+func (a *app) bumpFiles(ctx context.Context, repo gitrepo.Repository, version, tag string, dryRun bool) error {
+	files := make([]bumpfiles.File, len(a.cfg.VersionFiles))
+	paths := make([]string, len(a.cfg.VersionFiles))
+	for i, f := range a.cfg.VersionFiles {
+		files[i] = bumpfiles.File{Path: f.Path, Key: f.Key, Pattern: f.Pattern}
+		paths[i] = f.Path
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	b := &bumpfiles.Bumper{Dir: a.root, DryRun: dryRun, Log: a.stdout, Logger: a.log}
+	if _, err := b.Bump(files, version); err != nil {
+		return err
+	}
+	return repo.CommitFiles(ctx, "chore(release): "+tag, paths...)
+}
+
+// signing returns the tag signing settings from the configuration.
+func (a *app) signing() gitrepo.Signing {
+	return gitrepo.Signing{
+		Format:         a.cfg.Tag.SigningFormat,
+		Key:            a.cfg.Tag.SigningKey,
+		AllowedSigners: a.cfg.Tag.AllowedSigners,
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/gomod"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// moduleTagOptions are the flags of tag -all.
+type moduleTagOptions struct {
+	plan    *planOptions
+	message string
+	sign    bool
+	key     string
+	remote  string
+	tidy    bool
+	dryRun  bool
+}
+
+// moduleRelease is a module released by tag -all, and the new versions of
+// the modules it requires.
+type moduleRelease struct {
+	plan     workspace.Plan
+	requires []requirement
+}
+
+type requirement struct{ path, version string }
+
+// tagModules tags every module of the workspace with releasable changes, in
+// the order of their requirements. Before the modules of a group are
+// tagged, their go.mod files are moved to the versions just tagged of the
+// modules they require, tidied in parallel and committed; a module whose
+// requirements moved is released as a patch even without changes of its
+// own.
+func (a *app) tagModules(ctx context.Context, o moduleTagOptions) error {
+	ms, err := workspace.Resolve(a.root, a.cfg)
+	if err != nil {
+		return err
+	}
+	requires, err := workspace.Requires(a.root, ms)
+	if err != nil {
+		return err
+	}
+	groups, err := workspace.Order(ms, requires)
+	if err != nil {
+		return relerr.Wrap(relerr.Config, err)
+	}
+
+	// Plan every release first, so that nothing is changed unless every tag
+	// can be created.
+	tagged := make(map[string]string)
+	var plan [][]moduleRelease
+	for _, group := range groups {
+		var releases []moduleRelease
+		for _, m := range group {
+			p, err := a.planModule(ctx, m, o.plan)
+			if err != nil && !errors.Is(err, relerr.ErrNoCommitsSinceTag) {
+				return fmt.Errorf("%s: %w", m.Name, err)
+			}
+			var reqs []requirement
+			for _, r := range requires[m.Name] {
+				if v, ok := tagged[r]; ok {
+					reqs = append(reqs, requirement{path: r, version: v})
+				}
+			}
+			if p.Level == version.None && len(reqs) > 0 {
+				p = p.WithLevel(version.Patch)
+			}
+			if p.Level == version.None {
+				continue
+			}
+			if _, _, err := a.checkTag(ctx, p.Tag(), o.remote, true, false); err != nil {
+				return err
+			}
+			tagged[m.Name] = "v" + p.Next.String()
+			releases = append(releases, moduleRelease{plan: p, requires: reqs})
+		}
+		if len(releases) > 0 {
+			plan = append(plan, releases)
+		}
+	}
+	if len(plan) == 0 {
+		return relerr.ErrNoCommitsSinceTag
+	}
+
+	repo := a.repo(o.dryRun)
+	for _, releases := range plan {
+		for _, r := range releases {
+			a.advance(r.plan.Tag(), state.Versioned, o.dryRun)
+			if err := a.runHooks(ctx, hooks.PreBump, planEnv(r.plan), o.dryRun); err != nil {
+				return err
+			}
+		}
+		if err := a.updateRequires(ctx, repo, releases, o); err != nil {
+			return err
+		}
+		for _, r := range releases {
+			if err := a.createModuleTag(ctx, repo, r.plan, o); err != nil {
+				return err
+			}
+		}
+	}
+	a.result.TagCreated = !o.dryRun
+	return nil
+}
+
+// updateRequires moves the requirements of releases in their go.mod files,
+// runs go mod tidy in the modules changed when o.tidy is set, and commits
+// the files.
+func (a *app) updateRequires(ctx context.Context, repo gitrepo.Repository, releases []moduleRelease, o moduleTagOptions) error {
+	var dirs, moved []string
+	for _, r := range releases {
+		if len(r.requires) == 0 {
+			continue
+		}
+		dir := r.plan.Module.Dir
+		file := path.Join(dir, "go.mod")
+		for _, req := range r.requires {
+			if o.dryRun {
+				dryrun.Printf(a.stdout, "would require %s %s in %s", req.path, req.version, file)
+			}
+			if req := req.path + " " + req.version; !slices.Contains(moved, req) {
+				moved = append(moved, req)
+			}
+		}
+		if !o.dryRun {
+			if err := setRequires(filepath.Join(a.root, filepath.FromSlash(file)), r.requires); err != nil {
+				return err
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	if o.tidy {
+		if o.dryRun {
+			for _, dir := range dirs {
+				dryrun.Printf(a.stdout, "would run go mod tidy in %s", dir)
+			}
+		} else if err := a.tidy(ctx, dirs); err != nil {
+			return err
+		}
+	}
+	var paths []string
+	for _, dir := range dirs {
+		paths = append(paths, path.Join(dir, "go.mod"))
+		if _, err := os.Stat(filepath.Join(a.root, filepath.FromSlash(dir), "go.sum")); err == nil {
+			paths = append(paths, path.Join(dir, "go.sum"))
+		}
+	}
+	return repo.CommitFiles(ctx, "chore(release): require "+strings.Join(moved, ", "), paths...)
+}
+
+func setRequires(file string, reqs []requirement) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	for _, r := range reqs {
+		data, _ = gomod.SetRequire(data, r.path, r.version)
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+// tidy runs go mod tidy in the module directories dirs, in parallel.
+func (a *app) tidy(ctx context.Context, dirs []string) error {
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for _, dir := range dirs {
+		g.Go(func() error {
+			cmd := exec.CommandContext(ctx, "go", "mod", "tidy")
+			cmd.Dir = filepath.Join(a.root, filepath.FromSlash(dir))
+			if out, err := cmd.CombinedOutput(); err != nil {
+				return fmt.Errorf("go mod tidy in %s: %v: %s", dir, err, strings.TrimSpace(string(out)))
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// createModuleTag creates the tag of p at HEAD, as tag does.
+func (a *app) createModuleTag(ctx context.Context, repo gitrepo.Repository, p workspace.Plan, o moduleTagOptions) error {
+	tag := p.Tag()
+	msg, err := a.tagMessage(ctx, o.message, p)
+	if err != nil {
+		return err
+	}
+	if o.sign || o.key != "" {
+		s := a.signing()
+		s.Key = o.key
+		err = repo.CreateSignedTag(ctx, tag, msg, s)
+	} else {
+		err = repo.CreateTag(ctx, tag, msg)
+	}
+	if err != nil {
+		return err
+	}
+	a.advance(tag, state.Tagged, o.dryRun)
+	a.result.Modules = append(a.result.Modules, moduleResult{Name: p.Module.Name, Dir: p.Module.Dir, Current: p.PreviousTag, Next: tag, Bump: p.Level.String()})
+	fmt.Fprintln(a.stdout, tag)
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/telemetry"
+)
+
+// telemetryTimeout bounds each export to the collector, so that an
+// unreachable one does not hold up the end of a command.
+const telemetryTimeout = 10 * time.Second
+
+// defaultTelemetryInterval is how often serve exports without
+// telemetry.interval.
+const defaultTelemetryInterval = time.Minute
+
+// setupTelemetry records metrics and traces for export when
+// telemetry.endpoint or OTEL_EXPORTER_OTLP_ENDPOINT names a collector.
+func (a *app) setupTelemetry() error {
+	tc := a.cfg.Telemetry
+	endpoint := cmp.Or(tc.Endpoint, os.Getenv(telemetry.EnvEndpoint))
+	if endpoint == "" {
+		return nil
+	}
+	header, err := telemetry.ParseHeaders(os.Getenv(telemetry.EnvHeaders))
+	if err != nil {
+		return relerr.Wrap(relerr.Config, err)
+	}
+	for k, v := range tc.Headers {
+		header.Set(k, v)
+	}
+	a.telemetry = telemetry.NewRecorder(a.serviceName())
+	a.exporter = &telemetry.Exporter{Endpoint: endpoint, Header: header}
+	return nil
+}
+
+// serviceName is the service.name of the exported telemetry.
+func (a *app) serviceName() string {
+	return cmp.Or(a.cfg.Telemetry.ServiceName, os.Getenv(telemetry.EnvService), "release")
+}
+
+// metrics returns the telemetry of the app, telemetry.Nop when disabled.
+func (a *app) metrics() telemetry.Telemetry {
+	if a.telemetry == nil {
+		return telemetry.Nop
+	}
+	return a.telemetry
+}
+
+// httpClient returns the client publishers call their APIs with: one
+// recording their latency when telemetry is enabled, nil otherwise.
+func (a *app) httpClient() *http.Client {
+	if a.telemetry == nil {
+		return nil
+	}
+	return telemetry.Client(a.telemetry)
+}
+
+// recordCommand records a run of command that took d and failed with err,
+// if not nil.
+func (a *app) recordCommand(command string, d time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = relerr.KindOf(err).String()
+	}
+	a.metrics().Observe(telemetry.CommandDuration, d.Seconds(), telemetry.String("command", command), telemetry.String("outcome", outcome))
+	if err != nil && relerr.KindOf(err) != relerr.NothingToRelease {
+		a.metrics().Add(telemetry.Failures, 1, telemetry.String("stage", command), telemetry.String("kind", outcome))
+	}
+}
+
+// exportTelemetry sends the telemetry recorded so far to the collector.
+// A failed export is only warned about.
+func (a *app) exportTelemetry(ctx context.Context) {
+	if a.exporter == nil || a.telemetry == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), telemetryTimeout)
+	defer cancel()
+	if err := a.exporter.Export(ctx, a.telemetry); err != nil {
+		a.log.Warn(err.Error())
+	}
+}
+
+// exportTelemetryEvery exports the telemetry every interval until ctx is
+// done.
+func (a *app) exportTelemetryEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.exportTelemetry(ctx)
+		}
+	}
+}
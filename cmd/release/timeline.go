@@ -0,0 +1,238 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/timeline"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// timelineRef returns the notes ref of the release timeline.
+func (a *app) timelineRef() string {
+	return cmp.Or(a.cfg.Timeline.Ref, timeline.DefaultRef)
+}
+
+// recordTimeline adds step to the timeline of tag, in the git note of its
+// commit, when the timeline is enabled. plan gives the version and commits
+// of the release, for the tag step and for a release tagged without a
+// timeline. The provider releases of the command so far are recorded with
+// it. A failure only warns: the timeline is never worth failing a release
+// for. It reports whether the note was written.
+func (a *app) recordTimeline(ctx context.Context, tag, step string, plan func() workspace.Plan, dryRun bool) bool {
+	if !a.cfg.Timeline.Enabled {
+		return false
+	}
+	if err := a.writeTimeline(ctx, tag, step, plan, dryRun); err != nil {
+		a.log.Warn(fmt.Sprintf("could not record the timeline of %s: %v", tag, err))
+		return false
+	}
+	return true
+}
+
+func (a *app) writeTimeline(ctx context.Context, tag, step string, plan func() workspace.Plan, dryRun bool) error {
+	commit, err := a.tagCommit(ctx, tag)
+	if err != nil {
+		return err
+	}
+	ref := a.timelineRef()
+	data, err := a.git.Note(ctx, ref, commit)
+	if err != nil {
+		return err
+	}
+	note, err := timeline.Parse(data)
+	if err != nil {
+		return fmt.Errorf("the note of %s in %s: %w", shortHash(commit), ref, err)
+	}
+
+	r := note.Record(tag)
+	r.Commit = commit
+	if r.Version == "" || step == timeline.Tagged {
+		fillRecord(r, plan())
+	}
+	for _, rel := range a.result.Releases {
+		r.SetRelease(timeline.Release{
+			Provider: rel.Provider,
+			Repo:     rel.Repo,
+			ID:       rel.ID,
+			URL:      rel.URL,
+			Draft:    step == timeline.Drafted,
+			Assets:   rel.Assets,
+		})
+	}
+	r.Add(step, a.command, a.now())
+
+	out, err := note.Marshal()
+	if err != nil {
+		return err
+	}
+	return a.repo(dryRun).SetNote(ctx, ref, commit, out)
+}
+
+// fillRecord sets the version and commits of r from p.
+func fillRecord(r *timeline.Record, p workspace.Plan) {
+	r.Version = p.Next.String()
+	r.Module = p.Module.Name
+	r.PreviousTag = p.PreviousTag
+	r.Range = r.Tag
+	if p.PreviousTag != "" {
+		r.Range = p.PreviousTag + ".." + r.Tag
+	}
+	r.Commits = len(p.Raw)
+	r.Channel = p.Channel
+	if p.Level != version.None {
+		r.Bump = p.Level.String()
+	}
+}
+
+// tagCommit returns the commit tag points at, or HEAD for a tag yet to be
+// created, as in a dry run.
+func (a *app) tagCommit(ctx context.Context, tag string) (string, error) {
+	tags, err := a.git.Tags(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tags {
+		if t.Name == tag && t.Commit != "" {
+			return t.Commit, nil
+		}
+	}
+	return a.git.Head(ctx)
+}
+
+// pushTimeline pushes the notes of the timeline to remotes, warning of the
+// pushes that fail, such as when another release pushed its note first.
+func (a *app) pushTimeline(ctx context.Context, remotes []config.Remote, dryRun bool) {
+	ref := a.timelineRef()
+	repo := a.repo(dryRun)
+	for _, r := range remotes {
+		if err := repo.Push(ctx, r.Name, ref); err != nil {
+			a.log.Warn(fmt.Sprintf("could not push the release timeline to %s: %v; fetch %s:%s, then push it again", r.Name, err, ref, ref))
+		}
+	}
+}
+
+// history prints the release timeline recorded in the git notes of the
+// tagged commits, newest release first, or that of one release.
+func (a *app) history(ctx context.Context, args []string) error {
+	fs := a.flags("history")
+	format := fs.String("format", "text", "output format: text or json")
+	limit := fs.Int("limit", 0, "show the latest N releases only (default: all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("%w: unknown format %q: must be text or json", relerr.ErrUsage, *format)
+	}
+	if fs.NArg() > 1 || *limit < 0 {
+		return fmt.Errorf("%w: release history [flags] [version|tag]", relerr.ErrUsage)
+	}
+
+	records, err := a.readTimeline(ctx)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 1 {
+		tag := a.verifyTag(fs.Arg(0))
+		i := slices.IndexFunc(records, func(r timeline.Record) bool { return r.Tag == tag })
+		if i < 0 {
+			return relerr.Wrap(relerr.Precondition, fmt.Errorf("no timeline of %s in %s; fetch it with git fetch origin %s:%s", tag, a.timelineRef(), a.timelineRef(), a.timelineRef()))
+		}
+		records = records[i : i+1]
+	}
+	if *limit > 0 && len(records) > *limit {
+		records = records[:*limit]
+	}
+	a.result.History = records
+
+	if *format == "json" {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+	if len(records) == 0 {
+		fmt.Fprintf(a.stdout, "no releases recorded in %s\n", a.timelineRef())
+		return nil
+	}
+	for i, r := range records {
+		if i > 0 {
+			fmt.Fprintln(a.stdout)
+		}
+		a.printRecord(r)
+	}
+	return nil
+}
+
+// printRecord prints the range, steps and provider releases of r.
+func (a *app) printRecord(r timeline.Record) {
+	var about []string
+	if r.Range != "" {
+		about = append(about, r.Range)
+	}
+	if r.Commits > 0 {
+		about = append(about, fmt.Sprintf("%d commit(s)", r.Commits))
+	}
+	if r.Bump != "" {
+		about = append(about, r.Bump)
+	}
+	fmt.Fprintf(a.stdout, "%s at %s", r.Tag, shortHash(r.Commit))
+	if len(about) > 0 {
+		fmt.Fprintf(a.stdout, ": %s", strings.Join(about, ", "))
+	}
+	fmt.Fprintln(a.stdout)
+	for _, e := range r.Events {
+		fmt.Fprintf(a.stdout, "  %s  %s", e.At.Format("2006-01-02T15:04:05Z07:00"), e.Step)
+		if e.Command != "" {
+			fmt.Fprintf(a.stdout, " (%s)", e.Command)
+		}
+		fmt.Fprintln(a.stdout)
+	}
+	for _, rel := range r.Releases {
+		where := cmp.Or(rel.URL, rel.ID)
+		if rel.Draft {
+			where += " (draft)"
+		}
+		fmt.Fprintf(a.stdout, "  %s: %s\n", strings.TrimSpace(rel.Provider+" "+rel.Repo), where)
+	}
+}
+
+// readTimeline returns the records of the local tags, newest release
+// first. Notes that do not parse are skipped with a warning.
+func (a *app) readTimeline(ctx context.Context) ([]timeline.Record, error) {
+	tags, err := a.git.Tags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ref := a.timelineRef()
+	notes := make(map[string]*timeline.Note)
+	var records []timeline.Record
+	for _, t := range tags {
+		note, ok := notes[t.Commit]
+		if !ok {
+			data, err := a.git.Note(ctx, ref, t.Commit)
+			if err != nil {
+				return nil, err
+			}
+			n, err := timeline.Parse(data)
+			if err != nil {
+				a.log.Warn(fmt.Sprintf("skipped the note of %s in %s: %v", shortHash(t.Commit), ref, err))
+			}
+			note = &n
+			notes[t.Commit] = note
+		}
+		if r := note.Find(t.Name); r != nil {
+			records = append(records, *r)
+		}
+	}
+	slices.SortStableFunc(records, func(x, y timeline.Record) int {
+		return y.Started().Compare(x.Started())
+	})
+	return records, nil
+}
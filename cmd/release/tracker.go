@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gbrennon/release_automation_golang/pkg/notes"
+	"github.com/gbrennon/release_automation_golang/pkg/tracker"
+	"github.com/gbrennon/release_automation_golang/pkg/tracker/jira"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// issueTracker returns the client of the configured tracker, or nil when
+// none is configured.
+func (a *app) issueTracker(ctx context.Context, dryRun bool) (*jira.Client, error) {
+	tc := a.cfg.Tracker
+	if tc.Provider == "" {
+		return nil, nil
+	}
+	c, err := jira.NewFromSecrets(tc.BaseURL, a.secret(ctx))
+	if err != nil {
+		return nil, err
+	}
+	c.Retry, c.Logger = a.retry, a.log
+	c.Transition, c.FixVersion = tc.Transition, tc.FixVersion
+	c.DryRun, c.Log = dryRun, a.stdout
+	return c, nil
+}
+
+// resolvedIssues looks up the tracker issues the commits of p mention, for
+// its release notes. Offline, or when the tracker fails, the notes go
+// without them, with a warning.
+func (a *app) resolvedIssues(ctx context.Context, p workspace.Plan) []notes.Issue {
+	tc := a.cfg.Tracker
+	if tc.Provider == "" || len(tracker.AllKeys(p.Commits, tc.Projects)) == 0 {
+		return nil
+	}
+	if a.offline {
+		a.log.Warn("tracker: the issues of the release are not looked up -offline")
+		return nil
+	}
+	t, err := a.issueTracker(ctx, false)
+	if err != nil {
+		a.log.Warn(fmt.Sprintf("tracker: %v; the release notes list no issues", err))
+		return nil
+	}
+	issues, err := tracker.Resolve(ctx, p.Commits, tc.Projects, t)
+	if err != nil {
+		a.log.Warn(fmt.Sprintf("tracker: %v", err))
+	}
+	return issues
+}
+
+// markReleased records on the tracker that the issues the commits of the
+// release tagged tag mention shipped in version ver, as tracker.transition
+// and tracker.fix_version configure. A failure only warns: the release is
+// out already.
+func (a *app) markReleased(ctx context.Context, tag, ver string, dryRun bool) {
+	tc := a.cfg.Tracker
+	if tc.Provider == "" || (tc.Transition == "" && !tc.FixVersion) {
+		return
+	}
+	keys := tracker.AllKeys(a.taggedPlan(ctx, tag, ver).Commits, tc.Projects)
+	if len(keys) == 0 {
+		return
+	}
+	t, err := a.issueTracker(ctx, dryRun)
+	if err == nil {
+		err = t.Release(ctx, ver, keys)
+	}
+	if err != nil {
+		a.log.Warn(fmt.Sprintf("tracker: could not mark the issues of %s released: %v", tag, err))
+	}
+}
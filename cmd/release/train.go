@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/freeze"
+	"github.com/gbrennon/release_automation_golang/pkg/state"
+	"github.com/gbrennon/release_automation_golang/pkg/train"
+)
+
+// releaseTrain returns the release train of the configuration.
+func (a *app) releaseTrain() (train.Train, error) {
+	tc := a.cfg.Train
+	if tc.Every == "" {
+		return train.Train{}, relerr.Wrap(relerr.Config, errors.New("no release train is configured: set train.start and train.every"))
+	}
+	loc, err := time.LoadLocation(tc.Timezone)
+	if err != nil {
+		return train.Train{}, relerr.Wrap(relerr.Config, fmt.Errorf("train.timezone: %w", err))
+	}
+	start, err := train.ParseStart(tc.Start, loc)
+	if err != nil {
+		return train.Train{}, relerr.Wrap(relerr.Config, err)
+	}
+	every, err := time.ParseDuration(tc.Every)
+	if err != nil {
+		return train.Train{}, relerr.Wrap(relerr.Config, fmt.Errorf("train.every: %w", err))
+	}
+	var window time.Duration
+	if tc.Window != "" {
+		if window, err = time.ParseDuration(tc.Window); err != nil {
+			return train.Train{}, relerr.Wrap(relerr.Config, fmt.Errorf("train.window: %w", err))
+		}
+	}
+	t, err := train.New(start, every, window)
+	if err != nil {
+		return train.Train{}, relerr.Wrap(relerr.Config, err)
+	}
+	return t, nil
+}
+
+// departed reports whether the recorded release was tagged at or after
+// departure, by a train that already left.
+func (a *app) departed(departure time.Time) bool {
+	if a.state == nil {
+		return false
+	}
+	r, err := a.state.Load()
+	if err != nil {
+		a.log.Warn(err.Error())
+		return false
+	}
+	if r == nil {
+		return false
+	}
+	for _, tr := range r.History {
+		if tr.To == state.Tagged && !tr.At.Before(departure) {
+			return true
+		}
+	}
+	return false
+}
+
+// train cuts the release of the train departing now: it tags the next
+// version, bumped by the commits since the last release and as a patch
+// when none of them calls for a release, and publishes it. Outside the
+// window of a departure, or with no commits at all to ship, it reports so
+// and succeeds, so that it can run on a frequent schedule.
+func (a *app) train(ctx context.Context, args []string) error {
+	fs := a.flags("train")
+	module := fs.String("module", "", "release only this module (directory or name, see 'release modules')")
+	depart := fs.Bool("now", false, "depart now, whether or not a departure is due")
+	dryRun := a.dryRunFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	a.result.DryRun = *dryRun
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: train takes no arguments", relerr.ErrUsage)
+	}
+
+	t, err := a.releaseTrain()
+	if err != nil {
+		return err
+	}
+	at := a.now()
+	departure, due := t.Due(at)
+	next := t.Next(at).Format("2006-01-02 15:04 MST")
+	switch {
+	case *depart:
+		departure = at
+	case due && a.departed(departure):
+		fmt.Fprintf(a.stdout, "the train of %s has left: the next leaves at %s\n", departure.Format("2006-01-02 15:04 MST"), next)
+		return nil
+	case !due:
+		fmt.Fprintf(a.stdout, "no train departs now: the next leaves at %s\n", next)
+		return nil
+	}
+	when := departure.Format("2006-01-02 15:04 MST")
+
+	ws, err := a.freezeWindows()
+	if err != nil {
+		return err
+	}
+	if err := freeze.Check(ws, at); err != nil {
+		return fmt.Errorf("train of %s: %w", when, err)
+	}
+
+	opts := &planOptions{bump: "auto", module: *module}
+	p, err := a.newPlan(ctx, opts)
+	if errors.Is(err, relerr.ErrNoCommitsSinceTag) && len(p.Raw) > 0 {
+		// The train leaves whatever it carries.
+		opts.bump = "patch"
+		p, err = a.newPlan(ctx, opts)
+	}
+	if errors.Is(err, relerr.ErrNoCommitsSinceTag) {
+		a.result.setPlan(p)
+		fmt.Fprintf(a.stdout, "train of %s: nothing to ship since %s\n", when, p.PreviousTag)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "train of %s: releasing %s with %d commit(s)\n", when, p.Tag(), len(p.Raw))
+
+	notes, err := a.trainNotes(ctx, p.Next.String(), opts)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(notes)
+	dry := fmt.Sprintf("-dry-run=%t", *dryRun)
+	if err := a.tag(ctx, append(planArgs(*module, opts.bump, ""), dry)); err != nil {
+		return err
+	}
+	return a.publish(ctx, []string{dry, "-notes", notes, p.Tag()})
+}
+
+// trainNotes writes the release notes of ver to a temporary file and
+// returns its path: the curated notes of the version when there are some,
+// the notes of the plan of o otherwise.
+func (a *app) trainNotes(ctx context.Context, ver string, o *planOptions) (string, error) {
+	body, curated, err := a.curatedNotes(ver)
+	if err != nil {
+		return "", err
+	}
+	if !curated {
+		_, text, err := a.renderNotes(ctx, &notesOptions{plan: o, provider: "github"})
+		if err != nil {
+			return "", err
+		}
+		body = string(text)
+	}
+	f, err := os.CreateTemp("", "release-notes-*.md")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
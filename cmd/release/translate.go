@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/translate"
+)
+
+// translateChangelog gives the translations of the changelog at path
+// configured by changelog.translations the release sections they lack,
+// returning the files it wrote.
+func (a *app) translateChangelog(ctx context.Context, path string, dryRun bool) ([]string, error) {
+	tc := a.cfg.Changelog.Translations
+	if len(tc.Languages) == 0 {
+		return nil, nil
+	}
+	if dryRun {
+		for _, lang := range tc.Languages {
+			dryrun.Printf(a.stdout, "would translate the new sections of %s into %s", path, translate.Path(path, lang))
+		}
+		return nil, nil
+	}
+	t, err := translate.New(tc.Backend, translate.Options{Command: tc.Command})
+	if err != nil {
+		return nil, relerr.Wrap(relerr.Config, err)
+	}
+	canonical, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, lang := range tc.Languages {
+		file := translate.Path(path, lang)
+		localized, err := os.ReadFile(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return written, err
+		}
+		out, translated, err := translate.Sync(ctx, t, canonical, localized, lang)
+		if err != nil {
+			return written, err
+		}
+		if len(translated) == 0 {
+			continue
+		}
+		if err := os.WriteFile(file, out, 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, file)
+		a.log.Info(fmt.Sprintf("translated %s into %s", strings.Join(translated, ", "), file))
+	}
+	return written, nil
+}
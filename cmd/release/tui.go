@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// choiceModel picks one of choices: the arrow keys move between them and
+// enter picks the one under the cursor, as does the first letter of a
+// choice no other starts with.
+type choiceModel struct {
+	prompt  string
+	choices []string
+	cursor  int
+	done    bool
+}
+
+func (m choiceModel) Init() tea.Cmd { return nil }
+
+func (m choiceModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch k.String() {
+	case "ctrl+c", "esc":
+		return m, tea.Quit
+	case "up", "left", "shift+tab":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "right", "tab":
+		m.cursor = min(m.cursor+1, len(m.choices)-1)
+	case "enter":
+		m.done = true
+		return m, tea.Quit
+	default:
+		var matches []int
+		for i, c := range m.choices {
+			if strings.HasPrefix(c, k.String()) {
+				matches = append(matches, i)
+			}
+		}
+		if len(matches) == 1 {
+			m.cursor, m.done = matches[0], true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m choiceModel) View() string {
+	if m.done {
+		return fmt.Sprintf("%s: %s\n", m.prompt, m.choices[m.cursor])
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:", m.prompt)
+	for i, c := range m.choices {
+		if i == m.cursor {
+			fmt.Fprintf(&b, "  [%s]", c)
+		} else {
+			fmt.Fprintf(&b, "   %s ", c)
+		}
+	}
+	b.WriteString("\n(←/→ to move, enter to choose, esc to abort)\n")
+	return b.String()
+}
+
+// toggleModel selects any of items: the arrow keys move between them,
+// space toggles the one under the cursor and enter accepts the selection.
+type toggleModel struct {
+	prompt   string
+	items    []string
+	selected []bool
+	cursor   int
+	done     bool
+}
+
+func (m toggleModel) Init() tea.Cmd { return nil }
+
+func (m toggleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch k.String() {
+	case "ctrl+c", "esc":
+		return m, tea.Quit
+	case "up", "shift+tab":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "tab":
+		m.cursor = min(m.cursor+1, len(m.items)-1)
+	case " ", "x":
+		m.selected = slices.Clone(m.selected)
+		m.selected[m.cursor] = !m.selected[m.cursor]
+	case "enter":
+		m.done = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m toggleModel) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:\n", m.prompt)
+	for i, item := range m.items {
+		cursor, mark := " ", " "
+		if i == m.cursor && !m.done {
+			cursor = ">"
+		}
+		if m.selected[i] {
+			mark = "x"
+		}
+		fmt.Fprintf(&b, "%s %d. [%s] %s\n", cursor, i+1, mark, item)
+	}
+	if !m.done {
+		b.WriteString("(↑/↓ to move, space to toggle, enter to continue, esc to abort)\n")
+	}
+	return b.String()
+}
+
+// runTUI runs m on the terminal of w until it quits, and returns its final
+// state. It fails with errAborted when the user quits without answering.
+func (w *wizard) runTUI(ctx context.Context, m tea.Model) (tea.Model, error) {
+	p := tea.NewProgram(m, tea.WithContext(ctx), tea.WithInput(w.tui), tea.WithOutput(w.out))
+	m, err := p.Run()
+	switch {
+	case ctx.Err() != nil:
+		return m, ctx.Err()
+	case errors.Is(err, tea.ErrInterrupted):
+		return m, errAborted
+	case err != nil:
+		return m, err
+	}
+	return m, nil
+}
+
+// chooseTUI asks for one of choices on the terminal, def by default.
+func (w *wizard) chooseTUI(ctx context.Context, prompt string, choices []string, def string) (string, error) {
+	m, err := w.runTUI(ctx, choiceModel{prompt: prompt, choices: choices, cursor: max(slices.Index(choices, def), 0)})
+	if err != nil {
+		return "", err
+	}
+	c := m.(choiceModel)
+	if !c.done {
+		return "", errAborted
+	}
+	return c.choices[c.cursor], nil
+}
+
+// toggleTUI lets the user change the selection of items on the terminal.
+func (w *wizard) toggleTUI(ctx context.Context, prompt string, items []string, selected []bool) error {
+	m, err := w.runTUI(ctx, toggleModel{prompt: prompt, items: items, selected: selected})
+	if err != nil {
+		return err
+	}
+	t := m.(toggleModel)
+	if !t.done {
+		return errAborted
+	}
+	copy(selected, t.selected)
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// defaultUpgradeDir holds the upgrade guides without a
+// changelog.upgrade.dir.
+const defaultUpgradeDir = "docs/upgrade"
+
+// upgradeGuidePath returns the path of the upgrade guide of version,
+// relative to the repository root.
+func (a *app) upgradeGuidePath(version string) string {
+	return path.Join(cmp.Or(a.cfg.Changelog.Upgrade.Dir, defaultUpgradeDir), version+".md")
+}
+
+// upgradeGuide collects the breaking changes of cs into the upgrade guide
+// of version, upgraded to from previous. It reports false when
+// changelog.upgrade is off or the release breaks nothing.
+func (a *app) upgradeGuide(version, previous string, date time.Time, cs []commits.Commit) (changelog.Upgrade, bool) {
+	if !a.cfg.Changelog.Upgrade.Enabled {
+		return changelog.Upgrade{}, false
+	}
+	u := changelog.NewUpgrade(version, previous, date, cs)
+	return u, !u.Empty()
+}
+
+// planUpgradeGuide is upgradeGuide for the release planned by p.
+func (a *app) planUpgradeGuide(p workspace.Plan) (changelog.Upgrade, bool) {
+	previous := ""
+	if p.HasPrevious {
+		previous = p.Previous.String()
+	}
+	return a.upgradeGuide(p.Next.String(), previous, a.now(), p.Commits)
+}
+
+// upgradeGuideLink returns the link to the upgrade guide of version from
+// changelogFile, relative to its directory.
+func (a *app) upgradeGuideLink(version, changelogFile string) string {
+	guide, gerr := filepath.Abs(filepath.Join(a.root, filepath.FromSlash(a.upgradeGuidePath(version))))
+	file, ferr := filepath.Abs(changelogFile)
+	rel, err := filepath.Rel(filepath.Dir(file), guide)
+	if gerr != nil || ferr != nil || err != nil {
+		return a.upgradeGuidePath(version)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// writeUpgradeGuide writes u to its path and returns that path, relative
+// to the repository root.
+func (a *app) writeUpgradeGuide(u changelog.Upgrade, dryRun bool) (string, error) {
+	file := a.upgradeGuidePath(u.Version)
+	var buf bytes.Buffer
+	if err := changelog.RenderUpgrade(&buf, u); err != nil {
+		return "", err
+	}
+	if dryRun {
+		dryrun.Printf(a.stdout, "would write the upgrade guide of %s to %s", u.Version, file)
+		return file, nil
+	}
+	target := filepath.Join(a.root, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(target, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// upgradeGuideNotes points the release body of version, tagged tag, at its
+// upgrade guide when one was written: the link of the changelog section,
+// relative to changelogFile, becomes the web URL of the guide as of tag,
+// and a body without it gets it appended. Without a repository URL, body
+// is returned unchanged.
+func (a *app) upgradeGuideNotes(body, tag, version, changelogFile string) string {
+	if !a.cfg.Changelog.Upgrade.Enabled {
+		return body
+	}
+	file := a.upgradeGuidePath(version)
+	if _, err := os.Stat(filepath.Join(a.root, filepath.FromSlash(file))); err != nil {
+		return body
+	}
+	url := a.links("github", "").File(tag, file)
+	if url == "" {
+		return body
+	}
+	body = strings.ReplaceAll(body, "]("+a.upgradeGuideLink(version, changelogFile)+")", "]("+url+")")
+	if !strings.Contains(body, url) {
+		body = strings.TrimRight(body, "\n") + "\n\nSee the [upgrade guide](" + url + ") for what to change.\n"
+	}
+	return body
+}
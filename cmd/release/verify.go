@@ -0,0 +1,112 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/artifacts"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/preflight"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/verify"
+)
+
+func (a *app) verify(ctx context.Context, args []string) error {
+	ac := a.cfg.Artifacts
+	fs := a.flags("verify")
+	provider := fs.String("provider", "", "verify the release on this provider (default: config publish targets, then CI detection)")
+	repo := fs.String("repo", "", "repository (owner/repo) or project path of the release")
+	remote := fs.String("remote", "origin", "git remote whose tag must point at the commit; empty skips it")
+	commit := fs.String("commit", "", "commit the tag must point at (default: that of the local tag)")
+	dir := fs.String("dir", "", "directory to download the assets to and keep (default: a temporary directory)")
+	checksums := fs.Bool("checksums", true, "check the assets against the SHA256SUMS published with them")
+	signatures := fs.Bool("signatures", ac.Sign != "", "verify the signatures of SHA256SUMS and the provenance (default: when artifacts.sign is set)")
+	signer := fs.String("signer", cmp.Or(ac.Sign, "cosign"), "cosign or minisign, the tool the release was signed with")
+	key := fs.String("key", cmp.Or(ac.Verify.Key, artifacts.PublicKey(ac.Key)), "public key to verify the signatures with (default: artifacts.verify.key, then the pair of artifacts.key)")
+	identity := fs.String("identity", ac.Verify.Identity, "certificate identity of keyless cosign signatures")
+	issuer := fs.String("issuer", ac.Verify.Issuer, "OIDC issuer of keyless cosign signatures")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: release verify [flags] <version|tag>", relerr.ErrUsage)
+	}
+	tag := a.verifyTag(fs.Arg(0))
+	a.result.Tag, a.result.Version = tag, a.tagVersion(tag)
+
+	var verifier artifacts.Verifier
+	if *signatures {
+		v, err := artifacts.NewVerifier(*signer, *key, *identity, *issuer)
+		if err != nil {
+			return fmt.Errorf("%w: %v", relerr.ErrUsage, err)
+		}
+		verifier = v
+	}
+
+	want := *commit
+	if want == "" {
+		tags, err := a.git.Tags(ctx)
+		if err != nil {
+			return err
+		}
+		for _, t := range tags {
+			if t.Name == tag {
+				want = t.Commit
+			}
+		}
+	}
+
+	if *dir == "" {
+		tmp, err := os.MkdirTemp("", "release-verify-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		*dir = tmp
+	}
+
+	checks := []preflight.Check{verify.Tag(a.git, *remote, tag, want)}
+	targets := a.publishTargets(*provider, *repo)
+	for i, t := range targets {
+		p, err := a.publisher(ctx, t, false)
+		if err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		f, ok := p.(publish.Fetcher)
+		if !ok {
+			a.log.Warn(fmt.Sprintf("verify: provider %s cannot read releases back; skipped", t.Provider))
+			continue
+		}
+		r := &verify.Release{
+			Label:     strings.TrimSpace(t.Provider + " " + t.Repo),
+			Fetcher:   f,
+			Tag:       tag,
+			Commit:    want,
+			Dir:       *dir,
+			Checksums: *checksums,
+			Verifier:  verifier,
+		}
+		if len(targets) > 1 {
+			r.Dir = filepath.Join(*dir, strconv.Itoa(i+1)+"-"+t.Provider)
+		}
+		checks = append(checks, r.Checks()...)
+	}
+	a.recordChecks(checks)
+	return verify.Run(ctx, a.stdout, checks)
+}
+
+// verifyTag returns the tag of arg, a tag or a version of the repository.
+func (a *app) verifyTag(arg string) string {
+	if _, ok := a.tagModule(arg); ok {
+		return arg
+	}
+	if m, err := a.module(""); err == nil {
+		return m.Tag(arg)
+	}
+	return arg
+}
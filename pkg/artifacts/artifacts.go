@@ -0,0 +1,354 @@
+// Package artifacts builds release binaries and the files that accompany
+// them: a SHA256SUMS checksum file and, optionally, SBOMs of the binaries, a
+// cosign or minisign signature of the checksums and an SLSA provenance of
+// the build. The resulting paths are meant to be passed to a
+// publish.Release as Assets.
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/sbom"
+)
+
+// DefaultNameTemplate names binaries like "release_1.2.3_linux_amd64".
+const DefaultNameTemplate = "{{ .Binary }}_{{ .Version }}_{{ .OS }}_{{ .Arch }}"
+
+// DefaultOutput is the directory binaries are written to.
+const DefaultOutput = "dist"
+
+// ReproducibleLDFlags are the linker flags a Reproducible build adds.
+const ReproducibleLDFlags = "-buildid="
+
+// ErrInvalidTarget is returned by ParseTarget for malformed targets.
+var ErrInvalidTarget = errors.New("target must be GOOS/GOARCH")
+
+// Target is a GOOS/GOARCH pair.
+type Target struct {
+	OS   string
+	Arch string
+}
+
+// ParseTarget parses "linux/amd64".
+func ParseTarget(s string) (Target, error) {
+	goos, goarch, ok := strings.Cut(s, "/")
+	if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+		return Target{}, fmt.Errorf("artifacts: %w: %q", ErrInvalidTarget, s)
+	}
+	return Target{OS: goos, Arch: goarch}, nil
+}
+
+// ParseTargets parses each of ss with ParseTarget.
+func ParseTargets(ss []string) ([]Target, error) {
+	ts := make([]Target, 0, len(ss))
+	for _, s := range ss {
+		t, err := ParseTarget(s)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+func (t Target) String() string {
+	return t.OS + "/" + t.Arch
+}
+
+// Artifact is a built binary.
+type Artifact struct {
+	Target Target
+	// Path is where the binary was written.
+	Path string
+}
+
+// NameData is the value name and ldflags templates are executed with.
+type NameData struct {
+	Binary  string
+	Version string
+	OS      string
+	Arch    string
+	// Ext is ".exe" for windows and empty otherwise. It is appended to
+	// names automatically unless the template uses it.
+	Ext string
+}
+
+// Builder cross-compiles a main package with go build.
+type Builder struct {
+	// Package is the main package to build, e.g. "./cmd/release".
+	Package string
+	// Binary defaults to the last element of Package.
+	Binary  string
+	Targets []Target
+	// Dir is the working directory for go build; empty means the current
+	// directory.
+	Dir string
+	// Output defaults to DefaultOutput, relative to Dir.
+	Output string
+	// NameTemplate defaults to DefaultNameTemplate.
+	NameTemplate string
+	// LDFlags is passed to go build -ldflags after template expansion, so
+	// "-X main.version={{ .Version }}" stamps the version.
+	LDFlags string
+	// Reproducible pins the flags that make a build reproducible: an
+	// empty build ID, and the -trimpath every build has.
+	Reproducible bool
+	// SBOM, when set, has Run write an SBOM in that format next to each
+	// binary, named after it with the format's extension.
+	SBOM sbom.Format
+	// Signer, when set, signs the checksum file written by Run.
+	Signer Signer
+	// Provenance, when set, has Run write an SLSA provenance of the
+	// binaries to ProvenanceFile.
+	Provenance *Provenance
+
+	// Stdout and Stderr receive the output of go build.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// DryRun describes the builds on Log instead of running them.
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every build; nil discards.
+	Logger *slog.Logger
+
+	// now is replaced by tests to make provenance timestamps deterministic.
+	now func() time.Time
+}
+
+func (b *Builder) binary() string {
+	if b.Binary != "" {
+		return b.Binary
+	}
+	return path.Base(b.Package)
+}
+
+func (b *Builder) output() string {
+	out := b.Output
+	if out == "" {
+		out = DefaultOutput
+	}
+	if b.Dir != "" && !filepath.IsAbs(out) {
+		out = filepath.Join(b.Dir, out)
+	}
+	return out
+}
+
+// Name returns the file name of the binary for t.
+func (b *Builder) Name(version string, t Target) (string, error) {
+	text := b.NameTemplate
+	if text == "" {
+		text = DefaultNameTemplate
+	}
+	d := b.nameData(version, t)
+	name, err := expand("name", text, d)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(text, ".Ext") {
+		name += d.Ext
+	}
+	return name, nil
+}
+
+func (b *Builder) nameData(version string, t Target) NameData {
+	d := NameData{Binary: b.binary(), Version: version, OS: t.OS, Arch: t.Arch}
+	if t.OS == "windows" {
+		d.Ext = ".exe"
+	}
+	return d
+}
+
+// Build compiles the package for every target and returns the binaries in
+// target order.
+func (b *Builder) Build(ctx context.Context, version string) ([]Artifact, error) {
+	if b.Package == "" {
+		return nil, errors.New("artifacts: no package to build")
+	}
+	if len(b.Targets) == 0 {
+		return nil, errors.New("artifacts: no targets")
+	}
+
+	out := b.output()
+	if !b.DryRun {
+		if err := os.MkdirAll(out, 0o755); err != nil {
+			return nil, fmt.Errorf("artifacts: %w", err)
+		}
+	}
+
+	arts := make([]Artifact, 0, len(b.Targets))
+	for _, t := range b.Targets {
+		name, err := b.Name(version, t)
+		if err != nil {
+			return nil, err
+		}
+		a := Artifact{Target: t, Path: filepath.Join(out, name)}
+
+		ldflags, err := expand("ldflags", b.LDFlags, b.nameData(version, t))
+		if err != nil {
+			return nil, err
+		}
+		if b.Reproducible {
+			ldflags = strings.TrimSpace(ldflags + " " + ReproducibleLDFlags)
+		}
+		args := []string{"build", "-trimpath", "-o", a.Path}
+		if ldflags != "" {
+			args = append(args, "-ldflags", ldflags)
+		}
+		args = append(args, b.Package)
+
+		if b.DryRun {
+			dryrun.Printf(b.Log, "would build %s for %s", a.Path, t)
+			arts = append(arts, a)
+			continue
+		}
+
+		log.Or(b.Logger).Debug("go build", "target", t.String(), "output", a.Path)
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Dir = b.Dir
+		cmd.Stdout = b.Stdout
+		cmd.Stderr = b.Stderr
+		cmd.Env = append(os.Environ(), "GOOS="+t.OS, "GOARCH="+t.Arch, "CGO_ENABLED=0")
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("artifacts: build %s: %w", t, err)
+		}
+		arts = append(arts, a)
+	}
+	return arts, nil
+}
+
+// Run builds every target, writes their SBOMs when SBOM is set, writes
+// SHA256SUMS of the binaries and SBOMs and signs it with Signer, then writes
+// and signs the provenance when Provenance is set. It returns all files to
+// upload: the binaries, the SBOMs, the checksum file, the provenance and
+// their signatures.
+func (b *Builder) Run(ctx context.Context, version string) ([]string, error) {
+	started := b.clock()
+	arts, err := b.Build(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	finished := b.clock()
+	paths := make([]string, len(arts))
+	for i, a := range arts {
+		paths[i] = a.Path
+	}
+	if b.SBOM != "" {
+		boms, err := b.writeSBOMs(version, arts, finished)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, boms...)
+	}
+
+	sums := filepath.Join(b.output(), ChecksumsFile)
+	prov := filepath.Join(b.output(), ProvenanceFile)
+	if b.DryRun {
+		dryrun.Printf(b.Log, "would write %s", sums)
+		if b.Signer != nil {
+			dryrun.Printf(b.Log, "would sign %s with %s", sums, b.Signer)
+		}
+		paths = append(paths, sums)
+		if b.Provenance != nil {
+			dryrun.Printf(b.Log, "would write %s", prov)
+			if b.Provenance.Signer != nil {
+				dryrun.Printf(b.Log, "would sign %s with %s", prov, b.Provenance.Signer)
+			}
+			paths = append(paths, prov)
+		}
+		return paths, nil
+	}
+
+	if err := WriteChecksums(sums, paths); err != nil {
+		return nil, err
+	}
+	paths = append(paths, sums)
+
+	if b.Signer != nil {
+		sig, err := b.Signer.Sign(ctx, sums)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, sig)
+	}
+
+	if b.Provenance == nil {
+		return paths, nil
+	}
+	s, err := b.provenance(version, arts, started, finished)
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteProvenance(prov, s); err != nil {
+		return nil, err
+	}
+	paths = append(paths, prov)
+	if b.Provenance.Signer != nil {
+		sig, err := b.Provenance.Signer.Sign(ctx, prov)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, sig)
+	}
+	return paths, nil
+}
+
+// writeSBOMs writes the SBOM of each binary, with the main module at the
+// released version, and returns their paths.
+func (b *Builder) writeSBOMs(version string, arts []Artifact, created time.Time) ([]string, error) {
+	paths := make([]string, 0, len(arts))
+	for _, a := range arts {
+		path := a.Path + b.SBOM.Ext()
+		paths = append(paths, path)
+		if b.DryRun {
+			dryrun.Printf(b.Log, "would write %s", path)
+			continue
+		}
+		d, err := sbom.ReadBinary(a.Path)
+		if err != nil {
+			return nil, err
+		}
+		d.Main.Version = "v" + strings.TrimPrefix(version, "v")
+		d.Created = created
+		if err := sbom.Write(path, b.SBOM, d); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+func (b *Builder) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+func expand(name, text string, d NameData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("artifacts: parse %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, d); err != nil {
+		return "", fmt.Errorf("artifacts: %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
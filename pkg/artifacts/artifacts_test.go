@@ -0,0 +1,184 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/sbom"
+)
+
+func TestParseTarget(t *testing.T) {
+	got, err := ParseTarget("linux/arm64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != (Target{OS: "linux", Arch: "arm64"}) || got.String() != "linux/arm64" {
+		t.Errorf("Expected linux/arm64, got %v", got)
+	}
+
+	for _, s := range []string{"", "linux", "/amd64", "linux/", "linux/arm/v7"} {
+		if _, err := ParseTarget(s); !errors.Is(err, ErrInvalidTarget) {
+			t.Errorf("ParseTarget(%q): Expected ErrInvalidTarget, got %v", s, err)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	b := &Builder{Package: "./cmd/release"}
+
+	tests := []struct {
+		tmpl     string
+		target   Target
+		expected string
+	}{
+		{"", Target{"linux", "amd64"}, "release_1.2.3_linux_amd64"},
+		{"", Target{"windows", "arm64"}, "release_1.2.3_windows_arm64.exe"},
+		{"{{ .Binary }}-{{ .OS }}{{ .Ext }}.bin", Target{"windows", "amd64"}, "release-windows.exe.bin"},
+		{"app", Target{"darwin", "arm64"}, "app"},
+	}
+	for _, tt := range tests {
+		b.NameTemplate = tt.tmpl
+		got, err := b.Name("1.2.3", tt.target)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.expected {
+			t.Errorf("Name(%q, %s): Expected %q, got %q", tt.tmpl, tt.target, tt.expected, got)
+		}
+	}
+
+	b.NameTemplate = "{{ .Nope }}"
+	if _, err := b.Name("1.2.3", Target{"linux", "amd64"}); err == nil {
+		t.Error("Expected an error for an unknown template field")
+	}
+}
+
+// newModule writes a minimal main package into a temporary module.
+func newModule(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/hello\n\ngo 1.21\n"), 0o644)
+	os.MkdirAll(filepath.Join(dir, "cmd", "hello"), 0o755)
+	os.WriteFile(filepath.Join(dir, "cmd", "hello", "main.go"), []byte("package main\n\nvar version = \"dev\"\n\nfunc main() { println(version) }\n"), 0o644)
+	t.Setenv("GOWORK", "off")
+	t.Setenv("GOFLAGS", "")
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := newModule(t)
+	var stderr bytes.Buffer
+	b := &Builder{
+		Package: "./cmd/hello",
+		Targets: []Target{{"linux", "amd64"}},
+		Dir:     dir,
+		LDFlags: "-X main.version={{ .Version }}",
+		Stderr:  &stderr,
+	}
+
+	paths, err := b.Run(context.Background(), "1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, stderr.String())
+	}
+
+	out := filepath.Join(dir, "dist")
+	expected := []string{
+		filepath.Join(out, "hello_1.0.0_linux_amd64"),
+		filepath.Join(out, "SHA256SUMS"),
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected %v, got %v", expected, paths)
+	}
+
+	bin, err := os.ReadFile(expected[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(bin, []byte("1.0.0")) {
+		t.Error("Expected the version to be stamped into the binary")
+	}
+
+	sums, _ := os.ReadFile(expected[1])
+	if !strings.HasSuffix(string(sums), "  hello_1.0.0_linux_amd64\n") {
+		t.Errorf("Expected a checksum line for the binary, got:\n%s", sums)
+	}
+}
+
+func TestRunSBOM(t *testing.T) {
+	dir := newModule(t)
+	b := &Builder{Package: "./cmd/hello", Targets: []Target{{"linux", "arm64"}}, Dir: dir, SBOM: sbom.SPDX}
+
+	paths, err := b.Run(context.Background(), "1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := filepath.Join(dir, "dist")
+	bin := filepath.Join(out, "hello_1.0.0_linux_arm64")
+	expected := []string{bin, bin + ".spdx.json", filepath.Join(out, "SHA256SUMS")}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected %v, got %v", expected, paths)
+	}
+
+	doc, _ := os.ReadFile(expected[1])
+	if !strings.Contains(string(doc), `"referenceLocator": "pkg:golang/example.com/hello@v1.0.0"`) {
+		t.Errorf("Expected the main module at the released version, got:\n%s", doc)
+	}
+	sums, _ := os.ReadFile(expected[2])
+	if !strings.Contains(string(sums), "  hello_1.0.0_linux_arm64.spdx.json\n") {
+		t.Errorf("Expected a checksum line for the SBOM, got:\n%s", sums)
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	var log bytes.Buffer
+	b := &Builder{
+		Package:    "./cmd/release",
+		Targets:    []Target{{"darwin", "arm64"}},
+		Output:     "out",
+		SBOM:       sbom.CycloneDX,
+		Signer:     &Minisign{},
+		Provenance: &Provenance{Signer: &Cosign{Bundle: true}},
+		DryRun:     true,
+		Log:        &log,
+	}
+
+	paths, err := b.Run(context.Background(), "2.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 4 || paths[0] != filepath.Join("out", "release_2.0.0_darwin_arm64") || paths[3] != filepath.Join("out", ProvenanceFile) {
+		t.Errorf("Expected the planned paths, got %v", paths)
+	}
+
+	expected := "[dry-run] would build out/release_2.0.0_darwin_arm64 for darwin/arm64\n" +
+		"[dry-run] would write out/release_2.0.0_darwin_arm64.cdx.json\n" +
+		"[dry-run] would write out/SHA256SUMS\n" +
+		"[dry-run] would sign out/SHA256SUMS with minisign\n" +
+		"[dry-run] would write out/provenance.intoto.jsonl\n" +
+		"[dry-run] would sign out/provenance.intoto.jsonl with cosign\n"
+	if log.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, log.String())
+	}
+	if _, err := os.Stat("out"); !os.IsNotExist(err) {
+		t.Errorf("Expected no output directory in dry-run mode")
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	if _, err := (&Builder{Targets: []Target{{"linux", "amd64"}}}).Build(context.Background(), "1.0.0"); err == nil {
+		t.Error("Expected an error without a package")
+	}
+	if _, err := (&Builder{Package: "./cmd/x"}).Build(context.Background(), "1.0.0"); err == nil {
+		t.Error("Expected an error without targets")
+	}
+}
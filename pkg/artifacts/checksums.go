@@ -0,0 +1,62 @@
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ChecksumsFile is the name of the checksum file written by Builder.Run.
+const ChecksumsFile = "SHA256SUMS"
+
+// Checksum returns the hex-encoded SHA-256 digest of the file at path.
+func Checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("artifacts: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("artifacts: checksum %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Checksums returns the contents of a checksum file for paths in the format
+// of sha256sum: one "<digest>  <base name>" line per file, sorted by name,
+// so "sha256sum -c SHA256SUMS" verifies the downloads.
+func Checksums(paths []string) (string, error) {
+	sorted := slices.Clone(paths)
+	slices.SortFunc(sorted, func(a, b string) int {
+		return strings.Compare(filepath.Base(a), filepath.Base(b))
+	})
+
+	var b strings.Builder
+	for _, p := range sorted {
+		sum, err := Checksum(p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s  %s\n", sum, filepath.Base(p))
+	}
+	return b.String(), nil
+}
+
+// WriteChecksums writes the checksum file for paths to dest.
+func WriteChecksums(dest string, paths []string) error {
+	content, err := Checksums(paths)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dest, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("artifacts: %w", err)
+	}
+	return nil
+}
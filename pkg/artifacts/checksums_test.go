@@ -0,0 +1,31 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecksums(t *testing.T) {
+	dir := t.TempDir()
+	b := filepath.Join(dir, "b.txt")
+	a := filepath.Join(dir, "a.txt")
+	os.WriteFile(b, []byte("b\n"), 0o644)
+	os.WriteFile(a, []byte("hello\n"), 0o644)
+
+	dest := filepath.Join(dir, ChecksumsFile)
+	if err := WriteChecksums(dest, []string{b, a}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, _ := os.ReadFile(dest)
+	expected := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  a.txt\n" +
+		"0263829989b6fd954f72baaf2fc64bc2e2f01d692d4de72986ea808f6e99813f  b.txt\n"
+	if string(got) != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	if _, err := Checksums([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,163 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ProvenanceFile is the name of the provenance written by Builder.Run.
+const ProvenanceFile = "provenance.intoto.jsonl"
+
+// In-toto and SLSA identifiers of the provenance document.
+const (
+	StatementType = "https://in-toto.io/Statement/v1"
+	PredicateType = "https://slsa.dev/provenance/v1"
+	BuildType     = "https://github.com/gbrennon/release_automation_golang/artifacts/build@v1"
+)
+
+// Provenance describes how Builder.Run built the binaries, for the SLSA
+// provenance it writes to ProvenanceFile.
+type Provenance struct {
+	// BuilderID is a URI identifying the platform running the build, such
+	// as a CI system.
+	BuilderID string
+	// Repo is the URL of the source repository, e.g.
+	// "https://github.com/octo/app", Ref the ref built, e.g.
+	// "refs/tags/v1.2.3", and Commit its SHA.
+	Repo   string
+	Ref    string
+	Commit string
+	// InvocationID identifies the build run, e.g. the URL of the CI job.
+	InvocationID string
+	// Signer, when set, signs the provenance file.
+	Signer Signer
+}
+
+// Statement is an in-toto statement of SLSA provenance.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject is an artifact a Statement is about.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate is the SLSA v1 provenance predicate.
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition records the inputs of the build.
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]any       `json:"externalParameters"`
+	InternalParameters   map[string]any       `json:"internalParameters,omitempty"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// ResourceDescriptor names a resource the build used, such as its source.
+type ResourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// RunDetails records who ran the build, and when.
+type RunDetails struct {
+	Builder  BuilderInfo   `json:"builder"`
+	Metadata BuildMetadata `json:"metadata"`
+}
+
+// BuilderInfo identifies the build platform.
+type BuilderInfo struct {
+	ID string `json:"id"`
+}
+
+// BuildMetadata identifies one run of the build.
+type BuildMetadata struct {
+	InvocationID string    `json:"invocationId,omitempty"`
+	StartedOn    time.Time `json:"startedOn"`
+	FinishedOn   time.Time `json:"finishedOn"`
+}
+
+// provenance returns the statement that b built arts for version between
+// started and finished.
+func (b *Builder) provenance(version string, arts []Artifact, started, finished time.Time) (Statement, error) {
+	p := b.Provenance
+	subjects := make([]Subject, 0, len(arts))
+	targets := make([]string, 0, len(arts))
+	for _, a := range arts {
+		sum, err := Checksum(a.Path)
+		if err != nil {
+			return Statement{}, err
+		}
+		subjects = append(subjects, Subject{Name: filepath.Base(a.Path), Digest: map[string]string{"sha256": sum}})
+		targets = append(targets, a.Target.String())
+	}
+
+	external := map[string]any{
+		"package": b.Package,
+		"version": version,
+		"targets": targets,
+	}
+	if b.LDFlags != "" {
+		external["ldflags"] = b.LDFlags
+	}
+	var deps []ResourceDescriptor
+	if p.Repo != "" {
+		external["source"] = "git+" + p.Repo
+		if p.Ref != "" {
+			external["ref"] = p.Ref
+		}
+		source := ResourceDescriptor{URI: "git+" + p.Repo}
+		if p.Ref != "" {
+			source.URI += "@" + p.Ref
+		}
+		if p.Commit != "" {
+			source.Digest = map[string]string{"gitCommit": p.Commit}
+		}
+		deps = append(deps, source)
+	}
+
+	return Statement{
+		Type:          StatementType,
+		Subject:       subjects,
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{
+				BuildType:          BuildType,
+				ExternalParameters: external,
+				InternalParameters: map[string]any{
+					"flags": []string{"-trimpath"},
+					"env":   map[string]string{"CGO_ENABLED": "0"},
+				},
+				ResolvedDependencies: deps,
+			},
+			RunDetails: RunDetails{
+				Builder:  BuilderInfo{ID: p.BuilderID},
+				Metadata: BuildMetadata{InvocationID: p.InvocationID, StartedOn: started.UTC(), FinishedOn: finished.UTC()},
+			},
+		},
+	}, nil
+}
+
+// WriteProvenance writes s to dest as a single line of JSON, the in-toto
+// JSON Lines layout.
+func WriteProvenance(dest string, s Statement) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("artifacts: %w", err)
+	}
+	if err := os.WriteFile(dest, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("artifacts: %w", err)
+	}
+	return nil
+}
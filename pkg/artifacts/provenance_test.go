@@ -0,0 +1,119 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProvenance(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "app_1.2.3_linux_amd64")
+	os.WriteFile(bin, []byte("binary"), 0o644)
+
+	b := &Builder{
+		Package: "./cmd/app",
+		LDFlags: "-X main.version={{ .Version }}",
+		Provenance: &Provenance{
+			BuilderID:    "https://github.com/actions/runner",
+			Repo:         "https://github.com/octo/app",
+			Ref:          "refs/tags/v1.2.3",
+			Commit:       "abc123",
+			InvocationID: "https://github.com/octo/app/actions/runs/42",
+		},
+	}
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, err := b.provenance("1.2.3", []Artifact{{Target: Target{"linux", "amd64"}, Path: bin}}, started, started.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sum, _ := Checksum(bin)
+	if len(s.Subject) != 1 || s.Subject[0].Name != "app_1.2.3_linux_amd64" || s.Subject[0].Digest["sha256"] != sum {
+		t.Errorf("Expected the binary as subject, got %+v", s.Subject)
+	}
+	if s.Type != StatementType || s.PredicateType != PredicateType || s.Predicate.BuildDefinition.BuildType != BuildType {
+		t.Errorf("Unexpected statement types %+v", s)
+	}
+	external := map[string]any{
+		"package": "./cmd/app",
+		"version": "1.2.3",
+		"targets": []string{"linux/amd64"},
+		"ldflags": "-X main.version={{ .Version }}",
+		"source":  "git+https://github.com/octo/app",
+		"ref":     "refs/tags/v1.2.3",
+	}
+	if !reflect.DeepEqual(s.Predicate.BuildDefinition.ExternalParameters, external) {
+		t.Errorf("Expected %v, got %v", external, s.Predicate.BuildDefinition.ExternalParameters)
+	}
+	deps := []ResourceDescriptor{{URI: "git+https://github.com/octo/app@refs/tags/v1.2.3", Digest: map[string]string{"gitCommit": "abc123"}}}
+	if !reflect.DeepEqual(s.Predicate.BuildDefinition.ResolvedDependencies, deps) {
+		t.Errorf("Expected %v, got %v", deps, s.Predicate.BuildDefinition.ResolvedDependencies)
+	}
+	run := RunDetails{
+		Builder:  BuilderInfo{ID: "https://github.com/actions/runner"},
+		Metadata: BuildMetadata{InvocationID: "https://github.com/octo/app/actions/runs/42", StartedOn: started, FinishedOn: started.Add(time.Minute)},
+	}
+	if s.Predicate.RunDetails != run {
+		t.Errorf("Expected %+v, got %+v", run, s.Predicate.RunDetails)
+	}
+
+	dest := filepath.Join(dir, ProvenanceFile)
+	if err := WriteProvenance(dest, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(dest)
+	if bytes.Count(data, []byte("\n")) != 1 || !strings.HasPrefix(string(data), `{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"app_1.2.3_linux_amd64"`) {
+		t.Errorf("Expected the statement on one line, got %s", data)
+	}
+	var decoded Statement
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Predicate.RunDetails != run {
+		t.Errorf("Expected the statement to round-trip, got %+v (%v)", decoded.Predicate.RunDetails, err)
+	}
+}
+
+func TestRunProvenance(t *testing.T) {
+	dir := newModule(t)
+	args := stubPath(t, "cosign")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := &Builder{
+		Package:    "./cmd/hello",
+		Targets:    []Target{{"linux", "amd64"}},
+		Dir:        dir,
+		Provenance: &Provenance{BuilderID: "local://host", Signer: &Cosign{Bundle: true}},
+		now:        func() time.Time { return now },
+	}
+
+	paths, err := b.Run(context.Background(), "1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := filepath.Join(dir, "dist")
+	prov := filepath.Join(out, ProvenanceFile)
+	expected := []string{filepath.Join(out, "hello_1.0.0_linux_amd64"), filepath.Join(out, "SHA256SUMS"), prov, prov + ".sigstore.json"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected %v, got %v", expected, paths)
+	}
+
+	var s Statement
+	data, _ := os.ReadFile(prov)
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Subject) != 1 || s.Predicate.RunDetails.Builder.ID != "local://host" || !s.Predicate.RunDetails.Metadata.StartedOn.Equal(now) {
+		t.Errorf("Unexpected provenance %s", data)
+	}
+	if len(s.Predicate.BuildDefinition.ResolvedDependencies) != 0 {
+		t.Errorf("Expected no source without a repository, got %v", s.Predicate.BuildDefinition.ResolvedDependencies)
+	}
+	got, _ := os.ReadFile(args)
+	if want := "sign-blob --yes --bundle " + prov + ".sigstore.json " + prov; strings.TrimSpace(string(got)) != want {
+		t.Errorf("Expected cosign %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,140 @@
+package artifacts
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"strings"
+)
+
+// Differences reads the Go build information embedded in a published
+// binary and in its rebuild, and returns the differences between them
+// that explain why the two are not identical, such as another toolchain,
+// commit or dependency. It returns an error when either file is not a Go
+// binary.
+func Differences(published, rebuilt string) ([]string, error) {
+	p, err := buildinfo.ReadFile(published)
+	if err != nil {
+		return nil, fmt.Errorf("artifacts: %s: %w", published, err)
+	}
+	r, err := buildinfo.ReadFile(rebuilt)
+	if err != nil {
+		return nil, fmt.Errorf("artifacts: %s: %w", rebuilt, err)
+	}
+	return CompareBuildInfo(p, r), nil
+}
+
+// CompareBuildInfo returns the differences between the build information
+// of a published binary and that of its rebuild, each a sentence naming a
+// source of non-determinism.
+func CompareBuildInfo(published, rebuilt *debug.BuildInfo) []string {
+	var diffs []string
+	if published.GoVersion != rebuilt.GoVersion {
+		diffs = append(diffs, fmt.Sprintf("the release was built with %s and the rebuild with %s; set GOTOOLCHAIN=%s", published.GoVersion, rebuilt.GoVersion, published.GoVersion))
+	}
+	if published.Main.Version != rebuilt.Main.Version {
+		diffs = append(diffs, fmt.Sprintf("the main module is %s in the release and %s in the rebuild", published.Main.Version, rebuilt.Main.Version))
+	}
+	diffs = append(diffs, compareDeps(published.Deps, rebuilt.Deps)...)
+	return append(diffs, compareSettings(buildSettings(published), buildSettings(rebuilt))...)
+}
+
+// depVersion names the version of a dependency, with its replacement.
+func depVersion(m *debug.Module) string {
+	v := m.Version
+	if m.Replace != nil {
+		v += " => " + strings.TrimSpace(m.Replace.Path+" "+m.Replace.Version)
+	}
+	return v
+}
+
+func compareDeps(published, rebuilt []*debug.Module) []string {
+	versions := func(deps []*debug.Module) map[string]*debug.Module {
+		m := make(map[string]*debug.Module, len(deps))
+		for _, d := range deps {
+			m[d.Path] = d
+		}
+		return m
+	}
+	p, r := versions(published), versions(rebuilt)
+	paths := make(map[string]bool)
+	for path := range p {
+		paths[path] = true
+	}
+	for path := range r {
+		paths[path] = true
+	}
+
+	var diffs []string
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		pd, rd := p[path], r[path]
+		switch {
+		case rd == nil:
+			diffs = append(diffs, fmt.Sprintf("the release links %s %s, which the rebuild does not", path, depVersion(pd)))
+		case pd == nil:
+			diffs = append(diffs, fmt.Sprintf("the rebuild links %s %s, which the release does not", path, depVersion(rd)))
+		case depVersion(pd) != depVersion(rd) || pd.Sum != rd.Sum:
+			diffs = append(diffs, fmt.Sprintf("the release links %s %s and the rebuild %s", path, depVersion(pd), depVersion(rd)))
+		}
+	}
+	return diffs
+}
+
+func buildSettings(info *debug.BuildInfo) map[string]string {
+	m := make(map[string]string, len(info.Settings))
+	for _, s := range info.Settings {
+		m[s.Key] = s.Value
+	}
+	return m
+}
+
+// compareSettings compares the build settings. Go records -ldflags only
+// for builds without -trimpath, so the linker flags of a release built
+// with it cannot be compared.
+func compareSettings(published, rebuilt map[string]string) []string {
+	var diffs []string
+	if published["-trimpath"] != "true" {
+		diffs = append(diffs, "the release was built without -trimpath, embedding the paths of its build machine")
+	}
+	if published["vcs.modified"] == "true" {
+		diffs = append(diffs, "the release was built from a working tree with uncommitted changes")
+	}
+	if published["CGO_ENABLED"] == "1" {
+		diffs = append(diffs, "the release was built with cgo, linking the C toolchain and libraries of its build machine")
+	}
+	if ld, ok := published["-ldflags"]; ok && !strings.Contains(ld, ReproducibleLDFlags) {
+		diffs = append(diffs, fmt.Sprintf("the release was linked with -ldflags %q, without %s the build ID may differ; set artifacts.reproducible", ld, ReproducibleLDFlags))
+	}
+
+	keys := make(map[string]bool)
+	for k := range published {
+		keys[k] = true
+	}
+	for k := range rebuilt {
+		keys[k] = true
+	}
+	for _, k := range slices.Sorted(maps.Keys(keys)) {
+		switch k {
+		case "-trimpath", "vcs.modified", "CGO_ENABLED", "-ldflags":
+			continue
+		case "vcs.revision":
+			if published[k] != rebuilt[k] {
+				diffs = append(diffs, fmt.Sprintf("the release was built from commit %s and the rebuild from %s", orUnset(published[k]), orUnset(rebuilt[k])))
+			}
+			continue
+		}
+		if published[k] != rebuilt[k] {
+			diffs = append(diffs, fmt.Sprintf("%s is %s in the release and %s in the rebuild", k, orUnset(published[k]), orUnset(rebuilt[k])))
+		}
+	}
+	return diffs
+}
+
+func orUnset(s string) string {
+	if s == "" {
+		return "unset"
+	}
+	return fmt.Sprintf("%q", s)
+}
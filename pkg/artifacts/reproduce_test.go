@@ -0,0 +1,101 @@
+package artifacts
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+func TestCompareBuildInfo(t *testing.T) {
+	rebuilt := &debug.BuildInfo{
+		GoVersion: "go1.25.7",
+		Main:      debug.Module{Path: "example.com/hello", Version: "v1.0.0"},
+		Deps:      []*debug.Module{{Path: "golang.org/x/text", Version: "v0.20.0", Sum: "h1:a"}},
+		Settings: []debug.BuildSetting{
+			{Key: "-trimpath", Value: "true"},
+			{Key: "CGO_ENABLED", Value: "0"},
+			{Key: "vcs.revision", Value: "abc"},
+		},
+	}
+	if diffs := CompareBuildInfo(rebuilt, rebuilt); len(diffs) != 0 {
+		t.Errorf("Expected no differences, got %v", diffs)
+	}
+
+	published := &debug.BuildInfo{
+		GoVersion: "go1.24.1",
+		Main:      debug.Module{Path: "example.com/hello", Version: "v1.0.0"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/text", Version: "v0.19.0", Sum: "h1:b"},
+			{Path: "golang.org/x/term", Version: "v0.5.0"},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "-ldflags", Value: "-X main.version=1.0.0"},
+			{Key: "CGO_ENABLED", Value: "1"},
+			{Key: "vcs.revision", Value: "def"},
+			{Key: "vcs.modified", Value: "true"},
+			{Key: "GOAMD64", Value: "v3"},
+		},
+	}
+	expected := []string{
+		"the release was built with go1.24.1 and the rebuild with go1.25.7; set GOTOOLCHAIN=go1.24.1",
+		"the release links golang.org/x/term v0.5.0, which the rebuild does not",
+		"the release links golang.org/x/text v0.19.0 and the rebuild v0.20.0",
+		"the release was built without -trimpath, embedding the paths of its build machine",
+		"the release was built from a working tree with uncommitted changes",
+		"the release was built with cgo, linking the C toolchain and libraries of its build machine",
+		`the release was linked with -ldflags "-X main.version=1.0.0", without -buildid= the build ID may differ; set artifacts.reproducible`,
+		`GOAMD64 is "v3" in the release and unset in the rebuild`,
+		`the release was built from commit "def" and the rebuild from "abc"`,
+	}
+	if diffs := CompareBuildInfo(published, rebuilt); !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("Expected %q, got %q", expected, diffs)
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	dir := newModule(t)
+	build := func(output, ldflags string) string {
+		b := &Builder{
+			Package:      "./cmd/hello",
+			Targets:      []Target{{"linux", "amd64"}},
+			Dir:          dir,
+			Output:       output,
+			LDFlags:      ldflags,
+			Reproducible: true,
+		}
+		arts, err := b.Build(context.Background(), "1.0.0")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return arts[0].Path
+	}
+	first := build("first", "-X main.version={{ .Version }}")
+	second := build("second", "-X main.version={{ .Version }}")
+	if filepath.Base(first) != filepath.Base(second) {
+		t.Fatalf("Expected the same names, got %s and %s", first, second)
+	}
+	a, _ := Checksum(first)
+	b, _ := Checksum(second)
+	if a != b {
+		t.Errorf("Expected identical reproducible builds, got %s and %s", a, b)
+	}
+
+	// Go leaves -ldflags out of the build information of -trimpath
+	// builds, so that binaries stamped differently tell nothing apart.
+	other := build("other", "-X main.version=dev")
+	if c, _ := Checksum(other); c == a {
+		t.Errorf("Expected another stamp to change the binary")
+	}
+	diffs, err := Differences(other, first)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("Expected no recorded difference, got %q", diffs)
+	}
+	if _, err := Differences(filepath.Join(dir, "go.mod"), first); err == nil {
+		t.Error("Expected an error for a file that is not a Go binary")
+	}
+}
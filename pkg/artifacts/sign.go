@@ -0,0 +1,87 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// Signer creates a detached signature for a file.
+type Signer interface {
+	// Sign signs the file at path and returns the path of the signature.
+	Sign(ctx context.Context, path string) (string, error)
+}
+
+// Signers lists the names accepted by NewSigner.
+var Signers = []string{"cosign", "minisign"}
+
+// NewSigner returns the Signer called name, using key.
+func NewSigner(name, key string) (Signer, error) {
+	switch name {
+	case "cosign":
+		return &Cosign{Key: key}, nil
+	case "minisign":
+		return &Minisign{Key: key}, nil
+	}
+	return nil, fmt.Errorf("artifacts: unknown signer %q: must be cosign or minisign", name)
+}
+
+// Cosign signs blobs with "cosign sign-blob", writing "<path>.sig".
+type Cosign struct {
+	// Key is a key file or KMS URI. Empty selects keyless signing.
+	Key string
+	// Bundle writes a Sigstore bundle, "<path>.sigstore.json", instead of
+	// the signature alone. It holds the signing certificate of keyless
+	// signing and its transparency log entry, so "cosign verify-blob
+	// --bundle" can check the signature without a key.
+	Bundle bool
+	Stderr io.Writer
+}
+
+func (c *Cosign) Sign(ctx context.Context, path string) (string, error) {
+	sig := path + ".sig"
+	args := []string{"sign-blob", "--yes", "--output-signature", sig}
+	if c.Bundle {
+		sig = path + ".sigstore.json"
+		args = []string{"sign-blob", "--yes", "--bundle", sig}
+	}
+	if c.Key != "" {
+		args = append(args, "--key", c.Key)
+	}
+	if err := run(ctx, c.Stderr, "cosign", append(args, path)...); err != nil {
+		return "", err
+	}
+	return sig, nil
+}
+
+func (c *Cosign) String() string { return "cosign" }
+
+// Minisign signs files with "minisign -S", writing "<path>.minisig".
+type Minisign struct {
+	// Key is the secret key file. Empty uses minisign's default.
+	Key    string
+	Stderr io.Writer
+}
+
+func (m *Minisign) Sign(ctx context.Context, path string) (string, error) {
+	args := []string{"-S", "-m", path}
+	if m.Key != "" {
+		args = append(args, "-s", m.Key)
+	}
+	if err := run(ctx, m.Stderr, "minisign", args...); err != nil {
+		return "", err
+	}
+	return path + ".minisig", nil
+}
+
+func (m *Minisign) String() string { return "minisign" }
+
+func run(ctx context.Context, stderr io.Writer, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("artifacts: %s: %w", name, err)
+	}
+	return nil
+}
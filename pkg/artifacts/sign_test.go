@@ -0,0 +1,79 @@
+package artifacts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubPath puts an executable called name on PATH that records its
+// arguments in <dir>/args.
+func stubPath(t *testing.T, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return filepath.Join(dir, "args")
+}
+
+func TestSigners(t *testing.T) {
+	tests := []struct {
+		name, key, sig, args string
+	}{
+		{"cosign", "cosign.key", "SUMS.sig", "sign-blob --yes --output-signature SUMS.sig --key cosign.key SUMS"},
+		{"cosign", "", "SUMS.sig", "sign-blob --yes --output-signature SUMS.sig SUMS"},
+		{"minisign", "minisign.key", "SUMS.minisig", "-S -m SUMS -s minisign.key"},
+	}
+	for _, tt := range tests {
+		args := stubPath(t, tt.name)
+		s, err := NewSigner(tt.name, tt.key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		sig, err := s.Sign(context.Background(), "SUMS")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sig != tt.sig {
+			t.Errorf("Expected %q, got %q", tt.sig, sig)
+		}
+		got, _ := os.ReadFile(args)
+		if strings.TrimSpace(string(got)) != tt.args {
+			t.Errorf("Expected %s %s, got %s", tt.name, tt.args, got)
+		}
+	}
+}
+
+func TestCosignBundle(t *testing.T) {
+	args := stubPath(t, "cosign")
+	sig, err := (&Cosign{Bundle: true}).Sign(context.Background(), "SUMS")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sig != "SUMS.sigstore.json" {
+		t.Errorf("Expected SUMS.sigstore.json, got %q", sig)
+	}
+	got, _ := os.ReadFile(args)
+	if want := "sign-blob --yes --bundle SUMS.sigstore.json SUMS"; strings.TrimSpace(string(got)) != want {
+		t.Errorf("Expected cosign %s, got %s", want, got)
+	}
+}
+
+func TestNewSignerUnknown(t *testing.T) {
+	if _, err := NewSigner("gpg", ""); err == nil {
+		t.Error("Expected an error for an unknown signer")
+	}
+}
+
+func TestSignFailure(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := (&Cosign{}).Sign(context.Background(), "SUMS"); err == nil {
+		t.Error("Expected an error when cosign is not installed")
+	}
+}
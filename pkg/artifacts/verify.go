@@ -0,0 +1,98 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Verifier checks a detached signature made by a Signer.
+type Verifier interface {
+	// Verify checks that sig is a valid signature of the file at path.
+	Verify(ctx context.Context, path, sig string) error
+}
+
+// SignatureSuffixes are appended to the name of a signed file by the
+// Signers: cosign's signature and Sigstore bundle, and minisign's
+// signature.
+var SignatureSuffixes = []string{".sig", ".sigstore.json", ".minisig"}
+
+// NewVerifier returns the Verifier for signatures of the signer called
+// name, using the public key; see CosignVerifier for identity and issuer.
+func NewVerifier(name, key, identity, issuer string) (Verifier, error) {
+	switch name {
+	case "cosign":
+		return &CosignVerifier{Key: key, Identity: identity, Issuer: issuer}, nil
+	case "minisign":
+		return &MinisignVerifier{Key: key}, nil
+	}
+	return nil, fmt.Errorf("artifacts: unknown signer %q: must be cosign or minisign", name)
+}
+
+// PublicKey returns the public key matching the signing key of
+// ArtifactsConfig: "cosign.pub" for "cosign.key", and key itself for a KMS
+// URI, which verifies too. It returns "" for other keys.
+func PublicKey(key string) string {
+	switch {
+	case strings.Contains(key, "://"):
+		return key
+	case strings.HasSuffix(key, ".key"):
+		return strings.TrimSuffix(key, ".key") + ".pub"
+	}
+	return ""
+}
+
+// ErrNoIdentity is returned by CosignVerifier for a keyless signature when
+// the identity or issuer it must carry is not given.
+var ErrNoIdentity = errors.New("artifacts: keyless signatures need the certificate identity and OIDC issuer to verify")
+
+// CosignVerifier checks signatures and Sigstore bundles with "cosign
+// verify-blob".
+type CosignVerifier struct {
+	// Key is the public key file or KMS URI. Empty verifies keyless
+	// signatures, whose certificate must name Identity, e.g.
+	// "https://github.com/octo/app/.github/workflows/release.yml@refs/tags/v1.2.0",
+	// issued by Issuer, e.g. "https://token.actions.githubusercontent.com".
+	Key      string
+	Identity string
+	Issuer   string
+	Stderr   io.Writer
+}
+
+func (c *CosignVerifier) Verify(ctx context.Context, path, sig string) error {
+	args := []string{"verify-blob", "--signature", sig}
+	if strings.HasSuffix(sig, ".sigstore.json") {
+		args = []string{"verify-blob", "--bundle", sig}
+	}
+	switch {
+	case c.Key != "":
+		args = append(args, "--key", c.Key)
+	case c.Identity == "" || c.Issuer == "":
+		return ErrNoIdentity
+	default:
+		args = append(args, "--certificate-identity", c.Identity, "--certificate-oidc-issuer", c.Issuer)
+	}
+	return run(ctx, c.Stderr, "cosign", append(args, path)...)
+}
+
+func (c *CosignVerifier) String() string { return "cosign" }
+
+// MinisignVerifier checks signatures with "minisign -V".
+type MinisignVerifier struct {
+	// Key is the public key file. Empty uses minisign's default,
+	// ./minisign.pub.
+	Key    string
+	Stderr io.Writer
+}
+
+func (m *MinisignVerifier) Verify(ctx context.Context, path, sig string) error {
+	args := []string{"-V", "-m", path, "-x", sig}
+	if m.Key != "" {
+		args = append(args, "-p", m.Key)
+	}
+	return run(ctx, m.Stderr, "minisign", args...)
+}
+
+func (m *MinisignVerifier) String() string { return "minisign" }
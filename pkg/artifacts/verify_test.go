@@ -0,0 +1,58 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestVerifiers(t *testing.T) {
+	tests := []struct {
+		name, key, identity, issuer, sig, args string
+	}{
+		{"cosign", "cosign.pub", "", "", "SUMS.sig", "verify-blob --signature SUMS.sig --key cosign.pub SUMS"},
+		{"cosign", "", "https://github.com/octo/app/.github/workflows/release.yml@refs/tags/v1.0.0", "https://token.actions.githubusercontent.com", "SUMS.sigstore.json",
+			"verify-blob --bundle SUMS.sigstore.json --certificate-identity https://github.com/octo/app/.github/workflows/release.yml@refs/tags/v1.0.0 --certificate-oidc-issuer https://token.actions.githubusercontent.com SUMS"},
+		{"minisign", "minisign.pub", "", "", "SUMS.minisig", "-V -m SUMS -x SUMS.minisig -p minisign.pub"},
+		{"minisign", "", "", "", "SUMS.minisig", "-V -m SUMS -x SUMS.minisig"},
+	}
+	for _, tt := range tests {
+		args := stubPath(t, tt.name)
+		v, err := NewVerifier(tt.name, tt.key, tt.identity, tt.issuer)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := v.Verify(context.Background(), "SUMS", tt.sig); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, _ := os.ReadFile(args)
+		if strings.TrimSpace(string(got)) != tt.args {
+			t.Errorf("Expected %s %s, got %s", tt.name, tt.args, got)
+		}
+	}
+
+	if err := (&CosignVerifier{}).Verify(context.Background(), "SUMS", "SUMS.sig"); !errors.Is(err, ErrNoIdentity) {
+		t.Errorf("Expected ErrNoIdentity, got %v", err)
+	}
+	if _, err := NewVerifier("gpg", "", "", ""); err == nil {
+		t.Error("Expected an error for an unknown signer")
+	}
+}
+
+func TestPublicKey(t *testing.T) {
+	tests := map[string]string{
+		"cosign.key":                "cosign.pub",
+		"keys/minisign.key":         "keys/minisign.pub",
+		"awskms:///alias/release":   "awskms:///alias/release",
+		"hashivault://release-key":  "hashivault://release-key",
+		"":                          "",
+		"/home/ci/.minisign/secret": "",
+	}
+	for key, expected := range tests {
+		if got := PublicKey(key); got != expected {
+			t.Errorf("PublicKey(%q): Expected %q, got %q", key, expected, got)
+		}
+	}
+}
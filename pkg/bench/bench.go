@@ -0,0 +1,307 @@
+// Package bench measures the hot paths of the release analysis — parsing
+// commits, resolving the next version and rendering the changelog — on
+// synthetic histories of a given size, so that a change slowing them down
+// shows before it reaches the monorepos with tens of thousands of commits
+// to a release.
+//
+// NewHistory generates the history, Cases lists what is measured and Run
+// times each case in a loop, counting the allocations the runtime records
+// over it. Results saved from one run are the baseline Compare holds a
+// later one to. The benchmarks of the package run the same cases under go
+// test -bench.
+package bench
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// Size is the size of a synthetic history.
+type Size struct {
+	// Commits is the number of commits since the latest release.
+	Commits int
+	// Tags is the number of releases tagged before, prereleases included.
+	Tags int
+}
+
+// History is a synthetic repository history.
+type History struct {
+	Size Size
+	// Commits are newest first, as git log lists them: conventional
+	// commits with scopes, bodies and footers, pull request merges and
+	// squash merges, reverts, breaking changes and messages that follow no
+	// convention.
+	Commits []gitrepo.Commit
+	Tags    []gitrepo.Tag
+}
+
+var (
+	types  = []string{"feat", "fix", "fix", "fix", "docs", "chore", "refactor", "perf", "test", "build"}
+	scopes = []string{"", "api", "cli", "config", "publish", "changelog", "git", "notes"}
+)
+
+// NewHistory returns a history of size, the same for the same seed.
+func NewHistory(size Size, seed uint64) History {
+	r := rand.New(rand.NewPCG(seed, seed))
+	h := History{Size: size}
+	date := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range size.Commits {
+		h.Commits = append(h.Commits, gitrepo.Commit{
+			Hash:        fmt.Sprintf("%040x", uint64(size.Commits-i)<<20|r.Uint64N(1<<20)),
+			AuthorName:  fmt.Sprintf("Dev %d", r.IntN(40)),
+			AuthorEmail: fmt.Sprintf("dev%d@example.com", r.IntN(40)),
+			Date:        date.Add(-time.Duration(i) * time.Minute),
+			Message:     message(r, i),
+		})
+	}
+	for i := range size.Tags {
+		name := fmt.Sprintf("v%d.%d.%d", i/100, i/10%10, i%10)
+		if i%7 == 6 {
+			name += fmt.Sprintf("-rc.%d", i%3+1)
+		}
+		h.Tags = append(h.Tags, gitrepo.Tag{Name: name, Commit: fmt.Sprintf("%040x", i), Annotated: true})
+	}
+	return h
+}
+
+// message returns the message of the ith commit.
+func message(r *rand.Rand, i int) string {
+	header := func() string {
+		t, s := types[r.IntN(len(types))], scopes[r.IntN(len(scopes))]
+		if s != "" {
+			t += "(" + s + ")"
+		}
+		return fmt.Sprintf("%s: change %d of the %s", t, r.IntN(1000), scopes[r.IntN(len(scopes))])
+	}
+	switch n := r.IntN(100); {
+	case n < 10:
+		return fmt.Sprintf("Merge pull request #%d from octo/topic-%d\n\n%s", i+1, i, header())
+	case n < 15:
+		return fmt.Sprintf("Release train (#%d)\n\n* %s\n* %s\n* %s", i+1, header(), header(), header())
+	case n < 17:
+		return fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %040x.", header(), r.Uint64())
+	case n < 19:
+		return fmt.Sprintf("feat(api)!: drop the v%d endpoints\n\nBREAKING CHANGE: clients must move to v%d.", i%4+1, i%4+2)
+	case n < 25:
+		return fmt.Sprintf("update things %d", i)
+	case n < 60:
+		return fmt.Sprintf("%s\n\nThe change is explained here, at some length,\nover a couple of lines.\n\nRefs: #%d\nReviewed-by: Dev %d <dev%d@example.com>", header(), r.IntN(5000), i%40, i%40)
+	}
+	return header()
+}
+
+// Case is one measured operation.
+type Case struct {
+	Name string
+	// Op runs the operation once.
+	Op func() error
+}
+
+// Cases returns the cases measured on h:
+//
+//   - parse reads the commits as conventional commits, unwrapping merges;
+//   - resolve plans the next version: the latest stable tag, the commits
+//     left once reverts are dropped, their bump level and the version;
+//   - changelog groups the parsed commits and renders the section.
+func Cases(h History) []Case {
+	m := workspace.Repository("v")
+	parsed := workspace.Parse(h.Commits)
+	date := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	renderer := changelog.DefaultRenderer()
+	return []Case{
+		{"parse", func() error {
+			workspace.Parse(h.Commits)
+			return nil
+		}},
+		{"resolve", func() error {
+			latest, _, _ := workspace.LatestTag(h.Tags, m, true)
+			cs := workspace.Parse(workspace.DropReverted(h.Commits))
+			version.SemVer{}.Next(latest, commits.Classify(cs), date)
+			return nil
+		}},
+		{"changelog", func() error {
+			r := changelog.New("1.3.0", date, parsed, changelog.Options{})
+			return renderer.Render(io.Discard, r)
+		}},
+	}
+}
+
+// Result is the measurement of a case on a history.
+type Result struct {
+	// Name is the case and the number of commits, e.g. "parse/10000".
+	Name        string `json:"name"`
+	Commits     int    `json:"commits"`
+	N           int    `json:"n"`
+	NsPerOp     int64  `json:"ns_per_op"`
+	AllocsPerOp int64  `json:"allocs_per_op"`
+	BytesPerOp  int64  `json:"bytes_per_op"`
+}
+
+// Options select the cases Run measures and for how long.
+type Options struct {
+	// Filter selects the cases whose name contains it; empty selects all.
+	Filter string
+	// Benchtime is how long each case runs, as go test -benchtime takes
+	// it: a duration such as "1s", the default, or a number of iterations
+	// such as "100x".
+	Benchtime string
+}
+
+// Run measures the cases of h selected by o.
+func Run(h History, o Options) ([]Result, error) {
+	d, runs, err := ParseBenchtime(cmp.Or(o.Benchtime, "1s"))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, c := range Cases(h) {
+		if !strings.Contains(c.Name, o.Filter) {
+			continue
+		}
+		s, err := measure(c.Op, d, runs)
+		if err != nil {
+			return nil, fmt.Errorf("bench: %s: %w", c.Name, err)
+		}
+		results = append(results, Result{
+			Name:        fmt.Sprintf("%s/%d", c.Name, h.Size.Commits),
+			Commits:     h.Size.Commits,
+			N:           s.n,
+			NsPerOp:     s.elapsed.Nanoseconds() / int64(s.n),
+			AllocsPerOp: int64(s.allocs / uint64(s.n)),
+			BytesPerOp:  int64(s.bytes / uint64(s.n)),
+		})
+	}
+	return results, nil
+}
+
+// ParseBenchtime returns the duration of a benchtime, or its number of
+// runs when it is one such as "100x".
+func ParseBenchtime(s string) (time.Duration, int, error) {
+	if n, ok := strings.CutSuffix(s, "x"); ok {
+		runs, err := strconv.Atoi(n)
+		if err != nil || runs <= 0 {
+			return 0, 0, fmt.Errorf("bench: benchtime %q: not a positive number of runs", s)
+		}
+		return 0, runs, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("bench: benchtime %q: not a positive duration or number of runs such as 100x", s)
+	}
+	return d, 0, nil
+}
+
+// sample is the measurement of n runs of an operation.
+type sample struct {
+	n             int
+	elapsed       time.Duration
+	allocs, bytes uint64
+}
+
+// measure runs op the given number of runs, or, when runs is 0, as many
+// times as fit in d, growing the runs of a sample until one takes d.
+func measure(op func() error, d time.Duration, runs int) (sample, error) {
+	// A first run, not measured, warms up the caches.
+	if err := op(); err != nil {
+		return sample{}, err
+	}
+	if runs > 0 {
+		return runN(op, runs)
+	}
+	for n := 1; ; {
+		s, err := runN(op, n)
+		if err != nil || s.elapsed >= d || n >= 1e9 {
+			return s, err
+		}
+		// Aim 20% past d from the time per run so far, growing by at
+		// least twice and at most 100 times.
+		next := n * 2
+		if per := s.elapsed.Nanoseconds() / int64(n); per > 0 {
+			next = max(next, int(int64(d)*6/5/per))
+		}
+		n = min(next, n*100, 1e9)
+	}
+}
+
+// runN times n runs of op, with the allocations the runtime counts over
+// them.
+func runN(op func() error, n int) (sample, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for range n {
+		if err := op(); err != nil {
+			return sample{}, err
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return sample{
+		n:       n,
+		elapsed: elapsed,
+		allocs:  after.Mallocs - before.Mallocs,
+		bytes:   after.TotalAlloc - before.TotalAlloc,
+	}, nil
+}
+
+// Regression is a metric of a result grown beyond the threshold over its
+// baseline.
+type Regression struct {
+	Name     string  `json:"name"`
+	Metric   string  `json:"metric"`
+	Baseline int64   `json:"baseline"`
+	Got      int64   `json:"got"`
+	Change   float64 `json:"change"`
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: %s %d → %d (%+.0f%%)", r.Name, r.Metric, r.Baseline, r.Got, r.Change*100)
+}
+
+// Compare returns the regressions of results over baseline: the time,
+// allocations or bytes per operation of a case grown by more than
+// threshold, a fraction such as 0.2 for 20%. Cases missing from baseline
+// are not compared.
+func Compare(results, baseline []Result, threshold float64) []Regression {
+	base := make(map[string]Result, len(baseline))
+	for _, r := range baseline {
+		base[r.Name] = r
+	}
+	var regs []Regression
+	for _, r := range results {
+		b, ok := base[r.Name]
+		if !ok {
+			continue
+		}
+		for _, m := range []struct {
+			name      string
+			base, got int64
+		}{
+			{"ns/op", b.NsPerOp, r.NsPerOp},
+			{"allocs/op", b.AllocsPerOp, r.AllocsPerOp},
+			{"B/op", b.BytesPerOp, r.BytesPerOp},
+		} {
+			if m.base <= 0 {
+				continue
+			}
+			if change := float64(m.got-m.base) / float64(m.base); change > threshold {
+				regs = append(regs, Regression{Name: r.Name, Metric: m.name, Baseline: m.base, Got: m.got, Change: change})
+			}
+		}
+	}
+	return regs
+}
@@ -0,0 +1,101 @@
+package bench
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+func TestNewHistory(t *testing.T) {
+	h := NewHistory(Size{Commits: 500, Tags: 30}, 1)
+	if len(h.Commits) != 500 || len(h.Tags) != 30 {
+		t.Fatalf("Expected 500 commits and 30 tags, got %d and %d", len(h.Commits), len(h.Tags))
+	}
+	if !reflect.DeepEqual(h, NewHistory(Size{Commits: 500, Tags: 30}, 1)) {
+		t.Errorf("Expected the same history for the same seed")
+	}
+	if reflect.DeepEqual(h.Commits, NewHistory(Size{Commits: 500, Tags: 30}, 2).Commits) {
+		t.Errorf("Expected another history for another seed")
+	}
+
+	parsed := workspace.Parse(h.Commits)
+	if len(parsed) < 400 || len(parsed) > 700 {
+		t.Errorf("Expected most commits to parse, with squash merges listing several, got %d", len(parsed))
+	}
+	if dropped := len(h.Commits) - len(workspace.DropReverted(h.Commits)); dropped != 0 {
+		t.Errorf("Expected the reverts of released commits to be kept, got %d dropped", dropped)
+	}
+	latest, tag, ok := workspace.LatestTag(h.Tags, workspace.Repository("v"), true)
+	if !ok || tag != "v0.2.9" || latest.String() != "0.2.9" {
+		t.Errorf("Expected v0.2.9 as the latest stable tag, got %s (%v)", tag, ok)
+	}
+}
+
+func TestRun(t *testing.T) {
+	h := NewHistory(Size{Commits: 100, Tags: 10}, 1)
+	results, err := Run(h, Options{Benchtime: "2x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Name)
+		if r.N != 2 || r.Commits != 100 || r.AllocsPerOp <= 0 || r.BytesPerOp <= 0 {
+			t.Errorf("%s: Expected 2 measured runs with allocations, got %+v", r.Name, r)
+		}
+	}
+	if expected := []string{"parse/100", "resolve/100", "changelog/100"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	results, err = Run(h, Options{Filter: "log", Benchtime: "1x"})
+	if err != nil || len(results) != 1 || results[0].Name != "changelog/100" {
+		t.Errorf("Expected the changelog case alone, got %+v (err=%v)", results, err)
+	}
+	if _, err := Run(h, Options{Benchtime: "soon"}); err == nil {
+		t.Errorf("Expected an error for an invalid benchtime")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := []Result{
+		{Name: "parse/1000", NsPerOp: 1000, AllocsPerOp: 100, BytesPerOp: 4096},
+		{Name: "resolve/1000", NsPerOp: 2000, AllocsPerOp: 0, BytesPerOp: 0},
+	}
+	results := []Result{
+		{Name: "parse/1000", NsPerOp: 1100, AllocsPerOp: 150, BytesPerOp: 4096},
+		{Name: "resolve/1000", NsPerOp: 5000, AllocsPerOp: 10, BytesPerOp: 10},
+		{Name: "changelog/1000", NsPerOp: 1},
+	}
+	regs := Compare(results, baseline, 0.2)
+	expected := []Regression{
+		{Name: "parse/1000", Metric: "allocs/op", Baseline: 100, Got: 150, Change: 0.5},
+		{Name: "resolve/1000", Metric: "ns/op", Baseline: 2000, Got: 5000, Change: 1.5},
+	}
+	if !reflect.DeepEqual(regs, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, regs)
+	}
+	if s := regs[0].String(); s != "parse/1000: allocs/op 100 → 150 (+50%)" {
+		t.Errorf("Expected the regression described, got %q", s)
+	}
+}
+
+// BenchmarkAnalysis runs the cases on histories of a release of a small
+// project up to one of a large monorepo.
+func BenchmarkAnalysis(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		h := NewHistory(Size{Commits: n, Tags: 200}, 1)
+		for _, c := range Cases(h) {
+			b.Run(fmt.Sprintf("%s/%d", c.Name, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					if err := c.Op(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
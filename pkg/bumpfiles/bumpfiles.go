@@ -0,0 +1,195 @@
+// Package bumpfiles rewrites the version recorded in project files, such as
+// package.json, pyproject.toml or a plain VERSION file, when a release is
+// cut.
+//
+// A File locates the version in one of three ways: a dotted Key in a JSON,
+// YAML or TOML document, a regular expression whose first group is the
+// version, or, with neither, the whole file. Structured files are edited in
+// place, so formatting, comments and key order are preserved.
+package bumpfiles
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+)
+
+// ErrNotFound is returned when a file does not contain a version where its
+// File says it should.
+var ErrNotFound = errors.New("version not found")
+
+// Formats lists the document formats Key can be used with, keyed by file
+// extension.
+var Formats = map[string]string{
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+}
+
+// File is a file holding the project version.
+type File struct {
+	// Path is relative to the Bumper's Dir.
+	Path string
+	// Key is the dotted path of the version in a JSON, YAML or TOML
+	// document, e.g. "version" in package.json or "project.version" in
+	// pyproject.toml. The format is taken from the file extension.
+	Key string
+	// Pattern is a regular expression whose first group matches the
+	// version, e.g. `Version = "(.*)"`. Every match is replaced.
+	Pattern string
+}
+
+// Change is a version rewritten in a file.
+type Change struct {
+	Path string
+	Old  string
+	New  string
+}
+
+// Check reports whether f is usable: Key and Pattern are exclusive, Key
+// needs a known format and Pattern must compile with a group.
+func (f File) Check() error {
+	switch {
+	case f.Path == "":
+		return errors.New("bumpfiles: path must not be empty")
+	case f.Key != "" && f.Pattern != "":
+		return fmt.Errorf("bumpfiles: %s: key and pattern are mutually exclusive", f.Path)
+	case f.Key != "" && Formats[filepath.Ext(f.Path)] == "":
+		return fmt.Errorf("bumpfiles: %s: key needs a .json, .yaml, .yml or .toml file", f.Path)
+	}
+	if f.Pattern != "" {
+		re, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return fmt.Errorf("bumpfiles: %s: %w", f.Path, err)
+		}
+		if re.NumSubexp() == 0 {
+			return fmt.Errorf("bumpfiles: %s: pattern %q has no group for the version", f.Path, f.Pattern)
+		}
+	}
+	return nil
+}
+
+// Edit returns data with the version located by f replaced by version,
+// along with the version it replaced.
+func Edit(f File, data []byte, version string) ([]byte, string, error) {
+	if err := f.Check(); err != nil {
+		return nil, "", err
+	}
+	var (
+		out []byte
+		old string
+		err error
+	)
+	switch {
+	case f.Key != "":
+		out, old, err = editKey(Formats[filepath.Ext(f.Path)], data, strings.Split(f.Key, "."), version)
+	case f.Pattern != "":
+		out, old, err = editPattern(regexp.MustCompile(f.Pattern), data, version)
+	default:
+		out, old, err = editPlain(data, version)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("bumpfiles: %s: %w", f.Path, err)
+	}
+	return out, old, nil
+}
+
+func editPlain(data []byte, version string) ([]byte, string, error) {
+	old := string(bytes.TrimSpace(data))
+	if old == "" {
+		return nil, "", ErrNotFound
+	}
+	i := bytes.Index(data, []byte(old))
+	return splice(data, i, i+len(old), version), old, nil
+}
+
+func editPattern(re *regexp.Regexp, data []byte, version string) ([]byte, string, error) {
+	matches := re.FindAllSubmatchIndex(data, -1)
+	if len(matches) == 0 {
+		return nil, "", fmt.Errorf("%w: no match for %q", ErrNotFound, re)
+	}
+	old := string(data[matches[0][2]:matches[0][3]])
+	var out bytes.Buffer
+	last := 0
+	for _, m := range matches {
+		out.Write(data[last:m[2]])
+		out.WriteString(version)
+		last = m[3]
+	}
+	out.Write(data[last:])
+	return out.Bytes(), old, nil
+}
+
+// splice replaces data[start:end] with s.
+func splice(data []byte, start, end int, s string) []byte {
+	out := make([]byte, 0, len(data)-(end-start)+len(s))
+	out = append(out, data[:start]...)
+	out = append(out, s...)
+	return append(out, data[end:]...)
+}
+
+// Bumper rewrites files in a directory.
+type Bumper struct {
+	// Dir is the directory paths are relative to; empty means the current
+	// directory.
+	Dir string
+	// DryRun describes the changes on Log instead of writing them.
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every file rewritten; nil discards.
+	Logger *slog.Logger
+}
+
+// Bump sets the version in every file. All files are edited before any is
+// written, so a file without a version leaves every file untouched.
+func (b *Bumper) Bump(files []File, version string) ([]Change, error) {
+	type edit struct {
+		path string
+		data []byte
+		mode os.FileMode
+	}
+	var (
+		edits   []edit
+		changes []Change
+	)
+	for _, f := range files {
+		path := filepath.Join(b.Dir, f.Path)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("bumpfiles: %w", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("bumpfiles: %w", err)
+		}
+		out, old, err := Edit(f, data, version)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit{path: path, data: out, mode: info.Mode().Perm()})
+		changes = append(changes, Change{Path: f.Path, Old: old, New: version})
+	}
+
+	for i, e := range edits {
+		c := changes[i]
+		if b.DryRun {
+			dryrun.Printf(b.Log, "would update %s: %s -> %s", c.Path, c.Old, c.New)
+			continue
+		}
+		log.Or(b.Logger).Debug("bumping version", "path", c.Path, "old", c.Old, "new", c.New)
+		if err := os.WriteFile(e.path, e.data, e.mode); err != nil {
+			return nil, fmt.Errorf("bumpfiles: %w", err)
+		}
+	}
+	return changes, nil
+}
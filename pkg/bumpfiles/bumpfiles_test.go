@@ -0,0 +1,113 @@
+package bumpfiles
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := map[string]File{
+		"path must not be empty": {},
+		"mutually exclusive":     {Path: "a.json", Key: "version", Pattern: "(.*)"},
+		"key needs":              {Path: "setup.cfg", Key: "version"},
+		"missing closing":        {Path: "main.go", Pattern: "(.*"},
+		"no group":               {Path: "main.go", Pattern: `version = ".*"`},
+	}
+	for want, f := range tests {
+		if err := f.Check(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Check(%+v): Expected error mentioning %q, got %v", f, want, err)
+		}
+	}
+	if err := (File{Path: "VERSION"}).Check(); err != nil {
+		t.Errorf("Expected a plain file to be valid, got %v", err)
+	}
+}
+
+func TestEditPlain(t *testing.T) {
+	out, old, err := Edit(File{Path: "VERSION"}, []byte("1.2.0\n"), "1.3.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "1.3.0\n" || old != "1.2.0" {
+		t.Errorf("Expected %q (1.2.0), got %q (%s)", "1.3.0\n", out, old)
+	}
+
+	if _, _, err := Edit(File{Path: "VERSION"}, []byte("\n"), "1.3.0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestEditPattern(t *testing.T) {
+	data := "package main\n\nconst Version = \"1.2.0\"\n\n// Deprecated: Version = \"1.2.0\" before 2.0.\n"
+	out, old, err := Edit(File{Path: "version.go", Pattern: `Version = "([^"]*)"`}, []byte(data), "1.3.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "package main\n\nconst Version = \"1.3.0\"\n\n// Deprecated: Version = \"1.3.0\" before 2.0.\n"
+	if string(out) != expected || old != "1.2.0" {
+		t.Errorf("Expected %q (1.2.0), got %q (%s)", expected, out, old)
+	}
+
+	if _, _, err := Edit(File{Path: "version.go", Pattern: `version: (\S+)`}, []byte(data), "1.3.0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestBump(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "VERSION"), []byte("1.2.0\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"version": "1.2.0"}`), 0o600)
+	files := []File{{Path: "VERSION"}, {Path: "package.json", Key: "version"}}
+
+	var log bytes.Buffer
+	b := &Bumper{Dir: dir, DryRun: true, Log: &log}
+	if _, err := b.Bump(files, "1.3.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "[dry-run] would update VERSION: 1.2.0 -> 1.3.0\n[dry-run] would update package.json: 1.2.0 -> 1.3.0\n"
+	if log.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, log.String())
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "VERSION")); string(got) != "1.2.0\n" {
+		t.Errorf("Expected dry run to leave VERSION alone, got %q", got)
+	}
+
+	b.DryRun = false
+	changes, err := b.Bump(files, "1.3.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 2 || changes[1] != (Change{Path: "package.json", Old: "1.2.0", New: "1.3.0"}) {
+		t.Errorf("Expected two changes, got %+v", changes)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "package.json"))
+	if string(got) != `{"version": "1.3.0"}` {
+		t.Errorf("Expected package.json to be bumped, got %q", got)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, "package.json")); info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600 to be kept, got %v", info.Mode().Perm())
+	}
+}
+
+func TestBumpLeavesFilesOnError(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "VERSION"), []byte("1.2.0\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte("name: app\n"), 0o644)
+
+	b := &Bumper{Dir: dir}
+	_, err := b.Bump([]File{{Path: "VERSION"}, {Path: "Chart.yaml", Key: "version"}}, "1.3.0")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "VERSION")); string(got) != "1.2.0\n" {
+		t.Errorf("Expected VERSION to be left alone, got %q", got)
+	}
+
+	if _, err := b.Bump([]File{{Path: "missing"}}, "1.3.0"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file error, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package bumpfiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// editKey replaces the string at key in a document of the given format.
+// The document is parsed to find the value, which is then replaced in the
+// original bytes.
+func editKey(format string, data []byte, key []string, version string) ([]byte, string, error) {
+	var (
+		start, end int
+		old        string
+		err        error
+	)
+	switch format {
+	case "json":
+		start, end, old, err = findJSON(data, key)
+	case "yaml":
+		start, end, old, err = findYAML(data, key)
+	case "toml":
+		start, end, old, err = findTOML(data, key)
+	default:
+		return nil, "", fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return splice(data, start, end, version), old, nil
+}
+
+// notFound reports a key missing from a document.
+func notFound(key []string) error {
+	return fmt.Errorf("%w: no key %s", ErrNotFound, strings.Join(key, "."))
+}
+
+// findJSON returns the bounds of the string at key, without its quotes.
+func findJSON(data []byte, key []string) (start, end int, old string, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for i, k := range key {
+		if tok, err := dec.Token(); err != nil {
+			return 0, 0, "", err
+		} else if tok != json.Delim('{') {
+			return 0, 0, "", notFound(key[:i+1])
+		}
+		found := false
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return 0, 0, "", err
+			}
+			if tok == k {
+				found = true
+				break
+			}
+			if err := skipJSON(dec); err != nil {
+				return 0, 0, "", err
+			}
+		}
+		if !found {
+			return 0, 0, "", notFound(key[:i+1])
+		}
+	}
+
+	prev := int(dec.InputOffset())
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, 0, "", err
+	}
+	s, ok := tok.(string)
+	if !ok {
+		return 0, 0, "", fmt.Errorf("%s: %v is not a string", strings.Join(key, "."), tok)
+	}
+	end = int(dec.InputOffset()) - 1
+	start = prev + bytes.IndexByte(data[prev:end], '"') + 1
+	if string(data[start:end]) != s {
+		return 0, 0, "", fmt.Errorf("%s: escaped string %s cannot be edited", strings.Join(key, "."), data[start-1:end+1])
+	}
+	return start, end, s, nil
+}
+
+// skipJSON consumes the next value from dec.
+func skipJSON(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// findYAML returns the bounds of the scalar at key, without its quotes.
+func findYAML(data []byte, key []string) (start, end int, old string, err error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, 0, "", err
+	}
+	if len(doc.Content) == 0 {
+		return 0, 0, "", notFound(key)
+	}
+	n := doc.Content[0]
+	for i, k := range key {
+		var next *yaml.Node
+		if n.Kind == yaml.MappingNode {
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				if n.Content[j].Value == k {
+					next = n.Content[j+1]
+				}
+			}
+		}
+		if next == nil {
+			return 0, 0, "", notFound(key[:i+1])
+		}
+		n = next
+	}
+	if n.Kind != yaml.ScalarNode || n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return 0, 0, "", fmt.Errorf("%s: not a single-line scalar", strings.Join(key, "."))
+	}
+
+	start = offset(data, n.Line, n.Column)
+	if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		start++
+	}
+	if start < 0 || !bytes.HasPrefix(data[start:], []byte(n.Value)) {
+		return 0, 0, "", fmt.Errorf("%s: %q cannot be edited in place", strings.Join(key, "."), n.Value)
+	}
+	return start, start + len(n.Value), n.Value, nil
+}
+
+// offset converts a 1-based line and column, counted in characters, to a
+// byte offset in data, or -1 when data is shorter.
+func offset(data []byte, line, column int) int {
+	i := 0
+	for ; line > 1; line-- {
+		j := bytes.IndexByte(data[i:], '\n')
+		if j < 0 {
+			return -1
+		}
+		i += j + 1
+	}
+	for ; column > 1 && i < len(data); column-- {
+		_, size := utf8.DecodeRune(data[i:])
+		i += size
+	}
+	return i
+}
+
+// tomlHeader matches a [table] or [[array]] header line.
+var tomlHeader = regexp.MustCompile(`^\[\[?\s*([\w.\-"' ]+?)\s*\]\]?\s*(#.*)?$`)
+
+// findTOML returns the bounds of the string at key, without its quotes.
+// The document is decoded to check the value, then its line is located by
+// tracking table headers; the key must be a basic or literal string on a
+// single line.
+func findTOML(data []byte, key []string) (start, end int, old string, err error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return 0, 0, "", err
+	}
+	var v any = doc
+	for i, k := range key {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return 0, 0, "", notFound(key[:i+1])
+		}
+		if v, ok = m[k]; !ok {
+			return 0, 0, "", notFound(key[:i+1])
+		}
+	}
+	old, ok := v.(string)
+	if !ok {
+		return 0, 0, "", fmt.Errorf("%s: %v is not a string", strings.Join(key, "."), v)
+	}
+
+	var table []string
+	lineStart := 0
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := tomlHeader.FindStringSubmatch(trimmed); m != nil {
+			table = tomlKey(m[1])
+		} else if k, v, ok := strings.Cut(line, "="); ok && !strings.HasPrefix(trimmed, "#") &&
+			slices.Equal(append(slices.Clone(table), tomlKey(k)...), key) {
+			value := strings.TrimLeft(v, " \t")
+			if value == "" || (value[0] != '"' && value[0] != '\'') || !strings.HasPrefix(value[1:], old+value[:1]) {
+				break
+			}
+			start = lineStart + len(k) + 1 + len(v) - len(value) + 1
+			return start, start + len(old), old, nil
+		}
+		lineStart += len(line)
+	}
+	return 0, 0, "", fmt.Errorf("%s: %q cannot be edited in place", strings.Join(key, "."), old)
+}
+
+// tomlKey splits a dotted TOML key, unquoting its parts.
+func tomlKey(s string) []string {
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return parts
+}
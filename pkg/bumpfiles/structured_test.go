@@ -0,0 +1,127 @@
+package bumpfiles
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEditJSON(t *testing.T) {
+	data := `{
+  "name": "app",
+  "dependencies": {"left-pad": "1.2.0", "version": "9.9.9"},
+  "version": "1.2.0",
+  "scripts": ["a", {"version": "x"}]
+}
+`
+	out, old, err := Edit(File{Path: "package.json", Key: "version"}, []byte(data), "1.3.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{
+  "name": "app",
+  "dependencies": {"left-pad": "1.2.0", "version": "9.9.9"},
+  "version": "1.3.0",
+  "scripts": ["a", {"version": "x"}]
+}
+`
+	if string(out) != expected || old != "1.2.0" {
+		t.Errorf("Expected %q (1.2.0), got %q (%s)", expected, out, old)
+	}
+
+	out, _, err = Edit(File{Path: "package.json", Key: "dependencies.version"}, []byte(data), "10.0.0")
+	if err != nil || string(out) == data {
+		t.Errorf("Expected nested key to be edited, got %q %v", out, err)
+	}
+	if _, _, err := Edit(File{Path: "package.json", Key: "release.version"}, []byte(data), "1.3.0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, _, err := Edit(File{Path: "package.json", Key: "scripts"}, []byte(data), "1.3.0"); err == nil {
+		t.Errorf("Expected an error for a non-string value")
+	}
+}
+
+func TestEditYAML(t *testing.T) {
+	data := "# chart\nname: app\nversion: 1.2.0 # bumped on release\nappVersion: \"1.2.0\"\nimage:\n  tag: 'v1.2.0'\n"
+
+	tests := []struct {
+		key      string
+		version  string
+		expected string
+	}{
+		{"version", "1.3.0", "# chart\nname: app\nversion: 1.3.0 # bumped on release\nappVersion: \"1.2.0\"\nimage:\n  tag: 'v1.2.0'\n"},
+		{"appVersion", "1.3.0", "# chart\nname: app\nversion: 1.2.0 # bumped on release\nappVersion: \"1.3.0\"\nimage:\n  tag: 'v1.2.0'\n"},
+		{"image.tag", "v1.3.0", "# chart\nname: app\nversion: 1.2.0 # bumped on release\nappVersion: \"1.2.0\"\nimage:\n  tag: 'v1.3.0'\n"},
+	}
+	for _, tt := range tests {
+		out, _, err := Edit(File{Path: "Chart.yaml", Key: tt.key}, []byte(data), tt.version)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.key, err)
+		}
+		if string(out) != tt.expected {
+			t.Errorf("%s: Expected %q, got %q", tt.key, tt.expected, out)
+		}
+	}
+
+	if _, _, err := Edit(File{Path: "Chart.yaml", Key: "image.digest"}, []byte(data), "x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, _, err := Edit(File{Path: "Chart.yaml", Key: "image"}, []byte(data), "x"); err == nil {
+		t.Errorf("Expected an error for a mapping")
+	}
+}
+
+func TestEditTOML(t *testing.T) {
+	data := `[tool.poetry]
+version = "0.1.0"
+
+[project]
+name = "app"
+# version = "0.0.0"
+version = "1.2.0"
+classifiers = [
+  "Programming Language :: Python",
+]
+
+[project.urls]
+version = 'https://example.com'
+`
+	out, old, err := Edit(File{Path: "pyproject.toml", Key: "project.version"}, []byte(data), "1.3.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `[tool.poetry]
+version = "0.1.0"
+
+[project]
+name = "app"
+# version = "0.0.0"
+version = "1.3.0"
+classifiers = [
+  "Programming Language :: Python",
+]
+
+[project.urls]
+version = 'https://example.com'
+`
+	if string(out) != expected || old != "1.2.0" {
+		t.Errorf("Expected %q (1.2.0), got %q (%s)", expected, out, old)
+	}
+
+	out, _, err = Edit(File{Path: "pyproject.toml", Key: "project.urls.version"}, []byte(data), "https://example.org")
+	if err != nil || string(out) == data {
+		t.Errorf("Expected literal string to be edited, got %q %v", out, err)
+	}
+
+	dotted := "package.version = \"0.3.0\"\n"
+	out, _, err = Edit(File{Path: "Cargo.toml", Key: "package.version"}, []byte(dotted), "0.4.0")
+	if err != nil || string(out) != "package.version = \"0.4.0\"\n" {
+		t.Errorf("Expected dotted key to be edited, got %q %v", out, err)
+	}
+
+	if _, _, err := Edit(File{Path: "pyproject.toml", Key: "version"}, []byte(data), "1.3.0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, _, err := Edit(File{Path: "pyproject.toml", Key: "project.classifiers"}, []byte(data), "1.3.0"); err == nil {
+		t.Errorf("Expected an error for an array")
+	}
+}
@@ -0,0 +1,121 @@
+// Package cache keeps the issues, pull requests and commit authors looked up
+// through the provider APIs in a local store, so that changelogs and release
+// notes can be generated again offline, with the same links and handles.
+//
+// The store is content-addressed: each record is a JSON file named by the
+// SHA-256 of its key, which names the provider, the repository and the
+// issue number or commit hash it describes, under a directory of the first
+// two hex digits of the hash as git stores its objects. A record is written
+// through a temporary file, so that concurrent runs and interruptions never
+// leave a truncated one behind.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Store is a directory of records.
+type Store struct {
+	Dir string
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// record is the file of a value.
+type record struct {
+	// Key is kept to tell what the record describes when reading it.
+	Key     string          `json:"key"`
+	Fetched time.Time       `json:"fetched"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// IssueKey is the key of issue or pull request number on source, such as
+// "github octo/app".
+func IssueKey(source string, number int) string {
+	return source + " issue " + strconv.Itoa(number)
+}
+
+// AuthorKey is the key of the login of the author of commit hash on source.
+func AuthorKey(source, hash string) string {
+	return source + " author " + hash
+}
+
+func (s *Store) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// path returns the file of key.
+func (s *Store) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	h := hex.EncodeToString(sum[:])
+	return filepath.Join(s.Dir, h[:2], h[2:]+".json")
+}
+
+// Get decodes the value recorded for key into v and reports whether there
+// is one.
+func (s *Store) Get(key string, v any) (bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cache: %w", err)
+	}
+	var r record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return false, fmt.Errorf("cache: %s: %w", key, err)
+	}
+	if r.Key != key {
+		// A hash collision, or a file copied over; either way not key's.
+		return false, nil
+	}
+	if err := json.Unmarshal(r.Value, v); err != nil {
+		return false, fmt.Errorf("cache: %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Put records v for key, replacing the value recorded before.
+func (s *Store) Put(key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cache: %s: %w", key, err)
+	}
+	data, err := json.MarshalIndent(record{Key: key, Fetched: s.now().UTC(), Value: value}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cache: %s: %w", key, err)
+	}
+	path := s.path(key)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+func TestStore(t *testing.T) {
+	s := &Store{Dir: filepath.Join(t.TempDir(), ".release", "cache")}
+	s.Now = func() time.Time { return time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC) }
+
+	var r references.Reference
+	if ok, err := s.Get(IssueKey("github octo/app", 12), &r); ok || err != nil {
+		t.Fatalf("Expected no record in an empty store, got %v and %v", ok, err)
+	}
+	expected := references.Reference{Number: 12, Title: "Add x", Author: "octocat", Labels: []string{"feature"}, PullRequest: true}
+	if err := s.Put(IssueKey("github octo/app", 12), expected); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, err := s.Get(IssueKey("github octo/app", 12), &r); !ok || err != nil || !reflect.DeepEqual(r, expected) {
+		t.Errorf("Expected %+v, got %+v (%v, %v)", expected, r, ok, err)
+	}
+	if ok, _ := s.Get(IssueKey("gitlab octo/app", 12), &r); ok {
+		t.Errorf("Expected no record of another source")
+	}
+
+	// Records are named by the hash of their key, fanned out by its first
+	// two digits.
+	path := s.path(IssueKey("github octo/app", 12))
+	rel, _ := filepath.Rel(s.Dir, path)
+	if parts := strings.Split(rel, string(filepath.Separator)); len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 62+len(".json") {
+		t.Errorf("Expected a content-addressed path, got %s", rel)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"key": "github octo/app issue 12"`) || !strings.Contains(string(data), `"fetched": "2026-03-03T10:00:00Z"`) {
+		t.Errorf("Expected the record to tell its key and time, got %s (%v)", data, err)
+	}
+
+	// A record of another key under the same name is not the key's.
+	os.WriteFile(s.path(AuthorKey("github octo/app", "a1")), data, 0o644)
+	var login string
+	if ok, err := s.Get(AuthorKey("github octo/app", "a1"), &login); ok || err != nil {
+		t.Errorf("Expected a record of another key to be ignored, got %v and %v", ok, err)
+	}
+
+	os.WriteFile(path, []byte("{"), 0o644)
+	if _, err := s.Get(IssueKey("github octo/app", 12), &r); err == nil {
+		t.Errorf("Expected an error for a corrupt record")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".json.") {
+			t.Errorf("Expected no temporary file left, got %s", e.Name())
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+// Mode tells the finders of the package when to ask the provider.
+type Mode int
+
+const (
+	// ReadThrough asks the provider for what the store does not have, and
+	// records it.
+	ReadThrough Mode = iota
+	// Offline never asks the provider: what the store does not have is
+	// missed.
+	Offline
+	// Refresh always asks the provider, and records what it tells.
+	Refresh
+)
+
+var (
+	_ references.BatchFinder        = (*References)(nil)
+	_ contributors.BatchLoginFinder = (*Logins)(nil)
+)
+
+// References looks issues and pull requests up in Store before asking
+// Finder.
+type References struct {
+	Store *Store
+	// Source names the provider and repository of Finder, e.g. "github
+	// octo/app".
+	Source string
+	// Finder may be nil Offline.
+	Finder references.Finder
+	Mode   Mode
+	// Misses counts the lookups Offline found no record for; Fetched counts
+	// those asked of Finder and recorded.
+	Misses, Fetched int
+}
+
+// Issue returns the issue or pull request number. Offline, a number the
+// store has no record of fails with references.ErrUnavailable.
+func (c *References) Issue(ctx context.Context, number int) (references.Reference, error) {
+	key := IssueKey(c.Source, number)
+	var r references.Reference
+	if c.Mode != Refresh {
+		ok, err := c.Store.Get(key, &r)
+		if err != nil || ok {
+			return r, err
+		}
+	}
+	if c.Mode == Offline || c.Finder == nil {
+		c.Misses++
+		return r, fmt.Errorf("cache: %s #%d: %w", c.Source, number, references.ErrUnavailable)
+	}
+	r, err := c.Finder.Issue(ctx, number)
+	if err != nil {
+		return r, err
+	}
+	c.Fetched++
+	return r, c.Store.Put(key, r)
+}
+
+// Issues returns the issues and pull requests of numbers by number, asking
+// Finder for those the store does not have at once when it is a
+// references.BatchFinder. Offline, the numbers the store has no record of
+// are left out.
+func (c *References) Issues(ctx context.Context, numbers []int) (map[int]references.Reference, error) {
+	found := make(map[int]references.Reference, len(numbers))
+	var ask []int
+	for _, n := range numbers {
+		if c.Mode != Refresh {
+			var r references.Reference
+			ok, err := c.Store.Get(IssueKey(c.Source, n), &r)
+			if err != nil {
+				return found, err
+			}
+			if ok {
+				found[n] = r
+				continue
+			}
+		}
+		ask = append(ask, n)
+	}
+	if len(ask) == 0 {
+		return found, nil
+	}
+	if c.Mode == Offline || c.Finder == nil {
+		c.Misses += len(ask)
+		return found, nil
+	}
+	bf, ok := c.Finder.(references.BatchFinder)
+	if !ok {
+		for _, n := range ask {
+			r, err := c.Finder.Issue(ctx, n)
+			if errors.Is(err, references.ErrUnavailable) {
+				continue
+			} else if err != nil {
+				return found, err
+			}
+			c.Fetched++
+			found[n] = r
+			if err := c.Store.Put(IssueKey(c.Source, n), r); err != nil {
+				return found, err
+			}
+		}
+		return found, nil
+	}
+	fetched, err := bf.Issues(ctx, ask)
+	for n, r := range fetched {
+		c.Fetched++
+		found[n] = r
+		if perr := c.Store.Put(IssueKey(c.Source, n), r); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	return found, err
+}
+
+// Logins looks the logins of commit authors up in Store before asking
+// Finder.
+type Logins struct {
+	Store *Store
+	// Source names the provider and repository of Finder, e.g. "github
+	// octo/app".
+	Source string
+	// Finder may be nil Offline.
+	Finder contributors.LoginFinder
+	Mode   Mode
+	// Misses counts the lookups Offline found no record for; Fetched counts
+	// those asked of Finder and recorded.
+	Misses, Fetched int
+}
+
+// CommitAuthor returns the login of the author of the commit hash. Offline,
+// a commit the store has no record of has no login.
+func (c *Logins) CommitAuthor(ctx context.Context, hash string) (string, error) {
+	key := AuthorKey(c.Source, hash)
+	var login string
+	if c.Mode != Refresh {
+		ok, err := c.Store.Get(key, &login)
+		if err != nil || ok {
+			return login, err
+		}
+	}
+	if c.Mode == Offline || c.Finder == nil {
+		c.Misses++
+		return "", nil
+	}
+	login, err := c.Finder.CommitAuthor(ctx, hash)
+	if err != nil {
+		return login, err
+	}
+	c.Fetched++
+	return login, c.Store.Put(key, login)
+}
+
+// CommitAuthors returns the logins of the authors of hashes by hash, asking
+// Finder for those the store does not have at once when it is a
+// contributors.BatchLoginFinder. Offline, the commits the store has no
+// record of are left out.
+func (c *Logins) CommitAuthors(ctx context.Context, hashes []string) (map[string]string, error) {
+	found := make(map[string]string, len(hashes))
+	var ask []string
+	for _, h := range hashes {
+		if c.Mode != Refresh {
+			var login string
+			ok, err := c.Store.Get(AuthorKey(c.Source, h), &login)
+			if err != nil {
+				return found, err
+			}
+			if ok {
+				found[h] = login
+				continue
+			}
+		}
+		ask = append(ask, h)
+	}
+	if len(ask) == 0 {
+		return found, nil
+	}
+	if c.Mode == Offline || c.Finder == nil {
+		c.Misses += len(ask)
+		return found, nil
+	}
+	bf, ok := c.Finder.(contributors.BatchLoginFinder)
+	if !ok {
+		for _, h := range ask {
+			login, err := c.Finder.CommitAuthor(ctx, h)
+			if err != nil {
+				return found, err
+			}
+			c.Fetched++
+			found[h] = login
+			if err := c.Store.Put(AuthorKey(c.Source, h), login); err != nil {
+				return found, err
+			}
+		}
+		return found, nil
+	}
+	fetched, err := bf.CommitAuthors(ctx, ask)
+	for h, login := range fetched {
+		c.Fetched++
+		found[h] = login
+		if perr := c.Store.Put(AuthorKey(c.Source, h), login); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	return found, err
+}
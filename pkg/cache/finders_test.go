@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+type fakeFinder struct {
+	asked int
+	err   error
+}
+
+func (f *fakeFinder) Issue(_ context.Context, n int) (references.Reference, error) {
+	f.asked++
+	return references.Reference{Number: n, Title: fmt.Sprintf("issue %d (%d)", n, f.asked)}, f.err
+}
+
+func (f *fakeFinder) CommitAuthor(_ context.Context, hash string) (string, error) {
+	f.asked++
+	if hash == "noaccount" {
+		return "", f.err
+	}
+	return "login-" + hash, f.err
+}
+
+func TestReferences(t *testing.T) {
+	ctx := context.Background()
+	s := &Store{Dir: t.TempDir()}
+	f := &fakeFinder{}
+	c := &References{Store: s, Source: "github octo/app", Finder: f}
+
+	for range 2 {
+		r, err := c.Issue(ctx, 7)
+		if err != nil || r.Title != "issue 7 (1)" {
+			t.Errorf("Expected %q, got %q (err=%v)", "issue 7 (1)", r.Title, err)
+		}
+	}
+	if f.asked != 1 || c.Fetched != 1 {
+		t.Errorf("Expected the second lookup from the store, got %d requests", f.asked)
+	}
+
+	c.Mode = Refresh
+	if r, _ := c.Issue(ctx, 7); r.Title != "issue 7 (2)" || f.asked != 2 {
+		t.Errorf("Expected a refresh to ask again, got %q after %d requests", r.Title, f.asked)
+	}
+
+	offline := &References{Store: s, Source: "github octo/app", Mode: Offline}
+	if r, err := offline.Issue(ctx, 7); err != nil || r.Title != "issue 7 (2)" {
+		t.Errorf("Expected the refreshed record offline, got %q (err=%v)", r.Title, err)
+	}
+	if _, err := offline.Issue(ctx, 8); !errors.Is(err, references.ErrUnavailable) || offline.Misses != 1 {
+		t.Errorf("Expected a miss offline, got %v and %d misses", err, offline.Misses)
+	}
+
+	f.err = errors.New("boom")
+	c.Mode = ReadThrough
+	if _, err := c.Issue(ctx, 9); err == nil {
+		t.Errorf("Expected the error of the provider")
+	}
+	if ok, _ := s.Get(IssueKey("github octo/app", 9), &references.Reference{}); ok {
+		t.Errorf("Expected a failed lookup not to be recorded")
+	}
+}
+
+func TestLogins(t *testing.T) {
+	ctx := context.Background()
+	s := &Store{Dir: t.TempDir()}
+	f := &fakeFinder{}
+	c := &Logins{Store: s, Source: "github octo/app", Finder: f}
+
+	for _, hash := range []string{"a1", "noaccount", "a1", "noaccount"} {
+		if _, err := c.CommitAuthor(ctx, hash); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if f.asked != 2 {
+		t.Errorf("Expected authors without an account to be recorded too, got %d requests", f.asked)
+	}
+
+	offline := &Logins{Store: s, Source: "github octo/app", Mode: Offline}
+	if login, err := offline.CommitAuthor(ctx, "a1"); err != nil || login != "login-a1" {
+		t.Errorf("Expected %q, got %q (err=%v)", "login-a1", login, err)
+	}
+	if login, err := offline.CommitAuthor(ctx, "b2"); err != nil || login != "" || offline.Misses != 1 {
+		t.Errorf("Expected no login for a miss, got %q, %v and %d misses", login, err, offline.Misses)
+	}
+}
+
+type batchFinder struct {
+	fakeFinder
+	batches int
+	asks    []int
+}
+
+func (f *batchFinder) Issues(_ context.Context, ns []int) (map[int]references.Reference, error) {
+	f.batches++
+	f.asks = append(f.asks, ns...)
+	m := make(map[int]references.Reference)
+	for _, n := range ns {
+		if n != 404 {
+			m[n] = references.Reference{Number: n, Title: fmt.Sprintf("issue %d", n)}
+		}
+	}
+	return m, nil
+}
+
+func (f *batchFinder) CommitAuthors(_ context.Context, hashes []string) (map[string]string, error) {
+	f.batches++
+	m := make(map[string]string)
+	for _, h := range hashes {
+		m[h] = "login-" + h
+	}
+	return m, nil
+}
+
+func TestBatches(t *testing.T) {
+	ctx := context.Background()
+	s := &Store{Dir: t.TempDir()}
+	if err := s.Put(IssueKey("github octo/app", 7), references.Reference{Number: 7, Title: "cached"}); err != nil {
+		t.Fatal(err)
+	}
+	f := &batchFinder{}
+	c := &References{Store: s, Source: "github octo/app", Finder: f}
+	got, err := c.Issues(ctx, []int{7, 8, 404})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[7].Title != "cached" || got[8].Title != "issue 8" {
+		t.Errorf("Expected the cached and fetched issues, got %+v", got)
+	}
+	if f.batches != 1 || len(f.asks) != 2 || c.Fetched != 1 {
+		t.Errorf("Expected one batch of the 2 numbers not cached, got %d batches of %v", f.batches, f.asks)
+	}
+
+	offline := &References{Store: s, Source: "github octo/app", Mode: Offline}
+	if got, err := offline.Issues(ctx, []int{8, 9, 404}); err != nil || len(got) != 1 || offline.Misses != 2 {
+		t.Errorf("Expected 1 issue and 2 misses offline, got %+v, %v and %d misses", got, err, offline.Misses)
+	}
+
+	single := &References{Store: s, Source: "github octo/app", Finder: &fakeFinder{}, Mode: Refresh}
+	if got, err := single.Issues(ctx, []int{7, 9}); err != nil || got[7].Title != "issue 7 (1)" || single.Fetched != 2 {
+		t.Errorf("Expected each number asked of a plain finder, got %+v and %v", got, err)
+	}
+
+	logins := &Logins{Store: s, Source: "github octo/app", Finder: f}
+	for range 2 {
+		got, err := logins.CommitAuthors(ctx, []string{"a1", "b2"})
+		if err != nil || got["b2"] != "login-b2" {
+			t.Errorf("Expected %q, got %+v (err=%v)", "login-b2", got, err)
+		}
+	}
+	if f.batches != 2 || logins.Fetched != 2 {
+		t.Errorf("Expected the second lookup from the store, got %d batches", f.batches)
+	}
+}
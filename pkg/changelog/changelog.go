@@ -0,0 +1,248 @@
+// Package changelog builds and renders CHANGELOG.md sections from parsed
+// conventional commits.
+//
+// The default output follows the Keep a Changelog layout
+// (https://keepachangelog.com): one "## [version] - date" section per
+// release, with "### Group" headings and one bullet per change.
+package changelog
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+// BreakingTitle is the group title used for breaking changes, regardless of
+// their commit type.
+const BreakingTitle = "Breaking Changes"
+
+// Section maps a set of commit types to a changelog group title.
+type Section struct {
+	Title string
+	Types []string
+}
+
+// DefaultSections mirrors the groups documented in README.md and produced by
+// .cliff.toml. Breaking changes are always collected under BreakingTitle first.
+var DefaultSections = []Section{
+	{Title: "Features", Types: []string{"feat"}},
+	{Title: "Bug Fixes", Types: []string{"fix"}},
+	{Title: "Refactoring", Types: []string{"refactor"}},
+	{Title: "Documentation", Types: []string{"docs"}},
+	{Title: "Testing", Types: []string{"test"}},
+	{Title: "Build / CI", Types: []string{"build", "ci"}},
+}
+
+// DefaultLabelSections group commits by the labels of their pull requests
+// when Options.ByLabel is set: the Types of each Section are label names.
+var DefaultLabelSections = []Section{
+	{Title: "Features", Types: []string{"feature", "enhancement"}},
+	{Title: "Bug Fixes", Types: []string{"bug", "fix"}},
+	{Title: "Documentation", Types: []string{"documentation", "docs"}},
+	{Title: "Dependencies", Types: []string{"dependencies"}},
+}
+
+// OtherTitle is the group title for commits whose type matches no Section.
+const OtherTitle = "Other Changes"
+
+// Trailers authors write at commit time to place and word the entry of
+// their commit: SectionTrailer names its group, existing or added after
+// the others, and NoteTrailer replaces its description with user-facing
+// text.
+const (
+	SectionTrailer = "Changelog-Section"
+	NoteTrailer    = "Release-Note"
+)
+
+// Group is a titled list of commits within a release.
+type Group struct {
+	Title   string
+	Commits []Entry
+}
+
+// Entry is a commit listed in a Group, with the issues and pull requests
+// it references when they were resolved (see Options.References).
+type Entry struct {
+	commits.Commit
+	References []references.Reference
+}
+
+// PullRequest returns the first pull request e references, or nil.
+func (e Entry) PullRequest() *references.Reference {
+	for i, r := range e.References {
+		if r.PullRequest {
+			return &e.References[i]
+		}
+	}
+	return nil
+}
+
+// Summary returns the description without the trailing "(#123)" naming
+// the pull request, which templates link on their own.
+func (e Entry) Summary() string {
+	if pr := e.PullRequest(); pr != nil {
+		return strings.TrimSpace(strings.TrimSuffix(e.Description, "(#"+strconv.Itoa(pr.Number)+")"))
+	}
+	return e.Description
+}
+
+// labels returns the labels of the references of e, those of pull
+// requests first.
+func (e Entry) labels() []string {
+	var labels []string
+	for _, pr := range []bool{true, false} {
+		for _, r := range e.References {
+			if r.PullRequest == pr {
+				labels = append(labels, r.Labels...)
+			}
+		}
+	}
+	return labels
+}
+
+// Release is the changelog model for a single version.
+type Release struct {
+	// Version is the bare version string. An empty Version renders as
+	// "Unreleased".
+	Version string
+	Date    time.Time
+	Groups  []Group
+	// Contributors are listed after the groups when set; New leaves them
+	// for the caller to fill in with contributors.List.
+	Contributors []contributors.Contributor
+	// UpgradeGuide links the upgrade guide of the release from its
+	// breaking changes when set; New leaves it empty.
+	UpgradeGuide string
+}
+
+// Options controls how commits are grouped.
+type Options struct {
+	// Sections defaults to DefaultSections when nil, or to
+	// DefaultLabelSections with ByLabel.
+	Sections []Section
+	// HideOther drops commits that match no section instead of collecting
+	// them under OtherTitle.
+	HideOther bool
+	// References are the issues and pull requests of the commits, by
+	// hash, as returned by references.Resolve.
+	References map[string][]references.Reference
+	// ByLabel groups commits by the labels of their references instead of
+	// their types: a commit joins the first section naming one of them.
+	ByLabel bool
+	// Classifier overrides the group of the commits its rules match: a
+	// rule's Section names the group, existing or added after Sections,
+	// and a Hidden rule leaves the commits out.
+	Classifier commits.Classifier
+}
+
+// New groups cs into a Release. Groups keep the order of opts.Sections,
+// followed by those the SectionTrailer of commits and the rules of
+// opts.Classifier add, and commits keep their input order; empty groups
+// are omitted. A SectionTrailer takes precedence over the rules and the
+// type, but breaking changes stay under BreakingTitle; a NoteTrailer
+// replaces the description of the entry. Release commits ("chore(release):
+// ...") are never listed.
+func New(version string, date time.Time, cs []commits.Commit, opts Options) Release {
+	sections := opts.Sections
+	switch {
+	case sections == nil && opts.ByLabel:
+		sections = DefaultLabelSections
+	case sections == nil:
+		sections = DefaultSections
+	}
+
+	byType := make(map[string]int)
+	for i, s := range sections {
+		for _, t := range s.Types {
+			byType[t] = i
+		}
+	}
+
+	breaking := Group{Title: BreakingTitle}
+	grouped := make([]Group, len(sections))
+	for i, s := range sections {
+		grouped[i].Title = s.Title
+	}
+	other := Group{Title: OtherTitle}
+
+	for _, c := range cs {
+		if isReleaseCommit(c) {
+			continue
+		}
+		e := Entry{Commit: c, References: opts.References[c.Hash]}
+		if note, ok := c.Footer(NoteTrailer); ok && strings.TrimSpace(note) != "" {
+			e.Description = strings.Join(strings.Fields(note), " ")
+		}
+		if c.Breaking {
+			breaking.Commits = append(breaking.Commits, e)
+			continue
+		}
+		if title, ok := c.Footer(SectionTrailer); ok && strings.TrimSpace(title) != "" {
+			grouped = addTo(grouped, strings.TrimSpace(title), e)
+			continue
+		}
+		if r, ok := opts.Classifier.Match(c); ok && r.Hidden {
+			continue
+		} else if ok && r.Section != "" {
+			grouped = addTo(grouped, r.Section, e)
+			continue
+		}
+		if i, ok := section(e, byType, opts.ByLabel); ok {
+			grouped[i].Commits = append(grouped[i].Commits, e)
+			continue
+		}
+		if !opts.HideOther {
+			other.Commits = append(other.Commits, e)
+		}
+	}
+
+	r := Release{Version: version, Date: date}
+	for _, g := range append(append([]Group{breaking}, grouped...), other) {
+		if len(g.Commits) > 0 {
+			r.Groups = append(r.Groups, g)
+		}
+	}
+	return r
+}
+
+// addTo appends e to the group titled title, case aside, adding the group
+// after the others when there is none.
+func addTo(groups []Group, title string, e Entry) []Group {
+	i := slices.IndexFunc(groups, func(g Group) bool { return strings.EqualFold(g.Title, title) })
+	if i < 0 {
+		i = len(groups)
+		groups = append(groups, Group{Title: title})
+	}
+	groups[i].Commits = append(groups[i].Commits, e)
+	return groups
+}
+
+// section returns the index of the section e belongs to, by type or, with
+// byLabel, by the labels of its references.
+func section(e Entry, byType map[string]int, byLabel bool) (int, bool) {
+	if !byLabel {
+		i, ok := byType[e.Type]
+		return i, ok
+	}
+	best := -1
+	for _, l := range e.labels() {
+		if i, ok := byType[l]; ok && (best < 0 || i < best) {
+			best = i
+		}
+	}
+	return best, best >= 0
+}
+
+// Empty reports whether the release has no changes to list.
+func (r Release) Empty() bool {
+	return len(r.Groups) == 0
+}
+
+func isReleaseCommit(c commits.Commit) bool {
+	return c.Type == "chore" && c.Scope == "release"
+}
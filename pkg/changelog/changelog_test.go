@@ -0,0 +1,188 @@
+package changelog
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+func mustParse(t *testing.T, hash, message string) commits.Commit {
+	t.Helper()
+	c, err := commits.Parse(message)
+	if err != nil {
+		t.Fatalf("Parse(%q): unexpected error: %v", message, err)
+	}
+	c.Hash = hash
+	return c
+}
+
+func TestNewGroupsByType(t *testing.T) {
+	cs := []commits.Commit{
+		mustParse(t, "a1", "fix: first fix"),
+		mustParse(t, "a2", "feat: a feature"),
+		mustParse(t, "a3", "feat!: breaking feature"),
+		mustParse(t, "a4", "chore: tidy"),
+		mustParse(t, "a5", "fix(api): second fix"),
+		mustParse(t, "a6", "chore(release): v1.0.0"),
+	}
+
+	r := New("1.0.0", time.Time{}, cs, Options{})
+
+	expected := []struct {
+		title  string
+		hashes []string
+	}{
+		{BreakingTitle, []string{"a3"}},
+		{"Features", []string{"a2"}},
+		{"Bug Fixes", []string{"a1", "a5"}},
+		{OtherTitle, []string{"a4"}},
+	}
+
+	if len(r.Groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %d: %#v", len(expected), len(r.Groups), r.Groups)
+	}
+	for i, e := range expected {
+		g := r.Groups[i]
+		if g.Title != e.title {
+			t.Errorf("group %d: expected title %q, got %q", i, e.title, g.Title)
+		}
+		if len(g.Commits) != len(e.hashes) {
+			t.Errorf("group %q: expected %d commits, got %d", g.Title, len(e.hashes), len(g.Commits))
+			continue
+		}
+		for j, h := range e.hashes {
+			if g.Commits[j].Hash != h {
+				t.Errorf("group %q: expected commit %s at %d, got %s", g.Title, h, j, g.Commits[j].Hash)
+			}
+		}
+	}
+}
+
+func TestNewCustomSectionsHideOther(t *testing.T) {
+	cs := []commits.Commit{
+		mustParse(t, "", "perf: faster"),
+		mustParse(t, "", "chore: tidy"),
+	}
+
+	r := New("", time.Time{}, cs, Options{
+		Sections:  []Section{{Title: "Performance", Types: []string{"perf"}}},
+		HideOther: true,
+	})
+
+	if len(r.Groups) != 1 || r.Groups[0].Title != "Performance" {
+		t.Errorf("Expected a single Performance group, got %#v", r.Groups)
+	}
+}
+
+func TestNewClassifier(t *testing.T) {
+	cs := []commits.Commit{
+		mustParse(t, "a1", "perf: faster"),
+		mustParse(t, "a2", "deps: bump yaml"),
+		mustParse(t, "a3", "fix: crash"),
+		mustParse(t, "a4", "chore(security): pin actions"),
+		mustParse(t, "a5", "deps!: require yaml v4"),
+	}
+
+	r := New("1.0.0", time.Time{}, cs, Options{Classifier: commits.Classifier{Rules: []commits.ClassRule{
+		{Pattern: regexp.MustCompile(`^perf:`), Section: "Performance"},
+		{Pattern: regexp.MustCompile(`^deps:`), Hidden: true},
+		{Pattern: regexp.MustCompile(`^chore\(security\):`), Section: "Bug Fixes"},
+	}}})
+
+	var result []string
+	for _, g := range r.Groups {
+		for _, e := range g.Commits {
+			result = append(result, g.Title+" "+e.Hash)
+		}
+	}
+	expected := []string{BreakingTitle + " a5", "Bug Fixes a3", "Bug Fixes a4", "Performance a1"}
+	if !slices.Equal(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestNewTrailers(t *testing.T) {
+	cs := []commits.Commit{
+		mustParse(t, "a1", "fix(auth): escape the redirect parameter\n\nChangelog-Section: Security\nRelease-Note: Login redirects can no longer\n  point at other sites."),
+		mustParse(t, "a2", "deps: bump yaml\n\nChangelog-Section: bug fixes"),
+		mustParse(t, "a3", "feat: batch API\n\nRelease-Note: Requests can be sent in batches of 100."),
+		mustParse(t, "a4", "feat!: drop v1\n\nChangelog-Section: Security"),
+		mustParse(t, "a5", "fix: crash\n\nRelease-Note:  "),
+	}
+
+	r := New("1.0.0", time.Time{}, cs, Options{Classifier: commits.Classifier{Rules: []commits.ClassRule{
+		{Pattern: regexp.MustCompile(`^deps:`), Hidden: true},
+	}}})
+
+	var result []string
+	for _, g := range r.Groups {
+		for _, e := range g.Commits {
+			result = append(result, g.Title+" "+e.Hash+" "+e.Summary())
+		}
+	}
+	expected := []string{
+		BreakingTitle + " a4 drop v1",
+		"Features a3 Requests can be sent in batches of 100.",
+		"Bug Fixes a2 bump yaml",
+		"Bug Fixes a5 crash",
+		"Security a1 Login redirects can no longer point at other sites.",
+	}
+	if !slices.Equal(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestNewByLabel(t *testing.T) {
+	cs := []commits.Commit{
+		mustParse(t, "a1", "chore: handle empty tags (#12)"),
+		mustParse(t, "a2", "feat: add notes"),
+		mustParse(t, "a3", "fix!: drop v1 tags\n\nFixes #7"),
+		mustParse(t, "a4", "docs: typo\n\nRefs #9"),
+	}
+	refs := map[string][]references.Reference{
+		"a1": {{Number: 12, Labels: []string{"bug"}, PullRequest: true}},
+		"a3": {{Number: 7, Labels: []string{"bug"}}},
+		"a4": {{Number: 9, Labels: []string{"documentation"}}, {Number: 10, Labels: []string{"enhancement"}, PullRequest: true}},
+	}
+
+	r := New("1.0.0", time.Time{}, cs, Options{References: refs, ByLabel: true})
+
+	expected := []struct {
+		title  string
+		hashes []string
+	}{
+		{BreakingTitle, []string{"a3"}},
+		{"Features", []string{"a4"}},
+		{"Bug Fixes", []string{"a1"}},
+		{OtherTitle, []string{"a2"}},
+	}
+	if len(r.Groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %d: %#v", len(expected), len(r.Groups), r.Groups)
+	}
+	for i, e := range expected {
+		g := r.Groups[i]
+		if g.Title != e.title || len(g.Commits) != len(e.hashes) || g.Commits[0].Hash != e.hashes[0] {
+			t.Errorf("group %d: expected %s %v, got %s %#v", i, e.title, e.hashes, g.Title, g.Commits)
+		}
+	}
+	if pr := r.Groups[2].Commits[0].PullRequest(); pr == nil || pr.Number != 12 {
+		t.Errorf("Expected pull request #12, got %#v", pr)
+	}
+	if got := r.Groups[2].Commits[0].Summary(); got != "handle empty tags" {
+		t.Errorf("Expected %q, got %q", "handle empty tags", got)
+	}
+	if pr := r.Groups[3].Commits[0].PullRequest(); pr != nil {
+		t.Errorf("Expected no pull request, got %#v", pr)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	r := New("1.0.0", time.Time{}, []commits.Commit{mustParse(t, "", "chore(release): v1.0.0")}, Options{})
+	if !r.Empty() {
+		t.Errorf("Expected release to be empty, got %#v", r.Groups)
+	}
+}
@@ -0,0 +1,116 @@
+package changelog
+
+import (
+	"bytes"
+	"strings"
+)
+
+// DefaultHeader is written at the top of a new changelog file.
+const DefaultHeader = "# Changelog\n\nAll notable changes to this project will be documented in this file.\n"
+
+// Prepend inserts section into existing changelog content, above the most
+// recent release section and below any header text. When existing is empty,
+// DefaultHeader is used.
+func Prepend(existing, section []byte) []byte {
+	section = bytes.TrimRight(section, "\n")
+
+	if len(bytes.TrimSpace(existing)) == 0 {
+		existing = []byte(DefaultHeader)
+	}
+
+	header, releases := splitHeader(existing)
+	if len(bytes.TrimSpace(header)) == 0 {
+		header = []byte(DefaultHeader)
+	}
+
+	var out bytes.Buffer
+	out.Write(bytes.TrimRight(header, "\n"))
+	out.WriteString("\n\n")
+	out.Write(section)
+	out.WriteString("\n")
+	if len(releases) > 0 {
+		out.WriteString("\n")
+		out.Write(releases)
+		if !bytes.HasSuffix(releases, []byte("\n")) {
+			out.WriteString("\n")
+		}
+	}
+	return out.Bytes()
+}
+
+// Rebuild returns a changelog holding sections, newest first, below the
+// header of existing. The release sections of existing are dropped; when it
+// has no header, DefaultHeader is used.
+func Rebuild(existing []byte, sections [][]byte) []byte {
+	out, _ := splitHeader(existing)
+	if len(bytes.TrimSpace(out)) == 0 {
+		out = []byte(DefaultHeader)
+	}
+	for i := len(sections) - 1; i >= 0; i-- {
+		out = Prepend(out, sections[i])
+	}
+	return out
+}
+
+// splitHeader splits content at the first level-two heading.
+func splitHeader(content []byte) (header, releases []byte) {
+	if bytes.HasPrefix(content, []byte("## ")) {
+		return nil, content
+	}
+	if i := bytes.Index(content, []byte("\n## ")); i >= 0 {
+		return content[:i+1], content[i+1:]
+	}
+	return content, nil
+}
+
+// Extract returns the section for version from changelog content, from its
+// "## [version]" heading up to the next level-two heading. It mirrors the awk
+// extraction used by the CI workflows.
+func Extract(content []byte, version string) (string, bool) {
+	var (
+		out   bytes.Buffer
+		found bool
+	)
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("## ")) {
+			if found {
+				break
+			}
+			found = headingVersion(line) == version
+		}
+		if found {
+			out.Write(line)
+		}
+	}
+	return strings.TrimRight(out.String(), "\n") + "\n", found
+}
+
+// headingVersion returns the version from a "## [1.2.3] - date" heading.
+func headingVersion(line []byte) string {
+	h := strings.TrimSpace(strings.TrimPrefix(string(line), "## "))
+	h, _, _ = strings.Cut(h, " ")
+	return strings.TrimPrefix(strings.Trim(h, "[]"), "v")
+}
+
+// Sections splits changelog content into its header, the text above the
+// first level-two heading, and its release sections, in file order.
+func Sections(content []byte) (header []byte, sections [][]byte) {
+	header, releases := splitHeader(content)
+	for len(releases) > 0 {
+		i := bytes.Index(releases, []byte("\n## "))
+		if i < 0 {
+			sections = append(sections, releases)
+			break
+		}
+		sections = append(sections, releases[:i+1])
+		releases = releases[i+1:]
+	}
+	return header, sections
+}
+
+// SectionVersion returns the version of the "## [1.2.3] - date" heading
+// starting section, or "Unreleased" for an unreleased section.
+func SectionVersion(section []byte) string {
+	line, _, _ := bytes.Cut(section, []byte("\n"))
+	return headingVersion(line)
+}
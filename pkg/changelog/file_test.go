@@ -0,0 +1,94 @@
+package changelog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrependEmpty(t *testing.T) {
+	result := string(Prepend(nil, []byte("## [1.0.0]\n\n- first\n")))
+
+	expected := DefaultHeader + "\n## [1.0.0]\n\n- first\n"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestPrependExisting(t *testing.T) {
+	existing := "# Changelog\n\nIntro text.\n\n## [1.0.0] - 2026-01-01\n\n- first\n"
+
+	result := string(Prepend([]byte(existing), []byte("## [1.1.0] - 2026-02-01\n\n- second\n\n")))
+
+	expected := "# Changelog\n\nIntro text.\n\n## [1.1.0] - 2026-02-01\n\n- second\n\n## [1.0.0] - 2026-01-01\n\n- first\n"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestPrependWithoutHeader(t *testing.T) {
+	result := string(Prepend([]byte("## [1.0.0]\n- first"), []byte("## [1.1.0]\n- second")))
+
+	expected := DefaultHeader + "\n## [1.1.0]\n- second\n\n## [1.0.0]\n- first\n"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	existing := "# Changelog\n\nIntro text.\n\n## [0.1.0] - 2025-01-01\n\n- stale\n"
+	sections := [][]byte{[]byte("## [1.1.0]\n\n- second\n"), []byte("## [1.0.0]\n\n- first\n")}
+
+	result := string(Rebuild([]byte(existing), sections))
+	expected := "# Changelog\n\nIntro text.\n\n## [1.1.0]\n\n- second\n\n## [1.0.0]\n\n- first\n"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	if result := string(Rebuild(nil, sections[1:])); result != DefaultHeader+"\n## [1.0.0]\n\n- first\n" {
+		t.Errorf("Expected the default header, got %q", result)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	content := DefaultHeader + "\n## [1.1.0] - 2026-02-01\n\n- second\n\n## [1.0.0] - 2026-01-01\n\n- first\n"
+
+	result, ok := Extract([]byte(content), "1.1.0")
+	if !ok {
+		t.Fatalf("Expected section for 1.1.0 to be found")
+	}
+	if result != "## [1.1.0] - 2026-02-01\n\n- second\n" {
+		t.Errorf("Unexpected section %q", result)
+	}
+
+	result, ok = Extract([]byte(content), "1.0.0")
+	if !ok || result != "## [1.0.0] - 2026-01-01\n\n- first\n" {
+		t.Errorf("Unexpected section %q (ok=%v)", result, ok)
+	}
+
+	if _, ok := Extract([]byte(content), "1.0"); ok {
+		t.Errorf("Expected no section for a version prefix")
+	}
+}
+
+func TestSections(t *testing.T) {
+	content := "# Changelog\n\nIntro text.\n\n## [Unreleased]\n\n- next\n\n## [1.1.0] - 2026-02-01\n\n- second\n\n## v1.0.0\n\n- first\n"
+
+	header, sections := Sections([]byte(content))
+	if string(header) != "# Changelog\n\nIntro text.\n\n" {
+		t.Errorf("Unexpected header %q", header)
+	}
+	var versions []string
+	for _, s := range sections {
+		versions = append(versions, SectionVersion(s))
+	}
+	if got := strings.Join(versions, " "); got != "Unreleased 1.1.0 1.0.0" {
+		t.Errorf("Expected versions Unreleased 1.1.0 1.0.0, got %s", got)
+	}
+	if string(sections[1]) != "## [1.1.0] - 2026-02-01\n\n- second\n\n" {
+		t.Errorf("Unexpected section %q", sections[1])
+	}
+	if rejoined := string(header) + string(bytes.Join(sections, nil)); rejoined != content {
+		t.Errorf("Expected the sections to rejoin to %q, got %q", content, rejoined)
+	}
+}
@@ -0,0 +1,44 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// Files reads and writes changelog files. The rest of the package does no
+// I/O, so that it runs where there is no file system, such as WASM in a
+// browser, which passes Files of its own or none.
+type Files interface {
+	// ReadFile fails with an error matching fs.ErrNotExist for a missing
+	// file.
+	ReadFile(path string) ([]byte, error)
+	WriteFile(path string, data []byte) error
+}
+
+// OSFiles are the files of the host.
+type OSFiles struct{}
+
+func (OSFiles) ReadFile(path string) ([]byte, error) { return os.ReadFile(path) }
+
+func (OSFiles) WriteFile(path string, data []byte) error { return os.WriteFile(path, data, 0o644) }
+
+// PrependFile prepends section to the changelog at path, creating the file
+// with DefaultHeader if it does not exist.
+func PrependFile(path string, section []byte) error {
+	return PrependTo(OSFiles{}, path, section)
+}
+
+// PrependTo is PrependFile on files.
+func PrependTo(files Files, path string, section []byte) error {
+	existing, err := files.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("changelog: read %s: %w", path, err)
+	}
+
+	if err := files.WriteFile(path, Prepend(existing, section)); err != nil {
+		return fmt.Errorf("changelog: write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package changelog
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrependFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	if err := PrependFile(path, []byte("## [1.0.0]\n\n- first\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := PrependFile(path, []byte("## [1.1.0]\n\n- second\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := DefaultHeader + "\n## [1.1.0]\n\n- second\n\n## [1.0.0]\n\n- first\n"
+	if string(result) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(result))
+	}
+}
+
+// memFiles keeps files in memory, as a browser would.
+type memFiles map[string][]byte
+
+func (m memFiles) ReadFile(path string) ([]byte, error) {
+	data, ok := m[path]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return data, nil
+}
+
+func (m memFiles) WriteFile(path string, data []byte) error {
+	m[path] = data
+	return nil
+}
+
+func TestPrependTo(t *testing.T) {
+	files := memFiles{}
+	if err := PrependTo(files, "CHANGELOG.md", []byte("## [1.0.0]\n\n- first\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := DefaultHeader + "\n## [1.0.0]\n\n- first\n"
+	if string(files["CHANGELOG.md"]) != expected {
+		t.Errorf("Expected %q, got %q", expected, files["CHANGELOG.md"])
+	}
+
+	err := PrependTo(failingFiles{}, "CHANGELOG.md", nil)
+	if err == nil || !strings.Contains(err.Error(), "changelog: read CHANGELOG.md: denied") {
+		t.Errorf("Expected the read to fail, got %v", err)
+	}
+}
+
+type failingFiles struct{}
+
+func (failingFiles) ReadFile(string) ([]byte, error) { return nil, errors.New("denied") }
+func (failingFiles) WriteFile(string, []byte) error  { return errors.New("denied") }
+
+// TestWASMDeps keeps the engines buildable for browsers: without processes
+// or network connections among their dependencies.
+func TestWASMDeps(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	cmd := exec.Command("go", "list", "-deps", "../version", "../commits", ".")
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go list: %v: %s", err, out)
+	}
+	for _, dep := range strings.Fields(string(out)) {
+		if dep == "os/exec" || dep == "net" || dep == "net/http" {
+			t.Errorf("Expected no %s among the dependencies of the engines", dep)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+)
+
+// Formats lists the output formats of NewRenderer; Markdown is the default.
+var Formats = []string{"markdown", "asciidoc", "html", "json"}
+
+// NewRenderer returns the renderer of format, one of Formats.
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case "", "markdown":
+		return DefaultRenderer(), nil
+	case "asciidoc":
+		return AsciiDocRenderer(), nil
+	case "html":
+		return HTMLRenderer(), nil
+	case "json":
+		return JSONRenderer{}, nil
+	}
+	return nil, fmt.Errorf("changelog: unknown format %q: must be one of %s", format, strings.Join(Formats, ", "))
+}
+
+// AsciiDocTemplate renders a section with the layout of DefaultTemplate in
+// AsciiDoc, for documentation sites built with Asciidoctor or Antora.
+const AsciiDocTemplate = `== {{ if .Version }}{{ .Version }}{{ else }}Unreleased{{ end }}{{ if not .Date.IsZero }} - {{ .Date.Format "2006-01-02" }}{{ end }}
+{{ range .Groups }}
+=== {{ .Title }}
+
+{{ range .Commits }}* {{ if .Scope }}*{{ .Scope }}:* {{ end }}{{ .Summary }}{{ with .PullRequest }} ({{ .URL }}[#{{ .Number }}]{{ if .Author }} by @{{ .Author }}{{ end }}){{ end }}{{ if .Hash }} ({{ shortHash .Hash }}){{ end }}
+{{ end }}{{ end }}{{ if .Contributors }}
+=== Contributors
+
+{{ range .Contributors }}* {{ if .Login }}{{ .URL }}[{{ .Handle }}]{{ else }}{{ .Name }}{{ end }}
+{{ end }}{{ end }}`
+
+// AsciiDocRenderer returns a TemplateRenderer for AsciiDocTemplate.
+func AsciiDocRenderer() *TemplateRenderer {
+	r, err := NewTemplateRenderer(AsciiDocTemplate)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// HTMLTemplate renders a standalone HTML page of a section. Commit text is
+// escaped by html/template.
+const HTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ if .Version }}{{ .Version }}{{ else }}Unreleased{{ end }}</title>
+</head>
+<body>
+<h2>{{ if .Version }}{{ .Version }}{{ else }}Unreleased{{ end }}{{ if not .Date.IsZero }} - <time datetime="{{ .Date.Format "2006-01-02" }}">{{ .Date.Format "2006-01-02" }}</time>{{ end }}</h2>
+{{ range .Groups }}<h3>{{ .Title }}</h3>
+<ul>
+{{ range .Commits }}<li>{{ if .Scope }}<strong>{{ .Scope }}:</strong> {{ end }}{{ .Summary }}{{ with .PullRequest }} (<a href="{{ .URL }}">#{{ .Number }}</a>{{ if .Author }} by @{{ .Author }}{{ end }}){{ end }}{{ if .Hash }} (<code>{{ shortHash .Hash }}</code>){{ end }}</li>
+{{ end }}</ul>
+{{ end }}{{ if .Contributors }}<h3>Contributors</h3>
+<ul>
+{{ range .Contributors }}<li>{{ if .Login }}<a href="{{ .URL }}">{{ .Handle }}</a>{{ else }}{{ .Name }}{{ end }}</li>
+{{ end }}</ul>
+{{ end }}</body>
+</html>
+`
+
+// HTMLTemplateRenderer renders releases with an html/template, which
+// escapes the text of commits and references.
+type HTMLTemplateRenderer struct {
+	tmpl *htmltemplate.Template
+}
+
+// HTMLRenderer returns an HTMLTemplateRenderer for HTMLTemplate.
+func HTMLRenderer() *HTMLTemplateRenderer {
+	tmpl := htmltemplate.Must(htmltemplate.New("changelog").Funcs(htmltemplate.FuncMap(FuncMap)).Parse(HTMLTemplate))
+	return &HTMLTemplateRenderer{tmpl: tmpl}
+}
+
+// Render executes the template for r.
+func (h *HTMLTemplateRenderer) Render(w io.Writer, r Release) error {
+	if err := h.tmpl.Execute(w, r); err != nil {
+		return fmt.Errorf("changelog: render %s: %w", displayVersion(r), err)
+	}
+	return nil
+}
+
+// JSONRenderer writes a Release as an indented JSON document for
+// downstream tooling.
+type JSONRenderer struct{}
+
+type jsonRelease struct {
+	Version      string            `json:"version,omitempty"`
+	Date         string            `json:"date,omitempty"`
+	Groups       []jsonGroup       `json:"groups"`
+	Contributors []jsonContributor `json:"contributors,omitempty"`
+}
+
+type jsonGroup struct {
+	Title   string      `json:"title"`
+	Entries []jsonEntry `json:"entries"`
+}
+
+type jsonEntry struct {
+	Hash        string          `json:"hash,omitempty"`
+	Type        string          `json:"type"`
+	Scope       string          `json:"scope,omitempty"`
+	Description string          `json:"description"`
+	Breaking    bool            `json:"breaking,omitempty"`
+	References  []jsonReference `json:"references,omitempty"`
+}
+
+type jsonReference struct {
+	Number      int      `json:"number"`
+	Title       string   `json:"title,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	PullRequest bool     `json:"pull_request,omitempty"`
+}
+
+type jsonContributor struct {
+	Name    string `json:"name"`
+	Login   string `json:"login,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Commits int    `json:"commits,omitempty"`
+}
+
+// Render encodes r.
+func (JSONRenderer) Render(w io.Writer, r Release) error {
+	out := jsonRelease{Version: r.Version, Groups: []jsonGroup{}}
+	if !r.Date.IsZero() {
+		out.Date = r.Date.Format("2006-01-02")
+	}
+	for _, g := range r.Groups {
+		jg := jsonGroup{Title: g.Title, Entries: []jsonEntry{}}
+		for _, e := range g.Commits {
+			je := jsonEntry{Hash: e.Hash, Type: e.Type, Scope: e.Scope, Description: e.Summary(), Breaking: e.Breaking}
+			for _, ref := range e.References {
+				je.References = append(je.References, jsonReference(ref))
+			}
+			jg.Entries = append(jg.Entries, je)
+		}
+		out.Groups = append(out.Groups, jg)
+	}
+	for _, c := range r.Contributors {
+		out.Contributors = append(out.Contributors, jsonContributor{Name: c.Name, Login: c.Login, URL: c.URL(), Commits: c.Commits})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("changelog: render %s: %w", displayVersion(r), err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package changelog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+func formatsRelease(t *testing.T) Release {
+	t.Helper()
+	r := New("1.2.0", time.Date(2026, 2, 24, 0, 0, 0, 0, time.UTC), []commits.Commit{
+		mustParse(t, "5ff5e2c1d2", "fix: escape <html> (#12)"),
+		mustParse(t, "96435f9aaa", "feat(cli): add next command"),
+	}, Options{
+		References: map[string][]references.Reference{
+			"5ff5e2c1d2": {{Number: 12, URL: "https://github.com/octo/app/pull/12", Author: "ada", PullRequest: true}},
+		},
+	})
+	r.Contributors = []contributors.Contributor{{Name: "Ada Lovelace", Login: "ada", Commits: 2}}
+	return r
+}
+
+func TestAsciiDocRenderer(t *testing.T) {
+	result, err := RenderString(AsciiDocRenderer(), formatsRelease(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "== 1.2.0 - 2026-02-24\n" +
+		"\n" +
+		"=== Features\n" +
+		"\n" +
+		"* *cli:* add next command (96435f9)\n" +
+		"\n" +
+		"=== Bug Fixes\n" +
+		"\n" +
+		"* escape <html> (https://github.com/octo/app/pull/12[#12] by @ada) (5ff5e2c)\n" +
+		"\n" +
+		"=== Contributors\n" +
+		"\n" +
+		"* https://github.com/ada[@ada]\n"
+	if result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestHTMLRenderer(t *testing.T) {
+	result, err := RenderString(HTMLRenderer(), formatsRelease(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range []string{
+		"<!DOCTYPE html>",
+		`<h2>1.2.0 - <time datetime="2026-02-24">2026-02-24</time></h2>`,
+		"<li><strong>cli:</strong> add next command (<code>96435f9</code>)</li>",
+		`<li>escape &lt;html&gt; (<a href="https://github.com/octo/app/pull/12">#12</a> by @ada) (<code>5ff5e2c</code>)</li>`,
+		`<li><a href="https://github.com/ada">@ada</a></li>`,
+		"</html>\n",
+	} {
+		if !strings.Contains(result, s) {
+			t.Errorf("Expected %q in:\n%s", s, result)
+		}
+	}
+}
+
+func TestJSONRenderer(t *testing.T) {
+	result, err := RenderString(JSONRenderer{}, formatsRelease(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got jsonRelease
+	if err := json.Unmarshal([]byte(result), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Version != "1.2.0" || got.Date != "2026-02-24" {
+		t.Errorf("Expected 1.2.0 on 2026-02-24, got %s on %s", got.Version, got.Date)
+	}
+	if len(got.Groups) != 2 || got.Groups[1].Title != "Bug Fixes" {
+		t.Fatalf("Expected Features and Bug Fixes, got %+v", got.Groups)
+	}
+	fix := got.Groups[1].Entries[0]
+	if fix.Description != "escape <html>" || len(fix.References) != 1 || fix.References[0].Number != 12 {
+		t.Errorf("Expected the fix linking #12, got %+v", fix)
+	}
+	if len(got.Contributors) != 1 || got.Contributors[0].URL != "https://github.com/ada" {
+		t.Errorf("Expected @ada, got %+v", got.Contributors)
+	}
+}
+
+func TestJSONRendererEmpty(t *testing.T) {
+	result, err := RenderString(JSONRenderer{}, Release{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "{\n  \"groups\": []\n}\n" {
+		t.Errorf("Expected an empty groups list, got %q", result)
+	}
+}
+
+func TestNewRenderer(t *testing.T) {
+	for _, f := range Formats {
+		if _, err := NewRenderer(f); err != nil {
+			t.Errorf("%s: unexpected error: %v", f, err)
+		}
+	}
+	if _, err := NewRenderer("rst"); err == nil {
+		t.Errorf("Expected an error for an unknown format, got nil")
+	}
+}
@@ -0,0 +1,53 @@
+package changelog
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// FragmentExt is the extension of changelog fragments: the section of each
+// release kept in a file of its own, "<version>.md", instead of being
+// prepended to the changelog, so that release branches do not conflict.
+const FragmentExt = ".md"
+
+// FragmentName returns the file name of the fragment of version.
+func FragmentName(version string) string {
+	return version + FragmentExt
+}
+
+// FragmentVersion returns the version of the fragment file name, and false
+// for files that are not fragments, such as a README.
+func FragmentVersion(name string) (string, bool) {
+	v, ok := strings.CutSuffix(path.Base(name), FragmentExt)
+	if !ok {
+		return "", false
+	}
+	if _, err := version.Parse(v); err != nil {
+		return "", false
+	}
+	return v, true
+}
+
+// Assemble returns a changelog holding the fragments, keyed by version,
+// newest version first, below the header of existing, as Rebuild does.
+func Assemble(existing []byte, fragments map[string][]byte) []byte {
+	type fragment struct {
+		version version.Version
+		section []byte
+	}
+	var sorted []fragment
+	for v, section := range fragments {
+		if parsed, err := version.Parse(v); err == nil {
+			sorted = append(sorted, fragment{parsed, section})
+		}
+	}
+	slices.SortFunc(sorted, func(a, b fragment) int { return b.version.Compare(a.version) })
+	sections := make([][]byte, len(sorted))
+	for i, f := range sorted {
+		sections[i] = f.section
+	}
+	return Rebuild(existing, sections)
+}
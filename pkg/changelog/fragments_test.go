@@ -0,0 +1,43 @@
+package changelog
+
+import "testing"
+
+func TestFragmentVersion(t *testing.T) {
+	tests := map[string]string{
+		"1.2.0.md":             "1.2.0",
+		"changelog.d/1.3.0.md": "1.3.0",
+		"2.0.0-rc.1.md":        "2.0.0-rc.1",
+		"README.md":            "",
+		"1.2.0.txt":            "",
+	}
+	for name, expected := range tests {
+		got, ok := FragmentVersion(name)
+		if got != expected || ok != (expected != "") {
+			t.Errorf("%s: Expected %q, got %q (ok=%v)", name, expected, got, ok)
+		}
+	}
+	if FragmentName("1.2.0") != "1.2.0.md" {
+		t.Errorf("Expected 1.2.0.md, got %q", FragmentName("1.2.0"))
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	fragments := map[string][]byte{
+		"1.10.0": []byte("## [1.10.0] - 2026-03-01\n\n- ten\n"),
+		"1.2.0":  []byte("## [1.2.0] - 2026-01-01\n\n- two\n"),
+		"1.9.0":  []byte("## [1.9.0] - 2026-02-01\n\n- nine\n"),
+	}
+	existing := []byte("# Changelog\n\nOur history.\n\n## [0.1.0]\n\n- stale\n")
+
+	expected := "# Changelog\n\nOur history.\n\n" +
+		"## [1.10.0] - 2026-03-01\n\n- ten\n\n" +
+		"## [1.9.0] - 2026-02-01\n\n- nine\n\n" +
+		"## [1.2.0] - 2026-01-01\n\n- two\n"
+	if got := string(Assemble(existing, fragments)); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	if got := string(Assemble(nil, nil)); got != DefaultHeader {
+		t.Errorf("Expected the default header alone, got %q", got)
+	}
+}
@@ -0,0 +1,94 @@
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// Renderer writes a Release in some output format.
+type Renderer interface {
+	Render(w io.Writer, r Release) error
+}
+
+// DefaultTemplate renders a Keep a Changelog compatible section, followed
+// by the contributors when the Release has any. Entries of a resolved pull
+// request link it with its author, and the breaking changes the upgrade
+// guide when there is one.
+const DefaultTemplate = `## [{{ if .Version }}{{ .Version }}{{ else }}Unreleased{{ end }}]{{ if not .Date.IsZero }} - {{ .Date.Format "2006-01-02" }}{{ end }}
+{{ range .Groups }}
+### {{ .Title }}
+
+{{ range .Commits }}- {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ .Summary }}{{ with .PullRequest }} ([#{{ .Number }}]({{ .URL }}){{ if .Author }} by @{{ .Author }}{{ end }}){{ end }}{{ if .Hash }} ({{ shortHash .Hash }}){{ end }}
+{{ end }}{{ if and $.UpgradeGuide (eq .Title "Breaking Changes") }}
+See the [upgrade guide]({{ $.UpgradeGuide }}) for what to change.
+{{ end }}{{ end }}{{ if .Contributors }}
+### Contributors
+
+{{ range .Contributors }}- {{ if .Login }}[{{ .Handle }}]({{ .URL }}){{ else }}{{ .Name }}{{ end }}
+{{ end }}{{ end }}`
+
+// TemplateRenderer renders releases with a text/template. The template is
+// executed with a Release as its data and has access to FuncMap.
+type TemplateRenderer struct {
+	tmpl *template.Template
+}
+
+// FuncMap lists the helper functions available to changelog templates.
+var FuncMap = template.FuncMap{
+	"shortHash": shortHash,
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"trim":      strings.TrimSpace,
+}
+
+// NewTemplateRenderer parses text as a changelog template.
+func NewTemplateRenderer(text string) (*TemplateRenderer, error) {
+	tmpl, err := template.New("changelog").Funcs(FuncMap).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("changelog: parse template: %w", err)
+	}
+	return &TemplateRenderer{tmpl: tmpl}, nil
+}
+
+// DefaultRenderer returns a TemplateRenderer for DefaultTemplate.
+func DefaultRenderer() *TemplateRenderer {
+	r, err := NewTemplateRenderer(DefaultTemplate)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Render executes the template for r.
+func (t *TemplateRenderer) Render(w io.Writer, r Release) error {
+	if err := t.tmpl.Execute(w, r); err != nil {
+		return fmt.Errorf("changelog: render %s: %w", displayVersion(r), err)
+	}
+	return nil
+}
+
+// RenderString renders r with renderer and returns the result.
+func RenderString(renderer Renderer, r Release) (string, error) {
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, r); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func displayVersion(r Release) string {
+	if r.Version == "" {
+		return "Unreleased"
+	}
+	return r.Version
+}
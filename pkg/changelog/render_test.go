@@ -0,0 +1,132 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+func TestDefaultRenderer(t *testing.T) {
+	cs := []commits.Commit{
+		mustParse(t, "5ff5e2c1d2", "fix: prevent duplicate release branches"),
+		mustParse(t, "96435f9aaa", "feat(cli): add next command"),
+	}
+	r := New("1.2.0", time.Date(2026, 2, 24, 0, 0, 0, 0, time.UTC), cs, Options{})
+
+	result, err := RenderString(DefaultRenderer(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "## [1.2.0] - 2026-02-24\n" +
+		"\n" +
+		"### Features\n" +
+		"\n" +
+		"- **cli:** add next command (96435f9)\n" +
+		"\n" +
+		"### Bug Fixes\n" +
+		"\n" +
+		"- prevent duplicate release branches (5ff5e2c)\n"
+
+	if result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestDefaultRendererContributors(t *testing.T) {
+	r := New("1.2.0", time.Time{}, []commits.Commit{mustParse(t, "", "fix: x")}, Options{})
+	r.Contributors = []contributors.Contributor{{Name: "Ada Lovelace", Login: "ada"}, {Name: "Bob"}}
+
+	result, err := RenderString(DefaultRenderer(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "## [1.2.0]\n\n### Bug Fixes\n\n- x\n\n### Contributors\n\n- [@ada](https://github.com/ada)\n- Bob\n"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestDefaultRendererPullRequest(t *testing.T) {
+	r := New("1.2.0", time.Time{}, []commits.Commit{mustParse(t, "5ff5e2c1d2", "fix: x (#12)")}, Options{
+		References: map[string][]references.Reference{
+			"5ff5e2c1d2": {{Number: 12, URL: "https://github.com/octo/app/pull/12", Author: "ada", PullRequest: true}},
+		},
+	})
+
+	result, err := RenderString(DefaultRenderer(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "## [1.2.0]\n\n### Bug Fixes\n\n- x ([#12](https://github.com/octo/app/pull/12) by @ada) (5ff5e2c)\n"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestDefaultRendererUnreleased(t *testing.T) {
+	r := New("", time.Time{}, []commits.Commit{mustParse(t, "", "docs: readme")}, Options{})
+
+	result, err := RenderString(DefaultRenderer(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(result, "## [Unreleased]\n") {
+		t.Errorf("Expected Unreleased heading, got %q", result)
+	}
+}
+
+func TestCustomTemplate(t *testing.T) {
+	renderer, err := NewTemplateRenderer(`{{ .Version }}:{{ range .Groups }}{{ range .Commits }} {{ upper .Type }}{{ end }}{{ end }}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := New("2.0.0", time.Time{}, []commits.Commit{mustParse(t, "", "feat: x"), mustParse(t, "", "fix: y")}, Options{})
+
+	result, err := RenderString(renderer, r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "2.0.0: FEAT FIX" {
+		t.Errorf("Expected %q, got %q", "2.0.0: FEAT FIX", result)
+	}
+}
+
+func TestNewTemplateRendererInvalid(t *testing.T) {
+	if _, err := NewTemplateRenderer("{{ .Version "); err == nil {
+		t.Errorf("Expected parse error, got nil")
+	}
+}
+
+func TestDefaultRendererUpgradeGuide(t *testing.T) {
+	cs := []commits.Commit{
+		mustParse(t, "5ff5e2c1d2", "fix: prevent duplicate release branches"),
+		mustParse(t, "96435f9aaa", "feat(cli)!: drop the next command"),
+	}
+	r := New("2.0.0", time.Time{}, cs, Options{})
+	r.UpgradeGuide = "docs/upgrade/2.0.0.md"
+
+	result, err := RenderString(DefaultRenderer(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "## [2.0.0]\n" +
+		"\n" +
+		"### Breaking Changes\n" +
+		"\n" +
+		"- **cli:** drop the next command (96435f9)\n" +
+		"\n" +
+		"See the [upgrade guide](docs/upgrade/2.0.0.md) for what to change.\n" +
+		"\n" +
+		"### Bug Fixes\n" +
+		"\n" +
+		"- prevent duplicate release branches (5ff5e2c)\n"
+	if result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
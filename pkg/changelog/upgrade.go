@@ -0,0 +1,113 @@
+package changelog
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+)
+
+// UpgradeTitle heads the upgrade guide of a release.
+const UpgradeTitle = "Upgrade guide"
+
+// Upgrade is the upgrade guide of a release: the breaking changes of its
+// commits, to act on when upgrading to it.
+type Upgrade struct {
+	Version string
+	// Previous is the version upgraded from, empty for a first release.
+	Previous string
+	Date     time.Time
+	// Sections group the notes by scope, the unscoped ones first.
+	Sections []UpgradeSection
+}
+
+// UpgradeSection lists the notes of a scope; Scope is empty for the
+// changes that name none.
+type UpgradeSection struct {
+	Scope string
+	Notes []UpgradeNote
+}
+
+// UpgradeNote is a breaking change: the text of a BREAKING CHANGE footer,
+// or the description of a change only flagged with "!".
+type UpgradeNote struct {
+	Text string
+	// Hashes are the commits describing the change, oldest first.
+	Hashes []string
+}
+
+// NewUpgrade collects the breaking changes of cs, newest first as read
+// from git, into the upgrade guide of version. Notes come oldest first
+// within their scope; a text several commits repeat, case and spacing
+// aside, is listed once, with all of them, under the scope of the oldest.
+// Release commits are skipped.
+func NewUpgrade(version, previous string, date time.Time, cs []commits.Commit) Upgrade {
+	u := Upgrade{Version: version, Previous: previous, Date: date}
+	type located struct{ section, note int }
+	seen := make(map[string]located)
+	for _, c := range slices.Backward(cs) {
+		if isReleaseCommit(c) {
+			continue
+		}
+		for _, text := range c.BreakingChanges() {
+			key := strings.ToLower(strings.Join(strings.Fields(strings.TrimSuffix(strings.TrimSpace(text), ".")), " "))
+			if key == "" {
+				continue
+			}
+			if at, ok := seen[key]; ok {
+				n := &u.Sections[at.section].Notes[at.note]
+				if c.Hash != "" && !slices.Contains(n.Hashes, c.Hash) {
+					n.Hashes = append(n.Hashes, c.Hash)
+				}
+				continue
+			}
+			i := slices.IndexFunc(u.Sections, func(s UpgradeSection) bool { return s.Scope == c.Scope })
+			if i < 0 {
+				i = len(u.Sections)
+				u.Sections = append(u.Sections, UpgradeSection{Scope: c.Scope})
+			}
+			n := UpgradeNote{Text: strings.TrimSpace(text)}
+			if c.Hash != "" {
+				n.Hashes = []string{c.Hash}
+			}
+			seen[key] = located{i, len(u.Sections[i].Notes)}
+			u.Sections[i].Notes = append(u.Sections[i].Notes, n)
+		}
+	}
+	slices.SortStableFunc(u.Sections, func(a, b UpgradeSection) int { return cmp.Compare(a.Scope, b.Scope) })
+	return u
+}
+
+// Empty reports whether the release has no breaking change to guide
+// through.
+func (u Upgrade) Empty() bool {
+	return len(u.Sections) == 0
+}
+
+// UpgradeTemplate renders an upgrade guide as a Markdown document. Notes
+// spanning several lines are indented under their bullet.
+const UpgradeTemplate = `# ` + UpgradeTitle + `: {{ .Version }}
+
+{{ if .Previous }}Upgrading from {{ .Previous }} to {{ .Version }}{{ else }}Upgrading to {{ .Version }}{{ end }}{{ if not .Date.IsZero }}, released {{ .Date.Format "2006-01-02" }}{{ end }}, asks for the following changes.
+{{ range .Sections }}{{ if .Scope }}
+## {{ .Scope }}
+{{ end }}
+{{ range .Notes }}- {{ indent .Text }}{{ with .Hashes }} ({{ range $i, $h := . }}{{ if $i }}, {{ end }}{{ shortHash $h }}{{ end }}){{ end }}
+{{ end }}{{ end }}`
+
+var upgradeTemplate = template.Must(template.New("upgrade").Funcs(FuncMap).Funcs(template.FuncMap{
+	"indent": func(s string) string { return strings.ReplaceAll(s, "\n", "\n  ") },
+}).Parse(UpgradeTemplate))
+
+// RenderUpgrade writes the upgrade guide u with UpgradeTemplate.
+func RenderUpgrade(w io.Writer, u Upgrade) error {
+	if err := upgradeTemplate.Execute(w, u); err != nil {
+		return fmt.Errorf("changelog: render the upgrade guide of %s: %w", u.Version, err)
+	}
+	return nil
+}
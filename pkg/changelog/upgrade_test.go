@@ -0,0 +1,66 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+)
+
+func TestNewUpgrade(t *testing.T) {
+	// Newest first, as read from git.
+	cs := []commits.Commit{
+		mustParse(t, "ccccccc111", "chore(release): v2.0.0\n\nBREAKING CHANGE: not a change"),
+		mustParse(t, "bbbbbbb111", "feat(api)!: drop v1 again\n\nBREAKING CHANGE: The /v1 routes  are gone."),
+		mustParse(t, "aaaaaaa333", "feat!: require Go 1.25"),
+		mustParse(t, "aaaaaaa222", "fix(cli): rename flags\n\nBREAKING CHANGE: -out is now -output\nBREAKING CHANGE: -v is now -verbose"),
+		mustParse(t, "aaaaaaa111", "feat(api)!: drop v1\n\nBREAKING CHANGE: the /v1 routes are gone"),
+		mustParse(t, "9999999111", "feat(api): add v2"),
+	}
+	u := NewUpgrade("2.0.0", "1.4.0", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), cs)
+
+	var got []string
+	for _, s := range u.Sections {
+		for _, n := range s.Notes {
+			got = append(got, s.Scope+": "+n.Text+" "+strings.Join(n.Hashes, ","))
+		}
+	}
+	expected := []string{
+		": require Go 1.25 aaaaaaa333",
+		"api: the /v1 routes are gone aaaaaaa111,bbbbbbb111",
+		"cli: -out is now -output aaaaaaa222",
+		"cli: -v is now -verbose aaaaaaa222",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	if !NewUpgrade("1.1.0", "1.0.0", time.Time{}, cs[5:]).Empty() {
+		t.Errorf("Expected no upgrade notes without breaking changes")
+	}
+}
+
+func TestRenderUpgrade(t *testing.T) {
+	u := NewUpgrade("2.0.0", "1.4.0", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), []commits.Commit{
+		mustParse(t, "bbbbbbb111", "feat(api)!: drop v1\n\nBREAKING CHANGE: the /v1 routes are gone;\nuse /v2"),
+		mustParse(t, "aaaaaaa111", "feat!: require Go 1.25"),
+	})
+	var b strings.Builder
+	if err := RenderUpgrade(&b, u); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "# Upgrade guide: 2.0.0\n" +
+		"\n" +
+		"Upgrading from 1.4.0 to 2.0.0, released 2026-03-01, asks for the following changes.\n" +
+		"\n" +
+		"- require Go 1.25 (aaaaaaa)\n" +
+		"\n" +
+		"## api\n" +
+		"\n" +
+		"- the /v1 routes are gone;\n" +
+		"  use /v2 (bbbbbbb)\n"
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
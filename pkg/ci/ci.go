@@ -0,0 +1,213 @@
+// Package ci detects the continuous integration system a command runs in
+// and reads what it knows about the build: the repository and the forge
+// hosting it, the branch or tag built, an API token and the pull request
+// under test. GitHub Actions, GitLab CI, CircleCI and Jenkins are
+// recognised.
+//
+// Outputs are handed back to the CI system with WriteOutputs, so that later
+// steps of a GitHub Actions job can read the version released as
+// steps.<id>.outputs.version.
+package ci
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// System is a CI system.
+type System string
+
+const (
+	GitHubActions System = "github-actions"
+	GitLabCI      System = "gitlab-ci"
+	CircleCI      System = "circleci"
+	Jenkins       System = "jenkins"
+)
+
+// Env is what the CI system tells about the build. Fields it does not
+// provide are empty.
+type Env struct {
+	System System
+	// Provider is the forge hosting the repository, "github" or "gitlab",
+	// and Repo its owner/repo slug or project path.
+	Provider string
+	Repo     string
+	// ServerURL is the web URL of the forge, e.g. "https://github.com".
+	ServerURL string
+	// Branch is the branch built, the source branch of a pull request;
+	// Tag is set instead for a tag build.
+	Branch string
+	Tag    string
+	Commit string
+	// Token is the API token for Provider found in the environment.
+	Token string
+	// PullRequest is the number of the pull or merge request under test,
+	// and BaseBranch the branch it targets. PullRequest is 0 outside one.
+	PullRequest int
+	BaseBranch  string
+	// OutputFile is the file step outputs are appended to, if any.
+	OutputFile string
+	// JobURL is the web page of the running job or workflow run.
+	JobURL string
+}
+
+// FromEnv detects the CI environment of the process.
+func FromEnv() (Env, bool) {
+	return Detect(os.Getenv)
+}
+
+// Detect detects the CI environment from the variables getenv returns. The
+// boolean result is false outside a recognised CI system.
+func Detect(getenv func(string) string) (Env, bool) {
+	var e Env
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true" || getenv("GITHUB_REPOSITORY") != "":
+		e = Env{
+			System:     GitHubActions,
+			Provider:   "github",
+			Repo:       getenv("GITHUB_REPOSITORY"),
+			ServerURL:  or(getenv("GITHUB_SERVER_URL"), "https://github.com"),
+			Commit:     getenv("GITHUB_SHA"),
+			BaseBranch: getenv("GITHUB_BASE_REF"),
+			OutputFile: getenv("GITHUB_OUTPUT"),
+		}
+		if getenv("GITHUB_REF_TYPE") == "tag" {
+			e.Tag = getenv("GITHUB_REF_NAME")
+		} else {
+			// GITHUB_REF_NAME is "12/merge" on a pull request, whose
+			// branch is GITHUB_HEAD_REF.
+			e.Branch = or(getenv("GITHUB_HEAD_REF"), getenv("GITHUB_REF_NAME"))
+		}
+		if n, ok := strings.CutPrefix(getenv("GITHUB_REF"), "refs/pull/"); ok {
+			e.PullRequest = number(strings.TrimSuffix(n, "/merge"))
+		}
+		if id := getenv("GITHUB_RUN_ID"); id != "" && e.Repo != "" {
+			e.JobURL = e.RepoURL() + "/actions/runs/" + id
+			if attempt := getenv("GITHUB_RUN_ATTEMPT"); attempt != "" {
+				e.JobURL += "/attempts/" + attempt
+			}
+		}
+	case getenv("GITLAB_CI") == "true" || getenv("CI_PROJECT_PATH") != "":
+		e = Env{
+			System:      GitLabCI,
+			Provider:    "gitlab",
+			Repo:        getenv("CI_PROJECT_PATH"),
+			ServerURL:   getenv("CI_SERVER_URL"),
+			Branch:      or(getenv("CI_COMMIT_BRANCH"), getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")),
+			Tag:         getenv("CI_COMMIT_TAG"),
+			Commit:      getenv("CI_COMMIT_SHA"),
+			PullRequest: number(getenv("CI_MERGE_REQUEST_IID")),
+			BaseBranch:  getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"),
+			JobURL:      getenv("CI_JOB_URL"),
+		}
+		if e.Branch == "" && e.Tag == "" {
+			e.Branch = getenv("CI_COMMIT_REF_NAME")
+		}
+	case getenv("CIRCLECI") == "true":
+		e = Env{
+			System: CircleCI,
+			Branch: getenv("CIRCLE_BRANCH"),
+			Tag:    getenv("CIRCLE_TAG"),
+			Commit: getenv("CIRCLE_SHA1"),
+			JobURL: getenv("CIRCLE_BUILD_URL"),
+		}
+		e.ServerURL, e.Provider, e.Repo = parseRemote(getenv("CIRCLE_REPOSITORY_URL"))
+		if owner, name := getenv("CIRCLE_PROJECT_USERNAME"), getenv("CIRCLE_PROJECT_REPONAME"); owner != "" && name != "" {
+			e.Repo = owner + "/" + name
+		}
+		if pr := getenv("CIRCLE_PULL_REQUEST"); pr != "" {
+			e.PullRequest = number(path.Base(pr))
+		}
+	case getenv("JENKINS_URL") != "":
+		e = Env{
+			System:      Jenkins,
+			Branch:      or(getenv("CHANGE_BRANCH"), getenv("BRANCH_NAME")),
+			Tag:         getenv("TAG_NAME"),
+			Commit:      getenv("GIT_COMMIT"),
+			PullRequest: number(getenv("CHANGE_ID")),
+			BaseBranch:  getenv("CHANGE_TARGET"),
+			JobURL:      getenv("BUILD_URL"),
+		}
+		if e.Branch == "" && e.Tag == "" {
+			_, e.Branch, _ = strings.Cut(getenv("GIT_BRANCH"), "/")
+		}
+		e.ServerURL, e.Provider, e.Repo = parseRemote(getenv("GIT_URL"))
+	default:
+		return Env{}, false
+	}
+
+	switch e.Provider {
+	case "github":
+		e.Token = or(getenv("GITHUB_TOKEN"), getenv("GH_TOKEN"))
+	case "gitlab":
+		e.Token = or(getenv("GITLAB_TOKEN"), getenv("CI_JOB_TOKEN"))
+	}
+	return e, true
+}
+
+// builders identify the build platform of each system in provenance.
+var builders = map[System]string{
+	GitHubActions: "https://github.com/actions/runner",
+	GitLabCI:      "https://gitlab.com/gitlab-org/gitlab-runner",
+	CircleCI:      "https://circleci.com",
+	Jenkins:       "https://www.jenkins.io",
+}
+
+// BuilderID returns a URI identifying the CI system as the builder of
+// release artifacts, as SLSA provenance records it.
+func (e Env) BuilderID() string {
+	return builders[e.System]
+}
+
+// RepoURL returns the web URL of the repository, or "" when the forge or
+// the repository is unknown.
+func (e Env) RepoURL() string {
+	if e.ServerURL == "" || e.Repo == "" {
+		return ""
+	}
+	return strings.TrimSuffix(e.ServerURL, "/") + "/" + e.Repo
+}
+
+// parseRemote splits a git remote URL, "https://github.com/octo/app.git"
+// or "git@github.com:octo/app.git", into the forge's web URL, its provider
+// when the host names one, and the repository path.
+func parseRemote(remote string) (server, provider, repo string) {
+	if remote == "" {
+		return "", "", ""
+	}
+	var host string
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		host, repo = u.Hostname(), strings.TrimPrefix(u.Path, "/")
+	} else if at, rest, ok := strings.Cut(remote, "@"); ok && !strings.Contains(at, "/") {
+		host, repo, _ = strings.Cut(rest, ":")
+	} else {
+		return "", "", ""
+	}
+	repo = strings.TrimSuffix(repo, ".git")
+	switch {
+	case strings.Contains(host, "github"):
+		provider = "github"
+	case strings.Contains(host, "gitlab"):
+		provider = "gitlab"
+	}
+	return "https://" + host, provider, repo
+}
+
+// number parses a pull request number, returning 0 when s is not one.
+func number(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func or(s, def string) string {
+	if s != "" {
+		return s
+	}
+	return def
+}
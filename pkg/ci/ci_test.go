@@ -0,0 +1,112 @@
+package ci
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected Env
+	}{
+		{
+			"github pull request",
+			map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_REPOSITORY": "octo/app",
+				"GITHUB_REF":        "refs/pull/12/merge",
+				"GITHUB_REF_NAME":   "12/merge",
+				"GITHUB_REF_TYPE":   "branch",
+				"GITHUB_HEAD_REF":   "feature/x",
+				"GITHUB_BASE_REF":   "main",
+				"GITHUB_SHA":        "abc",
+				"GITHUB_OUTPUT":     "/tmp/out",
+				"GH_TOKEN":          "gh",
+			},
+			Env{System: GitHubActions, Provider: "github", Repo: "octo/app", ServerURL: "https://github.com", Branch: "feature/x", Commit: "abc", Token: "gh", PullRequest: 12, BaseBranch: "main", OutputFile: "/tmp/out"},
+		},
+		{
+			"github tag",
+			map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REPOSITORY": "octo/app", "GITHUB_SERVER_URL": "https://ghe.example.com", "GITHUB_REF_TYPE": "tag", "GITHUB_REF_NAME": "v1.2.0", "GITHUB_TOKEN": "t", "GITHUB_RUN_ID": "42", "GITHUB_RUN_ATTEMPT": "2"},
+			Env{System: GitHubActions, Provider: "github", Repo: "octo/app", ServerURL: "https://ghe.example.com", Tag: "v1.2.0", Token: "t", JobURL: "https://ghe.example.com/octo/app/actions/runs/42/attempts/2"},
+		},
+		{
+			"gitlab merge request",
+			map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI_PROJECT_PATH":                     "group/app",
+				"CI_SERVER_URL":                       "https://gitlab.example.com",
+				"CI_COMMIT_REF_NAME":                  "feature/x",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature/x",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+				"CI_MERGE_REQUEST_IID":                "7",
+				"CI_COMMIT_SHA":                       "abc",
+				"CI_JOB_TOKEN":                        "job",
+				"CI_JOB_URL":                          "https://gitlab.example.com/group/app/-/jobs/9",
+			},
+			Env{System: GitLabCI, Provider: "gitlab", Repo: "group/app", ServerURL: "https://gitlab.example.com", Branch: "feature/x", Commit: "abc", Token: "job", PullRequest: 7, BaseBranch: "main", JobURL: "https://gitlab.example.com/group/app/-/jobs/9"},
+		},
+		{
+			"circleci",
+			map[string]string{
+				"CIRCLECI":                "true",
+				"CIRCLE_REPOSITORY_URL":   "git@github.com:octo/app.git",
+				"CIRCLE_PROJECT_USERNAME": "octo",
+				"CIRCLE_PROJECT_REPONAME": "app",
+				"CIRCLE_BRANCH":           "feature/x",
+				"CIRCLE_PULL_REQUEST":     "https://github.com/octo/app/pull/12",
+				"CIRCLE_SHA1":             "abc",
+				"GITHUB_TOKEN":            "t",
+			},
+			Env{System: CircleCI, Provider: "github", Repo: "octo/app", ServerURL: "https://github.com", Branch: "feature/x", Commit: "abc", Token: "t", PullRequest: 12},
+		},
+		{
+			"jenkins",
+			map[string]string{
+				"JENKINS_URL":  "https://ci.example.com",
+				"GIT_URL":      "https://gitlab.com/group/app.git",
+				"GIT_BRANCH":   "origin/main",
+				"GIT_COMMIT":   "abc",
+				"GITLAB_TOKEN": "t",
+			},
+			Env{System: Jenkins, Provider: "gitlab", Repo: "group/app", ServerURL: "https://gitlab.com", Branch: "main", Commit: "abc", Token: "t"},
+		},
+		{
+			"jenkins change request",
+			map[string]string{"JENKINS_URL": "https://ci.example.com", "BRANCH_NAME": "PR-3", "CHANGE_ID": "3", "CHANGE_BRANCH": "feature/x", "CHANGE_TARGET": "main", "GIT_URL": "https://git.example.com/app.git", "BUILD_URL": "https://ci.example.com/job/app/5/"},
+			Env{System: Jenkins, ServerURL: "https://git.example.com", Repo: "app", Branch: "feature/x", PullRequest: 3, BaseBranch: "main", JobURL: "https://ci.example.com/job/app/5/"},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := Detect(func(key string) string { return tt.env[key] })
+		if !ok {
+			t.Errorf("%s: expected a CI environment", tt.name)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("%s: Expected %+v, got %+v", tt.name, tt.expected, got)
+		}
+	}
+
+	if e, ok := Detect(func(string) string { return "" }); ok {
+		t.Errorf("Expected no CI environment, got %+v", e)
+	}
+}
+
+func TestRepoURL(t *testing.T) {
+	e := Env{ServerURL: "https://gitlab.example.com/", Repo: "group/app"}
+	if got := e.RepoURL(); got != "https://gitlab.example.com/group/app" {
+		t.Errorf("Expected the project URL, got %q", got)
+	}
+	if got := (Env{Repo: "group/app"}).RepoURL(); got != "" {
+		t.Errorf("Expected no URL without a server, got %q", got)
+	}
+}
+
+func TestBuilderID(t *testing.T) {
+	if got := (Env{System: GitHubActions}).BuilderID(); got != "https://github.com/actions/runner" {
+		t.Errorf("Expected the GitHub Actions runner, got %q", got)
+	}
+	if got := (Env{}).BuilderID(); got != "" {
+		t.Errorf("Expected no builder outside CI, got %q", got)
+	}
+}
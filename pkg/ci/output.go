@@ -0,0 +1,67 @@
+package ci
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Output is a named value handed to later steps of the CI job.
+type Output struct {
+	Name  string
+	Value string
+}
+
+// WriteOutputs appends outputs to OutputFile, the $GITHUB_OUTPUT file of a
+// GitHub Actions step, in the FormatOutputs format. It does nothing when the
+// CI system reads no outputs.
+func (e Env) WriteOutputs(outputs []Output) error {
+	if e.OutputFile == "" || len(outputs) == 0 {
+		return nil
+	}
+	text, err := FormatOutputs(outputs)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(e.OutputFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("ci: outputs: %w", err)
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return fmt.Errorf("ci: outputs: %w", err)
+	}
+	return f.Close()
+}
+
+// FormatOutputs returns outputs as the $GITHUB_OUTPUT and $GITHUB_ENV files
+// of GitHub Actions read them, one "name=value" line each, using a random
+// heredoc delimiter for values that span lines.
+func FormatOutputs(outputs []Output) (string, error) {
+	var b strings.Builder
+	for _, o := range outputs {
+		if !strings.ContainsAny(o.Value, "\r\n") {
+			fmt.Fprintf(&b, "%s=%s\n", o.Name, o.Value)
+			continue
+		}
+		delimiter, err := randomDelimiter()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s<<%s\n%s\n%s\n", o.Name, delimiter, o.Value, delimiter)
+	}
+	return b.String(), nil
+}
+
+// randomDelimiter returns a heredoc delimiter no value can contain by
+// chance, as GitHub recommends.
+func randomDelimiter() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "ghadelimiter_" + hex.EncodeToString(b), nil
+}
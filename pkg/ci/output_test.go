@@ -0,0 +1,32 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestWriteOutputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	if err := os.WriteFile(path, []byte("earlier=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := Env{OutputFile: path}
+	if err := e.WriteOutputs([]Output{{"version", "1.2.0"}, {"urls", "https://a\nhttps://b"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := regexp.MustCompile(`^earlier=1\nversion=1\.2\.0\nurls<<(ghadelimiter_[0-9a-f]{32})\nhttps://a\nhttps://b\n(ghadelimiter_[0-9a-f]{32})\n$`)
+	m := expected.FindStringSubmatch(string(got))
+	if m == nil || m[1] != m[2] {
+		t.Errorf("Expected outputs appended with a heredoc, got %q", got)
+	}
+
+	if err := (Env{}).WriteOutputs([]Output{{"version", "1.2.0"}}); err != nil {
+		t.Errorf("Expected no error without an output file, got %v", err)
+	}
+}
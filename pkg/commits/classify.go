@@ -0,0 +1,82 @@
+package commits
+
+import (
+	"regexp"
+
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// Level returns the semantic version increment implied by c: Major for
+// breaking changes, Minor for "feat", Patch for "fix" and None otherwise.
+func (c Commit) Level() version.Level {
+	switch {
+	case c.Breaking:
+		return version.Major
+	case c.Type == "feat":
+		return version.Minor
+	case c.Type == "fix":
+		return version.Patch
+	}
+	return version.None
+}
+
+// Classify returns the highest increment implied by any of the commits.
+func Classify(commits []Commit) version.Level {
+	return Classifier{}.Classify(commits)
+}
+
+// ClassRule classifies the commits whose header, as Header writes it,
+// matches Pattern.
+type ClassRule struct {
+	Pattern *regexp.Regexp
+	// Level is the increment the commits imply when HasLevel is set;
+	// otherwise they keep the one of Commit.Level.
+	Level    version.Level
+	HasLevel bool
+	// Section is the title of the changelog group the commits are listed
+	// under; empty keeps the group of their type. Hidden leaves them out
+	// of the changelog.
+	Section string
+	Hidden  bool
+}
+
+// Classifier overrides the Conventional Commits defaults with Rules: the
+// first rule matching a commit classifies it. Breaking changes always
+// imply Major and are listed as such, whatever the rules say.
+type Classifier struct {
+	Rules []ClassRule
+}
+
+// Match returns the first rule matching c. Breaking changes match none.
+func (cl Classifier) Match(c Commit) (ClassRule, bool) {
+	if c.Breaking {
+		return ClassRule{}, false
+	}
+	header := c.Header()
+	for _, r := range cl.Rules {
+		if r.Pattern.MatchString(header) {
+			return r, true
+		}
+	}
+	return ClassRule{}, false
+}
+
+// Level returns the increment implied by c: that of the first rule
+// matching it, or Commit.Level.
+func (cl Classifier) Level(c Commit) version.Level {
+	if r, ok := cl.Match(c); ok && r.HasLevel {
+		return r.Level
+	}
+	return c.Level()
+}
+
+// Classify returns the highest increment implied by any of the commits.
+func (cl Classifier) Classify(commits []Commit) version.Level {
+	level := version.None
+	for _, c := range commits {
+		if l := cl.Level(c); l > level {
+			level = l
+		}
+	}
+	return level
+}
@@ -0,0 +1,114 @@
+package commits
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected version.Level
+	}{
+		{"feat: thing", version.Minor},
+		{"fix: thing", version.Patch},
+		{"docs: thing", version.None},
+		{"chore(deps): bump", version.None},
+		{"refactor!: thing", version.Major},
+		{"fix: thing\n\nBREAKING CHANGE: now different", version.Major},
+	}
+
+	for _, tt := range tests {
+		c, err := Parse(tt.message)
+		if err != nil {
+			t.Fatalf("Parse(%q): unexpected error: %v", tt.message, err)
+		}
+		if result := c.Level(); result != tt.expected {
+			t.Errorf("Level(%q): expected %s, got %s", tt.message, tt.expected, result)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	parse := func(messages ...string) []Commit {
+		var cs []Commit
+		for _, m := range messages {
+			c, err := Parse(m)
+			if err != nil {
+				t.Fatalf("Parse(%q): unexpected error: %v", m, err)
+			}
+			cs = append(cs, c)
+		}
+		return cs
+	}
+
+	tests := []struct {
+		commits  []Commit
+		expected version.Level
+	}{
+		{nil, version.None},
+		{parse("docs: a", "chore: b"), version.None},
+		{parse("docs: a", "fix: b"), version.Patch},
+		{parse("fix: a", "feat: b", "fix: c"), version.Minor},
+		{parse("feat: a", "fix!: b"), version.Major},
+	}
+
+	for i, tt := range tests {
+		if result := Classify(tt.commits); result != tt.expected {
+			t.Errorf("case %d: expected %s, got %s", i, tt.expected, result)
+		}
+	}
+}
+
+func TestClassifier(t *testing.T) {
+	cl := Classifier{Rules: []ClassRule{
+		{Pattern: regexp.MustCompile(`^perf(\(.*\))?:`), Level: version.Minor, HasLevel: true, Section: "Performance"},
+		{Pattern: regexp.MustCompile(`^(deps|chore\(deps\)):`), Hidden: true},
+		{Pattern: regexp.MustCompile(`^fix\(typo\):`), Level: version.None, HasLevel: true},
+		{Pattern: regexp.MustCompile(`^perf:`), Level: version.Major, HasLevel: true},
+	}}
+
+	tests := []struct {
+		message  string
+		expected version.Level
+		section  string
+		hidden   bool
+	}{
+		{"perf: faster lookups", version.Minor, "Performance", false},
+		{"perf(db): batch writes", version.Minor, "Performance", false},
+		{"deps: bump yaml", version.None, "", true},
+		{"chore(deps): bump yaml", version.None, "", true},
+		{"fix(typo): spelling", version.None, "", false},
+		{"fix: crash", version.Patch, "", false},
+		{"feat: search", version.Minor, "", false},
+		{"perf!: drop the cache", version.Major, "", false},
+	}
+
+	for _, tt := range tests {
+		c, err := Parse(tt.message)
+		if err != nil {
+			t.Fatalf("Parse(%q): unexpected error: %v", tt.message, err)
+		}
+		if result := cl.Level(c); result != tt.expected {
+			t.Errorf("Level(%q): expected %s, got %s", tt.message, tt.expected, result)
+		}
+		r, _ := cl.Match(c)
+		if r.Section != tt.section || r.Hidden != tt.hidden {
+			t.Errorf("Match(%q): expected section %q hidden %v, got %q %v", tt.message, tt.section, tt.hidden, r.Section, r.Hidden)
+		}
+	}
+
+	var cs []Commit
+	for _, m := range []string{"fix(typo): a", "deps: b", "perf: c"} {
+		c, _ := Parse(m)
+		cs = append(cs, c)
+	}
+	if result := cl.Classify(cs); result != version.Minor {
+		t.Errorf("Expected %s, got %s", version.Minor, result)
+	}
+	if result := cl.Classify(cs[:2]); result != version.None {
+		t.Errorf("Expected %s, got %s", version.None, result)
+	}
+}
@@ -0,0 +1,187 @@
+// Package commits parses commit messages according to the Conventional
+// Commits 1.0.0 specification (https://www.conventionalcommits.org).
+//
+// A conventional commit message has the shape:
+//
+//	<type>[(<scope>)][!]: <description>
+//
+//	[body]
+//
+//	[footer(s)]
+package commits
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrNotConventional is returned (wrapped) when a message header does not
+// follow the Conventional Commits format.
+var ErrNotConventional = errors.New("not a conventional commit")
+
+// Footer is a single "Token: value" or "Token #value" trailer.
+type Footer struct {
+	Token string
+	Value string
+}
+
+// Commit is a parsed conventional commit.
+type Commit struct {
+	// Hash is not part of the message; it is left for callers to populate.
+	Hash string
+
+	Type        string
+	Scope       string
+	Description string
+	Body        string
+	Footers     []Footer
+
+	// Breaking is set when the header carries a "!" marker or a
+	// BREAKING CHANGE footer is present.
+	Breaking bool
+}
+
+var (
+	headerPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)(?:\(([^()\r\n]*)\))?(!)?: (\S.*)$`)
+	footerPattern = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][A-Za-z0-9-]*)(?:: | #)(.*)$`)
+)
+
+// Parse parses a full commit message (header, optional body and footers).
+func Parse(message string) (Commit, error) {
+	var c Commit
+
+	message = strings.ReplaceAll(message, "\r\n", "\n")
+	message = strings.Trim(message, "\n")
+
+	header, rest, _ := strings.Cut(message, "\n")
+	header = strings.TrimSpace(header)
+
+	m := headerPattern.FindStringSubmatch(header)
+	if m == nil {
+		return c, fmt.Errorf("%w: %q", ErrNotConventional, header)
+	}
+
+	c.Type = strings.ToLower(m[1])
+	c.Scope = strings.TrimSpace(m[2])
+	c.Breaking = m[3] == "!"
+	c.Description = strings.TrimSpace(m[4])
+
+	body, footers := splitFooters(strings.Trim(rest, "\n"))
+	c.Body = body
+	c.Footers = footers
+
+	for _, f := range footers {
+		if isBreakingToken(f.Token) {
+			c.Breaking = true
+		}
+	}
+
+	return c, nil
+}
+
+// Header returns the first line of the commit message as it would be
+// written: "type(scope)!: description".
+func (c Commit) Header() string {
+	var b strings.Builder
+	b.WriteString(c.Type)
+	if c.Scope != "" {
+		b.WriteString("(" + c.Scope + ")")
+	}
+	if c.Breaking && !c.hasBreakingFooter() {
+		b.WriteByte('!')
+	}
+	b.WriteString(": ")
+	b.WriteString(c.Description)
+	return b.String()
+}
+
+// Footer returns the value of the first footer with the given token. The
+// comparison is case-insensitive; BREAKING CHANGE and BREAKING-CHANGE are
+// treated as synonyms.
+func (c Commit) Footer(token string) (string, bool) {
+	for _, f := range c.Footers {
+		if strings.EqualFold(f.Token, token) || isBreakingQuery(token) && isBreakingToken(f.Token) {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// BreakingChange returns the text describing a breaking change. It prefers
+// the BREAKING CHANGE footer and falls back to the description when the
+// change was only flagged with "!". It is empty for non-breaking commits.
+func (c Commit) BreakingChange() string {
+	if !c.Breaking {
+		return ""
+	}
+	if v, ok := c.Footer("BREAKING CHANGE"); ok {
+		return v
+	}
+	return c.Description
+}
+
+// BreakingChanges returns the text of every BREAKING CHANGE footer of c, in
+// order, or the description when the change was only flagged with "!". It
+// is empty for non-breaking commits.
+func (c Commit) BreakingChanges() []string {
+	if !c.Breaking {
+		return nil
+	}
+	var texts []string
+	for _, f := range c.Footers {
+		if isBreakingToken(f.Token) {
+			texts = append(texts, f.Value)
+		}
+	}
+	if len(texts) == 0 {
+		return []string{c.Description}
+	}
+	return texts
+}
+
+func (c Commit) hasBreakingFooter() bool {
+	_, ok := c.Footer("BREAKING CHANGE")
+	return ok
+}
+
+func isBreakingToken(token string) bool {
+	return token == "BREAKING CHANGE" || token == "BREAKING-CHANGE"
+}
+
+func isBreakingQuery(token string) bool {
+	return strings.EqualFold(strings.ReplaceAll(token, "-", " "), "BREAKING CHANGE")
+}
+
+// splitFooters separates the trailing footer paragraph from the body. The
+// last paragraph is treated as footers only when its first line is a valid
+// footer; subsequent non-footer lines are continuations of the previous value.
+func splitFooters(rest string) (string, []Footer) {
+	if rest == "" {
+		return "", nil
+	}
+
+	paragraphs := strings.Split(rest, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	lines := strings.Split(last, "\n")
+	if !footerPattern.MatchString(lines[0]) {
+		return strings.TrimSpace(rest), nil
+	}
+
+	var footers []Footer
+	for _, line := range lines {
+		if m := footerPattern.FindStringSubmatch(line); m != nil {
+			footers = append(footers, Footer{Token: m[1], Value: m[2]})
+			continue
+		}
+		f := &footers[len(footers)-1]
+		f.Value += "\n" + line
+	}
+	for i := range footers {
+		footers[i].Value = strings.TrimSpace(footers[i].Value)
+	}
+
+	body := strings.Join(paragraphs[:len(paragraphs)-1], "\n\n")
+	return strings.TrimSpace(body), footers
+}
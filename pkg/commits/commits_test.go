@@ -0,0 +1,169 @@
+package commits
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected Commit
+	}{
+		{"feat: add batch processing", Commit{Type: "feat", Description: "add batch processing"}},
+		{"fix(api): handle nil pointer", Commit{Type: "fix", Scope: "api", Description: "handle nil pointer"}},
+		{"feat!: drop Config.Timeout", Commit{Type: "feat", Description: "drop Config.Timeout", Breaking: true}},
+		{"refactor(core)!: rename package", Commit{Type: "refactor", Scope: "core", Description: "rename package", Breaking: true}},
+		{"FEAT: shout", Commit{Type: "feat", Description: "shout"}},
+		{"chore(release): v1.3.0", Commit{Type: "chore", Scope: "release", Description: "v1.3.0"}},
+	}
+
+	for _, tt := range tests {
+		result, err := Parse(tt.message)
+		if err != nil {
+			t.Errorf("Parse(%q): unexpected error: %v", tt.message, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("Parse(%q): expected %#v, got %#v", tt.message, tt.expected, result)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	messages := []string{
+		"",
+		"add a thing",
+		"feat add a thing",
+		"feat:",
+		"feat: ",
+		"feat:no space",
+		"(scope): missing type",
+		"Merge branch 'main' into feature",
+	}
+
+	for _, message := range messages {
+		_, err := Parse(message)
+		if !errors.Is(err, ErrNotConventional) {
+			t.Errorf("Parse(%q): expected ErrNotConventional, got %v", message, err)
+		}
+	}
+}
+
+func TestParseBodyAndFooters(t *testing.T) {
+	message := "fix: prevent racing of requests\n" +
+		"\n" +
+		"Introduce a request id and a reference to latest request.\n" +
+		"\n" +
+		"Remove timeouts which were used to mitigate the racing issue.\n" +
+		"\n" +
+		"Reviewed-by: Z\n" +
+		"Refs #123\n"
+
+	result, err := Parse(message)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedBody := "Introduce a request id and a reference to latest request.\n\nRemove timeouts which were used to mitigate the racing issue."
+	if result.Body != expectedBody {
+		t.Errorf("Expected body %q, got %q", expectedBody, result.Body)
+	}
+
+	expectedFooters := []Footer{{Token: "Reviewed-by", Value: "Z"}, {Token: "Refs", Value: "123"}}
+	if !reflect.DeepEqual(result.Footers, expectedFooters) {
+		t.Errorf("Expected footers %#v, got %#v", expectedFooters, result.Footers)
+	}
+	if result.Breaking {
+		t.Errorf("Expected non-breaking commit")
+	}
+}
+
+func TestParseBreakingFooter(t *testing.T) {
+	message := "feat: allow provided config object to extend other configs\n" +
+		"\n" +
+		"BREAKING CHANGE: `extends` key in config file is now used for\n" +
+		"extending other config files"
+
+	result, err := Parse(message)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !result.Breaking {
+		t.Errorf("Expected breaking commit")
+	}
+	if result.Body != "" {
+		t.Errorf("Expected empty body, got %q", result.Body)
+	}
+
+	expected := "`extends` key in config file is now used for\nextending other config files"
+	if result.BreakingChange() != expected {
+		t.Errorf("Expected %q, got %q", expected, result.BreakingChange())
+	}
+
+	if v, ok := result.Footer("breaking-change"); !ok || v != expected {
+		t.Errorf("Expected BREAKING-CHANGE synonym lookup to succeed, got %q (ok=%v)", v, ok)
+	}
+}
+
+func TestBodyWithoutFooters(t *testing.T) {
+	result, err := Parse("docs: explain hooks\r\n\r\nThis is just prose: not a footer.\r\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Body != "This is just prose: not a footer." {
+		t.Errorf("Expected body to be kept, got %q", result.Body)
+	}
+	if len(result.Footers) != 0 {
+		t.Errorf("Expected no footers, got %#v", result.Footers)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	inputs := []string{"feat(api)!: drop v1 endpoints", "fix: typo", "docs(readme): usage"}
+
+	for _, input := range inputs {
+		c, err := Parse(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Header() != input {
+			t.Errorf("Expected %q, got %q", input, c.Header())
+		}
+	}
+}
+
+func TestBreakingChangeFallsBackToDescription(t *testing.T) {
+	c, _ := Parse("feat!: remove legacy flag")
+	if c.BreakingChange() != "remove legacy flag" {
+		t.Errorf("Expected description fallback, got %q", c.BreakingChange())
+	}
+
+	c, _ = Parse("feat: harmless")
+	if c.BreakingChange() != "" {
+		t.Errorf("Expected empty breaking change, got %q", c.BreakingChange())
+	}
+}
+
+func TestBreakingChanges(t *testing.T) {
+	c, err := Parse("feat(api)!: drop v1\n\nBREAKING CHANGE: the /v1 routes are gone\nBREAKING-CHANGE: tokens must be rotated\nRefs: #12")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"the /v1 routes are gone", "tokens must be rotated"}
+	if got := c.BreakingChanges(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	c, _ = Parse("feat!: remove legacy flag")
+	if got := c.BreakingChanges(); !reflect.DeepEqual(got, []string{"remove legacy flag"}) {
+		t.Errorf("Expected description fallback, got %q", got)
+	}
+
+	c, _ = Parse("feat: harmless")
+	if got := c.BreakingChanges(); got != nil {
+		t.Errorf("Expected no breaking changes, got %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package commits
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Rule is a regular expression every commit message must match.
+type Rule struct {
+	Pattern *regexp.Regexp
+	// Message explains the rule to the author of a commit breaking it.
+	Message string
+}
+
+// Linter checks commit messages against the Conventional Commits format,
+// or against its Rules when there are any.
+type Linter struct {
+	// Types restricts the conventional commit types; empty allows any.
+	Types []string
+	Rules []Rule
+}
+
+// Lint returns the problems found in message, or nil when it passes.
+// Merge and squash-merge messages are checked as the messages they hold
+// (see Messages).
+func (l Linter) Lint(message string) []string {
+	if len(l.Rules) > 0 {
+		var problems []string
+		for _, r := range l.Rules {
+			if !r.Pattern.MatchString(message) {
+				problems = append(problems, r.explain())
+			}
+		}
+		return problems
+	}
+
+	var problems []string
+	for _, m := range Messages(message) {
+		problems = append(problems, l.conventional(m)...)
+	}
+	return problems
+}
+
+// conventional lints a single conventional commit message, explaining the
+// most common mistakes rather than only rejecting the header.
+func (l Linter) conventional(message string) []string {
+	message = strings.Trim(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if strings.TrimSpace(message) == "" {
+		return []string{"the message is empty"}
+	}
+	header, rest, _ := strings.Cut(message, "\n")
+	header = strings.TrimSpace(header)
+
+	c, err := Parse(message)
+	if err != nil {
+		return []string{headerProblem(header)}
+	}
+	var problems []string
+	if len(l.Types) > 0 && !slices.Contains(l.Types, c.Type) {
+		problems = append(problems, fmt.Sprintf("type %q is not one of %s", c.Type, strings.Join(l.Types, ", ")))
+	}
+	if rest != "" && !strings.HasPrefix(rest, "\n") {
+		problems = append(problems, "the body must be separated from the header by a blank line")
+	}
+	return problems
+}
+
+// headerProblem explains why header is not "<type>[(<scope>)][!]: <description>".
+func headerProblem(header string) string {
+	const format = `expected "<type>[(<scope>)][!]: <description>"`
+	typ, desc, ok := strings.Cut(header, ":")
+	switch {
+	case !ok:
+		return fmt.Sprintf("header %q has no type: %s, e.g. \"fix: %s\"", header, format, header)
+	case strings.TrimSpace(desc) == "":
+		return fmt.Sprintf("header %q has no description", header)
+	case !strings.HasPrefix(desc, " ") || strings.HasPrefix(desc, "  "):
+		return fmt.Sprintf("header %q needs a single space after the colon", header)
+	case strings.ContainsAny(typ, " \t"):
+		return fmt.Sprintf("type %q must be a single word", typ)
+	}
+	return fmt.Sprintf("header %q is malformed: %s", header, format)
+}
+
+func (r Rule) explain() string {
+	if r.Message != "" {
+		return r.Message
+	}
+	return fmt.Sprintf("the message does not match %s", r.Pattern)
+}
@@ -0,0 +1,54 @@
+package commits
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	l := Linter{Types: []string{"feat", "fix", "docs"}}
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"feat(api)!: add batch processing\n\nBatches of 100.", ""},
+		{"Merge pull request #12 from octo/batch\n\nfix: handle nil", ""},
+		{"Add batch processing (#12)\n\n* feat: add batch\n* docs: explain", ""},
+		{"", "the message is empty"},
+		{"Add batch processing", `has no type: expected "<type>[(<scope>)][!]: <description>", e.g. "fix: Add batch processing"`},
+		{"feat:", `header "feat:" has no description`},
+		{"feat:add", "needs a single space after the colon"},
+		{"new feature: add", `type "new feature" must be a single word`},
+		{"feat(a(b)): add", "is malformed"},
+		{"chore: tidy", `type "chore" is not one of feat, fix, docs`},
+		{"fix: bug\nbody", "separated from the header by a blank line"},
+	}
+	for _, tt := range tests {
+		problems := l.Lint(tt.message)
+		if tt.expected == "" {
+			if len(problems) != 0 {
+				t.Errorf("Lint(%q): expected no problems, got %q", tt.message, problems)
+			}
+			continue
+		}
+		if len(problems) != 1 || !strings.Contains(problems[0], tt.expected) {
+			t.Errorf("Lint(%q): expected %q, got %q", tt.message, tt.expected, problems)
+		}
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	l := Linter{Rules: []Rule{
+		{Pattern: regexp.MustCompile(`^[A-Z]+-\d+ `), Message: "start with the ticket, e.g. ABC-12"},
+		{Pattern: regexp.MustCompile(`^.{1,50}(\n|$)`)},
+	}}
+	if problems := l.Lint("ABC-12 Add batch processing"); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %q", problems)
+	}
+	problems := l.Lint("feat: add batch processing to every endpoint we have ever made")
+	expected := []string{"start with the ticket, e.g. ABC-12", "the message does not match ^.{1,50}(\\n|$)"}
+	if strings.Join(problems, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %q, got %q", expected, problems)
+	}
+}
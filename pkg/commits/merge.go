@@ -0,0 +1,62 @@
+package commits
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mergeHeader matches the subjects providers write for merged pull and
+// merge requests: GitHub, GitLab and Bitbucket, in that order.
+var mergeHeader = regexp.MustCompile(`^(Merge pull request #\d+ from \S+|Merge branch '[^']+' into '[^']+'|Merged in \S+ \(pull request #\d+\))$`)
+
+// Messages returns the conventional commit messages held by message,
+// unwrapping those created by merging a pull request:
+//
+//   - a merge commit whose subject is the provider's "Merge pull request
+//     #12 from org/branch" is read from its body, which starts with the
+//     pull request title;
+//   - a squash merge whose subject is not conventional yields one message
+//     per "* type: description" item of its body, the way GitHub lists the
+//     squashed commits.
+//
+// A conventional subject is taken as it is. Any other message is returned
+// unchanged, for Parse to reject.
+func Messages(message string) []string {
+	message = strings.Trim(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	header, rest, _ := strings.Cut(message, "\n")
+	header = strings.TrimSpace(header)
+	rest = strings.Trim(rest, "\n")
+	if headerPattern.MatchString(header) {
+		return []string{message}
+	}
+	if mergeHeader.MatchString(header) && rest != "" {
+		return Messages(rest)
+	}
+
+	var items []string
+	in := false
+	for _, line := range strings.Split(rest, "\n") {
+		item, ok := strings.CutPrefix(line, "* ")
+		if !ok {
+			item, ok = strings.CutPrefix(line, "- ")
+		}
+		switch {
+		case ok:
+			// A squashed commit; those that are not conventional are
+			// skipped with the rest of their message.
+			item = strings.TrimSpace(item)
+			if in = headerPattern.MatchString(item); in {
+				items = append(items, item)
+			}
+		case in:
+			items[len(items)-1] += "\n" + strings.TrimPrefix(line, "  ")
+		}
+	}
+	for i := range items {
+		items[i] = strings.TrimRight(items[i], "\n")
+	}
+	if len(items) > 0 {
+		return items
+	}
+	return []string{message}
+}
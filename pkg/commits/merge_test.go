@@ -0,0 +1,34 @@
+package commits
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMessages(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected []string
+	}{
+		{"feat: add batch processing (#12)\n\n* wip\n\n* fix: typo", []string{"feat: add batch processing (#12)\n\n* wip\n\n* fix: typo"}},
+		{"Merge pull request #12 from octo/batch\n\nfeat: add batch processing", []string{"feat: add batch processing"}},
+		{
+			"Merge branch 'batch' into 'main'\n\nfeat(api)!: add batch processing\n\nSee merge request octo/app!12",
+			[]string{"feat(api)!: add batch processing\n\nSee merge request octo/app!12"},
+		},
+		{"Merged in batch (pull request #12)\n\nfix: handle nil", []string{"fix: handle nil"}},
+		{
+			"Add batch processing (#12)\r\n\r\n* feat: add batch processing\r\n\r\n  Batches of 100.\r\n\r\n* wip\r\n* fix: handle nil\r\n",
+			[]string{"feat: add batch processing\n\nBatches of 100.", "fix: handle nil"},
+		},
+		{"Add batch processing (#12)\n\n- feat: batches", []string{"feat: batches"}},
+		{"Merge branch 'main' into batch", []string{"Merge branch 'main' into batch"}},
+		{"Merge pull request #12 from octo/batch", []string{"Merge pull request #12 from octo/batch"}},
+		{"Add a thing\n\n* not conventional", []string{"Add a thing\n\n* not conventional"}},
+	}
+	for _, tt := range tests {
+		if got := Messages(tt.message); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Messages(%q): expected %q, got %q", tt.message, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package commits
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// revertHeader matches the subject git revert writes, `Revert "<subject>"`.
+	revertHeader = regexp.MustCompile(`^Revert "(.+)"$`)
+	// revertedLine matches the line git revert adds to the body.
+	revertedLine = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-f]{7,40})\b`)
+	// revertsTrailer matches a "Reverts: <hash>[, <hash>]" trailer.
+	revertsTrailer = regexp.MustCompile(`(?im)^Reverts: *(.+)$`)
+	hashPattern    = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+)
+
+// Revert is what a revert commit undoes.
+type Revert struct {
+	// Hashes are the commits reverted, possibly abbreviated.
+	Hashes []string
+	// Subject is the subject of the reverted commit, when the revert
+	// names it: empty for a revert known only by its hashes.
+	Subject string
+}
+
+// Reverts reports whether message reverts other commits, and which. A
+// revert is recognized by:
+//
+//   - the subject of git revert, `Revert "feat: add x"`, with the "This
+//     reverts commit <hash>." line of its body;
+//   - a "Reverts: <hash>" trailer, several hashes separated by commas or
+//     spaces, as conventional "revert: feat: add x" commits carry.
+//
+// A `Revert "..."` subject without the line still names the reverted
+// commit by its subject.
+func Reverts(message string) (Revert, bool) {
+	message = strings.Trim(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	header, rest, _ := strings.Cut(message, "\n")
+	header = strings.TrimSpace(header)
+
+	var r Revert
+	if m := revertHeader.FindStringSubmatch(header); m != nil {
+		r.Subject = m[1]
+	} else if s, ok := strings.CutPrefix(header, "revert: "); ok {
+		r.Subject = strings.TrimSpace(s)
+	}
+	for _, m := range revertedLine.FindAllStringSubmatch(rest, -1) {
+		r.Hashes = append(r.Hashes, m[1])
+	}
+	for _, m := range revertsTrailer.FindAllStringSubmatch(rest, -1) {
+		for _, h := range strings.FieldsFunc(m[1], func(c rune) bool { return c == ',' || c == ' ' }) {
+			if h = strings.ToLower(h); hashPattern.MatchString(h) {
+				r.Hashes = append(r.Hashes, h)
+			}
+		}
+	}
+	if len(r.Hashes) == 0 && !revertHeader.MatchString(header) {
+		// A "revert:" commit that names nothing it reverts is taken as
+		// any other change.
+		return Revert{}, false
+	}
+	return r, true
+}
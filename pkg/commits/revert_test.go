@@ -0,0 +1,40 @@
+package commits
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReverts(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected Revert
+		ok       bool
+	}{
+		{
+			"Revert \"feat: add x\"\n\nThis reverts commit 0a1b2c3d4e5f60718293a4b5c6d7e8f901234567.\n",
+			Revert{Hashes: []string{"0a1b2c3d4e5f60718293a4b5c6d7e8f901234567"}, Subject: "feat: add x"},
+			true,
+		},
+		{
+			"Revert \"Revert \"feat: add x\"\"\r\n\r\nThis reverts commit abcdef1.",
+			Revert{Hashes: []string{"abcdef1"}, Subject: "Revert \"feat: add x\""},
+			true,
+		},
+		{
+			"revert: feat: add x\n\nIt broke the build.\n\nReverts: 0a1b2c3, ABCDEF1 cafe",
+			Revert{Hashes: []string{"0a1b2c3", "abcdef1"}, Subject: "feat: add x"},
+			true,
+		},
+		{"Revert \"feat: add x\"", Revert{Subject: "feat: add x"}, true},
+		{"fix: undo x\n\nReverts: 0a1b2c3", Revert{Hashes: []string{"0a1b2c3"}}, true},
+		{"revert: feat: add x", Revert{}, false},
+		{"feat: add x\n\nThis reverts nothing.", Revert{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := Reverts(tt.message)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Reverts(%q): expected %+v, %v, got %+v, %v", tt.message, tt.expected, tt.ok, got, ok)
+		}
+	}
+}
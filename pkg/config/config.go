@@ -0,0 +1,1008 @@
+// Package config loads the release configuration file.
+//
+// The configuration lives in the repository root as .release.yaml (or
+// .release.yml). JSON (.release.json) and TOML (.release.toml) are accepted
+// as alternatives. Every field is optional; Default documents the values used
+// when a field, or the whole file, is absent.
+//
+// Example:
+//
+//	branches: [main]
+//	tag:
+//	  prefix: v
+//	changelog:
+//	  path: CHANGELOG.md
+//	publish:
+//	  - provider: github
+//	    repo: octo/app
+//	    assets: [dist/app_linux_amd64.tar.gz]
+//	channels:
+//	  - branch: release/*
+//	    channel: rc
+//	hooks:
+//	  pre-publish:
+//	    - make test
+package config
+
+import (
+	"errors"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Config is the full release configuration.
+type Config struct {
+	// Model is one of Models. Its preset fills Branches, Channels and Lines
+	// when the file leaves them out; see Presets.
+	Model string `yaml:"model" json:"model" toml:"model"`
+	// Branches lists the branches stable releases are cut from.
+	Branches []string  `yaml:"branches" json:"branches" toml:"branches"`
+	Tag      TagConfig `yaml:"tag" json:"tag" toml:"tag"`
+	// Versioning selects how the next version is computed.
+	Versioning VersioningConfig `yaml:"versioning" json:"versioning" toml:"versioning"`
+	Changelog  ChangelogConfig  `yaml:"changelog" json:"changelog" toml:"changelog"`
+	// Commits selects the commits analysed for a release.
+	Commits CommitsConfig   `yaml:"commits" json:"commits" toml:"commits"`
+	Publish []PublishTarget `yaml:"publish" json:"publish" toml:"publish"`
+	// Remotes lists the git remotes release tags and commits are pushed
+	// to, in order; empty means origin.
+	Remotes   []Remote        `yaml:"remotes" json:"remotes" toml:"remotes"`
+	Artifacts ArtifactsConfig `yaml:"artifacts" json:"artifacts" toml:"artifacts"`
+	Notify    []NotifyTarget  `yaml:"notify" json:"notify" toml:"notify"`
+	// Hooks maps a lifecycle stage (see HookStages) to shell commands.
+	Hooks map[string][]string `yaml:"hooks" json:"hooks" toml:"hooks"`
+	// HooksOS overrides Hooks by operating system: keyed by a GOOS value
+	// (see HookSystems), it replaces the commands of the stages it lists.
+	HooksOS map[string]map[string][]string `yaml:"hooks_os" json:"hooks_os" toml:"hooks_os"`
+	// HookShell runs hook commands; one of HookShells, empty for cmd on
+	// Windows and sh elsewhere.
+	HookShell string `yaml:"hook_shell" json:"hook_shell" toml:"hook_shell"`
+	// Projects lists independently versioned sub-projects of a monorepo.
+	// When empty, Go modules are detected from go.mod files.
+	Projects []ProjectConfig `yaml:"projects" json:"projects" toml:"projects"`
+	// Channels maps branches other than Branches to prerelease channels.
+	// They are matched in order; the first match wins.
+	Channels []ChannelConfig `yaml:"channels" json:"channels" toml:"channels"`
+	// Lines maps maintenance branches to release lines, which only take
+	// patch releases. They are matched in order; the first match wins.
+	Lines []LineConfig `yaml:"lines" json:"lines" toml:"lines"`
+	// Plugins declares external plugin executables. A plugin's name may be
+	// used as a publish provider or a notify type.
+	Plugins []PluginConfig `yaml:"plugins" json:"plugins" toml:"plugins"`
+	// VersionFiles are rewritten with the new version and committed by
+	// `release tag` before the tag is created.
+	VersionFiles []VersionFile `yaml:"version_files" json:"version_files" toml:"version_files"`
+	// Retry tunes retries of provider API calls and git pushes.
+	Retry RetryConfig `yaml:"retry" json:"retry" toml:"retry"`
+	// RateLimit paces provider API calls by their rate limit budget.
+	RateLimit RateLimitConfig `yaml:"rate_limit" json:"rate_limit" toml:"rate_limit"`
+	// Telemetry exports the metrics and traces of releases.
+	Telemetry TelemetryConfig `yaml:"telemetry" json:"telemetry" toml:"telemetry"`
+	// Preflight configures the checks run by `release preflight`.
+	Preflight PreflightConfig `yaml:"preflight" json:"preflight" toml:"preflight"`
+	// Freeze lists the windows during which `release publish` refuses to
+	// run without -force.
+	Freeze []FreezeWindow `yaml:"freeze" json:"freeze" toml:"freeze"`
+	// Train schedules the release train `release train` departs on.
+	Train TrainConfig `yaml:"train" json:"train" toml:"train"`
+	// Approval configures the approval `release approve` requires before
+	// publishing the drafts of `release draft`.
+	Approval ApprovalConfig `yaml:"approval" json:"approval" toml:"approval"`
+	// Links selects the repository web pages release notes link to.
+	Links LinksConfig `yaml:"links" json:"links" toml:"links"`
+	// Tracker configures the issue tracker release notes list the issues
+	// resolved by a release from, and that marks them released.
+	Tracker TrackerConfig `yaml:"tracker" json:"tracker" toml:"tracker"`
+	// Snapshot configures the untagged builds of `release snapshot`.
+	Snapshot SnapshotConfig `yaml:"snapshot" json:"snapshot" toml:"snapshot"`
+	// Prune configures which releases `release prune` keeps.
+	Prune PruneConfig `yaml:"prune" json:"prune" toml:"prune"`
+	// Timeline records the metadata of each release as a git note on its
+	// tagged commit, for `release history`.
+	Timeline TimelineConfig `yaml:"timeline" json:"timeline" toml:"timeline"`
+	// Secrets lists the stores provider tokens and notify credentials are
+	// looked up in, in order. The environment is always searched, last
+	// unless listed.
+	Secrets []SecretSource `yaml:"secrets" json:"secrets" toml:"secrets"`
+}
+
+// FreezeWindow is a period during which releases are frozen: from From to
+// Until, or starting at every match of Cron and lasting Duration.
+type FreezeWindow struct {
+	Name string `yaml:"name" json:"name" toml:"name"`
+	// From and Until are dates ("2026-12-20") or times
+	// ("2026-12-20T18:00"). A date Until includes the whole day.
+	From  string `yaml:"from" json:"from" toml:"from"`
+	Until string `yaml:"until" json:"until" toml:"until"`
+	// Cron is a five-field cron expression, e.g. "0 18 * * fri".
+	Cron string `yaml:"cron" json:"cron" toml:"cron"`
+	// Duration is how long a Cron window lasts, e.g. "62h".
+	Duration string `yaml:"duration" json:"duration" toml:"duration"`
+	// Timezone is the IANA time zone of the window, e.g. "Europe/Berlin";
+	// empty means UTC.
+	Timezone string `yaml:"timezone" json:"timezone" toml:"timezone"`
+}
+
+// TrainConfig is the cadence of a release train: a release is cut at
+// Start and every Every after it, whatever the commits since the last one.
+type TrainConfig struct {
+	// Start is the first departure, a date ("2026-01-06") or time
+	// ("2026-01-06T10:00").
+	Start string `yaml:"start" json:"start" toml:"start"`
+	// Every is the cadence, e.g. "336h" for a fortnight; empty runs no
+	// train.
+	Every string `yaml:"every" json:"every" toml:"every"`
+	// Window is how long after a departure the train still leaves; empty
+	// means 24h, and at most Every.
+	Window string `yaml:"window" json:"window" toml:"window"`
+	// Timezone is the IANA time zone of Start, e.g. "Europe/Berlin";
+	// empty means UTC.
+	Timezone string `yaml:"timezone" json:"timezone" toml:"timezone"`
+}
+
+// ApprovalConfig lists who must approve a drafted release. Approvals are
+// the reviews of the pull request of the release on the first GitHub or
+// GitLab publish target.
+type ApprovalConfig struct {
+	// Approvers are the logins that must all approve; empty approves every
+	// draft on `release approve`.
+	Approvers []string `yaml:"approvers" json:"approvers" toml:"approvers"`
+}
+
+// LinksConfig locates the repository's web pages, for the issue, commit
+// and compare links of release notes. Empty fields are taken from the CI
+// environment, then the first publish target.
+type LinksConfig struct {
+	// Provider is one of LinkProviders; it selects the URL layout.
+	Provider string `yaml:"provider" json:"provider" toml:"provider"`
+	// BaseURL is the web address of a self-hosted instance, e.g.
+	// "https://gitlab.example.com"; empty means the public one.
+	BaseURL string `yaml:"base_url" json:"base_url" toml:"base_url"`
+	// Repo is the owner/repo slug or project path.
+	Repo string `yaml:"repo" json:"repo" toml:"repo"`
+	// Templates replace the URL layout of Provider for code browsers it
+	// does not match, such as cgit or Bitbucket Server.
+	Templates LinkTemplates `yaml:"templates" json:"templates" toml:"templates"`
+}
+
+// LinkTemplates are URL templates of the repository's pages. {repo} is
+// the repository's web address; issue takes {issue}, pull_request {pr},
+// commit {commit}, compare {from} and {to}, and file {tag} and {path}.
+// Empty templates keep the layout of the links provider.
+type LinkTemplates struct {
+	Issue       string `yaml:"issue" json:"issue" toml:"issue"`
+	PullRequest string `yaml:"pull_request" json:"pull_request" toml:"pull_request"`
+	Commit      string `yaml:"commit" json:"commit" toml:"commit"`
+	Compare     string `yaml:"compare" json:"compare" toml:"compare"`
+	File        string `yaml:"file" json:"file" toml:"file"`
+}
+
+// TrackerConfig locates the issue tracker the keys commits mention, such
+// as "PROJ-123", are looked up in. Credentials are the JIRA_USER and
+// JIRA_TOKEN secrets.
+type TrackerConfig struct {
+	// Provider is one of TrackerProviders; empty disables the tracker.
+	Provider string `yaml:"provider" json:"provider" toml:"provider"`
+	// BaseURL is the web address of the instance, e.g.
+	// "https://example.atlassian.net".
+	BaseURL string `yaml:"base_url" json:"base_url" toml:"base_url"`
+	// Projects are the keys of the projects whose issues are looked up,
+	// e.g. PROJ; other keys are not taken for issues.
+	Projects []string `yaml:"projects" json:"projects" toml:"projects"`
+	// Transition, when set, names the transition the issues of a release
+	// are moved through once it is published, or the status it leads to,
+	// e.g. "Released".
+	Transition string `yaml:"transition" json:"transition" toml:"transition"`
+	// FixVersion adds the published version, created as needed, to the
+	// fix versions of the issues of the release.
+	FixVersion bool `yaml:"fix_version" json:"fix_version" toml:"fix_version"`
+}
+
+// SnapshotConfig configures the snapshot builds published between
+// releases, such as nightlies, versioned like 1.4.0-dev.20240601.abc1234
+// and kept on one rolling prerelease. Zero values keep the defaults.
+type SnapshotConfig struct {
+	// Tag is the tag of the rolling prerelease; empty means "nightly".
+	Tag string `yaml:"tag" json:"tag" toml:"tag"`
+	// Channel is the first prerelease identifier of snapshot versions;
+	// empty means "dev".
+	Channel string `yaml:"channel" json:"channel" toml:"channel"`
+	// Keep is the number of builds whose assets the prerelease keeps,
+	// the latest included; 0 means 5.
+	Keep int `yaml:"keep" json:"keep" toml:"keep"`
+}
+
+// PruneConfig is the retention policy of `release prune`. Drafts and
+// prereleases are kept apart, and stable releases with Stable: the Keep
+// newest of each are kept, and the others younger than MaxAge.
+type PruneConfig struct {
+	// Keep is the number of releases of each kind kept whatever their
+	// age; 0 means 5.
+	Keep int `yaml:"keep" json:"keep" toml:"keep"`
+	// MaxAge keeps the releases beyond Keep younger than it, e.g. "720h";
+	// empty keeps none beyond Keep.
+	MaxAge string `yaml:"max_age" json:"max_age" toml:"max_age"`
+	// Stable prunes stable releases too; by default every one is kept.
+	Stable bool `yaml:"stable" json:"stable" toml:"stable"`
+	// Superseded prunes the prereleases of versions released since, such
+	// as 1.2.0-rc.1 once 1.2.0 is out, whatever Keep and MaxAge say.
+	Superseded bool `yaml:"superseded" json:"superseded" toml:"superseded"`
+}
+
+// TimelineConfig enables the release timeline: `release tag` and
+// `release publish` record the version, commits, provider releases and
+// times of each release in a git note of its tagged commit, and push the
+// notes with the release.
+type TimelineConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+	// Ref is the notes ref, under refs/notes/; empty means
+	// refs/notes/release.
+	Ref string `yaml:"ref" json:"ref" toml:"ref"`
+}
+
+// SecretSource is a store of secrets such as GITHUB_TOKEN, keyed by the
+// names of the environment variables they would otherwise be read from.
+type SecretSource struct {
+	// Provider is one of SecretProviders.
+	Provider string `yaml:"provider" json:"provider" toml:"provider"`
+	// Dir is the directory of file, holding one file per secret named
+	// after it, e.g. /run/secrets.
+	Dir string `yaml:"dir" json:"dir" toml:"dir"`
+	// Address is the Vault server of vault; empty means $VAULT_ADDR.
+	Address string `yaml:"address" json:"address" toml:"address"`
+	// Mount is the key/value engine of vault; empty means "secret".
+	Mount string `yaml:"mount" json:"mount" toml:"mount"`
+	// Path is the vault secret holding the secrets as its keys.
+	Path string `yaml:"path" json:"path" toml:"path"`
+	// Region is the region of aws; empty means $AWS_REGION.
+	Region string `yaml:"region" json:"region" toml:"region"`
+	// SecretID is the aws secret, a JSON object holding the secrets as its
+	// keys.
+	SecretID string `yaml:"secret_id" json:"secret_id" toml:"secret_id"`
+}
+
+// PreflightConfig configures the release precondition checks. Every check
+// runs unless disabled here, except CI, which needs a provider and is
+// opt-in.
+type PreflightConfig struct {
+	// Before lists commands, of PreflightCommands, that run the checks
+	// first and stop when one fails.
+	Before []string `yaml:"before" json:"before" toml:"before"`
+	// AllowDirty skips the clean working tree check.
+	AllowDirty bool `yaml:"allow_dirty" json:"allow_dirty" toml:"allow_dirty"`
+	// AllowAnyBranch skips the check that HEAD is on one of Branches or a
+	// channel branch.
+	AllowAnyBranch bool `yaml:"allow_any_branch" json:"allow_any_branch" toml:"allow_any_branch"`
+	// AllowBehind skips the check that the branch is up to date with
+	// Remote.
+	AllowBehind bool `yaml:"allow_behind" json:"allow_behind" toml:"allow_behind"`
+	// CI requires the checks of HEAD to have passed on the first publish
+	// target's provider.
+	CI bool `yaml:"ci" json:"ci" toml:"ci"`
+	// RequiredFiles must exist, relative to the repository root.
+	RequiredFiles []string `yaml:"required_files" json:"required_files" toml:"required_files"`
+	// Remote is the remote compared against; empty means "origin".
+	Remote string `yaml:"remote" json:"remote" toml:"remote"`
+	// Policy lists the release policies the commits since the latest
+	// release are held to.
+	Policy PolicyConfig `yaml:"policy" json:"policy" toml:"policy"`
+}
+
+// PolicyConfig configures the release policies of the preflight checks.
+// Violations are warnings unless Strict is set.
+type PolicyConfig struct {
+	// Strict fails the checks on a violation.
+	Strict bool `yaml:"strict" json:"strict" toml:"strict"`
+	// MaxUnreleasedCommits is the number of commits beyond which a
+	// release is overdue; 0 allows any number.
+	MaxUnreleasedCommits int `yaml:"max_unreleased_commits" json:"max_unreleased_commits" toml:"max_unreleased_commits"`
+	// ReleaseNoteTypes are the commit types, such as feat, whose commits
+	// must carry a Release-Note trailer and not be hidden by
+	// commits.rules.
+	ReleaseNoteTypes []string `yaml:"release_note_types" json:"release_note_types" toml:"release_note_types"`
+	// ProtectedBranches are the branches, names or glob patterns, that
+	// must only take merges and squashed pull requests.
+	ProtectedBranches []string `yaml:"protected_branches" json:"protected_branches" toml:"protected_branches"`
+}
+
+// VersionFile is a file recording the project version. Without Key or
+// Pattern the whole file is the version.
+type VersionFile struct {
+	Path string `yaml:"path" json:"path" toml:"path"`
+	// Key is the dotted path of the version in a JSON, YAML or TOML file,
+	// e.g. "version" or "project.version".
+	Key string `yaml:"key" json:"key" toml:"key"`
+	// Pattern is a regular expression whose first group is the version.
+	Pattern string `yaml:"pattern" json:"pattern" toml:"pattern"`
+}
+
+// RetryConfig tunes the exponential backoff used for transient failures.
+// Zero values keep the defaults of pkg/retry.
+type RetryConfig struct {
+	// MaxAttempts counts the first attempt; 1 disables retries.
+	MaxAttempts int `yaml:"max_attempts" json:"max_attempts" toml:"max_attempts"`
+	// InitialDelay and MaxDelay are durations such as "500ms" or "1m".
+	InitialDelay string `yaml:"initial_delay" json:"initial_delay" toml:"initial_delay"`
+	MaxDelay     string `yaml:"max_delay" json:"max_delay" toml:"max_delay"`
+}
+
+// RateLimitConfig tunes the pacing of provider API calls. Zero values keep
+// the defaults of pkg/ratelimit.
+type RateLimitConfig struct {
+	// Reserve is the number of requests left unused: once no more remain,
+	// calls wait for the budget to reset. Zero means ratelimit.DefaultReserve.
+	Reserve int `yaml:"reserve" json:"reserve" toml:"reserve"`
+	// MinInterval spaces the calls creating content, such as uploads, e.g.
+	// "1s", to stay below GitHub's secondary rate limits.
+	MinInterval string `yaml:"min_interval" json:"min_interval" toml:"min_interval"`
+	// BatchSize is the most issues or commit authors one lookup request
+	// asks for: a GraphQL query on GitHub, 100 at most on GitLab. Zero
+	// means providerapi.DefaultSize.
+	BatchSize int `yaml:"batch_size" json:"batch_size" toml:"batch_size"`
+	// Concurrency is the most lookup requests in flight. Zero means
+	// providerapi.DefaultConcurrency.
+	Concurrency int `yaml:"concurrency" json:"concurrency" toml:"concurrency"`
+}
+
+// Remote is a git remote releases are pushed to.
+type Remote struct {
+	Name string `yaml:"name" json:"name" toml:"name"`
+	// Optional remotes, such as mirrors, only warn when a push fails. A
+	// failed push to any other remote fails the release once every remote
+	// was tried.
+	Optional bool `yaml:"optional" json:"optional" toml:"optional"`
+}
+
+// TelemetryConfig sends metrics and traces to an OpenTelemetry collector
+// over OTLP/HTTP. Export is disabled without an endpoint.
+type TelemetryConfig struct {
+	// Endpoint is the base URL of the collector, e.g.
+	// "http://localhost:4318"; empty reads OTEL_EXPORTER_OTLP_ENDPOINT.
+	Endpoint string `yaml:"endpoint" json:"endpoint" toml:"endpoint"`
+	// Headers are sent with every export, besides those of
+	// OTEL_EXPORTER_OTLP_HEADERS, which suits secrets better.
+	Headers map[string]string `yaml:"headers" json:"headers" toml:"headers"`
+	// ServiceName defaults to OTEL_SERVICE_NAME, then "release".
+	ServiceName string `yaml:"service_name" json:"service_name" toml:"service_name"`
+	// Interval is how often `release serve` exports, e.g. "30s"; empty
+	// means a minute. Other commands export once, when they finish.
+	Interval string `yaml:"interval" json:"interval" toml:"interval"`
+}
+
+// PluginConfig is an executable speaking the pkg/plugin protocol.
+type PluginConfig struct {
+	Name string `yaml:"name" json:"name" toml:"name"`
+	// Command is the executable, looked up in PATH when it has no slash.
+	Command string   `yaml:"command" json:"command" toml:"command"`
+	Args    []string `yaml:"args" json:"args" toml:"args"`
+}
+
+// ChannelConfig releases a set of branches as prereleases.
+type ChannelConfig struct {
+	// Branch is a branch name or a path.Match pattern such as "release/*".
+	Branch string `yaml:"branch" json:"branch" toml:"branch"`
+	// Channel is the prerelease identifier, e.g. "rc" for 1.3.0-rc.1.
+	Channel string `yaml:"channel" json:"channel" toml:"channel"`
+}
+
+// LineConfig releases a set of maintenance branches as patches of an
+// earlier release line.
+type LineConfig struct {
+	// Branch is a branch name or a path.Match pattern such as "release/*".
+	Branch string `yaml:"branch" json:"branch" toml:"branch"`
+	// Line is "MAJOR.x" or "MAJOR.MINOR.x". When empty it is the last path
+	// element of the branch, so "release/*" serves release/1.x, release/2.x
+	// and so on.
+	Line string `yaml:"line" json:"line" toml:"line"`
+}
+
+// ProjectConfig is an independently versioned sub-project.
+type ProjectConfig struct {
+	// Path is the project directory relative to the repository root.
+	Path string `yaml:"path" json:"path" toml:"path"`
+	// Name defaults to Path.
+	Name string `yaml:"name" json:"name" toml:"name"`
+	// TagPrefix defaults to "<path>/<tag.prefix>", e.g. "pkg/foo/v".
+	TagPrefix string `yaml:"tag_prefix" json:"tag_prefix" toml:"tag_prefix"`
+	// TagTemplate replaces TagPrefix and tag.template for this project
+	// (see TagConfig.Template).
+	TagTemplate string `yaml:"tag_template" json:"tag_template" toml:"tag_template"`
+	// Scopes are the Conventional Commit scopes of the project, such as
+	// "api" for "feat(api): ...".
+	Scopes []string `yaml:"scopes" json:"scopes" toml:"scopes"`
+	// Filter is one of ProjectFilters and selects the commits of the
+	// project: those touching Path (the default), those with one of
+	// Scopes, either, or both.
+	Filter string `yaml:"filter" json:"filter" toml:"filter"`
+}
+
+// TagConfig controls how release tags are named.
+type TagConfig struct {
+	// Prefix is prepended to the version to form the tag name.
+	Prefix string `yaml:"prefix" json:"prefix" toml:"prefix"`
+	// Template is a text/template naming tags instead of Prefix, e.g.
+	// "release-{{.Version}}" or "{{.Module}}/v{{.Version}}", executed with
+	// TagData. It must use .Version exactly once; existing tags are read
+	// back with the same template.
+	Template string `yaml:"template" json:"template" toml:"template"`
+	// Message is a text/template for the message of annotated tags,
+	// executed like publish titles (default: "chore(release): <tag>").
+	Message string `yaml:"message" json:"message" toml:"message"`
+	// Sign creates signed tags (git tag --sign).
+	Sign bool `yaml:"sign" json:"sign" toml:"sign"`
+	// Verify requires the previous release tag to carry a valid signature
+	// before a new version is computed from it.
+	Verify bool `yaml:"verify" json:"verify" toml:"verify"`
+	// SigningFormat is "openpgp", "ssh" or "x509"; empty uses git's
+	// gpg.format.
+	SigningFormat string `yaml:"signing_format" json:"signing_format" toml:"signing_format"`
+	// SigningKey is a GPG key ID or SSH key file; empty uses git's
+	// user.signingkey.
+	SigningKey string `yaml:"signing_key" json:"signing_key" toml:"signing_key"`
+	// AllowedSigners is the SSH allowed signers file used by Verify.
+	AllowedSigners string `yaml:"allowed_signers" json:"allowed_signers" toml:"allowed_signers"`
+}
+
+// TagData is the data tag templates are executed with.
+type TagData struct {
+	// Module is the directory of the module tagged, empty for the
+	// repository root.
+	Module string
+	// Name is the name of the module.
+	Name    string
+	Version string
+}
+
+// versionMark stands for the version while a tag template is split.
+const versionMark = "\x00"
+
+// TagAffixes executes the tag template text for the module in dir named
+// name and returns what comes before and after the version. At the root,
+// where .Module is empty, a leading slash is dropped, so that
+// "{{.Module}}/v{{.Version}}" tags the root module v1.2.3 as Go does.
+func TagAffixes(text, dir, name string) (prefix, suffix string, err error) {
+	tmpl, err := template.New("tag").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, TagData{Module: dir, Name: name, Version: versionMark}); err != nil {
+		return "", "", err
+	}
+	tag := b.String()
+	if dir == "" {
+		tag = strings.TrimPrefix(tag, "/")
+	}
+	if strings.Count(tag, versionMark) != 1 {
+		return "", "", errors.New("must use {{.Version}} exactly once")
+	}
+	prefix, suffix, _ = strings.Cut(tag, versionMark)
+	return prefix, suffix, nil
+}
+
+// CommitsConfig controls how the history is walked.
+type CommitsConfig struct {
+	// Traversal is one of Traversals: "all" (the default) reads every
+	// commit, "no-merges" skips merge commits and "first-parent" reads
+	// only the mainline, where each merge stands for its pull request.
+	Traversal string `yaml:"traversal" json:"traversal" toml:"traversal"`
+	// MaxCommits caps the commits read since the latest release, newest
+	// first, for histories too long to walk whole; 0 reads them all.
+	MaxCommits int `yaml:"max_commits" json:"max_commits" toml:"max_commits"`
+	// Deepen, on a shallow clone, fetches the tags and this many more
+	// commits at a time until the latest release is reached; 0 reads the
+	// clone as it is.
+	Deepen int `yaml:"deepen" json:"deepen" toml:"deepen"`
+	// Lint configures `release lint-commits`.
+	Lint LintConfig `yaml:"lint" json:"lint" toml:"lint"`
+	// Rules override how commits bump the version and which changelog
+	// group lists them; the first rule matching a commit applies.
+	Rules []CommitRule `yaml:"rules" json:"rules" toml:"rules"`
+}
+
+// CommitRule classifies the commits whose header, such as
+// "perf(db): batch writes", matches a regular expression.
+type CommitRule struct {
+	Pattern string `yaml:"pattern" json:"pattern" toml:"pattern"`
+	// Bump is one of Bumps; empty keeps the Conventional Commits level.
+	Bump string `yaml:"bump" json:"bump" toml:"bump"`
+	// Section is the changelog group title; empty keeps the group of
+	// the commit type.
+	Section string `yaml:"section" json:"section" toml:"section"`
+	// Hidden leaves the commits out of the changelog.
+	Hidden bool `yaml:"hidden" json:"hidden" toml:"hidden"`
+}
+
+// LintConfig sets the rules commit messages are linted against.
+type LintConfig struct {
+	// Types restricts the conventional commit types; empty allows any.
+	Types []string `yaml:"types" json:"types" toml:"types"`
+	// Rules replace the Conventional Commits check with regular
+	// expressions every message must match.
+	Rules []LintRule `yaml:"rules" json:"rules" toml:"rules"`
+}
+
+// LintRule is a regular expression commit messages must match.
+type LintRule struct {
+	Pattern string `yaml:"pattern" json:"pattern" toml:"pattern"`
+	// Message is shown for commits that do not match.
+	Message string `yaml:"message" json:"message" toml:"message"`
+}
+
+// VersioningConfig selects the version scheme.
+type VersioningConfig struct {
+	// Scheme is "semver" (the default) or "calver".
+	Scheme string `yaml:"scheme" json:"scheme" toml:"scheme"`
+	// Layout is the CalVer layout, one of CalVerLayouts; empty means
+	// YYYY.MM.MICRO.
+	Layout string `yaml:"layout" json:"layout" toml:"layout"`
+	// Initial is the version of the first release of a module without
+	// version tags, such as 0.1.0 or 1.0.0, whatever its commits; empty
+	// bumps 0.0.0 by them. SemVer only.
+	Initial string `yaml:"initial" json:"initial" toml:"initial"`
+	// ImportFrom names a file, such as VERSION, holding the version of a
+	// module released before its versions were tagged, relative to the
+	// module directory: until a version is tagged, releases bump it. It
+	// takes precedence over Initial.
+	ImportFrom string `yaml:"import_from" json:"import_from" toml:"import_from"`
+}
+
+// ChangelogConfig controls changelog generation.
+type ChangelogConfig struct {
+	// Path is the changelog file, relative to the repository root.
+	Path string `yaml:"path" json:"path" toml:"path"`
+	// Template is an optional path to a text/template replacing the
+	// built-in changelog section template.
+	Template string `yaml:"template" json:"template" toml:"template"`
+	// Contributors controls the contributors listed in changelog sections
+	// and release notes.
+	Contributors ContributorsConfig `yaml:"contributors" json:"contributors" toml:"contributors"`
+	// References controls the issues and pull requests linked from
+	// changelog entries.
+	References ReferencesConfig `yaml:"references" json:"references" toml:"references"`
+	// Translations keeps translations of the changelog next to it.
+	Translations TranslationsConfig `yaml:"translations" json:"translations" toml:"translations"`
+	// Upgrade writes an upgrade guide for each release with breaking
+	// changes.
+	Upgrade UpgradeConfig `yaml:"upgrade" json:"upgrade" toml:"upgrade"`
+	// Fragments keeps each release section in a file of its own instead
+	// of prepending it to Path.
+	Fragments FragmentsConfig `yaml:"fragments" json:"fragments" toml:"fragments"`
+}
+
+// FragmentsConfig stores the changelog as fragments: one <version>.md per
+// release, which release branches add without conflicting, assembled into
+// the changelog by `release changelog compile`.
+type FragmentsConfig struct {
+	// Enabled writes the section of each release to its fragment wherever
+	// it would be prepended to the changelog.
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+	// Dir holds the fragments, relative to the repository root; empty
+	// means changelog.d.
+	Dir string `yaml:"dir" json:"dir" toml:"dir"`
+}
+
+// UpgradeConfig controls the upgrade guides: one Markdown document per
+// release, collecting the BREAKING CHANGE footers of its commits, linked
+// from its changelog section and release body.
+type UpgradeConfig struct {
+	// Enabled writes the guides whenever the changelog is written.
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+	// Dir holds the guides, one <version>.md per release, relative to
+	// the repository root; empty means docs/upgrade.
+	Dir string `yaml:"dir" json:"dir" toml:"dir"`
+}
+
+// TranslationsConfig controls the translations of the changelog: for each
+// language, a CHANGELOG.<lang>.md is given the release sections it lacks
+// whenever the changelog is written.
+type TranslationsConfig struct {
+	// Languages are the BCP 47 tags, such as pt-BR, of the translations.
+	Languages []string `yaml:"languages" json:"languages" toml:"languages"`
+	// Backend translates the sections: one of TranslationBackends, empty
+	// for copy, which copies them for people to translate.
+	Backend string `yaml:"backend" json:"backend" toml:"backend"`
+	// Command is the command of the command backend, reading the text on
+	// standard input and writing its translation; "{lang}" in it is
+	// replaced by the language.
+	Command []string `yaml:"command" json:"command" toml:"command"`
+}
+
+// ReferencesConfig controls the resolution of the "#123" and "Fixes #456"
+// references of commits on the first GitHub or GitLab publish target.
+type ReferencesConfig struct {
+	// Enabled looks the references up and links each entry to the pull
+	// request it came from, with its author.
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+	// GroupByLabel groups entries by the labels of their references
+	// instead of their commit types.
+	GroupByLabel bool `yaml:"group_by_label" json:"group_by_label" toml:"group_by_label"`
+	// Labels are the sections of GroupByLabel, in order; empty means the
+	// built-in Features, Bug Fixes, Documentation and Dependencies.
+	Labels []LabelSection `yaml:"labels" json:"labels" toml:"labels"`
+}
+
+// LabelSection is a changelog section collecting the entries carrying any
+// of Labels.
+type LabelSection struct {
+	Title  string   `yaml:"title" json:"title" toml:"title"`
+	Labels []string `yaml:"labels" json:"labels" toml:"labels"`
+}
+
+// ContributorsConfig controls the contributors of a release: the authors
+// and co-authors of its commits, bots excluded.
+type ContributorsConfig struct {
+	// Enabled adds a Contributors section to changelog sections. Release
+	// notes always list the contributors.
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+	// Lookup asks the first GitHub publish target for the logins of the
+	// authors, to link their handles and list each person once.
+	Lookup bool `yaml:"lookup" json:"lookup" toml:"lookup"`
+	// Exclude lists names, emails or logins left out besides bots.
+	Exclude []string `yaml:"exclude" json:"exclude" toml:"exclude"`
+}
+
+// PublishTarget is a provider release to create on publish.
+type PublishTarget struct {
+	// Provider is "github", "gitlab", "docker", "homebrew", "npm", "pypi"
+	// or the name of a plugin.
+	Provider string `yaml:"provider" json:"provider" toml:"provider"`
+	// Repo is the owner/repo slug (GitHub), project path (GitLab), image
+	// repository (docker), e.g. "ghcr.io/org/app", tap (homebrew), e.g.
+	// "org/homebrew-tap", or package name (npm and pypi), e.g. "@org/app".
+	Repo       string   `yaml:"repo" json:"repo" toml:"repo"`
+	Assets     []string `yaml:"assets" json:"assets" toml:"assets"`
+	Milestones []string `yaml:"milestones" json:"milestones" toml:"milestones"`
+	Draft      bool     `yaml:"draft" json:"draft" toml:"draft"`
+	// Title is a text/template naming the release, executed with the data
+	// of release notes and .Date as YYYY-MM-DD, e.g.
+	// "MyApp v{{.Version}} — {{.Date}}" (default: "Release <tag>").
+	Title string `yaml:"title" json:"title" toml:"title"`
+	// Concurrency is the number of assets uploaded at once (default 4).
+	Concurrency int `yaml:"concurrency" json:"concurrency" toml:"concurrency"`
+	// Source is the local image tagged and pushed by docker; empty means
+	// Repo.
+	Source string `yaml:"source" json:"source" toml:"source"`
+	// Tags are templates for the image tags pushed by docker, with .Tag,
+	// .Version, .Major, .Minor, .Patch and .Prerelease (default: the
+	// version and, except for prereleases, latest).
+	Tags []string `yaml:"tags" json:"tags" toml:"tags"`
+	// Homebrew describes the formula updated by homebrew.
+	Homebrew HomebrewConfig `yaml:"homebrew" json:"homebrew" toml:"homebrew"`
+	// Package describes the package published by npm and pypi.
+	Package PackageConfig `yaml:"package" json:"package" toml:"package"`
+	// BaseURL is the GitHub Enterprise Server or self-hosted GitLab
+	// instance, e.g. "https://ghe.example.com", or its API endpoint
+	// (default: github.com, gitlab.com or CI_API_V4_URL).
+	BaseURL string `yaml:"base_url" json:"base_url" toml:"base_url"`
+	// APIVersion is the GitHub REST API version requested (default:
+	// 2022-11-28, or none when the server rejects it).
+	APIVersion string `yaml:"api_version" json:"api_version" toml:"api_version"`
+	// Auth is how the token is sent, one of GitHubAuth or GitLabAuth
+	// (default: bearer on GitHub, private-token on GitLab, job-token
+	// with CI_JOB_TOKEN).
+	Auth string `yaml:"auth" json:"auth" toml:"auth"`
+}
+
+// HomebrewConfig describes a Homebrew formula installing the release
+// binaries.
+type HomebrewConfig struct {
+	// Name is the formula name (default: artifacts.binary).
+	Name        string `yaml:"name" json:"name" toml:"name"`
+	Description string `yaml:"description" json:"description" toml:"description"`
+	Homepage    string `yaml:"homepage" json:"homepage" toml:"homepage"`
+	License     string `yaml:"license" json:"license" toml:"license"`
+	// Binary is the command installed (default: Name).
+	Binary string `yaml:"binary" json:"binary" toml:"binary"`
+	// Test is the Ruby body of the formula's test block.
+	Test string `yaml:"test" json:"test" toml:"test"`
+	// URL is a text/template for asset download URLs with .Tag, .Version
+	// and .Name (default: the release assets of the first github target).
+	URL string `yaml:"url" json:"url" toml:"url"`
+	// Template is an optional path to a text/template replacing the
+	// built-in formula.
+	Template string `yaml:"template" json:"template" toml:"template"`
+	// Path is the formula file in the tap (default: Formula/<name>.rb).
+	Path string `yaml:"path" json:"path" toml:"path"`
+	// Branch is the tap branch updated (default: its default branch).
+	Branch string `yaml:"branch" json:"branch" toml:"branch"`
+	// PullRequest opens a pull request instead of pushing to Branch.
+	PullRequest bool `yaml:"pull_request" json:"pull_request" toml:"pull_request"`
+}
+
+// PackageConfig describes an npm or PyPI package published at the release
+// version.
+type PackageConfig struct {
+	// Dir is the directory of package.json (npm, default ".") or of the
+	// built distributions (pypi, default "dist").
+	Dir string `yaml:"dir" json:"dir" toml:"dir"`
+	// Registry is the npm registry or the upload URL of the Python index
+	// (default: https://registry.npmjs.org/ or
+	// https://upload.pypi.org/legacy/).
+	Registry string `yaml:"registry" json:"registry" toml:"registry"`
+	// Tag is the npm dist-tag (default: latest, or the channel of a
+	// prerelease).
+	Tag string `yaml:"tag" json:"tag" toml:"tag"`
+	// Access is one of NPMAccess (default: npm's).
+	Access string `yaml:"access" json:"access" toml:"access"`
+	// Provenance publishes an npm provenance statement; on GitHub Actions
+	// the job needs the id-token: write permission.
+	Provenance bool `yaml:"provenance" json:"provenance" toml:"provenance"`
+}
+
+// ArtifactsConfig describes the release binaries built by `release build`
+// and attached to releases by `release publish`.
+type ArtifactsConfig struct {
+	// Package is the main package to build, e.g. "./cmd/app".
+	Package string `yaml:"package" json:"package" toml:"package"`
+	// Binary defaults to the last element of Package.
+	Binary string `yaml:"binary" json:"binary" toml:"binary"`
+	// Targets are GOOS/GOARCH pairs such as "linux/amd64".
+	Targets []string `yaml:"targets" json:"targets" toml:"targets"`
+	// Output is the directory binaries are written to (default "dist").
+	Output string `yaml:"output" json:"output" toml:"output"`
+	// Name is a text/template for binary names with .Binary, .Version,
+	// .OS, .Arch and .Ext.
+	Name string `yaml:"name" json:"name" toml:"name"`
+	// LDFlags is passed to go build -ldflags; it is a template like Name.
+	LDFlags string `yaml:"ldflags" json:"ldflags" toml:"ldflags"`
+	// Reproducible pins the build flags `release reproduce` rebuilds with,
+	// so that the binaries can be reproduced from the tagged commit.
+	Reproducible bool `yaml:"reproducible" json:"reproducible" toml:"reproducible"`
+	// SBOM is "cyclonedx" or "spdx" to write an SBOM of each binary.
+	SBOM string `yaml:"sbom" json:"sbom" toml:"sbom"`
+	// Sign is "cosign" or "minisign" to sign the checksum file.
+	Sign string `yaml:"sign" json:"sign" toml:"sign"`
+	// Key is the signing key for Sign.
+	Key string `yaml:"key" json:"key" toml:"key"`
+	// Provenance writes a SLSA provenance attestation of the binaries.
+	Provenance ProvenanceConfig `yaml:"provenance" json:"provenance" toml:"provenance"`
+	// Verify tells `release verify` how to check the signatures.
+	Verify VerifyConfig `yaml:"verify" json:"verify" toml:"verify"`
+}
+
+// VerifyConfig describes the signatures `release verify` accepts.
+type VerifyConfig struct {
+	// Key is the public key; it defaults to the one matching Key, e.g.
+	// "cosign.pub" for "cosign.key".
+	Key string `yaml:"key" json:"key" toml:"key"`
+	// Identity and Issuer are the certificate identity and OIDC issuer
+	// of keyless cosign signatures.
+	Identity string `yaml:"identity" json:"identity" toml:"identity"`
+	Issuer   string `yaml:"issuer" json:"issuer" toml:"issuer"`
+}
+
+// ProvenanceConfig describes the SLSA provenance written next to the
+// artifacts and attached to releases.
+type ProvenanceConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+	// BuilderID identifies the build platform; it defaults to the CI
+	// provider's runner, or local://<hostname> outside CI.
+	BuilderID string `yaml:"builder_id" json:"builder_id" toml:"builder_id"`
+	// Sign is "cosign" to sign the attestation into a Sigstore bundle,
+	// keyless unless Key is set.
+	Sign string `yaml:"sign" json:"sign" toml:"sign"`
+	// Key is the cosign key for Sign.
+	Key string `yaml:"key" json:"key" toml:"key"`
+}
+
+// NotifyTarget is a notification sent after `release publish`. Values may
+// reference environment variables as $VAR or ${VAR}, which keeps webhook
+// URLs and passwords out of the file.
+type NotifyTarget struct {
+	// Type is one of NotifyTypes or the name of a plugin.
+	Type string `yaml:"type" json:"type" toml:"type"`
+	// URL is the webhook URL for slack, discord and webhook.
+	URL string `yaml:"url" json:"url" toml:"url"`
+	// SMTP is the mail server as host:port for email.
+	SMTP     string   `yaml:"smtp" json:"smtp" toml:"smtp"`
+	From     string   `yaml:"from" json:"from" toml:"from"`
+	To       []string `yaml:"to" json:"to" toml:"to"`
+	Username string   `yaml:"username" json:"username" toml:"username"`
+	Password string   `yaml:"password" json:"password" toml:"password"`
+	// On limits the notification to "success" or "failure"; empty means
+	// both.
+	On []string `yaml:"on" json:"on" toml:"on"`
+}
+
+// NotifyTypes lists the accepted notify[].type values.
+var NotifyTypes = []string{"slack", "discord", "webhook", "email"}
+
+// TranslationBackends lists the accepted changelog.translations.backend
+// values.
+var TranslationBackends = []string{"copy", "command"}
+
+// SBOMFormats lists the accepted artifacts.sbom values.
+var SBOMFormats = []string{"cyclonedx", "spdx"}
+
+// ArtifactSigners lists the accepted artifacts.sign values.
+var ArtifactSigners = []string{"cosign", "minisign"}
+
+// ProvenanceSigners lists the accepted artifacts.provenance.sign values.
+var ProvenanceSigners = []string{"cosign"}
+
+// HookStages lists the lifecycle stages hooks may be attached to, in the
+// order they run.
+var HookStages = []string{"pre-bump", "post-changelog", "pre-publish", "post-publish"}
+
+// HookSystems lists the accepted hooks_os keys.
+var HookSystems = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "linux", "netbsd", "openbsd", "plan9", "solaris", "windows"}
+
+// HookShells lists the accepted hook_shell values.
+var HookShells = []string{"sh", "bash", "cmd", "powershell", "pwsh"}
+
+// VersionSchemes lists the accepted versioning.scheme values.
+var VersionSchemes = []string{"semver", "calver"}
+
+// CalVerLayouts lists the accepted versioning.layout values.
+var CalVerLayouts = []string{"YYYY.MM.MICRO", "YY.MM.MICRO", "YYYY.WW.MICRO", "YY.WW.MICRO"}
+
+// Traversals lists the accepted commits.traversal values.
+var Traversals = []string{"all", "no-merges", "first-parent"}
+
+// Bumps lists the accepted commits.rules[].bump values.
+var Bumps = []string{"major", "minor", "patch", "none"}
+
+// PreflightCommands lists the accepted preflight.before values.
+var PreflightCommands = []string{"tag", "publish"}
+
+// SigningFormats lists the accepted tag.signing_format values.
+var SigningFormats = []string{"openpgp", "ssh", "x509"}
+
+// ProjectFilters lists the accepted projects[].filter values.
+var ProjectFilters = []string{"path", "scope", "path_or_scope", "path_and_scope"}
+
+// GitHubAuth and GitLabAuth list the accepted publish[].auth values of
+// github and gitlab targets.
+var (
+	GitHubAuth = []string{"bearer", "token"}
+	GitLabAuth = []string{"private-token", "job-token", "bearer"}
+)
+
+// LinkProviders lists the accepted links.provider values.
+var LinkProviders = []string{"github", "gitlab", "gitea", "bitbucket"}
+
+// TrackerProviders lists the accepted tracker.provider values.
+var TrackerProviders = []string{"jira"}
+
+// SecretProviders lists the accepted secrets[].provider values.
+var SecretProviders = []string{"env", "file", "vault", "aws"}
+
+// Providers lists the supported publish providers.
+var Providers = []string{"github", "gitlab", "docker", "homebrew", "npm", "pypi"}
+
+// NPMAccess lists the accepted publish[].package.access values.
+var NPMAccess = []string{"public", "restricted"}
+
+// Plugin returns the plugin declared with name.
+func (c *Config) Plugin(name string) (PluginConfig, bool) {
+	for _, p := range c.Plugins {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PluginConfig{}, false
+}
+
+// HooksFor returns the hooks run on goos: Hooks, with the stages listed
+// in HooksOS[goos] replaced.
+func (c *Config) HooksFor(goos string) map[string][]string {
+	over := c.HooksOS[goos]
+	if len(over) == 0 {
+		return c.Hooks
+	}
+	hooks := maps.Clone(c.Hooks)
+	if hooks == nil {
+		hooks = make(map[string][]string, len(over))
+	}
+	maps.Copy(hooks, over)
+	return hooks
+}
+
+// Default returns the configuration used when no file is present.
+func Default() *Config {
+	return &Config{
+		Branches:  []string{"main"},
+		Tag:       TagConfig{Prefix: "v"},
+		Changelog: ChangelogConfig{Path: "CHANGELOG.md"},
+	}
+}
+
+// Models lists the accepted model values, the branching models of Presets.
+var Models = []string{"gitflow", "trunk", "github-flow"}
+
+// Preset is the branch settings a branching model stands for.
+type Preset struct {
+	Branches []string
+	Channels []ChannelConfig
+	Lines    []LineConfig
+}
+
+// Presets maps each of Models to its settings:
+//
+//   - gitflow releases main, prereleases develop as beta and release/* and
+//     hotfix/* as rc, and maintains the support/* lines.
+//   - trunk releases main and takes patches backported to release/*
+//     branches, one per line such as release/1.x.
+//   - github-flow releases main and nothing else.
+//
+// A field set in the file, even to an empty list, replaces that of the
+// preset.
+var Presets = map[string]Preset{
+	"gitflow": {
+		Branches: []string{"main"},
+		Channels: []ChannelConfig{
+			{Branch: "develop", Channel: "beta"},
+			{Branch: "release/*", Channel: "rc"},
+			{Branch: "hotfix/*", Channel: "rc"},
+		},
+		Lines: []LineConfig{{Branch: "support/*"}},
+	},
+	"trunk": {
+		Branches: []string{"main"},
+		Lines:    []LineConfig{{Branch: "release/*"}},
+	},
+	"github-flow": {
+		Branches: []string{"main"},
+	},
+}
+
+// Channel returns the prerelease channel for releases cut from branch, or
+// "" for stable releases: branches listed in Branches, and branches no
+// channel matches.
+func (c *Config) Channel(branch string) string {
+	if slices.Contains(c.Branches, branch) {
+		return ""
+	}
+	for _, ch := range c.Channels {
+		if ok, _ := path.Match(ch.Branch, branch); ok {
+			return ch.Channel
+		}
+	}
+	return ""
+}
+
+// Line returns the release line maintained on branch, or "" when branch
+// is not a maintenance branch. Branches listed in Branches never are.
+func (c *Config) Line(branch string) string {
+	if slices.Contains(c.Branches, branch) {
+		return ""
+	}
+	for _, l := range c.Lines {
+		if ok, _ := path.Match(l.Branch, branch); ok {
+			if l.Line == "" {
+				return path.Base(branch)
+			}
+			return l.Line
+		}
+	}
+	return ""
+}
+
+// applyDefaults fills fields left empty by a configuration file.
+func (c *Config) applyDefaults() {
+	if p, ok := Presets[c.Model]; ok {
+		if c.Branches == nil {
+			c.Branches = slices.Clone(p.Branches)
+		}
+		if c.Channels == nil {
+			c.Channels = slices.Clone(p.Channels)
+		}
+		if c.Lines == nil {
+			c.Lines = slices.Clone(p.Lines)
+		}
+	}
+	d := Default()
+	if len(c.Branches) == 0 {
+		c.Branches = d.Branches
+	}
+	if c.Tag.Prefix == "" {
+		c.Tag.Prefix = d.Tag.Prefix
+	}
+	if c.Changelog.Path == "" {
+		c.Changelog.Path = d.Changelog.Path
+	}
+}
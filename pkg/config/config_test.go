@@ -0,0 +1,496 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const yamlConfig = `
+branches: [main, release/1.x]
+tag:
+  prefix: release-
+changelog:
+  path: docs/CHANGELOG.md
+publish:
+  - provider: github
+    repo: octo/app
+    assets: [dist/app.tar.gz]
+hooks:
+  pre-publish:
+    - make test
+`
+
+func expectedConfig() *Config {
+	return &Config{
+		Branches:  []string{"main", "release/1.x"},
+		Tag:       TagConfig{Prefix: "release-"},
+		Changelog: ChangelogConfig{Path: "docs/CHANGELOG.md"},
+		Publish:   []PublishTarget{{Provider: "github", Repo: "octo/app", Assets: []string{"dist/app.tar.gz"}}},
+		Hooks:     map[string][]string{"pre-publish": {"make test"}},
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	tests := map[string]string{
+		"yaml": yamlConfig,
+		"json": `{
+			"branches": ["main", "release/1.x"],
+			"tag": {"prefix": "release-"},
+			"changelog": {"path": "docs/CHANGELOG.md"},
+			"publish": [{"provider": "github", "repo": "octo/app", "assets": ["dist/app.tar.gz"]}],
+			"hooks": {"pre-publish": ["make test"]}
+		}`,
+		"toml": `
+branches = ["main", "release/1.x"]
+
+[tag]
+prefix = "release-"
+
+[changelog]
+path = "docs/CHANGELOG.md"
+
+[[publish]]
+provider = "github"
+repo = "octo/app"
+assets = ["dist/app.tar.gz"]
+
+[hooks]
+pre-publish = ["make test"]
+`,
+	}
+
+	for format, data := range tests {
+		result, err := Parse([]byte(data), format)
+		if err != nil {
+			t.Errorf("Parse(%s): unexpected error: %v", format, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, expectedConfig()) {
+			t.Errorf("Parse(%s): expected %#v, got %#v", format, expectedConfig(), result)
+		}
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	for _, data := range []string{"", "tag: {}\n"} {
+		result, err := Parse([]byte(data), "yaml")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(result, Default()) {
+			t.Errorf("Expected defaults %#v, got %#v", Default(), result)
+		}
+	}
+}
+
+func TestParseModel(t *testing.T) {
+	c, err := Parse([]byte("model: gitflow\n"), "yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Channel("develop") != "beta" || c.Channel("release/1.3") != "rc" || c.Channel("hotfix/crash") != "rc" {
+		t.Errorf("Expected the gitflow channels, got %+v", c.Channels)
+	}
+	if c.Line("support/1.x") != "1.x" || !reflect.DeepEqual(c.Branches, []string{"main"}) {
+		t.Errorf("Expected main released and support/* maintained, got %v and %+v", c.Branches, c.Lines)
+	}
+
+	tests := map[string]string{
+		"yaml": "model: gitflow\nbranches: [master]\nlines: []\n",
+		"json": `{"model": "gitflow", "branches": ["master"], "lines": []}`,
+		"toml": "model = \"gitflow\"\nbranches = [\"master\"]\nlines = []\n",
+	}
+	for format, data := range tests {
+		c, err := Parse([]byte(data), format)
+		if err != nil {
+			t.Fatalf("Parse(%s): unexpected error: %v", format, err)
+		}
+		if !reflect.DeepEqual(c.Branches, []string{"master"}) || len(c.Lines) != 0 || len(c.Channels) != 3 {
+			t.Errorf("Parse(%s): Expected the preset overridden field by field, got %v, %+v and %+v", format, c.Branches, c.Lines, c.Channels)
+		}
+	}
+
+	c, err = Parse([]byte("model: trunk\n"), "yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Line("release/2.x") != "2.x" || c.Channel("release/2.x") != "" {
+		t.Errorf("Expected release/* maintained, got %+v and %+v", c.Lines, c.Channels)
+	}
+	if _, err := Parse([]byte("model: gitlab-flow\n"), "yaml"); err == nil || !strings.Contains(err.Error(), "model") {
+		t.Errorf("Expected an unknown model error, got %v", err)
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	tests := map[string]string{
+		"yaml": "brnaches: [main]\n",
+		"json": `{"brnaches": ["main"]}`,
+		"toml": `brnaches = ["main"]`,
+	}
+
+	for format, data := range tests {
+		_, err := Parse([]byte(data), format)
+		if err == nil || !strings.Contains(err.Error(), "brnaches") {
+			t.Errorf("Parse(%s): expected unknown field error, got %v", format, err)
+		}
+	}
+}
+
+func TestParseUnsupportedFormat(t *testing.T) {
+	if _, err := Parse([]byte("x"), "ini"); err == nil {
+		t.Errorf("Expected error for unsupported format")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Default()
+	c.Model = "gitlab-flow"
+	c.Branches = []string{""}
+	c.Tag.Prefix = "v "
+	c.Tag.Template = "release-{{.Module}}"
+	c.Tag.SigningFormat = "pgp"
+	c.Publish = []PublishTarget{{Provider: "bitbucket", Concurrency: -1}, {Provider: "github", Repo: "octo/app", Tags: []string{""}, Homebrew: HomebrewConfig{Name: "app"}, BaseURL: "ghe.example.com", Auth: "basic"}, {Provider: "docker", Repo: "ghcr.io/octo/app", Auth: "bearer", APIVersion: "v4", Package: PackageConfig{Dir: "web"}}, {Provider: "pypi", Repo: "app", Package: PackageConfig{Access: "open", Registry: "pypi.org"}}}
+	c.Hooks = map[string][]string{"pre-lunch": {"eat"}, "pre-publish": {" "}}
+	c.HooksOS = map[string]map[string][]string{"macos": {"pre-publish": {"make"}}, "windows": {"pre-lunch": {"eat"}, "post-publish": {""}}}
+	c.HookShell = "zsh"
+	c.Projects = []ProjectConfig{{Path: "a"}, {Path: "a"}, {}, {Path: "b", TagTemplate: "b {{.Version}}"}, {Path: "c", Filter: "scope"}, {Path: "d", Filter: "tree", Scopes: []string{""}}}
+	c.Channels = []ChannelConfig{{Branch: "[", Channel: "rc"}, {Branch: "next", Channel: "42"}}
+	c.Lines = []LineConfig{{Branch: "", Line: "1.x"}, {Branch: "maint", Line: "1.4"}}
+	c.Notify = []NotifyTarget{{Type: "pager"}, {Type: "slack", On: []string{"always"}}}
+	c.Artifacts = ArtifactsConfig{Targets: []string{"linux/amd64", "linux"}, SBOM: "swid", Sign: "gpg", Provenance: ProvenanceConfig{Sign: "minisign"}}
+	c.Plugins = []PluginConfig{{Name: "slack", Command: "x"}, {Name: "p"}, {Name: "p", Command: "x"}}
+	c.VersionFiles = []VersionFile{{Key: "version"}, {Path: "setup.cfg", Key: "version"}, {Path: "main.go", Pattern: "v.*"}}
+	c.Versioning = VersioningConfig{Scheme: "romver", Layout: "YYYY.MM.DD", Initial: "1.0.0-rc.1", ImportFrom: "/VERSION"}
+	c.Commits.Traversal = "sideways"
+	c.Freeze = []FreezeWindow{{From: "2026-12-20"}, {Cron: "0 18 * * fri", Until: "2027-01-02"}, {Cron: "0 18 *", Duration: "-1h", Timezone: "Mars/Olympus"}}
+	c.Train = TrainConfig{Start: "every other tuesday", Every: "2w", Window: "-1h", Timezone: "Mars/Olympus"}
+	c.Approval.Approvers = []string{"ada", " "}
+	c.Links = LinksConfig{Provider: "sourcehut", BaseURL: "gitlab.example.com", Templates: LinkTemplates{Commit: "{repo}/commit/?id={commit}", Compare: "cgit/diff/?id={to}", File: "{repo}/tree/{path}"}}
+	c.Tracker = TrackerConfig{Provider: "linear", BaseURL: "jira", Projects: []string{"PROJ", "proj-1"}}
+	c.Snapshot = SnapshotConfig{Tag: "night ly", Channel: "42", Keep: -1}
+	c.Prune = PruneConfig{Keep: -1, MaxAge: "30d"}
+	c.Timeline = TimelineConfig{Enabled: true, Ref: "release"}
+	c.Secrets = []SecretSource{{Provider: "keychain"}, {Provider: "file"}, {Provider: "vault"}, {Provider: "aws"}, {Provider: "env"}}
+	c.Changelog.References.Labels = []LabelSection{{Labels: []string{"bug"}}, {Title: "Fixes"}}
+	c.Changelog.Translations = TranslationsConfig{Languages: []string{"pt-BR", "pt_br"}, Backend: "command"}
+	c.Changelog.Upgrade = UpgradeConfig{Enabled: true, Dir: "../docs"}
+	c.Changelog.Fragments = FragmentsConfig{Enabled: true, Dir: "/changelog.d"}
+	c.Commits.Lint = LintConfig{Types: []string{"feat", "Fix!"}, Rules: []LintRule{{Pattern: "("}}}
+	c.Commits.Rules = []CommitRule{{Pattern: "[", Bump: "huge"}, {Pattern: "^deps:", Section: "Deps", Hidden: true}}
+	c.Commits.MaxCommits, c.Commits.Deepen = -1, -100
+	c.Retry = RetryConfig{MaxAttempts: -1, InitialDelay: "soon", MaxDelay: "-1s"}
+	c.RateLimit = RateLimitConfig{Reserve: -1, MinInterval: "often", BatchSize: -1, Concurrency: -2}
+	c.Remotes = []Remote{{Name: "origin"}, {Name: ""}, {Name: "origin", Optional: true}}
+	c.Telemetry = TelemetryConfig{Endpoint: "localhost:4318", Interval: "0s"}
+	c.Preflight = PreflightConfig{Before: []string{"tag", "build"}, RequiredFiles: []string{""}, Policy: PolicyConfig{MaxUnreleasedCommits: -1, ReleaseNoteTypes: []string{"Feat"}, ProtectedBranches: []string{"main", "["}}}
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatalf("Expected validation errors")
+	}
+
+	for _, want := range []string{
+		"model",
+		"branches[0]",
+		"tag.prefix",
+		"versioning.initial",
+		"versioning.import_from",
+		"tag.template",
+		"tag.signing_format",
+		"freeze[0]: needs from and until",
+		"freeze[1]: cron and from/until",
+		"freeze[2].cron",
+		"freeze[2].duration",
+		"freeze[2].timezone",
+		"train.every",
+		"train.window",
+		"train.start",
+		"train.timezone",
+		"approval.approvers[1]",
+		"links.provider",
+		"links.base_url",
+		"links.templates.compare",
+		"links.templates.file",
+		"tracker.provider",
+		"tracker.base_url",
+		"tracker.projects[1]",
+		"snapshot.tag",
+		"snapshot.channel",
+		"snapshot.keep",
+		"prune.keep",
+		"prune.max_age",
+		"timeline.ref",
+		"secrets[0].provider",
+		"secrets[1].dir",
+		"secrets[2].path",
+		"secrets[3].secret_id",
+		"changelog.references.labels[0].title",
+		"changelog.references.labels[1].labels",
+		"changelog.translations.languages[1]",
+		"changelog.translations.command",
+		"changelog.upgrade.dir",
+		"changelog.fragments.dir",
+		"publish[0].provider",
+		"publish[0].repo",
+		"publish[0].concurrency",
+		"publish[1]: source and tags",
+		"publish[1].tags[0]",
+		"publish[1].homebrew",
+		"publish[1].base_url",
+		"publish[1].auth",
+		"publish[2]: base_url and auth",
+		"publish[2].api_version",
+		"publish[2].package: only used with providers npm and pypi",
+		"publish[3].package: tag, access and provenance",
+		"publish[3].package.access",
+		"publish[3].package.registry",
+		"commits.traversal",
+		"commits.max_commits",
+		"commits.deepen",
+		"commits.lint.types[1]",
+		"commits.lint.rules[0].pattern",
+		"commits.rules[0].pattern",
+		"commits.rules[0].bump",
+		"commits.rules[1]: section and hidden",
+		"hooks.pre-lunch",
+		"hooks.pre-publish[0]",
+		"hooks_os.macos: unknown operating system",
+		"hooks_os.windows.pre-lunch",
+		"hooks_os.windows.post-publish[0]",
+		"hook_shell",
+		"projects[1].path: duplicate",
+		"projects[2].path",
+		"projects[3].tag_template",
+		"projects[4].scopes: filter scope",
+		"projects[5].filter",
+		"projects[5].scopes[0]",
+		"channels[0].branch",
+		"channels[1].channel",
+		"lines[0].branch",
+		"lines[1].line",
+		"artifacts.package",
+		"notify[0].type",
+		"notify[1].on",
+		"artifacts.targets[1]",
+		"artifacts.sbom",
+		"artifacts.sign",
+		"artifacts.provenance.sign",
+		"plugins[0].name",
+		"plugins[1].command",
+		"plugins[2].name: duplicate",
+		"version_files[0].path",
+		"version_files[1].key",
+		"version_files[2].pattern",
+		"versioning.scheme",
+		"versioning.layout",
+		"retry.max_attempts",
+		"retry.initial_delay",
+		"retry.max_delay",
+		"rate_limit.reserve",
+		"rate_limit.batch_size",
+		"rate_limit.concurrency",
+		"rate_limit.min_interval",
+		"remotes[1].name",
+		"remotes[2].name: duplicate",
+		"telemetry.endpoint",
+		"telemetry.interval",
+		"preflight.before[1]",
+		"preflight.required_files[0]",
+		"preflight.policy.max_unreleased_commits",
+		"preflight.policy.release_note_types[0]",
+		"preflight.policy.protected_branches[1]",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidatePluginNames(t *testing.T) {
+	c := Default()
+	c.Plugins = []PluginConfig{{Name: "s3", Command: "release-plugin-s3"}}
+	c.Publish = []PublishTarget{{Provider: "s3", Repo: "bucket"}}
+	c.Notify = []NotifyTarget{{Type: "s3"}}
+
+	if err := c.Validate(); err != nil {
+		t.Errorf("Expected plugin names to be accepted, got %v", err)
+	}
+	if _, ok := c.Plugin("s3"); !ok {
+		t.Errorf("Expected plugin s3 to be found")
+	}
+	if _, ok := c.Plugin("gcs"); ok {
+		t.Errorf("Expected plugin gcs not to be found")
+	}
+}
+
+func TestChannel(t *testing.T) {
+	c := Default()
+	c.Branches = []string{"main", "next"}
+	c.Channels = []ChannelConfig{
+		{Branch: "next", Channel: "beta"},
+		{Branch: "release/*", Channel: "rc"},
+		{Branch: "*", Channel: "alpha"},
+	}
+
+	tests := map[string]string{
+		"main":        "",
+		"next":        "",
+		"release/1.3": "rc",
+		"feature-x":   "alpha",
+		"feature/x/y": "",
+	}
+	for branch, expected := range tests {
+		if got := c.Channel(branch); got != expected {
+			t.Errorf("Channel(%q): Expected %q, got %q", branch, expected, got)
+		}
+	}
+}
+
+func TestLine(t *testing.T) {
+	c := Default()
+	c.Lines = []LineConfig{
+		{Branch: "maint", Line: "1.4.x"},
+		{Branch: "release/*"},
+		{Branch: "main", Line: "2.x"},
+	}
+
+	tests := map[string]string{
+		"main":        "",
+		"maint":       "1.4.x",
+		"release/1.x": "1.x",
+		"release/v2":  "v2",
+		"feature-x":   "",
+	}
+	for branch, expected := range tests {
+		if got := c.Line(branch); got != expected {
+			t.Errorf("Line(%q): Expected %q, got %q", branch, expected, got)
+		}
+	}
+}
+
+func TestHooksFor(t *testing.T) {
+	c := Default()
+	c.Hooks = map[string][]string{"pre-bump": {"make gen"}, "pre-publish": {"make test"}}
+	c.HooksOS = map[string]map[string][]string{"windows": {"pre-publish": {"nmake test"}}}
+
+	expected := map[string][]string{"pre-bump": {"make gen"}, "pre-publish": {"nmake test"}}
+	if got := c.HooksFor("windows"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got := c.HooksFor("linux"); !reflect.DeepEqual(got, c.Hooks) {
+		t.Errorf("Expected %v, got %v", c.Hooks, got)
+	}
+	if c.Hooks["pre-publish"][0] != "make test" {
+		t.Errorf("Expected HooksFor not to modify Hooks, got %v", c.Hooks)
+	}
+}
+
+func TestTagAffixes(t *testing.T) {
+	tests := []struct {
+		text, dir      string
+		prefix, suffix string
+	}{
+		{"v{{.Version}}", "", "v", ""},
+		{"release-{{.Version}}", "pkg/app", "release-", ""},
+		{"{{.Module}}/v{{.Version}}", "pkg/app", "pkg/app/v", ""},
+		{"{{.Module}}/v{{.Version}}", "", "v", ""},
+		{"{{.Name}}@{{.Version}}+final", "pkg/app", "app@", "+final"},
+	}
+	for _, tt := range tests {
+		prefix, suffix, err := TagAffixes(tt.text, tt.dir, "app")
+		if err != nil {
+			t.Errorf("TagAffixes(%q): unexpected error: %v", tt.text, err)
+			continue
+		}
+		if prefix != tt.prefix || suffix != tt.suffix {
+			t.Errorf("TagAffixes(%q, %q): expected %q and %q, got %q and %q", tt.text, tt.dir, tt.prefix, tt.suffix, prefix, suffix)
+		}
+	}
+
+	for _, text := range []string{"v", "{{.Version}}-{{.Version}}", "{{.Tag}}{{.Version}}", "{{"} {
+		if _, _, err := TagAffixes(text, "", "app"); err == nil {
+			t.Errorf("TagAffixes(%q): expected an error", text)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+
+	c, path, err := Find(dir)
+	if err != nil || path != "" || !reflect.DeepEqual(c, Default()) {
+		t.Errorf("Expected defaults without a file, got %#v %q %v", c, path, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".release.toml"), []byte(`branches = ["trunk"]`), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".release.yaml"), []byte(yamlConfig), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, path, err = Find(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filepath.Base(path) != ".release.yaml" {
+		t.Errorf("Expected .release.yaml to take precedence, got %q", path)
+	}
+	if !reflect.DeepEqual(c, expectedConfig()) {
+		t.Errorf("Expected %#v, got %#v", expectedConfig(), c)
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".release.yaml")
+	if err := os.WriteFile(path, []byte("publish: [{provider: svn, repo: x}]\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Expected error mentioning the path, got %v", err)
+	}
+}
+
+func TestScaffoldEncode(t *testing.T) {
+	s := Scaffold{
+		Branches:   []string{"trunk"},
+		Tag:        ScaffoldTag{Prefix: "v"},
+		Versioning: ScaffoldVersioning{Initial: "1.0.0"},
+		Changelog:  ScaffoldChangelog{Path: "CHANGELOG.md"},
+	}
+	for _, format := range []string{"yaml", "json", "toml"} {
+		data, err := s.Encode(format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		c, err := Parse(data, format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if !reflect.DeepEqual(c.Branches, []string{"trunk"}) || c.Versioning.Initial != "1.0.0" || c.Changelog.Path != "CHANGELOG.md" {
+			t.Errorf("%s: Expected the scaffold back, got %+v", format, c)
+		}
+		if strings.Contains(string(data), "import_from") {
+			t.Errorf("%s: Expected the unset import_from to be left out, got %s", format, data)
+		}
+	}
+
+	expected := "branches:\n  - trunk\ntag:\n  prefix: v\nversioning:\n  initial: 1.0.0\nchangelog:\n  path: CHANGELOG.md\n"
+	if data, _ := s.Encode("yaml"); string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	s.Versioning.Initial = "one"
+	if _, err := s.Encode("yaml"); err == nil || !strings.Contains(err.Error(), "versioning.initial") {
+		t.Errorf("Expected an invalid scaffold to be refused, got %v", err)
+	}
+	if _, err := s.Encode("ini"); err == nil {
+		t.Errorf("Expected an unsupported format to fail")
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the configuration files Find looks for, in order of
+// preference.
+var FileNames = []string{".release.yaml", ".release.yml", ".release.json", ".release.toml"}
+
+// Load reads, defaults and validates the configuration file at path. The
+// format is chosen from the file extension.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	c, err := Parse(data, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Find loads the first of FileNames present in dir. When none exists it
+// returns Default and an empty path.
+func Find(dir string) (*Config, string, error) {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		c, err := Load(path)
+		return c, path, err
+	}
+	return Default(), "", nil
+}
+
+// Parse decodes data in the given format ("yaml", "yml", "json" or "toml"),
+// applies defaults and validates the result. Unknown keys are rejected so
+// that typos do not silently fall back to defaults.
+func Parse(data []byte, format string) (*Config, error) {
+	c := &Config{}
+
+	switch format {
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return nil, err
+		}
+	case "toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return nil, err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("unknown field %q", undecoded[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	c.applyDefaults()
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Scaffold is the configuration `release init` writes for a new project:
+// the settings it most often changes, the others left to their defaults.
+type Scaffold struct {
+	Branches   []string           `yaml:"branches" json:"branches" toml:"branches"`
+	Tag        ScaffoldTag        `yaml:"tag" json:"tag" toml:"tag"`
+	Versioning ScaffoldVersioning `yaml:"versioning" json:"versioning" toml:"versioning"`
+	Changelog  ScaffoldChangelog  `yaml:"changelog" json:"changelog" toml:"changelog"`
+}
+
+// ScaffoldTag is the tag section of a Scaffold.
+type ScaffoldTag struct {
+	Prefix string `yaml:"prefix" json:"prefix" toml:"prefix"`
+}
+
+// ScaffoldVersioning is the versioning section of a Scaffold; it sets
+// either the first version or the file it is imported from.
+type ScaffoldVersioning struct {
+	Initial    string `yaml:"initial,omitempty" json:"initial,omitempty" toml:"initial,omitempty"`
+	ImportFrom string `yaml:"import_from,omitempty" json:"import_from,omitempty" toml:"import_from,omitempty"`
+}
+
+// ScaffoldChangelog is the changelog section of a Scaffold.
+type ScaffoldChangelog struct {
+	Path string `yaml:"path" json:"path" toml:"path"`
+}
+
+// Encode writes s in the given format ("yaml", "yml", "json" or "toml"),
+// after checking that Parse accepts the result.
+func (s Scaffold) Encode(format string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "yaml", "yml":
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(s); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s); err != nil {
+			return nil, err
+		}
+	case "toml":
+		if err := toml.NewEncoder(&buf).Encode(s); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if _, err := Parse(buf.Bytes(), format); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
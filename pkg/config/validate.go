@@ -0,0 +1,563 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/gbrennon/release_automation_golang/pkg/train"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// Validate checks c for values that cannot work, reporting every problem
+// found rather than only the first.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Model != "" && !slices.Contains(Models, c.Model) {
+		errs = append(errs, fmt.Errorf("model: %q must be one of %s", c.Model, strings.Join(Models, ", ")))
+	}
+	if len(c.Branches) == 0 {
+		errs = append(errs, errors.New("branches: at least one branch is required"))
+	}
+	for i, b := range c.Branches {
+		if strings.TrimSpace(b) == "" {
+			errs = append(errs, fmt.Errorf("branches[%d]: must not be empty", i))
+		}
+	}
+
+	if strings.ContainsAny(c.Tag.Prefix, " \t\n~^:?*[\\") {
+		errs = append(errs, fmt.Errorf("tag.prefix: %q is not valid in a git ref name", c.Tag.Prefix))
+	}
+	if c.Tag.Template != "" {
+		if err := validTagTemplate(c.Tag.Template); err != nil {
+			errs = append(errs, fmt.Errorf("tag.template: %w", err))
+		}
+	}
+
+	if c.Tag.SigningFormat != "" && !slices.Contains(SigningFormats, c.Tag.SigningFormat) {
+		errs = append(errs, fmt.Errorf("tag.signing_format: %q must be one of %s", c.Tag.SigningFormat, strings.Join(SigningFormats, ", ")))
+	}
+
+	if c.Versioning.Scheme != "" && !slices.Contains(VersionSchemes, c.Versioning.Scheme) {
+		errs = append(errs, fmt.Errorf("versioning.scheme: %q must be one of %s", c.Versioning.Scheme, strings.Join(VersionSchemes, ", ")))
+	}
+	if c.Versioning.Layout != "" {
+		switch {
+		case c.Versioning.Scheme != "calver":
+			errs = append(errs, errors.New("versioning.layout: only used with scheme calver"))
+		case !slices.Contains(CalVerLayouts, c.Versioning.Layout):
+			errs = append(errs, fmt.Errorf("versioning.layout: %q must be one of %s", c.Versioning.Layout, strings.Join(CalVerLayouts, ", ")))
+		}
+	}
+	if iv := c.Versioning.Initial; iv != "" {
+		switch v, err := version.Parse(strings.TrimPrefix(iv, "v")); {
+		case c.Versioning.Scheme == "calver":
+			errs = append(errs, errors.New("versioning.initial: not used with scheme calver"))
+		case err != nil || v.IsPrerelease():
+			errs = append(errs, fmt.Errorf("versioning.initial: %q is not a stable version such as 0.1.0 or 1.0.0", iv))
+		}
+	}
+	if f := c.Versioning.ImportFrom; f != "" && filepath.IsAbs(f) {
+		errs = append(errs, fmt.Errorf("versioning.import_from: %q must be relative to the module directory", f))
+	}
+
+	if c.Commits.Traversal != "" && !slices.Contains(Traversals, c.Commits.Traversal) {
+		errs = append(errs, fmt.Errorf("commits.traversal: %q must be one of %s", c.Commits.Traversal, strings.Join(Traversals, ", ")))
+	}
+	if c.Commits.MaxCommits < 0 {
+		errs = append(errs, errors.New("commits.max_commits: must not be negative"))
+	}
+	if c.Commits.Deepen < 0 {
+		errs = append(errs, errors.New("commits.deepen: must not be negative"))
+	}
+	for i, typ := range c.Commits.Lint.Types {
+		if !commitType.MatchString(typ) {
+			errs = append(errs, fmt.Errorf("commits.lint.types[%d]: %q is not a commit type", i, typ))
+		}
+	}
+	for i, r := range c.Commits.Lint.Rules {
+		if _, err := regexp.Compile(r.Pattern); r.Pattern == "" || err != nil {
+			errs = append(errs, fmt.Errorf("commits.lint.rules[%d].pattern: %q is not a valid regular expression", i, r.Pattern))
+		}
+	}
+	for i, r := range c.Commits.Rules {
+		if _, err := regexp.Compile(r.Pattern); r.Pattern == "" || err != nil {
+			errs = append(errs, fmt.Errorf("commits.rules[%d].pattern: %q is not a valid regular expression", i, r.Pattern))
+		}
+		if r.Bump != "" && !slices.Contains(Bumps, r.Bump) {
+			errs = append(errs, fmt.Errorf("commits.rules[%d].bump: %q must be one of %s", i, r.Bump, strings.Join(Bumps, ", ")))
+		}
+		if r.Hidden && r.Section != "" {
+			errs = append(errs, fmt.Errorf("commits.rules[%d]: section and hidden are mutually exclusive", i))
+		}
+	}
+
+	if c.Changelog.Path == "" {
+		errs = append(errs, errors.New("changelog.path: must not be empty"))
+	}
+	for i, l := range c.Changelog.References.Labels {
+		if l.Title == "" {
+			errs = append(errs, fmt.Errorf("changelog.references.labels[%d].title: must not be empty", i))
+		}
+		if len(l.Labels) == 0 {
+			errs = append(errs, fmt.Errorf("changelog.references.labels[%d].labels: must not be empty", i))
+		}
+	}
+	for _, d := range []struct{ key, dir string }{{"changelog.upgrade.dir", c.Changelog.Upgrade.Dir}, {"changelog.fragments.dir", c.Changelog.Fragments.Dir}} {
+		if d.dir == "" {
+			continue
+		}
+		if clean := filepath.ToSlash(filepath.Clean(d.dir)); filepath.IsAbs(d.dir) || clean == ".." || strings.HasPrefix(clean, "../") {
+			errs = append(errs, fmt.Errorf("%s: %q must be inside the repository", d.key, d.dir))
+		}
+	}
+	tc := c.Changelog.Translations
+	for i, lang := range tc.Languages {
+		if tag, err := language.Parse(lang); err != nil || tag.String() != lang {
+			errs = append(errs, fmt.Errorf("changelog.translations.languages[%d]: %q is not a BCP 47 language tag such as pt-BR", i, lang))
+		}
+	}
+	if tc.Backend != "" && !slices.Contains(TranslationBackends, tc.Backend) {
+		errs = append(errs, fmt.Errorf("changelog.translations.backend: %q must be one of %s", tc.Backend, strings.Join(TranslationBackends, ", ")))
+	}
+	if tc.Backend == "command" && len(tc.Command) == 0 {
+		errs = append(errs, errors.New("changelog.translations.command: must not be empty for the command backend"))
+	}
+
+	for i, t := range c.Publish {
+		if _, ok := c.Plugin(t.Provider); !ok && !slices.Contains(Providers, t.Provider) {
+			errs = append(errs, fmt.Errorf("publish[%d].provider: %q must be one of %s or a plugin name", i, t.Provider, strings.Join(Providers, ", ")))
+		}
+		if t.Repo == "" {
+			errs = append(errs, fmt.Errorf("publish[%d].repo: must not be empty", i))
+		}
+		if t.Concurrency < 0 {
+			errs = append(errs, fmt.Errorf("publish[%d].concurrency: %d must not be negative", i, t.Concurrency))
+		}
+		if (t.Source != "" || len(t.Tags) > 0) && t.Provider != "docker" {
+			errs = append(errs, fmt.Errorf("publish[%d]: source and tags are only used with provider docker", i))
+		}
+		if t.Homebrew != (HomebrewConfig{}) && t.Provider != "homebrew" {
+			errs = append(errs, fmt.Errorf("publish[%d].homebrew: only used with provider homebrew", i))
+		}
+		if pkg := t.Package; pkg != (PackageConfig{}) && t.Provider != "npm" && t.Provider != "pypi" {
+			errs = append(errs, fmt.Errorf("publish[%d].package: only used with providers npm and pypi", i))
+		} else if (pkg.Tag != "" || pkg.Access != "" || pkg.Provenance) && t.Provider == "pypi" {
+			errs = append(errs, fmt.Errorf("publish[%d].package: tag, access and provenance are only used with provider npm", i))
+		}
+		if a := t.Package.Access; a != "" && !slices.Contains(NPMAccess, a) {
+			errs = append(errs, fmt.Errorf("publish[%d].package.access: %q must be one of %s", i, a, strings.Join(NPMAccess, ", ")))
+		}
+		if r := t.Package.Registry; r != "" {
+			if u, err := url.Parse(r); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("publish[%d].package.registry: %q is not an http(s) URL", i, r))
+			}
+		}
+		for j, tag := range t.Tags {
+			if tag == "" {
+				errs = append(errs, fmt.Errorf("publish[%d].tags[%d]: must not be empty", i, j))
+			}
+		}
+		if (t.BaseURL != "" || t.Auth != "") && t.Provider != "github" && t.Provider != "gitlab" {
+			errs = append(errs, fmt.Errorf("publish[%d]: base_url and auth are only used with providers github and gitlab", i))
+		}
+		if u, err := url.Parse(t.BaseURL); t.BaseURL != "" && (err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "") {
+			errs = append(errs, fmt.Errorf("publish[%d].base_url: %q is not an http(s) URL", i, t.BaseURL))
+		}
+		if t.APIVersion != "" && t.Provider != "github" {
+			errs = append(errs, fmt.Errorf("publish[%d].api_version: only used with provider github", i))
+		}
+		if auth := map[string][]string{"github": GitHubAuth, "gitlab": GitLabAuth}[t.Provider]; t.Auth != "" && auth != nil && !slices.Contains(auth, t.Auth) {
+			errs = append(errs, fmt.Errorf("publish[%d].auth: %q must be one of %s", i, t.Auth, strings.Join(auth, ", ")))
+		}
+	}
+
+	if len(c.Artifacts.Targets) > 0 && c.Artifacts.Package == "" {
+		errs = append(errs, errors.New("artifacts.package: required when targets are set"))
+	}
+	for i, t := range c.Artifacts.Targets {
+		goos, goarch, ok := strings.Cut(t, "/")
+		if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+			errs = append(errs, fmt.Errorf("artifacts.targets[%d]: %q must be GOOS/GOARCH", i, t))
+		}
+	}
+	if c.Artifacts.SBOM != "" && !slices.Contains(SBOMFormats, c.Artifacts.SBOM) {
+		errs = append(errs, fmt.Errorf("artifacts.sbom: %q must be one of %s", c.Artifacts.SBOM, strings.Join(SBOMFormats, ", ")))
+	}
+	if c.Artifacts.Sign != "" && !slices.Contains(ArtifactSigners, c.Artifacts.Sign) {
+		errs = append(errs, fmt.Errorf("artifacts.sign: %q must be one of %s", c.Artifacts.Sign, strings.Join(ArtifactSigners, ", ")))
+	}
+	if p := c.Artifacts.Provenance; p.Sign != "" && !slices.Contains(ProvenanceSigners, p.Sign) {
+		errs = append(errs, fmt.Errorf("artifacts.provenance.sign: %q must be one of %s", p.Sign, strings.Join(ProvenanceSigners, ", ")))
+	}
+
+	for i, n := range c.Notify {
+		if _, ok := c.Plugin(n.Type); !ok && !slices.Contains(NotifyTypes, n.Type) {
+			errs = append(errs, fmt.Errorf("notify[%d].type: %q must be one of %s or a plugin name", i, n.Type, strings.Join(NotifyTypes, ", ")))
+		}
+		for _, on := range n.On {
+			if on != "success" && on != "failure" {
+				errs = append(errs, fmt.Errorf("notify[%d].on: %q must be success or failure", i, on))
+			}
+		}
+	}
+
+	for i, w := range c.Freeze {
+		switch {
+		case w.Cron == "" && (w.From == "" || w.Until == ""):
+			errs = append(errs, fmt.Errorf("freeze[%d]: needs from and until, or cron and duration", i))
+		case w.Cron != "" && (w.From != "" || w.Until != ""):
+			errs = append(errs, fmt.Errorf("freeze[%d]: cron and from/until are mutually exclusive", i))
+		case w.Cron != "":
+			if len(strings.Fields(w.Cron)) != 5 {
+				errs = append(errs, fmt.Errorf("freeze[%d].cron: %q must have 5 fields", i, w.Cron))
+			}
+			if d, err := time.ParseDuration(w.Duration); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("freeze[%d].duration: %q is not a positive duration", i, w.Duration))
+			}
+		}
+		if _, err := time.LoadLocation(w.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("freeze[%d].timezone: %q is not a time zone", i, w.Timezone))
+		}
+	}
+
+	if tc := c.Train; tc != (TrainConfig{}) {
+		if d, err := time.ParseDuration(tc.Every); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("train.every: %q is not a positive duration", tc.Every))
+		}
+		if d, err := time.ParseDuration(tc.Window); tc.Window != "" && (err != nil || d <= 0) {
+			errs = append(errs, fmt.Errorf("train.window: %q is not a positive duration", tc.Window))
+		}
+		if _, err := train.ParseStart(tc.Start, nil); err != nil {
+			errs = append(errs, fmt.Errorf("train.start: %q is not a date (2006-01-02) or time (2006-01-02T15:04)", tc.Start))
+		}
+		if _, err := time.LoadLocation(tc.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("train.timezone: %q is not a time zone", tc.Timezone))
+		}
+	}
+
+	for i, login := range c.Approval.Approvers {
+		if strings.TrimSpace(login) == "" {
+			errs = append(errs, fmt.Errorf("approval.approvers[%d]: must not be empty", i))
+		}
+	}
+
+	if p := c.Links.Provider; p != "" && !slices.Contains(LinkProviders, p) {
+		errs = append(errs, fmt.Errorf("links.provider: %q must be one of %s", p, strings.Join(LinkProviders, ", ")))
+	}
+	if u, err := url.Parse(c.Links.BaseURL); c.Links.BaseURL != "" && (err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "") {
+		errs = append(errs, fmt.Errorf("links.base_url: %q is not an http(s) URL", c.Links.BaseURL))
+	}
+	lt := c.Links.Templates
+	for _, t := range []struct {
+		key, tmpl    string
+		placeholders []string
+	}{
+		{"issue", lt.Issue, []string{"{issue}"}},
+		{"pull_request", lt.PullRequest, []string{"{pr}"}},
+		{"commit", lt.Commit, []string{"{commit}"}},
+		{"compare", lt.Compare, []string{"{from}", "{to}"}},
+		{"file", lt.File, []string{"{tag}", "{path}"}},
+	} {
+		if t.tmpl == "" {
+			continue
+		}
+		if u, err := url.Parse(strings.ReplaceAll(t.tmpl, "{repo}", "https://example.com")); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("links.templates.%s: %q must start with {repo} or be an http(s) URL", t.key, t.tmpl))
+		}
+		for _, ph := range t.placeholders {
+			if !strings.Contains(t.tmpl, ph) {
+				errs = append(errs, fmt.Errorf("links.templates.%s: %q must contain %s", t.key, t.tmpl, ph))
+			}
+		}
+	}
+
+	if t := c.Tracker; t.Provider != "" {
+		if !slices.Contains(TrackerProviders, t.Provider) {
+			errs = append(errs, fmt.Errorf("tracker.provider: %q must be one of %s", t.Provider, strings.Join(TrackerProviders, ", ")))
+		}
+		if u, err := url.Parse(t.BaseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("tracker.base_url: %q is not an http(s) URL", t.BaseURL))
+		}
+		if len(t.Projects) == 0 {
+			errs = append(errs, errors.New("tracker.projects: must not be empty"))
+		}
+		for i, p := range t.Projects {
+			if !trackerProject.MatchString(p) {
+				errs = append(errs, fmt.Errorf("tracker.projects[%d]: %q is not a project key such as PROJ", i, p))
+			}
+		}
+	}
+
+	if tag := c.Snapshot.Tag; strings.ContainsAny(tag, " \t\n~^:?*[\\") {
+		errs = append(errs, fmt.Errorf("snapshot.tag: %q is not valid in a git ref name", tag))
+	}
+	if ch := c.Snapshot.Channel; ch != "" && !validChannel(ch) {
+		errs = append(errs, fmt.Errorf("snapshot.channel: %q must be a non-numeric prerelease identifier", ch))
+	}
+	if c.Snapshot.Keep < 0 {
+		errs = append(errs, fmt.Errorf("snapshot.keep: %d must not be negative", c.Snapshot.Keep))
+	}
+	if c.Prune.Keep < 0 {
+		errs = append(errs, fmt.Errorf("prune.keep: %d must not be negative", c.Prune.Keep))
+	}
+	if age := c.Prune.MaxAge; age != "" {
+		if d, err := time.ParseDuration(age); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("prune.max_age: %q is not a positive duration", age))
+		}
+	}
+	if ref := c.Timeline.Ref; ref != "" && (!strings.HasPrefix(ref, "refs/notes/") || ref == "refs/notes/" || strings.ContainsAny(ref, " ~^:?*[\\")) {
+		errs = append(errs, fmt.Errorf("timeline.ref: %q is not a notes ref such as refs/notes/release", ref))
+	}
+
+	for i, src := range c.Secrets {
+		switch src.Provider {
+		case "env":
+		case "file":
+			if src.Dir == "" {
+				errs = append(errs, fmt.Errorf("secrets[%d].dir: must not be empty", i))
+			}
+		case "vault":
+			if src.Path == "" {
+				errs = append(errs, fmt.Errorf("secrets[%d].path: must not be empty", i))
+			}
+		case "aws":
+			if src.SecretID == "" {
+				errs = append(errs, fmt.Errorf("secrets[%d].secret_id: must not be empty", i))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("secrets[%d].provider: %q must be one of %s", i, src.Provider, strings.Join(SecretProviders, ", ")))
+		}
+	}
+
+	errs = append(errs, validateHooks("hooks", c.Hooks)...)
+	for _, goos := range slices.Sorted(maps.Keys(c.HooksOS)) {
+		if !slices.Contains(HookSystems, goos) {
+			errs = append(errs, fmt.Errorf("hooks_os.%s: unknown operating system, must be one of %s", goos, strings.Join(HookSystems, ", ")))
+		}
+		errs = append(errs, validateHooks("hooks_os."+goos, c.HooksOS[goos])...)
+	}
+	if c.HookShell != "" && !slices.Contains(HookShells, c.HookShell) {
+		errs = append(errs, fmt.Errorf("hook_shell: %q must be one of %s", c.HookShell, strings.Join(HookShells, ", ")))
+	}
+
+	seen := make(map[string]bool)
+	for i, p := range c.Projects {
+		switch {
+		case strings.TrimSpace(p.Path) == "":
+			errs = append(errs, fmt.Errorf("projects[%d].path: must not be empty", i))
+		case seen[p.Path]:
+			errs = append(errs, fmt.Errorf("projects[%d].path: duplicate project %q", i, p.Path))
+		}
+		seen[p.Path] = true
+		if p.TagTemplate != "" {
+			if err := validTagTemplate(p.TagTemplate); err != nil {
+				errs = append(errs, fmt.Errorf("projects[%d].tag_template: %w", i, err))
+			}
+		}
+		if p.Filter != "" && !slices.Contains(ProjectFilters, p.Filter) {
+			errs = append(errs, fmt.Errorf("projects[%d].filter: %q must be one of %s", i, p.Filter, strings.Join(ProjectFilters, ", ")))
+		} else if p.Filter != "" && p.Filter != "path" && len(p.Scopes) == 0 {
+			errs = append(errs, fmt.Errorf("projects[%d].scopes: filter %s needs at least one scope", i, p.Filter))
+		}
+		for j, sc := range p.Scopes {
+			if strings.TrimSpace(sc) == "" {
+				errs = append(errs, fmt.Errorf("projects[%d].scopes[%d]: must not be empty", i, j))
+			}
+		}
+	}
+
+	for i, ch := range c.Channels {
+		if _, err := path.Match(ch.Branch, ""); ch.Branch == "" || err != nil {
+			errs = append(errs, fmt.Errorf("channels[%d].branch: %q is not a valid branch pattern", i, ch.Branch))
+		}
+		if !validChannel(ch.Channel) {
+			errs = append(errs, fmt.Errorf("channels[%d].channel: %q must be a non-numeric prerelease identifier", i, ch.Channel))
+		}
+	}
+
+	for i, l := range c.Lines {
+		if _, err := path.Match(l.Branch, ""); l.Branch == "" || err != nil {
+			errs = append(errs, fmt.Errorf("lines[%d].branch: %q is not a valid branch pattern", i, l.Branch))
+		}
+		if l.Line != "" && !linePattern.MatchString(l.Line) {
+			errs = append(errs, fmt.Errorf("lines[%d].line: %q must be MAJOR.x or MAJOR.MINOR.x", i, l.Line))
+		}
+	}
+
+	remotes := make(map[string]bool)
+	for i, r := range c.Remotes {
+		switch {
+		case strings.TrimSpace(r.Name) == "" || strings.ContainsAny(r.Name, " \t"):
+			errs = append(errs, fmt.Errorf("remotes[%d].name: %q is not a remote name", i, r.Name))
+		case remotes[r.Name]:
+			errs = append(errs, fmt.Errorf("remotes[%d].name: duplicate remote %q", i, r.Name))
+		}
+		remotes[r.Name] = true
+	}
+
+	names := make(map[string]bool)
+	for i, p := range c.Plugins {
+		switch {
+		case strings.TrimSpace(p.Name) == "":
+			errs = append(errs, fmt.Errorf("plugins[%d].name: must not be empty", i))
+		case names[p.Name]:
+			errs = append(errs, fmt.Errorf("plugins[%d].name: duplicate plugin %q", i, p.Name))
+		case slices.Contains(Providers, p.Name) || slices.Contains(NotifyTypes, p.Name):
+			errs = append(errs, fmt.Errorf("plugins[%d].name: %q is built in", i, p.Name))
+		}
+		names[p.Name] = true
+		if strings.TrimSpace(p.Command) == "" {
+			errs = append(errs, fmt.Errorf("plugins[%d].command: must not be empty", i))
+		}
+	}
+
+	for i, f := range c.VersionFiles {
+		if strings.TrimSpace(f.Path) == "" {
+			errs = append(errs, fmt.Errorf("version_files[%d].path: must not be empty", i))
+		}
+		switch ext := filepath.Ext(f.Path); {
+		case f.Key != "" && f.Pattern != "":
+			errs = append(errs, fmt.Errorf("version_files[%d]: key and pattern are mutually exclusive", i))
+		case f.Key != "" && !slices.Contains([]string{".json", ".yaml", ".yml", ".toml"}, ext):
+			errs = append(errs, fmt.Errorf("version_files[%d].key: %s is not a JSON, YAML or TOML file", i, f.Path))
+		}
+		if f.Pattern != "" {
+			if re, err := regexp.Compile(f.Pattern); err != nil || re.NumSubexp() == 0 {
+				errs = append(errs, fmt.Errorf("version_files[%d].pattern: %q must be a regular expression with a group for the version", i, f.Pattern))
+			}
+		}
+	}
+
+	if c.Retry.MaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("retry.max_attempts: %d must not be negative", c.Retry.MaxAttempts))
+	}
+	for _, d := range []struct{ key, value string }{
+		{"initial_delay", c.Retry.InitialDelay},
+		{"max_delay", c.Retry.MaxDelay},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d.value); err != nil || v < 0 {
+			errs = append(errs, fmt.Errorf("retry.%s: %q is not a valid duration", d.key, d.value))
+		}
+	}
+
+	if c.RateLimit.Reserve < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.reserve: %d must not be negative", c.RateLimit.Reserve))
+	}
+	if c.RateLimit.BatchSize < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.batch_size: %d must not be negative", c.RateLimit.BatchSize))
+	}
+	if c.RateLimit.Concurrency < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.concurrency: %d must not be negative", c.RateLimit.Concurrency))
+	}
+	if c.RateLimit.MinInterval != "" {
+		if v, err := time.ParseDuration(c.RateLimit.MinInterval); err != nil || v < 0 {
+			errs = append(errs, fmt.Errorf("rate_limit.min_interval: %q is not a valid duration", c.RateLimit.MinInterval))
+		}
+	}
+	if u, err := url.Parse(c.Telemetry.Endpoint); c.Telemetry.Endpoint != "" && (err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "") {
+		errs = append(errs, fmt.Errorf("telemetry.endpoint: %q is not an http(s) URL", c.Telemetry.Endpoint))
+	}
+	if c.Telemetry.Interval != "" {
+		if v, err := time.ParseDuration(c.Telemetry.Interval); err != nil || v <= 0 {
+			errs = append(errs, fmt.Errorf("telemetry.interval: %q is not a valid duration", c.Telemetry.Interval))
+		}
+	}
+
+	for i, cmd := range c.Preflight.Before {
+		if !slices.Contains(PreflightCommands, cmd) {
+			errs = append(errs, fmt.Errorf("preflight.before[%d]: %q must be one of %s", i, cmd, strings.Join(PreflightCommands, ", ")))
+		}
+	}
+	for i, f := range c.Preflight.RequiredFiles {
+		if strings.TrimSpace(f) == "" {
+			errs = append(errs, fmt.Errorf("preflight.required_files[%d]: must not be empty", i))
+		}
+	}
+	pp := c.Preflight.Policy
+	if pp.MaxUnreleasedCommits < 0 {
+		errs = append(errs, fmt.Errorf("preflight.policy.max_unreleased_commits: %d must not be negative", pp.MaxUnreleasedCommits))
+	}
+	for i, typ := range pp.ReleaseNoteTypes {
+		if !commitType.MatchString(typ) {
+			errs = append(errs, fmt.Errorf("preflight.policy.release_note_types[%d]: %q is not a commit type such as feat", i, typ))
+		}
+	}
+	for i, b := range pp.ProtectedBranches {
+		if _, err := path.Match(b, ""); err != nil || b == "" {
+			errs = append(errs, fmt.Errorf("preflight.policy.protected_branches[%d]: %q is not a branch name or pattern", i, b))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// commitType matches the types of conventional commits.
+var commitType = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// trackerProject matches the project keys package tracker finds issues of.
+var trackerProject = regexp.MustCompile(`^[A-Z][A-Z0-9_]+$`)
+
+// linePattern matches the release lines version.ParseLine accepts.
+var linePattern = regexp.MustCompile(`^v?(0|[1-9][0-9]*)(\.(0|[1-9][0-9]*))?\.x$`)
+
+// validTagTemplate checks that text is a tag template naming valid refs.
+func validTagTemplate(text string) error {
+	prefix, suffix, err := TagAffixes(text, "pkg/app", "app")
+	if err != nil {
+		return err
+	}
+	if strings.ContainsAny(prefix+suffix, " \t\n~^:?*[\\") {
+		return fmt.Errorf("%q is not valid in a git ref name", prefix+"1.2.3"+suffix)
+	}
+	return nil
+}
+
+// validateHooks checks the stages and commands of hooks, reported under
+// key.
+func validateHooks(key string, hooks map[string][]string) []error {
+	var errs []error
+	for _, stage := range slices.Sorted(maps.Keys(hooks)) {
+		if !slices.Contains(HookStages, stage) {
+			errs = append(errs, fmt.Errorf("%s.%s: unknown stage, must be one of %s", key, stage, strings.Join(HookStages, ", ")))
+		}
+		for i, cmd := range hooks[stage] {
+			if strings.TrimSpace(cmd) == "" {
+				errs = append(errs, fmt.Errorf("%s.%s[%d]: command must not be empty", key, stage, i))
+			}
+		}
+	}
+	return errs
+}
+
+// validChannel reports whether name can lead a prerelease such as
+// "rc.1": alphanumerics and hyphens, not purely numeric.
+func validChannel(name string) bool {
+	if name == "" {
+		return false
+	}
+	numeric := true
+	for _, r := range name {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+			numeric = false
+		default:
+			return false
+		}
+	}
+	return !numeric
+}
@@ -0,0 +1,210 @@
+// Package contributors lists the people behind a set of commits: their
+// authors and the co-authors named in Co-authored-by trailers.
+//
+// A person committing under several addresses is listed once when the
+// addresses name the same GitHub login, either because one is a GitHub
+// noreply address or because a LoginFinder, typically the GitHub API, tells
+// the login of their commits. Bots are left out.
+package contributors
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitobj"
+)
+
+// Contributor is a commit author or co-author.
+type Contributor struct {
+	Name  string
+	Email string
+	// Login is the GitHub login, when known.
+	Login string
+	// Commits counts the commits authored or co-authored.
+	Commits int
+}
+
+// URL returns the GitHub profile of c, or "" without a Login.
+func (c Contributor) URL() string {
+	if c.Login == "" {
+		return ""
+	}
+	return "https://github.com/" + c.Login
+}
+
+// Handle returns "@login", or the name without a Login.
+func (c Contributor) Handle() string {
+	if c.Login == "" {
+		return c.Name
+	}
+	return "@" + c.Login
+}
+
+// LoginFinder tells the GitHub login of the author of a commit; it returns
+// "" when the author's address belongs to no account.
+type LoginFinder interface {
+	CommitAuthor(ctx context.Context, hash string) (string, error)
+}
+
+// BatchLoginFinder is a LoginFinder that also tells the logins of many
+// commits at once, in fewer requests than one per commit.
+type BatchLoginFinder interface {
+	LoginFinder
+	// CommitAuthors returns the logins of the authors of hashes by hash,
+	// leaving out the commits it knows nothing of.
+	CommitAuthors(ctx context.Context, hashes []string) (map[string]string, error)
+}
+
+var (
+	coAuthorPattern = regexp.MustCompile(`(?im)^co-authored-by:\s*(.*?)\s*<([^<>\s]+)>\s*$`)
+	// noreplyPattern matches GitHub noreply addresses, "login@..." or,
+	// since 2017, "id+login@...".
+	noreplyPattern = regexp.MustCompile(`(?i)^(?:\d+\+)?([a-z0-9](?:[a-z0-9-]*[a-z0-9])?)@users\.noreply\.github\.com$`)
+)
+
+// bots are names of automation accounts that do not end in "[bot]".
+var bots = []string{"dependabot", "renovate", "github-actions", "greenkeeper", "semantic-release-bot", "snyk-bot"}
+
+// List returns the contributors to raw, ordered by number of commits, then
+// name. Contributors are the same person when their addresses match,
+// ignoring case, or name the same login; the name kept is the one of their
+// newest commit. Bots and the contributors matching exclude, by name,
+// address or login, are left out.
+func List(raw []gitobj.Commit, exclude ...string) []Contributor {
+	var cs []Contributor
+	index := make(map[string]int)
+	add := func(name, email string) {
+		if name == "" && email == "" {
+			return
+		}
+		key := strings.ToLower(email)
+		if key == "" {
+			key = name
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(cs)
+			index[key] = i
+			cs = append(cs, Contributor{Name: name, Email: email, Login: noreplyLogin(email)})
+		}
+		cs[i].Commits++
+	}
+	for _, rc := range raw {
+		add(rc.AuthorName, rc.AuthorEmail)
+		for _, m := range coAuthorPattern.FindAllStringSubmatch(rc.Message, -1) {
+			if !strings.EqualFold(m[2], rc.AuthorEmail) {
+				add(m[1], m[2])
+			}
+		}
+	}
+	return tidy(cs, exclude)
+}
+
+// Resolve asks f for the logins of the contributors in cs that have none,
+// using a commit of raw they authored, then merges the contributors sharing
+// a login and leaves out the bots it reveals. A BatchLoginFinder is asked
+// for every commit at once. On error, the contributors resolved so far are
+// returned with it.
+func Resolve(ctx context.Context, cs []Contributor, raw []gitobj.Commit, f LoginFinder, exclude ...string) ([]Contributor, error) {
+	cs = slices.Clone(cs)
+	hashes := make(map[int]string)
+	for i, c := range cs {
+		if c.Login != "" || c.Email == "" {
+			continue
+		}
+		j := slices.IndexFunc(raw, func(rc gitobj.Commit) bool { return strings.EqualFold(rc.AuthorEmail, c.Email) })
+		if j >= 0 {
+			hashes[i] = raw[j].Hash
+		}
+	}
+	if bf, ok := f.(BatchLoginFinder); ok {
+		var ask []string
+		for i := range cs {
+			if h, ok := hashes[i]; ok {
+				ask = append(ask, h)
+			}
+		}
+		var logins map[string]string
+		var err error
+		if len(ask) > 0 {
+			logins, err = bf.CommitAuthors(ctx, ask)
+		}
+		for i, h := range hashes {
+			cs[i].Login = logins[h]
+		}
+		return tidy(cs, exclude), err
+	}
+	var err error
+	for i := range cs {
+		h, ok := hashes[i]
+		if !ok {
+			continue
+		}
+		var login string
+		if login, err = f.CommitAuthor(ctx, h); err != nil {
+			break
+		}
+		cs[i].Login = login
+	}
+	return tidy(cs, exclude), err
+}
+
+// tidy merges the contributors sharing a login, drops bots and excluded
+// contributors and sorts the rest.
+func tidy(cs []Contributor, exclude []string) []Contributor {
+	var out []Contributor
+	logins := make(map[string]int)
+	for _, c := range cs {
+		if IsBot(c) || excluded(c, exclude) {
+			continue
+		}
+		if c.Login != "" {
+			key := strings.ToLower(c.Login)
+			if i, ok := logins[key]; ok {
+				out[i].Commits += c.Commits
+				continue
+			}
+			logins[key] = len(out)
+		}
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Commits != out[j].Commits {
+			return out[i].Commits > out[j].Commits
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// IsBot reports whether c is an automation account: GitHub App logins and
+// names end in "[bot]", and a few well-known bots commit under plain names.
+func IsBot(c Contributor) bool {
+	for _, s := range []string{c.Name, c.Login, noreplyLogin(c.Email)} {
+		s = strings.ToLower(s)
+		if strings.HasSuffix(s, "[bot]") || slices.Contains(bots, s) {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(c.Email), "[bot]@")
+}
+
+func excluded(c Contributor, exclude []string) bool {
+	for _, e := range exclude {
+		if strings.EqualFold(e, c.Name) || strings.EqualFold(e, c.Email) || c.Login != "" && strings.EqualFold(strings.TrimPrefix(e, "@"), c.Login) {
+			return true
+		}
+	}
+	return false
+}
+
+// noreplyLogin returns the login a GitHub noreply address names, or "".
+func noreplyLogin(email string) string {
+	if m := noreplyPattern.FindStringSubmatch(email); m != nil {
+		return m[1]
+	}
+	return ""
+}
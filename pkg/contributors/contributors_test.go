@@ -0,0 +1,131 @@
+package contributors
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitobj"
+)
+
+var testRaw = []gitobj.Commit{
+	{Hash: "a1", AuthorName: "Ada", AuthorEmail: "ada@example.com", Message: "feat: x\n\nCo-authored-by: Bob <bob@example.com>\nCo-authored-by: Ada <ADA@example.com>"},
+	{Hash: "b2", AuthorName: "Bob B.", AuthorEmail: "1234+bobb@users.noreply.github.com", Message: "fix: y"},
+	{Hash: "c3", AuthorName: "Ada L.", AuthorEmail: "ADA@example.com", Message: "tidy up"},
+	{Hash: "d4", AuthorName: "dependabot[bot]", AuthorEmail: "49699333+dependabot[bot]@users.noreply.github.com", Message: "chore(deps): bump"},
+	{Hash: "e5", AuthorName: "Renovate", AuthorEmail: "renovate@example.com", Message: "chore(deps): bump\n\nCo-authored-by: github-actions <41898282+github-actions@users.noreply.github.com>"},
+	{Hash: "f6", AuthorName: "Bob B.", AuthorEmail: "bobb@users.noreply.github.com", Message: "docs: z"},
+}
+
+func TestList(t *testing.T) {
+	got := List(testRaw)
+	expected := []Contributor{
+		{Name: "Ada", Email: "ada@example.com", Commits: 2},
+		{Name: "Bob B.", Email: "1234+bobb@users.noreply.github.com", Login: "bobb", Commits: 2},
+		{Name: "Bob", Email: "bob@example.com", Commits: 1},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	got = List(testRaw, "@bobb", "BOB@example.com")
+	if len(got) != 1 || got[0].Name != "Ada" {
+		t.Errorf("Expected the excluded contributors left out, got %+v", got)
+	}
+}
+
+type fakeFinder map[string]string
+
+func (f fakeFinder) CommitAuthor(ctx context.Context, hash string) (string, error) {
+	login, ok := f[hash]
+	if !ok {
+		return "", errors.New("no such commit")
+	}
+	return login, nil
+}
+
+func TestResolve(t *testing.T) {
+	raw := []gitobj.Commit{
+		{Hash: "a1", AuthorName: "Ada", AuthorEmail: "ada@work.example", Message: "feat: x"},
+		{Hash: "b2", AuthorName: "Ada Lovelace", AuthorEmail: "ada@home.example", Message: "fix: y"},
+		{Hash: "c3", AuthorName: "Bot", AuthorEmail: "ci@example.com", Message: "chore: z"},
+		{Hash: "d4", AuthorName: "Eve", AuthorEmail: "eve@example.com", Message: "docs: w"},
+	}
+	finder := fakeFinder{"a1": "ada", "b2": "ada", "c3": "release-bot[bot]", "d4": ""}
+
+	got, err := Resolve(context.Background(), List(raw), raw, finder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Contributor{
+		{Name: "Ada", Email: "ada@work.example", Login: "ada", Commits: 2},
+		{Name: "Eve", Email: "eve@example.com", Commits: 1},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	delete(finder, "d4")
+	got, err = Resolve(context.Background(), List(raw), raw, finder)
+	if err == nil {
+		t.Error("Expected the lookup error")
+	}
+	if len(got) != 2 || got[0].Login != "ada" {
+		t.Errorf("Expected the contributors resolved so far, got %+v", got)
+	}
+}
+
+type batchFinder struct {
+	fakeFinder
+	batches [][]string
+}
+
+func (f *batchFinder) CommitAuthors(ctx context.Context, hashes []string) (map[string]string, error) {
+	f.batches = append(f.batches, hashes)
+	logins := make(map[string]string)
+	for _, h := range hashes {
+		if login, ok := f.fakeFinder[h]; ok {
+			logins[h] = login
+		}
+	}
+	return logins, nil
+}
+
+func TestResolveBatch(t *testing.T) {
+	raw := []gitobj.Commit{
+		{Hash: "a1", AuthorName: "Ada", AuthorEmail: "ada@work.example", Message: "feat: x"},
+		{Hash: "b2", AuthorName: "Ada Lovelace", AuthorEmail: "ada@home.example", Message: "fix: y"},
+		{Hash: "c3", AuthorName: "Bot", AuthorEmail: "ci@example.com", Message: "chore: z"},
+		{Hash: "d4", AuthorName: "Eve", AuthorEmail: "eve@example.com", Message: "docs: w"},
+		{Hash: "e5", AuthorName: "Mallory", AuthorEmail: "12+mallory@users.noreply.github.com", Message: "docs: v"},
+	}
+	finder := &batchFinder{fakeFinder: fakeFinder{"a1": "ada", "b2": "ada", "c3": "release-bot[bot]"}}
+
+	got, err := Resolve(context.Background(), List(raw), raw, finder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Contributor{
+		{Name: "Ada", Email: "ada@work.example", Login: "ada", Commits: 2},
+		{Name: "Eve", Email: "eve@example.com", Commits: 1},
+		{Name: "Mallory", Email: "12+mallory@users.noreply.github.com", Login: "mallory", Commits: 1},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if len(finder.batches) != 1 || len(finder.batches[0]) != 4 {
+		t.Errorf("Expected the commits of the contributors without a login asked at once, got %v", finder.batches)
+	}
+}
+
+func TestContributorLinks(t *testing.T) {
+	c := Contributor{Name: "Ada", Login: "ada"}
+	if c.Handle() != "@ada" || c.URL() != "https://github.com/ada" {
+		t.Errorf("Expected the GitHub handle and profile, got %q and %q", c.Handle(), c.URL())
+	}
+	c.Login = ""
+	if c.Handle() != "Ada" || c.URL() != "" {
+		t.Errorf("Expected the name without a login, got %q and %q", c.Handle(), c.URL())
+	}
+}
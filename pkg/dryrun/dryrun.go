@@ -0,0 +1,36 @@
+// Package dryrun holds the shared conventions for dry-run mode.
+//
+// Components that mutate state (git tags and pushes, changelog files, provider
+// releases) expose a DryRun flag and a Log writer. When DryRun is set they
+// describe the action with Printf instead of performing it, so every
+// component reports in the same "[dry-run] would ..." format.
+package dryrun
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Prefix starts every line written by Printf.
+const Prefix = "[dry-run] "
+
+// EnvVar enables dry-run mode for the CLI when set to a true value
+// (1, true, yes).
+const EnvVar = "RELEASE_DRY_RUN"
+
+// Printf writes a single Prefix-ed line to w describing a skipped action.
+func Printf(w io.Writer, format string, args ...any) {
+	fmt.Fprintf(w, Prefix+format+"\n", args...)
+}
+
+// FromEnv reports whether EnvVar enables dry-run mode.
+func FromEnv() bool {
+	v := os.Getenv(EnvVar)
+	if v == "yes" {
+		return true
+	}
+	b, err := strconv.ParseBool(v)
+	return err == nil && b
+}
@@ -0,0 +1,38 @@
+package dryrun
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintf(t *testing.T) {
+	var buf bytes.Buffer
+	Printf(&buf, "would push %s to %s", "v1.0.0", "origin")
+
+	expected := "[dry-run] would push v1.0.0 to origin\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"", false},
+		{"0", false},
+		{"false", false},
+		{"nope", false},
+		{"1", true},
+		{"true", true},
+		{"yes", true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(EnvVar, tt.value)
+		if result := FromEnv(); result != tt.expected {
+			t.Errorf("FromEnv() with %q: expected %v, got %v", tt.value, tt.expected, result)
+		}
+	}
+}
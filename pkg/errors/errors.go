@@ -0,0 +1,179 @@
+// Package errors classifies the failures of a release so that callers, and
+// scripts through the CLI's exit status, can tell them apart.
+//
+// Every sentinel below is an *Error carrying a Kind. Packages return them
+// wrapped with fmt.Errorf("...: %w"), and some re-export them under their
+// own names (preflight.ErrDirtyWorktree, gitrepo.ErrTagExists), so
+// errors.Is works with either. Wrap attaches a Kind to any other error
+// without changing its message, and KindOf recovers the Kind of an error
+// chain.
+//
+// The package shadows the standard library's errors; import it under
+// another name, such as relerr.
+package errors
+
+import (
+	"context"
+	"errors"
+)
+
+// Kind is a category of failure.
+type Kind int
+
+const (
+	// Unknown is the Kind of errors that were not classified.
+	Unknown Kind = iota
+	// Usage is a command invoked with invalid arguments.
+	Usage
+	// NothingToRelease means there are no releasable commits.
+	NothingToRelease
+	// Precondition is a repository not in a state to be released.
+	Precondition
+	// Conflict is a release that already exists, such as its tag.
+	Conflict
+	// Config is a configuration file that cannot be loaded.
+	Config
+	// Signature is a tag signature that could not be verified.
+	Signature
+	// Provider is a failure of a publish provider, such as an API error or
+	// a missing token.
+	Provider
+	// Hook is a failing pre-* hook.
+	Hook
+	// Canceled is an operation interrupted before it finished.
+	Canceled
+	// Timeout is an operation that exceeded its deadline.
+	Timeout
+	// Partial is a release published on some of its publish targets, or
+	// with some of its assets, before a provider failed.
+	Partial
+	// Warned is a command that succeeded with warnings, for callers failing
+	// on them.
+	Warned
+)
+
+var kindNames = []string{"unknown", "usage", "nothing to release", "precondition", "conflict", "config", "signature", "provider", "hook", "canceled", "timeout", "partial", "warned"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "unknown"
+	}
+	return kindNames[k]
+}
+
+// Error is an error of a known Kind.
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+// New returns a sentinel error of kind k with message msg.
+func New(k Kind, msg string) error {
+	return &Error{Kind: k, Err: errors.New(msg)}
+}
+
+// Wrap returns err classified as k, with err's message. It returns nil
+// for a nil err, and err itself when err already has a Kind.
+func Wrap(k Kind, err error) error {
+	if err == nil || KindOf(err) != Unknown {
+		return err
+	}
+	return &Error{Kind: k, Err: err}
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+func (e *Error) Unwrap() error { return e.Err }
+
+// KindOf returns the Kind of the first *Error in err's chain. Errors caused
+// by a cancelled or expired context are Canceled or Timeout, whatever else
+// they wrap; nil and unclassified errors are Unknown.
+func KindOf(err error) Kind {
+	switch {
+	case err == nil:
+		return Unknown
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return Unknown
+}
+
+// Usage errors.
+var ErrUsage = New(Usage, "usage")
+
+// Release planning errors.
+var (
+	ErrNoCommitsSinceTag = New(NothingToRelease, "no releasable commits since the last tag")
+	// ErrMaintenanceBump is returned for a minor or major release on a
+	// maintenance branch, which only takes patches.
+	ErrMaintenanceBump = New(Precondition, "maintenance lines only take patch releases")
+	// ErrLintFailed is returned by lint-commits when a commit message
+	// breaks the commit conventions.
+	ErrLintFailed = New(Precondition, "commit messages do not follow the conventions")
+)
+
+// Repository preconditions, checked by pkg/preflight.
+var (
+	ErrPreflightFailed  = New(Precondition, "preflight checks failed")
+	ErrDirtyWorktree    = New(Precondition, "working tree has uncommitted changes")
+	ErrBranchNotAllowed = New(Precondition, "branch is not a release branch")
+	ErrBehindRemote     = New(Precondition, "branch is behind its remote")
+	ErrCIFailing        = New(Precondition, "CI is not green")
+	ErrMissingFiles     = New(Precondition, "required files are missing")
+	ErrDetachedHead     = New(Precondition, "HEAD is detached")
+	ErrPolicyViolated   = New(Precondition, "release policy violated")
+)
+
+// ErrFrozen is returned when a release is published during a freeze
+// window, see pkg/freeze.
+var ErrFrozen = New(Precondition, "releases are frozen")
+
+// ErrNotApproved is returned when a drafted release lacks the approvals
+// release approve requires.
+var ErrNotApproved = New(Precondition, "the release is not approved")
+
+// Existing release tags, see gitrepo.CheckTag.
+var (
+	// ErrTagExists is returned when the release tag already exists.
+	ErrTagExists = New(Conflict, "tag already exists")
+	// ErrTagMoved is returned when tagging would move an existing tag to
+	// another commit.
+	ErrTagMoved = New(Conflict, "tag exists at another commit")
+	// ErrLightweightTag is returned when the release tag exists as a
+	// lightweight tag, where releases create annotated ones.
+	ErrLightweightTag = New(Conflict, "tag exists as a lightweight tag")
+)
+
+// ErrPartialPublish is returned when a provider fails after the release
+// was published elsewhere, or created with some of its assets: publishing
+// again resumes it.
+var ErrPartialPublish = New(Partial, "the release was published in part")
+
+// ErrWarnings is returned by the CLI with -fail-on warnings for a command
+// that logged warnings.
+var ErrWarnings = New(Warned, "warnings were logged")
+
+// ErrBadSignature is returned when a tag is unsigned, or its signature is
+// invalid or made by an untrusted key.
+var ErrBadSignature = New(Signature, "tag signature could not be verified")
+
+// Published release verification, see pkg/verify.
+var (
+	// ErrVerifyFailed is wrapped by the error of a verification with a
+	// failing check.
+	ErrVerifyFailed = New(Conflict, "release verification failed")
+	// ErrDrift is returned when the tag, the release or its assets differ
+	// from what was released.
+	ErrDrift = New(Conflict, "the release has drifted")
+	// ErrBadAssetSignature is returned when a release asset is unsigned,
+	// or its signature is invalid.
+	ErrBadAssetSignature = New(Signature, "release asset signature could not be verified")
+	// ErrNotReproducible is returned when a published binary differs from
+	// its rebuild from the tagged commit.
+	ErrNotReproducible = New(Conflict, "the release artifact is not reproducible")
+)
@@ -0,0 +1,63 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	plain := errors.New("boom")
+	tests := []struct {
+		err  error
+		kind Kind
+	}{
+		{nil, Unknown},
+		{plain, Unknown},
+		{ErrDirtyWorktree, Precondition},
+		{fmt.Errorf("tag: %w", ErrTagExists), Conflict},
+		{Wrap(Provider, plain), Provider},
+		{errors.Join(plain, ErrNoCommitsSinceTag), NothingToRelease},
+		{fmt.Errorf("hook: %w", Wrap(Hook, context.Canceled)), Canceled},
+		{fmt.Errorf("push: %w", context.DeadlineExceeded), Timeout},
+		{fmt.Errorf("%w: gitlab: %w", ErrPartialPublish, Wrap(Provider, plain)), Partial},
+	}
+	for _, tt := range tests {
+		if got := KindOf(tt.err); got != tt.kind {
+			t.Errorf("KindOf(%v): Expected %v, got %v", tt.err, tt.kind, got)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	plain := errors.New("boom")
+	err := Wrap(Config, plain)
+	if err.Error() != "boom" {
+		t.Errorf("Expected %q, got %q", "boom", err.Error())
+	}
+	if !errors.Is(err, plain) {
+		t.Errorf("Expected %v to wrap %v", err, plain)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Kind != Config {
+		t.Errorf("Expected an *Error of kind config, got %#v", err)
+	}
+
+	if Wrap(Config, nil) != nil {
+		t.Errorf("Expected nil for a nil error")
+	}
+	kept := fmt.Errorf("x: %w", ErrBadSignature)
+	if got := Wrap(Provider, kept); got != kept || KindOf(got) != Signature {
+		t.Errorf("Expected a classified error to keep its kind, got %v", KindOf(got))
+	}
+}
+
+func TestSentinelsDistinct(t *testing.T) {
+	if errors.Is(ErrDirtyWorktree, ErrBehindRemote) {
+		t.Errorf("Expected sentinels of the same kind to be distinct")
+	}
+	if Precondition.String() != "precondition" || Kind(99).String() != "unknown" {
+		t.Errorf("Expected kind names, got %q and %q", Precondition, Kind(99))
+	}
+}
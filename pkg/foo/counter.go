@@ -0,0 +1,112 @@
+package foo
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// FooCounter counts the Foo lines emitted, for services printing Foo as a
+// liveness beacon. It is safe for concurrent use, and counting does not
+// lock or allocate. The zero value is ready to use.
+//
+// A FooCounter is an expvar.Var, to publish with expvar.Publish, and an
+// http.Handler serving its metrics in the Prometheus text format.
+type FooCounter struct {
+	emitted atomic.Uint64
+	failed  atomic.Uint64
+	// last is the time of the last emission in Unix nanoseconds, 0
+	// before the first.
+	last atomic.Int64
+}
+
+var (
+	_ expvar.Var   = (*FooCounter)(nil)
+	_ http.Handler = (*FooCounter)(nil)
+)
+
+// Counter counts the lines of PrintFoo, FooPrinter.PrintFoo and Print in
+// the text format.
+var Counter = &FooCounter{}
+
+// FooCounterSnapshot is the state of a FooCounter at one time.
+type FooCounterSnapshot struct {
+	// Emitted is the number of Foo lines written, and Failed the number
+	// of those whose write failed.
+	Emitted uint64 `json:"emitted"`
+	Failed  uint64 `json:"failed"`
+	// Last is the time of the last emission, zero before the first.
+	Last time.Time `json:"last"`
+}
+
+// PrintFoo writes Foo on a line of its own to w, as PrintFoo does, and
+// counts it in c.
+func (c *FooCounter) PrintFoo(w io.Writer) error {
+	_, err := writeFoo(w)
+	c.count(err)
+	return err
+}
+
+// count records an emission whose write returned err.
+func (c *FooCounter) count(err error) {
+	c.emitted.Add(1)
+	if err != nil {
+		c.failed.Add(1)
+	}
+	c.last.Store(time.Now().UnixNano())
+}
+
+// Snapshot returns the counts of c.
+func (c *FooCounter) Snapshot() FooCounterSnapshot {
+	s := FooCounterSnapshot{Emitted: c.emitted.Load(), Failed: c.failed.Load()}
+	if last := c.last.Load(); last != 0 {
+		s.Last = time.Unix(0, last)
+	}
+	return s
+}
+
+// Reset sets the counts of c back to zero.
+func (c *FooCounter) Reset() {
+	c.emitted.Store(0)
+	c.failed.Store(0)
+	c.last.Store(0)
+}
+
+// String returns the snapshot of c as JSON, for expvar.
+func (c *FooCounter) String() string {
+	b, _ := json.Marshal(c.Snapshot())
+	return string(b)
+}
+
+// WritePrometheus writes the metrics of c to w in the Prometheus text
+// exposition format, named after namespace: <namespace>_emitted_total,
+// <namespace>_failed_total and <namespace>_last_emitted_timestamp_seconds.
+func (c *FooCounter) WritePrometheus(w io.Writer, namespace string) error {
+	s := c.Snapshot()
+	var last float64
+	if !s.Last.IsZero() {
+		last = float64(s.Last.UnixNano()) / 1e9
+	}
+	_, err := fmt.Fprintf(w, `# HELP %[1]s_emitted_total Foo lines emitted.
+# TYPE %[1]s_emitted_total counter
+%[1]s_emitted_total %[2]d
+# HELP %[1]s_failed_total Foo lines whose write failed.
+# TYPE %[1]s_failed_total counter
+%[1]s_failed_total %[3]d
+# HELP %[1]s_last_emitted_timestamp_seconds Time of the last Foo line, 0 before the first.
+# TYPE %[1]s_last_emitted_timestamp_seconds gauge
+%[1]s_last_emitted_timestamp_seconds %[4]g
+`, namespace, s.Emitted, s.Failed, last)
+	return err
+}
+
+// ServeHTTP serves the metrics of c in the Prometheus text format, in the
+// foo namespace.
+func (c *FooCounter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WritePrometheus(w, "foo")
+}
@@ -0,0 +1,112 @@
+package foo
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestFooCounter(t *testing.T) {
+	var c FooCounter
+	if s := c.Snapshot(); s.Emitted != 0 || !s.Last.IsZero() {
+		t.Errorf("Expected an empty snapshot, got %+v", s)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				c.PrintFoo(io.Discard)
+			}
+		})
+	}
+	wg.Wait()
+	if err := c.PrintFoo(failingWriter{}); err == nil {
+		t.Error("Expected the write error")
+	}
+
+	s := c.Snapshot()
+	if s.Emitted != 801 || s.Failed != 1 {
+		t.Errorf("Expected 801 emitted and 1 failed, got %+v", s)
+	}
+	if time.Since(s.Last) > time.Minute {
+		t.Errorf("Expected the last emission just now, got %v", s.Last)
+	}
+
+	c.Reset()
+	if s := c.Snapshot(); s != (FooCounterSnapshot{}) {
+		t.Errorf("Expected the counts reset, got %+v", s)
+	}
+}
+
+func TestPrintFooCounts(t *testing.T) {
+	before := Counter.Snapshot().Emitted
+	PrintFoo(io.Discard)
+	p := NewFooPrinter(io.Discard)
+	p.PrintFoo()
+	if err := Print(io.Discard, Text); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := Counter.Snapshot().Emitted - before; n != 3 {
+		t.Errorf("Expected 3 lines counted, got %d", n)
+	}
+}
+
+func TestFooCounterExpvar(t *testing.T) {
+	var c FooCounter
+	c.PrintFoo(io.Discard)
+
+	var s FooCounterSnapshot
+	if err := json.Unmarshal([]byte(c.String()), &s); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", c.String(), err)
+	}
+	if s.Emitted != 1 || s.Failed != 0 || s.Last.IsZero() {
+		t.Errorf("Unexpected snapshot %+v", s)
+	}
+}
+
+func TestFooCounterPrometheus(t *testing.T) {
+	var c FooCounter
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	for _, want := range []string{
+		"# TYPE foo_emitted_total counter\nfoo_emitted_total 0\n",
+		"# TYPE foo_failed_total counter\nfoo_failed_total 0\n",
+		"# TYPE foo_last_emitted_timestamp_seconds gauge\nfoo_last_emitted_timestamp_seconds 0\n",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %q in %q", want, rec.Body.String())
+		}
+	}
+
+	c.PrintFoo(io.Discard)
+	var b strings.Builder
+	if err := c.WritePrometheus(&b, "beacon"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(b.String(), "\nbeacon_emitted_total 1\n") || strings.Contains(b.String(), "beacon_last_emitted_timestamp_seconds 0\n") {
+		t.Errorf("Unexpected metrics %q", b.String())
+	}
+}
+
+func TestFooCounterAllocs(t *testing.T) {
+	var c FooCounter
+	if n := testing.AllocsPerRun(100, func() { c.PrintFoo(io.Discard) }); n != 0 {
+		t.Errorf("Expected 0 allocations, got %v", n)
+	}
+}
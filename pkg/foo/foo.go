@@ -1,14 +1,26 @@
 package foo
 
 import (
-	"fmt"
 	"io"
 )
 
+// fooLine is the line PrintFoo writes.
+const fooLine = "Foo\n"
+
 func Foo() string {
 	return "Foo"
 }
 
+// PrintFoo writes Foo on a line of its own to w, counting it in Counter.
+// Writers implementing io.StringWriter, such as *bytes.Buffer,
+// *bufio.Writer and *os.File, are written without allocating.
 func PrintFoo(w io.Writer) {
-	fmt.Fprintln(w, Foo())
+	Counter.PrintFoo(w)
+}
+
+func writeFoo(w io.Writer) (int, error) {
+	if sw, ok := w.(io.StringWriter); ok {
+		return sw.WriteString(fooLine)
+	}
+	return w.Write([]byte(fooLine))
 }
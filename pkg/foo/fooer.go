@@ -0,0 +1,56 @@
+package foo
+
+import (
+	"fmt"
+	"io"
+)
+
+// Fooer is implemented by values that render a Foo message, such as
+// *Greeter and the messages built by Foof.
+type Fooer interface {
+	Foo() string
+}
+
+// Default is the Fooer whose message is Foo.
+var Default Fooer = defaultFooer{}
+
+type defaultFooer struct{}
+
+func (defaultFooer) Foo() string { return Foo() }
+
+// Formatted is a custom message built by Foof.
+type Formatted string
+
+// Foof formats a custom message as fmt.Sprintf does, so callers can
+// compose it with Foo or a Greeter's translation:
+//
+//	foo.Foof("%s, %s!", foo.Foo(), name)
+func Foof(format string, args ...any) Formatted {
+	return Formatted(fmt.Sprintf(format, args...))
+}
+
+func (f Formatted) Foo() string { return string(f) }
+
+// WriteTo writes f on a line of its own, as PrintFoo does.
+func (f Formatted) WriteTo(w io.Writer) (int64, error) {
+	return Line(f).WriteTo(w)
+}
+
+// Line returns an io.WriterTo writing f's message followed by a newline.
+// Line(Default) writes exactly what PrintFoo does.
+func Line(f Fooer) io.WriterTo {
+	return line{f}
+}
+
+type line struct{ f Fooer }
+
+func (l line) WriteTo(w io.Writer) (int64, error) {
+	n, err := fmt.Fprintln(w, l.f.Foo())
+	return int64(n), err
+}
+
+// PrintFooer writes f's message to w, like PrintFoo.
+func PrintFooer(w io.Writer, f Fooer) error {
+	_, err := Line(f).WriteTo(w)
+	return err
+}
@@ -0,0 +1,59 @@
+package foo
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestFoof(t *testing.T) {
+	result := Foof("%s, %s!", Foo(), "world")
+
+	expected := "Foo, world!"
+	if result.Foo() != expected {
+		t.Errorf("Expected %q, got %q", expected, result.Foo())
+	}
+}
+
+func TestFooers(t *testing.T) {
+	tests := []struct {
+		fooer    Fooer
+		expected string
+	}{
+		{Default, "Foo\n"},
+		{Foof("%s x%d", Foo(), 3), "Foo x3\n"},
+		{NewGreeter(language.German), "Fuh\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := PrintFooer(&buf, tt.fooer); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, buf.String())
+		}
+	}
+}
+
+func TestWriterTo(t *testing.T) {
+	var want bytes.Buffer
+	PrintFoo(&want)
+
+	var buf bytes.Buffer
+	n, err := Line(Default).WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != want.String() || n != int64(want.Len()) {
+		t.Errorf("Expected %q (%d bytes), got %q (%d bytes)", want.String(), want.Len(), buf.String(), n)
+	}
+
+	buf.Reset()
+	var w io.WriterTo = Foof("Foo #%d", 2)
+	if _, err := w.WriteTo(&buf); err != nil || buf.String() != "Foo #2\n" {
+		t.Errorf("Expected %q, got %q (err=%v)", "Foo #2\n", buf.String(), err)
+	}
+}
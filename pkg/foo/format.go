@@ -0,0 +1,81 @@
+package foo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format selects the output encoding used by Print.
+type Format int
+
+const (
+	Text Format = iota
+	JSON
+	YAML
+)
+
+// ParseFormat converts "text", "json" or "yaml" into a Format.
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "text":
+		return Text, nil
+	case "json":
+		return JSON, nil
+	case "yaml":
+		return YAML, nil
+	}
+	return Text, fmt.Errorf("foo: unknown format %q: must be text, json or yaml", s)
+}
+
+func (f Format) String() string {
+	switch f {
+	case Text:
+		return "text"
+	case JSON:
+		return "json"
+	case YAML:
+		return "yaml"
+	}
+	return "Format(" + strconv.Itoa(int(f)) + ")"
+}
+
+// Message is the structured payload emitted by PrintFooJSON and PrintFooYAML.
+type Message struct {
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// now is replaced in tests to make timestamps deterministic.
+var now = time.Now
+
+func NewMessage() Message {
+	return Message{Message: Foo(), Timestamp: now().UTC()}
+}
+
+func PrintFooJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(NewMessage())
+}
+
+func PrintFooYAML(w io.Writer) error {
+	m := NewMessage()
+	_, err := fmt.Fprintf(w, "message: %s\ntimestamp: %s\n",
+		strconv.Quote(m.Message), m.Timestamp.Format(time.RFC3339Nano))
+	return err
+}
+
+// Print writes Foo to w in the given format. Text output is identical to
+// PrintFoo, and counted by Counter likewise.
+func Print(w io.Writer, f Format) error {
+	switch f {
+	case Text:
+		return Counter.PrintFoo(w)
+	case JSON:
+		return PrintFooJSON(w)
+	case YAML:
+		return PrintFooYAML(w)
+	}
+	return fmt.Errorf("foo: unknown format %s", f)
+}
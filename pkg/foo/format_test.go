@@ -0,0 +1,91 @@
+package foo
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func fixedNow(t *testing.T) {
+	t.Helper()
+	orig := now
+	now = func() time.Time { return time.Date(2026, 2, 24, 12, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = orig })
+}
+
+func TestPrintFooJSON(t *testing.T) {
+	fixedNow(t)
+	var buf bytes.Buffer
+
+	if err := PrintFooJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"message":"Foo","timestamp":"2026-02-24T12:30:00Z"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	var m Message
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Message != "Foo" {
+		t.Errorf("Expected %q, got %q", "Foo", m.Message)
+	}
+}
+
+func TestPrintFooYAML(t *testing.T) {
+	fixedNow(t)
+	var buf bytes.Buffer
+
+	if err := PrintFooYAML(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "message: \"Foo\"\ntimestamp: 2026-02-24T12:30:00Z\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestPrint(t *testing.T) {
+	fixedNow(t)
+
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{Text, "Foo\n"},
+		{JSON, `{"message":"Foo","timestamp":"2026-02-24T12:30:00Z"}` + "\n"},
+		{YAML, "message: \"Foo\"\ntimestamp: 2026-02-24T12:30:00Z\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Print(&buf, tt.format); err != nil {
+			t.Fatalf("Print(%s): unexpected error: %v", tt.format, err)
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("Print(%s): expected %q, got %q", tt.format, tt.expected, buf.String())
+		}
+	}
+
+	if err := Print(&bytes.Buffer{}, Format(42)); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range []Format{Text, JSON, YAML} {
+		result, err := ParseFormat(f.String())
+		if err != nil || result != f {
+			t.Errorf("Expected %s, got %s (err=%v)", f, result, err)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
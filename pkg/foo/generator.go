@@ -0,0 +1,144 @@
+package foo
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultCharset is the runes a FooGenerator draws messages from without
+// a GeneratorOptions.Charset.
+const DefaultCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 "
+
+// Default lengths of generated messages, in runes.
+const (
+	DefaultMinLen = 1
+	DefaultMaxLen = 32
+)
+
+// Newline selects whether a FooGenerator ends the messages of Next with a
+// newline.
+type Newline int
+
+const (
+	// NewlineNever leaves messages as a Fooer returns them.
+	NewlineNever Newline = iota
+	// NewlineAlways ends every message with a newline, as PrintFooer
+	// writes it.
+	NewlineAlways
+	// NewlineRandom ends about half of the messages with a newline.
+	NewlineRandom
+)
+
+func (n Newline) String() string {
+	switch n {
+	case NewlineNever:
+		return "never"
+	case NewlineAlways:
+		return "always"
+	case NewlineRandom:
+		return "random"
+	}
+	return fmt.Sprintf("Newline(%d)", int(n))
+}
+
+// GeneratorOptions controls the messages of a FooGenerator. The zero value
+// generates messages of DefaultMinLen to DefaultMaxLen runes of
+// DefaultCharset, without newlines.
+type GeneratorOptions struct {
+	// MinLen and MaxLen bound the length of messages in runes, both
+	// included. Zero takes DefaultMinLen and DefaultMaxLen.
+	MinLen int
+	MaxLen int
+	// Charset is the runes messages are drawn from. It must be valid
+	// UTF-8 without line breaks.
+	Charset string
+	// Newline selects the newline ending the messages of Next.
+	Newline Newline
+}
+
+// FooGenerator produces random but valid Foo messages for fuzz and
+// property tests: well-formed UTF-8 without line breaks, which Line,
+// PrintFooer and NewFooerReader write as exactly one line each. The same
+// seed and options produce the same messages, on every platform and Go
+// release, so a failing input can be reproduced from its seed. A
+// FooGenerator is not safe for concurrent use.
+type FooGenerator struct {
+	seed    uint64
+	opts    GeneratorOptions
+	charset []rune
+	rng     *rand.Rand
+}
+
+// NewFooGenerator returns a FooGenerator of the messages seed and opts
+// determine. It fails on options no message can satisfy.
+func NewFooGenerator(seed uint64, opts GeneratorOptions) (*FooGenerator, error) {
+	if opts.MinLen == 0 {
+		opts.MinLen = DefaultMinLen
+	}
+	if opts.MaxLen == 0 {
+		opts.MaxLen = max(DefaultMaxLen, opts.MinLen)
+	}
+	if opts.Charset == "" {
+		opts.Charset = DefaultCharset
+	}
+	switch {
+	case opts.MinLen < 0 || opts.MaxLen < opts.MinLen:
+		return nil, fmt.Errorf("foo: invalid message lengths %d to %d", opts.MinLen, opts.MaxLen)
+	case !utf8.ValidString(opts.Charset):
+		return nil, errors.New("foo: charset is not valid UTF-8")
+	case strings.ContainsAny(opts.Charset, "\n\r"):
+		return nil, errors.New("foo: charset must not contain line breaks")
+	case opts.Newline < NewlineNever || opts.Newline > NewlineRandom:
+		return nil, fmt.Errorf("foo: unknown newline mode %s", opts.Newline)
+	}
+	g := &FooGenerator{seed: seed, opts: opts, charset: []rune(opts.Charset)}
+	g.Reset()
+	return g, nil
+}
+
+// Seed returns the seed of g, to report along with a failing message.
+func (g *FooGenerator) Seed() uint64 {
+	return g.seed
+}
+
+// Reset makes g produce its messages again from the first.
+func (g *FooGenerator) Reset() {
+	g.rng = rand.New(rand.NewPCG(g.seed, g.seed))
+}
+
+// NextFooer returns the next message as a Fooer, without a newline.
+func (g *FooGenerator) NextFooer() Formatted {
+	n := g.opts.MinLen + g.rng.IntN(g.opts.MaxLen-g.opts.MinLen+1)
+	var b strings.Builder
+	for range n {
+		b.WriteRune(g.charset[g.rng.IntN(len(g.charset))])
+	}
+	return Formatted(b.String())
+}
+
+// Next returns the next message as text, ending with a newline as the
+// Newline option selects.
+func (g *FooGenerator) Next() string {
+	msg := string(g.NextFooer())
+	switch g.opts.Newline {
+	case NewlineAlways:
+		msg += "\n"
+	case NewlineRandom:
+		if g.rng.IntN(2) == 0 {
+			msg += "\n"
+		}
+	}
+	return msg
+}
+
+// Messages returns the next n messages of Next.
+func (g *FooGenerator) Messages(n int) []string {
+	msgs := make([]string, n)
+	for i := range msgs {
+		msgs[i] = g.Next()
+	}
+	return msgs
+}
@@ -0,0 +1,123 @@
+package foo
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFooGeneratorReproducible(t *testing.T) {
+	g, err := NewFooGenerator(42, GeneratorOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := g.Messages(20)
+	g.Reset()
+	if again := g.Messages(20); !reflect.DeepEqual(first, again) {
+		t.Errorf("Expected the same messages after Reset, got %q and %q", first, again)
+	}
+	h, _ := NewFooGenerator(42, GeneratorOptions{})
+	if other := h.Messages(20); !reflect.DeepEqual(first, other) {
+		t.Errorf("Expected the same messages from the same seed, got %q and %q", first, other)
+	}
+	h, _ = NewFooGenerator(43, GeneratorOptions{})
+	if other := h.Messages(20); reflect.DeepEqual(first, other) {
+		t.Errorf("Expected other messages from another seed, got %q", other)
+	}
+	if g.Seed() != 42 {
+		t.Errorf("Expected seed 42, got %d", g.Seed())
+	}
+}
+
+func TestFooGeneratorOptions(t *testing.T) {
+	g, err := NewFooGenerator(1, GeneratorOptions{MinLen: 3, MaxLen: 5, Charset: "fø", Newline: NewlineAlways})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, msg := range g.Messages(200) {
+		body, ok := strings.CutSuffix(msg, "\n")
+		if !ok {
+			t.Fatalf("Expected a newline ending %q", msg)
+		}
+		if n := utf8.RuneCountInString(body); n < 3 || n > 5 {
+			t.Errorf("Expected 3 to 5 runes, got %q", body)
+		}
+		if strings.Trim(body, "fø") != "" {
+			t.Errorf("Expected only runes of the charset, got %q", body)
+		}
+	}
+
+	g, _ = NewFooGenerator(1, GeneratorOptions{Newline: NewlineRandom})
+	var with, without int
+	for _, msg := range g.Messages(200) {
+		if strings.HasSuffix(msg, "\n") {
+			with++
+		} else {
+			without++
+		}
+	}
+	if with == 0 || without == 0 {
+		t.Errorf("Expected messages with and without newlines, got %d and %d", with, without)
+	}
+
+	g, _ = NewFooGenerator(1, GeneratorOptions{MinLen: 40})
+	for _, msg := range g.Messages(50) {
+		if n := utf8.RuneCountInString(msg); n != 40 || strings.HasSuffix(msg, "\n") {
+			t.Errorf("Expected 40 runes without newline, got %q", msg)
+		}
+	}
+}
+
+func TestFooGeneratorInvalidOptions(t *testing.T) {
+	for name, opts := range map[string]GeneratorOptions{
+		"negative length":  {MinLen: -1},
+		"inverted lengths": {MinLen: 10, MaxLen: 2},
+		"line break":       {Charset: "ab\n"},
+		"invalid UTF-8":    {Charset: "a\xff"},
+		"unknown newline":  {Newline: Newline(7)},
+	} {
+		if _, err := NewFooGenerator(1, opts); err == nil {
+			t.Errorf("%s: Expected an error", name)
+		}
+	}
+}
+
+func TestFooGeneratorProperties(t *testing.T) {
+	g, _ := NewFooGenerator(7, GeneratorOptions{Charset: DefaultCharset + "äß€"})
+	for range 100 {
+		f := g.NextFooer()
+		var buf bytes.Buffer
+		if err := PrintFooer(&buf, f); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if buf.String() != f.Foo()+"\n" || strings.Count(buf.String(), "\n") != 1 {
+			t.Fatalf("seed %d: Expected %q on one line, got %q", g.Seed(), f, buf.String())
+		}
+		lines, err := io.ReadAll(NewFooerReader(f, 3))
+		if err != nil || string(lines) != strings.Repeat(f.Foo()+"\n", 3) {
+			t.Fatalf("seed %d: Expected three lines of %q, got %q: %v", g.Seed(), f, lines, err)
+		}
+	}
+}
+
+func FuzzLine(f *testing.F) {
+	g, _ := NewFooGenerator(1, GeneratorOptions{})
+	for _, msg := range g.Messages(8) {
+		f.Add(msg)
+	}
+	f.Fuzz(func(t *testing.T, msg string) {
+		if msg == "" || strings.ContainsAny(msg, "\n\r") {
+			t.Skip()
+		}
+		var buf bytes.Buffer
+		if _, err := Line(Formatted(msg)).WriteTo(&buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if buf.String() != msg+"\n" {
+			t.Errorf("Expected %q, got %q", msg+"\n", buf.String())
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package foo
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// msgFoo is the catalog key of the message returned by Foo.
+const msgFoo = "Foo"
+
+// translations maps each supported language to its rendering of msgFoo.
+var translations = map[language.Tag]string{
+	language.English:    "Foo",
+	language.Portuguese: "Fu",
+	language.Spanish:    "Fu",
+	language.French:     "Fou",
+	language.German:     "Fuh",
+	language.Russian:    "Фу",
+	language.Japanese:   "フー",
+}
+
+// Catalog holds the translations of the messages printed by this package.
+// Unsupported languages fall back to English.
+var Catalog catalog.Catalog = newCatalog()
+
+func newCatalog() catalog.Catalog {
+	b := catalog.NewBuilder(catalog.Fallback(language.English))
+	for tag, s := range translations {
+		if err := b.SetString(tag, msgFoo, s); err != nil {
+			panic(err)
+		}
+	}
+	return b
+}
+
+// Languages returns the languages Catalog has translations for.
+func Languages() []language.Tag {
+	return Catalog.Languages()
+}
+
+// Greeter prints the package's messages in one language.
+type Greeter struct {
+	p *message.Printer
+}
+
+// NewGreeter returns a Greeter for the supported language closest to tag,
+// so "pt" and "es-MX" select the Portuguese and Spanish translations.
+func NewGreeter(tag language.Tag) *Greeter {
+	return &Greeter{p: message.NewPrinter(tag, message.Catalog(Catalog))}
+}
+
+// Foo is the localized form of Foo.
+func (g *Greeter) Foo() string {
+	return g.p.Sprintf(msgFoo)
+}
+
+// PrintFoo writes g.Foo to w, like PrintFoo.
+func (g *Greeter) PrintFoo(w io.Writer) {
+	fmt.Fprintln(w, g.Foo())
+}
+
+// PrintLocalized writes Foo translated for tag to w.
+func PrintLocalized(w io.Writer, tag language.Tag) {
+	NewGreeter(tag).PrintFoo(w)
+}
@@ -0,0 +1,55 @@
+package foo
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestGreeter(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected string
+	}{
+		{"en", "Foo"},
+		{"en-GB", "Foo"},
+		{"pt-BR", "Fu"},
+		{"pt", "Fu"},
+		{"es-MX", "Fu"},
+		{"fr-CA", "Fou"},
+		{"de", "Fuh"},
+		{"ru", "Фу"},
+		{"ja", "フー"},
+		{"sw", "Foo"},
+	}
+
+	for _, tt := range tests {
+		g := NewGreeter(language.MustParse(tt.tag))
+		if result := g.Foo(); result != tt.expected {
+			t.Errorf("%s: Expected %q, got %q", tt.tag, tt.expected, result)
+		}
+	}
+}
+
+func TestPrintLocalized(t *testing.T) {
+	var buf bytes.Buffer
+	PrintLocalized(&buf, language.French)
+
+	expected := "Fou\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	PrintLocalized(&buf, language.Und)
+	if buf.String() != "Foo\n" {
+		t.Errorf("Expected English fallback, got %q", buf.String())
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	if n := len(Languages()); n != len(translations) {
+		t.Errorf("Expected %d languages, got %d", len(translations), n)
+	}
+}
@@ -0,0 +1,45 @@
+package foo
+
+import (
+	"bufio"
+	"io"
+)
+
+// FooPrinter prints Foo lines through an internal buffer, for callers
+// printing in tight loops such as heartbeat logging. Printing does not
+// allocate; the lines reach the underlying writer when the buffer fills
+// or on Flush.
+type FooPrinter struct {
+	w *bufio.Writer
+}
+
+// NewFooPrinter returns a FooPrinter writing to w.
+func NewFooPrinter(w io.Writer) *FooPrinter {
+	return &FooPrinter{w: bufio.NewWriter(w)}
+}
+
+// PrintFoo buffers the line PrintFoo writes, counting it in Counter.
+func (p *FooPrinter) PrintFoo() error {
+	_, err := p.w.WriteString(fooLine)
+	Counter.count(err)
+	return err
+}
+
+// PrintFooer buffers f's message on a line of its own, like PrintFooer.
+func (p *FooPrinter) PrintFooer(f Fooer) error {
+	if _, err := p.w.WriteString(f.Foo()); err != nil {
+		return err
+	}
+	return p.w.WriteByte('\n')
+}
+
+// Flush writes the buffered lines to the underlying writer.
+func (p *FooPrinter) Flush() error {
+	return p.w.Flush()
+}
+
+// Reset discards the buffered lines and makes p write to w, so a printer
+// can be reused.
+func (p *FooPrinter) Reset(w io.Writer) {
+	p.w.Reset(w)
+}
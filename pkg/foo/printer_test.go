@@ -0,0 +1,91 @@
+package foo
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// writerOnly hides the io.StringWriter of the writer it wraps.
+type writerOnly struct{ io.Writer }
+
+func TestPrintFooWriters(t *testing.T) {
+	var buf bytes.Buffer
+	PrintFoo(writerOnly{&buf})
+	PrintFoo(&buf)
+
+	expected := "Foo\nFoo\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestFooPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewFooPrinter(&buf)
+
+	if err := p.PrintFoo(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.PrintFooer(Foof("%s x%d", Foo(), 2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing written before Flush, got %q", buf.String())
+	}
+	if err := p.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Foo\nFoo x2\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	var other bytes.Buffer
+	p.PrintFoo()
+	p.Reset(&other)
+	p.PrintFoo()
+	p.Flush()
+	if buf.String() != expected || other.String() != "Foo\n" {
+		t.Errorf("Expected the reset printer to write only to the new writer, got %q and %q", buf.String(), other.String())
+	}
+}
+
+func TestPrintFooAllocs(t *testing.T) {
+	p := NewFooPrinter(io.Discard)
+	tests := map[string]func(){
+		"PrintFoo":              func() { PrintFoo(io.Discard) },
+		"FooPrinter.PrintFoo":   func() { p.PrintFoo() },
+		"FooPrinter.PrintFooer": func() { p.PrintFooer(Default) },
+	}
+	for name, f := range tests {
+		if n := testing.AllocsPerRun(100, f); n != 0 {
+			t.Errorf("%s: expected 0 allocations, got %v", name, n)
+		}
+	}
+}
+
+func BenchmarkPrintFoo(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		PrintFoo(io.Discard)
+	}
+}
+
+func BenchmarkPrintFooWriter(b *testing.B) {
+	b.ReportAllocs()
+	w := writerOnly{io.Discard}
+	for b.Loop() {
+		PrintFoo(w)
+	}
+}
+
+func BenchmarkFooPrinter(b *testing.B) {
+	b.ReportAllocs()
+	p := NewFooPrinter(io.Discard)
+	for b.Loop() {
+		p.PrintFoo()
+	}
+	p.Flush()
+}
@@ -0,0 +1,108 @@
+package foo
+
+import (
+	"io"
+	"strings"
+)
+
+// chunkSize is about the size of the repeated lines a FooReader copies
+// from, so that large reads and WriteTo copy whole runs of lines at once.
+const chunkSize = 4096
+
+// FooReader reads Foo lines, the output of PrintFoo repeated, for piping
+// Foo into tests and load generators. It implements io.WriterTo, so
+// io.Copy writes its lines in large runs without allocating, and it reads
+// into the ReadFrom of writers such as *bytes.Buffer.
+type FooReader struct {
+	line  string
+	chunk []byte
+	// left is the number of bytes still to read, or -1 forever; off is the
+	// offset of the next byte within its line.
+	left int64
+	off  int
+}
+
+var (
+	_ io.Reader   = (*FooReader)(nil)
+	_ io.WriterTo = (*FooReader)(nil)
+)
+
+// NewFooReader returns a FooReader of n Foo lines; n < 0 repeats them
+// forever.
+func NewFooReader(n int) *FooReader {
+	return NewFooerReader(Default, n)
+}
+
+// NewFooerReader returns a FooReader of n lines of f's message, like
+// PrintFooer writes; n < 0 repeats them forever.
+func NewFooerReader(f Fooer, n int) *FooReader {
+	line := f.Foo() + "\n"
+	r := &FooReader{line: line, chunk: []byte(strings.Repeat(line, max(1, chunkSize/len(line))))}
+	r.Reset(n)
+	return r
+}
+
+// Read reads the next bytes of the lines. It returns io.EOF once all n
+// lines are read, and never for a reader repeating forever.
+func (r *FooReader) Read(p []byte) (int, error) {
+	if r.left == 0 {
+		return 0, io.EOF
+	}
+	var n int
+	for n < len(p) && r.left != 0 {
+		n += r.advance(copy(p[n:], r.next()))
+	}
+	return n, nil
+}
+
+// WriteTo writes the unread lines to w. For a reader repeating forever, it
+// only returns with the error of a write.
+func (r *FooReader) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for r.left != 0 {
+		k, err := w.Write(r.next())
+		total += int64(r.advance(k))
+		if err != nil {
+			return total, err
+		}
+		if k == 0 {
+			return total, io.ErrShortWrite
+		}
+	}
+	return total, nil
+}
+
+// Len returns the number of unread bytes, or -1 for a reader repeating
+// forever.
+func (r *FooReader) Len() int64 {
+	return r.left
+}
+
+// Reset makes r read n lines again from the start; n < 0 repeats them
+// forever.
+func (r *FooReader) Reset(n int) {
+	r.off = 0
+	r.left = -1
+	if n >= 0 {
+		r.left = int64(n) * int64(len(r.line))
+	}
+}
+
+// next returns the bytes of the chunk from the current offset, cut to the
+// bytes left.
+func (r *FooReader) next() []byte {
+	b := r.chunk[r.off:]
+	if r.left >= 0 && int64(len(b)) > r.left {
+		b = b[:r.left]
+	}
+	return b
+}
+
+// advance moves past k bytes read from next, and returns k.
+func (r *FooReader) advance(k int) int {
+	r.off = (r.off + k) % len(r.line)
+	if r.left > 0 {
+		r.left -= int64(k)
+	}
+	return k
+}
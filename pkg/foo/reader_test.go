@@ -0,0 +1,130 @@
+package foo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFooReader(t *testing.T) {
+	expected := strings.Repeat("Foo\n", 3000)
+	if err := iotest.TestReader(NewFooReader(3000), []byte(expected)); err != nil {
+		t.Error(err)
+	}
+
+	r := NewFooerReader(Foof("%s x%d", Foo(), 2), 2)
+	if r.Len() != 14 {
+		t.Errorf("Expected %d, got %d", 14, r.Len())
+	}
+	data, err := io.ReadAll(iotest.OneByteReader(r))
+	if err != nil || string(data) != "Foo x2\nFoo x2\n" {
+		t.Errorf("Expected %q, got %q (err=%v)", "Foo x2\nFoo x2\n", data, err)
+	}
+	if n, err := r.Read(make([]byte, 8)); n != 0 || err != io.EOF {
+		t.Errorf("Expected io.EOF, got %d and %v", n, err)
+	}
+
+	r.Reset(0)
+	if n, err := r.Read(make([]byte, 8)); n != 0 || err != io.EOF {
+		t.Errorf("Expected io.EOF for no lines, got %d and %v", n, err)
+	}
+}
+
+func TestFooReaderForever(t *testing.T) {
+	r := NewFooReader(-1)
+	if r.Len() != -1 {
+		t.Errorf("Expected %d, got %d", -1, r.Len())
+	}
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, 10002)
+	if err != nil || n != 10002 {
+		t.Fatalf("Expected 10002 bytes, got %d and %v", n, err)
+	}
+	if expected := strings.Repeat("Foo\n", 2501)[:10002]; buf.String() != expected {
+		t.Errorf("Expected %d bytes of Foo lines, got %q", len(expected), buf.String()[:40])
+	}
+
+	// CopyN stopped within a line; the next read carries on from there.
+	p := make([]byte, 6)
+	if _, err := io.ReadFull(r, p); err != nil || string(p) != "o\nFoo\n" {
+		t.Errorf("Expected %q, got %q (err=%v)", "o\nFoo\n", p, err)
+	}
+}
+
+// fullWriter discards limit bytes, then fails.
+type fullWriter struct{ limit int }
+
+var errFull = errors.New("full")
+
+func (w *fullWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errFull
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestFooReaderWriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, NewFooReader(5000))
+	if err != nil || n != 20000 || buf.String() != strings.Repeat("Foo\n", 5000) {
+		t.Errorf("Expected 5000 lines, got %d bytes and %v", n, err)
+	}
+
+	// bytes.Buffer reads the lines through its ReadFrom.
+	buf.Reset()
+	if _, err := buf.ReadFrom(NewFooReader(3)); err != nil || buf.String() != "Foo\nFoo\nFoo\n" {
+		t.Errorf("Expected %q, got %q (err=%v)", "Foo\nFoo\nFoo\n", buf.String(), err)
+	}
+
+	r := NewFooReader(-1)
+	n, err = r.WriteTo(&fullWriter{limit: 4099})
+	if !errors.Is(err, errFull) || n != 4099 {
+		t.Errorf("Expected the write error after 4099 bytes, got %d and %v", n, err)
+	}
+	p := make([]byte, 4)
+	if _, err := io.ReadFull(r, p); err != nil || string(p) != "\nFoo" {
+		t.Errorf("Expected %q, got %q (err=%v)", "\nFoo", p, err)
+	}
+}
+
+func TestFooReaderAllocs(t *testing.T) {
+	r := NewFooReader(-1)
+	p := make([]byte, 1024)
+	w := &fullWriter{}
+	tests := map[string]func(){
+		"Read":    func() { r.Read(p) },
+		"WriteTo": func() { w.limit = 1 << 16; r.WriteTo(w) },
+	}
+	for name, f := range tests {
+		if n := testing.AllocsPerRun(100, f); n != 0 {
+			t.Errorf("%s: expected 0 allocations, got %v", name, n)
+		}
+	}
+}
+
+func BenchmarkFooReaderRead(b *testing.B) {
+	r := NewFooReader(-1)
+	p := make([]byte, 32*1024)
+	b.SetBytes(int64(len(p)))
+	b.ReportAllocs()
+	for b.Loop() {
+		r.Read(p)
+	}
+}
+
+func BenchmarkFooReaderWriteTo(b *testing.B) {
+	const lines = 8192
+	r := NewFooReader(lines)
+	b.SetBytes(lines * int64(len(fooLine)))
+	b.ReportAllocs()
+	for b.Loop() {
+		r.Reset(lines)
+		r.WriteTo(io.Discard)
+	}
+}
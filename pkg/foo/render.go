@@ -0,0 +1,136 @@
+package foo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Level is the severity a Renderer prints a message with.
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Color is the SGR parameter of an ANSI color, such as "31" for red.
+type Color string
+
+const (
+	Red    Color = "31"
+	Green  Color = "32"
+	Yellow Color = "33"
+	Blue   Color = "34"
+	Cyan   Color = "36"
+	Gray   Color = "90"
+)
+
+// DefaultColors are the colors of a new Renderer.
+var DefaultColors = map[Level]Color{
+	LevelInfo:  Cyan,
+	LevelWarn:  Yellow,
+	LevelError: Red,
+}
+
+// DefaultTemplate renders the message alone, as PrintFoo does.
+const DefaultTemplate = "{{.Message}}"
+
+// Entry is the data templates are executed with.
+type Entry struct {
+	Level   Level
+	Message string
+	Time    time.Time
+}
+
+// Renderer writes messages through a template, such as
+// "[{{.Level}}] {{.Message}}", one per line, colored by level when Color
+// is set.
+type Renderer struct {
+	w      io.Writer
+	tmpl   *template.Template
+	colors map[Level]Color
+
+	// Color wraps each line in the ANSI color of its level. NewRenderer
+	// sets it when w supports colors (see ColorEnabled).
+	Color bool
+}
+
+// NewRenderer returns a Renderer writing to w with DefaultTemplate and
+// DefaultColors.
+func NewRenderer(w io.Writer) *Renderer {
+	r := &Renderer{w: w, colors: make(map[Level]Color, len(DefaultColors)), Color: ColorEnabled(w)}
+	for l, c := range DefaultColors {
+		r.colors[l] = c
+	}
+	if err := r.SetTemplate(DefaultTemplate); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// SetTemplate makes r render messages with the text/template text, whose
+// data is an Entry.
+func (r *Renderer) SetTemplate(text string) error {
+	tmpl, err := template.New("foo").Parse(text)
+	if err != nil {
+		return fmt.Errorf("foo: parse template: %w", err)
+	}
+	r.tmpl = tmpl
+	return nil
+}
+
+// SetColor sets the color of the lines printed at level; an empty c
+// prints them uncolored.
+func (r *Renderer) SetColor(level Level, c Color) {
+	r.colors[level] = c
+}
+
+// Render writes f's message at level on a line of its own.
+func (r *Renderer) Render(level Level, f Fooer) error {
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, Entry{Level: level, Message: f.Foo(), Time: now()}); err != nil {
+		return fmt.Errorf("foo: render: %w", err)
+	}
+	line := strings.TrimSuffix(buf.String(), "\n")
+	if c := r.colors[level]; r.Color && c != "" {
+		line = "\x1b[" + string(c) + "m" + line + "\x1b[0m"
+	}
+	_, err := io.WriteString(r.w, line+"\n")
+	return err
+}
+
+// IsTerminal reports whether w is a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// ColorEnabled reports whether ANSI colors should be written to w: w must
+// be a terminal, NO_COLOR (https://no-color.org) unset or empty, and TERM
+// not "dumb".
+func ColorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(w)
+}
@@ -0,0 +1,99 @@
+package foo
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestRenderer(t *testing.T) {
+	fixedNow(t)
+	var buf bytes.Buffer
+	r := NewRenderer(&buf)
+	if r.Color {
+		t.Fatalf("Expected no colors for a buffer")
+	}
+
+	if err := r.Render(LevelInfo, Default); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.SetTemplate("[{{.Level}}] {{.Message}} at {{.Time.Format \"15:04\"}}"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Render(LevelWarn, Foof("%s twice", Foo())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Foo\n[warn] Foo twice at 12:30\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	if err := r.SetTemplate("{{.Message"); err == nil {
+		t.Errorf("Expected error for an invalid template")
+	}
+	if err := r.SetTemplate("{{.Missing}}"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Render(LevelInfo, Default); err == nil {
+		t.Errorf("Expected error for an unknown field")
+	}
+}
+
+func TestRendererColor(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRenderer(&buf)
+	r.Color = true
+	r.SetTemplate("[{{.Level}}] {{.Message}}\n")
+	r.SetColor(LevelInfo, "")
+	r.SetColor(LevelWarn, Gray)
+
+	r.Render(LevelError, Default)
+	r.Render(LevelWarn, Default)
+	r.Render(LevelInfo, Default)
+
+	expected := "\x1b[31m[error] Foo\x1b[0m\n\x1b[90m[warn] Foo\x1b[0m\n[info] Foo\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if DefaultColors[LevelWarn] != Yellow {
+		t.Errorf("Expected SetColor not to change DefaultColors")
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+	if IsTerminal(f) || IsTerminal(&bytes.Buffer{}) {
+		t.Errorf("Expected files and buffers not to be terminals")
+	}
+	if ColorEnabled(f) {
+		t.Errorf("Expected no colors for a file")
+	}
+
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		if !ColorEnabled(tty) {
+			t.Errorf("Expected colors for a terminal")
+		}
+		t.Setenv("NO_COLOR", "1")
+		if ColorEnabled(tty) {
+			t.Errorf("Expected NO_COLOR to disable colors")
+		}
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	expected := map[Level]string{LevelInfo: "info", LevelWarn: "warn", LevelError: "error", Level(7): "Level(7)"}
+	for l, s := range expected {
+		if l.String() != s {
+			t.Errorf("Expected %q, got %q", s, l.String())
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package freeze
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2026, 3, 4, 10, 30, 15, 0, time.UTC) // a Wednesday
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 4, 10, 31, 0, 0, time.UTC)},
+		{"0 18 * * fri", time.Date(2026, 3, 6, 18, 0, 0, 0, time.UTC)},
+		{"*/15 9-17 * * 1-5", time.Date(2026, 3, 4, 10, 45, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 15 * 0", time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)},
+		{"30 10 4 3 *", time.Date(2027, 3, 4, 10, 30, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec, nil)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): unexpected error: %v", tt.spec, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.expected) {
+			t.Errorf("%q: Expected %s, got %s", tt.spec, tt.expected, got)
+		}
+	}
+}
+
+func TestScheduleLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+	s, err := ParseSchedule("0 18 * * *", loc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := s.Next(time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+	if expected := time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC); !got.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * * funday", "5-1 * * * *", "*/0 * * * *"} {
+		if _, err := ParseSchedule(spec, nil); err == nil {
+			t.Errorf("ParseSchedule(%q): Expected an error", spec)
+		}
+	}
+}
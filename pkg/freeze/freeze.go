@@ -0,0 +1,144 @@
+// Package freeze implements release freeze windows: periods, one-off or
+// recurring, during which releases are not published.
+//
+// A one-off Window spans From to Until; a recurring one starts whenever its
+// cron Schedule matches and lasts Duration, e.g. "0 18 * * fri" for 62h
+// freezes weekends from Friday evening to Monday morning.
+package freeze
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+)
+
+// ErrFrozen is returned when a release is attempted inside a window.
+var ErrFrozen = relerr.ErrFrozen
+
+// Window is a period during which releases are frozen.
+type Window struct {
+	Name string
+	// From and Until bound a one-off window; Until is excluded.
+	From, Until time.Time
+	// Schedule starts a recurring window lasting Duration.
+	Schedule *Schedule
+	Duration time.Duration
+}
+
+// Range returns the one-off window from from to until, which are dates
+// ("2026-12-20") or times ("2026-12-20T18:00", or RFC 3339) in loc. A date
+// until includes the whole day.
+func Range(name, from, until string, loc *time.Location) (Window, error) {
+	w := Window{Name: name}
+	var err error
+	if w.From, _, err = parseTime(from, loc); err != nil {
+		return Window{}, err
+	}
+	end, date, err := parseTime(until, loc)
+	if err != nil {
+		return Window{}, err
+	}
+	if date {
+		end = end.AddDate(0, 0, 1)
+	}
+	if !end.After(w.From) {
+		return Window{}, fmt.Errorf("freeze: %s: until %s is not after from %s", name, until, from)
+	}
+	w.Until = end
+	return w, nil
+}
+
+// Recurring returns the window starting at every match of the cron
+// expression spec in loc and lasting d.
+func Recurring(name, spec string, d time.Duration, loc *time.Location) (Window, error) {
+	if d <= 0 {
+		return Window{}, fmt.Errorf("freeze: %s: duration %s must be positive", name, d)
+	}
+	s, err := ParseSchedule(spec, loc)
+	if err != nil {
+		return Window{}, err
+	}
+	return Window{Name: name, Schedule: s, Duration: d}, nil
+}
+
+var layouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// parseTime parses s in loc, reporting whether it is a date alone.
+func parseTime(s string, loc *time.Location) (time.Time, bool, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, loc); err == nil {
+		return t, true, nil
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("freeze: %q is not a date (2006-01-02) or time (2006-01-02T15:04)", s)
+}
+
+// Active reports whether t is inside w, and when the period containing t
+// ends.
+func (w Window) Active(t time.Time) (end time.Time, ok bool) {
+	if w.Schedule == nil {
+		return w.Until, !t.Before(w.From) && t.Before(w.Until)
+	}
+	// The earliest start whose period has not ended by t.
+	start := w.Schedule.Next(t.Add(-w.Duration).Add(time.Nanosecond))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, false
+	}
+	return start.Add(w.Duration), true
+}
+
+// Frozen returns the window of ws that t is inside, if any, and when its
+// period ends.
+func Frozen(ws []Window, t time.Time) (Window, time.Time, bool) {
+	for _, w := range ws {
+		if end, ok := w.Active(t); ok {
+			return w, end, true
+		}
+	}
+	return Window{}, time.Time{}, false
+}
+
+// maxHops bounds the overlapping periods OpenAt steps over.
+const maxHops = 10000
+
+// OpenAt returns the first time at or after t outside every window of ws,
+// which is t itself when no window is active.
+func OpenAt(ws []Window, t time.Time) (time.Time, error) {
+	for range maxHops {
+		_, end, ok := Frozen(ws, t)
+		if !ok {
+			return t, nil
+		}
+		t = end
+	}
+	return time.Time{}, fmt.Errorf("%w: the freeze windows never open", ErrFrozen)
+}
+
+// Check returns an error wrapping ErrFrozen when t is inside a window of
+// ws, naming it and when releases open again.
+func Check(ws []Window, t time.Time) error {
+	w, _, ok := Frozen(ws, t)
+	if !ok {
+		return nil
+	}
+	open, err := OpenAt(ws, t)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s until %s", ErrFrozen, w.describe(), open.Format("2006-01-02 15:04 MST"))
+}
+
+func (w Window) describe() string {
+	if strings.TrimSpace(w.Name) == "" {
+		return "freeze window"
+	}
+	return "freeze window " + w.Name
+}
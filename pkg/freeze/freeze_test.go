@@ -0,0 +1,96 @@
+package freeze
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRange(t *testing.T) {
+	w, err := Range("holidays", "2026-12-20", "2027-01-02", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		at     time.Time
+		active bool
+	}{
+		{time.Date(2026, 12, 19, 23, 59, 0, 0, time.UTC), false},
+		{time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2027, 1, 2, 23, 0, 0, 0, time.UTC), true},
+		{time.Date(2027, 1, 3, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if _, ok := w.Active(tt.at); ok != tt.active {
+			t.Errorf("%s: Expected active %v, got %v", tt.at, tt.active, ok)
+		}
+	}
+
+	if _, err := Range("x", "2026-12-20T18:00", "2026-12-20T09:00", nil); err == nil {
+		t.Errorf("Expected an error for an empty range")
+	}
+	if _, err := Range("x", "tomorrow", "2026-12-20", nil); err == nil {
+		t.Errorf("Expected an error for an invalid date")
+	}
+}
+
+func TestRecurring(t *testing.T) {
+	w, err := Recurring("weekend", "0 18 * * fri", 62*time.Hour, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	end, ok := w.Active(time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)) // Sunday
+	if expected := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC); !ok || !end.Equal(expected) {
+		t.Errorf("Expected a window ending %s, got %s, %v", expected, end, ok)
+	}
+	if _, ok := w.Active(time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)); ok {
+		t.Errorf("Expected Monday 08:00 to be open")
+	}
+	if _, ok := w.Active(time.Date(2026, 3, 6, 17, 59, 0, 0, time.UTC)); ok {
+		t.Errorf("Expected Friday 17:59 to be open")
+	}
+
+	if _, err := Recurring("x", "0 18 * * fri", 0, nil); err == nil {
+		t.Errorf("Expected an error for a zero duration")
+	}
+}
+
+func TestOpenAt(t *testing.T) {
+	weekend, _ := Recurring("weekend", "0 18 * * fri", 62*time.Hour, nil)
+	holidays, _ := Range("holidays", "2026-03-09", "2026-03-10", nil)
+	ws := []Window{weekend, holidays}
+
+	at := time.Date(2026, 3, 7, 9, 0, 0, 0, time.UTC)
+	open, err := OpenAt(ws, at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC); !open.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, open)
+	}
+
+	free := time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)
+	if open, _ := OpenAt(ws, free); !open.Equal(free) {
+		t.Errorf("Expected %s, got %s", free, open)
+	}
+
+	always, _ := Recurring("always", "* * * * *", time.Hour, nil)
+	if _, err := OpenAt([]Window{always}, free); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	holidays, _ := Range("holidays", "2026-12-20", "2027-01-02", nil)
+
+	err := Check([]Window{holidays}, time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, ErrFrozen) || !strings.Contains(err.Error(), "freeze window holidays until 2027-01-03 00:00 UTC") {
+		t.Errorf("Expected the holidays freeze, got %v", err)
+	}
+	if err := Check([]Window{holidays}, time.Date(2027, 1, 3, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
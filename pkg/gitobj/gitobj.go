@@ -0,0 +1,27 @@
+// Package gitobj holds the git objects the release engines read, commits
+// and tags, as plain values. It does no I/O, so that the packages analysing
+// them (commits, changelog, contributors) build for targets without a git
+// binary or a file system, such as WASM in a browser; gitrepo reads them
+// from a repository.
+package gitobj
+
+import "time"
+
+// Tag is a git tag and the commit it points at.
+type Tag struct {
+	Name string
+	// Commit is the hash of the tagged commit (annotated tags are peeled).
+	Commit    string
+	Annotated bool
+}
+
+// Commit is a single commit read from the history.
+type Commit struct {
+	Hash        string
+	Parents     []string
+	AuthorName  string
+	AuthorEmail string
+	Date        time.Time
+	// Message is the full raw commit message (subject, body and trailers).
+	Message string
+}
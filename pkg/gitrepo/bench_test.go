@@ -0,0 +1,131 @@
+package gitrepo
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchCommits is the size of the history of the benchmarks: merges of
+// three-commit pull requests on a mainline, the latest release
+// benchReleased commits back.
+const (
+	benchCommits  = 20000
+	benchReleased = 1000
+)
+
+// newBenchRepo writes the history of the benchmarks through git
+// fast-import, which is much faster than committing one by one.
+func newBenchRepo(b *testing.B) *Git {
+	b.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		b.Skip("git not installed")
+	}
+	dir := b.TempDir()
+	b.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	b.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	g := &Git{Dir: dir}
+	if _, err := g.run(context.Background(), "init", "--quiet", "--initial-branch=main"); err != nil {
+		b.Fatal(err)
+	}
+
+	var stream strings.Builder
+	mark, main, tagged := 0, 0, false
+	commit := func(ref, message string, from int, merge int) int {
+		mark++
+		fmt.Fprintf(&stream, "commit %s\nmark :%d\ncommitter Bench <bench@example.com> %d +0000\ndata %d\n%s\n", ref, mark, 1700000000+mark, len(message), message)
+		if from > 0 {
+			fmt.Fprintf(&stream, "from :%d\n", from)
+		}
+		if merge > 0 {
+			fmt.Fprintf(&stream, "merge :%d\n", merge)
+		}
+		return mark
+	}
+	for pr := 1; mark < benchCommits; pr++ {
+		topic := main
+		for i := range 3 {
+			topic = commit("refs/heads/topic", fmt.Sprintf("fix(pkg%d): change %d of pull request %d", pr%50, i, pr), topic, 0)
+		}
+		main = commit("refs/heads/main", fmt.Sprintf("Merge pull request #%d from octo/topic\n\nfeat(pkg%d): pull request %d", pr, pr%50, pr), main, topic)
+		if mark >= benchCommits-benchReleased && !tagged {
+			tagged = true
+			fmt.Fprintf(&stream, "tag v1.0.0\nfrom :%d\ntagger Bench <bench@example.com> 1700000000 +0000\ndata 6\nv1.0.0\n", main)
+		}
+	}
+	cmd := exec.Command("git", "fast-import", "--quiet")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stream.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		b.Fatalf("fast-import: %v: %s", err, out)
+	}
+	if _, err := g.run(context.Background(), "checkout", "--quiet", "main"); err != nil {
+		b.Fatal(err)
+	}
+	return g
+}
+
+// BenchmarkCommitsSince reads the commits since the latest release, and
+// the whole history, the way each traversal and commit limit does.
+func BenchmarkCommitsSince(b *testing.B) {
+	g := newBenchRepo(b)
+	ctx := context.Background()
+	for _, bc := range []struct {
+		name       string
+		ref        string
+		traversal  Traversal
+		maxCommits int
+	}{
+		{"since/all", "v1.0.0", TraverseAll, 0},
+		{"since/first-parent", "v1.0.0", TraverseFirstParent, 0},
+		{"history/all", "", TraverseAll, 0},
+		{"history/first-parent", "", TraverseFirstParent, 0},
+		{"history/max-1000", "", TraverseAll, 1000},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			r := &Git{Dir: g.Dir, Traversal: bc.traversal, MaxCommits: bc.maxCommits}
+			for b.Loop() {
+				if _, err := r.CommitsSince(ctx, bc.ref); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkClone gets a checkout ready to plan the next release: a full
+// clone, against a shallow one deepened until the latest release, as a CI
+// checkout with commits.deepen would.
+func BenchmarkClone(b *testing.B) {
+	origin := newBenchRepo(b)
+	ctx := context.Background()
+	b.Run("full", func(b *testing.B) {
+		for b.Loop() {
+			dir := filepath.Join(b.TempDir(), "clone")
+			if _, err := origin.run(ctx, "clone", "--quiet", "file://"+origin.Dir, dir); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := (&Git{Dir: dir}).CommitsSince(ctx, "v1.0.0"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("shallow", func(b *testing.B) {
+		for b.Loop() {
+			dir := filepath.Join(b.TempDir(), "clone")
+			if _, err := origin.run(ctx, "clone", "--quiet", "--depth=1", "--no-tags", "file://"+origin.Dir, dir); err != nil {
+				b.Fatal(err)
+			}
+			g := &Git{Dir: dir, Deepen: 2000}
+			if _, err := g.Tags(ctx); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := g.CommitsSince(ctx, "v1.0.0"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
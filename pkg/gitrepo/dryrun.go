@@ -0,0 +1,68 @@
+package gitrepo
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+)
+
+// dryRunRepository delegates reads to the wrapped Repository and reports
+// mutations to log instead of performing them.
+type dryRunRepository struct {
+	Repository
+	log io.Writer
+}
+
+// DryRun wraps repo so that CreateTag, CreateSignedTag, CommitFiles, Push,
+// Pull, DeleteTag, DeleteRemoteTag, Revert and SetNote only describe what
+// they would do on log. All read operations are passed through.
+func DryRun(repo Repository, log io.Writer) Repository {
+	return dryRunRepository{Repository: repo, log: log}
+}
+
+func (d dryRunRepository) CreateTag(_ context.Context, name, message string) error {
+	dryrun.Printf(d.log, "would create tag %s (%q)", name, message)
+	return nil
+}
+
+func (d dryRunRepository) CreateSignedTag(_ context.Context, name, message string, s Signing) error {
+	dryrun.Printf(d.log, "would create signed tag %s (%q)", name, message)
+	return nil
+}
+
+func (d dryRunRepository) CommitFiles(_ context.Context, message string, paths ...string) error {
+	dryrun.Printf(d.log, "would commit %s (%q)", strings.Join(paths, ", "), message)
+	return nil
+}
+
+func (d dryRunRepository) Push(_ context.Context, remote, ref string) error {
+	dryrun.Printf(d.log, "would push %s to %s", ref, remote)
+	return nil
+}
+
+func (d dryRunRepository) Pull(_ context.Context, remote, branch string) error {
+	dryrun.Printf(d.log, "would pull %s from %s", branch, remote)
+	return nil
+}
+
+func (d dryRunRepository) DeleteTag(_ context.Context, name string) error {
+	dryrun.Printf(d.log, "would delete tag %s", name)
+	return nil
+}
+
+func (d dryRunRepository) DeleteRemoteTag(_ context.Context, remote, name string) error {
+	dryrun.Printf(d.log, "would delete tag %s from %s", name, remote)
+	return nil
+}
+
+func (d dryRunRepository) Revert(_ context.Context, commit string) error {
+	dryrun.Printf(d.log, "would revert %s", commit)
+	return nil
+}
+
+func (d dryRunRepository) SetNote(_ context.Context, ref, commit string, _ []byte) error {
+	dryrun.Printf(d.log, "would write the note of %s to %s", shortHash(commit), ref)
+	return nil
+}
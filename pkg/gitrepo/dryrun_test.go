@@ -0,0 +1,69 @@
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+
+	var log bytes.Buffer
+	repo := DryRun(g, &log)
+
+	if err := repo.CreateTag(context.Background(), "v1.0.0", "chore(release): v1.0.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.CreateSignedTag(context.Background(), "v1.0.1", "chore(release): v1.0.1", Signing{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.CommitFiles(context.Background(), "chore(release): v1.0.0", "VERSION", "package.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.Push(context.Background(), "origin", "v1.0.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.Pull(context.Background(), "origin", "main"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mustRun(t, g, "tag", "v0.9.0")
+	if err := repo.DeleteTag(context.Background(), "v0.9.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.DeleteRemoteTag(context.Background(), "origin", "v0.9.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.Revert(context.Background(), "HEAD"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	head, _ := g.Head(context.Background())
+	if err := repo.SetNote(context.Background(), "refs/notes/release", head, []byte("{}")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if note, err := repo.Note(context.Background(), "refs/notes/release", head); err != nil || note != nil {
+		t.Errorf("Expected no note to be written, got %q and %v", note, err)
+	}
+
+	tags, err := repo.Tags(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 1 || tags[0].Name != "v0.9.0" {
+		t.Errorf("Expected no tags to be created or deleted, got %#v", tags)
+	}
+
+	expected := "[dry-run] would create tag v1.0.0 (\"chore(release): v1.0.0\")\n" +
+		"[dry-run] would create signed tag v1.0.1 (\"chore(release): v1.0.1\")\n" +
+		"[dry-run] would commit VERSION, package.json (\"chore(release): v1.0.0\")\n" +
+		"[dry-run] would push v1.0.0 to origin\n" +
+		"[dry-run] would pull main from origin\n" +
+		"[dry-run] would delete tag v0.9.0\n" +
+		"[dry-run] would delete tag v0.9.0 from origin\n" +
+		"[dry-run] would revert HEAD\n" +
+		"[dry-run] would write the note of " + head[:7] + " to refs/notes/release\n"
+	if log.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, log.String())
+	}
+}
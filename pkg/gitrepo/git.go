@@ -0,0 +1,449 @@
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+const (
+	fieldSep  = "\x1f"
+	recordSep = "\x1e"
+)
+
+// Git implements Repository by running the git binary in Dir.
+type Git struct {
+	Dir string
+	// Logger receives a debug record for every git command; nil discards.
+	Logger *slog.Logger
+	// Retry governs retries of pushes and fetches failing with a network
+	// error. The zero value tries once.
+	Retry retry.Policy
+	// Traversal selects the commits CommitsSince returns around merges;
+	// empty means TraverseAll.
+	Traversal Traversal
+	// MaxCommits caps the commits CommitsSince reads, newest first; 0 reads
+	// them all. A capped read is logged as a warning.
+	MaxCommits int
+	// Deepen, on a shallow clone, fetches this many more commits of
+	// history from Remote each time CommitsSince needs commits the clone
+	// does not have, and the remote's tags before Tags lists them; 0 reads
+	// the clone as it is. See deepenTo.
+	Deepen int
+	// Remote is fetched from to deepen a shallow clone; empty means origin.
+	Remote string
+
+	// tagsFetched is set once the tags of a shallow clone were fetched.
+	tagsFetched bool
+}
+
+// Traversal is a strategy for walking the history through merge commits.
+type Traversal string
+
+const (
+	// TraverseAll returns every commit: merge commits and the commits of
+	// the branches they merged.
+	TraverseAll Traversal = "all"
+	// TraverseNoMerges skips merge commits, keeping the commits of merged
+	// branches.
+	TraverseNoMerges Traversal = "no-merges"
+	// TraverseFirstParent follows only the first parent of merges, so a
+	// merge commit stands for the branch it merged, whose own commits are
+	// skipped.
+	TraverseFirstParent Traversal = "first-parent"
+)
+
+// Traversals lists the valid traversal strategies.
+var Traversals = []Traversal{TraverseAll, TraverseNoMerges, TraverseFirstParent}
+
+var _ Repository = (*Git)(nil)
+
+// Open returns a Git for the repository containing dir.
+func Open(ctx context.Context, dir string) (*Git, error) {
+	g := &Git{Dir: dir}
+	root, err := g.run(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("gitrepo: open %s: %w", dir, err)
+	}
+	g.Dir = strings.TrimSpace(root)
+	return g, nil
+}
+
+func (g *Git) Tags(ctx context.Context) ([]Tag, error) {
+	if err := g.fetchShallowTags(ctx); err != nil {
+		return nil, err
+	}
+	out, err := g.run(ctx, "for-each-ref", "refs/tags",
+		"--format=%(refname:short)"+fieldSep+"%(objecttype)"+fieldSep+"%(objectname)"+fieldSep+"%(*objectname)")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		f := strings.Split(line, fieldSep)
+		if len(f) != 4 {
+			return nil, fmt.Errorf("gitrepo: unexpected for-each-ref output %q", line)
+		}
+		t := Tag{Name: f[0], Commit: f[2], Annotated: f[1] == "tag"}
+		if t.Annotated {
+			t.Commit = f[3]
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// RemoteTags parses git ls-remote, which lists an annotated tag twice: the
+// tag object, then the commit it peels to as "<name>^{}".
+func (g *Git) RemoteTags(ctx context.Context, remote string) ([]Tag, error) {
+	var out string
+	err := g.Retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		out, err = g.run(ctx, "ls-remote", "--tags", remote)
+		if err != nil && transient(err) {
+			return retry.Retryable(err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	index := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		hash, ref, ok := strings.Cut(line, "\t")
+		name, isTag := strings.CutPrefix(ref, "refs/tags/")
+		if !ok || !isTag {
+			return nil, fmt.Errorf("gitrepo: unexpected ls-remote output %q", line)
+		}
+		if name, peeled := strings.CutSuffix(name, "^{}"); peeled {
+			if i, seen := index[name]; seen {
+				tags[i].Commit, tags[i].Annotated = hash, true
+			}
+			continue
+		}
+		index[name] = len(tags)
+		tags = append(tags, Tag{Name: name, Commit: hash})
+	}
+	return tags, nil
+}
+
+func (g *Git) CommitsSince(ctx context.Context, ref string, paths ...string) ([]Commit, error) {
+	if err := g.deepenTo(ctx, ref); err != nil {
+		return nil, err
+	}
+	revs := ""
+	if ref != "" {
+		revs = ref + "..HEAD"
+	}
+	cs, err := g.log(ctx, revs, g.maxCount(), paths...)
+	if err == nil && g.MaxCommits > 0 && len(cs) == g.MaxCommits {
+		since := "of the history"
+		if ref != "" {
+			since = "since " + ref
+		}
+		log.Or(g.Logger).Warn(fmt.Sprintf("gitrepo: read only the latest %d commits %s: the commit limit may leave older ones out", g.MaxCommits, since))
+	}
+	return cs, err
+}
+
+func (g *Git) Commits(ctx context.Context, revs string) ([]Commit, error) {
+	return g.log(ctx, revs, nil)
+}
+
+// maxCount returns the git log arguments capping the commits read at
+// MaxCommits.
+func (g *Git) maxCount() []string {
+	if g.MaxCommits <= 0 {
+		return nil
+	}
+	return []string{"--max-count=" + strconv.Itoa(g.MaxCommits)}
+}
+
+// log reads the commits of revs, all of HEAD's history when it is empty,
+// that touch paths. opts are passed on to git log.
+func (g *Git) log(ctx context.Context, revs string, opts []string, paths ...string) ([]Commit, error) {
+	args := []string{"log", "--format=" + strings.Join([]string{"%H", "%P", "%an", "%ae", "%aI", "%B"}, fieldSep) + recordSep}
+	args = append(args, opts...)
+	switch g.Traversal {
+	case "", TraverseAll:
+	case TraverseNoMerges:
+		args = append(args, "--no-merges")
+	case TraverseFirstParent:
+		args = append(args, "--first-parent")
+	default:
+		return nil, fmt.Errorf("gitrepo: unknown traversal %q", g.Traversal)
+	}
+	if revs != "" {
+		args = append(args, revs)
+	}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		args = append(args, paths...)
+	}
+	out, err := g.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out)
+}
+
+func (g *Git) CurrentBranch(ctx context.Context) (string, error) {
+	out, err := g.run(ctx, "branch", "--show-current")
+	if err != nil {
+		return "", err
+	}
+	branch := strings.TrimSpace(out)
+	if branch == "" {
+		return "", ErrDetachedHead
+	}
+	return branch, nil
+}
+
+func (g *Git) Head(ctx context.Context) (string, error) {
+	out, err := g.run(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (g *Git) Status(ctx context.Context) ([]string, error) {
+	out, err := g.run(ctx, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 3 {
+			paths = append(paths, line[3:])
+		}
+	}
+	return paths, nil
+}
+
+func (g *Git) Behind(ctx context.Context, remote, branch string) (int, error) {
+	err := g.Retry.Do(ctx, func(ctx context.Context) error {
+		_, err := g.run(ctx, "fetch", "--quiet", remote, branch)
+		if err != nil && transient(err) {
+			return retry.Retryable(err)
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	out, err := g.run(ctx, "rev-list", "--count", "HEAD..FETCH_HEAD")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(out))
+}
+
+func (g *Git) CreateTag(ctx context.Context, name, message string) error {
+	_, err := g.run(ctx, "tag", "--annotate", name, "--message", message)
+	return tagError(name, err)
+}
+
+// tagError marks a failure to create tag name because it exists with
+// ErrTagExists.
+func tagError(name string, err error) error {
+	if err != nil && strings.Contains(err.Error(), "already exists") {
+		return fmt.Errorf("%w: %s", ErrTagExists, name)
+	}
+	return err
+}
+
+func (g *Git) CommitFiles(ctx context.Context, message string, paths ...string) error {
+	if len(paths) == 0 {
+		return errors.New("git commit: no paths")
+	}
+	if _, err := g.run(ctx, append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	_, err := g.run(ctx, append([]string{"commit", "--quiet", "--message", message, "--"}, paths...)...)
+	return err
+}
+
+func (g *Git) Push(ctx context.Context, remote, ref string) error {
+	return g.Retry.Do(ctx, func(ctx context.Context) error {
+		_, err := g.run(ctx, "push", remote, ref)
+		if err != nil && transient(err) {
+			return retry.Retryable(err)
+		}
+		return err
+	})
+}
+
+func (g *Git) Pull(ctx context.Context, remote, branch string) error {
+	return g.Retry.Do(ctx, func(ctx context.Context) error {
+		_, err := g.run(ctx, "pull", "--quiet", "--ff-only", "--tags", remote, branch)
+		if err != nil && transient(err) {
+			return retry.Retryable(err)
+		}
+		return err
+	})
+}
+
+func (g *Git) DeleteTag(ctx context.Context, name string) error {
+	_, err := g.run(ctx, "tag", "--delete", name)
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		return fmt.Errorf("%w: %s", ErrNoSuchTag, name)
+	}
+	return err
+}
+
+func (g *Git) DeleteRemoteTag(ctx context.Context, remote, name string) error {
+	return g.Retry.Do(ctx, func(ctx context.Context) error {
+		// Deleting a missing ref only warns, so look it up first.
+		out, err := g.run(ctx, "ls-remote", "--tags", remote, "refs/tags/"+name)
+		if err == nil && strings.TrimSpace(out) == "" {
+			return fmt.Errorf("%w: %s on %s", ErrNoSuchTag, name, remote)
+		}
+		if err == nil {
+			_, err = g.run(ctx, "push", remote, "--delete", "refs/tags/"+name)
+		}
+		if err != nil && transient(err) {
+			return retry.Retryable(err)
+		}
+		return err
+	})
+}
+
+func (g *Git) Revert(ctx context.Context, commit string) error {
+	_, err := g.run(ctx, "revert", "--no-edit", commit)
+	return err
+}
+
+func (g *Git) ReadFile(ctx context.Context, rev, path string) ([]byte, error) {
+	out, err := g.run(ctx, "show", rev+":"+path)
+	switch {
+	case err != nil && (strings.Contains(err.Error(), "does not exist in") || strings.Contains(err.Error(), "exists on disk, but not in")):
+		return nil, fmt.Errorf("%s at %s: %w", path, rev, fs.ErrNotExist)
+	case err != nil:
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+func (g *Git) DiffStat(ctx context.Context, commit string) (DiffStat, error) {
+	out, err := g.run(ctx, "show", "--numstat", "--format=", "--diff-merges=first-parent", commit)
+	if err != nil {
+		return DiffStat{}, err
+	}
+	var s DiffStat
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		f := strings.SplitN(line, "\t", 3)
+		if len(f) != 3 {
+			continue
+		}
+		s.Files++
+		// Binary files are listed as "-\t-\tpath".
+		ins, _ := strconv.Atoi(f[0])
+		del, _ := strconv.Atoi(f[1])
+		s.Insertions += ins
+		s.Deletions += del
+	}
+	return s, nil
+}
+
+func (g *Git) Note(ctx context.Context, ref, commit string) ([]byte, error) {
+	out, err := g.run(ctx, "notes", "--ref", ref, "show", commit)
+	switch {
+	case err != nil && strings.Contains(err.Error(), "no note found"):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+func (g *Git) SetNote(ctx context.Context, ref, commit string, note []byte) error {
+	_, err := g.run(ctx, "notes", "--ref", ref, "add", "--force", "--message", string(note), commit)
+	return err
+}
+
+// transientErrors are fragments of git's messages for network failures
+// that may succeed when tried again.
+var transientErrors = []string{
+	"Could not resolve host",
+	"Connection timed out",
+	"Connection reset",
+	"Connection refused",
+	"Operation timed out",
+	"The remote end hung up unexpectedly",
+	"early EOF",
+	"RPC failed",
+	"returned error: 429",
+	"returned error: 5",
+}
+
+func transient(err error) bool {
+	for _, s := range transientErrors {
+		if strings.Contains(err.Error(), s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Git) run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	log.Or(g.Logger).Debug("git", "args", strings.Join(args, " "), "dir", g.Dir)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.Dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), ctx.Err())
+		}
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func parseLog(out string) ([]Commit, error) {
+	var cs []Commit
+	for _, record := range strings.Split(out, recordSep) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		f := strings.SplitN(record, fieldSep, 6)
+		if len(f) != 6 {
+			return nil, fmt.Errorf("gitrepo: unexpected log record %q", record)
+		}
+		date, err := time.Parse(time.RFC3339, f[4])
+		if err != nil {
+			return nil, fmt.Errorf("gitrepo: commit %s: %w", f[0], err)
+		}
+		cs = append(cs, Commit{
+			Hash:        f[0],
+			Parents:     strings.Fields(f[1]),
+			AuthorName:  f[2],
+			AuthorEmail: f[3],
+			Date:        date,
+			Message:     strings.TrimSpace(f[5]),
+		})
+	}
+	return cs, nil
+}
@@ -0,0 +1,657 @@
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+// newTestRepo initialises an empty repository in a temporary directory.
+func newTestRepo(t *testing.T) *Git {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	g := &Git{Dir: dir}
+	t.Setenv("GIT_AUTHOR_NAME", "Test Author")
+	t.Setenv("GIT_AUTHOR_EMAIL", "author@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test Author")
+	t.Setenv("GIT_COMMITTER_EMAIL", "author@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	mustRun(t, g, "init", "--quiet", "--initial-branch=main")
+	mustRun(t, g, "config", "tag.gpgSign", "false")
+	mustRun(t, g, "config", "commit.gpgSign", "false")
+	return g
+}
+
+func mustRun(t *testing.T, g *Git, args ...string) string {
+	t.Helper()
+	out, err := g.run(context.Background(), args...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return out
+}
+
+func commit(t *testing.T, g *Git, message string) {
+	t.Helper()
+	mustRun(t, g, "commit", "--quiet", "--allow-empty", "--message", message)
+}
+
+func TestOpen(t *testing.T) {
+	g := newTestRepo(t)
+
+	opened, err := Open(context.Background(), g.Dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened.Dir == "" {
+		t.Errorf("Expected repository root to be resolved")
+	}
+
+	if _, err := Open(context.Background(), t.TempDir()); err == nil {
+		t.Errorf("Expected error opening a non-repository")
+	}
+}
+
+func TestLogger(t *testing.T) {
+	g := newTestRepo(t)
+	var buf bytes.Buffer
+	g.Logger = log.New(&buf, log.Options{Level: slog.LevelDebug})
+
+	if _, err := g.Head(context.Background()); err == nil {
+		t.Fatalf("Expected error for a repository without commits")
+	}
+	expected := "debug: git args=\"rev-parse HEAD\" dir=" + g.Dir + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestCancelled(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := g.CommitsSince(ctx, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if err := g.VerifyTag(ctx, "v1.0.0", Signing{}); !errors.Is(err, context.Canceled) || errors.Is(err, ErrBadSignature) {
+		t.Errorf("Expected context.Canceled rather than a bad signature, got %v", err)
+	}
+}
+
+func TestPushRetries(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	var delays int
+	g.Retry = retry.Policy{MaxAttempts: 3, OnRetry: func(int, error, time.Duration) { delays++ }}
+
+	// A missing remote is a permanent failure: it is not retried.
+	if err := g.Push(context.Background(), filepath.Join(t.TempDir(), "missing"), "main"); err == nil {
+		t.Fatalf("Expected push to a missing remote to fail")
+	}
+	if delays != 0 {
+		t.Errorf("Expected no retries, got %d", delays)
+	}
+
+	for msg, expected := range map[string]bool{
+		"git push: exit status 128: fatal: unable to access 'https://x/': Could not resolve host: x":         true,
+		"git push: exit status 1: error: RPC failed; HTTP 502 curl 22 The requested URL returned error: 502": true,
+		"git push: exit status 1: ! [rejected] v1.0.0 -> v1.0.0 (already exists)":                            false,
+	} {
+		if got := transient(errors.New(msg)); got != expected {
+			t.Errorf("transient(%q): Expected %v, got %v", msg, expected, got)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	first, _ := g.Head(context.Background())
+	mustRun(t, g, "tag", "v0.1.0")
+	commit(t, g, "fix: second")
+	second, _ := g.Head(context.Background())
+	if err := g.CreateTag(context.Background(), "v0.1.1", "chore(release): v0.1.1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tags, err := g.Tags(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Tag{
+		{Name: "v0.1.0", Commit: first, Annotated: false},
+		{Name: "v0.1.1", Commit: second, Annotated: true},
+	}
+	if len(tags) != len(expected) {
+		t.Fatalf("Expected %d tags, got %#v", len(expected), tags)
+	}
+	for i := range expected {
+		if tags[i] != expected[i] {
+			t.Errorf("Expected %#v, got %#v", expected[i], tags[i])
+		}
+	}
+}
+
+func TestRemoteTags(t *testing.T) {
+	upstream := newTestRepo(t)
+	commit(t, upstream, "feat: first")
+	first, _ := upstream.Head(context.Background())
+	mustRun(t, upstream, "tag", "v0.1.0")
+	commit(t, upstream, "fix: second")
+	second, _ := upstream.Head(context.Background())
+	mustRun(t, upstream, "tag", "--annotate", "v0.1.1", "--message", "v0.1.1")
+	clone := &Git{Dir: t.TempDir()}
+	mustRun(t, clone, "clone", "--quiet", "--no-tags", upstream.Dir, ".")
+
+	tags, err := clone.RemoteTags(context.Background(), "origin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Tag{
+		{Name: "v0.1.0", Commit: first, Annotated: false},
+		{Name: "v0.1.1", Commit: second, Annotated: true},
+	}
+	if !slices.Equal(tags, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, tags)
+	}
+	if local, _ := clone.Tags(context.Background()); len(local) != 0 {
+		t.Errorf("Expected no tags fetched, got %v", local)
+	}
+	if _, err := clone.RemoteTags(context.Background(), "missing"); err == nil {
+		t.Error("Expected an error for a missing remote")
+	}
+}
+
+func TestCreateTagExists(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	mustRun(t, g, "tag", "v0.1.0")
+
+	err := g.CreateTag(context.Background(), "v0.1.0", "chore(release): v0.1.0")
+	if !errors.Is(err, ErrTagExists) || !errors.Is(err, relerr.ErrTagExists) {
+		t.Errorf("Expected ErrTagExists, got %v", err)
+	}
+	if relerr.KindOf(err) != relerr.Conflict {
+		t.Errorf("Expected kind %v, got %v", relerr.Conflict, relerr.KindOf(err))
+	}
+}
+
+func TestCommitsSince(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	mustRun(t, g, "tag", "v0.1.0")
+	commit(t, g, "fix: second\n\nwith a body")
+	commit(t, g, "docs: third")
+
+	all, err := g.CommitsSince(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 commits, got %d", len(all))
+	}
+
+	since, err := g.CommitsSince(context.Background(), "v0.1.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(since) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(since))
+	}
+
+	if since[0].Message != "docs: third" {
+		t.Errorf("Expected newest commit first, got %q", since[0].Message)
+	}
+	if since[1].Message != "fix: second\n\nwith a body" {
+		t.Errorf("Expected full message, got %q", since[1].Message)
+	}
+	if since[1].AuthorName != "Test Author" || since[1].AuthorEmail != "author@example.com" {
+		t.Errorf("Expected author to be read, got %q <%s>", since[1].AuthorName, since[1].AuthorEmail)
+	}
+	if len(since[0].Parents) != 1 || since[0].Parents[0] != since[1].Hash {
+		t.Errorf("Expected parent %s, got %v", since[1].Hash, since[0].Parents)
+	}
+	if since[0].Date.IsZero() {
+		t.Errorf("Expected commit date to be parsed")
+	}
+}
+
+func TestCommitsSinceMaxCommits(t *testing.T) {
+	g := newTestRepo(t)
+	for i := range 5 {
+		commit(t, g, fmt.Sprintf("fix: change %d", i))
+	}
+	var logs bytes.Buffer
+	g.Logger = log.New(&logs, log.Options{})
+	g.MaxCommits = 3
+
+	cs, err := g.CommitsSince(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cs) != 3 || cs[0].Message != "fix: change 4" {
+		t.Errorf("Expected the latest 3 commits, got %d", len(cs))
+	}
+	if !strings.Contains(logs.String(), "read only the latest 3 commits of the history") {
+		t.Errorf("Expected a warning, got %q", logs.String())
+	}
+}
+
+func TestCommits(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	mustRun(t, g, "tag", "v0.1.0")
+	commit(t, g, "fix: second")
+	mustRun(t, g, "tag", "v0.1.1")
+	commit(t, g, "docs: third")
+
+	cs, err := g.Commits(context.Background(), "v0.1.0..v0.1.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cs) != 1 || cs[0].Message != "fix: second" {
+		t.Errorf("Expected only the second commit, got %#v", cs)
+	}
+	if _, err := g.Commits(context.Background(), "nope..HEAD"); err == nil {
+		t.Errorf("Expected an error for an unknown revision")
+	}
+}
+
+func TestCommitsSincePaths(t *testing.T) {
+	g := newTestRepo(t)
+	write := func(path string) {
+		t.Helper()
+		full := filepath.Join(g.Dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(path), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		mustRun(t, g, "add", path)
+	}
+
+	write("README.md")
+	commit(t, g, "docs: root")
+	write("pkg/foo/foo.go")
+	commit(t, g, "feat(foo): add foo")
+	write("pkg/bar/bar.go")
+	commit(t, g, "fix(bar): fix bar")
+
+	foo, err := g.CommitsSince(context.Background(), "", "pkg/foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(foo) != 1 || foo[0].Message != "feat(foo): add foo" {
+		t.Errorf("Expected only the foo commit, got %#v", foo)
+	}
+
+	root, err := g.CommitsSince(context.Background(), "", ".", ":(exclude)pkg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(root) != 1 || root[0].Message != "docs: root" {
+		t.Errorf("Expected only the root commit, got %#v", root)
+	}
+}
+
+func TestCommitsSinceTraversal(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	mustRun(t, g, "checkout", "--quiet", "-b", "topic")
+	commit(t, g, "wip")
+	commit(t, g, "fix typo")
+	mustRun(t, g, "checkout", "--quiet", "main")
+	commit(t, g, "fix: on main")
+	mustRun(t, g, "merge", "--quiet", "--no-ff", "topic", "--message", "Merge pull request #1 from octo/topic\n\nfeat: topic")
+
+	tests := map[Traversal][]string{
+		"":                  {"Merge pull request #1 from octo/topic\n\nfeat: topic", "fix: on main", "fix typo", "wip", "feat: first"},
+		TraverseAll:         {"Merge pull request #1 from octo/topic\n\nfeat: topic", "fix: on main", "fix typo", "wip", "feat: first"},
+		TraverseNoMerges:    {"fix: on main", "fix typo", "wip", "feat: first"},
+		TraverseFirstParent: {"Merge pull request #1 from octo/topic\n\nfeat: topic", "fix: on main", "feat: first"},
+	}
+	for traversal, expected := range tests {
+		g.Traversal = traversal
+		commits, err := g.CommitsSince(context.Background(), "")
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", traversal, err)
+		}
+		var got []string
+		for _, c := range commits {
+			got = append(got, c.Message)
+		}
+		// Commits made within a second have no defined order.
+		slices.Sort(got)
+		if expected = slices.Sorted(slices.Values(expected)); !slices.Equal(got, expected) {
+			t.Errorf("%q: Expected %q, got %q", traversal, expected, got)
+		}
+	}
+
+	g.Traversal = "sideways"
+	if _, err := g.CommitsSince(context.Background(), ""); err == nil {
+		t.Errorf("Expected an error for an unknown traversal")
+	}
+}
+
+func TestCommitFiles(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	for _, name := range []string{"VERSION", "unrelated.txt"} {
+		if err := os.WriteFile(filepath.Join(g.Dir, name), []byte("1.1.0\n"), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := g.CommitFiles(context.Background(), "chore(release): v1.1.0", "VERSION"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commits, err := g.CommitsSince(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Message != "chore(release): v1.1.0" {
+		t.Errorf("Expected a release commit, got %#v", commits)
+	}
+	if status := mustRun(t, g, "status", "--porcelain"); status != "?? unrelated.txt\n" {
+		t.Errorf("Expected only unrelated.txt to be left, got %q", status)
+	}
+	if err := g.CommitFiles(context.Background(), "empty"); err == nil {
+		t.Errorf("Expected an error without paths")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	g := newTestRepo(t)
+	os.WriteFile(filepath.Join(g.Dir, "a.txt"), []byte("a"), 0o644)
+	mustRun(t, g, "add", "a.txt")
+	commit(t, g, "feat: first")
+
+	if paths, err := g.Status(context.Background()); err != nil || len(paths) != 0 {
+		t.Errorf("Expected a clean tree, got %v %v", paths, err)
+	}
+
+	os.WriteFile(filepath.Join(g.Dir, "a.txt"), []byte("changed"), 0o644)
+	os.WriteFile(filepath.Join(g.Dir, "untracked.txt"), []byte("x"), 0o644)
+	paths, err := g.Status(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "a.txt" {
+		t.Errorf("Expected only a.txt, got %q", paths)
+	}
+}
+
+func TestBehind(t *testing.T) {
+	upstream := newTestRepo(t)
+	commit(t, upstream, "feat: first")
+	clone := &Git{Dir: t.TempDir()}
+	mustRun(t, clone, "clone", "--quiet", upstream.Dir, ".")
+	commit(t, upstream, "fix: second")
+	commit(t, upstream, "fix: third")
+
+	n, err := clone.Behind(context.Background(), "origin", "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 commits behind, got %d", n)
+	}
+
+	mustRun(t, clone, "pull", "--quiet", "origin", "main")
+	if n, err := clone.Behind(context.Background(), "origin", "main"); err != nil || n != 0 {
+		t.Errorf("Expected to be up to date, got %d %v", n, err)
+	}
+}
+
+func TestPull(t *testing.T) {
+	upstream := newTestRepo(t)
+	commit(t, upstream, "feat: first")
+	clone := &Git{Dir: t.TempDir()}
+	mustRun(t, clone, "clone", "--quiet", upstream.Dir, ".")
+	commit(t, upstream, "fix: second")
+	mustRun(t, upstream, "tag", "v0.1.1")
+
+	if err := clone.Pull(context.Background(), "origin", "main"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	head, _ := clone.Head(context.Background())
+	expected, _ := upstream.Head(context.Background())
+	if head != expected {
+		t.Errorf("Expected HEAD at %s, got %s", expected, head)
+	}
+	tags, _ := clone.Tags(context.Background())
+	if len(tags) != 1 || tags[0].Name != "v0.1.1" {
+		t.Errorf("Expected the tags to be fetched, got %v", tags)
+	}
+
+	commit(t, clone, "fix: local")
+	commit(t, upstream, "fix: remote")
+	if err := clone.Pull(context.Background(), "origin", "main"); err == nil {
+		t.Error("Expected an error pulling a diverged branch")
+	}
+}
+
+func TestDeleteTag(t *testing.T) {
+	upstream := newTestRepo(t)
+	commit(t, upstream, "feat: first")
+	mustRun(t, upstream, "tag", "v0.1.0")
+	clone := &Git{Dir: t.TempDir()}
+	mustRun(t, clone, "clone", "--quiet", upstream.Dir, ".")
+
+	if err := clone.DeleteRemoteTag(context.Background(), "origin", "v0.1.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tags, _ := upstream.Tags(context.Background()); len(tags) != 0 {
+		t.Errorf("Expected the remote tag to be deleted, got %v", tags)
+	}
+	if err := clone.DeleteTag(context.Background(), "v0.1.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tags, _ := clone.Tags(context.Background()); len(tags) != 0 {
+		t.Errorf("Expected the local tag to be deleted, got %v", tags)
+	}
+	if err := clone.DeleteTag(context.Background(), "v0.1.0"); !errors.Is(err, ErrNoSuchTag) {
+		t.Errorf("Expected ErrNoSuchTag deleting a missing tag, got %v", err)
+	}
+	if err := clone.DeleteRemoteTag(context.Background(), "origin", "v0.1.0"); !errors.Is(err, ErrNoSuchTag) {
+		t.Errorf("Expected ErrNoSuchTag deleting a missing remote tag, got %v", err)
+	}
+}
+
+func TestRevert(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	if err := os.WriteFile(filepath.Join(g.Dir, "VERSION"), []byte("1.1.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g.CommitFiles(context.Background(), "chore(release): v1.1.0", "VERSION")
+
+	if err := g.Revert(context.Background(), "HEAD"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commits, _ := g.CommitsSince(context.Background(), "")
+	if len(commits) != 3 || !strings.HasPrefix(commits[0].Message, `Revert "chore(release): v1.1.0"`) {
+		t.Errorf("Expected a revert commit, got %#v", commits)
+	}
+	if _, err := os.Stat(filepath.Join(g.Dir, "VERSION")); !os.IsNotExist(err) {
+		t.Errorf("Expected VERSION to be removed, got %v", err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	if err := os.WriteFile(filepath.Join(g.Dir, "go.mod"), []byte("module example.com/app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g.CommitFiles(context.Background(), "chore: add go.mod", "go.mod")
+	os.WriteFile(filepath.Join(g.Dir, "go.mod"), []byte("changed\n"), 0o644)
+
+	got, err := g.ReadFile(context.Background(), "HEAD", "go.mod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != "module example.com/app\n" {
+		t.Errorf("Expected the committed go.mod, got %q", got)
+	}
+	if _, err := g.ReadFile(context.Background(), "HEAD~1", "go.mod"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist before go.mod was added, got %v", err)
+	}
+	if _, err := g.ReadFile(context.Background(), "HEAD", "missing/go.mod"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for a missing file, got %v", err)
+	}
+}
+
+func TestNotes(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	head, _ := g.Head(context.Background())
+
+	if note, err := g.Note(context.Background(), "refs/notes/release", head); err != nil || note != nil {
+		t.Fatalf("Expected no note yet, got %q and %v", note, err)
+	}
+	for _, note := range []string{"{\n  \"tag\": \"v1.0.0\"\n}\n", "{\n  \"tag\": \"v1.0.1\"\n}\n"} {
+		if err := g.SetNote(context.Background(), "refs/notes/release", head, []byte(note)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := g.Note(context.Background(), "refs/notes/release", head)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(got) != note {
+			t.Errorf("Expected %q, got %q", note, got)
+		}
+	}
+	if note, _ := g.Note(context.Background(), "refs/notes/commits", head); note != nil {
+		t.Errorf("Expected the note in its own ref only, got %q", note)
+	}
+	if out := mustRun(t, g, "log", "-1", "--format=%s"); out != "feat: first\n" {
+		t.Errorf("Expected the commit untouched, got %q", out)
+	}
+}
+
+func TestDiffStat(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	os.WriteFile(filepath.Join(g.Dir, "a.txt"), []byte("one\ntwo\nthree\n"), 0o644)
+	os.WriteFile(filepath.Join(g.Dir, "b.bin"), []byte{0, 1, 2}, 0o644)
+	g.CommitFiles(context.Background(), "feat: add files", "a.txt", "b.bin")
+
+	s, err := g.DiffStat(context.Background(), "HEAD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := (DiffStat{Files: 2, Insertions: 3}); s != expected {
+		t.Errorf("Expected %+v, got %+v", expected, s)
+	}
+
+	os.WriteFile(filepath.Join(g.Dir, "a.txt"), []byte("one\n2\n"), 0o644)
+	g.CommitFiles(context.Background(), "fix: shorten", "a.txt")
+	s, _ = g.DiffStat(context.Background(), "HEAD")
+	if s.Lines() != 3 || s.Insertions != 1 || s.Deletions != 2 {
+		t.Errorf("Expected 1 insertion and 2 deletions, got %+v", s)
+	}
+}
+
+func TestCurrentBranchAndHead(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+
+	branch, err := g.CurrentBranch(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if branch != "main" {
+		t.Errorf("Expected %q, got %q", "main", branch)
+	}
+
+	head, err := g.Head(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mustRun(t, g, "checkout", "--quiet", "--detach", head)
+
+	if _, err := g.CurrentBranch(context.Background()); !errors.Is(err, ErrDetachedHead) {
+		t.Errorf("Expected ErrDetachedHead, got %v", err)
+	}
+}
+
+func TestWorktree(t *testing.T) {
+	g := newTestRepo(t)
+	commit(t, g, "feat: first")
+	mustRun(t, g, "tag", "v0.1.0")
+	commit(t, g, "fix: second")
+	remote := &Git{Dir: t.TempDir()}
+	mustRun(t, remote, "init", "--quiet", "--bare")
+	mustRun(t, g, "remote", "add", "origin", remote.Dir)
+
+	// A linked worktree on a detached HEAD, as CI checkouts are.
+	dir := filepath.Join(t.TempDir(), "wt")
+	mustRun(t, g, "worktree", "add", "--quiet", "--detach", dir, "HEAD")
+	wt, err := Open(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved, _ := filepath.EvalSymlinks(dir); wt.Dir != resolved && wt.Dir != dir {
+		t.Errorf("Expected the worktree root %s, got %s", dir, wt.Dir)
+	}
+	if _, err := wt.CurrentBranch(context.Background()); !errors.Is(err, ErrDetachedHead) {
+		t.Errorf("Expected ErrDetachedHead, got %v", err)
+	}
+	tags, err := wt.Tags(context.Background())
+	if err != nil || len(tags) != 1 || tags[0].Name != "v0.1.0" {
+		t.Errorf("Expected the tags of the repository, got %v %v", tags, err)
+	}
+	since, err := wt.CommitsSince(context.Background(), "v0.1.0")
+	if err != nil || len(since) != 1 {
+		t.Fatalf("Expected 1 commit since v0.1.0, got %v %v", since, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "CHANGELOG.md"), []byte("# Changelog\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := wt.CommitFiles(context.Background(), "chore(release): changelog for v0.1.1", "CHANGELOG.md"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := wt.CreateTag(context.Background(), "v0.1.1", "chore(release): v0.1.1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := wt.Push(context.Background(), "origin", "HEAD:refs/heads/main"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	head, _ := wt.Head(context.Background())
+	if pushed := strings.TrimSpace(mustRun(t, remote, "rev-parse", "refs/heads/main")); pushed != head {
+		t.Errorf("Expected main at %s on the remote, got %s", head, pushed)
+	}
+	// The tag is shared with the main worktree.
+	if tags, _ := g.Tags(context.Background()); len(tags) != 2 {
+		t.Errorf("Expected the tag visible from the main worktree, got %v", tags)
+	}
+	if n, err := wt.Behind(context.Background(), "origin", "main"); err != nil || n != 0 {
+		t.Errorf("Expected the detached worktree up to date with origin/main, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,113 @@
+// Package gitrepo provides the git primitives used by the release tooling:
+// listing tags, walking commits, reading HEAD, committing release changes
+// and creating (optionally signed) tags.
+//
+// Repository is the interface the rest of the module depends on; Git is the
+// production implementation, which shells out to the git binary in the same
+// way as the scripts in scripts/.
+package gitrepo
+
+import (
+	"context"
+	"errors"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitobj"
+)
+
+// ErrDetachedHead is returned by CurrentBranch when HEAD is not on a branch.
+var ErrDetachedHead = relerr.ErrDetachedHead
+
+// ErrTagExists is returned by CreateTag and CreateSignedTag when the tag
+// already exists.
+var ErrTagExists = relerr.ErrTagExists
+
+// ErrTagMoved and ErrLightweightTag are returned by CheckTag.
+var (
+	ErrTagMoved       = relerr.ErrTagMoved
+	ErrLightweightTag = relerr.ErrLightweightTag
+)
+
+// ErrNoSuchTag is returned by DeleteTag and DeleteRemoteTag when the tag
+// does not exist.
+var ErrNoSuchTag = errors.New("no such tag")
+
+// Tag and Commit are the objects the history is read into; see gitobj.
+type (
+	Tag    = gitobj.Tag
+	Commit = gitobj.Commit
+)
+
+// Repository is the set of git operations needed to drive a release. Every
+// method stops the underlying git process when ctx is cancelled.
+type Repository interface {
+	// Tags lists all tags in the repository.
+	Tags(ctx context.Context) ([]Tag, error)
+	// RemoteTags lists the tags of remote without fetching them.
+	RemoteTags(ctx context.Context, remote string) ([]Tag, error)
+	// CommitsSince returns the commits reachable from HEAD but not from ref,
+	// newest first. An empty ref returns the full history. When paths are
+	// given, only commits touching them are returned; paths are git
+	// pathspecs, so ":(exclude)dir" removes a subtree. Git walks merges
+	// according to its Traversal.
+	CommitsSince(ctx context.Context, ref string, paths ...string) ([]Commit, error)
+	// Commits returns the commits of a revision range such as
+	// "v1.2.0..HEAD" or "origin/main..feature", newest first, walked
+	// according to the Traversal.
+	Commits(ctx context.Context, revs string) ([]Commit, error)
+	// CurrentBranch returns the short name of the checked out branch.
+	CurrentBranch(ctx context.Context) (string, error)
+	// Head returns the hash of the commit at HEAD.
+	Head(ctx context.Context) (string, error)
+	// Status returns the tracked paths with uncommitted changes, staged or
+	// not. Untracked files are ignored.
+	Status(ctx context.Context) ([]string, error)
+	// Behind fetches branch from remote and returns the number of its
+	// commits missing from HEAD.
+	Behind(ctx context.Context, remote, branch string) (int, error)
+	// CreateTag creates an annotated tag at HEAD.
+	CreateTag(ctx context.Context, name, message string) error
+	// CreateSignedTag creates a signed annotated tag at HEAD.
+	CreateSignedTag(ctx context.Context, name, message string, s Signing) error
+	// VerifyTag checks the signature of tag name, returning an error
+	// wrapping ErrBadSignature when it does not verify.
+	VerifyTag(ctx context.Context, name string, s Signing) error
+	// CommitFiles stages paths and commits them, and only them, with
+	// message.
+	CommitFiles(ctx context.Context, message string, paths ...string) error
+	// Push pushes ref to remote.
+	Push(ctx context.Context, remote, ref string) error
+	// Pull fetches branch and the tags from remote and fast-forwards HEAD
+	// to the branch, failing when HEAD has diverged from it.
+	Pull(ctx context.Context, remote, branch string) error
+	// DeleteTag deletes the local tag name.
+	DeleteTag(ctx context.Context, name string) error
+	// DeleteRemoteTag deletes tag name from remote.
+	DeleteRemoteTag(ctx context.Context, remote, name string) error
+	// Revert commits the reversal of commit on top of HEAD.
+	Revert(ctx context.Context, commit string) error
+	// ReadFile returns the content of the slash-separated path, relative to
+	// the repository root, at revision rev. The error wraps fs.ErrNotExist
+	// when rev has no such file.
+	ReadFile(ctx context.Context, rev, path string) ([]byte, error)
+	// DiffStat returns the size of the change commit makes to its first
+	// parent, which for a merge commit is the branch it merged.
+	DiffStat(ctx context.Context, commit string) (DiffStat, error)
+	// Note returns the git note of commit in the notes ref, such as
+	// "refs/notes/release", or nil when it has none.
+	Note(ctx context.Context, ref, commit string) ([]byte, error)
+	// SetNote replaces the git note of commit in the notes ref with note.
+	SetNote(ctx context.Context, ref, commit string, note []byte) error
+}
+
+// DiffStat is the size of a change. Binary files count towards Files only.
+type DiffStat struct {
+	Files      int
+	Insertions int
+	Deletions  int
+}
+
+// Lines returns the lines inserted and deleted.
+func (s DiffStat) Lines() int {
+	return s.Insertions + s.Deletions
+}
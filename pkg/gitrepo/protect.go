@@ -0,0 +1,48 @@
+package gitrepo
+
+import "fmt"
+
+// CheckTag reports whether the annotated tag name can be created at commit
+// without clobbering a tag of the same name among local, the tags of the
+// repository, or remote, those of the remote called remoteName. It fails
+// with ErrTagMoved when such a tag points at another commit, with
+// ErrLightweightTag when it is lightweight, and with ErrTagExists when it
+// is already the tag to create. An empty commit stands for a commit yet to
+// be made, which every existing tag would move from.
+func CheckTag(name, commit string, local, remote []Tag, remoteName string) error {
+	found := false
+	check := func(tags []Tag, desc string) error {
+		for _, t := range tags {
+			if t.Name != name {
+				continue
+			}
+			found = true
+			switch {
+			case t.Commit != commit && commit == "":
+				return fmt.Errorf("%w: %s points at %s; tagging a new commit would move it", ErrTagMoved, desc, shortHash(t.Commit))
+			case t.Commit != commit:
+				return fmt.Errorf("%w: %s points at %s, not %s", ErrTagMoved, desc, shortHash(t.Commit), shortHash(commit))
+			case !t.Annotated:
+				return fmt.Errorf("%w: %s is lightweight; releases are annotated tags", ErrLightweightTag, desc)
+			}
+		}
+		return nil
+	}
+	if err := check(local, "the local tag "+name); err != nil {
+		return err
+	}
+	if err := check(remote, "tag "+name+" on "+remoteName); err != nil {
+		return err
+	}
+	if found {
+		return fmt.Errorf("%w: %s, at %s", ErrTagExists, name, shortHash(commit))
+	}
+	return nil
+}
+
+func shortHash(h string) string {
+	if len(h) > 7 {
+		return h[:7]
+	}
+	return h
+}
@@ -0,0 +1,74 @@
+package gitrepo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+)
+
+func TestCheckTag(t *testing.T) {
+	const head = "1111111111111111111111111111111111111111"
+	const other = "2222222222222222222222222222222222222222"
+	tests := []struct {
+		name          string
+		commit        string
+		local, remote []Tag
+		err           error
+		message       string
+	}{
+		{name: "new", commit: head, local: []Tag{{Name: "v1.2.0", Commit: other, Annotated: true}}},
+		{
+			name:    "moved locally",
+			commit:  head,
+			local:   []Tag{{Name: "v1.3.0", Commit: other, Annotated: true}},
+			err:     ErrTagMoved,
+			message: "the local tag v1.3.0 points at 2222222, not 1111111",
+		},
+		{
+			name:    "moved on the remote",
+			commit:  head,
+			local:   []Tag{{Name: "v1.3.0", Commit: head, Annotated: true}},
+			remote:  []Tag{{Name: "v1.3.0", Commit: other, Annotated: true}},
+			err:     ErrTagMoved,
+			message: "tag v1.3.0 on origin points at 2222222, not 1111111",
+		},
+		{
+			name:    "new commit",
+			remote:  []Tag{{Name: "v1.3.0", Commit: head, Annotated: true}},
+			err:     ErrTagMoved,
+			message: "tag v1.3.0 on origin points at 1111111; tagging a new commit would move it",
+		},
+		{
+			name:    "lightweight on the remote",
+			commit:  head,
+			local:   []Tag{{Name: "v1.3.0", Commit: head, Annotated: true}},
+			remote:  []Tag{{Name: "v1.3.0", Commit: head}},
+			err:     ErrLightweightTag,
+			message: "tag v1.3.0 on origin is lightweight",
+		},
+		{
+			name:    "already tagged",
+			commit:  head,
+			remote:  []Tag{{Name: "v1.3.0", Commit: head, Annotated: true}},
+			err:     ErrTagExists,
+			message: "v1.3.0, at 1111111",
+		},
+	}
+	for _, tt := range tests {
+		err := CheckTag("v1.3.0", tt.commit, tt.local, tt.remote, "origin")
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.err) || relerr.KindOf(err) != relerr.Conflict {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.err, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: Expected %q in %q", tt.name, tt.message, err)
+		}
+	}
+}
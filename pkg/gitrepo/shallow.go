@@ -0,0 +1,98 @@
+package gitrepo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+// IsShallow reports whether the repository is a shallow clone, whose
+// history stops short of its first commit.
+func (g *Git) IsShallow(ctx context.Context) (bool, error) {
+	out, err := g.run(ctx, "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "true", nil
+}
+
+func (g *Git) remote() string {
+	if g.Remote != "" {
+		return g.Remote
+	}
+	return "origin"
+}
+
+// fetchShallowTags fetches the tags of Remote into a shallow clone, once,
+// each with its commit alone, so that a clone made without them, as CI
+// checkouts are, still tells the latest release. It does nothing unless
+// Deepen is set.
+func (g *Git) fetchShallowTags(ctx context.Context) error {
+	if g.Deepen <= 0 || g.tagsFetched {
+		return nil
+	}
+	shallow, err := g.IsShallow(ctx)
+	if err != nil || !shallow {
+		g.tagsFetched = err == nil
+		return err
+	}
+	if err := g.fetch(ctx, "--depth=1", g.remote(), "+refs/tags/*:refs/tags/*"); err != nil {
+		return fmt.Errorf("gitrepo: fetch the tags of the shallow clone: %w", err)
+	}
+	g.tagsFetched = true
+	return nil
+}
+
+// deepenTo fetches Deepen more commits of history at a time into a shallow
+// clone until ref is an ancestor of HEAD, so that ref..HEAD holds every
+// commit since ref rather than those the clone happens to have. An empty
+// ref asks for the whole history: the clone is deepened to MaxCommits
+// commits, or else made complete. Deepening stops once the clone is
+// complete. It does nothing unless Deepen is set.
+func (g *Git) deepenTo(ctx context.Context, ref string) error {
+	if g.Deepen <= 0 {
+		return nil
+	}
+	for {
+		shallow, err := g.IsShallow(ctx)
+		if err != nil || !shallow {
+			return err
+		}
+		switch {
+		case ref != "":
+			if _, err := g.run(ctx, "merge-base", "--is-ancestor", ref, "HEAD"); err == nil {
+				return nil
+			}
+		case g.MaxCommits > 0:
+			out, err := g.run(ctx, "rev-list", "--count", "HEAD")
+			if err != nil {
+				return err
+			}
+			if n, _ := strconv.Atoi(strings.TrimSpace(out)); n >= g.MaxCommits {
+				return nil
+			}
+		default:
+			if err := g.fetch(ctx, "--unshallow", g.remote()); err != nil {
+				return fmt.Errorf("gitrepo: complete the shallow clone: %w", err)
+			}
+			return nil
+		}
+		if err := g.fetch(ctx, "--deepen="+strconv.Itoa(g.Deepen), g.remote()); err != nil {
+			return fmt.Errorf("gitrepo: deepen the shallow clone: %w", err)
+		}
+	}
+}
+
+// fetch runs git fetch with args, retrying network errors.
+func (g *Git) fetch(ctx context.Context, args ...string) error {
+	return g.Retry.Do(ctx, func(ctx context.Context) error {
+		_, err := g.run(ctx, append([]string{"fetch", "--quiet"}, args...)...)
+		if err != nil && transient(err) {
+			return retry.Retryable(err)
+		}
+		return err
+	})
+}
@@ -0,0 +1,67 @@
+package gitrepo
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// newShallowClone clones origin with only its latest commit, as CI
+// checkouts do, through file:// for git to honour the depth.
+func newShallowClone(t *testing.T, origin *Git) *Git {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "clone")
+	mustRun(t, origin, "clone", "--quiet", "--depth=1", "--no-tags", "file://"+origin.Dir, dir)
+	return &Git{Dir: dir}
+}
+
+func TestDeepen(t *testing.T) {
+	origin := newTestRepo(t)
+	for i := 1; i <= 30; i++ {
+		commit(t, origin, fmt.Sprintf("fix: change %d", i))
+		if i == 10 {
+			mustRun(t, origin, "tag", "--annotate", "v1.0.0", "--message", "v1.0.0")
+		}
+	}
+	ctx := context.Background()
+
+	g := newShallowClone(t, origin)
+	if shallow, err := g.IsShallow(ctx); err != nil || !shallow {
+		t.Fatalf("Expected a shallow clone, got %v, %v", shallow, err)
+	}
+	if tags, err := g.Tags(ctx); err != nil || len(tags) != 0 {
+		t.Errorf("Expected no tags without Deepen, got %v, %v", tags, err)
+	}
+
+	g.Deepen = 4
+	tags, err := g.Tags(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 1 || tags[0].Name != "v1.0.0" {
+		t.Fatalf("Expected the tags to be fetched, got %v", tags)
+	}
+	cs, err := g.CommitsSince(ctx, "v1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cs) != 20 || cs[19].Message != "fix: change 11" {
+		t.Errorf("Expected the 20 commits since v1.0.0, got %d", len(cs))
+	}
+	if shallow, _ := g.IsShallow(ctx); !shallow {
+		t.Errorf("Expected the clone deepened only as needed")
+	}
+
+	g.MaxCommits = 25
+	if cs, err := g.CommitsSince(ctx, ""); err != nil || len(cs) != 25 {
+		t.Errorf("Expected 25 commits, got %d, %v", len(cs), err)
+	}
+	g.MaxCommits = 0
+	if cs, err := g.CommitsSince(ctx, ""); err != nil || len(cs) != 30 {
+		t.Errorf("Expected the whole history, got %d, %v", len(cs), err)
+	}
+	if shallow, _ := g.IsShallow(ctx); shallow {
+		t.Errorf("Expected the clone completed")
+	}
+}
@@ -0,0 +1,56 @@
+package gitrepo
+
+import (
+	"context"
+	"fmt"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+)
+
+// ErrBadSignature is returned by VerifyTag when a tag is unsigned, or its
+// signature is invalid or made by an untrusted key.
+var ErrBadSignature = relerr.ErrBadSignature
+
+// Signing selects how tags are signed and verified. The zero value uses
+// git's own configuration (gpg.format and user.signingkey).
+type Signing struct {
+	// Format is git's gpg.format: "openpgp", "ssh" or "x509".
+	Format string
+	// Key is passed to git tag --local-user: a GPG key ID, or for SSH the
+	// path to a key file.
+	Key string
+	// AllowedSigners is the SSH allowed signers file consulted when
+	// verifying (gpg.ssh.allowedSignersFile).
+	AllowedSigners string
+}
+
+// config returns the "-c" options applying s to a git invocation.
+func (s Signing) config() []string {
+	var args []string
+	if s.Format != "" {
+		args = append(args, "-c", "gpg.format="+s.Format)
+	}
+	if s.AllowedSigners != "" {
+		args = append(args, "-c", "gpg.ssh.allowedSignersFile="+s.AllowedSigners)
+	}
+	return args
+}
+
+func (g *Git) CreateSignedTag(ctx context.Context, name, message string, s Signing) error {
+	args := append(s.config(), "tag", "--sign")
+	if s.Key != "" {
+		args = append(args, "--local-user", s.Key)
+	}
+	_, err := g.run(ctx, append(args, name, "--message", message)...)
+	return tagError(name, err)
+}
+
+func (g *Git) VerifyTag(ctx context.Context, name string, s Signing) error {
+	if _, err := g.run(ctx, append(s.config(), "verify-tag", name)...); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s: %v", ErrBadSignature, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package gitrepo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newSSHSigning generates an SSH key trusted for the test author.
+func newSSHSigning(t *testing.T) Signing {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not installed")
+	}
+
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ed25519")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v: %s", err, out)
+	}
+	pub, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := filepath.Join(dir, "allowed_signers")
+	if err := os.WriteFile(signers, append([]byte("author@example.com "), pub...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return Signing{Format: "ssh", Key: key, AllowedSigners: signers}
+}
+
+func TestSignedTag(t *testing.T) {
+	g := newTestRepo(t)
+	s := newSSHSigning(t)
+	commit(t, g, "feat: first")
+
+	if err := g.CreateSignedTag(context.Background(), "v1.0.0", "chore(release): v1.0.0", s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := g.VerifyTag(context.Background(), "v1.0.0", s); err != nil {
+		t.Errorf("Expected the signature to verify, got %v", err)
+	}
+
+	tags, err := g.Tags(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 1 || !tags[0].Annotated {
+		t.Errorf("Expected one annotated tag, got %#v", tags)
+	}
+}
+
+func TestVerifyTagRejects(t *testing.T) {
+	g := newTestRepo(t)
+	s := newSSHSigning(t)
+	commit(t, g, "feat: first")
+	mustRun(t, g, "tag", "--annotate", "v1.0.0", "--message", "unsigned")
+	mustRun(t, g, "tag", "v1.0.1")
+
+	other := newSSHSigning(t)
+	if err := g.CreateSignedTag(context.Background(), "v1.0.2", "untrusted", Signing{Format: "ssh", Key: other.Key}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tag := range []string{"v1.0.0", "v1.0.1", "v1.0.2"} {
+		if err := g.VerifyTag(context.Background(), tag, s); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("VerifyTag(%s): Expected ErrBadSignature, got %v", tag, err)
+		}
+	}
+}
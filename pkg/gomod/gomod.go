@@ -0,0 +1,188 @@
+// Package gomod compares the requirements of two go.mod files, so that
+// release notes can list the dependencies a release adds, removes, upgrades
+// or downgrades, and moves requirements to new versions, so that the modules
+// of a monorepo can require the releases of each other.
+//
+// Only require directives are read: replace, exclude and retract directives
+// do not change what a module declares it depends on.
+package gomod
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// Require is a requirement of a go.mod file.
+type Require struct {
+	Path    string
+	Version string
+	// Indirect is true for requirements marked "// indirect".
+	Indirect bool
+}
+
+// Parse returns the requirements of the go.mod file data, in file order.
+func Parse(data []byte) ([]Require, error) {
+	var reqs []Require
+	block := false
+	for i, line := range strings.Split(string(data), "\n") {
+		text, ok := requirement(line, &block)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("go.mod:%d: malformed requirement %q", i+1, strings.TrimSpace(line))
+		}
+		path, err := unquote(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("go.mod:%d: %v", i+1, err)
+		}
+		_, comment, _ := strings.Cut(line, "//")
+		reqs = append(reqs, Require{Path: path, Version: fields[1], Indirect: indirect(comment)})
+	}
+	return reqs, nil
+}
+
+// SetRequire returns the go.mod file data with the requirement of path
+// moved to version, keeping the rest of the file as it is. It reports
+// whether data requires path.
+func SetRequire(data []byte, path, version string) ([]byte, bool) {
+	lines := strings.Split(string(data), "\n")
+	block, found := false, false
+	for i, line := range lines {
+		text, ok := requirement(line, &block)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(text)
+		if p, err := unquote(fields[0]); err != nil || p != path || len(fields) != 2 {
+			continue
+		}
+		code, comment, commented := strings.Cut(line, "//")
+		at := strings.LastIndex(code, fields[1])
+		lines[i] = code[:at] + version + code[at+len(fields[1]):]
+		if commented {
+			lines[i] += "//" + comment
+		}
+		found = true
+	}
+	return []byte(strings.Join(lines, "\n")), found
+}
+
+// requirement returns the requirement on line, "<path> <version>" without
+// the comment, and reports whether there is one. Block tracks whether line
+// is inside a require block.
+func requirement(line string, block *bool) (string, bool) {
+	text, _, _ := strings.Cut(line, "//")
+	text = strings.TrimSpace(text)
+	switch {
+	case *block && text == ")":
+		*block = false
+		return "", false
+	case *block:
+	case text == "require (" || text == "require(":
+		*block = true
+		return "", false
+	case strings.HasPrefix(text, "require ") || strings.HasPrefix(text, "require\t"):
+		text = strings.TrimSpace(text[len("require"):])
+	default:
+		return "", false
+	}
+	return text, text != ""
+}
+
+// indirect reports whether the comment of a requirement marks it indirect:
+// it is "indirect" or starts with "indirect;".
+func indirect(comment string) bool {
+	comment = strings.TrimSpace(comment)
+	return comment == "indirect" || strings.HasPrefix(comment, "indirect;")
+}
+
+func unquote(path string) (string, error) {
+	if strings.HasPrefix(path, `"`) || strings.HasPrefix(path, "`") {
+		return strconv.Unquote(path)
+	}
+	return path, nil
+}
+
+// Kind is the way a requirement changed.
+type Kind string
+
+const (
+	Added      Kind = "added"
+	Removed    Kind = "removed"
+	Upgraded   Kind = "upgraded"
+	Downgraded Kind = "downgraded"
+	// Changed is a change between versions that cannot be ordered.
+	Changed Kind = "changed"
+)
+
+// Change is a requirement added, removed or moved to another version.
+type Change struct {
+	Path string
+	Kind Kind
+	// Old is the version before, empty when Added; New the version after,
+	// empty when Removed.
+	Old string
+	New string
+	// Indirect is true when the requirement is indirect after the change,
+	// or was before its removal.
+	Indirect bool
+}
+
+// Diff returns the changes from the requirements old to new, ordered by
+// module path. Requirements whose version is unchanged are left out, even
+// when they became direct or indirect.
+func Diff(old, new []Require) []Change {
+	before := make(map[string]Require, len(old))
+	for _, r := range old {
+		before[r.Path] = r
+	}
+	var changes []Change
+	for _, r := range new {
+		o, ok := before[r.Path]
+		delete(before, r.Path)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: r.Path, Kind: Added, New: r.Version, Indirect: r.Indirect})
+		case o.Version != r.Version:
+			changes = append(changes, Change{Path: r.Path, Kind: compare(o.Version, r.Version), Old: o.Version, New: r.Version, Indirect: r.Indirect})
+		}
+	}
+	for _, o := range before {
+		changes = append(changes, Change{Path: o.Path, Kind: Removed, Old: o.Version, Indirect: o.Indirect})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// compare tells whether moving from version a to b is an upgrade or a
+// downgrade. Pseudo-versions order by their timestamp, as prereleases.
+func compare(a, b string) Kind {
+	va, errA := version.Parse(a)
+	vb, errB := version.Parse(b)
+	switch {
+	case errA != nil || errB != nil:
+		return Changed
+	case vb.GreaterThan(va):
+		return Upgraded
+	case vb.LessThan(va):
+		return Downgraded
+	}
+	return Changed
+}
+
+// Direct returns the changes to direct requirements.
+func Direct(changes []Change) []Change {
+	var direct []Change
+	for _, c := range changes {
+		if !c.Indirect {
+			direct = append(direct, c)
+		}
+	}
+	return direct
+}
@@ -0,0 +1,104 @@
+package gomod
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const oldMod = `module example.com/app
+
+go 1.25
+
+require github.com/BurntSushi/toml v1.4.0
+
+require (
+	golang.org/x/sync v0.21.0
+	golang.org/x/text v0.20.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+	example.com/pseudo v0.0.0-20240101000000-abcdefabcdef
+)
+
+replace golang.org/x/sync => ../sync
+`
+
+const newMod = `module example.com/app
+
+go 1.25
+
+require (
+	"github.com/BurntSushi/toml" v1.3.0
+	golang.org/x/sync v0.22.0
+	golang.org/x/term v0.45.0
+	golang.org/x/text v0.21.0 // indirect; needed by x/term
+	example.com/pseudo v0.0.0-20250101000000-abcdefabcdef
+)
+`
+
+func TestParse(t *testing.T) {
+	got, err := Parse([]byte(oldMod))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Require{
+		{Path: "github.com/BurntSushi/toml", Version: "v1.4.0"},
+		{Path: "golang.org/x/sync", Version: "v0.21.0"},
+		{Path: "golang.org/x/text", Version: "v0.20.0", Indirect: true},
+		{Path: "gopkg.in/yaml.v3", Version: "v3.0.1"},
+		{Path: "example.com/pseudo", Version: "v0.0.0-20240101000000-abcdefabcdef"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	if _, err := Parse([]byte("require (\n\tgolang.org/x/sync\n)\n")); err == nil {
+		t.Error("Expected an error for a requirement without a version")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old, _ := Parse([]byte(oldMod))
+	new, _ := Parse([]byte(newMod))
+	got := Diff(old, new)
+	expected := []Change{
+		{Path: "example.com/pseudo", Kind: Upgraded, Old: "v0.0.0-20240101000000-abcdefabcdef", New: "v0.0.0-20250101000000-abcdefabcdef"},
+		{Path: "github.com/BurntSushi/toml", Kind: Downgraded, Old: "v1.4.0", New: "v1.3.0"},
+		{Path: "golang.org/x/sync", Kind: Upgraded, Old: "v0.21.0", New: "v0.22.0"},
+		{Path: "golang.org/x/term", Kind: Added, New: "v0.45.0"},
+		{Path: "golang.org/x/text", Kind: Upgraded, Old: "v0.20.0", New: "v0.21.0", Indirect: true},
+		{Path: "gopkg.in/yaml.v3", Kind: Removed, Old: "v3.0.1"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	if direct := Direct(got); len(direct) != 5 || direct[4].Path != "gopkg.in/yaml.v3" {
+		t.Errorf("Expected the indirect change left out, got %+v", direct)
+	}
+	if changes := Diff(old, old); changes != nil {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+}
+
+func TestSetRequire(t *testing.T) {
+	got, ok := SetRequire([]byte(oldMod), "golang.org/x/text", "v0.21.0")
+	if !ok {
+		t.Fatal("Expected the requirement to be found")
+	}
+	expected := strings.Replace(oldMod, "golang.org/x/text v0.20.0 // indirect", "golang.org/x/text v0.21.0 // indirect", 1)
+	if string(got) != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	got, _ = SetRequire([]byte(newMod), "github.com/BurntSushi/toml", "v1.5.0")
+	if !strings.Contains(string(got), "\t\"github.com/BurntSushi/toml\" v1.5.0\n") {
+		t.Errorf("Expected the quoted requirement moved, got %q", got)
+	}
+	got, _ = SetRequire([]byte(oldMod), "github.com/BurntSushi/toml", "v1.5.0")
+	if !strings.Contains(string(got), "\nrequire github.com/BurntSushi/toml v1.5.0\n") {
+		t.Errorf("Expected the single-line requirement moved, got %q", got)
+	}
+	if got, ok := SetRequire([]byte(oldMod), "example.com/missing", "v1.0.0"); ok || string(got) != oldMod {
+		t.Errorf("Expected the file unchanged, got %v and %q", ok, got)
+	}
+}
@@ -0,0 +1,182 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// waitDelay bounds how long a cancelled command's output may be drained,
+// since children of the shell can hold its pipes open after it is killed.
+const waitDelay = time.Second
+
+// Shell runs the command lines of hooks.
+type Shell string
+
+const (
+	ShellSh         Shell = "sh"
+	ShellBash       Shell = "bash"
+	ShellCmd        Shell = "cmd"
+	ShellPowerShell Shell = "powershell"
+	ShellPwsh       Shell = "pwsh"
+)
+
+// Shells lists every supported shell.
+var Shells = []Shell{ShellSh, ShellBash, ShellCmd, ShellPowerShell, ShellPwsh}
+
+// ParseShell validates a shell name; empty means DefaultShell.
+func ParseShell(s string) (Shell, error) {
+	if s == "" || slices.Contains(Shells, Shell(s)) {
+		return Shell(s), nil
+	}
+	return "", fmt.Errorf("hooks: unknown shell %q", s)
+}
+
+// DefaultShell returns the shell of goos: cmd on Windows, sh elsewhere.
+func DefaultShell(goos string) Shell {
+	if goos == "windows" {
+		return ShellCmd
+	}
+	return ShellSh
+}
+
+// Args returns the command line running line with s.
+func (s Shell) Args(line string) []string {
+	switch s {
+	case ShellCmd:
+		return []string{"cmd", "/d", "/s", "/c", line}
+	case ShellPowerShell, ShellPwsh:
+		return []string{string(s), "-NoProfile", "-NonInteractive", "-Command", line}
+	}
+	return []string{string(s), "-c", line}
+}
+
+// Quote returns arg quoted as a single word of a command line run by s.
+func (s Shell) Quote(arg string) string {
+	switch s {
+	case ShellCmd:
+		return `"` + arg + `"`
+	case ShellPowerShell, ShellPwsh:
+		return "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// PathVars lists the Env keys holding file paths, whose separators Command
+// converts to those of the system it runs on.
+var PathVars = []string{EnvChangelog}
+
+// Process is a process started by a Runner.
+type Process struct {
+	// Args holds the program and its arguments.
+	Args []string
+	Dir  string
+	// Env is the full environment, as KEY=value pairs.
+	Env    []string
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Runner runs processes for Command until they exit or ctx is cancelled.
+type Runner interface {
+	Run(ctx context.Context, p Process) error
+}
+
+// ExecRunner runs processes with os/exec, killing them when ctx is
+// cancelled.
+type ExecRunner struct{}
+
+func (ExecRunner) Run(ctx context.Context, p Process) error {
+	cmd := exec.CommandContext(ctx, p.Args[0], p.Args[1:]...)
+	cmd.Dir = p.Dir
+	cmd.Stdout = p.Stdout
+	cmd.Stderr = p.Stderr
+	cmd.Env = p.Env
+	cmd.WaitDelay = waitDelay
+	prepare(cmd)
+	return cmd.Run()
+}
+
+// Command is a hook that runs Cmd with Shell. Env values are added to the
+// current process environment. The shell is killed when ctx is cancelled.
+type Command struct {
+	Cmd string
+	// Dir is the working directory; empty means the current directory.
+	Dir    string
+	Stdout io.Writer
+	Stderr io.Writer
+	// Shell runs Cmd; empty means DefaultShell(GOOS).
+	Shell Shell
+	// GOOS is the system Cmd runs on; empty means runtime.GOOS.
+	GOOS string
+	// Runner starts the shell; nil means ExecRunner.
+	Runner Runner
+}
+
+func (c *Command) Run(ctx context.Context, env Env) error {
+	goos := c.GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	shell := c.Shell
+	if shell == "" {
+		shell = DefaultShell(goos)
+	}
+	var r Runner = ExecRunner{}
+	if c.Runner != nil {
+		r = c.Runner
+	}
+
+	err := r.Run(ctx, Process{
+		Args:   shell.Args(c.Cmd),
+		Dir:    c.Dir,
+		Env:    append(os.Environ(), env.localize(goos).List()...),
+		Stdout: c.Stdout,
+		Stderr: c.Stderr,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Command) String() string {
+	return c.Cmd
+}
+
+// List returns env as sorted KEY=value pairs.
+func (env Env) List() []string {
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// localize returns env with the separators of its PathVars set to those of
+// goos.
+func (env Env) localize(goos string) Env {
+	from, to := `\`, "/"
+	if goos == "windows" {
+		from, to = to, from
+	}
+	out := make(Env, len(env))
+	for k, v := range env {
+		if slices.Contains(PathVars, k) {
+			v = strings.ReplaceAll(v, from, to)
+		}
+		out[k] = v
+	}
+	return out
+}
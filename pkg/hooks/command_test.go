@@ -0,0 +1,181 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// recordingRunner records the processes it is asked to run.
+type recordingRunner struct {
+	procs []Process
+	err   error
+}
+
+func (r *recordingRunner) Run(ctx context.Context, p Process) error {
+	r.procs = append(r.procs, p)
+	return r.err
+}
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	var stdout bytes.Buffer
+	c := &Command{Cmd: `echo "$RELEASE_STAGE $NEXT_VERSION"`, Stdout: &stdout}
+
+	if err := c.Run(context.Background(), Env{EnvStage: "pre-bump", EnvNextVersion: "2.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "pre-bump 2.0.0\n" {
+		t.Errorf("Expected %q, got %q", "pre-bump 2.0.0\n", stdout.String())
+	}
+
+	if err := (&Command{Cmd: "exit 3"}).Run(context.Background(), nil); err == nil {
+		t.Errorf("Expected error for a failing command")
+	}
+}
+
+func TestCommandCancel(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := (&Command{Cmd: "sleep 5", Stdout: &bytes.Buffer{}}).Run(ctx, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected the hook to be stopped promptly, took %s", elapsed)
+	}
+}
+
+func TestEnvList(t *testing.T) {
+	list := Env{"B": "2", "A": "1"}.List()
+	if len(list) != 2 || list[0] != "A=1" || list[1] != "B=2" {
+		t.Errorf("Expected sorted pairs, got %v", list)
+	}
+}
+
+func TestCommandShell(t *testing.T) {
+	tests := []struct {
+		goos     string
+		shell    Shell
+		expected []string
+	}{
+		{"linux", "", []string{"sh", "-c", "make test"}},
+		{"darwin", ShellBash, []string{"bash", "-c", "make test"}},
+		{"windows", "", []string{"cmd", "/d", "/s", "/c", "make test"}},
+		{"windows", ShellPowerShell, []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", "make test"}},
+		{"linux", ShellPwsh, []string{"pwsh", "-NoProfile", "-NonInteractive", "-Command", "make test"}},
+	}
+	for _, tt := range tests {
+		r := &recordingRunner{}
+		c := &Command{Cmd: "make test", Dir: "build", Shell: tt.shell, GOOS: tt.goos, Runner: r}
+		if err := c.Run(context.Background(), nil); err != nil {
+			t.Fatalf("%s/%s: unexpected error: %v", tt.goos, tt.shell, err)
+		}
+		if len(r.procs) != 1 || !reflect.DeepEqual(r.procs[0].Args, tt.expected) || r.procs[0].Dir != "build" {
+			t.Errorf("%s/%s: Expected %v in build, got %+v", tt.goos, tt.shell, tt.expected, r.procs)
+		}
+	}
+}
+
+func TestCommandPathVars(t *testing.T) {
+	env := Env{EnvChangelog: `docs\CHANGELOG.md`, EnvTag: `v1/2\3`}
+
+	r := &recordingRunner{}
+	if err := (&Command{Cmd: "true", GOOS: "linux", Runner: r}).Run(context.Background(), env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, kv := range []string{EnvChangelog + "=docs/CHANGELOG.md", EnvTag + `=v1/2\3`} {
+		if !slices.Contains(r.procs[0].Env, kv) {
+			t.Errorf("Expected %s in the linux environment", kv)
+		}
+	}
+
+	r = &recordingRunner{}
+	env[EnvChangelog] = "docs/CHANGELOG.md"
+	if err := (&Command{Cmd: "true", GOOS: "windows", Runner: r}).Run(context.Background(), env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, kv := range []string{EnvChangelog + `=docs\CHANGELOG.md`, EnvTag + `=v1/2\3`} {
+		if !slices.Contains(r.procs[0].Env, kv) {
+			t.Errorf("Expected %s in the windows environment", kv)
+		}
+	}
+	if env[EnvChangelog] != "docs/CHANGELOG.md" {
+		t.Errorf("Expected Run not to modify env, got %v", env)
+	}
+}
+
+func TestCommandRunnerError(t *testing.T) {
+	boom := errors.New("boom")
+	if err := (&Command{Cmd: "true", Runner: &recordingRunner{err: boom}}).Run(context.Background(), nil); !errors.Is(err, boom) {
+		t.Errorf("Expected boom, got %v", err)
+	}
+}
+
+func TestCommandScript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	dir := t.TempDir()
+	script := "printf '%s' \"$RELEASE_TAG\" > \"$RELEASE_CHANGELOG\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "hook.sh"), []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := &Command{Cmd: "sh hook.sh", Dir: dir, Shell: ShellSh, Runner: ExecRunner{}}
+
+	if err := c.Run(context.Background(), Env{EnvTag: "v1.2.3", EnvChangelog: "out/tag.txt"}); err == nil {
+		t.Errorf("Expected error writing to a missing directory")
+	}
+	if err := os.Mkdir(filepath.Join(dir, "out"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background(), Env{EnvTag: "v1.2.3", EnvChangelog: "out/tag.txt"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "out", "tag.txt"))
+	if err != nil || string(b) != "v1.2.3" {
+		t.Errorf("Expected %q, got %q (%v)", "v1.2.3", b, err)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, tt := range []struct {
+		shell    Shell
+		expected string
+	}{
+		{ShellSh, `'C:\Temp\it'\''s.md'`},
+		{ShellCmd, `"C:\Temp\it's.md"`},
+		{ShellPwsh, `'C:\Temp\it''s.md'`},
+	} {
+		if got := tt.shell.Quote(`C:\Temp\it's.md`); got != tt.expected {
+			t.Errorf("%s: Expected %s, got %s", tt.shell, tt.expected, got)
+		}
+	}
+}
+
+func TestParseShell(t *testing.T) {
+	for _, s := range []string{"", "sh", "cmd", "pwsh"} {
+		if _, err := ParseShell(s); err != nil {
+			t.Errorf("%q: unexpected error: %v", s, err)
+		}
+	}
+	if _, err := ParseShell("zsh"); err == nil {
+		t.Errorf("Expected error for an unknown shell")
+	}
+}
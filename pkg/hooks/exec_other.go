@@ -0,0 +1,8 @@
+//go:build !windows
+
+package hooks
+
+import "os/exec"
+
+// prepare adjusts cmd for the platform; only Windows needs to.
+func prepare(*exec.Cmd) {}
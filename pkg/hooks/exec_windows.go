@@ -0,0 +1,18 @@
+package hooks
+
+import (
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// prepare passes the command line of cmd.exe verbatim: it does not parse
+// its arguments with the quoting rules os/exec escapes them for, and /s
+// strips the outer quotes added here.
+func prepare(cmd *exec.Cmd) {
+	if len(cmd.Args) == 5 && strings.EqualFold(cmd.Args[0], "cmd") {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			CmdLine: strings.Join(cmd.Args[:4], " ") + ` "` + cmd.Args[4] + `"`,
+		}
+	}
+}
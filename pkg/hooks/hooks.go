@@ -0,0 +1,173 @@
+// Package hooks runs user-defined actions at fixed points of the release
+// lifecycle.
+//
+// Hooks are either shell commands (typically from the hooks section of
+// .release.yaml) or Go functions registered by programs embedding the
+// release packages. Each hook receives the computed release values through
+// Env, which shell commands see as environment variables.
+//
+// A failing hook in a pre-* stage aborts the release: Run stops at the first
+// error. Hooks in post-* stages all run; their failures are collected and
+// returned wrapped in ErrPostHook so callers can report them without undoing
+// work that has already been done.
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+)
+
+// Stage is a point in the release lifecycle.
+type Stage string
+
+const (
+	PreBump       Stage = "pre-bump"
+	PostChangelog Stage = "post-changelog"
+	PrePublish    Stage = "pre-publish"
+	PostPublish   Stage = "post-publish"
+)
+
+// Stages lists every stage in lifecycle order.
+var Stages = []Stage{PreBump, PostChangelog, PrePublish, PostPublish}
+
+// ParseStage validates a stage name.
+func ParseStage(s string) (Stage, error) {
+	for _, st := range Stages {
+		if string(st) == s {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("hooks: unknown stage %q", s)
+}
+
+// IsPre reports whether failures at s abort the release.
+func (s Stage) IsPre() bool {
+	return strings.HasPrefix(string(s), "pre-")
+}
+
+// ErrPostHook wraps failures of hooks in post-* stages.
+var ErrPostHook = errors.New("post hook failed")
+
+// Env holds the values injected into hooks.
+type Env map[string]string
+
+// Well-known Env keys.
+const (
+	EnvStage       = "RELEASE_STAGE"
+	EnvNextVersion = "NEXT_VERSION"
+	EnvPrevVersion = "PREV_VERSION"
+	EnvTag         = "RELEASE_TAG"
+	EnvBump        = "RELEASE_BUMP"
+	EnvChannel     = "RELEASE_CHANNEL"
+	// EnvChangelog is the path of the changelog file.
+	EnvChangelog = "RELEASE_CHANGELOG"
+)
+
+// Hook is a single action run at a stage.
+type Hook interface {
+	Run(ctx context.Context, env Env) error
+}
+
+// Func adapts a Go function to Hook.
+type Func func(ctx context.Context, env Env) error
+
+func (f Func) Run(ctx context.Context, env Env) error { return f(ctx, env) }
+
+// Engine holds the hooks registered for each stage.
+type Engine struct {
+	hooks map[Stage][]Hook
+
+	// DryRun describes the hooks on Log instead of running them.
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every hook run; nil discards.
+	Logger *slog.Logger
+}
+
+// New returns an Engine with no hooks.
+func New() *Engine {
+	return &Engine{hooks: make(map[Stage][]Hook), Log: io.Discard}
+}
+
+// FromConfig builds an Engine running shell commands, keyed by stage name as
+// in config.Config.Hooks. Each command is a copy of base, which sets where
+// its output goes and the shell it runs with.
+func FromConfig(commands map[string][]string, base Command) (*Engine, error) {
+	if _, err := ParseShell(string(base.Shell)); err != nil {
+		return nil, err
+	}
+	e := New()
+	for name, cmds := range commands {
+		stage, err := ParseStage(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cmds {
+			cmd := base
+			cmd.Cmd = c
+			e.Register(stage, &cmd)
+		}
+	}
+	return e, nil
+}
+
+// Register appends h to the hooks run at stage.
+func (e *Engine) Register(stage Stage, h Hook) {
+	e.hooks[stage] = append(e.hooks[stage], h)
+}
+
+// Hooks returns the hooks registered for stage.
+func (e *Engine) Hooks(stage Stage) []Hook {
+	return e.hooks[stage]
+}
+
+// Run executes the hooks registered for stage in registration order. env is
+// extended with EnvStage.
+func (e *Engine) Run(ctx context.Context, stage Stage, env Env) error {
+	hooks := e.hooks[stage]
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	full := Env{EnvStage: string(stage)}
+	for k, v := range env {
+		full[k] = v
+	}
+
+	var errs []error
+	for i, h := range hooks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if e.DryRun {
+			dryrun.Printf(e.Log, "would run %s hook %s", stage, describe(h))
+			continue
+		}
+		log.Or(e.Logger).Debug("running hook", "stage", string(stage), "hook", describe(h))
+		if err := h.Run(ctx, full); err != nil {
+			err = fmt.Errorf("hooks: %s hook %d (%s): %w", stage, i+1, describe(h), err)
+			if stage.IsPre() {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrPostHook, errors.Join(errs...))
+	}
+	return nil
+}
+
+func describe(h Hook) string {
+	if s, ok := h.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return "func"
+}
@@ -0,0 +1,139 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+)
+
+func TestRunOrderAndEnv(t *testing.T) {
+	var calls []string
+	e := New()
+	e.Register(PreBump, Func(func(ctx context.Context, env Env) error {
+		calls = append(calls, "first:"+env[EnvStage]+":"+env[EnvNextVersion])
+		return nil
+	}))
+	e.Register(PreBump, Func(func(ctx context.Context, env Env) error {
+		calls = append(calls, "second")
+		return nil
+	}))
+
+	if err := e.Run(context.Background(), PreBump, Env{EnvNextVersion: "1.2.3"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"first:pre-bump:1.2.3", "second"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected %v, got %v", expected, calls)
+	}
+
+	if err := e.Run(context.Background(), PostPublish, nil); err != nil {
+		t.Errorf("Expected no error for a stage without hooks, got %v", err)
+	}
+}
+
+func TestPreHookFailureAborts(t *testing.T) {
+	boom := errors.New("boom")
+	ran := false
+	e := New()
+	e.Register(PrePublish, Func(func(context.Context, Env) error { return boom }))
+	e.Register(PrePublish, Func(func(context.Context, Env) error { ran = true; return nil }))
+
+	err := e.Run(context.Background(), PrePublish, nil)
+	if !errors.Is(err, boom) {
+		t.Errorf("Expected boom, got %v", err)
+	}
+	if errors.Is(err, ErrPostHook) {
+		t.Errorf("Did not expect ErrPostHook for a pre stage")
+	}
+	if ran {
+		t.Errorf("Expected later hooks not to run after a pre hook failure")
+	}
+}
+
+func TestPostHookFailuresCollected(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+	e := New()
+	e.Register(PostPublish, Func(func(context.Context, Env) error { return first }))
+	e.Register(PostPublish, Func(func(context.Context, Env) error { return second }))
+
+	err := e.Run(context.Background(), PostPublish, nil)
+	if !errors.Is(err, ErrPostHook) || !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("Expected both failures wrapped in ErrPostHook, got %v", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var log bytes.Buffer
+	ran := false
+	e := New()
+	e.DryRun, e.Log = true, &log
+	e.Register(PrePublish, &Command{Cmd: "make test"})
+	e.Register(PrePublish, Func(func(context.Context, Env) error { ran = true; return nil }))
+
+	if err := e.Run(context.Background(), PrePublish, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ran {
+		t.Errorf("Expected hooks not to run in dry-run mode")
+	}
+
+	expected := "[dry-run] would run pre-publish hook make test\n[dry-run] would run pre-publish hook func\n"
+	if log.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, log.String())
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	e := New()
+	e.Logger = log.New(&buf, log.Options{Level: slog.LevelDebug})
+	e.Register(PostPublish, &Command{Cmd: "true"})
+
+	if err := e.Run(context.Background(), PostPublish, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "debug: running hook stage=post-publish hook=true\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	e, err := FromConfig(map[string][]string{"pre-bump": {"echo a", "echo b"}}, Command{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(e.Hooks(PreBump)) != 2 {
+		t.Errorf("Expected 2 pre-bump hooks, got %d", len(e.Hooks(PreBump)))
+	}
+
+	if _, err := FromConfig(map[string][]string{"pre-lunch": {"eat"}}, Command{}); err == nil {
+		t.Errorf("Expected error for unknown stage")
+	}
+
+	e, err = FromConfig(map[string][]string{"pre-bump": {"echo a"}}, Command{Dir: "build", Shell: ShellPwsh})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c := e.Hooks(PreBump)[0].(*Command); c.Cmd != "echo a" || c.Dir != "build" || c.Shell != ShellPwsh {
+		t.Errorf("Expected a copy of the base command, got %+v", c)
+	}
+	if _, err := FromConfig(nil, Command{Shell: "zsh"}); err == nil {
+		t.Errorf("Expected error for unknown shell")
+	}
+}
+
+func TestIsPre(t *testing.T) {
+	expected := map[Stage]bool{PreBump: true, PostChangelog: false, PrePublish: true, PostPublish: false}
+	for stage, pre := range expected {
+		if stage.IsPre() != pre {
+			t.Errorf("%s: expected IsPre %v", stage, pre)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Counter counts the warnings and errors logged, whether or not the level
+// of the Logger writes them, so that a caller can fail a command that
+// warned. The zero value is ready to use and safe for concurrent use.
+type Counter struct {
+	warnings, errors atomic.Int64
+}
+
+// Warnings returns the number of records logged at warning level.
+func (c *Counter) Warnings() int {
+	return int(c.warnings.Load())
+}
+
+// Errors returns the number of records logged at error level and above.
+func (c *Counter) Errors() int {
+	return int(c.errors.Load())
+}
+
+type countHandler struct {
+	h slog.Handler
+	c *Counter
+}
+
+func (h *countHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= slog.LevelWarn || h.h.Enabled(ctx, l)
+}
+
+func (h *countHandler) Handle(ctx context.Context, r slog.Record) error {
+	switch {
+	case r.Level >= slog.LevelError:
+		h.c.errors.Add(1)
+	case r.Level >= slog.LevelWarn:
+		h.c.warnings.Add(1)
+	}
+	if !h.h.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.h.Handle(ctx, r)
+}
+
+func (h *countHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &countHandler{h: h.h.WithAttrs(attrs), c: h.c}
+}
+
+func (h *countHandler) WithGroup(name string) slog.Handler {
+	return &countHandler{h: h.h.WithGroup(name), c: h.c}
+}
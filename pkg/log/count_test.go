@@ -0,0 +1,24 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	var buf bytes.Buffer
+	c := &Counter{}
+	l := New(&buf, Options{Level: slog.LevelError, Counter: c})
+	l.Info("starting")
+	l.Warn("slow")
+	l.With("step", "push").Warn("retrying")
+	l.Error("failed")
+
+	if c.Warnings() != 2 || c.Errors() != 1 {
+		t.Errorf("Expected 2 warnings and 1 error, got %d and %d", c.Warnings(), c.Errors())
+	}
+	if expected := "error: failed\n"; buf.String() != expected {
+		t.Errorf("Expected the warnings left out by the level, got %q", buf.String())
+	}
+}
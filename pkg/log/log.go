@@ -0,0 +1,192 @@
+// Package log configures the structured logging shared by the release
+// packages, built on log/slog.
+//
+// Components take an optional Logger field (a *slog.Logger); a nil Logger
+// discards everything, see Or. Loggers made by New redact secrets from
+// every message and attribute before they are written, so API tokens and
+// passwords never reach CI logs.
+//
+// Logging is separate from dry-run reporting (package dryrun): dry-run lines
+// describe what a command would do and are part of its output, while logs
+// are diagnostics.
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// EnvFormat selects the log format for the CLI when the flag is not given.
+const EnvFormat = "RELEASE_LOG_FORMAT"
+
+// Format is the encoding of log records.
+type Format string
+
+const (
+	// Text writes one human-readable line per record: the message prefixed
+	// by the level ("warning: ...") and followed by key=value attributes.
+	// Info records have no prefix.
+	Text Format = "text"
+	// JSON writes one slog JSON object per record, for CI log processors.
+	JSON Format = "json"
+)
+
+// ParseFormat converts "text" or "json" into a Format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Text, JSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("log: unknown format %q: must be text or json", s)
+}
+
+// Options configures New.
+type Options struct {
+	Level  slog.Leveler
+	Format Format
+	// Secrets are redacted wherever they appear, in addition to the values
+	// of sensitive attribute keys (see Sensitive).
+	Secrets []string
+	// Redactor, when set, redacts the records instead of a new one and is
+	// given Secrets, so secrets added to it later are redacted too.
+	Redactor *Redactor
+	// Counter, when set, counts the warnings and errors logged, including
+	// those below Level.
+	Counter *Counter
+}
+
+// New returns a Logger writing records at o.Level and above to w.
+func New(w io.Writer, o Options) *slog.Logger {
+	level := o.Level
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	var h slog.Handler
+	if o.Format == JSON {
+		h = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	} else {
+		h = &textHandler{w: w, level: level}
+	}
+	r := o.Redactor
+	if r == nil {
+		r = &Redactor{}
+	}
+	r.Add(o.Secrets...)
+	h = &redactHandler{h: h, r: r}
+	if o.Counter != nil {
+		h = &countHandler{h: h, c: o.Counter}
+	}
+	return slog.New(h)
+}
+
+// Discard is a Logger that drops every record.
+var Discard = slog.New(slog.DiscardHandler)
+
+// Or returns l, or Discard when l is nil.
+func Or(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return Discard
+	}
+	return l
+}
+
+// EnvSecrets returns the values of environment variables that conventionally
+// hold credentials (GITHUB_TOKEN, CI_JOB_TOKEN, SMTP_PASSWORD, ...).
+func EnvSecrets() []string {
+	var secrets []string
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if v != "" && Sensitive(k) {
+			secrets = append(secrets, v)
+		}
+	}
+	return secrets
+}
+
+// Sensitive reports whether key names a credential, such as "token",
+// "GITLAB_TOKEN", "password" or "Authorization".
+func Sensitive(key string) bool {
+	k := strings.ToLower(key)
+	for _, s := range []string{"token", "password", "secret", "authorization", "api_key", "apikey"} {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type textHandler struct {
+	w      io.Writer
+	level  slog.Leveler
+	prefix string // key prefix from WithGroup
+	attrs  string // preformatted attributes from WithAttrs
+}
+
+func (h *textHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString("error: ")
+	case r.Level >= slog.LevelWarn:
+		b.WriteString("warning: ")
+	case r.Level < slog.LevelInfo:
+		b.WriteString("debug: ")
+	}
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		writeAttr(&b, h.prefix, a)
+	}
+	c := *h
+	c.attrs += b.String()
+	return &c
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.prefix += name + "."
+	return &c
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			writeAttr(b, prefix, ga)
+		}
+		return
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	s := v.String()
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		s = fmt.Sprintf("%q", s)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, s)
+}
@@ -0,0 +1,93 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Level: slog.LevelDebug})
+
+	l.Debug("running git", "args", "tag -a v1.0.0")
+	l.Info("published", "url", "https://example.com/r/1")
+	l.With("stage", "post-publish").Warn("hook failed", "err", errors.New("exit status 1"))
+	l.WithGroup("req").Error("request failed", "status", 500)
+	l.Info("grouped", slog.Group("build", "os", "linux", "arch", "amd64"))
+
+	expected := "debug: running git args=\"tag -a v1.0.0\"\n" +
+		"published url=https://example.com/r/1\n" +
+		"warning: hook failed stage=post-publish err=\"exit status 1\"\n" +
+		"error: request failed req.status=500\n" +
+		"grouped build.os=linux build.arch=amd64\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Level: slog.LevelWarn})
+
+	l.Info("hidden")
+	l.Warn("shown")
+
+	if buf.String() != "warning: shown\n" {
+		t.Errorf("Expected only the warning, got %q", buf.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Format: JSON})
+
+	l.Info("published", "tag", "v1.0.0")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", buf.String(), err)
+	}
+	if got["level"] != "INFO" || got["msg"] != "published" || got["tag"] != "v1.0.0" {
+		t.Errorf("Expected level, msg and tag, got %v", got)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range []Format{Text, JSON} {
+		result, err := ParseFormat(string(f))
+		if err != nil || result != f {
+			t.Errorf("Expected %s, got %s (err=%v)", f, result, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != Discard {
+		t.Errorf("Expected Discard for a nil logger")
+	}
+	l := New(&bytes.Buffer{}, Options{})
+	if Or(l) != l {
+		t.Errorf("Expected the logger itself")
+	}
+}
+
+func TestEnvSecrets(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_secret")
+	t.Setenv("SMTP_PASSWORD", "hunter22")
+	t.Setenv("RELEASE_TAG", "v1.0.0")
+
+	secrets := EnvSecrets()
+	found := map[string]bool{}
+	for _, s := range secrets {
+		found[s] = true
+	}
+	if !found["ghp_secret"] || !found["hunter22"] || found["v1.0.0"] {
+		t.Errorf("Expected token and password values only, got %v", secrets)
+	}
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in log output.
+const Redacted = "[REDACTED]"
+
+// minSecretLen keeps short values such as "1" or "yes" from being treated
+// as secrets and redacting unrelated text.
+const minSecretLen = 4
+
+// Redactor replaces known secret values in strings. It is safe for
+// concurrent use.
+type Redactor struct {
+	mu      sync.RWMutex
+	secrets []string
+	r       *strings.Replacer
+}
+
+// NewRedactor returns a Redactor for secrets. Values shorter than four
+// characters are ignored.
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{}
+	r.Add(secrets...)
+	return r
+}
+
+// Add redacts secrets too, such as credentials looked up after the logger
+// was made. Values shorter than four characters are ignored.
+func (r *Redactor) Add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	added := false
+	for _, s := range secrets {
+		if len(s) >= minSecretLen && !slices.Contains(r.secrets, s) {
+			r.secrets = append(r.secrets, s)
+			added = true
+		}
+	}
+	if !added {
+		return
+	}
+	// Longer secrets first, so a secret containing another is replaced whole.
+	slices.SortFunc(r.secrets, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	var pairs []string
+	for _, s := range r.secrets {
+		pairs = append(pairs, s, Redacted)
+	}
+	r.r = strings.NewReplacer(pairs...)
+}
+
+// Redact returns s with every secret replaced by Redacted.
+func (r *Redactor) Redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.r == nil {
+		return s
+	}
+	return r.r.Replace(s)
+}
+
+func (r *Redactor) attr(a slog.Attr) slog.Attr {
+	if Sensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		attrs := v.Group()
+		out := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			out[i] = r.attr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(out...)}
+	case slog.KindString, slog.KindAny:
+		if s := v.String(); r.Redact(s) != s {
+			return slog.String(a.Key, r.Redact(s))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+type redactHandler struct {
+	h slog.Handler
+	r *Redactor
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.h.Enabled(ctx, l)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, h.r.Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.r.attr(a))
+		return true
+	})
+	return h.h.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		out[i] = h.r.attr(a)
+	}
+	return &redactHandler{h: h.h.WithAttrs(out), r: h.r}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{h: h.h.WithGroup(name), r: h.r}
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor("abc", "glpat-123456", "glpat-123456-extra", "")
+
+	tests := map[string]string{
+		"token glpat-123456 leaked":       "token [REDACTED] leaked",
+		"token glpat-123456-extra leaked": "token [REDACTED] leaked",
+		"abc is too short to be a secret": "abc is too short to be a secret",
+	}
+	for in, expected := range tests {
+		if got := r.Redact(in); got != expected {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+	}
+
+	if got := NewRedactor().Redact("unchanged"); got != "unchanged" {
+		t.Errorf("Expected %q, got %q", "unchanged", got)
+	}
+}
+
+func TestRedactLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Secrets: []string{"s3cr3t-token"}})
+
+	l.With("auth", "Bearer s3cr3t-token").Info("request to https://x/?t=s3cr3t-token",
+		"password", "hunter22",
+		"err", errors.New("401 for s3cr3t-token"),
+		slog.Group("headers", "Authorization", "Basic abc"),
+	)
+
+	expected := "request to https://x/?t=[REDACTED] auth=\"Bearer [REDACTED]\" password=[REDACTED] err=\"401 for [REDACTED]\" headers.Authorization=[REDACTED]\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRedactorAdd(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRedactor()
+	l := New(&buf, Options{Secrets: []string{"s3cr3t-token"}, Redactor: r})
+
+	r.Add("ghp_from_vault", "ghp_from_vault")
+	l.Info("s3cr3t-token then ghp_from_vault")
+
+	expected := "[REDACTED] then [REDACTED]\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Format: JSON, Secrets: []string{"s3cr3t-token"}})
+
+	l.Warn("failed", "url", "https://x/?t=s3cr3t-token", "token", "other")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", buf.String(), err)
+	}
+	if got["url"] != "https://x/?t=[REDACTED]" || got["token"] != Redacted {
+		t.Errorf("Expected redacted url and token, got %v", got)
+	}
+}
@@ -0,0 +1,250 @@
+// Package manifest describes a platform release: repositories released
+// one after the other by `release meta`, in the order of their needs, each
+// picking up the versions just released of the repositories it needs.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gbrennon/release_automation_golang/pkg/bumpfiles"
+)
+
+// FileName is the manifest `release meta` reads by default.
+const FileName = "release-manifest.yaml"
+
+// Defaults of CIConfig.
+const (
+	DefaultCITimeout  = 30 * time.Minute
+	DefaultCIInterval = 30 * time.Second
+)
+
+// ErrCycle is reported by Order when repositories need each other.
+var ErrCycle = errors.New("manifest: repositories need each other")
+
+// Manifest lists the repositories of a platform release.
+type Manifest struct {
+	// Name names the platform release in messages, e.g. "platform".
+	Name         string       `yaml:"name" json:"name" toml:"name"`
+	Repositories []Repository `yaml:"repositories" json:"repositories" toml:"repositories"`
+	CI           CIConfig     `yaml:"ci" json:"ci" toml:"ci"`
+}
+
+// Repository is a repository released by the platform release, from a
+// local checkout with its own release configuration.
+type Repository struct {
+	// Name identifies the repository in Needs and Propagate.
+	Name string `yaml:"name" json:"name" toml:"name"`
+	// Path is the checkout, relative to the manifest.
+	Path string `yaml:"path" json:"path" toml:"path"`
+	// Needs names the repositories released before this one, whose CI
+	// must pass on their new tags first.
+	Needs []string `yaml:"needs" json:"needs" toml:"needs"`
+	// Propagate lists where the versions of the repositories needed are
+	// written before this one is released.
+	Propagate []Propagation `yaml:"propagate" json:"propagate" toml:"propagate"`
+	// Remote is the remote the commit of the propagated versions is
+	// pushed to; empty means "origin".
+	Remote string `yaml:"remote" json:"remote" toml:"remote"`
+	// Provider and Repo name where the CI of the new tag is checked, e.g.
+	// github and octo/api; empty ones are those of the release published.
+	Provider string `yaml:"provider" json:"provider" toml:"provider"`
+	Repo     string `yaml:"repo" json:"repo" toml:"repo"`
+}
+
+// Propagation writes the version released of repository From in a file,
+// located as the version_files of the release configuration are.
+type Propagation struct {
+	From string `yaml:"from" json:"from" toml:"from"`
+	Path string `yaml:"path" json:"path" toml:"path"`
+	// Key is the dotted path of the version in a JSON, YAML or TOML file,
+	// e.g. "images.api.tag".
+	Key string `yaml:"key" json:"key" toml:"key"`
+	// Pattern is a regular expression whose first group is the version.
+	Pattern string `yaml:"pattern" json:"pattern" toml:"pattern"`
+	// Tag writes the tag, e.g. v1.4.0, instead of the version.
+	Tag bool `yaml:"tag" json:"tag" toml:"tag"`
+}
+
+// File returns the file p writes in.
+func (p Propagation) File() bumpfiles.File {
+	return bumpfiles.File{Path: p.Path, Key: p.Key, Pattern: p.Pattern}
+}
+
+// CIConfig tunes the wait for the CI of a tag before the repositories
+// needing it are released. Zero values keep the defaults.
+type CIConfig struct {
+	// Timeout is how long CI may take, e.g. "45m"; empty means 30m.
+	Timeout string `yaml:"timeout" json:"timeout" toml:"timeout"`
+	// Interval is how often the status is checked; empty means 30s.
+	Interval string `yaml:"interval" json:"interval" toml:"interval"`
+}
+
+// Durations returns the timeout and interval of c, defaults filled in.
+func (c CIConfig) Durations() (timeout, interval time.Duration) {
+	timeout, interval = DefaultCITimeout, DefaultCIInterval
+	if d, err := time.ParseDuration(c.Timeout); err == nil {
+		timeout = d
+	}
+	if d, err := time.ParseDuration(c.Interval); err == nil {
+		interval = d
+	}
+	return timeout, interval
+}
+
+// Load reads and validates the manifest at path. The format is chosen from
+// the file extension, and repository paths are made relative to the
+// current directory.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	m, err := Parse(data, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %s: %w", path, err)
+	}
+	for i, r := range m.Repositories {
+		if !filepath.IsAbs(r.Path) {
+			m.Repositories[i].Path = filepath.Join(filepath.Dir(path), filepath.FromSlash(r.Path))
+		}
+	}
+	return m, nil
+}
+
+// Parse decodes data in the given format ("yaml", "yml", "json" or "toml")
+// and validates the result. Unknown keys are rejected.
+func Parse(data []byte, format string) (*Manifest, error) {
+	m := &Manifest{}
+	switch format {
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(m); err != nil {
+			return nil, err
+		}
+	case "toml":
+		md, err := toml.Decode(string(data), m)
+		if err != nil {
+			return nil, err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("unknown field %q", undecoded[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate reports every problem of m at once.
+func (m *Manifest) Validate() error {
+	var errs []error
+	if len(m.Repositories) == 0 {
+		errs = append(errs, errors.New("repositories: at least one is required"))
+	}
+	names := make(map[string]bool)
+	for _, r := range m.Repositories {
+		names[r.Name] = true
+	}
+	seen := make(map[string]bool)
+	for i, r := range m.Repositories {
+		switch {
+		case r.Name == "":
+			errs = append(errs, fmt.Errorf("repositories[%d].name: required", i))
+		case seen[r.Name]:
+			errs = append(errs, fmt.Errorf("repositories[%d].name: %q is listed twice", i, r.Name))
+		}
+		seen[r.Name] = true
+		if r.Path == "" {
+			errs = append(errs, fmt.Errorf("repositories[%d].path: required", i))
+		}
+		for j, n := range r.Needs {
+			switch {
+			case n == r.Name:
+				errs = append(errs, fmt.Errorf("repositories[%d].needs[%d]: %q cannot need itself", i, j, n))
+			case !names[n]:
+				errs = append(errs, fmt.Errorf("repositories[%d].needs[%d]: unknown repository %q", i, j, n))
+			}
+		}
+		for j, p := range r.Propagate {
+			if !slices.Contains(r.Needs, p.From) {
+				errs = append(errs, fmt.Errorf("repositories[%d].propagate[%d].from: %q is not one of needs", i, j, p.From))
+			}
+			if p.Path == "" {
+				errs = append(errs, fmt.Errorf("repositories[%d].propagate[%d].path: required", i, j))
+			} else if err := p.File().Check(); err != nil {
+				errs = append(errs, fmt.Errorf("repositories[%d].propagate[%d]: %w", i, j, err))
+			}
+		}
+		if (r.Provider == "") != (r.Repo == "") {
+			errs = append(errs, fmt.Errorf("repositories[%d]: provider and repo go together", i))
+		}
+	}
+	for _, d := range []struct{ key, value string }{{"ci.timeout", m.CI.Timeout}, {"ci.interval", m.CI.Interval}} {
+		if d.value == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d.value); err != nil || v <= 0 {
+			errs = append(errs, fmt.Errorf("%s: %q is not a positive duration", d.key, d.value))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Order groups the repositories of m in the order of their needs: those of
+// a group only need repositories of earlier groups. Within a group,
+// repositories keep the order of the manifest.
+func (m *Manifest) Order() ([][]Repository, error) {
+	left := slices.Clone(m.Repositories)
+	done := make(map[string]bool, len(left))
+	var groups [][]Repository
+	for len(left) > 0 {
+		var group, rest []Repository
+		for _, r := range left {
+			if slices.ContainsFunc(r.Needs, func(n string) bool { return !done[n] }) {
+				rest = append(rest, r)
+			} else {
+				group = append(group, r)
+			}
+		}
+		if len(group) == 0 {
+			names := make([]string, len(rest))
+			for i, r := range rest {
+				names[i] = r.Name
+			}
+			return nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(names, ", "))
+		}
+		for _, r := range group {
+			done[r.Name] = true
+		}
+		groups = append(groups, group)
+		left = rest
+	}
+	return groups, nil
+}
+
+// Needed reports whether a repository of m needs the one named name.
+func (m *Manifest) Needed(name string) bool {
+	return slices.ContainsFunc(m.Repositories, func(r Repository) bool { return slices.Contains(r.Needs, name) })
+}
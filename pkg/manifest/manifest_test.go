@@ -0,0 +1,137 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const platform = `
+name: platform
+repositories:
+  - name: api
+    path: services/api
+    needs: [lib, auth]
+    propagate:
+      - from: lib
+        path: go.mod
+        pattern: 'example.com/lib (v\S+)'
+        tag: true
+  - name: lib
+    path: lib
+  - name: web
+    path: /src/web
+    needs: [api]
+    provider: github
+    repo: octo/web
+  - name: auth
+    path: auth
+ci:
+  timeout: 45m
+`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte(platform), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Name != "platform" || len(m.Repositories) != 4 {
+		t.Fatalf("Unexpected manifest %+v", m)
+	}
+	if expected := filepath.Join(dir, "services", "api"); m.Repositories[0].Path != expected {
+		t.Errorf("Expected %q, got %q", expected, m.Repositories[0].Path)
+	}
+	if m.Repositories[2].Path != "/src/web" {
+		t.Errorf("Expected the absolute path kept, got %q", m.Repositories[2].Path)
+	}
+	p := m.Repositories[0].Propagate[0]
+	if p.From != "lib" || !p.Tag || p.File().Pattern != `example.com/lib (v\S+)` {
+		t.Errorf("Unexpected propagation %+v", p)
+	}
+	timeout, interval := m.CI.Durations()
+	if timeout != 45*time.Minute || interval != DefaultCIInterval {
+		t.Errorf("Expected 45m and the default interval, got %v and %v", timeout, interval)
+	}
+
+	if _, err := Parse([]byte("repositories: [{name: a, path: a, branch: main}]"), "yaml"); err == nil {
+		t.Error("Expected unknown fields rejected")
+	}
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Expected a missing manifest reported")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	m, err := Parse([]byte(platform), "yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	groups, err := m.Order()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got [][]string
+	for _, g := range groups {
+		var names []string
+		for _, r := range g {
+			names = append(names, r.Name)
+		}
+		got = append(got, names)
+	}
+	expected := [][]string{{"lib", "auth"}, {"api"}, {"web"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if !m.Needed("api") || m.Needed("web") {
+		t.Errorf("Expected api needed and web not")
+	}
+
+	m.Repositories[1].Needs = []string{"web"}
+	if _, err := m.Order(); !errors.Is(err, ErrCycle) || !strings.Contains(err.Error(), "api, lib, web") {
+		t.Errorf("Expected a cycle of api, lib and web, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	m := &Manifest{
+		Repositories: []Repository{
+			{Name: "api", Path: "api", Needs: []string{"api", "db"}, Propagate: []Propagation{{From: "lib", Path: "go.mod", Key: "x", Pattern: "(.*)"}, {From: "api"}}},
+			{Name: "api", Provider: "github"},
+			{Path: "lib"},
+		},
+		CI: CIConfig{Timeout: "soon", Interval: "-1s"},
+	}
+	err := m.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, want := range []string{
+		"repositories[0].needs[0]",
+		"repositories[0].needs[1]",
+		"repositories[0].propagate[0].from",
+		"repositories[0].propagate[0]: bumpfiles: go.mod: key and pattern",
+		"repositories[0].propagate[1].path",
+		"repositories[1].name",
+		"repositories[1].path",
+		"repositories[1]: provider and repo",
+		"repositories[2].name",
+		"ci.timeout",
+		"ci.interval",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+	if err := (&Manifest{}).Validate(); err == nil || !strings.Contains(err.Error(), "at least one") {
+		t.Errorf("Expected an empty manifest refused, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package notes
+
+import (
+	"regexp"
+	"slices"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+// Reasons a change is highlighted, in order of precedence.
+const (
+	ReasonBreaking   = "breaking"
+	ReasonSize       = "size"
+	ReasonReferences = "references"
+)
+
+// Highlight is a change picked for the Highlights section of the notes.
+type Highlight struct {
+	commits.Commit
+	// Reason is ReasonBreaking, ReasonSize or ReasonReferences.
+	Reason string
+	// Lines are the lines its commit changed, as in stats.
+	Lines int
+	// References counts the commits of the release referencing the issues
+	// it references, itself included.
+	References int
+}
+
+// minorTypes are the commit types never highlighted unless breaking.
+var minorTypes = []string{"build", "chore", "ci", "docs", "style", "test"}
+
+// squashPattern matches the "(#123)" GitHub appends to squash merges.
+var squashPattern = regexp.MustCompile(`\(#\d+\)\s*$`)
+
+// Highlights picks the n most significant changes of raw: the breaking
+// changes first, then the largest pull requests by the lines stats says
+// their commits changed, then the changes referencing the issues the most
+// commits reference. Pull requests are merge commits and squash merges,
+// whose description ends in "(#123)". Builds, chores, CI, docs, style and
+// test changes are only picked when breaking. Ties keep the order of raw.
+func Highlights(raw []gitrepo.Commit, stats map[string]gitrepo.DiffStat, n int) []Highlight {
+	if n <= 0 {
+		return nil
+	}
+	type candidate struct {
+		Highlight
+		pr bool
+	}
+	var cs []candidate
+	mentions := make(map[int]int)
+	for _, rc := range raw {
+		c, err := commits.Parse(rc.Message)
+		if err != nil || (c.Type == "chore" && c.Scope == "release") {
+			continue
+		}
+		c.Hash = rc.Hash
+		for _, num := range references.Numbers(c) {
+			mentions[num]++
+		}
+		cs = append(cs, candidate{
+			Highlight: Highlight{Commit: c, Lines: stats[rc.Hash].Lines()},
+			pr:        len(rc.Parents) > 1 || squashPattern.MatchString(c.Description),
+		})
+	}
+	for i := range cs {
+		for _, num := range references.Numbers(cs[i].Commit) {
+			cs[i].References = max(cs[i].References, mentions[num])
+		}
+	}
+
+	tiers := [3][]Highlight{}
+	for _, c := range cs {
+		switch {
+		case c.Breaking:
+			c.Reason = ReasonBreaking
+			tiers[0] = append(tiers[0], c.Highlight)
+		case slices.Contains(minorTypes, c.Type):
+		case c.pr && c.Lines > 0:
+			c.Reason = ReasonSize
+			tiers[1] = append(tiers[1], c.Highlight)
+		case c.References > 0:
+			c.Reason = ReasonReferences
+			tiers[2] = append(tiers[2], c.Highlight)
+		}
+	}
+	slices.SortStableFunc(tiers[1], func(a, b Highlight) int { return b.Lines - a.Lines })
+	slices.SortStableFunc(tiers[2], func(a, b Highlight) int { return b.References - a.References })
+
+	var hs []Highlight
+	for _, t := range tiers {
+		hs = append(hs, t[:min(len(t), n-len(hs))]...)
+	}
+	return hs
+}
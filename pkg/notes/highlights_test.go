@@ -0,0 +1,61 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+func TestHighlights(t *testing.T) {
+	raw := []gitrepo.Commit{
+		{Hash: "a", Message: "feat: small pull request (#3)"},
+		{Hash: "b", Message: "fix: refs a popular issue\n\nFixes #9"},
+		{Hash: "c", Message: "feat: large pull request (#4)"},
+		{Hash: "d", Message: "docs: huge rewrite (#5)"},
+		{Hash: "e", Message: "refactor!: drop the v1 API"},
+		{Hash: "f", Message: "fix: also refs it\n\nRefs #9"},
+		{Hash: "g", Message: "feat: refs a lonely issue #11"},
+		{Hash: "h", Message: "chore(release): v1.2.0"},
+	}
+	stats := map[string]gitrepo.DiffStat{
+		"a": {Insertions: 10},
+		"c": {Insertions: 300, Deletions: 20},
+		"d": {Insertions: 5000},
+	}
+
+	hs := Highlights(raw, stats, 5)
+	var got []string
+	for _, h := range hs {
+		got = append(got, h.Hash+":"+h.Reason)
+	}
+	expected := "e:breaking c:size a:size b:references f:references"
+	if strings.Join(got, " ") != expected {
+		t.Errorf("Expected %q, got %q", expected, strings.Join(got, " "))
+	}
+	if hs[1].Lines != 320 || hs[3].References != 2 {
+		t.Errorf("Expected 320 lines and 2 references, got %d and %d", hs[1].Lines, hs[3].References)
+	}
+
+	if hs := Highlights(raw, stats, 2); len(hs) != 2 || hs[1].Hash != "c" {
+		t.Errorf("Expected the breaking change and the largest pull request, got %+v", hs)
+	}
+	if hs := Highlights(raw, stats, 0); hs != nil {
+		t.Errorf("Expected no highlights, got %+v", hs)
+	}
+}
+
+func TestDefaultTemplateHighlights(t *testing.T) {
+	links := Links{RepoURL: "https://github.com/octo/app"}
+	d := NewData(testMeta, testRaw, links)
+	d.Highlights = Highlights(testRaw, map[string]gitrepo.DiffStat{"1111111aaaa": {Insertions: 40}}, 3)
+
+	result, err := Default(links).RenderString(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "### Highlights\n\n- **cli:** add notes command ([#7](https://github.com/octo/app/issues/7)) ([1111111](https://github.com/octo/app/commit/1111111aaaa))\n\n### Features"
+	if !strings.HasPrefix(result, expected) {
+		t.Errorf("Expected notes starting with %q, got %q", expected, result)
+	}
+}
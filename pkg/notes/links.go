@@ -0,0 +1,175 @@
+package notes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Links builds web URLs for a repository hosted on GitHub, GitLab, Gitea
+// (Forgejo, Codeberg) or Bitbucket, public or self-hosted. The zero value
+// has no RepoURL and produces no links.
+type Links struct {
+	// RepoURL is the repository's web address, e.g.
+	// "https://github.com/octo/app".
+	RepoURL string
+	// Provider is one of LinkProviders; it selects the URL layout.
+	// Anything else is treated as "github".
+	Provider string
+	// Templates replace the URL layout of Provider, page by page.
+	Templates URLTemplates
+}
+
+// URLTemplates are the URLs of the pages of a code browser whose layout
+// Links does not know, such as cgit or Bitbucket Server, e.g.
+// "{repo}/commit/?id={commit}". {repo} is the RepoURL; issue takes
+// {issue}, pull request {pr}, commit {commit}, compare {from} and {to},
+// and file {tag} and {path}. Empty templates keep the provider's layout.
+type URLTemplates struct {
+	Issue       string
+	PullRequest string
+	Commit      string
+	Compare     string
+	File        string
+}
+
+// layout is the URL scheme of the pages of a provider, relative to the
+// repository: issue and pr take the number, commit the sha, compare the
+// base and head refs, file the tag and the path.
+type layout struct {
+	host                             string
+	issue, pr, commit, compare, file string
+}
+
+var layouts = map[string]layout{
+	"github":    {"https://github.com", "/issues/%s", "/pull/%s", "/commit/%s", "/compare/%s...%s", "/blob/%s/%s"},
+	"gitlab":    {"https://gitlab.com", "/-/issues/%s", "/-/merge_requests/%s", "/-/commit/%s", "/-/compare/%s...%s", "/-/blob/%s/%s"},
+	"gitea":     {"https://gitea.com", "/issues/%s", "/pulls/%s", "/commit/%s", "/compare/%s...%s", "/src/tag/%s/%s"},
+	"bitbucket": {"https://bitbucket.org", "/issues/%s", "/pull-requests/%s", "/commits/%s", "/branches/compare/%[2]s%%0D%[1]s", "/src/%s/%s"},
+}
+
+// LinkProviders lists the providers whose URL layouts Links knows.
+var LinkProviders = []string{"github", "gitlab", "gitea", "bitbucket"}
+
+// NewLinks returns the Links for repo (an "owner/name" or GitLab project
+// path) on the public instance of provider.
+func NewLinks(provider, repo string) Links {
+	return NewLinksAt(provider, "", repo)
+}
+
+// NewLinksAt returns the Links for repo on the instance of provider at
+// baseURL, e.g. "https://gitlab.example.com"; empty means the public one.
+func NewLinksAt(provider, baseURL, repo string) Links {
+	if baseURL == "" {
+		baseURL = layoutOf(provider).host
+	}
+	return Links{RepoURL: strings.TrimSuffix(baseURL, "/") + "/" + strings.Trim(repo, "/"), Provider: provider}
+}
+
+func layoutOf(provider string) layout {
+	if l, ok := layouts[provider]; ok {
+		return l
+	}
+	return layouts["github"]
+}
+
+func (l Links) url(format string, args ...any) string {
+	return strings.TrimSuffix(l.RepoURL, "/") + fmt.Sprintf(format, args...)
+}
+
+// expand fills the placeholders of a URL template, given as pairs of a
+// placeholder and its value.
+func (l Links) expand(tmpl string, pairs ...string) string {
+	return strings.NewReplacer(append([]string{"{repo}", strings.TrimSuffix(l.RepoURL, "/")}, pairs...)...).Replace(tmpl)
+}
+
+// Issue returns the URL of issue n, or "" without a RepoURL.
+func (l Links) Issue(n string) string {
+	switch {
+	case l.RepoURL == "":
+		return ""
+	case l.Templates.Issue != "":
+		return l.expand(l.Templates.Issue, "{issue}", n)
+	}
+	return l.url(layoutOf(l.Provider).issue, n)
+}
+
+// PullRequest returns the URL of pull or merge request n, or "" without a
+// RepoURL.
+func (l Links) PullRequest(n string) string {
+	switch {
+	case l.RepoURL == "":
+		return ""
+	case l.Templates.PullRequest != "":
+		return l.expand(l.Templates.PullRequest, "{pr}", n)
+	}
+	return l.url(layoutOf(l.Provider).pr, n)
+}
+
+// Commit returns the URL of commit sha, or "" without a RepoURL.
+func (l Links) Commit(sha string) string {
+	switch {
+	case l.RepoURL == "":
+		return ""
+	case l.Templates.Commit != "":
+		return l.expand(l.Templates.Commit, "{commit}", sha)
+	}
+	return l.url(layoutOf(l.Provider).commit, sha)
+}
+
+// Compare returns the URL comparing two refs, or "" without a RepoURL or
+// a base ref.
+func (l Links) Compare(from, to string) string {
+	switch {
+	case l.RepoURL == "" || from == "":
+		return ""
+	case l.Templates.Compare != "":
+		return l.expand(l.Templates.Compare, "{from}", from, "{to}", to)
+	}
+	return l.url(layoutOf(l.Provider).compare, from, to)
+}
+
+// File returns the URL of the file at path, relative to the repository
+// root, as of tag, or "" without a RepoURL.
+func (l Links) File(tag, path string) string {
+	path = strings.TrimPrefix(path, "/")
+	switch {
+	case l.RepoURL == "":
+		return ""
+	case l.Templates.File != "":
+		return l.expand(l.Templates.File, "{tag}", tag, "{path}", path)
+	}
+	return l.url(layoutOf(l.Provider).file, tag, path)
+}
+
+// issueRef matches "#123" not already part of a word, URL or Markdown link.
+var issueRef = regexp.MustCompile(`(^|[^\w/&\[])#(\d+)\b`)
+
+// LinkIssues replaces issue references such as "#123" in s with Markdown
+// links. s is returned unchanged without a RepoURL.
+func (l Links) LinkIssues(s string) string {
+	if l.RepoURL == "" {
+		return s
+	}
+	return issueRef.ReplaceAllStringFunc(s, func(m string) string {
+		sub := issueRef.FindStringSubmatch(m)
+		return sub[1] + "[#" + sub[2] + "](" + l.Issue(sub[2]) + ")"
+	})
+}
+
+// LinkCommit returns the abbreviated sha as a Markdown link to the commit,
+// or just the abbreviation without a RepoURL.
+func (l Links) LinkCommit(sha string) string {
+	short := shortHash(sha)
+	if l.RepoURL == "" {
+		return short
+	}
+	return "[" + short + "](" + l.Commit(sha) + ")"
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
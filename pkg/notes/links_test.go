@@ -0,0 +1,166 @@
+package notes
+
+import "testing"
+
+func TestLinks(t *testing.T) {
+	tests := []struct {
+		links                       Links
+		issue, commit, compar, file string
+	}{
+		{
+			Links{RepoURL: "https://github.com/octo/app"},
+			"https://github.com/octo/app/issues/12",
+			"https://github.com/octo/app/commit/abc",
+			"https://github.com/octo/app/compare/v1.0.0...v1.1.0",
+			"https://github.com/octo/app/blob/v1.1.0/docs/upgrade/1.1.0.md",
+		},
+		{
+			Links{RepoURL: "https://gitlab.com/group/app/", Provider: "gitlab"},
+			"https://gitlab.com/group/app/-/issues/12",
+			"https://gitlab.com/group/app/-/commit/abc",
+			"https://gitlab.com/group/app/-/compare/v1.0.0...v1.1.0",
+			"https://gitlab.com/group/app/-/blob/v1.1.0/docs/upgrade/1.1.0.md",
+		},
+		{
+			Links{RepoURL: "https://codeberg.org/octo/app", Provider: "gitea"},
+			"https://codeberg.org/octo/app/issues/12",
+			"https://codeberg.org/octo/app/commit/abc",
+			"https://codeberg.org/octo/app/compare/v1.0.0...v1.1.0",
+			"https://codeberg.org/octo/app/src/tag/v1.1.0/docs/upgrade/1.1.0.md",
+		},
+		{
+			Links{RepoURL: "https://bitbucket.org/octo/app", Provider: "bitbucket"},
+			"https://bitbucket.org/octo/app/issues/12",
+			"https://bitbucket.org/octo/app/commits/abc",
+			"https://bitbucket.org/octo/app/branches/compare/v1.1.0%0Dv1.0.0",
+			"https://bitbucket.org/octo/app/src/v1.1.0/docs/upgrade/1.1.0.md",
+		},
+		{Links{}, "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.links.Issue("12"); got != tt.issue {
+			t.Errorf("Expected %q, got %q", tt.issue, got)
+		}
+		if got := tt.links.Commit("abc"); got != tt.commit {
+			t.Errorf("Expected %q, got %q", tt.commit, got)
+		}
+		if got := tt.links.Compare("v1.0.0", "v1.1.0"); got != tt.compar {
+			t.Errorf("Expected %q, got %q", tt.compar, got)
+		}
+		if got := tt.links.File("v1.1.0", "docs/upgrade/1.1.0.md"); got != tt.file {
+			t.Errorf("Expected %q, got %q", tt.file, got)
+		}
+	}
+}
+
+func TestLinksPullRequest(t *testing.T) {
+	tests := map[string]string{
+		"github":    "https://git.test/app/pull/7",
+		"gitlab":    "https://git.test/app/-/merge_requests/7",
+		"gitea":     "https://git.test/app/pulls/7",
+		"bitbucket": "https://git.test/app/pull-requests/7",
+	}
+	for provider, expected := range tests {
+		if got := (Links{RepoURL: "https://git.test/app", Provider: provider}).PullRequest("7"); got != expected {
+			t.Errorf("Expected %q for %s, got %q", expected, provider, got)
+		}
+	}
+	if got := (Links{}).PullRequest("7"); got != "" {
+		t.Errorf("Expected no URL without a repository, got %q", got)
+	}
+}
+
+func TestLinksTemplates(t *testing.T) {
+	l := Links{
+		RepoURL:  "https://git.example.com/cgit/app.git/",
+		Provider: "gitlab",
+		Templates: URLTemplates{
+			Commit:  "{repo}/commit/?id={commit}",
+			Compare: "{repo}/diff/?id={to}&id2={from}",
+			File:    "{repo}/tree/{path}?h={tag}",
+		},
+	}
+	tests := []struct{ got, expected string }{
+		{l.Commit("abc"), "https://git.example.com/cgit/app.git/commit/?id=abc"},
+		{l.Compare("v1.0.0", "v1.1.0"), "https://git.example.com/cgit/app.git/diff/?id=v1.1.0&id2=v1.0.0"},
+		{l.File("v1.1.0", "/docs/upgrade.md"), "https://git.example.com/cgit/app.git/tree/docs/upgrade.md?h=v1.1.0"},
+		{l.Issue("12"), "https://git.example.com/cgit/app.git/-/issues/12"},
+		{l.Compare("", "v1.1.0"), ""},
+		{l.LinkCommit("abcdef1234"), "[abcdef1](https://git.example.com/cgit/app.git/commit/?id=abcdef1234)"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, tt.got)
+		}
+	}
+
+	bb := Links{
+		RepoURL: "https://bitbucket.example.com/projects/OCTO/repos/app",
+		Templates: URLTemplates{
+			Issue:       "https://jira.example.com/browse/APP-{issue}",
+			PullRequest: "{repo}/pull-requests/{pr}/overview",
+		},
+	}
+	if got := bb.Issue("3"); got != "https://jira.example.com/browse/APP-3" {
+		t.Errorf("Expected the issue template, got %q", got)
+	}
+	if got := bb.PullRequest("4"); got != "https://bitbucket.example.com/projects/OCTO/repos/app/pull-requests/4/overview" {
+		t.Errorf("Expected the pull request template, got %q", got)
+	}
+	if got := (Links{Templates: bb.Templates}).Issue("3"); got != "" {
+		t.Errorf("Expected no URL without a repository, got %q", got)
+	}
+}
+
+func TestNewLinks(t *testing.T) {
+	if got := NewLinks("gitlab", "group/app").Issue("1"); got != "https://gitlab.com/group/app/-/issues/1" {
+		t.Errorf("Expected gitlab.com issue URL, got %q", got)
+	}
+	if got := NewLinks("github", "octo/app").RepoURL; got != "https://github.com/octo/app" {
+		t.Errorf("Expected github.com repository URL, got %q", got)
+	}
+}
+
+func TestNewLinksAt(t *testing.T) {
+	l := NewLinksAt("gitlab", "https://gitlab.example.com/", "group/app")
+	if got := l.Compare("v1.2.0", "v1.3.0"); got != "https://gitlab.example.com/group/app/-/compare/v1.2.0...v1.3.0" {
+		t.Errorf("Expected a self-hosted compare URL, got %q", got)
+	}
+	if got := NewLinksAt("bitbucket", "", "octo/app").RepoURL; got != "https://bitbucket.org/octo/app" {
+		t.Errorf("Expected bitbucket.org repository URL, got %q", got)
+	}
+}
+
+func TestLinkIssues(t *testing.T) {
+	l := Links{RepoURL: "https://github.com/octo/app"}
+
+	tests := map[string]string{
+		"fix crash (#12)":          "fix crash ([#12](https://github.com/octo/app/issues/12))",
+		"#3 and #4":                "[#3](https://github.com/octo/app/issues/3) and [#4](https://github.com/octo/app/issues/4)",
+		"see [#5](https://x/5)":    "see [#5](https://x/5)",
+		"anchor page#12 and &#39;": "anchor page#12 and &#39;",
+		"color #fff is not a ref":  "color #fff is not a ref",
+		"https://x.test/#12 stays": "https://x.test/#12 stays",
+	}
+	for in, expected := range tests {
+		if got := l.LinkIssues(in); got != expected {
+			t.Errorf("LinkIssues(%q): Expected %q, got %q", in, expected, got)
+		}
+	}
+
+	if got := (Links{}).LinkIssues("fix #12"); got != "fix #12" {
+		t.Errorf("Expected text unchanged without a RepoURL, got %q", got)
+	}
+}
+
+func TestLinkCommit(t *testing.T) {
+	l := Links{RepoURL: "https://github.com/octo/app"}
+	expected := "[abcdef1](https://github.com/octo/app/commit/abcdef1234)"
+	if got := l.LinkCommit("abcdef1234"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got := (Links{}).LinkCommit("abcdef1234"); got != "abcdef1" {
+		t.Errorf("Expected %q, got %q", "abcdef1", got)
+	}
+}
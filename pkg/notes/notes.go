@@ -0,0 +1,159 @@
+// Package notes renders release notes — the body of a provider release —
+// from a text/template.
+//
+// Templates are executed with a Data value, which carries the version
+// metadata, the conventional commits (flat and grouped as in the
+// changelog), the highlights, the dependency changes, the resolved
+// issues, the contributors, the commit stats and the compare URL. Besides
+// the changelog helpers, templates can call linkIssues, linkCommit,
+// issueURL, pullRequestURL, commitURL and compareURL, bound to the
+// repository's Links.
+package notes
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"maps"
+	"text/template"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/gomod"
+	"github.com/gbrennon/release_automation_golang/pkg/tracker"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// DefaultTemplate lists the highlights, if any, then the changes by group
+// with linked issues and commits, followed by the dependency changes, the
+// resolved issues, the contributors and the compare URL.
+//
+//go:embed default.tmpl
+var DefaultTemplate string
+
+// Metadata describes the release the notes are for.
+type Metadata struct {
+	Version     version.Version
+	Tag         string
+	PreviousTag string
+	Date        time.Time
+}
+
+// Contributor is a commit author or co-author within the release.
+type Contributor = contributors.Contributor
+
+// DependencyChange is a requirement of go.mod the release changed.
+type DependencyChange = gomod.Change
+
+// Issue is an issue of the tracker the commits of the release mention.
+type Issue = tracker.Issue
+
+// Data is the value templates are executed with.
+type Data struct {
+	Metadata
+	// Commits are the conventional commits in the release, newest first.
+	Commits []commits.Commit
+	// Groups are Commits grouped by changelog.DefaultSections.
+	Groups []changelog.Group
+	// Contributors are ordered by number of commits, then name, and
+	// exclude bots (see package contributors).
+	Contributors []Contributor
+	// Dependencies are the changes to the direct requirements of go.mod
+	// since PreviousTag, ordered by module path. NewData leaves them empty.
+	Dependencies []DependencyChange
+	// Highlights are the most significant changes, as picked by
+	// Highlights. NewData leaves them empty.
+	Highlights []Highlight
+	// Issues are the tracker issues the commits mention, in the order of
+	// their first mention, newest first. NewData leaves them empty.
+	Issues []Issue
+	// Stats counts the changes of the release. NewData leaves their sizes
+	// at zero.
+	Stats   Stats
+	RepoURL string
+	// CompareURL compares PreviousTag with Tag; it is empty for the first
+	// release or without a RepoURL.
+	CompareURL string
+	// UpgradeGuideURL links the upgrade guide of the release from its
+	// breaking changes. NewData leaves it empty.
+	UpgradeGuideURL string
+}
+
+// NewData builds the template data for m from the commits read from git.
+// Commits that are not conventional still count towards contributors.
+func NewData(m Metadata, raw []gitrepo.Commit, links Links) Data {
+	d := Data{
+		Metadata:     m,
+		Contributors: contributors.List(raw),
+		RepoURL:      links.RepoURL,
+		CompareURL:   links.Compare(m.PreviousTag, m.Tag),
+		Stats:        NewStats(raw, nil),
+	}
+	for _, rc := range raw {
+		c, err := commits.Parse(rc.Message)
+		if err != nil {
+			continue
+		}
+		c.Hash = rc.Hash
+		d.Commits = append(d.Commits, c)
+	}
+	d.Groups = changelog.New(m.Version.String(), m.Date, d.Commits, changelog.Options{}).Groups
+	return d
+}
+
+// Template is a parsed release notes template.
+type Template struct {
+	tmpl *template.Template
+}
+
+// FuncMap returns the helper functions available to notes templates: those
+// of changelog.FuncMap plus the link helpers bound to links.
+func FuncMap(links Links) template.FuncMap {
+	fm := maps.Clone(changelog.FuncMap)
+	fm["linkIssues"] = links.LinkIssues
+	fm["linkCommit"] = links.LinkCommit
+	fm["issueURL"] = links.Issue
+	fm["commitURL"] = links.Commit
+	fm["pullRequestURL"] = links.PullRequest
+	fm["compareURL"] = links.Compare
+	return fm
+}
+
+// New parses text as a notes template whose link helpers use links.
+func New(text string, links Links) (*Template, error) {
+	tmpl, err := template.New("notes").Funcs(FuncMap(links)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("notes: parse template: %w", err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Default returns the Template for DefaultTemplate.
+func Default(links Links) *Template {
+	t, err := New(DefaultTemplate, links)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Execute writes the notes for d to w.
+func (t *Template) Execute(w io.Writer, d Data) error {
+	if err := t.tmpl.Execute(w, d); err != nil {
+		return fmt.Errorf("notes: render %s: %w", d.Tag, err)
+	}
+	return nil
+}
+
+// RenderString renders the notes for d and returns them.
+func (t *Template) RenderString(d Data) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,172 @@
+package notes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/gomod"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+var testRaw = []gitrepo.Commit{
+	{Hash: "1111111aaaa", AuthorName: "Ada", AuthorEmail: "ada@example.com", Message: "feat(cli): add notes command (#7)"},
+	{Hash: "2222222bbbb", AuthorName: "Bob", AuthorEmail: "bob@example.com", Message: "fix: handle empty tags"},
+	{Hash: "3333333cccc", AuthorName: "Ada", AuthorEmail: "ADA@example.com", Message: "tidy up"},
+}
+
+var testMeta = Metadata{
+	Version:     version.MustParse("1.3.0"),
+	Tag:         "v1.3.0",
+	PreviousTag: "v1.2.0",
+	Date:        time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+}
+
+func TestNewData(t *testing.T) {
+	d := NewData(testMeta, testRaw, Links{RepoURL: "https://github.com/octo/app"})
+
+	if len(d.Commits) != 2 {
+		t.Fatalf("Expected 2 conventional commits, got %d", len(d.Commits))
+	}
+	if d.Commits[0].Hash != "1111111aaaa" {
+		t.Errorf("Expected the commit hash to be kept, got %q", d.Commits[0].Hash)
+	}
+	if len(d.Groups) != 2 || d.Groups[0].Title != "Features" {
+		t.Errorf("Expected Features and Bug Fixes groups, got %v", d.Groups)
+	}
+
+	expected := []Contributor{
+		{Name: "Ada", Email: "ada@example.com", Commits: 2},
+		{Name: "Bob", Email: "bob@example.com", Commits: 1},
+	}
+	if !reflect.DeepEqual(d.Contributors, expected) {
+		t.Errorf("Expected %v, got %v", expected, d.Contributors)
+	}
+	if d.CompareURL != "https://github.com/octo/app/compare/v1.2.0...v1.3.0" {
+		t.Errorf("Expected a compare URL, got %q", d.CompareURL)
+	}
+}
+
+func TestNewDataFirstRelease(t *testing.T) {
+	m := testMeta
+	m.PreviousTag = ""
+	d := NewData(m, testRaw, Links{RepoURL: "https://github.com/octo/app"})
+	if d.CompareURL != "" {
+		t.Errorf("Expected no compare URL for the first release, got %q", d.CompareURL)
+	}
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	links := Links{RepoURL: "https://github.com/octo/app"}
+	got, err := Default(links).RenderString(NewData(testMeta, testRaw, links))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `### Features
+
+- **cli:** add notes command ([#7](https://github.com/octo/app/issues/7)) ([1111111](https://github.com/octo/app/commit/1111111aaaa))
+
+### Bug Fixes
+
+- handle empty tags ([2222222](https://github.com/octo/app/commit/2222222bbbb))
+
+### Contributors
+
+- Ada
+- Bob
+
+**Full Changelog**: https://github.com/octo/app/compare/v1.2.0...v1.3.0
+`
+	if got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestDefaultTemplateDependencies(t *testing.T) {
+	data := NewData(testMeta, testRaw[1:2], Links{})
+	data.Contributors = nil
+	data.Dependencies = []DependencyChange{
+		{Path: "golang.org/x/sync", Kind: gomod.Upgraded, Old: "v0.21.0", New: "v0.22.0"},
+		{Path: "golang.org/x/term", Kind: gomod.Added, New: "v0.45.0"},
+		{Path: "gopkg.in/yaml.v3", Kind: gomod.Removed, Old: "v3.0.1"},
+	}
+	got, err := Default(Links{}).RenderString(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "### Bug Fixes\n\n- handle empty tags (2222222)\n\n" +
+		"### Dependency changes\n\n" +
+		"- Upgraded `golang.org/x/sync` from v0.21.0 to v0.22.0\n" +
+		"- Added `golang.org/x/term` v0.45.0\n" +
+		"- Removed `gopkg.in/yaml.v3` v3.0.1\n\n"
+	if got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestDefaultTemplateIssues(t *testing.T) {
+	data := NewData(testMeta, testRaw[1:2], Links{})
+	data.Contributors = nil
+	data.Issues = []Issue{
+		{Key: "PROJ-7", Summary: "Empty tags crash the release", Status: "Done", URL: "https://example.atlassian.net/browse/PROJ-7"},
+		{Key: "PROJ-9", Summary: "Tags without a prefix"},
+	}
+	got, err := Default(Links{}).RenderString(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "### Bug Fixes\n\n- handle empty tags (2222222)\n\n" +
+		"### Resolved issues\n\n" +
+		"- [PROJ-7](https://example.atlassian.net/browse/PROJ-7) Empty tags crash the release (Done)\n" +
+		"- PROJ-9 Tags without a prefix\n\n"
+	if got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestDefaultTemplateUpgradeGuide(t *testing.T) {
+	raw := []gitrepo.Commit{{Hash: "4444444dddd", Message: "feat!: drop Go 1.24"}}
+	data := NewData(testMeta, raw, Links{})
+	data.Contributors = nil
+	data.UpgradeGuideURL = "https://github.com/octo/app/blob/v1.3.0/docs/upgrade/1.3.0.md"
+	got, err := Default(Links{}).RenderString(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "### Breaking Changes\n\n- drop Go 1.24 (4444444)\n\n" +
+		"See the [upgrade guide](https://github.com/octo/app/blob/v1.3.0/docs/upgrade/1.3.0.md) for what to change.\n\n"
+	if got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestCustomTemplate(t *testing.T) {
+	links := Links{RepoURL: "https://gitlab.com/g/app", Provider: "gitlab"}
+	tmpl, err := New(`{{ .Tag }} ({{ .Version.Minor }}){{ range .Commits }} {{ upper .Type }}:{{ commitURL .Hash }}{{ end }}`, links)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := tmpl.RenderString(NewData(testMeta, testRaw[1:2], links))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "v1.3.0 (3) FIX:https://gitlab.com/g/app/-/commit/2222222bbbb"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	if _, err := New("{{ .Tag", Links{}); err == nil || !strings.HasPrefix(err.Error(), "notes: parse template") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+
+	tmpl, _ := New("{{ .Missing }}", Links{})
+	if _, err := tmpl.RenderString(Data{}); err == nil {
+		t.Error("Expected an execution error for an unknown field")
+	}
+}
@@ -0,0 +1,95 @@
+package notes
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+// Stats counts the changes of a release.
+type Stats struct {
+	// Commits counts every commit, conventional or not.
+	Commits int
+	// Features, Fixes and Breaking count the conventional commits of type
+	// feat, of type fix, and marking a breaking change.
+	Features int
+	Fixes    int
+	Breaking int
+	// Files, Insertions and Deletions sum the diff stats of the commits,
+	// so a file changed twice counts twice. They are zero when the sizes
+	// of the commits are not known.
+	Files      int
+	Insertions int
+	Deletions  int
+}
+
+// NewStats counts the changes of raw. Sizes are the diff stats of raw by
+// hash, as for Highlights, and may be nil.
+func NewStats(raw []gitrepo.Commit, sizes map[string]gitrepo.DiffStat) Stats {
+	s := Stats{Commits: len(raw)}
+	for _, rc := range raw {
+		st := sizes[rc.Hash]
+		s.Files += st.Files
+		s.Insertions += st.Insertions
+		s.Deletions += st.Deletions
+
+		c, err := commits.Parse(rc.Message)
+		if err != nil {
+			continue
+		}
+		switch c.Type {
+		case "feat":
+			s.Features++
+		case "fix":
+			s.Fixes++
+		}
+		if c.Breaking {
+			s.Breaking++
+		}
+	}
+	return s
+}
+
+// TitleData is the value Title templates are executed with: Data, with
+// Date formatted as YYYY-MM-DD so that "{{.Date}}" reads well. The time is
+// still available as .Time.
+type TitleData struct {
+	Data
+	Date string
+	Time time.Time
+}
+
+// Title is a parsed template for a short text about a release, such as an
+// annotated tag message ("MyApp {{.Tag}}") or a provider release title
+// ("MyApp v{{.Version}} — {{.Date}}"). It has the helpers of changelog
+// templates, but no links.
+type Title struct {
+	tmpl *template.Template
+}
+
+// NewTitle parses text as a Title template.
+func NewTitle(text string) (*Title, error) {
+	tmpl, err := template.New("title").Funcs(FuncMap(Links{})).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("notes: parse title template: %w", err)
+	}
+	return &Title{tmpl: tmpl}, nil
+}
+
+// Render executes t for d and returns the result without surrounding white
+// space. It is an error for the result to be empty.
+func (t *Title) Render(d Data) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, TitleData{Data: d, Date: d.Date.Format(time.DateOnly), Time: d.Date}); err != nil {
+		return "", fmt.Errorf("notes: render title of %s: %w", d.Tag, err)
+	}
+	s := strings.TrimSpace(b.String())
+	if s == "" {
+		return "", fmt.Errorf("notes: the title of %s is empty", d.Tag)
+	}
+	return s, nil
+}
@@ -0,0 +1,63 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+func TestNewStats(t *testing.T) {
+	raw := append(testRaw, gitrepo.Commit{Hash: "4444444dddd", Message: "feat!: drop v1 API"})
+	sizes := map[string]gitrepo.DiffStat{
+		"1111111aaaa": {Files: 2, Insertions: 40, Deletions: 3},
+		"4444444dddd": {Files: 5, Insertions: 1, Deletions: 200},
+	}
+
+	expected := Stats{Commits: 4, Features: 2, Fixes: 1, Breaking: 1, Files: 7, Insertions: 41, Deletions: 203}
+	if got := NewStats(raw, sizes); got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if got := NewData(testMeta, testRaw, Links{}).Stats; got != (Stats{Commits: 3, Features: 1, Fixes: 1}) {
+		t.Errorf("Expected the counts without sizes, got %+v", got)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	d := NewData(testMeta, testRaw, Links{})
+	d.Highlights = Highlights(testRaw, nil, 1)
+
+	tests := map[string]string{
+		"MyApp v{{.Version}} — {{.Date}}":                            "MyApp v1.3.0 — 2024-05-01",
+		"{{.Tag}} ({{.Time.Format \"Jan 2006\"}})":                   "v1.3.0 (May 2024)",
+		"{{.Stats.Features}} features, {{.Stats.Fixes}} fixes\n":     "1 features, 1 fixes",
+		"{{range .Highlights}}{{.Description | upper}}{{end}}":       "ADD NOTES COMMAND (#7)",
+		"since {{.PreviousTag}}, {{len .Contributors}} contributors": "since v1.2.0, 2 contributors",
+	}
+	for text, expected := range tests {
+		title, err := NewTitle(text)
+		if err != nil {
+			t.Fatalf("NewTitle(%q): unexpected error: %v", text, err)
+		}
+		got, err := title.Render(d)
+		if err != nil || got != expected {
+			t.Errorf("Render(%q): Expected %q, got %q and %v", text, expected, got, err)
+		}
+	}
+}
+
+func TestTitleErrors(t *testing.T) {
+	if _, err := NewTitle("{{.Tag"); err == nil {
+		t.Errorf("Expected a parse error")
+	}
+	d := NewData(testMeta, testRaw, Links{})
+	for _, text := range []string{"{{.Nope}}", "{{if false}}x{{end}}  "} {
+		title, err := NewTitle(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := title.Render(d); err == nil || !strings.Contains(err.Error(), "v1.3.0") {
+			t.Errorf("Render(%q): Expected an error naming the tag, got %v", text, err)
+		}
+	}
+}
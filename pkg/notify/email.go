@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email sends the event summary as a plain-text mail over SMTP.
+type Email struct {
+	// Addr is the SMTP server as host:port.
+	Addr string
+	From string
+	To   []string
+	// Username and Password enable PLAIN authentication when set.
+	Username string
+	Password string
+
+	// send defaults to sendMail; tests replace it.
+	send func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail returns an Email notifier sending from from to the to addresses
+// through the SMTP server at addr.
+func NewEmail(addr, from string, to ...string) (*Email, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("notify: invalid SMTP address %q: %w", addr, err)
+	}
+	if from == "" || len(to) == 0 {
+		return nil, errors.New("notify: email needs a sender and at least one recipient")
+	}
+	return &Email{Addr: addr, From: from, To: to, send: sendMail}, nil
+}
+
+func newEmailFromTarget(t Target) (Notifier, error) {
+	e, err := NewEmail(t.Addr, t.From, t.To...)
+	if err != nil {
+		return nil, err
+	}
+	e.Username, e.Password = t.Username, t.Password
+	return e, nil
+}
+
+// Message returns the RFC 5322 message sent for ev.
+func (e *Email) Message(ev Event) []byte {
+	date := ev.Time
+	if date.IsZero() {
+		date = time.Now()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", ev.Summary())
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(ev.Summary())
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// Notify sends the message. Cancelling ctx aborts the SMTP conversation.
+func (e *Email) Notify(ctx context.Context, ev Event) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, _ := net.SplitHostPort(e.Addr)
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	send := e.send
+	if send == nil {
+		send = sendMail
+	}
+	return send(ctx, e.Addr, auth, e.From, e.To, e.Message(ev))
+}
+
+// sendMail is smtp.SendMail with a context: the connection is dialled with
+// ctx and closed when ctx is done.
+func sendMail(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(addr)
+	err = func() error {
+		c, err := smtp.NewClient(conn, host)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		defer c.Close()
+
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return err
+			}
+		}
+		if a != nil {
+			if ok, _ := c.Extension("AUTH"); !ok {
+				return errors.New("smtp: server doesn't support AUTH")
+			}
+			if err := c.Auth(a); err != nil {
+				return err
+			}
+		}
+		if err := c.Mail(from); err != nil {
+			return err
+		}
+		for _, addr := range to {
+			if err := c.Rcpt(addr); err != nil {
+				return err
+			}
+		}
+		w, err := c.Data()
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(msg); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		return c.Quit()
+	}()
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmail(t *testing.T) {
+	n, err := New(Target{Type: "email", Addr: "smtp.example.com:587", From: "release@example.com", To: []string{"dev@example.com", "ops@example.com"}, Username: "bot", Password: "pw"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := n.(*Email)
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	var gotAuth smtp.Auth
+	e.send = func(_ context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo, gotMsg = addr, a, from, to, msg
+		return nil
+	}
+
+	ev := Event{Status: Success, Tag: "v1.0.0", Time: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	if err := e.Notify(context.Background(), ev); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotAddr != "smtp.example.com:587" || gotFrom != "release@example.com" || len(gotTo) != 2 || gotAuth == nil {
+		t.Errorf("Expected mail to be sent with auth, got %s %s %v %v", gotAddr, gotFrom, gotTo, gotAuth)
+	}
+	msg := string(gotMsg)
+	for _, want := range []string{
+		"To: dev@example.com, ops@example.com\r\n",
+		"Subject: Released v1.0.0\r\n",
+		"Date: Sun, 01 Mar 2026 12:00:00 +0000\r\n",
+		"\r\n\r\nReleased v1.0.0\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected message to contain %q, got:\n%s", want, msg)
+		}
+	}
+}
+
+func TestNewEmailErrors(t *testing.T) {
+	if _, err := NewEmail("smtp.example.com", "a@example.com", "b@example.com"); err == nil {
+		t.Error("Expected an error for an address without a port")
+	}
+	if _, err := NewEmail("smtp.example.com:25", "a@example.com"); err == nil {
+		t.Error("Expected an error without recipients")
+	}
+}
+
+func TestSendMail(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ln.Close()
+
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 test\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			got = append(got, line)
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				fmt.Fprint(conn, "250 test\r\n")
+			case line == "DATA":
+				fmt.Fprint(conn, "354 go ahead\r\n")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+				}
+				fmt.Fprint(conn, "250 queued\r\n")
+			case line == "QUIT":
+				fmt.Fprint(conn, "221 bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "250 ok\r\n")
+			}
+		}
+	}()
+
+	err = sendMail(context.Background(), ln.Addr().String(), nil, "a@example.com", []string{"b@example.com"}, []byte("Subject: x\r\n\r\nbody\r\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-done
+	expected := []string{"EHLO localhost", "MAIL FROM:<a@example.com>", "RCPT TO:<b@example.com>", "DATA", "QUIT"}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestSendMailCancel(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ln.Close()
+	// The server accepts but never greets, so only cancellation ends the
+	// conversation.
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = sendMail(ctx, ln.Addr().String(), nil, "a@example.com", []string{"b@example.com"}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package notify announces the outcome of a release through pluggable
+// notifiers: Slack and Discord incoming webhooks, generic JSON webhooks and
+// email over SMTP.
+//
+// Anything implementing Notifier can be added to a Dispatcher. Types that
+// should be constructible from configuration are made known with Register;
+// the built-in ones are registered as "slack", "discord", "webhook" and
+// "email".
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+)
+
+// Status is the outcome of a release.
+type Status string
+
+const (
+	Success Status = "success"
+	Failure Status = "failure"
+)
+
+// Event describes a finished release attempt.
+type Event struct {
+	Status Status
+	// Project names what was released, e.g. the repository or module.
+	Project string
+	Tag     string
+	// URLs are the web pages of the releases created, one per provider.
+	URLs []string
+	// Err is the error a failed release stopped with.
+	Err  error
+	Time time.Time
+}
+
+// Summary returns a one-line, human-readable description of e.
+func (e Event) Summary() string {
+	name := e.Tag
+	if e.Project != "" {
+		name = e.Project + " " + e.Tag
+	}
+	if e.Status == Failure {
+		if e.Err != nil {
+			return fmt.Sprintf("Release %s failed: %v", name, e.Err)
+		}
+		return fmt.Sprintf("Release %s failed", name)
+	}
+	if len(e.URLs) > 0 {
+		return fmt.Sprintf("Released %s: %s", name, strings.Join(e.URLs, " "))
+	}
+	return "Released " + name
+}
+
+// Notifier delivers release events somewhere.
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// Func adapts a function to the Notifier interface.
+type Func func(ctx context.Context, e Event) error
+
+func (f Func) Notify(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// Target configures a notifier. Only the fields relevant to Type are used.
+type Target struct {
+	// Type selects the registered Factory, e.g. "slack".
+	Type string
+	// URL is the webhook URL for slack, discord and webhook.
+	URL string
+	// Addr is the SMTP server as host:port for email.
+	Addr     string
+	From     string
+	To       []string
+	Username string
+	Password string
+}
+
+// Factory builds a Notifier for a Target.
+type Factory func(t Target) (Notifier, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{
+		"slack":   func(t Target) (Notifier, error) { return NewSlack(t.URL) },
+		"discord": func(t Target) (Notifier, error) { return NewDiscord(t.URL) },
+		"webhook": func(t Target) (Notifier, error) { return NewWebhook(t.URL) },
+		"email":   newEmailFromTarget,
+	}
+)
+
+// Register makes a notifier type available to New. Registering a type
+// twice replaces the previous factory.
+func Register(typ string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[typ] = f
+}
+
+// Types returns the registered notifier types, sorted.
+func Types() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	types := make([]string, 0, len(factories))
+	for t := range factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New builds the Notifier for t using the Factory registered for t.Type.
+func New(t Target) (Notifier, error) {
+	mu.RLock()
+	f, ok := factories[t.Type]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("notify: unknown type %q: must be one of %s", t.Type, strings.Join(Types(), ", "))
+	}
+	return f(t)
+}
+
+type entry struct {
+	name     string
+	notifier Notifier
+	on       []Status
+}
+
+// Dispatcher sends events to several notifiers, each subscribed to some
+// statuses.
+type Dispatcher struct {
+	entries []entry
+
+	// DryRun describes the notifications on Log instead of sending them.
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every notification sent; nil
+	// discards.
+	Logger *slog.Logger
+}
+
+// Add subscribes n, described by name in errors and dry-run output, to
+// events with one of the statuses on; with none, to every event.
+func (d *Dispatcher) Add(name string, n Notifier, on ...Status) {
+	d.entries = append(d.entries, entry{name: name, notifier: n, on: on})
+}
+
+// Len returns the number of notifiers added.
+func (d *Dispatcher) Len() int {
+	return len(d.entries)
+}
+
+// Notify sends e to every subscribed notifier. A failing notifier does not
+// prevent the others from running; all failures are returned joined.
+func (d *Dispatcher) Notify(ctx context.Context, e Event) error {
+	var errs []error
+	for _, en := range d.entries {
+		if len(en.on) > 0 && !slices.Contains(en.on, e.Status) {
+			continue
+		}
+		if d.DryRun {
+			dryrun.Printf(d.Log, "would notify %s: %s", en.name, e.Summary())
+			continue
+		}
+		if err := en.notifier.Notify(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("notify: %s: %w", en.name, err))
+			continue
+		}
+		log.Or(d.Logger).Debug("notified", "notifier", en.name, "status", string(e.Status))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		e        Event
+		expected string
+	}{
+		{Event{Status: Success, Tag: "v1.2.0"}, "Released v1.2.0"},
+		{Event{Status: Success, Project: "octo/app", Tag: "v1.2.0", URLs: []string{"https://x/r/1"}}, "Released octo/app v1.2.0: https://x/r/1"},
+		{Event{Status: Failure, Tag: "v1.2.0", Err: errors.New("push rejected")}, "Release v1.2.0 failed: push rejected"},
+		{Event{Status: Failure, Tag: "v1.2.0"}, "Release v1.2.0 failed"},
+	}
+	for _, tt := range tests {
+		if got := tt.e.Summary(); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	var got []string
+	record := func(name string) Notifier {
+		return Func(func(ctx context.Context, e Event) error {
+			got = append(got, name+":"+string(e.Status))
+			return nil
+		})
+	}
+
+	var d Dispatcher
+	d.Add("all", record("all"))
+	d.Add("failures", record("failures"), Failure)
+	d.Add("broken", Func(func(ctx context.Context, e Event) error { return errors.New("boom") }), Success)
+	d.Add("after", record("after"))
+
+	err := d.Notify(context.Background(), Event{Status: Success, Tag: "v1"})
+	if err == nil || !strings.Contains(err.Error(), "notify: broken: boom") {
+		t.Errorf("Expected the broken notifier's error, got %v", err)
+	}
+	if !slices.Equal(got, []string{"all:success", "after:success"}) {
+		t.Errorf("Expected success notifications to all and after, got %v", got)
+	}
+
+	got = nil
+	if err := d.Notify(context.Background(), Event{Status: Failure, Tag: "v1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got, []string{"all:failure", "failures:failure", "after:failure"}) {
+		t.Errorf("Expected failure notifications, got %v", got)
+	}
+	if d.Len() != 4 {
+		t.Errorf("Expected 4 notifiers, got %d", d.Len())
+	}
+}
+
+func TestDispatcherDryRun(t *testing.T) {
+	var log bytes.Buffer
+	d := Dispatcher{DryRun: true, Log: &log}
+	d.Add("slack", Func(func(ctx context.Context, e Event) error {
+		t.Error("Expected no notification in dry-run mode")
+		return nil
+	}))
+
+	if err := d.Notify(context.Background(), Event{Status: Success, Tag: "v1.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if log.String() != "[dry-run] would notify slack: Released v1.0.0\n" {
+		t.Errorf("Expected a dry-run line, got %q", log.String())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	called := false
+	Register("test-custom", func(tg Target) (Notifier, error) {
+		called = tg.URL == "custom://x"
+		return Func(func(context.Context, Event) error { return nil }), nil
+	})
+
+	if _, err := New(Target{Type: "test-custom", URL: "custom://x"}); err != nil || !called {
+		t.Errorf("Expected the registered factory to be used, got %v", err)
+	}
+	if !slices.Contains(Types(), "test-custom") || !slices.Contains(Types(), "slack") {
+		t.Errorf("Expected custom and built-in types, got %v", Types())
+	}
+
+	if _, err := New(Target{Type: "pigeon"}); err == nil || !strings.Contains(err.Error(), "unknown type") {
+		t.Errorf("Expected an unknown type error, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Slack posts the event summary to a Slack incoming webhook.
+type Slack struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewSlack returns a Slack notifier for the incoming webhook URL.
+func NewSlack(webhookURL string) (*Slack, error) {
+	if err := checkURL(webhookURL); err != nil {
+		return nil, err
+	}
+	return &Slack{URL: webhookURL}, nil
+}
+
+func (s *Slack) Notify(ctx context.Context, e Event) error {
+	return postJSON(ctx, s.HTTPClient, s.URL, map[string]string{"text": e.Summary()})
+}
+
+// Discord posts the event summary to a Discord webhook.
+type Discord struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewDiscord returns a Discord notifier for the webhook URL.
+func NewDiscord(webhookURL string) (*Discord, error) {
+	if err := checkURL(webhookURL); err != nil {
+		return nil, err
+	}
+	return &Discord{URL: webhookURL}, nil
+}
+
+func (d *Discord) Notify(ctx context.Context, e Event) error {
+	return postJSON(ctx, d.HTTPClient, d.URL, map[string]string{"content": e.Summary()})
+}
+
+// Webhook posts the whole event as JSON (see Payload) to an arbitrary URL.
+type Webhook struct {
+	URL string
+	// Header is added to every request, e.g. for authentication.
+	Header     http.Header
+	HTTPClient *http.Client
+}
+
+// NewWebhook returns a Webhook notifier for url.
+func NewWebhook(webhookURL string) (*Webhook, error) {
+	if err := checkURL(webhookURL); err != nil {
+		return nil, err
+	}
+	return &Webhook{URL: webhookURL}, nil
+}
+
+// Payload is the JSON body sent by Webhook.
+type Payload struct {
+	Status  Status   `json:"status"`
+	Project string   `json:"project,omitempty"`
+	Tag     string   `json:"tag"`
+	URLs    []string `json:"urls,omitempty"`
+	Error   string   `json:"error,omitempty"`
+	Time    string   `json:"time,omitempty"`
+	Summary string   `json:"summary"`
+}
+
+// NewPayload converts e to its JSON representation.
+func NewPayload(e Event) Payload {
+	p := Payload{
+		Status:  e.Status,
+		Project: e.Project,
+		Tag:     e.Tag,
+		URLs:    e.URLs,
+		Summary: e.Summary(),
+	}
+	if e.Err != nil {
+		p.Error = e.Err.Error()
+	}
+	if !e.Time.IsZero() {
+		p.Time = e.Time.UTC().Format(time.RFC3339)
+	}
+	return p
+}
+
+func (w *Webhook) Notify(ctx context.Context, e Event) error {
+	return post(ctx, w.HTTPClient, w.URL, w.Header, NewPayload(e))
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, body any) error {
+	return post(ctx, client, endpoint, nil, body)
+}
+
+func post(ctx context.Context, client *http.Client, endpoint string, header http.Header, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("POST %s: %s: %s", redact(endpoint), resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func checkURL(raw string) error {
+	if raw == "" {
+		return errors.New("notify: webhook URL is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("notify: invalid webhook URL %q", redact(raw))
+	}
+	return nil
+}
+
+// redact strips the path and query of a webhook URL, which carry its
+// secret, for use in error messages.
+func redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "<invalid URL>"
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newServer records the body and headers of the last request.
+func newServer(t *testing.T, status int) (*httptest.Server, *string, *http.Header) {
+	t.Helper()
+	var body string
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, header = string(b), r.Header
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &body, &header
+}
+
+func TestSlackAndDiscord(t *testing.T) {
+	srv, body, _ := newServer(t, http.StatusOK)
+	e := Event{Status: Success, Tag: "v1.0.0"}
+
+	for typ, expected := range map[string]string{
+		"slack":   `{"text":"Released v1.0.0"}`,
+		"discord": `{"content":"Released v1.0.0"}`,
+	} {
+		n, err := New(Target{Type: typ, URL: srv.URL + "/hook"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := n.Notify(context.Background(), e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *body != expected {
+			t.Errorf("%s: Expected %s, got %s", typ, expected, *body)
+		}
+	}
+}
+
+func TestWebhookPayload(t *testing.T) {
+	srv, body, header := newServer(t, http.StatusNoContent)
+	w, err := NewWebhook(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Header = http.Header{"Authorization": {"Bearer s3cret"}}
+
+	e := Event{
+		Status: Failure,
+		Tag:    "v2.0.0",
+		Err:    errors.New("upload failed"),
+		Time:   time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := w.Notify(context.Background(), e); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var p Payload
+	if err := json.Unmarshal([]byte(*body), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Payload{Status: Failure, Tag: "v2.0.0", Error: "upload failed", Time: "2026-03-01T12:00:00Z", Summary: "Release v2.0.0 failed: upload failed"}
+	if p.Status != expected.Status || p.Error != expected.Error || p.Time != expected.Time || p.Summary != expected.Summary {
+		t.Errorf("Expected %+v, got %+v", expected, p)
+	}
+	if header.Get("Authorization") != "Bearer s3cret" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected auth and JSON headers, got %v", *header)
+	}
+}
+
+func TestWebhookErrorRedactsURL(t *testing.T) {
+	srv, _, _ := newServer(t, http.StatusForbidden)
+	s, _ := NewSlack(srv.URL + "/services/T000/B000/secret")
+
+	err := s.Notify(context.Background(), Event{Status: Success, Tag: "v1"})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Expected a 403 error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected the webhook secret to be redacted, got %v", err)
+	}
+}
+
+func TestInvalidWebhookURL(t *testing.T) {
+	for _, u := range []string{"", "hooks.slack.com/x", "ftp://x/y"} {
+		if _, err := NewSlack(u); err == nil {
+			t.Errorf("NewSlack(%q): Expected an error", u)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"context"
+
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// Publisher returns a publish.Publisher backed by the plugin. repo is sent
+// along with every release.
+func (c *Client) Publisher(repo string) publish.Publisher {
+	return &publisher{c: c, repo: repo}
+}
+
+type publisher struct {
+	c    *Client
+	repo string
+}
+
+func (p *publisher) Publish(ctx context.Context, r publish.Release) (publish.Result, error) {
+	var res PublishResult
+	err := p.c.Call(ctx, MethodPublish, PublishParams{
+		Tag:        r.Tag,
+		Name:       r.Title(),
+		Body:       r.Body,
+		Draft:      r.Draft,
+		Prerelease: r.Prerelease,
+		Assets:     r.Assets,
+		Milestones: r.Milestones,
+		Repo:       p.repo,
+	}, &res)
+	return publish.Result{ID: res.ID, URL: res.URL, Existing: res.Existing, Uploaded: res.Uploaded}, err
+}
+
+// Notifier returns a notify.Notifier backed by the plugin, which receives
+// events as notify.Payload.
+func (c *Client) Notifier() notify.Notifier {
+	return notify.Func(func(ctx context.Context, e notify.Event) error {
+		return c.Call(ctx, MethodNotify, notify.NewPayload(e), nil)
+	})
+}
+
+// VersionSource lists versions released outside git.
+type VersionSource interface {
+	Versions(ctx context.Context) ([]version.Version, error)
+}
+
+// VersionSource returns a VersionSource backed by the plugin. Entries that
+// are not semantic versions are skipped.
+func (c *Client) VersionSource() VersionSource {
+	return versionSource{c}
+}
+
+type versionSource struct{ c *Client }
+
+func (s versionSource) Versions(ctx context.Context) ([]version.Version, error) {
+	var res VersionsResult
+	if err := s.c.Call(ctx, MethodVersions, nil, &res); err != nil {
+		return nil, err
+	}
+	var vs []version.Version
+	for _, raw := range res.Versions {
+		if v, err := version.Parse(raw); err == nil {
+			vs = append(vs, v)
+		}
+	}
+	return vs, nil
+}
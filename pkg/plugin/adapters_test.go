@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// pipe connects a Client to ServeConn running h in the same process.
+func pipe(t *testing.T, h Handlers) *Client {
+	t.Helper()
+	hostR, pluginW := io.Pipe()
+	pluginR, hostW := io.Pipe()
+	go func() {
+		ServeConn(context.Background(), pluginR, pluginW, Info{Name: "pipe"}, h)
+		pluginW.Close()
+	}()
+	c := NewClient(hostR, hostW)
+	t.Cleanup(func() { c.Close() })
+	if _, err := c.Info(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return c
+}
+
+func TestPublisher(t *testing.T) {
+	var got PublishParams
+	c := pipe(t, Handlers{Publish: func(_ context.Context, p PublishParams) (PublishResult, error) {
+		got = p
+		return PublishResult{ID: "7", URL: "https://example.com/r/7", Uploaded: p.Assets}, nil
+	}})
+
+	res, err := c.Publisher("o/r").Publish(context.Background(), publish.Release{
+		Tag:        "v1.2.0",
+		Body:       "notes",
+		Prerelease: true,
+		Assets:     []string{"dist/app"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Name != "Release v1.2.0" || got.Repo != "o/r" || !got.Prerelease || got.Body != "notes" {
+		t.Errorf("Expected release params, got %+v", got)
+	}
+	if res.ID != "7" || res.URL != "https://example.com/r/7" || !slices.Equal(res.Uploaded, []string{"dist/app"}) {
+		t.Errorf("Expected result from plugin, got %+v", res)
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	var got notify.Payload
+	c := pipe(t, Handlers{Notify: func(_ context.Context, p notify.Payload) error {
+		got = p
+		return nil
+	}})
+
+	e := notify.Event{Status: notify.Success, Tag: "v1.2.0", Time: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	if err := c.Notifier().Notify(context.Background(), e); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Tag != "v1.2.0" || got.Status != notify.Success || got.Time != "2024-05-01T00:00:00Z" {
+		t.Errorf("Expected payload for event, got %+v", got)
+	}
+}
+
+func TestVersionSource(t *testing.T) {
+	c := pipe(t, Handlers{Versions: func(context.Context) ([]string, error) {
+		return []string{"v1.0.0", "latest", "1.1.0-rc.1"}, nil
+	}})
+
+	vs, err := c.VersionSource().Versions(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var got []string
+	for _, v := range vs {
+		got = append(got, v.String())
+	}
+	if !slices.Equal(got, []string{"1.0.0", "1.1.0-rc.1"}) {
+		t.Errorf("Expected parsed versions, got %v", got)
+	}
+}
@@ -0,0 +1,202 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"syscall"
+)
+
+// ErrClosed is returned by Call after Close or after the plugin exited.
+var ErrClosed = errors.New("plugin: closed")
+
+// Client is the host side of a connection to one plugin.
+type Client struct {
+	mu     sync.Mutex
+	w      io.WriteCloser
+	r      *bufio.Reader
+	nextID uint64
+	closed bool
+	info   Info
+
+	cmd *exec.Cmd
+}
+
+// Start runs the plugin executable path with args and performs the info
+// handshake. The plugin's standard error is copied to stderr, which may be
+// nil to discard it.
+func Start(ctx context.Context, stderr io.Writer, path string, args ...string) (*Client, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin: start %s: %w", path, err)
+	}
+
+	c := NewClient(stdout, stdin)
+	c.cmd = cmd
+	if err := c.handshake(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("plugin: %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// NewClient returns a Client exchanging messages over r and w, for plugins
+// that are not separate processes. Call Info to perform the handshake.
+func NewClient(r io.Reader, w io.WriteCloser) *Client {
+	return &Client{w: w, r: bufio.NewReader(r)}
+}
+
+func (c *Client) handshake(ctx context.Context) error {
+	var info Info
+	if err := c.Call(ctx, MethodInfo, nil, &info); err != nil {
+		return err
+	}
+	if info.Name == "" {
+		return errors.New("info: plugin has no name")
+	}
+	c.info = info
+	return nil
+}
+
+// Info returns the plugin's self-description, performing the handshake if
+// it has not happened yet.
+func (c *Client) Info(ctx context.Context) (Info, error) {
+	if c.info.Name == "" {
+		if err := c.handshake(ctx); err != nil {
+			return Info{}, err
+		}
+	}
+	return c.info, nil
+}
+
+// Has reports whether the plugin declared capability.
+func (c *Client) Has(capability string) bool {
+	return slices.Contains(c.info.Capabilities, capability)
+}
+
+// Call invokes method with params and decodes the result into result,
+// which may be nil. Calls are serialised. Cancelling ctx abandons the wait
+// for the response and closes the connection, since the stream can no
+// longer be trusted.
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+
+	c.nextID++
+	id := c.nextID
+	req := request{JSONRPC: "2.0", ID: &id, Method: method}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("plugin: %s: %w", method, err)
+		}
+		req.Params = b
+	}
+	if err := c.send(req); err != nil {
+		if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) || errors.Is(err, io.ErrClosedPipe) {
+			// The plugin exited before reading the request.
+			c.closeLocked()
+			err = ErrClosed
+		}
+		return fmt.Errorf("plugin: %s: %w", method, err)
+	}
+
+	type reply struct {
+		resp response
+		err  error
+	}
+	done := make(chan reply, 1)
+	go func() {
+		var resp response
+		line, err := c.r.ReadBytes('\n')
+		if err == nil {
+			err = json.Unmarshal(line, &resp)
+		}
+		done <- reply{resp, err}
+	}()
+
+	var rep reply
+	select {
+	case rep = <-done:
+	case <-ctx.Done():
+		if c.cmd != nil {
+			c.cmd.Process.Kill()
+		}
+		c.closeLocked()
+		return ctx.Err()
+	}
+	if rep.err != nil {
+		c.closeLocked()
+		if errors.Is(rep.err, io.EOF) {
+			return fmt.Errorf("plugin: %s: %w", method, ErrClosed)
+		}
+		return fmt.Errorf("plugin: %s: %w", method, rep.err)
+	}
+	if rep.resp.ID == nil || *rep.resp.ID != id {
+		c.closeLocked()
+		return fmt.Errorf("plugin: %s: response id does not match request %d", method, id)
+	}
+	if rep.resp.Error != nil {
+		return fmt.Errorf("plugin: %s: %w", method, rep.resp.Error)
+	}
+	if result != nil && len(rep.resp.Result) > 0 {
+		if err := json.Unmarshal(rep.resp.Result, result); err != nil {
+			return fmt.Errorf("plugin: %s: decode result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) send(req request) error {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	_, err = c.w.Write(append(b, '\n'))
+	return err
+}
+
+// Close asks the plugin to shut down, closes its input and waits for it
+// to exit.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.send(request{JSONRPC: "2.0", Method: MethodShutdown})
+	return c.closeLocked()
+}
+
+func (c *Client) closeLocked() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	err := c.w.Close()
+	if c.cmd != nil {
+		if werr := c.cmd.Wait(); werr != nil && err == nil {
+			err = fmt.Errorf("plugin: %w", werr)
+		}
+	}
+	return err
+}
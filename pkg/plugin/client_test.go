@@ -0,0 +1,120 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+)
+
+// TestHelperProcess is run as a plugin by the tests below.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_PLUGIN_HELPER") != "1" {
+		return
+	}
+	err := Serve("helper", "0.1.0", Handlers{
+		Publish: func(_ context.Context, p PublishParams) (PublishResult, error) {
+			if p.Tag == "fail" {
+				return PublishResult{}, errors.New("refused")
+			}
+			return PublishResult{ID: "1", URL: "https://example.com/" + p.Repo + "/" + p.Tag}, nil
+		},
+		Notify: func(context.Context, notify.Payload) error {
+			time.Sleep(time.Minute)
+			return nil
+		},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func startHelper(t *testing.T) *Client {
+	t.Helper()
+	t.Setenv("GO_WANT_PLUGIN_HELPER", "1")
+	c, err := Start(context.Background(), nil, os.Args[0], "-test.run=TestHelperProcess")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return c
+}
+
+func TestStart(t *testing.T) {
+	c := startHelper(t)
+
+	info, err := c.Info(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if info.Name != "helper" || info.Version != "0.1.0" {
+		t.Errorf("Expected helper 0.1.0, got %+v", info)
+	}
+	if !c.Has(CapPublisher) || !c.Has(CapNotifier) || c.Has(CapVersionSource) {
+		t.Errorf("Expected publisher and notifier capabilities, got %v", info.Capabilities)
+	}
+
+	var res PublishResult
+	if err := c.Call(context.Background(), MethodPublish, PublishParams{Tag: "v1.0.0", Repo: "o/r"}, &res); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.URL != "https://example.com/o/r/v1.0.0" {
+		t.Errorf("Expected release URL, got %q", res.URL)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("Expected clean exit, got %v", err)
+	}
+	if err := c.Call(context.Background(), MethodInfo, nil, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	c := startHelper(t)
+	defer c.Close()
+
+	err := c.Call(context.Background(), MethodPublish, PublishParams{Tag: "fail"}, nil)
+	var perr *Error
+	if !errors.As(err, &perr) || perr.Code != CodeInternalError || perr.Message != "refused" {
+		t.Errorf("Expected plugin error, got %v", err)
+	}
+
+	err = c.Call(context.Background(), MethodVersions, nil, nil)
+	if !errors.As(err, &perr) || perr.Code != CodeMethodNotFound {
+		t.Errorf("Expected method not found, got %v", err)
+	}
+
+	// A failed call leaves the connection usable.
+	if err := c.Call(context.Background(), MethodInfo, nil, nil); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestCallCancel(t *testing.T) {
+	c := startHelper(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := c.Call(ctx, MethodNotify, notify.Payload{Tag: "v1.0.0"}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if err := c.Call(context.Background(), MethodInfo, nil, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed after cancellation, got %v", err)
+	}
+}
+
+func TestStartNotAPlugin(t *testing.T) {
+	if _, err := Start(context.Background(), nil, "true"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if _, err := Start(context.Background(), nil, "/nonexistent/plugin"); err == nil {
+		t.Errorf("Expected error for missing executable")
+	}
+}
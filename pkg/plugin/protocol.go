@@ -0,0 +1,108 @@
+// Package plugin extends the release tooling with external programs.
+//
+// A plugin is an executable speaking JSON-RPC 2.0 over its standard input
+// and output, one JSON message per line. The host starts it, calls
+// MethodInfo to learn its name and capabilities, then calls the methods of
+// the capabilities it needs. Standard error is left to the plugin for
+// diagnostics. When the host is done it sends MethodShutdown and closes the
+// plugin's standard input; the plugin should then exit.
+//
+// Capabilities and their methods:
+//
+//	publisher       publisher.publish  PublishParams → PublishResult
+//	notifier        notifier.notify    notify.Payload → null
+//	version-source  versions.list      null → VersionsResult
+//
+// Plugins written in Go can use Serve, which implements the protocol around
+// a Handlers value. Hosts use Start and the adapters returned by
+// Client.Publisher, Client.Notifier and Client.VersionSource.
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Method names.
+const (
+	MethodInfo     = "plugin.info"
+	MethodShutdown = "plugin.shutdown"
+	MethodPublish  = "publisher.publish"
+	MethodNotify   = "notifier.notify"
+	MethodVersions = "versions.list"
+)
+
+// Capabilities.
+const (
+	CapPublisher     = "publisher"
+	CapNotifier      = "notifier"
+	CapVersionSource = "version-source"
+)
+
+// Standard JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Info is the result of MethodInfo.
+type Info struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version,omitempty"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// PublishParams are the parameters of MethodPublish.
+type PublishParams struct {
+	Tag        string   `json:"tag"`
+	Name       string   `json:"name"`
+	Body       string   `json:"body"`
+	Draft      bool     `json:"draft"`
+	Prerelease bool     `json:"prerelease"`
+	Assets     []string `json:"assets,omitempty"`
+	Milestones []string `json:"milestones,omitempty"`
+	// Repo is the repository configured for the publish target.
+	Repo string `json:"repo,omitempty"`
+}
+
+// PublishResult is the result of MethodPublish.
+type PublishResult struct {
+	ID       string   `json:"id"`
+	URL      string   `json:"url"`
+	Existing bool     `json:"existing,omitempty"`
+	Uploaded []string `json:"uploaded,omitempty"`
+}
+
+// VersionsResult is the result of MethodVersions: versions released
+// outside git, such as those on a package registry.
+type VersionsResult struct {
+	Versions []string `json:"versions"`
+}
+
+// request is a JSON-RPC request, or a notification when ID is nil.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *uint64         `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *uint64         `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error returned by a plugin.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
@@ -0,0 +1,113 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gbrennon/release_automation_golang/pkg/notify"
+)
+
+// Handlers implements the capabilities of a plugin. Nil handlers are
+// neither advertised nor callable.
+type Handlers struct {
+	Publish  func(ctx context.Context, p PublishParams) (PublishResult, error)
+	Notify   func(ctx context.Context, p notify.Payload) error
+	Versions func(ctx context.Context) ([]string, error)
+}
+
+func (h Handlers) capabilities() []string {
+	caps := []string{}
+	if h.Publish != nil {
+		caps = append(caps, CapPublisher)
+	}
+	if h.Notify != nil {
+		caps = append(caps, CapNotifier)
+	}
+	if h.Versions != nil {
+		caps = append(caps, CapVersionSource)
+	}
+	return caps
+}
+
+// Serve runs the plugin side of the protocol on standard input and output
+// until the host shuts it down. It is meant to be called from main.
+func Serve(name, version string, h Handlers) error {
+	return ServeConn(context.Background(), os.Stdin, os.Stdout, Info{Name: name, Version: version}, h)
+}
+
+// ServeConn is Serve over arbitrary streams. info.Capabilities is filled
+// in from h.
+func ServeConn(ctx context.Context, r io.Reader, w io.Writer, info Info, h Handlers) error {
+	info.Capabilities = h.capabilities()
+	br := bufio.NewReader(r)
+	enc := json.NewEncoder(w)
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		var req request
+		if jerr := json.Unmarshal(line, &req); jerr != nil {
+			if err := enc.Encode(response{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: jerr.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == MethodShutdown {
+			return nil
+		}
+		if req.ID == nil {
+			continue
+		}
+
+		resp := response{JSONRPC: "2.0", ID: req.ID}
+		result, rerr := h.dispatch(ctx, info, req)
+		if rerr != nil {
+			resp.Error = rerr
+		} else if resp.Result, err = json.Marshal(result); err != nil {
+			resp.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (h Handlers) dispatch(ctx context.Context, info Info, req request) (any, *Error) {
+	switch {
+	case req.Method == MethodInfo:
+		return info, nil
+	case req.Method == MethodPublish && h.Publish != nil:
+		var p PublishParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return wrap(h.Publish(ctx, p))
+	case req.Method == MethodNotify && h.Notify != nil:
+		var p notify.Payload
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return wrap[any](nil, h.Notify(ctx, p))
+	case req.Method == MethodVersions && h.Versions != nil:
+		vs, err := h.Versions(ctx)
+		return wrap(VersionsResult{Versions: vs}, err)
+	}
+	return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+}
+
+func wrap[T any](v T, err error) (any, *Error) {
+	if err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	return v, nil
+}
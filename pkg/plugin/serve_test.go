@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestServeConn(t *testing.T) {
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"plugin.info"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","method":"notifier.notify","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"versions.list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"publisher.publish","params":{"tag":"v1"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"publisher.publish","params":"v1"}`,
+		`{"jsonrpc":"2.0","method":"plugin.shutdown"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"plugin.info"}`,
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	h := Handlers{Versions: func(context.Context) ([]string, error) { return []string{"1.0.0"}, nil }}
+	if err := ServeConn(context.Background(), strings.NewReader(in), &out, Info{Name: "test"}, h); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got []response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r response
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("Expected JSON response, got %v", err)
+		}
+		got = append(got, r)
+	}
+	if len(got) != 5 {
+		t.Fatalf("Expected 5 responses, got %d", len(got))
+	}
+
+	var info Info
+	json.Unmarshal(got[0].Result, &info)
+	if info.Name != "test" || len(info.Capabilities) != 1 || info.Capabilities[0] != CapVersionSource {
+		t.Errorf("Expected info with version-source, got %+v", info)
+	}
+	if got[1].Error == nil || got[1].Error.Code != CodeParseError {
+		t.Errorf("Expected parse error, got %+v", got[1])
+	}
+	if string(got[2].Result) != `{"versions":["1.0.0"]}` {
+		t.Errorf("Expected versions result, got %s", got[2].Result)
+	}
+	if got[3].Error == nil || got[3].Error.Code != CodeMethodNotFound {
+		t.Errorf("Expected method not found for missing handler, got %+v", got[3])
+	}
+	if got[4].Error == nil || got[4].Error.Code != CodeMethodNotFound {
+		t.Errorf("Expected method not found, got %+v", got[4])
+	}
+}
+
+func TestServeConnInvalidParams(t *testing.T) {
+	in := `{"jsonrpc":"2.0","id":1,"method":"publisher.publish","params":"v1"}` + "\n"
+	h := Handlers{Publish: func(context.Context, PublishParams) (PublishResult, error) { return PublishResult{}, nil }}
+
+	var out bytes.Buffer
+	if err := ServeConn(context.Background(), strings.NewReader(in), &out, Info{Name: "test"}, h); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var r response
+	json.Unmarshal(out.Bytes(), &r)
+	if r.Error == nil || r.Error.Code != CodeInvalidParams {
+		t.Errorf("Expected invalid params, got %s", out.String())
+	}
+}
@@ -0,0 +1,186 @@
+// Package policy enforces release policies on the commits since the latest
+// release: how many of them may wait for a release, which of them must
+// word their changelog entry, and whether the release branch took direct
+// pushes. Evaluate reports every violation as a Diagnostic rather than
+// stopping at the first, so that preflight lists them all.
+package policy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+// ErrViolated is wrapped by the Error of a range breaking a policy.
+var ErrViolated = relerr.ErrPolicyViolated
+
+// The rules, as named by Diagnostic.Rule.
+const (
+	RuleMaxUnreleased = "max-unreleased-commits"
+	RuleReleaseNote   = "release-note"
+	RuleDirectPush    = "direct-push"
+)
+
+// Policy lists the rules to enforce; the zero Policy enforces none.
+type Policy struct {
+	// MaxUnreleased is the number of commits beyond which a release is
+	// overdue; 0 allows any number.
+	MaxUnreleased int
+	// ReleaseNoteTypes are the commit types, such as feat, whose commits
+	// must word their changelog entry with a changelog.NoteTrailer and
+	// must not be hidden from the changelog by Classifier.
+	ReleaseNoteTypes []string
+	// Protected lists the branches, names or path.Match patterns, that
+	// only take merges and squashed pull requests.
+	Protected []string
+	// Classifier holds the commits.rules of the changelog.
+	Classifier commits.Classifier
+}
+
+// Enabled reports whether p enforces any rule.
+func (p Policy) Enabled() bool {
+	return p.MaxUnreleased > 0 || len(p.ReleaseNoteTypes) > 0 || len(p.Protected) > 0
+}
+
+// Range is what a policy is evaluated on.
+type Range struct {
+	// Branch is the branch released from; empty for a detached HEAD.
+	Branch string
+	// Raw are the commits since the latest release, newest first, and
+	// Commits those of them that parsed, as in workspace.Plan.
+	Raw     []gitrepo.Commit
+	Commits []commits.Commit
+}
+
+// Diagnostic is a violation of a rule.
+type Diagnostic struct {
+	Rule string `json:"rule"`
+	// Commit is the hash of the commit breaking the rule, empty when the
+	// range as a whole does.
+	Commit  string `json:"commit,omitempty"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Commit == "" {
+		return d.Rule + ": " + d.Message
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Rule, short(d.Commit), d.Message)
+}
+
+// Error lists the diagnostics of a range breaking a policy.
+type Error struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d time(s):", ErrViolated, len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		b.WriteString("\n    - " + d.String())
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error { return ErrViolated }
+
+// Evaluate returns the violations of p by r, rule by rule.
+func (p Policy) Evaluate(r Range) []Diagnostic {
+	var ds []Diagnostic
+	if p.MaxUnreleased > 0 && len(r.Raw) > p.MaxUnreleased {
+		ds = append(ds, Diagnostic{
+			Rule:    RuleMaxUnreleased,
+			Message: fmt.Sprintf("%d commits since the latest release, more than the %d allowed; release them", len(r.Raw), p.MaxUnreleased),
+		})
+	}
+	for _, c := range r.Commits {
+		if !slices.Contains(p.ReleaseNoteTypes, c.Type) {
+			continue
+		}
+		if rule, ok := p.Classifier.Match(c); ok && rule.Hidden {
+			ds = append(ds, Diagnostic{Rule: RuleReleaseNote, Commit: c.Hash, Message: fmt.Sprintf("%s commit %q is hidden from the changelog by commits.rules", c.Type, c.Header())})
+			continue
+		}
+		if note, ok := c.Footer(changelog.NoteTrailer); !ok || strings.TrimSpace(note) == "" {
+			ds = append(ds, Diagnostic{Rule: RuleReleaseNote, Commit: c.Hash, Message: fmt.Sprintf("%s commit %q has no %s trailer wording its changelog entry", c.Type, c.Header(), changelog.NoteTrailer)})
+		}
+	}
+	if protected(r.Branch, p.Protected) {
+		for _, c := range firstParents(r.Raw) {
+			if len(c.Parents) > 1 || merged(c.Message) {
+				continue
+			}
+			ds = append(ds, Diagnostic{Rule: RuleDirectPush, Commit: c.Hash, Message: fmt.Sprintf("%q was pushed to %s directly instead of merged", subject(c.Message), r.Branch)})
+		}
+	}
+	return ds
+}
+
+// protected reports whether branch matches one of patterns.
+func protected(branch string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok && branch != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// firstParents returns the commits of raw on the first-parent chain of
+// its newest commit: those the branch itself received.
+func firstParents(raw []gitrepo.Commit) []gitrepo.Commit {
+	if len(raw) == 0 {
+		return nil
+	}
+	byHash := make(map[string]gitrepo.Commit, len(raw))
+	for _, c := range raw {
+		byHash[c.Hash] = c
+	}
+	var chain []gitrepo.Commit
+	for c, ok := raw[0], true; ok; {
+		chain = append(chain, c)
+		if len(c.Parents) == 0 {
+			break
+		}
+		c, ok = byHash[c.Parents[0]]
+	}
+	return chain
+}
+
+var (
+	// pullRequest matches the "(#123)" GitHub appends to the subject of
+	// squashed pull requests.
+	pullRequest = regexp.MustCompile(`\(#\d+\)$`)
+	// mergeRequest matches the line GitLab adds to squashed merge
+	// requests.
+	mergeRequest = regexp.MustCompile(`(?m)^See merge request \S+!\d+$`)
+)
+
+// merged reports whether message is that of a squashed pull request or a
+// release commit, which the release itself pushes.
+func merged(message string) bool {
+	if pullRequest.MatchString(subject(message)) || mergeRequest.MatchString(message) {
+		return true
+	}
+	c, err := commits.Parse(message)
+	return err == nil && c.Type == "chore" && c.Scope == "release"
+}
+
+func subject(message string) string {
+	s, _, _ := strings.Cut(message, "\n")
+	return strings.TrimSpace(s)
+}
+
+func short(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,107 @@
+package policy
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+func rangeOf(branch string, raw ...gitrepo.Commit) Range {
+	r := Range{Branch: branch, Raw: raw}
+	for _, c := range raw {
+		if parsed, err := commits.Parse(c.Message); err == nil {
+			parsed.Hash = c.Hash
+			r.Commits = append(r.Commits, parsed)
+		}
+	}
+	return r
+}
+
+func rules(ds []Diagnostic) []string {
+	var out []string
+	for _, d := range ds {
+		out = append(out, d.Rule+" "+d.Commit)
+	}
+	return out
+}
+
+func TestEvaluateMaxUnreleased(t *testing.T) {
+	r := rangeOf("main", gitrepo.Commit{Hash: "c3", Message: "fix: c"}, gitrepo.Commit{Hash: "c2", Message: "fix: b"}, gitrepo.Commit{Hash: "c1", Message: "fix: a"})
+
+	if ds := (Policy{MaxUnreleased: 3}).Evaluate(r); len(ds) != 0 {
+		t.Errorf("Expected no violations, got %v", ds)
+	}
+	ds := Policy{MaxUnreleased: 2}.Evaluate(r)
+	if len(ds) != 1 || ds[0].Rule != RuleMaxUnreleased || ds[0].Commit != "" {
+		t.Fatalf("Expected one range violation, got %v", ds)
+	}
+	if expected := "max-unreleased-commits: 3 commits since the latest release, more than the 2 allowed; release them"; ds[0].String() != expected {
+		t.Errorf("Expected %q, got %q", expected, ds[0].String())
+	}
+}
+
+func TestEvaluateReleaseNote(t *testing.T) {
+	r := rangeOf("main",
+		gitrepo.Commit{Hash: "c4", Message: "feat: export\n\nRelease-Note: Reports can be exported as CSV."},
+		gitrepo.Commit{Hash: "c3", Message: "feat: import"},
+		gitrepo.Commit{Hash: "c2", Message: "feat(internal): cache\n\nRelease-Note: Faster."},
+		gitrepo.Commit{Hash: "c1", Message: "fix: crash"},
+	)
+	p := Policy{
+		ReleaseNoteTypes: []string{"feat"},
+		Classifier:       commits.Classifier{Rules: []commits.ClassRule{{Pattern: regexp.MustCompile(`\(internal\)`), Hidden: true}}},
+	}
+
+	ds := p.Evaluate(r)
+	if expected := []string{"release-note c3", "release-note c2"}; !reflect.DeepEqual(rules(ds), expected) {
+		t.Fatalf("Expected %v, got %v", expected, rules(ds))
+	}
+	if !strings.Contains(ds[0].Message, "no Release-Note trailer") || !strings.Contains(ds[1].Message, "hidden from the changelog") {
+		t.Errorf("Unexpected messages %v", ds)
+	}
+}
+
+func TestEvaluateDirectPush(t *testing.T) {
+	r := rangeOf("main",
+		gitrepo.Commit{Hash: "m2", Parents: []string{"s1", "b1"}, Message: "Merge pull request #8 from octo/topic"},
+		gitrepo.Commit{Hash: "b1", Parents: []string{"d0"}, Message: "fix: on a branch"},
+		gitrepo.Commit{Hash: "s1", Parents: []string{"g1"}, Message: "feat: squashed (#7)"},
+		gitrepo.Commit{Hash: "g1", Parents: []string{"r1"}, Message: "fix: squashed\n\nSee merge request octo/app!12"},
+		gitrepo.Commit{Hash: "r1", Parents: []string{"d1"}, Message: "chore(release): v1.2.0"},
+		gitrepo.Commit{Hash: "d1", Parents: []string{"d0"}, Message: "fix: hotfix on main"},
+	)
+	p := Policy{Protected: []string{"main", "release/*"}}
+
+	ds := p.Evaluate(r)
+	if expected := []string{"direct-push d1"}; !reflect.DeepEqual(rules(ds), expected) {
+		t.Fatalf("Expected %v, got %v", expected, rules(ds))
+	}
+	if expected := `direct-push: d1: "fix: hotfix on main" was pushed to main directly instead of merged`; ds[0].String() != expected {
+		t.Errorf("Expected %q, got %q", expected, ds[0].String())
+	}
+
+	r.Branch = "topic"
+	if ds := p.Evaluate(r); len(ds) != 0 {
+		t.Errorf("Expected unprotected branches left alone, got %v", ds)
+	}
+}
+
+func TestError(t *testing.T) {
+	err := error(&Error{Diagnostics: []Diagnostic{{Rule: RuleDirectPush, Commit: "0123456789", Message: "pushed"}}})
+
+	if !errors.Is(err, ErrViolated) || relerr.KindOf(err) != relerr.Precondition {
+		t.Errorf("Expected a precondition violation, got %v", err)
+	}
+	if expected := "release policy violated 1 time(s):\n    - direct-push: 0123456: pushed"; err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+	if (Policy{}).Enabled() || !(Policy{Protected: []string{"main"}}).Enabled() {
+		t.Error("Expected only a policy with rules enabled")
+	}
+}
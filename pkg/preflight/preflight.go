@@ -0,0 +1,195 @@
+// Package preflight verifies the preconditions of a release before anything
+// is tagged or pushed: a clean working tree, an allowed branch, a branch up
+// to date with its remote, passing CI and the presence of required files.
+//
+// Run executes every check and reports all failures together, as
+// scripts/preflight.sh does, so that a single run lists everything to fix.
+// Each failure wraps one of the sentinel errors below and says how to fix
+// it.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// The sentinels are those of pkg/errors, of kind Precondition.
+var (
+	// ErrFailed is wrapped by the error Run returns when any check fails.
+	ErrFailed = relerr.ErrPreflightFailed
+
+	ErrDirtyWorktree    = relerr.ErrDirtyWorktree
+	ErrBranchNotAllowed = relerr.ErrBranchNotAllowed
+	ErrBehindRemote     = relerr.ErrBehindRemote
+	ErrCIFailing        = relerr.ErrCIFailing
+	ErrMissingFiles     = relerr.ErrMissingFiles
+)
+
+// Check is a single precondition.
+type Check struct {
+	// Name describes the precondition, e.g. "clean working tree".
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Error lists the checks that failed.
+type Error struct {
+	Failed []error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(ErrFailed.Error() + ":")
+	for _, err := range e.Failed {
+		b.WriteString("\n  - " + err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns ErrFailed and the failures, so errors.Is matches both.
+func (e *Error) Unwrap() []error {
+	return append([]error{ErrFailed}, e.Failed...)
+}
+
+// Run runs every check, writing a line per check to w when it is not nil.
+// It returns an *Error listing the failures, or nil when all checks pass;
+// only a cancelled ctx stops it early.
+func Run(ctx context.Context, w io.Writer, checks []Check) error {
+	var failed []error
+	for _, c := range checks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := c.Run(ctx)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", c.Name, err))
+		}
+		if w != nil {
+			mark := "✓"
+			if err != nil {
+				mark = "✗"
+			}
+			fmt.Fprintf(w, "%s %s\n", mark, c.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return &Error{Failed: failed}
+	}
+	return nil
+}
+
+// CleanWorktree requires repo to have no uncommitted changes to tracked
+// files. Untracked files, such as build output, are allowed.
+func CleanWorktree(repo gitrepo.Repository) Check {
+	return Check{Name: "clean working tree", Run: func(ctx context.Context) error {
+		paths, err := repo.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if len(paths) > 0 {
+			return fmt.Errorf("%w in %s; commit or stash them first", ErrDirtyWorktree, list(paths))
+		}
+		return nil
+	}}
+}
+
+// Branch requires branch to match one of allowed, which are branch names
+// or path.Match patterns. An empty branch is a detached HEAD.
+func Branch(branch string, allowed []string) Check {
+	return Check{Name: "release branch", Run: func(context.Context) error {
+		for _, pattern := range allowed {
+			if ok, _ := path.Match(pattern, branch); ok && branch != "" {
+				return nil
+			}
+		}
+		current := fmt.Sprintf("on %q", branch)
+		if branch == "" {
+			current = "HEAD is detached"
+		}
+		return fmt.Errorf("%w: %s; check out one of %s", ErrBranchNotAllowed, current, strings.Join(allowed, ", "))
+	}}
+}
+
+// UpToDate requires HEAD to contain every commit of branch on remote.
+func UpToDate(repo gitrepo.Repository, remote, branch string) Check {
+	return Check{Name: "up to date with " + remote, Run: func(ctx context.Context) error {
+		if branch == "" {
+			return fmt.Errorf("%w: HEAD is detached, so there is no branch to compare", ErrBehindRemote)
+		}
+		n, err := repo.Behind(ctx, remote, branch)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("%w: %s/%s has %d commit(s) missing from HEAD; run git pull %s %s", ErrBehindRemote, remote, branch, n, remote, branch)
+		}
+		return nil
+	}}
+}
+
+// CI requires the checks of the commit ref to have passed.
+func CI(s publish.StatusChecker, ref string) Check {
+	return Check{Name: "CI passing", Run: func(ctx context.Context) error {
+		st, err := s.CIStatus(ctx, ref)
+		if err != nil {
+			return err
+		}
+		var msg string
+		switch st.State {
+		case publish.CISuccess:
+			return nil
+		case publish.CIFailure:
+			msg = "checks failed" + names(st.Checks) + "; fix them and push again"
+		case publish.CIPending:
+			msg = "checks still running" + names(st.Checks) + "; wait for them to finish"
+		default:
+			msg = "no checks reported for " + ref + "; wait for CI to start or disable the ci check"
+		}
+		if st.URL != "" {
+			msg += " (" + st.URL + ")"
+		}
+		return fmt.Errorf("%w: %s", ErrCIFailing, msg)
+	}}
+}
+
+// Files requires each of paths to exist under dir.
+func Files(dir string, paths []string) Check {
+	return Check{Name: "required files", Run: func(context.Context) error {
+		var missing []string
+		for _, p := range paths {
+			if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%w: %s; add them before releasing", ErrMissingFiles, list(missing))
+		}
+		return nil
+	}}
+}
+
+// names formats the names of checks after a colon, if there are any.
+func names(checks []string) string {
+	if len(checks) == 0 {
+		return ""
+	}
+	return ": " + list(checks)
+}
+
+// list joins items, eliding all but the first five.
+func list(items []string) string {
+	const limit = 5
+	if len(items) <= limit {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(items[:limit], ", "), len(items)-limit)
+}
@@ -0,0 +1,120 @@
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+type fakeRepo struct {
+	gitrepo.Repository
+	dirty  []string
+	behind int
+}
+
+func (f *fakeRepo) Status(context.Context) ([]string, error) { return f.dirty, nil }
+
+func (f *fakeRepo) Behind(context.Context, string, string) (int, error) { return f.behind, nil }
+
+type fakeChecker publish.CIStatus
+
+func (f fakeChecker) CIStatus(context.Context, string) (publish.CIStatus, error) {
+	return publish.CIStatus(f), nil
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "LICENSE"), nil, 0o644)
+	clean := &fakeRepo{}
+	checks := []Check{
+		CleanWorktree(clean),
+		Branch("main", []string{"main", "release/*"}),
+		UpToDate(clean, "origin", "main"),
+		CI(fakeChecker{State: publish.CISuccess}, "abc"),
+		Files(dir, []string{"LICENSE"}),
+	}
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), &out, checks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "✓ clean working tree\n✓ release branch\n✓ up to date with origin\n✓ CI passing\n✓ required files\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	repo := &fakeRepo{dirty: []string{"a", "b", "c", "d", "e", "f", "g"}, behind: 3}
+	checks := []Check{
+		CleanWorktree(repo),
+		Branch("feature/x", []string{"main", "release/*"}),
+		UpToDate(repo, "origin", "feature/x"),
+		CI(fakeChecker{State: publish.CIFailure, Checks: []string{"lint"}, URL: "https://ci/1"}, "abc"),
+		Files(t.TempDir(), []string{"LICENSE", "CHANGELOG.md"}),
+	}
+
+	var out bytes.Buffer
+	err := Run(context.Background(), &out, checks)
+	for _, sentinel := range []error{ErrFailed, ErrDirtyWorktree, ErrBranchNotAllowed, ErrBehindRemote, ErrCIFailing, ErrMissingFiles} {
+		if !errors.Is(err, sentinel) {
+			t.Errorf("Expected error to match %v, got %v", sentinel, err)
+		}
+	}
+	var pe *Error
+	if !errors.As(err, &pe) || len(pe.Failed) != 5 {
+		t.Fatalf("Expected an *Error with 5 failures, got %v", err)
+	}
+
+	for _, want := range []string{
+		"preflight checks failed:\n  - clean working tree: working tree has uncommitted changes in a, b, c, d, e and 2 more; commit or stash them first",
+		`release branch: branch is not a release branch: on "feature/x"; check out one of main, release/*`,
+		"origin/feature/x has 3 commit(s) missing from HEAD; run git pull origin feature/x",
+		"CI passing: CI is not green: checks failed: lint; fix them and push again (https://ci/1)",
+		"required files: required files are missing: LICENSE, CHANGELOG.md; add them before releasing",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if strings.Count(out.String(), "✗") != 5 {
+		t.Errorf("Expected 5 failed checks, got %q", out.String())
+	}
+}
+
+func TestCIStates(t *testing.T) {
+	tests := map[publish.CIState]string{
+		publish.CIPending: "checks still running: test; wait for them to finish",
+		publish.CINone:    "no checks reported for abc; wait for CI to start",
+	}
+	for state, want := range tests {
+		err := CI(fakeChecker{State: state, Checks: []string{"test"}}, "abc").Run(context.Background())
+		if !errors.Is(err, ErrCIFailing) || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: Expected %q, got %v", state, want, err)
+		}
+	}
+}
+
+func TestDetachedHead(t *testing.T) {
+	if err := Branch("", []string{"*"}).Run(context.Background()); err == nil || !strings.Contains(err.Error(), "HEAD is detached") {
+		t.Errorf("Expected a detached HEAD error, got %v", err)
+	}
+	if err := UpToDate(&fakeRepo{}, "origin", "").Run(context.Background()); !errors.Is(err, ErrBehindRemote) {
+		t.Errorf("Expected ErrBehindRemote, got %v", err)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Run(ctx, nil, []Check{Files(t.TempDir(), []string{"x"})}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package progress reports the stages of a long-running command, such as
+// the analysis, build, uploads, publishing and notifications of a release.
+//
+// A Reporter is told when each stage starts and how its items, such as the
+// assets of an upload, progress. Terminal renders them as a multi-line
+// display redrawn in place, with the bytes per second and the time left of
+// each item in flight, for a terminal; Log writes one complete line per
+// event, for CI logs and files, where redrawing would garble the output.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Reporter receives the progress of a command. Its methods may be called
+// from several goroutines.
+type Reporter interface {
+	// Stage starts the stage name, ending the one before. total counts
+	// its items, 0 when they are not known up front.
+	Stage(name string, total int)
+	// Transfer reports the bytes of an item of the stage sent so far.
+	Transfer(t Transfer)
+	// Item reports an item of the stage done, or failed.
+	Item(it Item)
+	// Close ends the last stage, failed when err is not nil, and the
+	// report.
+	Close(err error)
+}
+
+// Transfer is the bytes of an item sent so far, out of Size.
+type Transfer struct {
+	Name       string
+	Sent, Size int64
+}
+
+// Item is a finished item of a stage.
+type Item struct {
+	Name string
+	// Size is the size of the item in bytes, or -1 when unknown.
+	Size    int64
+	Elapsed time.Duration
+	// Err is nil when the item succeeded.
+	Err error
+	// Done counts the finished items of the stage, including this one, out
+	// of Total.
+	Done, Total int
+}
+
+// Rate returns the bytes per second of it, or 0 when unknown.
+func (it Item) Rate() float64 {
+	if it.Size <= 0 || it.Elapsed <= 0 {
+		return 0
+	}
+	return float64(it.Size) / it.Elapsed.Seconds()
+}
+
+// Nop reports nothing.
+type Nop struct{}
+
+func (Nop) Stage(string, int) {}
+func (Nop) Transfer(Transfer) {}
+func (Nop) Item(Item)         {}
+func (Nop) Close(error)       {}
+
+// Log reports the progress as lines: the start of each stage as a log
+// record at info level, and its duration at debug level once it ends, and
+// each item as a line of W, worded as the asset upload it is.
+type Log struct {
+	W io.Writer
+	// Logger receives the stages; nil logs none.
+	Logger *slog.Logger
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	stage string
+	start time.Time
+}
+
+// NewLog returns a Log writing the items to w and the stages to logger.
+func NewLog(w io.Writer, logger *slog.Logger) *Log {
+	return &Log{W: w, Logger: logger}
+}
+
+func (l *Log) Stage(name string, total int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.end(nil)
+	l.stage, l.start = name, l.now()
+	if l.Logger == nil {
+		return
+	}
+	if total > 0 {
+		l.Logger.Info(fmt.Sprintf("stage %s: %d item(s)", name, total))
+		return
+	}
+	l.Logger.Info("stage " + name)
+}
+
+// end logs the duration of the current stage.
+func (l *Log) end(err error) {
+	if l.stage == "" || l.Logger == nil {
+		return
+	}
+	d := l.now().Sub(l.start).Round(100 * time.Millisecond)
+	if err != nil {
+		l.Logger.Debug(fmt.Sprintf("stage %s failed after %s", l.stage, d))
+	} else {
+		l.Logger.Debug(fmt.Sprintf("stage %s done in %s", l.stage, d))
+	}
+	l.stage = ""
+}
+
+func (l *Log) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
+
+func (l *Log) Transfer(t Transfer) {
+	fmt.Fprintf(l.W, "uploading %s: %s of %s (%d%%)\n", t.Name, FormatSize(t.Sent), FormatSize(t.Size), t.Sent*100/max(t.Size, 1))
+}
+
+func (l *Log) Item(it Item) {
+	if it.Err != nil {
+		fmt.Fprintf(l.W, "failed to upload %s [%d/%d]\n", it.Name, it.Done, it.Total)
+		return
+	}
+	rate := ""
+	if r := it.Rate(); r > 0 {
+		rate = ", " + FormatRate(r)
+	}
+	fmt.Fprintf(l.W, "uploaded %s (%s in %s%s) [%d/%d]\n", it.Name, FormatSize(it.Size), it.Elapsed.Round(100*time.Millisecond), rate, it.Done, it.Total)
+}
+
+// Close logs the duration of the last stage; the outcome of the command is
+// reported by it.
+func (l *Log) Close(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.end(err)
+}
+
+// FormatSize formats n bytes with a binary unit, e.g. "1.5 MiB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// FormatRate formats bytes per second, e.g. "1.5 MiB/s".
+func FormatRate(bps float64) string {
+	return FormatSize(int64(bps)) + "/s"
+}
+
+// FormatETA formats the time left, rounded to the second, e.g. "1m5s".
+func FormatETA(d time.Duration) string {
+	return max(d, 0).Round(time.Second).String()
+}
@@ -0,0 +1,74 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLog(t *testing.T) {
+	var out, logs bytes.Buffer
+	dropTime := func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}
+	l := NewLog(&out, slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: dropTime})))
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	l.Now = func() time.Time { return now }
+
+	var r Reporter = l
+	r.Stage("analysis", 0)
+	now = now.Add(300 * time.Millisecond)
+	r.Stage("publish github octo/app", 2)
+	r.Transfer(Transfer{Name: "dist/installer.exe", Sent: 512 << 20, Size: 2 << 30})
+	r.Item(Item{Name: "dist/app", Size: 3 << 19, Elapsed: 1500 * time.Millisecond, Done: 1, Total: 2})
+	r.Item(Item{Name: "dist/app.exe", Size: -1, Err: errors.New("boom"), Done: 2, Total: 2})
+	now = now.Add(2 * time.Second)
+	r.Close(errors.New("boom"))
+
+	expected := "uploading dist/installer.exe: 512.0 MiB of 2.0 GiB (25%)\n" +
+		"uploaded dist/app (1.5 MiB in 1.5s, 1.0 MiB/s) [1/2]\n" +
+		"failed to upload dist/app.exe [2/2]\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	expected = "level=INFO msg=\"stage analysis\"\n" +
+		"level=DEBUG msg=\"stage analysis done in 300ms\"\n" +
+		"level=INFO msg=\"stage publish github octo/app: 2 item(s)\"\n" +
+		"level=DEBUG msg=\"stage publish github octo/app failed after 2s\"\n"
+	if logs.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, logs.String())
+	}
+
+	out.Reset()
+	quiet := NewLog(&out, nil)
+	quiet.Stage("build", 0)
+	quiet.Close(nil)
+	if out.Len() != 0 {
+		t.Errorf("Expected no stages without a logger, got %q", out.String())
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct{ got, expected string }{
+		{FormatSize(512), "512 B"},
+		{FormatSize(3 << 19), "1.5 MiB"},
+		{FormatSize(2 << 30), "2.0 GiB"},
+		{FormatRate(2048), "2.0 KiB/s"},
+		{FormatETA(65400 * time.Millisecond), "1m5s"},
+		{FormatETA(-time.Second), "0s"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, tt.got)
+		}
+	}
+	if r := (Item{Size: -1, Elapsed: time.Second}).Rate(); r != 0 {
+		t.Errorf("Expected no rate of an item of unknown size, got %v", r)
+	}
+}
@@ -0,0 +1,265 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedrawInterval is how often a Terminal redraws the display between
+// events, to keep the durations and the spinner moving.
+var RedrawInterval = 100 * time.Millisecond
+
+// spinner are the frames of the mark of the stage in progress.
+var spinner = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// Terminal renders the report as a block of lines at the bottom of a
+// terminal, redrawn in place: a line per stage with its status, items done
+// and duration, and below the current stage a line per item in flight with
+// its bytes sent, bytes per second and time left. Once closed, the block
+// is left as the last output, and the next stage starts a new one.
+type Terminal struct {
+	w io.Writer
+	// width truncates the lines; 0 leaves them as they are.
+	width int
+	now   func() time.Time
+
+	mu     sync.Mutex
+	stages []*stage
+	items  []*item
+	// lines is the height of the block drawn last.
+	lines int
+	frame int
+	stop  chan struct{}
+}
+
+type stage struct {
+	name               string
+	total, done, fails int
+	start, end         time.Time
+	err                error
+}
+
+type item struct {
+	name       string
+	sent, size int64
+	// first and sent0 are when the item was first reported and the bytes
+	// sent then, which the rate is measured from.
+	first time.Time
+	sent0 int64
+	last  time.Time
+}
+
+// NewTerminal returns a Terminal drawing on w, a terminal width columns
+// wide.
+func NewTerminal(w io.Writer, width int) *Terminal {
+	return &Terminal{w: w, width: width, now: time.Now}
+}
+
+func (t *Terminal) Stage(name string, total int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.endStage(now, nil)
+	t.stages = append(t.stages, &stage{name: name, total: total, start: now})
+	t.items = nil
+	if t.stop == nil {
+		t.stop = make(chan struct{})
+		go t.tick(t.stop, RedrawInterval)
+	}
+	t.draw()
+}
+
+func (t *Terminal) Transfer(tr Transfer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	it := t.item(tr.Name)
+	if it == nil {
+		it = &item{name: tr.Name, first: now, sent0: tr.Sent}
+		t.items = append(t.items, it)
+	}
+	it.sent, it.size, it.last = tr.Sent, tr.Size, now
+	t.draw()
+}
+
+func (t *Terminal) Item(done Item) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, it := range t.items {
+		if it.name == done.Name {
+			t.items = append(t.items[:i], t.items[i+1:]...)
+			break
+		}
+	}
+	if s := t.current(); s != nil {
+		s.done++
+		if done.Err != nil {
+			s.fails++
+		}
+		s.total = max(s.total, done.Total)
+	}
+	t.draw()
+}
+
+// Close draws the final state of the block and leaves it.
+func (t *Terminal) Close(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+	if len(t.stages) == 0 {
+		return
+	}
+	t.endStage(t.now(), err)
+	t.items = nil
+	t.draw()
+	t.stages, t.lines = nil, 0
+}
+
+// Output returns a writer to w, such as the standard output of the
+// command, printing above the block, so that the output and the display
+// do not overwrite each other. Writes should be whole lines.
+func (t *Terminal) Output(w io.Writer) io.Writer {
+	return &output{t: t, w: w}
+}
+
+type output struct {
+	t *Terminal
+	w io.Writer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.t.mu.Lock()
+	defer o.t.mu.Unlock()
+	o.t.clear()
+	n, err := o.w.Write(p)
+	o.t.draw()
+	return n, err
+}
+
+func (t *Terminal) tick(stop chan struct{}, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			t.frame++
+			t.draw()
+			t.mu.Unlock()
+		}
+	}
+}
+
+func (t *Terminal) current() *stage {
+	if len(t.stages) == 0 {
+		return nil
+	}
+	return t.stages[len(t.stages)-1]
+}
+
+func (t *Terminal) endStage(now time.Time, err error) {
+	if s := t.current(); s != nil && s.end.IsZero() {
+		s.end, s.err = now, err
+	}
+}
+
+func (t *Terminal) item(name string) *item {
+	for _, it := range t.items {
+		if it.name == name {
+			return it
+		}
+	}
+	return nil
+}
+
+// clear erases the block drawn last, leaving the cursor where it began.
+func (t *Terminal) clear() {
+	if t.lines > 0 {
+		fmt.Fprintf(t.w, "\x1b[%dF\x1b[J", t.lines)
+		t.lines = 0
+	}
+}
+
+// draw replaces the block with the current state.
+func (t *Terminal) draw() {
+	if len(t.stages) == 0 {
+		return
+	}
+	lines := t.render(t.now())
+	var b strings.Builder
+	if t.lines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dF\x1b[J", t.lines)
+	}
+	for _, l := range lines {
+		b.WriteString(t.truncate(l))
+		b.WriteByte('\n')
+	}
+	io.WriteString(t.w, b.String())
+	t.lines = len(lines)
+}
+
+// render returns the lines of the block at now.
+func (t *Terminal) render(now time.Time) []string {
+	var lines []string
+	for _, s := range t.stages {
+		mark := string(spinner[t.frame%len(spinner)])
+		end := now
+		switch {
+		case !s.end.IsZero() && (s.err != nil || s.fails > 0):
+			mark, end = "✗", s.end
+		case !s.end.IsZero():
+			mark, end = "✓", s.end
+		}
+		line := mark + " " + s.name
+		if s.total > 0 {
+			line += fmt.Sprintf("  [%d/%d]", s.done, s.total)
+		} else if s.done > 0 {
+			line += fmt.Sprintf("  [%d]", s.done)
+		}
+		lines = append(lines, line+"  "+end.Sub(s.start).Round(100*time.Millisecond).String())
+	}
+	for _, it := range t.items {
+		lines = append(lines, "    "+it.render())
+	}
+	return lines
+}
+
+// render returns the line of an item in flight: its bytes sent, and once
+// measured, its rate and the time left.
+func (it *item) render() string {
+	line := fmt.Sprintf("%s  %s / %s", it.name, FormatSize(it.sent), FormatSize(it.size))
+	if it.size > 0 {
+		line += fmt.Sprintf("  %d%%", it.sent*100/it.size)
+	}
+	elapsed := it.last.Sub(it.first)
+	if elapsed <= 0 || it.sent <= it.sent0 {
+		return line
+	}
+	rate := float64(it.sent-it.sent0) / elapsed.Seconds()
+	line += "  " + FormatRate(rate)
+	if it.size > it.sent {
+		line += "  ETA " + FormatETA(time.Duration(float64(it.size-it.sent)/rate*float64(time.Second)))
+	}
+	return line
+}
+
+// truncate cuts l to the width of the terminal, so that no line wraps and
+// the block keeps its height.
+func (t *Terminal) truncate(l string) string {
+	if t.width <= 0 {
+		return l
+	}
+	r := []rune(l)
+	if len(r) < t.width {
+		return l
+	}
+	return string(r[:t.width-1])
+}
@@ -0,0 +1,91 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a clock advanced by hand.
+func fakeClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time { return now }, func(d time.Duration) { now = now.Add(d) }
+}
+
+// lastBlock returns the lines of the block drawn last on out.
+func lastBlock(out string) string {
+	if i := strings.LastIndex(out, "\x1b[J"); i >= 0 {
+		out = out[i+len("\x1b[J"):]
+	}
+	return out
+}
+
+func TestTerminal(t *testing.T) {
+	RedrawInterval = time.Hour
+	var out bytes.Buffer
+	term := NewTerminal(&out, 0)
+	now, advance := fakeClock()
+	term.now = now
+
+	term.Stage("analysis", 0)
+	advance(300 * time.Millisecond)
+	term.Stage("publish github octo/app", 2)
+	term.Transfer(Transfer{Name: "app.tar.gz", Sent: 1 << 20, Size: 11 << 20})
+	advance(2 * time.Second)
+	term.Transfer(Transfer{Name: "app.tar.gz", Sent: 5 << 20, Size: 11 << 20})
+
+	expected := "✓ analysis  300ms\n" +
+		"⠋ publish github octo/app  [0/2]  2s\n" +
+		"    app.tar.gz  5.0 MiB / 11.0 MiB  45%  2.0 MiB/s  ETA 3s\n"
+	if got := lastBlock(out.String()); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	term.Item(Item{Name: "app.tar.gz", Size: 11 << 20, Done: 1, Total: 2})
+	term.Item(Item{Name: "app.zip", Err: errors.New("boom"), Done: 2, Total: 2})
+	advance(time.Second)
+	term.Stage("notify", 0)
+	advance(time.Second)
+	term.Close(nil)
+	expected = "✓ analysis  300ms\n" +
+		"✗ publish github octo/app  [2/2]  3s\n" +
+		"✓ notify  1s\n"
+	if got := lastBlock(out.String()); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	out.Reset()
+	term.Stage("build", 0)
+	if got := out.String(); got != "⠋ build  0s\n" {
+		t.Errorf("Expected a new block after Close, got %q", got)
+	}
+	term.Close(errors.New("build failed"))
+	if got := lastBlock(out.String()); got != "✗ build  0s\n" {
+		t.Errorf("Expected the failed stage, got %q", got)
+	}
+}
+
+func TestTerminalOutput(t *testing.T) {
+	RedrawInterval = time.Hour
+	var out bytes.Buffer
+	term := NewTerminal(&out, 20)
+	term.now, _ = fakeClock()
+	w := term.Output(&out)
+
+	fmt.Fprintln(w, "before")
+	term.Stage("publish a rather long target name", 0)
+	fmt.Fprintln(w, "published https://example.com")
+	term.Close(nil)
+
+	expected := "before\n" +
+		"⠋ publish a rather \n" +
+		"\x1b[1F\x1b[Jpublished https://example.com\n" +
+		"⠋ publish a rather \n" +
+		"\x1b[1F\x1b[J✓ publish a rather \n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,170 @@
+// Package providerapi batches the lookups of many issues, pull requests or
+// commit authors on a provider API, such as those of the hundreds of
+// entries of a changelog, into few requests: GraphQL queries of many
+// aliases on GitHub, REST calls listing many items on GitLab.
+//
+// Batches splits the keys looked up into requests of Size keys, sent
+// Concurrency at a time. Once the rate limit budget of the API runs low,
+// the requests are sent one at a time, so that a large changelog does not
+// draw the budget down faster than the limiter of the API paces it. Cache
+// remembers what was looked up, found or not, so each key is asked once.
+package providerapi
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/gbrennon/release_automation_golang/pkg/ratelimit"
+	"golang.org/x/sync/semaphore"
+)
+
+// Defaults of Batches.
+const (
+	DefaultSize        = 50
+	DefaultConcurrency = 2
+)
+
+// Budgeter reports the rate limit budget of an API, as *ratelimit.Limiter
+// does.
+type Budgeter interface {
+	Budget() (ratelimit.Budget, bool)
+}
+
+// Batches sets how lookups are split into requests. The zero value sends
+// DefaultSize keys per request, DefaultConcurrency requests at a time.
+type Batches struct {
+	// Size is the most keys looked up by one request.
+	Size int
+	// Concurrency is the most requests in flight.
+	Concurrency int
+	// Budget, when set, is checked before each request: while less than a
+	// tenth of its limit remains, requests are sent one at a time.
+	Budget Budgeter
+}
+
+func (b Batches) size() int {
+	if b.Size <= 0 {
+		return DefaultSize
+	}
+	return b.Size
+}
+
+func (b Batches) concurrency() int64 {
+	if b.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return int64(b.Concurrency)
+}
+
+// pressed reports whether the budget of the API is running low.
+func (b Batches) pressed() bool {
+	if b.Budget == nil {
+		return false
+	}
+	budget, ok := b.Budget.Budget()
+	return ok && budget.Limit > 0 && budget.Remaining*10 < budget.Limit
+}
+
+// Fetch looks up one batch of keys, returning the values found by key;
+// keys without a value are left out.
+type Fetch[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Run looks keys up with fetch in the batches of b and returns what every
+// batch found. The first batch failing stops the others; what was found so
+// far is returned with its error.
+func Run[K comparable, V any](ctx context.Context, b Batches, keys []K, fetch Fetch[K, V]) (map[K]V, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n := b.concurrency()
+	sem := semaphore.NewWeighted(n)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		found = make(map[K]V, len(keys))
+		first error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if first == nil {
+			first = err
+			cancel()
+		}
+	}
+	for batch := range slices.Chunk(keys, b.size()) {
+		// A batch sent under pressure holds every slot, waiting for the
+		// batches in flight and keeping the next ones back.
+		weight := int64(1)
+		if b.pressed() {
+			weight = n
+		}
+		if err := sem.Acquire(ctx, weight); err != nil {
+			fail(err)
+			break
+		}
+		wg.Go(func() {
+			defer sem.Release(weight)
+			m, err := fetch(ctx, batch)
+			if err != nil {
+				fail(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for k, v := range m {
+				found[k] = v
+			}
+		})
+	}
+	wg.Wait()
+	return found, first
+}
+
+// Cache remembers the values looked up by key, and the keys found to have
+// none. The zero value is empty. A Cache is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	values  map[K]V
+	missing map[K]bool
+}
+
+// Get returns the values of keys, looking those it does not remember up
+// with fetch in the batches of b. Keys without a value are left out, and
+// remembered as missing once every batch succeeded.
+func (c *Cache[K, V]) Get(ctx context.Context, b Batches, keys []K, fetch Fetch[K, V]) (map[K]V, error) {
+	out := make(map[K]V, len(keys))
+	var ask []K
+	asked := make(map[K]bool)
+	c.mu.Lock()
+	for _, k := range keys {
+		if v, ok := c.values[k]; ok {
+			out[k] = v
+		} else if !c.missing[k] && !asked[k] {
+			ask, asked[k] = append(ask, k), true
+		}
+	}
+	c.mu.Unlock()
+	if len(ask) == 0 {
+		return out, nil
+	}
+
+	found, err := Run(ctx, b, ask, fetch)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values, c.missing = make(map[K]V), make(map[K]bool)
+	}
+	for k, v := range found {
+		c.values[k], out[k] = v, v
+	}
+	if err == nil {
+		for _, k := range ask {
+			if _, ok := found[k]; !ok {
+				c.missing[k] = true
+			}
+		}
+	}
+	return out, err
+}
@@ -0,0 +1,163 @@
+package providerapi
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/ratelimit"
+)
+
+type budget struct {
+	mu sync.Mutex
+	b  ratelimit.Budget
+}
+
+func (b *budget) Budget() (ratelimit.Budget, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b, true
+}
+
+// recorder fetches the square of each key, recording the batches and the
+// most of them in flight at once.
+type recorder struct {
+	mu       sync.Mutex
+	batches  [][]int
+	inFlight int
+	most     int
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) (map[int]int, error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, keys)
+	r.inFlight++
+	r.most = max(r.most, r.inFlight)
+	r.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	r.mu.Lock()
+	r.inFlight--
+	r.mu.Unlock()
+
+	m := make(map[int]int)
+	for _, k := range keys {
+		if k%10 != 0 {
+			m[k] = k * k
+		}
+	}
+	return m, nil
+}
+
+func keys(n int) []int {
+	ks := make([]int, n)
+	for i := range ks {
+		ks[i] = i + 1
+	}
+	return ks
+}
+
+func TestRun(t *testing.T) {
+	r := &recorder{}
+	found, err := Run(context.Background(), Batches{Size: 10, Concurrency: 3}, keys(95), r.fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.batches) != 10 {
+		t.Errorf("Expected 10 batches, got %d", len(r.batches))
+	}
+	if r.most != 3 {
+		t.Errorf("Expected 3 batches in flight, got %d", r.most)
+	}
+	if len(found) != 86 || found[7] != 49 {
+		t.Errorf("Expected 86 values with 7 squared, got %d and %d", len(found), found[7])
+	}
+	if _, ok := found[10]; ok {
+		t.Errorf("Expected no value for a key fetch left out")
+	}
+}
+
+func TestRunDefaults(t *testing.T) {
+	r := &recorder{}
+	if _, err := Run(context.Background(), Batches{}, keys(120), r.fetch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.batches) != 3 || len(r.batches[0]) != DefaultSize {
+		t.Errorf("Expected 3 batches of %d, got %d", DefaultSize, len(r.batches))
+	}
+	if r.most > DefaultConcurrency {
+		t.Errorf("Expected at most %d batches in flight, got %d", DefaultConcurrency, r.most)
+	}
+}
+
+func TestRunBackPressure(t *testing.T) {
+	low := &budget{b: ratelimit.Budget{Limit: 5000, Remaining: 300}}
+	r := &recorder{}
+	if _, err := Run(context.Background(), Batches{Size: 5, Concurrency: 4, Budget: low}, keys(40), r.fetch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.most != 1 {
+		t.Errorf("Expected one batch at a time on a low budget, got %d", r.most)
+	}
+
+	plenty := &budget{b: ratelimit.Budget{Limit: 5000, Remaining: 4000}}
+	r = &recorder{}
+	if _, err := Run(context.Background(), Batches{Size: 5, Concurrency: 4, Budget: plenty}, keys(40), r.fetch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.most != 4 {
+		t.Errorf("Expected 4 batches in flight, got %d", r.most)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	boom := errors.New("boom")
+	var mu sync.Mutex
+	calls := 0
+	found, err := Run(context.Background(), Batches{Size: 2, Concurrency: 1}, keys(10), func(ctx context.Context, ks []int) (map[int]int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 2 {
+			return nil, boom
+		}
+		return map[int]int{ks[0]: 1}, nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("Expected %v, got %v", boom, err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the batches to stop after the failure, got %d calls", calls)
+	}
+	if len(found) != 1 {
+		t.Errorf("Expected the value found before the failure, got %v", found)
+	}
+}
+
+func TestCache(t *testing.T) {
+	var c Cache[int, int]
+	r := &recorder{}
+	got, err := c.Get(context.Background(), Batches{Size: 10}, []int{1, 2, 10, 2}, r.fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[2] != 4 {
+		t.Errorf("Expected 2 values, got %v", got)
+	}
+	if !slices.Equal(r.batches[0], []int{1, 2, 10}) {
+		t.Errorf("Expected each key asked once, got %v", r.batches)
+	}
+
+	got, err = c.Get(context.Background(), Batches{Size: 10}, []int{1, 3, 10}, r.fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[3] != 9 {
+		t.Errorf("Expected 2 values, got %v", got)
+	}
+	if len(r.batches) != 2 || !slices.Equal(r.batches[1], []int{3}) {
+		t.Errorf("Expected only the new key asked, got %v", r.batches)
+	}
+}
@@ -0,0 +1,167 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrLFSPointer is returned by OpenAsset for a Git LFS pointer file: the
+// asset was checked out without `git lfs pull`, and uploading it would
+// publish the pointer instead of the file.
+var ErrLFSPointer = errors.New("asset is a Git LFS pointer")
+
+// lfsPointer starts every Git LFS pointer file, which is at most a few
+// hundred bytes.
+const (
+	lfsPointer    = "version https://git-lfs.github.com/spec/"
+	maxPointerLen = 1024
+)
+
+// Asset is a release asset opened for upload. Its request bodies read the
+// file as they are sent, so that assets of any size upload in constant
+// memory, and report the bytes read to the Uploader's Transfer callback.
+type Asset struct {
+	// Name is the file name of the asset, and Size its size in bytes.
+	Name string
+	Size int64
+
+	f      *os.File
+	report func(sent int64)
+}
+
+// OpenAsset opens the asset at path. Within an Uploader's upload function,
+// ctx carries the callback reporting the transfer of the asset.
+func OpenAsset(ctx context.Context, path string) (*Asset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size() <= maxPointerLen {
+		head := make([]byte, len(lfsPointer))
+		if n, _ := f.ReadAt(head, 0); n == len(head) && string(head) == lfsPointer {
+			f.Close()
+			return nil, fmt.Errorf("%w: %s; run git lfs pull before publishing", ErrLFSPointer, path)
+		}
+	}
+	report, _ := ctx.Value(transferKey{}).(func(int64))
+	return &Asset{Name: filepath.Base(path), Size: info.Size(), f: f, report: report}, nil
+}
+
+// Body returns the content of the asset as a request body. A SectionReader
+// is streamed by the HTTP client, can be rewound with Seek for a retry,
+// and gives the request its Content-Length.
+func (a *Asset) Body() *io.SectionReader {
+	return io.NewSectionReader(a.reader(), 0, a.Size)
+}
+
+// FormField is a text field of a multipart form. A name may repeat, for a
+// field with several values.
+type FormField struct{ Name, Value string }
+
+// Multipart returns a multipart/form-data request body with the asset as
+// the file of field, and its content type. The fields come before the
+// file, as upload APIs that read the form as a stream, like PyPI's, expect.
+// Like Body, it streams the file: only the fields and part headers are held
+// in memory.
+func (a *Asset) Multipart(field string, fields ...FormField) (*io.SectionReader, string, error) {
+	var head bytes.Buffer
+	mw := multipart.NewWriter(&head)
+	for _, f := range fields {
+		if err := mw.WriteField(f.Name, f.Value); err != nil {
+			return nil, "", err
+		}
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(field), escapeQuotes(a.Name)))
+	h.Set("Content-Type", "application/octet-stream")
+	if _, err := mw.CreatePart(h); err != nil {
+		return nil, "", err
+	}
+	tail := "\r\n--" + mw.Boundary() + "--\r\n"
+
+	parts := multiReaderAt{
+		io.NewSectionReader(bytes.NewReader(head.Bytes()), 0, int64(head.Len())),
+		io.NewSectionReader(a.reader(), 0, a.Size),
+		io.NewSectionReader(strings.NewReader(tail), 0, int64(len(tail))),
+	}
+	return io.NewSectionReader(parts, 0, parts.size()), mw.FormDataContentType(), nil
+}
+
+// Close closes the file of the asset.
+func (a *Asset) Close() error {
+	return a.f.Close()
+}
+
+func (a *Asset) reader() io.ReaderAt {
+	if a.report == nil {
+		return a.f
+	}
+	return progressReaderAt{r: a.f, report: a.report}
+}
+
+// progressReaderAt reports the end of every read, the bytes sent so far
+// when the HTTP client reads the body in order.
+type progressReaderAt struct {
+	r      io.ReaderAt
+	report func(int64)
+}
+
+func (p progressReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	n, err := p.r.ReadAt(b, off)
+	if n > 0 {
+		p.report(off + int64(n))
+	}
+	return n, err
+}
+
+// multiReaderAt reads its sections one after the other, as one.
+type multiReaderAt []*io.SectionReader
+
+func (m multiReaderAt) size() int64 {
+	var n int64
+	for _, s := range m {
+		n += s.Size()
+	}
+	return n
+}
+
+func (m multiReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	var n int
+	for _, s := range m {
+		if off >= s.Size() {
+			off -= s.Size()
+			continue
+		}
+		k, err := s.ReadAt(b[n:], off)
+		n += k
+		if n == len(b) {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+		off = 0
+	}
+	return n, io.EOF
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// escapeQuotes escapes a Content-Disposition parameter as
+// mime/multipart does.
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
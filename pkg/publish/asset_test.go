@@ -0,0 +1,136 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeAsset(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAssetBody(t *testing.T) {
+	a, err := OpenAsset(context.Background(), writeAsset(t, "app.tar.gz", "binary content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if a.Name != "app.tar.gz" || a.Size != 14 {
+		t.Errorf("Expected app.tar.gz of 14 bytes, got %s of %d", a.Name, a.Size)
+	}
+
+	body := a.Body()
+	for range 2 {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(body)
+		if err != nil || string(b) != "binary content" {
+			t.Errorf("Expected the file content, got %q and %v", b, err)
+		}
+	}
+}
+
+func TestAssetMultipart(t *testing.T) {
+	a, err := OpenAsset(context.Background(), writeAsset(t, `my "app".zip`, strings.Repeat("x", 10000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	body, contentType, err := a.Multipart("file", FormField{"name", "app"}, FormField{"classifiers", "A"}, FormField{"classifiers", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read twice, as a retry would.
+	for range 2 {
+		body.Seek(0, io.SeekStart)
+		form, err := multipart.NewReader(body, params["boundary"]).ReadForm(1 << 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fh := form.File["file"]
+		if len(fh) != 1 || fh[0].Filename != `my "app".zip` || fh[0].Size != 10000 {
+			t.Fatalf("Expected the file part, got %+v", form.File)
+		}
+		if got := form.Value["classifiers"]; form.Value["name"][0] != "app" || len(got) != 2 || got[1] != "B" {
+			t.Errorf("Expected the fields, got %v", form.Value)
+		}
+	}
+	data, _ := io.ReadAll(io.NewSectionReader(body, 0, body.Size()))
+	if int64(len(data)) != body.Size() {
+		t.Errorf("Expected %d bytes, got %d", body.Size(), len(data))
+	}
+}
+
+func TestOpenAssetLFSPointer(t *testing.T) {
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a\nsize 2147483648\n"
+	_, err := OpenAsset(context.Background(), writeAsset(t, "installer.exe", pointer))
+	if !errors.Is(err, ErrLFSPointer) || !strings.Contains(err.Error(), "git lfs pull") {
+		t.Errorf("Expected ErrLFSPointer, got %v", err)
+	}
+	if _, err := OpenAsset(context.Background(), filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file, got %v", err)
+	}
+}
+
+func TestUploaderTransfer(t *testing.T) {
+	defer func(d time.Duration) { TransferInterval = d }(TransferInterval)
+	TransferInterval = 0
+	path := writeAsset(t, "big.bin", strings.Repeat("x", 3000))
+
+	var transfers []Transfer
+	u := Uploader{Transfer: func(tr Transfer) { transfers = append(transfers, tr) }}
+	_, err := u.Upload(context.Background(), []string{path}, func(ctx context.Context, i int) error {
+		a, err := OpenAsset(ctx, path)
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		r := io.NewSectionReader(a.Body(), 0, a.Size)
+		buf := make([]byte, 1000)
+		for {
+			if _, err := r.Read(buf); err == io.EOF {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 3 || transfers[2] != (Transfer{Asset: path, Sent: 3000, Size: 3000}) {
+		t.Errorf("Expected three transfer reports up to 3000 bytes, got %+v", transfers)
+	}
+
+	transfers = nil
+	u.Interval = time.Hour
+	u.Upload(context.Background(), []string{path}, func(ctx context.Context, i int) error {
+		a, err := OpenAsset(ctx, path)
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		_, err = io.Copy(io.Discard, io.NewSectionReader(a.Body(), 0, a.Size))
+		return err
+	})
+	if len(transfers) != 0 {
+		t.Errorf("Expected no transfer reports within the interval, got %+v", transfers)
+	}
+}
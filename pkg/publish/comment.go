@@ -0,0 +1,21 @@
+package publish
+
+import "context"
+
+// Comment is a comment on a pull request.
+type Comment struct {
+	// URL is the web page of the comment.
+	URL string
+	// Updated is true when an existing comment was edited.
+	Updated bool
+}
+
+// Commenter is implemented by providers that can comment on pull requests,
+// merge requests on GitLab.
+type Commenter interface {
+	// UpsertComment edits the first comment of pull request number that
+	// contains marker, typically an HTML comment, to body, or posts body as
+	// a new comment when there is none. body should contain marker so the
+	// next call finds the comment again.
+	UpsertComment(ctx context.Context, number int, marker, body string) (Comment, error)
+}
@@ -0,0 +1,311 @@
+// Package docker publishes container images: a locally built image is
+// tagged with the release version, and any other configured tags such as
+// "latest", and pushed to a registry such as ghcr.io or Docker Hub.
+//
+// Registry credentials come from the environment when DOCKER_USERNAME and
+// DOCKER_PASSWORD are set, in which case the publisher logs in to a
+// temporary docker configuration, and from the standard docker
+// configuration ($DOCKER_CONFIG or ~/.docker/config.json) otherwise.
+package docker
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+const (
+	// UsernameEnv and PasswordEnv are the environment variables read by
+	// NewFromEnv for registry credentials. The password may be a token.
+	UsernameEnv = "DOCKER_USERNAME"
+	PasswordEnv = "DOCKER_PASSWORD"
+
+	// DockerHub is the registry of images without a registry host, such as
+	// "org/app".
+	DockerHub = "docker.io"
+)
+
+// DefaultTags are pushed when a Publisher has no Tags: the version and,
+// for releases that are not prereleases, "latest".
+var DefaultTags = []string{"{{ .Version }}", "latest"}
+
+// ErrNoCredentials is returned when neither the environment nor the docker
+// configuration holds credentials for the registry.
+var ErrNoCredentials = errors.New("docker: no registry credentials")
+
+// Publisher tags and pushes an image for each release.
+type Publisher struct {
+	// Image is the repository pushed to, e.g. "ghcr.io/org/app".
+	Image string
+	// Source is the local image to tag, e.g. "app:dev"; empty means
+	// Image itself, as built by "docker build -t ghcr.io/org/app".
+	Source string
+	// Tags are text/template names for the pushed tags, executed with
+	// .Tag, .Version, .Major, .Minor, .Patch and .Prerelease. Empty means
+	// DefaultTags.
+	Tags []string
+	// Username and Password log in to the registry of Image; empty uses
+	// the credentials already in the docker configuration.
+	Username string
+	Password string
+
+	// Retry governs retries of pushes failing with a network error. The
+	// zero value pushes each tag once.
+	Retry retry.Policy
+	// Stderr receives docker's output; nil discards it.
+	Stderr io.Writer
+	// DryRun describes the tags and pushes on Log instead of running them.
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every docker command; nil
+	// discards.
+	Logger *slog.Logger
+
+	// config is the temporary docker configuration of a login.
+	config string
+}
+
+// NewFromEnv returns a Publisher for image that logs in with the
+// credentials in UsernameEnv and PasswordEnv, when both are set.
+func NewFromEnv(image string) *Publisher {
+	return NewFromSecrets(image, os.Getenv)
+}
+
+// NewFromSecrets is NewFromEnv with the credentials looked up by secret.
+func NewFromSecrets(image string, secret func(name string) string) *Publisher {
+	return &Publisher{
+		Image:    image,
+		Username: secret(UsernameEnv),
+		Password: secret(PasswordEnv),
+	}
+}
+
+// Publish tags the source image with every tag of r and pushes them, in
+// order. Pushing a tag that already exists overwrites it, so a failed
+// release can be published again. The release's assets are ignored.
+func (p *Publisher) Publish(ctx context.Context, r publish.Release) (_ publish.Result, err error) {
+	refs, err := p.refs(r)
+	if err != nil {
+		return publish.Result{}, err
+	}
+	source := cmp.Or(p.Source, p.Image)
+	if p.DryRun {
+		for _, ref := range refs {
+			dryrun.Printf(p.Log, "would tag %s as %s", source, ref)
+			dryrun.Printf(p.Log, "would push %s", ref)
+		}
+		return publish.Result{}, nil
+	}
+
+	registry := Registry(p.Image)
+	if p.Username != "" && p.Password != "" {
+		if p.config, err = os.MkdirTemp("", "release-docker-"); err != nil {
+			return publish.Result{}, fmt.Errorf("docker: %w", err)
+		}
+		defer func() {
+			err = errors.Join(err, os.RemoveAll(p.config))
+			p.config = ""
+		}()
+		if _, err := p.run(ctx, strings.NewReader(p.Password), "login", "--username", p.Username, "--password-stdin", registry); err != nil {
+			return publish.Result{}, err
+		}
+	} else if err := CheckCredentials(registry); err != nil {
+		return publish.Result{}, err
+	}
+
+	for _, ref := range refs {
+		if ref != source {
+			if _, err := p.run(ctx, nil, "tag", source, ref); err != nil {
+				return publish.Result{}, err
+			}
+		}
+	}
+	var pushed []string
+	for _, ref := range refs {
+		err := p.Retry.Do(ctx, func(ctx context.Context) error {
+			_, err := p.run(ctx, nil, "push", ref)
+			if err != nil && transient(err) {
+				return retry.Retryable(err)
+			}
+			return err
+		})
+		if err != nil {
+			return publish.Result{Uploaded: pushed}, err
+		}
+		pushed = append(pushed, ref)
+	}
+	return publish.Result{ID: refs[0], Uploaded: pushed}, nil
+}
+
+// tagName matches a valid image tag.
+var tagName = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
+// refs returns the image references to push for r, without duplicates.
+func (p *Publisher) refs(r publish.Release) ([]string, error) {
+	if p.Image == "" {
+		return nil, errors.New("docker: no image")
+	}
+	data := struct {
+		Tag, Version        string
+		Major, Minor, Patch uint64
+		Prerelease          bool
+	}{Tag: r.Tag, Version: cmp.Or(r.Version, strings.TrimPrefix(r.Tag, "v")), Prerelease: r.Prerelease}
+	if v, err := version.Parse(data.Version); err == nil {
+		data.Major, data.Minor, data.Patch = v.Major, v.Minor, v.Patch
+	}
+
+	tags := p.Tags
+	if len(tags) == 0 {
+		tags = DefaultTags
+		if r.Prerelease {
+			tags = tags[:1]
+		}
+	}
+	var refs []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		tmpl, err := template.New("tag").Option("missingkey=error").Parse(t)
+		if err != nil {
+			return nil, fmt.Errorf("docker: tag %q: %w", t, err)
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("docker: tag %q: %w", t, err)
+		}
+		// "+" separates semver build metadata but is not allowed in tags.
+		name := strings.ReplaceAll(b.String(), "+", "_")
+		if !tagName.MatchString(name) {
+			return nil, fmt.Errorf("docker: tag %q: %q is not a valid image tag", t, name)
+		}
+		if ref := p.Image + ":" + name; !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// Registry returns the registry host of image, e.g. "ghcr.io" for
+// "ghcr.io/org/app" and DockerHub for "org/app".
+func Registry(image string) string {
+	host, _, ok := strings.Cut(image, "/")
+	if !ok || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return DockerHub
+	}
+	return host
+}
+
+// dockerConfig is the part of config.json that holds credentials.
+type dockerConfig struct {
+	Auths       map[string]json.RawMessage `json:"auths"`
+	CredHelpers map[string]string          `json:"credHelpers"`
+	CredsStore  string                     `json:"credsStore"`
+}
+
+// CheckCredentials reports whether the docker configuration has
+// credentials for registry: an auths entry, a credential helper for it or
+// a credential store, which is trusted to hold them.
+func CheckCredentials(registry string) error {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("docker: %w", err)
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	path := filepath.Join(dir, "config.json")
+	missing := fmt.Errorf("%w for %s in %s; run docker login %s or set %s and %s", ErrNoCredentials, registry, path, registry, UsernameEnv, PasswordEnv)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return missing
+	} else if err != nil {
+		return fmt.Errorf("docker: %w", err)
+	}
+	var c dockerConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("docker: %s: %w", path, err)
+	}
+	if c.CredsStore != "" {
+		return nil
+	}
+	keys := []string{registry, "https://" + registry}
+	if registry == DockerHub {
+		keys = append(keys, "https://index.docker.io/v1/", "index.docker.io")
+	}
+	for _, k := range keys {
+		if _, ok := c.Auths[k]; ok {
+			return nil
+		}
+		if _, ok := c.CredHelpers[k]; ok {
+			return nil
+		}
+	}
+	return missing
+}
+
+// transientErrors are fragments of docker's messages for registry failures
+// that may succeed when tried again.
+var transientErrors = []string{
+	"connection reset",
+	"connection refused",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"unexpected EOF",
+	"net/http: request canceled",
+	"429 Too Many Requests",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+}
+
+func transient(err error) bool {
+	for _, s := range transientErrors {
+		if strings.Contains(err.Error(), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs docker with args, using the temporary configuration of a login
+// if there is one, and returns its standard output.
+func (p *Publisher) run(ctx context.Context, stdin io.Reader, args ...string) (string, error) {
+	log.Or(p.Logger).Debug("docker", "args", strings.Join(args, " "))
+	full := args
+	if p.config != "" {
+		full = append([]string{"--config", p.config}, args...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", full...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if p.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(&stderr, p.Stderr)
+	}
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("docker %s: %w", args[0], ctx.Err())
+		}
+		return "", fmt.Errorf("docker %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
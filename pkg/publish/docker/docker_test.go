@@ -0,0 +1,238 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// stubDocker puts a docker script on PATH that appends its arguments, and
+// its standard input for login, to a file whose path is returned.
+func stubDocker(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\ncase \"$*\" in *login*) cat >> " + calls + "; echo >> " + calls + ";; esac\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return calls
+}
+
+// dockerConfigDir points DOCKER_CONFIG at a directory holding config.
+func dockerConfigDir(t *testing.T, config string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+}
+
+func readCalls(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestPublish(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Publisher
+		release publish.Release
+		calls   []string
+	}{
+		{
+			name:    "default tags",
+			p:       Publisher{Image: "ghcr.io/org/app", Source: "app:dev"},
+			release: publish.Release{Tag: "v1.2.3"},
+			calls: []string{
+				"tag app:dev ghcr.io/org/app:1.2.3",
+				"tag app:dev ghcr.io/org/app:latest",
+				"push ghcr.io/org/app:1.2.3",
+				"push ghcr.io/org/app:latest",
+			},
+		},
+		{
+			name:    "prerelease",
+			p:       Publisher{Image: "ghcr.io/org/app", Source: "app:dev"},
+			release: publish.Release{Tag: "app/v2.0.0-rc.1", Version: "2.0.0-rc.1", Prerelease: true},
+			calls: []string{
+				"tag app:dev ghcr.io/org/app:2.0.0-rc.1",
+				"push ghcr.io/org/app:2.0.0-rc.1",
+			},
+		},
+		{
+			name: "templates",
+			p: Publisher{Image: "ghcr.io/org/app", Tags: []string{
+				"{{ .Tag }}", "{{ .Major }}.{{ .Minor }}", "{{ .Major }}", "{{ .Major }}",
+			}},
+			release: publish.Release{Tag: "v1.2.3"},
+			calls: []string{
+				"tag ghcr.io/org/app ghcr.io/org/app:v1.2.3",
+				"tag ghcr.io/org/app ghcr.io/org/app:1.2",
+				"tag ghcr.io/org/app ghcr.io/org/app:1",
+				"push ghcr.io/org/app:v1.2.3",
+				"push ghcr.io/org/app:1.2",
+				"push ghcr.io/org/app:1",
+			},
+		},
+		{
+			name:    "build metadata",
+			p:       Publisher{Image: "ghcr.io/org/app", Tags: []string{"{{ .Version }}"}},
+			release: publish.Release{Tag: "v1.2.3+build.5"},
+			calls: []string{
+				"tag ghcr.io/org/app ghcr.io/org/app:1.2.3_build.5",
+				"push ghcr.io/org/app:1.2.3_build.5",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := stubDocker(t)
+			dockerConfigDir(t, `{"auths": {"ghcr.io": {"auth": "dXNlcjpwYXNz"}}}`)
+
+			result, err := tt.p.Publish(context.Background(), tt.release)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := readCalls(t, calls); !slices.Equal(got, tt.calls) {
+				t.Errorf("Expected calls %q, got %q", tt.calls, got)
+			}
+			pushed := tt.calls[len(tt.calls)/2:]
+			if result.ID != strings.TrimPrefix(pushed[0], "push ") {
+				t.Errorf("Expected ID %q, got %q", strings.TrimPrefix(pushed[0], "push "), result.ID)
+			}
+			if len(result.Uploaded) != len(pushed) {
+				t.Errorf("Expected %d pushed tags, got %q", len(pushed), result.Uploaded)
+			}
+		})
+	}
+}
+
+func TestPublishLogin(t *testing.T) {
+	calls := stubDocker(t)
+	t.Setenv("DOCKER_CONFIG", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv(UsernameEnv, "bot")
+	t.Setenv(PasswordEnv, "s3cret")
+
+	p := NewFromEnv("org/app")
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0", Prerelease: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := readCalls(t, calls)
+	if len(got) != 4 {
+		t.Fatalf("Expected login, password, tag and push, got %q", got)
+	}
+	fields := strings.Fields(got[0])
+	if len(fields) != 7 || fields[0] != "--config" || strings.Join(fields[2:], " ") != "login --username bot --password-stdin docker.io" {
+		t.Errorf("Expected a login to docker.io in a temporary config, got %q", got[0])
+	}
+	if got[1] != "s3cret" {
+		t.Errorf("Expected the password on stdin, got %q", got[1])
+	}
+	for i, want := range []string{"tag org/app org/app:1.0.0", "push org/app:1.0.0"} {
+		if want = "--config " + fields[1] + " " + want; got[i+2] != want {
+			t.Errorf("Expected %q, got %q", want, got[i+2])
+		}
+	}
+	if _, err := os.Stat(fields[1]); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the temporary config to be removed, got %v", err)
+	}
+}
+
+func TestPublishNoCredentials(t *testing.T) {
+	calls := stubDocker(t)
+	dockerConfigDir(t, `{"auths": {"ghcr.io": {}}}`)
+
+	p := &Publisher{Image: "registry.example.com/app"}
+	_, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"})
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("Expected ErrNoCredentials, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "docker login registry.example.com") {
+		t.Errorf("Expected advice to log in, got %q", err)
+	}
+	if _, err := os.Stat(calls); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected docker not to run, got %v", err)
+	}
+}
+
+func TestPublishDryRun(t *testing.T) {
+	calls := stubDocker(t)
+	var log bytes.Buffer
+	p := &Publisher{Image: "ghcr.io/org/app", Source: "app:dev", DryRun: true, Log: &log}
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.3"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"would tag app:dev as ghcr.io/org/app:1.2.3",
+		"would push ghcr.io/org/app:1.2.3",
+		"would push ghcr.io/org/app:latest",
+	} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("Expected %q in %q", want, log.String())
+		}
+	}
+	if _, err := os.Stat(calls); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected docker not to run, got %v", err)
+	}
+}
+
+func TestPublishInvalidTag(t *testing.T) {
+	p := &Publisher{Image: "ghcr.io/org/app", Tags: []string{"release/{{ .Version }}"}}
+	_, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.3"})
+	if err == nil || !strings.Contains(err.Error(), "not a valid image tag") {
+		t.Errorf("Expected an invalid tag error, got %v", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	tests := map[string]string{
+		"ghcr.io/org/app":          "ghcr.io",
+		"org/app":                  DockerHub,
+		"app":                      DockerHub,
+		"localhost/app":            "localhost",
+		"localhost:5000/org/app":   "localhost:5000",
+		"registry.example.com/app": "registry.example.com",
+	}
+	for image, want := range tests {
+		if got := Registry(image); got != want {
+			t.Errorf("Registry(%q): Expected %q, got %q", image, want, got)
+		}
+	}
+}
+
+func TestCheckCredentials(t *testing.T) {
+	tests := []struct {
+		config, registry string
+		ok               bool
+	}{
+		{`{"auths": {"https://index.docker.io/v1/": {}}}`, DockerHub, true},
+		{`{"credHelpers": {"123.dkr.ecr.us-east-1.amazonaws.com": "ecr-login"}}`, "123.dkr.ecr.us-east-1.amazonaws.com", true},
+		{`{"credsStore": "desktop"}`, "ghcr.io", true},
+		{`{"auths": {"ghcr.io": {}}}`, DockerHub, false},
+		{`{}`, "ghcr.io", false},
+	}
+	for _, tt := range tests {
+		dockerConfigDir(t, tt.config)
+		err := CheckCredentials(tt.registry)
+		if tt.ok && err != nil {
+			t.Errorf("%s for %s: unexpected error: %v", tt.config, tt.registry, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("%s for %s: Expected ErrNoCredentials, got %v", tt.config, tt.registry, err)
+		}
+	}
+}
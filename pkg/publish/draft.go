@@ -0,0 +1,12 @@
+package publish
+
+import "context"
+
+// Promoter is implemented by providers that keep draft releases, which
+// release draft creates and release approve publishes.
+type Promoter interface {
+	// Promote publishes the draft release of tag. A release of tag already
+	// published is returned as Existing. It reports false when tag has
+	// neither.
+	Promote(ctx context.Context, tag string) (Result, bool, error)
+}
@@ -0,0 +1,40 @@
+package publish
+
+import (
+	"context"
+	"io"
+)
+
+// Published is a release as the provider reports it.
+type Published struct {
+	Tag  string
+	Name string
+	// URL is the web page of the release.
+	URL string
+	// Commit is the commit the provider says the tag points at, or ""
+	// when it does not say.
+	Commit string
+	Assets []RemoteAsset
+}
+
+// RemoteAsset is a file attached to a published release.
+type RemoteAsset struct {
+	Name string
+	// Size is the size in bytes, or 0 when the provider does not report
+	// it.
+	Size int64
+	// URL is where Download fetches the content from.
+	URL string
+}
+
+// Fetcher is implemented by providers that can read a published release
+// back, as release verify does.
+type Fetcher interface {
+	// Fetch returns the release of tag. It reports false when tag has no
+	// release.
+	Fetch(ctx context.Context, tag string) (Published, bool, error)
+	// Download writes the content of a, an asset of a fetched release, to
+	// w. Requests are retried until the content starts, not after: a
+	// failure midway leaves part of it in w.
+	Download(ctx context.Context, a RemoteAsset, w io.Writer) error
+}
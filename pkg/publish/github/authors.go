@@ -0,0 +1,33 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+)
+
+var _ contributors.LoginFinder = (*Publisher)(nil)
+
+type commitResponse struct {
+	// Author is null when the commit's author address belongs to no
+	// account.
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+// CommitAuthor returns the login of the GitHub account the author of the
+// commit hash belongs to, or "" when their address matches no account.
+func (p *Publisher) CommitAuthor(ctx context.Context, hash string) (string, error) {
+	var c commitResponse
+	if err := p.do(ctx, http.MethodGet, p.reposURL("commits/"+url.PathEscape(hash)), "application/json", nil, &c); err != nil {
+		return "", fmt.Errorf("github: author of %s: %w", hash, err)
+	}
+	if c.Author == nil {
+		return "", nil
+	}
+	return c.Author.Login, nil
+}
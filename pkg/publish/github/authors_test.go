@@ -0,0 +1,38 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCommitAuthor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/app/commits/abc123":
+			io.WriteString(w, `{"sha": "abc123", "author": {"login": "ada"}}`)
+		case "/repos/octo/app/commits/def456":
+			io.WriteString(w, `{"sha": "def456", "author": null}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	for hash, expected := range map[string]string{"abc123": "ada", "def456": ""} {
+		login, err := p.CommitAuthor(context.Background(), hash)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if login != expected {
+			t.Errorf("Expected %q for %s, got %q", expected, hash, login)
+		}
+	}
+	if _, err := p.CommitAuthor(context.Background(), "missing"); err == nil {
+		t.Error("Expected an error for an unknown commit")
+	}
+}
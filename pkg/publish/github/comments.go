@@ -0,0 +1,73 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Commenter = (*Publisher)(nil)
+
+type commentResponse struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// commentsPerPage is the page size of comment listings, GitHub's maximum.
+const commentsPerPage = 100
+
+// UpsertComment comments on pull request number through the issues API,
+// which holds the conversation of pull requests.
+func (p *Publisher) UpsertComment(ctx context.Context, number int, marker, body string) (publish.Comment, error) {
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would comment on pull request #%d of %s/%s", number, p.Owner, p.Repo)
+		return publish.Comment{}, nil
+	}
+
+	existing, err := p.findComment(ctx, number, marker)
+	if err != nil {
+		return publish.Comment{}, fmt.Errorf("github: comments of #%d: %w", number, err)
+	}
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return publish.Comment{}, err
+	}
+	var c commentResponse
+	if existing != nil {
+		err = p.do(ctx, http.MethodPatch, p.reposURL("issues/comments/"+strconv.FormatInt(existing.ID, 10)), "application/json", bytes.NewReader(payload), &c)
+	} else {
+		err = p.do(ctx, http.MethodPost, p.reposURL("issues/"+strconv.Itoa(number)+"/comments"), "application/json", bytes.NewReader(payload), &c)
+	}
+	if err != nil {
+		return publish.Comment{}, fmt.Errorf("github: comment on #%d: %w", number, err)
+	}
+	return publish.Comment{URL: c.HTMLURL, Updated: existing != nil}, nil
+}
+
+// findComment returns the first comment of issue number containing marker,
+// or nil.
+func (p *Publisher) findComment(ctx context.Context, number int, marker string) (*commentResponse, error) {
+	for page := 1; ; page++ {
+		var comments []commentResponse
+		endpoint := p.reposURL(fmt.Sprintf("issues/%d/comments?per_page=%d&page=%d", number, commentsPerPage, page))
+		if err := p.do(ctx, http.MethodGet, endpoint, "application/json", nil, &comments); err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			if strings.Contains(comments[i].Body, marker) {
+				return &comments[i], nil
+			}
+		}
+		if len(comments) < commentsPerPage {
+			return nil, nil
+		}
+	}
+}
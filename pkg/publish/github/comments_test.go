@@ -0,0 +1,103 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpsertComment(t *testing.T) {
+	var comments []commentResponse
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		var in struct{ Body string }
+		json.NewDecoder(r.Body).Decode(&in)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/issues/12/comments":
+			// The first page is full of other comments.
+			page := []commentResponse{}
+			if r.URL.Query().Get("page") == "1" {
+				for i := range commentsPerPage {
+					page = append(page, commentResponse{ID: int64(1000 + i), Body: "lgtm"})
+				}
+			} else {
+				page = comments
+			}
+			json.NewEncoder(w).Encode(page)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/octo/app/issues/12/comments":
+			c := commentResponse{ID: 7, Body: in.Body, HTMLURL: "https://github.com/octo/app/pull/12#issuecomment-7"}
+			comments = append(comments, c)
+			json.NewEncoder(w).Encode(c)
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/octo/app/issues/comments/7":
+			comments[0].Body = in.Body
+			json.NewEncoder(w).Encode(comments[0])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	c, err := p.UpsertComment(context.Background(), 12, "<!-- preview -->", "<!-- preview -->\nv1.1.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Updated || c.URL != "https://github.com/octo/app/pull/12#issuecomment-7" {
+		t.Errorf("Expected a new comment, got %+v", c)
+	}
+	c, err = p.UpsertComment(context.Background(), 12, "<!-- preview -->", "<!-- preview -->\nv2.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.Updated || len(comments) != 1 || comments[0].Body != "<!-- preview -->\nv2.0.0" {
+		t.Errorf("Expected the comment updated, got %+v and %+v", c, comments)
+	}
+
+	expected := []string{
+		fmt.Sprintf("GET /repos/octo/app/issues/12/comments?per_page=%d&page=1", commentsPerPage),
+		fmt.Sprintf("GET /repos/octo/app/issues/12/comments?per_page=%d&page=2", commentsPerPage),
+		"POST /repos/octo/app/issues/12/comments",
+	}
+	if got := strings.Join(requests[:3], "\n"); got != strings.Join(expected, "\n") {
+		t.Errorf("Expected %q, got %q", expected, requests[:3])
+	}
+	if last := requests[len(requests)-1]; last != "PATCH /repos/octo/app/issues/comments/7" {
+		t.Errorf("Expected a PATCH last, got %s", last)
+	}
+}
+
+func TestUpsertCommentError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"message": "Resource not accessible by integration"}`)
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	p.Retry.MaxAttempts = 1
+
+	if _, err := p.UpsertComment(context.Background(), 12, "m", "m"); err == nil || !strings.Contains(err.Error(), "comments of #12") {
+		t.Errorf("Expected the listing error, got %v", err)
+	}
+}
+
+func TestUpsertCommentDryRun(t *testing.T) {
+	var log bytes.Buffer
+	p := New("octo", "app", "secret")
+	p.DryRun, p.Log = true, &log
+
+	if _, err := p.UpsertComment(context.Background(), 12, "m", "m"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "[dry-run] would comment on pull request #12 of octo/app\n"; log.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, log.String())
+	}
+}
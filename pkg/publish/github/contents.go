@@ -0,0 +1,158 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The methods below edit files of the repository through the contents,
+// git references and pull requests APIs, as the Homebrew publisher does for
+// a tap. None of them honour DryRun; callers describe their changes
+// themselves.
+
+type contentResponse struct {
+	SHA      string `json:"sha"`
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+type putContentRequest struct {
+	Message string `json:"message"`
+	Content string `json:"content"`
+	SHA     string `json:"sha,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+}
+
+type refResponse struct {
+	Object struct {
+		SHA string `json:"sha"`
+	} `json:"object"`
+}
+
+type pullResponse struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	// MergedAt is null for pull requests not merged.
+	MergedAt *string `json:"merged_at"`
+}
+
+// DefaultBranch returns the name of the repository's default branch.
+func (p *Publisher) DefaultBranch(ctx context.Context) (string, error) {
+	var repo struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := p.do(ctx, http.MethodGet, strings.TrimSuffix(p.reposURL(""), "/"), "application/json", nil, &repo); err != nil {
+		return "", fmt.Errorf("github: repository %s/%s: %w", p.Owner, p.Repo, err)
+	}
+	return repo.DefaultBranch, nil
+}
+
+// File returns the content and blob SHA of the file at path on ref, an
+// empty ref meaning the default branch. A missing file has an empty SHA and
+// no error.
+func (p *Publisher) File(ctx context.Context, path, ref string) ([]byte, string, error) {
+	resource := "contents/" + escapePath(path)
+	if ref != "" {
+		resource += "?ref=" + url.QueryEscape(ref)
+	}
+	var c contentResponse
+	err := p.do(ctx, http.MethodGet, p.reposURL(resource), "application/json", nil, &c)
+	if errors.Is(err, errNotFound) {
+		return nil, "", nil
+	} else if err != nil {
+		return nil, "", fmt.Errorf("github: get %s: %w", path, err)
+	}
+	if c.Encoding != "base64" {
+		return nil, "", fmt.Errorf("github: get %s: unsupported encoding %q", path, c.Encoding)
+	}
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(c.Content, "\n", ""))
+	if err != nil {
+		return nil, "", fmt.Errorf("github: get %s: %w", path, err)
+	}
+	return content, c.SHA, nil
+}
+
+// PutFile commits content to path on branch with message and returns the
+// web page of the commit. sha is the blob SHA of the file being replaced,
+// as returned by File, or empty to create it.
+func (p *Publisher) PutFile(ctx context.Context, path, branch, message string, content []byte, sha string) (string, error) {
+	payload, err := json.Marshal(putContentRequest{
+		Message: message,
+		Content: base64.StdEncoding.EncodeToString(content),
+		SHA:     sha,
+		Branch:  branch,
+	})
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Commit struct {
+			HTMLURL string `json:"html_url"`
+		} `json:"commit"`
+	}
+	if err := p.do(ctx, http.MethodPut, p.reposURL("contents/"+escapePath(path)), "application/json", bytes.NewReader(payload), &resp); err != nil {
+		return "", fmt.Errorf("github: put %s: %w", path, err)
+	}
+	return resp.Commit.HTMLURL, nil
+}
+
+// CreateBranch creates branch name at the head of branch from. An existing
+// branch is left as it is, so an interrupted run can be repeated.
+func (p *Publisher) CreateBranch(ctx context.Context, name, from string) error {
+	var ref refResponse
+	err := p.do(ctx, http.MethodGet, p.reposURL("git/ref/heads/"+escapePath(name)), "application/json", nil, &ref)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, errNotFound) {
+		return fmt.Errorf("github: branch %s: %w", name, err)
+	}
+	if err := p.do(ctx, http.MethodGet, p.reposURL("git/ref/heads/"+escapePath(from)), "application/json", nil, &ref); err != nil {
+		return fmt.Errorf("github: branch %s: %w", from, err)
+	}
+	payload, err := json.Marshal(map[string]string{"ref": "refs/heads/" + name, "sha": ref.Object.SHA})
+	if err != nil {
+		return err
+	}
+	if err := p.do(ctx, http.MethodPost, p.reposURL("git/refs"), "application/json", bytes.NewReader(payload), nil); err != nil {
+		return fmt.Errorf("github: create branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// PullRequest returns the web page of the open pull request from head into
+// base, opening one with title and body when there is none.
+func (p *Publisher) PullRequest(ctx context.Context, head, base, title, body string) (string, error) {
+	query := url.Values{"head": {p.Owner + ":" + head}, "base": {base}, "state": {"open"}}
+	var open []pullResponse
+	if err := p.do(ctx, http.MethodGet, p.reposURL("pulls?"+query.Encode()), "application/json", nil, &open); err != nil {
+		return "", fmt.Errorf("github: pull requests from %s: %w", head, err)
+	}
+	if len(open) > 0 {
+		return open[0].HTMLURL, nil
+	}
+	payload, err := json.Marshal(map[string]string{"title": title, "head": head, "base": base, "body": body})
+	if err != nil {
+		return "", err
+	}
+	var pr pullResponse
+	if err := p.do(ctx, http.MethodPost, p.reposURL("pulls"), "application/json", bytes.NewReader(payload), &pr); err != nil {
+		return "", fmt.Errorf("github: open pull request from %s: %w", head, err)
+	}
+	return pr.HTMLURL, nil
+}
+
+// escapePath escapes each element of a slash-separated path.
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, s := range parts {
+		parts[i] = url.PathEscape(s)
+	}
+	return strings.Join(parts, "/")
+}
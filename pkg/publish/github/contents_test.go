@@ -0,0 +1,120 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	var requests []string
+	var put putContentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/octo/tap":
+			io.WriteString(w, `{"default_branch": "main"}`)
+		case "GET /repos/octo/tap/contents/Formula/app.rb":
+			io.WriteString(w, `{"sha": "abc", "encoding": "base64", "content": "b2xk\n"}`)
+		case "GET /repos/octo/tap/contents/Formula/new.rb":
+			w.WriteHeader(http.StatusNotFound)
+		case "PUT /repos/octo/tap/contents/Formula/app.rb":
+			json.NewDecoder(r.Body).Decode(&put)
+			io.WriteString(w, `{"commit": {"html_url": "https://github.com/octo/tap/commit/1"}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "tap", "secret")
+	p.BaseURL = server.URL
+	ctx := context.Background()
+
+	branch, err := p.DefaultBranch(ctx)
+	if err != nil || branch != "main" {
+		t.Errorf("Expected main, got %q (%v)", branch, err)
+	}
+	content, sha, err := p.File(ctx, "Formula/app.rb", "main")
+	if err != nil || string(content) != "old" || sha != "abc" {
+		t.Errorf("Expected old at abc, got %q at %q (%v)", content, sha, err)
+	}
+	content, sha, err = p.File(ctx, "Formula/new.rb", "")
+	if err != nil || content != nil || sha != "" {
+		t.Errorf("Expected no file, got %q at %q (%v)", content, sha, err)
+	}
+	url, err := p.PutFile(ctx, "Formula/app.rb", "main", "app 1.2.0", []byte("new"), "abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url != "https://github.com/octo/tap/commit/1" {
+		t.Errorf("Expected the commit URL, got %q", url)
+	}
+	expected := putContentRequest{Message: "app 1.2.0", Content: "bmV3", SHA: "abc", Branch: "main"}
+	if put != expected {
+		t.Errorf("Expected %+v, got %+v", expected, put)
+	}
+	if requests[1] != "GET /repos/octo/tap/contents/Formula/app.rb?ref=main" {
+		t.Errorf("Expected the ref in the query, got %q", requests[1])
+	}
+}
+
+func TestBranchAndPullRequest(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/octo/tap/git/ref/heads/app-1.2.0":
+			w.WriteHeader(http.StatusNotFound)
+		case "GET /repos/octo/tap/git/ref/heads/main":
+			io.WriteString(w, `{"object": {"sha": "def"}}`)
+		case "POST /repos/octo/tap/git/refs":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["ref"] != "refs/heads/app-1.2.0" || body["sha"] != "def" {
+				t.Errorf("unexpected ref %v", body)
+			}
+			w.WriteHeader(http.StatusCreated)
+		case "GET /repos/octo/tap/pulls":
+			if got := r.URL.Query().Get("head"); got != "octo:app-1.2.0" {
+				t.Errorf("Expected head octo:app-1.2.0, got %q", got)
+			}
+			io.WriteString(w, `[]`)
+		case "POST /repos/octo/tap/pulls":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"html_url": "https://github.com/octo/tap/pull/7"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "tap", "secret")
+	p.BaseURL = server.URL
+	ctx := context.Background()
+
+	if err := p.CreateBranch(ctx, "app-1.2.0", "main"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	url, err := p.PullRequest(ctx, "app-1.2.0", "main", "app 1.2.0", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url != "https://github.com/octo/tap/pull/7" {
+		t.Errorf("Expected the pull request URL, got %q", url)
+	}
+	expected := []string{
+		"GET /repos/octo/tap/git/ref/heads/app-1.2.0",
+		"GET /repos/octo/tap/git/ref/heads/main",
+		"POST /repos/octo/tap/git/refs",
+		"GET /repos/octo/tap/pulls",
+		"POST /repos/octo/tap/pulls",
+	}
+	if !slices.Equal(requests, expected) {
+		t.Errorf("Expected %q, got %q", expected, requests)
+	}
+}
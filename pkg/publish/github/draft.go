@@ -0,0 +1,64 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Promoter = (*Publisher)(nil)
+
+// releasesPerPage is the page size of release listings, GitHub's maximum.
+const releasesPerPage = 100
+
+// Promote publishes the draft release of tag, which attaches it to the tag
+// and makes it the latest release unless it is a pre-release.
+func (p *Publisher) Promote(ctx context.Context, tag string) (publish.Result, bool, error) {
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would publish the draft GitHub release %s on %s/%s", tag, p.Owner, p.Repo)
+		return publish.Result{}, true, nil
+	}
+
+	release, found, err := p.find(ctx, tag)
+	if err != nil {
+		return publish.Result{}, false, fmt.Errorf("github: find release %s: %w", tag, err)
+	}
+	if found {
+		return publish.Result{ID: strconv.FormatInt(release.ID, 10), URL: release.HTMLURL, Existing: true}, true, nil
+	}
+	release, found, err = p.findDraft(ctx, tag)
+	if err != nil || !found {
+		return publish.Result{}, false, err
+	}
+	endpoint := p.reposURL("releases/" + strconv.FormatInt(release.ID, 10))
+	if err := p.do(ctx, http.MethodPatch, endpoint, "application/json", strings.NewReader(`{"draft": false}`), &release); err != nil {
+		return publish.Result{}, false, fmt.Errorf("github: publish draft release %s: %w", tag, err)
+	}
+	return publish.Result{ID: strconv.FormatInt(release.ID, 10), URL: release.HTMLURL}, true, nil
+}
+
+// findDraft looks up the draft release for tag, which only the listing of
+// releases shows, newest first. The boolean result is false when there is
+// none.
+func (p *Publisher) findDraft(ctx context.Context, tag string) (releaseResponse, bool, error) {
+	for page := 1; ; page++ {
+		var releases []releaseResponse
+		endpoint := p.reposURL(fmt.Sprintf("releases?per_page=%d&page=%d", releasesPerPage, page))
+		if err := p.do(ctx, http.MethodGet, endpoint, "application/json", nil, &releases); err != nil {
+			return releaseResponse{}, false, fmt.Errorf("github: find draft release %s: %w", tag, err)
+		}
+		for _, r := range releases {
+			if r.Draft && r.TagName == tag {
+				return r, true, nil
+			}
+		}
+		if len(releases) < releasesPerPage {
+			return releaseResponse{}, false, nil
+		}
+	}
+}
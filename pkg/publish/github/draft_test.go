@@ -0,0 +1,107 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+func TestPromote(t *testing.T) {
+	var requests []string
+	published := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/releases/tags/v1.0.0" && published:
+			io.WriteString(w, `{"id": 8, "html_url": "https://github.com/octo/app/releases/tag/v1.0.0"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/releases":
+			// The first page is full of other releases.
+			var page []string
+			if r.URL.Query().Get("page") == "1" {
+				for i := range releasesPerPage {
+					page = append(page, fmt.Sprintf(`{"id": %d, "tag_name": "v0.%d.0"}`, 100+i, i))
+				}
+			} else {
+				page = append(page, `{"id": 7, "tag_name": "v1.0.0"}`, `{"id": 8, "tag_name": "v1.0.0", "draft": true}`)
+			}
+			io.WriteString(w, "["+strings.Join(page, ",")+"]")
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/octo/app/releases/8":
+			requests = append(requests, string(body))
+			published = true
+			io.WriteString(w, `{"id": 8, "html_url": "https://github.com/octo/app/releases/tag/v1.0.0"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	result, found, err := p.Promote(context.Background(), "v1.0.0")
+	if err != nil || !found {
+		t.Fatalf("Expected the draft published, got %v, %v", found, err)
+	}
+	if result.ID != "8" || result.URL != "https://github.com/octo/app/releases/tag/v1.0.0" || result.Existing {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if len(requests) != 1 || requests[0] != `{"draft": false}` {
+		t.Errorf("Expected the draft flag cleared, got %q", requests)
+	}
+
+	// Approving again finds the published release.
+	if result, found, err = p.Promote(context.Background(), "v1.0.0"); err != nil || !found || !result.Existing || len(requests) != 1 {
+		t.Errorf("Expected the existing release, got %+v, %v, %v", result, found, err)
+	}
+	if _, found, err := p.Promote(context.Background(), "v2.0.0"); found || err != nil {
+		t.Errorf("Expected no draft for v2.0.0, got %v, %v", found, err)
+	}
+
+	var log bytes.Buffer
+	p.DryRun, p.Log = true, &log
+	if _, found, err := p.Promote(context.Background(), "v2.0.0"); !found || err != nil || !strings.Contains(log.String(), "would publish the draft GitHub release v2.0.0 on octo/app") {
+		t.Errorf("Expected a dry-run message, got %q (%v, %v)", log.String(), found, err)
+	}
+}
+
+func TestPublishResumesDraft(t *testing.T) {
+	asset := filepath.Join(t.TempDir(), "app.tar.gz")
+	os.WriteFile(asset, []byte("data"), 0o644)
+	var requests []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/releases":
+			fmt.Fprintf(w, `[{"id": 8, "tag_name": "v1.0.0", "draft": true, "upload_url": "%s/uploads/8{?name,label}", "assets": [{"name": "notes.txt"}]}]`, server.URL)
+		case r.Method == http.MethodPost && r.URL.Path == "/uploads/8":
+			io.WriteString(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	result, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0", Draft: true, Assets: []string{asset}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Existing || result.ID != "8" || len(result.Uploaded) != 1 {
+		t.Errorf("Expected the draft resumed, got %+v", result)
+	}
+	for _, req := range requests {
+		if req == "POST /repos/octo/app/releases" {
+			t.Errorf("Expected no second draft created, got %q", requests)
+		}
+	}
+}
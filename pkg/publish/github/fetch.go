@@ -0,0 +1,39 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Fetcher = (*Publisher)(nil)
+
+// Fetch returns the published release of tag with its assets. GitHub does
+// not report the commit of the tag, so Commit is left empty. Drafts are
+// not attached to their tag and are not found.
+func (p *Publisher) Fetch(ctx context.Context, tag string) (publish.Published, bool, error) {
+	release, found, err := p.find(ctx, tag)
+	if err != nil {
+		return publish.Published{}, false, fmt.Errorf("github: find release %s: %w", tag, err)
+	}
+	if !found {
+		return publish.Published{}, false, nil
+	}
+	r := publish.Published{Tag: release.TagName, Name: release.Name, URL: release.HTMLURL}
+	for _, a := range release.Assets {
+		r.Assets = append(r.Assets, publish.RemoteAsset{Name: a.Name, Size: a.Size, URL: a.URL})
+	}
+	return r, true, nil
+}
+
+// Download writes the content of a to w. The API redirects to the storage
+// of the asset, which is asked without the token.
+func (p *Publisher) Download(ctx context.Context, a publish.RemoteAsset, w io.Writer) error {
+	if err := p.do(ctx, http.MethodGet, a.URL, "", nil, w); err != nil {
+		return fmt.Errorf("github: download %s: %w", a.Name, err)
+	}
+	return nil
+}
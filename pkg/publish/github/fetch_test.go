@@ -0,0 +1,75 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+func TestFetch(t *testing.T) {
+	var server *httptest.Server
+	attempts := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/app/releases/tags/v1.0.0":
+			io.WriteString(w, `{"id": 7, "tag_name": "v1.0.0", "name": "App 1.0", "html_url": "https://github.com/octo/app/releases/v1.0.0",
+				"assets": [{"name": "app.tar.gz", "size": 7, "url": "`+server.URL+`/repos/octo/app/releases/assets/1"}]}`)
+		case "/repos/octo/app/releases/assets/1":
+			if attempts++; attempts == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			if r.Header.Get("Accept") != "application/octet-stream" {
+				t.Errorf("Expected the content asked for, got Accept %q", r.Header.Get("Accept"))
+			}
+			http.Redirect(w, r, "/storage/app.tar.gz", http.StatusFound)
+		case "/storage/app.tar.gz":
+			io.WriteString(w, "content")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	p.Retry = retry.Policy{MaxAttempts: 2, Initial: time.Millisecond}
+
+	r, found, err := p.Fetch(context.Background(), "v1.0.0")
+	if err != nil || !found {
+		t.Fatalf("Expected the release, got %v, %v", found, err)
+	}
+	expected := publish.Published{
+		Tag:    "v1.0.0",
+		Name:   "App 1.0",
+		URL:    "https://github.com/octo/app/releases/v1.0.0",
+		Assets: []publish.RemoteAsset{{Name: "app.tar.gz", Size: 7, URL: server.URL + "/repos/octo/app/releases/assets/1"}},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, r)
+	}
+
+	var content bytes.Buffer
+	if err := p.Download(context.Background(), r.Assets[0], &content); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content.String() != "content" || attempts != 2 {
+		t.Errorf("Expected the content after a retry, got %q in %d attempts", content.String(), attempts)
+	}
+
+	if _, found, err := p.Fetch(context.Background(), "v2.0.0"); found || err != nil {
+		t.Errorf("Expected no release for v2.0.0, got %v, %v", found, err)
+	}
+	err = p.Download(context.Background(), publish.RemoteAsset{Name: "gone", URL: server.URL + "/missing"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "github: download gone") {
+		t.Errorf("Expected a download error, got %v", err)
+	}
+}
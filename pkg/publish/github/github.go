@@ -0,0 +1,409 @@
+// Package github publishes releases through the GitHub REST API
+// (https://docs.github.com/en/rest/releases).
+package github
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/providerapi"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/ratelimit"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+const (
+	// DefaultBaseURL is the public GitHub API endpoint.
+	DefaultBaseURL = "https://api.github.com"
+
+	// DefaultAPIVersion is the REST API version requested by New.
+	DefaultAPIVersion = "2022-11-28"
+
+	// TokenEnv is the environment variable read by NewFromEnv. GH_TOKEN, the
+	// variable used by the gh CLI, is accepted as a fallback.
+	TokenEnv = "GITHUB_TOKEN"
+)
+
+// ErrNoToken is returned by NewFromEnv when no token is configured.
+var ErrNoToken = errors.New("github: no token in " + TokenEnv + " or GH_TOKEN")
+
+// Publisher creates GitHub releases for a single repository.
+type Publisher struct {
+	Owner string
+	Repo  string
+	Token string
+
+	// BaseURL defaults to DefaultBaseURL; see APIBaseURL for GitHub
+	// Enterprise Server.
+	BaseURL string
+	// APIVersion is sent in the X-GitHub-Api-Version header. A server
+	// rejecting it, as GitHub Enterprise Server releases predating it do, is
+	// asked again without the header, which is then left out.
+	APIVersion string
+	// AuthScheme is the Authorization scheme of Token: "Bearer" when
+	// empty, or "token" for servers that only accept the older one.
+	AuthScheme string
+	HTTPClient *http.Client
+	// Retry governs retries of requests failing with 429, 5xx or a network
+	// error. The zero value sends each request once.
+	Retry retry.Policy
+	// RateLimit paces requests by the budget responses report; nil sends
+	// them unpaced.
+	RateLimit *ratelimit.Limiter
+	// GraphQLRateLimit paces the GraphQL queries, which draw on a budget of
+	// their own; nil sends them unpaced.
+	GraphQLRateLimit *ratelimit.Limiter
+	// Uploads sets how many assets are uploaded at once and reports their
+	// progress.
+	Uploads publish.Uploader
+	// Lookups batches the issues and commit authors looked up at once
+	// into GraphQL queries.
+	Lookups providerapi.Batches
+
+	// DryRun describes the requests that would be made on Log instead of
+	// sending them (see package dryrun).
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every API request; nil discards.
+	Logger *slog.Logger
+
+	// unversioned is set once the server rejected APIVersion.
+	unversioned atomic.Bool
+	// issues and authors remember the lookups of Issues and CommitAuthors.
+	issues  providerapi.Cache[int, references.Reference]
+	authors providerapi.Cache[string, string]
+}
+
+var _ publish.Publisher = (*Publisher)(nil)
+
+// New returns a Publisher for owner/repo authenticated with token.
+func New(owner, repo, token string) *Publisher {
+	return &Publisher{
+		Owner:      owner,
+		Repo:       repo,
+		Token:      token,
+		BaseURL:    DefaultBaseURL,
+		APIVersion: DefaultAPIVersion,
+		HTTPClient: http.DefaultClient,
+		Retry:      retry.Default(),
+		RateLimit:  ratelimit.New(),
+		Log:        io.Discard,
+
+		GraphQLRateLimit: ratelimit.New(),
+	}
+}
+
+// NewFromEnv returns a Publisher for the "owner/repo" slug, reading the token
+// from the environment.
+func NewFromEnv(slug string) (*Publisher, error) {
+	return NewFromSecrets(slug, os.Getenv)
+}
+
+// NewFromSecrets is NewFromEnv with the token looked up by secret, which is
+// asked for TokenEnv, then GH_TOKEN.
+func NewFromSecrets(slug string, secret func(name string) string) (*Publisher, error) {
+	owner, repo, ok := strings.Cut(slug, "/")
+	if !ok || owner == "" || repo == "" {
+		return nil, fmt.Errorf("github: invalid repository %q: expected owner/repo", slug)
+	}
+
+	token := secret(TokenEnv)
+	if token == "" {
+		token = secret("GH_TOKEN")
+	}
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	return New(owner, repo, token), nil
+}
+
+// APIBaseURL returns the REST API endpoint of the GitHub instance at base:
+// base itself when it has a path, such as "https://ghe.example.com/api/v3",
+// or the Enterprise Server endpoint of a bare host such as
+// "https://ghe.example.com". It returns DefaultBaseURL for an empty base
+// or github.com.
+func APIBaseURL(base string) string {
+	base = strings.TrimRight(base, "/")
+	u, err := url.Parse(base)
+	switch {
+	case base == "" || err != nil:
+		return DefaultBaseURL
+	case u.Host == "github.com" || u.Host == "api.github.com":
+		return DefaultBaseURL
+	case u.Path == "":
+		return base + "/api/v3"
+	}
+	return base
+}
+
+type releaseRequest struct {
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+type releaseResponse struct {
+	ID         int64     `json:"id"`
+	TagName    string    `json:"tag_name"`
+	Name       string    `json:"name"`
+	HTMLURL    string    `json:"html_url"`
+	UploadURL  string    `json:"upload_url"`
+	Draft      bool      `json:"draft"`
+	Prerelease bool      `json:"prerelease"`
+	CreatedAt  time.Time `json:"created_at"`
+	Assets     []struct {
+		Name string `json:"name"`
+		Size int64  `json:"size"`
+		// URL is the API endpoint of the asset, which serves its content
+		// when asked for application/octet-stream.
+		URL string `json:"url"`
+	} `json:"assets"`
+}
+
+// secondaryWait is the least wait before retrying a request that hit a
+// secondary rate limit, as GitHub documents.
+var secondaryWait = time.Minute
+
+// errNotFound is reported by do for 404 responses.
+var errNotFound = errors.New("404 Not Found")
+
+// errAPIVersion is reported by send when the server rejects APIVersion.
+var errAPIVersion = errors.New("API version not supported")
+
+// Publish creates the release and uploads its assets. When a published
+// release for the tag already exists, it is resumed: only the assets it
+// lacks are uploaded and its notes are left untouched. A draft release is
+// resumed the same way, by a draft only: drafts are not attached to their
+// tag and are found by listing the releases. Milestones are not supported
+// by GitHub and are ignored.
+func (p *Publisher) Publish(ctx context.Context, r publish.Release) (publish.Result, error) {
+	name := r.Title()
+
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would create GitHub release %s (%q) on %s/%s", r.Tag, name, p.Owner, p.Repo)
+		for _, a := range r.Assets {
+			dryrun.Printf(p.Log, "would upload asset %s", a)
+		}
+		return publish.Result{}, nil
+	}
+
+	if err := checkSizes(r.Assets); err != nil {
+		return publish.Result{}, err
+	}
+	release, existing, err := p.find(ctx, r.Tag)
+	if err != nil {
+		return publish.Result{}, fmt.Errorf("github: find release %s: %w", r.Tag, err)
+	}
+	if !existing && r.Draft {
+		if release, existing, err = p.findDraft(ctx, r.Tag); err != nil {
+			return publish.Result{}, err
+		}
+	}
+
+	assets := r.Assets
+	if existing {
+		var attached []string
+		for _, a := range release.Assets {
+			attached = append(attached, a.Name)
+		}
+		assets = publish.MissingAssets(assets, attached)
+	} else {
+		payload, err := json.Marshal(releaseRequest{
+			TagName:    r.Tag,
+			Name:       name,
+			Body:       r.Body,
+			Draft:      r.Draft,
+			Prerelease: r.Prerelease,
+		})
+		if err != nil {
+			return publish.Result{}, err
+		}
+		if err := p.do(ctx, http.MethodPost, p.reposURL("releases"), "application/json", bytes.NewReader(payload), &release); err != nil {
+			return publish.Result{}, fmt.Errorf("github: create release %s: %w", r.Tag, err)
+		}
+	}
+
+	result := publish.Result{ID: strconv.FormatInt(release.ID, 10), URL: release.HTMLURL, Existing: existing}
+	result.Uploaded, err = p.Uploads.Upload(ctx, assets, func(ctx context.Context, i int) error {
+		if err := p.upload(ctx, release.UploadURL, assets[i]); err != nil {
+			return fmt.Errorf("github: upload %s: %w", assets[i], err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// find looks up the published release for tag. The boolean result is false
+// when there is none.
+func (p *Publisher) find(ctx context.Context, tag string) (releaseResponse, bool, error) {
+	var release releaseResponse
+	err := p.do(ctx, http.MethodGet, p.reposURL("releases/tags/"+url.PathEscape(tag)), "application/json", nil, &release)
+	if errors.Is(err, errNotFound) {
+		return releaseResponse{}, false, nil
+	}
+	return release, err == nil, err
+}
+
+// maxAssetSize is the size GitHub release assets must stay under.
+const maxAssetSize = 2 << 30
+
+// checkSizes fails for assets GitHub would reject as too large, before
+// anything is created.
+func checkSizes(assets []string) error {
+	for _, a := range assets {
+		if info, err := os.Stat(a); err == nil && info.Size() >= maxAssetSize {
+			return fmt.Errorf("github: %s is %d bytes; release assets must be under 2 GiB", a, info.Size())
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) reposURL(resource string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/%s", strings.TrimRight(p.BaseURL, "/"), p.Owner, p.Repo, resource)
+}
+
+func (p *Publisher) upload(ctx context.Context, uploadURL, path string) error {
+	a, err := publish.OpenAsset(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	// upload_url is an RFC 6570 template such as ".../assets{?name,label}".
+	base, _, _ := strings.Cut(uploadURL, "{")
+	endpoint := base + "?name=" + url.QueryEscape(a.Name)
+	return p.do(ctx, http.MethodPost, endpoint, "application/octet-stream", a.Body(), nil)
+}
+
+// do sends a request, retrying it according to p.Retry. A body that is
+// not an io.Seeker cannot be replayed, so such requests are sent once.
+func (p *Publisher) do(ctx context.Context, method, endpoint, contentType string, body io.Reader, out any) error {
+	policy := p.Retry
+	seeker, ok := body.(io.Seeker)
+	if body != nil && !ok {
+		policy = retry.Policy{}
+	}
+	return policy.Do(ctx, func(ctx context.Context) error {
+		for {
+			if seeker != nil {
+				if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+			}
+			err := p.send(ctx, method, endpoint, contentType, body, out)
+			// Ask again without the rejected API version, which send
+			// leaves out from now on.
+			if !errors.Is(err, errAPIVersion) || (body != nil && seeker == nil) {
+				return err
+			}
+		}
+	})
+}
+
+func (p *Publisher) send(ctx context.Context, method, endpoint, contentType string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if sr, ok := body.(*io.SectionReader); ok {
+		req.ContentLength = sr.Size()
+	}
+	if _, ok := out.(io.Writer); ok {
+		req.Header.Set("Accept", "application/octet-stream")
+	} else {
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+	req.Header.Set("Authorization", cmp.Or(p.AuthScheme, "Bearer")+" "+p.Token)
+	req.Header.Set("Content-Type", contentType)
+	versioned := p.APIVersion != "" && !p.unversioned.Load()
+	if versioned {
+		req.Header.Set("X-GitHub-Api-Version", p.APIVersion)
+	}
+
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	limiter, pace := p.RateLimit, method
+	if endpoint == p.graphqlURL() {
+		// GraphQL queries are reads, whatever their method.
+		limiter, pace = p.GraphQLRateLimit, http.MethodGet
+	}
+	if limiter != nil {
+		if err := limiter.Wait(ctx, pace); err != nil {
+			return err
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if limiter != nil {
+		limiter.Update(resp.Header, ratelimit.GitHub)
+	}
+	attrs := []any{"method", method, "url", endpoint, "status", resp.StatusCode}
+	if remaining := resp.Header.Get(ratelimit.GitHub + "Remaining"); remaining != "" {
+		attrs = append(attrs, "rate_limit_remaining", remaining)
+	}
+	log.Or(p.Logger).Debug("github API request", attrs...)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, endpoint, errNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		err := fmt.Errorf("%s %s: %s: %s", method, endpoint, resp.Status, strings.TrimSpace(string(msg)))
+		if versioned && resp.StatusCode == http.StatusBadRequest && bytes.Contains(bytes.ToLower(msg), []byte("x-github-api-version")) {
+			log.Or(p.Logger).Debug("github API version not supported, retrying without it", "version", p.APIVersion)
+			p.unversioned.Store(true)
+			return fmt.Errorf("%w: %w", err, errAPIVersion)
+		}
+		now := time.Now()
+		if wait := retry.RateLimitReset(resp.Header.Get("X-RateLimit-Remaining"), resp.Header.Get("X-RateLimit-Reset"), now); wait > 0 && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) {
+			return retry.After(fmt.Errorf("%w (rate limit resets in %s)", err, wait.Round(time.Second)), wait)
+		}
+		// Secondary rate limits answer 403 with a Retry-After, or at least
+		// a message asking to wait a minute.
+		if resp.StatusCode == http.StatusForbidden && (resp.Header.Get("Retry-After") != "" || bytes.Contains(bytes.ToLower(msg), []byte("secondary rate limit"))) {
+			return retry.After(err, max(retry.RetryAfter(resp.Header.Get("Retry-After"), now), secondaryWait))
+		}
+		if retry.HTTPStatus(resp.StatusCode) {
+			return retry.After(err, retry.RetryAfter(resp.Header.Get("Retry-After"), now))
+		}
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if w, ok := out.(io.Writer); ok {
+		// Part of the content may be written already, so a failure to
+		// read the rest is not retried.
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%s %s: %v", method, endpoint, err)
+		}
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
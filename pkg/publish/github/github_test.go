@@ -0,0 +1,409 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/ratelimit"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+func TestPublish(t *testing.T) {
+	var (
+		created  releaseRequest
+		uploaded = make(map[string]string)
+		server   *httptest.Server
+	)
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/releases/tags/v1.0.0":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "Not Found"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/octo/app/releases":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id": 7, "html_url": "https://github.com/octo/app/releases/tag/v1.0.0", "upload_url": "`+server.URL+`/uploads/7/assets{?name,label}"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/uploads/7/assets":
+			body, _ := io.ReadAll(r.Body)
+			uploaded[r.URL.Query().Get("name")] = string(body)
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	asset := filepath.Join(t.TempDir(), "app_linux_amd64.tar.gz")
+	if err := os.WriteFile(asset, []byte("binary"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	result, err := p.Publish(context.Background(), publish.Release{
+		Tag:        "v1.0.0",
+		Body:       "## [1.0.0]\n\n- first",
+		Prerelease: false,
+		Assets:     []string{asset},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.ID != "7" || result.URL != "https://github.com/octo/app/releases/tag/v1.0.0" {
+		t.Errorf("Unexpected result %#v", result)
+	}
+	expected := releaseRequest{TagName: "v1.0.0", Name: "Release v1.0.0", Body: "## [1.0.0]\n\n- first"}
+	if created != expected {
+		t.Errorf("Expected %#v, got %#v", expected, created)
+	}
+	if uploaded["app_linux_amd64.tar.gz"] != "binary" {
+		t.Errorf("Expected asset to be uploaded, got %#v", uploaded)
+	}
+}
+
+func TestPublishAssetTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+	}))
+	defer server.Close()
+
+	// A sparse file takes no disk space.
+	asset := filepath.Join(t.TempDir(), "installer.exe")
+	f, err := os.Create(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(2 << 30); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	_, err = p.Publish(context.Background(), publish.Release{Tag: "v1.0.0", Assets: []string{asset}})
+	if err == nil || !strings.Contains(err.Error(), "release assets must be under 2 GiB") {
+		t.Errorf("Expected the asset refused, got %v", err)
+	}
+}
+
+func TestPublishResume(t *testing.T) {
+	var (
+		uploaded []string
+		server   *httptest.Server
+	)
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/releases/tags/v1.0.0":
+			io.WriteString(w, `{"id": 7, "html_url": "https://github.com/octo/app/releases/tag/v1.0.0", "upload_url": "`+server.URL+`/uploads/7/assets{?name,label}", "assets": [{"name": "app_linux"}]}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/uploads/7/assets":
+			uploaded = append(uploaded, r.URL.Query().Get("name"))
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	var assets []string
+	for _, name := range []string{"app_linux", "app_darwin"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(name), 0o644)
+		assets = append(assets, path)
+	}
+
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	result, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0", Assets: assets})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Existing || result.ID != "7" {
+		t.Errorf("Expected the existing release to be resumed, got %#v", result)
+	}
+	if len(uploaded) != 1 || uploaded[0] != "app_darwin" {
+		t.Errorf("Expected only the missing asset to be uploaded, got %v", uploaded)
+	}
+	if len(result.Uploaded) != 1 || result.Uploaded[0] != assets[1] {
+		t.Errorf("Expected Uploaded to list the missing asset, got %v", result.Uploaded)
+	}
+}
+
+func TestPublishAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		io.WriteString(w, `{"message": "Validation Failed"}`)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	p.Logger = log.New(&logs, log.Options{Level: slog.LevelDebug, Secrets: []string{p.Token}})
+
+	_, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"})
+	if err == nil || !strings.Contains(err.Error(), "create release v1.0.0") || !strings.Contains(err.Error(), "Validation Failed") {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+	expected := "debug: github API request method=GET url=" + server.URL + "/repos/octo/app/releases/tags/v1.0.0 status=404\n" +
+		"debug: github API request method=POST url=" + server.URL + "/repos/octo/app/releases status=422\n"
+	if logs.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, logs.String())
+	}
+}
+
+func TestPublishRetries(t *testing.T) {
+	var attempts, uploads int
+	var bodies []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/repos/octo/app/releases":
+			attempts++
+			if attempts == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id": 7, "upload_url": "`+server.URL+`/uploads/7/assets{?name,label}"}`)
+		default:
+			uploads++
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if uploads == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	asset := filepath.Join(t.TempDir(), "app")
+	os.WriteFile(asset, []byte("binary"), 0o644)
+
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	p.Retry = retry.Policy{MaxAttempts: 3, Initial: time.Millisecond}
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0", Assets: []string{asset}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 2 || uploads != 2 {
+		t.Errorf("Expected one retry of each request, got %d creates and %d uploads", attempts, uploads)
+	}
+	if len(bodies) != 2 || bodies[1] != "binary" {
+		t.Errorf("Expected the asset to be sent again in full, got %q", bodies)
+	}
+}
+
+func TestPublishRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"message": "API rate limit exceeded"}`)
+	}))
+	defer server.Close()
+
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	p.Retry = retry.Policy{}
+
+	_, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"})
+	if err == nil || !strings.Contains(err.Error(), "rate limit resets in") || !retry.IsRetryable(err) {
+		t.Errorf("Expected a retryable rate limit error, got %v", err)
+	}
+}
+
+func TestPublishSecondaryRateLimit(t *testing.T) {
+	defer func(d time.Duration) { secondaryWait = d }(secondaryWait)
+	secondaryWait = 5 * time.Millisecond
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"message": "You have exceeded a secondary rate limit."}`)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	p.Retry = retry.Policy{MaxAttempts: 2, OnRetry: func(_ int, _ error, d time.Duration) { delays = append(delays, d) }}
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"}); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if attempts != 2 || len(delays) != 1 || delays[0] != secondaryWait {
+		t.Errorf("Expected one retry after %s, got %d attempts and delays %v", secondaryWait, attempts, delays)
+	}
+}
+
+func TestPublishRateLimitBudget(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4321")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id": 7}`)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	p.Logger = log.New(&buf, log.Options{Level: slog.LevelDebug})
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, ok := p.RateLimit.Budget()
+	if expected := (ratelimit.Budget{Limit: 5000, Remaining: 4321, Reset: time.Unix(reset, 0)}); !ok || b != expected {
+		t.Errorf("Expected %+v, got %+v", expected, b)
+	}
+	if !strings.Contains(buf.String(), "status=201 rate_limit_remaining=4321\n") {
+		t.Errorf("Expected the remaining budget to be logged, got %q", buf.String())
+	}
+}
+
+func TestPublishAPIVersionFallback(t *testing.T) {
+	var versions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versions = append(versions, r.Header.Get("X-GitHub-Api-Version"))
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("Expected the token scheme, got %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("X-GitHub-Api-Version") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"message": "Unsupported 'X-GitHub-Api-Version' header"}`)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id": 7}`)
+	}))
+	defer server.Close()
+
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	p.AuthScheme = "token"
+	p.Retry = retry.Policy{}
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{DefaultAPIVersion, "", ""}
+	if !slices.Equal(versions, expected) {
+		t.Errorf("Expected API versions %q, got %q", expected, versions)
+	}
+}
+
+func TestAPIBaseURL(t *testing.T) {
+	tests := map[string]string{
+		"":                                DefaultBaseURL,
+		"https://github.com":              DefaultBaseURL,
+		"https://api.github.com/":         DefaultBaseURL,
+		"https://ghe.example.com":         "https://ghe.example.com/api/v3",
+		"https://ghe.example.com/":        "https://ghe.example.com/api/v3",
+		"https://ghe.example.com/api/v3/": "https://ghe.example.com/api/v3",
+	}
+	for base, expected := range tests {
+		if got := APIBaseURL(base); got != expected {
+			t.Errorf("APIBaseURL(%q): Expected %q, got %q", base, expected, got)
+		}
+	}
+}
+
+func TestPublishDryRun(t *testing.T) {
+	var log bytes.Buffer
+	p := New("octo", "app", "secret")
+	p.BaseURL = "http://127.0.0.1:0"
+	p.DryRun = true
+	p.Log = &log
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0", Assets: []string{"dist/app"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "[dry-run] would create GitHub release v1.0.0 (\"Release v1.0.0\") on octo/app\n" +
+		"[dry-run] would upload asset dist/app\n"
+	if log.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, log.String())
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	t.Setenv("GH_TOKEN", "")
+
+	if _, err := NewFromEnv("octo/app"); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Expected ErrNoToken, got %v", err)
+	}
+
+	t.Setenv("GH_TOKEN", "from-gh")
+	p, err := NewFromEnv("octo/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Owner != "octo" || p.Repo != "app" || p.Token != "from-gh" {
+		t.Errorf("Unexpected publisher %#v", p)
+	}
+
+	if _, err := NewFromEnv("octo"); err == nil {
+		t.Errorf("Expected error for invalid slug")
+	}
+}
+
+func TestNewFromSecrets(t *testing.T) {
+	var asked []string
+	p, err := NewFromSecrets("octo/app", func(name string) string {
+		asked = append(asked, name)
+		return map[string]string{"GH_TOKEN": "from-vault"}[name]
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Token != "from-vault" || strings.Join(asked, ",") != TokenEnv+",GH_TOKEN" {
+		t.Errorf("Expected the GH_TOKEN secret after %s, got %q asking %v", TokenEnv, p.Token, asked)
+	}
+}
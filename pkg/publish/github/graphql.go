@@ -0,0 +1,192 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/providerapi"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+var (
+	_ references.BatchFinder        = (*Publisher)(nil)
+	_ contributors.BatchLoginFinder = (*Publisher)(nil)
+)
+
+// graphqlURL returns the GraphQL endpoint: /graphql of the public API, or
+// /api/graphql next to the /api/v3 of an Enterprise Server.
+func (p *Publisher) graphqlURL() string {
+	base := strings.TrimRight(p.BaseURL, "/")
+	if api, ok := strings.CutSuffix(base, "/v3"); ok {
+		return api + "/graphql"
+	}
+	return base + "/graphql"
+}
+
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphqlError struct {
+	Type    string `json:"type"`
+	Path    []any  `json:"path"`
+	Message string `json:"message"`
+}
+
+// graphql sends query and decodes its data into out. An alias that names
+// nothing, such as an issue number never used, is null in the data, and
+// not an error.
+func (p *Publisher) graphql(ctx context.Context, query string, vars map[string]any, out any) error {
+	payload, err := json.Marshal(graphqlRequest{Query: query, Variables: vars})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphqlError  `json:"errors"`
+	}
+	if err := p.do(ctx, http.MethodPost, p.graphqlURL(), "application/json", bytes.NewReader(payload), &resp); err != nil {
+		return err
+	}
+	for _, e := range resp.Errors {
+		// Aliases below the repository that are not found only leave
+		// their field null.
+		if e.Type != "NOT_FOUND" || len(e.Path) < 2 {
+			return fmt.Errorf("GraphQL: %s", e.Message)
+		}
+	}
+	if bytes.Equal(bytes.TrimSpace(resp.Data), []byte("null")) || len(resp.Data) == 0 {
+		return errors.New("GraphQL: no data")
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// lookups returns the batches of the GraphQL queries, held back by its
+// rate limit.
+func (p *Publisher) lookups() providerapi.Batches {
+	b := p.Lookups
+	if b.Budget == nil && p.GraphQLRateLimit != nil {
+		b.Budget = p.GraphQLRateLimit
+	}
+	return b
+}
+
+// issueFields are the fields of an issue or pull request.
+const issueFields = "number title url author { login } labels(first: 100) { nodes { name } }"
+
+type issueNode struct {
+	Typename string `json:"__typename"`
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	// Author is null for deleted accounts.
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+}
+
+// Issues returns the issues and pull requests of numbers, each batch of
+// p.Lookups asked in one GraphQL query. Numbers that name neither are left
+// out.
+func (p *Publisher) Issues(ctx context.Context, numbers []int) (map[int]references.Reference, error) {
+	refs, err := p.issues.Get(ctx, p.lookups(), numbers, p.issueBatch)
+	if err != nil {
+		return refs, fmt.Errorf("github: issues: %w", err)
+	}
+	return refs, nil
+}
+
+func (p *Publisher) issueBatch(ctx context.Context, numbers []int) (map[int]references.Reference, error) {
+	var q strings.Builder
+	q.WriteString("query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) {")
+	for _, n := range numbers {
+		fmt.Fprintf(&q, " i%d: issueOrPullRequest(number: %d) { __typename ... on Issue { %s } ... on PullRequest { %s } }", n, n, issueFields, issueFields)
+	}
+	q.WriteString(" } }")
+
+	var data struct {
+		Repository map[string]*issueNode `json:"repository"`
+	}
+	if err := p.graphql(ctx, q.String(), map[string]any{"owner": p.Owner, "name": p.Repo}, &data); err != nil {
+		return nil, err
+	}
+	refs := make(map[int]references.Reference, len(numbers))
+	for _, n := range numbers {
+		i := data.Repository["i"+strconv.Itoa(n)]
+		if i == nil {
+			continue
+		}
+		r := references.Reference{Number: i.Number, Title: i.Title, URL: i.URL, PullRequest: i.Typename == "PullRequest"}
+		if i.Author != nil {
+			r.Author = i.Author.Login
+		}
+		for _, l := range i.Labels.Nodes {
+			r.Labels = append(r.Labels, l.Name)
+		}
+		refs[n] = r
+	}
+	return refs, nil
+}
+
+type commitNode struct {
+	Author *struct {
+		// User is null when the author's address belongs to no account.
+		User *struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"author"`
+}
+
+// CommitAuthors returns the logins of the GitHub accounts the authors of
+// hashes belong to, "" for addresses matching no account, each batch of
+// p.Lookups asked in one GraphQL query. Commits the repository does not
+// have are left out.
+func (p *Publisher) CommitAuthors(ctx context.Context, hashes []string) (map[string]string, error) {
+	logins, err := p.authors.Get(ctx, p.lookups(), hashes, p.authorBatch)
+	if err != nil {
+		return logins, fmt.Errorf("github: commit authors: %w", err)
+	}
+	return logins, nil
+}
+
+func (p *Publisher) authorBatch(ctx context.Context, hashes []string) (map[string]string, error) {
+	var q strings.Builder
+	q.WriteString("query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) {")
+	for i, h := range hashes {
+		fmt.Fprintf(&q, " c%d: object(expression: %s) { ... on Commit { author { user { login } } } }", i, strconv.Quote(h))
+	}
+	q.WriteString(" } }")
+
+	var data struct {
+		Repository map[string]*commitNode `json:"repository"`
+	}
+	if err := p.graphql(ctx, q.String(), map[string]any{"owner": p.Owner, "name": p.Repo}, &data); err != nil {
+		return nil, err
+	}
+	logins := make(map[string]string, len(hashes))
+	for i, h := range hashes {
+		c := data.Repository["c"+strconv.Itoa(i)]
+		if c == nil {
+			continue
+		}
+		var login string
+		if c.Author != nil && c.Author.User != nil {
+			login = c.Author.User.Login
+		}
+		logins[h] = login
+	}
+	return logins, nil
+}
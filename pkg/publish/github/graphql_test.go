@@ -0,0 +1,137 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/providerapi"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+// aliasPattern matches the aliases of a batched query and what they look
+// up.
+var aliasPattern = regexp.MustCompile(`(\w+): (?:issueOrPullRequest\(number: (\d+)\)|object\(expression: "(\w+)"\))`)
+
+func TestIssues(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/graphql" {
+			t.Errorf("Expected POST /graphql, got %s %s", r.Method, r.URL.Path)
+		}
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Variables["owner"] != "octo" || req.Variables["name"] != "app" {
+			t.Errorf("Expected the repository in the variables, got %v", req.Variables)
+		}
+		mu.Lock()
+		queries = append(queries, req.Query)
+		mu.Unlock()
+
+		var fields, errs []string
+		for _, m := range aliasPattern.FindAllStringSubmatch(req.Query, -1) {
+			switch m[2] {
+			case "12":
+				fields = append(fields, fmt.Sprintf(`%q: {"__typename": "PullRequest", "number": 12, "title": "Add notes", "url": "https://github.com/octo/app/pull/12", "author": {"login": "ada"}, "labels": {"nodes": [{"name": "feature"}]}}`, m[1]))
+			case "404":
+				fields = append(fields, fmt.Sprintf(`%q: null`, m[1]))
+				errs = append(errs, fmt.Sprintf(`{"type": "NOT_FOUND", "path": ["repository", %q], "message": "Could not resolve"}`, m[1]))
+			default:
+				fields = append(fields, fmt.Sprintf(`%q: {"__typename": "Issue", "number": %s, "title": "Crash", "url": "https://github.com/octo/app/issues/%s", "author": null, "labels": {"nodes": []}}`, m[1], m[2], m[2]))
+			}
+		}
+		fmt.Fprintf(w, `{"data": {"repository": {%s}}, "errors": [%s]}`, strings.Join(fields, ", "), strings.Join(errs, ", "))
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	p.Lookups = providerapi.Batches{Size: 2, Concurrency: 1}
+
+	got, err := p.Issues(context.Background(), []int{12, 3, 404})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[int]references.Reference{
+		12: {Number: 12, Title: "Add notes", Author: "ada", Labels: []string{"feature"}, URL: "https://github.com/octo/app/pull/12", PullRequest: true},
+		3:  {Number: 3, Title: "Crash", URL: "https://github.com/octo/app/issues/3"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if len(queries) != 2 {
+		t.Errorf("Expected 2 queries of 2 numbers at most, got %d", len(queries))
+	}
+
+	if _, err := p.Issues(context.Background(), []int{3, 404, 12}); err != nil || len(queries) != 2 {
+		t.Errorf("Expected the numbers looked up before not to be asked again, got %d queries (err=%v)", len(queries), err)
+	}
+}
+
+func TestCommitAuthors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		var fields []string
+		for _, m := range aliasPattern.FindAllStringSubmatch(req.Query, -1) {
+			switch m[3] {
+			case "a1":
+				fields = append(fields, fmt.Sprintf(`%q: {"author": {"user": {"login": "ada"}}}`, m[1]))
+			case "b2":
+				fields = append(fields, fmt.Sprintf(`%q: {"author": {"user": null}}`, m[1]))
+			default:
+				fields = append(fields, fmt.Sprintf(`%q: null`, m[1]))
+			}
+		}
+		fmt.Fprintf(w, `{"data": {"repository": {%s}}}`, strings.Join(fields, ", "))
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	got, err := p.CommitAuthors(context.Background(), []string{"a1", "b2", "c3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"a1": "ada", "b2": ""}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data": {"repository": null}, "errors": [{"type": "NOT_FOUND", "path": ["repository"], "message": "Could not resolve to a Repository with the name 'octo/app'."}]}`)
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	if _, err := p.Issues(context.Background(), []int{1}); err == nil || !strings.Contains(err.Error(), "Could not resolve to a Repository") {
+		t.Errorf("Expected the error of a missing repository, got %v", err)
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	for base, expected := range map[string]string{
+		DefaultBaseURL:                   "https://api.github.com/graphql",
+		"https://ghe.example.com/api/v3": "https://ghe.example.com/api/graphql",
+	} {
+		p := &Publisher{BaseURL: base}
+		if got := p.graphqlURL(); got != expected {
+			t.Errorf("Expected %s, got %s", expected, got)
+		}
+	}
+}
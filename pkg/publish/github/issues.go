@@ -0,0 +1,40 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+var _ references.Finder = (*Publisher)(nil)
+
+type issueResponse struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	// PullRequest is set when the issue is a pull request.
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+// Issue returns the issue or pull request number; GitHub numbers both in
+// the same sequence.
+func (p *Publisher) Issue(ctx context.Context, number int) (references.Reference, error) {
+	var i issueResponse
+	if err := p.do(ctx, http.MethodGet, p.reposURL("issues/"+strconv.Itoa(number)), "application/json", nil, &i); err != nil {
+		return references.Reference{}, fmt.Errorf("github: issue #%d: %w", number, err)
+	}
+	r := references.Reference{Number: i.Number, Title: i.Title, Author: i.User.Login, URL: i.HTMLURL, PullRequest: i.PullRequest != nil}
+	for _, l := range i.Labels {
+		r.Labels = append(r.Labels, l.Name)
+	}
+	return r, nil
+}
@@ -0,0 +1,43 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+func TestIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/app/issues/12":
+			io.WriteString(w, `{"number": 12, "title": "Add notes", "html_url": "https://github.com/octo/app/pull/12", "user": {"login": "ada"}, "labels": [{"name": "feature"}, {"name": "cli"}], "pull_request": {"url": "x"}}`)
+		case "/repos/octo/app/issues/3":
+			io.WriteString(w, `{"number": 3, "title": "Crash", "html_url": "https://github.com/octo/app/issues/3", "user": {"login": "bob"}, "labels": []}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	got, err := p.Issue(context.Background(), 12)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := references.Reference{Number: 12, Title: "Add notes", Author: "ada", Labels: []string{"feature", "cli"}, URL: "https://github.com/octo/app/pull/12", PullRequest: true}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if got, _ := p.Issue(context.Background(), 3); got.PullRequest || got.Author != "bob" {
+		t.Errorf("Expected a plain issue, got %+v", got)
+	}
+	if _, err := p.Issue(context.Background(), 99); err == nil {
+		t.Error("Expected an error for a missing issue")
+	}
+}
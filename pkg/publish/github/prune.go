@@ -0,0 +1,57 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Lister = (*Publisher)(nil)
+
+// Releases returns every release of the repository, drafts included,
+// newest first as GitHub lists them.
+func (p *Publisher) Releases(ctx context.Context) ([]publish.Listed, error) {
+	var listed []publish.Listed
+	for page := 1; ; page++ {
+		var releases []releaseResponse
+		endpoint := p.reposURL(fmt.Sprintf("releases?per_page=%d&page=%d", releasesPerPage, page))
+		if err := p.do(ctx, http.MethodGet, endpoint, "application/json", nil, &releases); err != nil {
+			return nil, fmt.Errorf("github: list releases: %w", err)
+		}
+		for _, r := range releases {
+			l := publish.Listed{
+				ID:         strconv.FormatInt(r.ID, 10),
+				Tag:        r.TagName,
+				Name:       r.Name,
+				URL:        r.HTMLURL,
+				Draft:      r.Draft,
+				Prerelease: r.Prerelease,
+				Created:    r.CreatedAt,
+			}
+			for _, a := range r.Assets {
+				l.Assets = append(l.Assets, publish.RemoteAsset{Name: a.Name, Size: a.Size, URL: a.URL})
+			}
+			listed = append(listed, l)
+		}
+		if len(releases) < releasesPerPage {
+			return listed, nil
+		}
+	}
+}
+
+// DeleteRelease deletes the listed release r by its ID, which tells a
+// draft apart from the published release of the same tag.
+func (p *Publisher) DeleteRelease(ctx context.Context, r publish.Listed) error {
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would delete GitHub release %s on %s/%s", r.Tag, p.Owner, p.Repo)
+		return nil
+	}
+	if err := p.do(ctx, http.MethodDelete, p.reposURL("releases/"+r.ID), "application/json", nil, nil); err != nil {
+		return fmt.Errorf("github: delete release %s: %w", r.Tag, err)
+	}
+	return nil
+}
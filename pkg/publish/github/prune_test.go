@@ -0,0 +1,74 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+func TestReleases(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Query().Get("page") == "1":
+			var releases []string
+			for i := range releasesPerPage {
+				releases = append(releases, fmt.Sprintf(`{"id": %d, "tag_name": "v0.%d.0"}`, i+10, i))
+			}
+			io.WriteString(w, "["+strings.Join(releases, ",")+"]")
+		default:
+			io.WriteString(w, `[{"id": 7, "tag_name": "v1.0.0-rc.1", "name": "RC", "html_url": "https://github.com/octo/app/releases/tag/v1.0.0-rc.1", `+
+				`"draft": true, "prerelease": true, "created_at": "2024-06-01T10:00:00Z", "assets": [{"name": "app.tar.gz", "size": 6, "url": "https://api.github.com/assets/1"}]}]`)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	releases, err := p.Releases(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(releases) != releasesPerPage+1 {
+		t.Fatalf("Expected %d releases, got %d", releasesPerPage+1, len(releases))
+	}
+	last := releases[releasesPerPage]
+	if last.ID != "7" || !last.Draft || !last.Prerelease || last.Name != "RC" || !last.Created.Equal(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected release %#v", last)
+	}
+	if len(last.Assets) != 1 || last.Assets[0] != (publish.RemoteAsset{Name: "app.tar.gz", Size: 6, URL: "https://api.github.com/assets/1"}) {
+		t.Errorf("Unexpected assets %#v", last.Assets)
+	}
+
+	requests = nil
+	if err := p.DeleteRelease(context.Background(), last); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requests) != 1 || requests[0] != "DELETE /repos/octo/app/releases/7" {
+		t.Errorf("Expected the release deleted by ID, got %q", requests)
+	}
+}
+
+func TestDeleteReleaseDryRun(t *testing.T) {
+	var log bytes.Buffer
+	p := New("octo", "app", "secret")
+	p.BaseURL, p.DryRun, p.Log = "http://127.0.0.1:0", true, &log
+
+	if err := p.DeleteRelease(context.Background(), publish.Listed{ID: "7", Tag: "v1.0.0-rc.1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "would delete GitHub release v1.0.0-rc.1 on octo/app"; !strings.Contains(log.String(), want) {
+		t.Errorf("Expected %q in %q", want, log.String())
+	}
+}
@@ -0,0 +1,50 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Retractor = (*Publisher)(nil)
+
+// Retract deletes the published release of tag, or turns it back into a
+// draft, which keeps its notes and assets but hides it and detaches it
+// from the tag. A draft of tag is deleted, or kept with draft. The tag
+// itself is left alone.
+func (p *Publisher) Retract(ctx context.Context, tag string, draft bool) (bool, error) {
+	if p.DryRun {
+		if draft {
+			dryrun.Printf(p.Log, "would turn GitHub release %s on %s/%s into a draft", tag, p.Owner, p.Repo)
+		} else {
+			dryrun.Printf(p.Log, "would delete GitHub release %s on %s/%s", tag, p.Owner, p.Repo)
+		}
+		return true, nil
+	}
+
+	release, found, err := p.find(ctx, tag)
+	if err != nil {
+		return false, fmt.Errorf("github: find release %s: %w", tag, err)
+	}
+	if !found {
+		// A draft awaiting approval is deleted, or left as it is.
+		if release, found, err = p.findDraft(ctx, tag); err != nil || !found || draft {
+			return found, err
+		}
+	}
+	endpoint := p.reposURL("releases/" + strconv.FormatInt(release.ID, 10))
+	if draft {
+		err = p.do(ctx, http.MethodPatch, endpoint, "application/json", strings.NewReader(`{"draft": true}`), nil)
+	} else {
+		err = p.do(ctx, http.MethodDelete, endpoint, "application/json", nil, nil)
+	}
+	if err != nil {
+		return false, fmt.Errorf("github: retract release %s: %w", tag, err)
+	}
+	return true, nil
+}
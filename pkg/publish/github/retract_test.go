@@ -0,0 +1,71 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRetract(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/releases/tags/v1.0.0":
+			io.WriteString(w, `{"id": 7}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/releases":
+			io.WriteString(w, `[{"id": 9, "tag_name": "v1.1.0", "draft": true}]`)
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	for _, draft := range []bool{false, true} {
+		found, err := p.Retract(context.Background(), "v1.0.0", draft)
+		if err != nil || !found {
+			t.Fatalf("Expected the release retracted, got %v, %v", found, err)
+		}
+	}
+	expected := []string{"DELETE /repos/octo/app/releases/7 ", `PATCH /repos/octo/app/releases/7 {"draft": true}`}
+	if len(requests) != 2 || requests[0] != expected[0] || requests[1] != expected[1] {
+		t.Errorf("Expected %q, got %q", expected, requests)
+	}
+
+	if found, err := p.Retract(context.Background(), "v2.0.0", false); found || err != nil {
+		t.Errorf("Expected no release for v2.0.0, got %v, %v", found, err)
+	}
+
+	// A draft is deleted, or kept as it is.
+	requests = nil
+	for _, draft := range []bool{true, false} {
+		if found, err := p.Retract(context.Background(), "v1.1.0", draft); !found || err != nil {
+			t.Errorf("Expected the draft of v1.1.0, got %v, %v", found, err)
+		}
+	}
+	if len(requests) != 1 || requests[0] != "DELETE /repos/octo/app/releases/9 " {
+		t.Errorf("Expected the draft deleted once, got %q", requests)
+	}
+}
+
+func TestRetractDryRun(t *testing.T) {
+	var log bytes.Buffer
+	p := New("octo", "app", "secret")
+	p.DryRun, p.Log = true, &log
+
+	if _, err := p.Retract(context.Background(), "v1.0.0", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "[dry-run] would turn GitHub release v1.0.0 on octo/app into a draft\n"
+	if log.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, log.String())
+	}
+}
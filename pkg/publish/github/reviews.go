@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Reviewer = (*Publisher)(nil)
+
+type reviewResponse struct {
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	State string `json:"state"`
+}
+
+// reviewsPerPage is the page size of review listings, GitHub's maximum.
+const reviewsPerPage = 100
+
+// CommitPullRequest returns the merged pull request commit is part of, the
+// first GitHub lists.
+func (p *Publisher) CommitPullRequest(ctx context.Context, commit string) (int, bool, error) {
+	var pulls []pullResponse
+	if err := p.do(ctx, http.MethodGet, p.reposURL("commits/"+url.PathEscape(commit)+"/pulls"), "application/json", nil, &pulls); err != nil {
+		return 0, false, fmt.Errorf("github: pull requests of %s: %w", commit, err)
+	}
+	for _, pr := range pulls {
+		if pr.MergedAt != nil {
+			return pr.Number, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// Approvers returns the users whose latest review of pull request number
+// approves it, sorted. Comments leave a review as it was; requesting
+// changes or having the approval dismissed takes it back.
+func (p *Publisher) Approvers(ctx context.Context, number int) ([]string, error) {
+	latest := make(map[string]string)
+	for page := 1; ; page++ {
+		var reviews []reviewResponse
+		endpoint := p.reposURL(fmt.Sprintf("pulls/%d/reviews?per_page=%d&page=%d", number, reviewsPerPage, page))
+		if err := p.do(ctx, http.MethodGet, endpoint, "application/json", nil, &reviews); err != nil {
+			return nil, fmt.Errorf("github: reviews of #%d: %w", number, err)
+		}
+		for _, r := range reviews {
+			switch r.State {
+			case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+				latest[r.User.Login] = r.State
+			}
+		}
+		if len(reviews) < reviewsPerPage {
+			break
+		}
+	}
+	var approvers []string
+	for login, state := range latest {
+		if state == "APPROVED" {
+			approvers = append(approvers, login)
+		}
+	}
+	slices.Sort(approvers)
+	return approvers, nil
+}
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestReviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/app/commits/abc123/pulls":
+			io.WriteString(w, `[{"number": 11, "merged_at": null}, {"number": 12, "merged_at": "2026-03-01T10:00:00Z"}]`)
+		case "/repos/octo/app/commits/def456/pulls":
+			io.WriteString(w, `[]`)
+		case "/repos/octo/app/pulls/12/reviews":
+			io.WriteString(w, `[
+				{"user": {"login": "ada"}, "state": "APPROVED"},
+				{"user": {"login": "ada"}, "state": "COMMENTED"},
+				{"user": {"login": "bob"}, "state": "APPROVED"},
+				{"user": {"login": "bob"}, "state": "CHANGES_REQUESTED"},
+				{"user": {"login": "eve"}, "state": "APPROVED"},
+				{"user": {"login": "eve"}, "state": "DISMISSED"},
+				{"user": {"login": "cy"}, "state": "CHANGES_REQUESTED"},
+				{"user": {"login": "cy"}, "state": "APPROVED"}
+			]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+
+	n, found, err := p.CommitPullRequest(context.Background(), "abc123")
+	if err != nil || !found || n != 12 {
+		t.Errorf("Expected the merged pull request #12, got %d, %v, %v", n, found, err)
+	}
+	if _, found, err := p.CommitPullRequest(context.Background(), "def456"); found || err != nil {
+		t.Errorf("Expected no pull request, got %v, %v", found, err)
+	}
+
+	approvers, err := p.Approvers(context.Background(), 12)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"ada", "cy"}; !reflect.DeepEqual(approvers, expected) {
+		t.Errorf("Expected %v, got %v", expected, approvers)
+	}
+	if _, err := p.Approvers(context.Background(), 404); err == nil {
+		t.Errorf("Expected an error for a missing pull request")
+	}
+}
@@ -0,0 +1,143 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Snapshotter = (*Publisher)(nil)
+
+type snapshotRequest struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Prerelease      bool   `json:"prerelease"`
+}
+
+// Snapshot points the prerelease of r.Tag at commit and uploads r.Assets
+// to it, replacing the assets of the same names. A new release creates
+// the tag at commit; the tag of an existing one is moved there through
+// the git references API, leaving the local repository alone. The commit
+// must be pushed already.
+func (p *Publisher) Snapshot(ctx context.Context, r publish.Release, commit string) (publish.Result, error) {
+	name := r.Title()
+
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would point GitHub prerelease %s (%q) on %s/%s at %s", r.Tag, name, p.Owner, p.Repo, commit)
+		for _, a := range r.Assets {
+			dryrun.Printf(p.Log, "would upload asset %s", a)
+		}
+		return publish.Result{}, nil
+	}
+
+	if err := checkSizes(r.Assets); err != nil {
+		return publish.Result{}, err
+	}
+	release, existing, err := p.find(ctx, r.Tag)
+	if err != nil {
+		return publish.Result{}, fmt.Errorf("github: find release %s: %w", r.Tag, err)
+	}
+	payload, err := json.Marshal(snapshotRequest{
+		TagName:         r.Tag,
+		TargetCommitish: commit,
+		Name:            name,
+		Body:            r.Body,
+		Prerelease:      true,
+	})
+	if err != nil {
+		return publish.Result{}, err
+	}
+	if existing {
+		if err := p.moveTag(ctx, r.Tag, commit); err != nil {
+			return publish.Result{}, fmt.Errorf("github: move tag %s to %s: %w", r.Tag, commit, err)
+		}
+		endpoint := p.reposURL("releases/" + strconv.FormatInt(release.ID, 10))
+		if err := p.do(ctx, http.MethodPatch, endpoint, "application/json", bytes.NewReader(payload), &release); err != nil {
+			return publish.Result{}, fmt.Errorf("github: update release %s: %w", r.Tag, err)
+		}
+		var replaced []string
+		for _, a := range r.Assets {
+			replaced = append(replaced, filepath.Base(a))
+		}
+		if err := p.deleteAssets(ctx, release, replaced); err != nil {
+			return publish.Result{}, err
+		}
+	} else if err := p.do(ctx, http.MethodPost, p.reposURL("releases"), "application/json", bytes.NewReader(payload), &release); err != nil {
+		return publish.Result{}, fmt.Errorf("github: create release %s: %w", r.Tag, err)
+	}
+
+	result := publish.Result{ID: strconv.FormatInt(release.ID, 10), URL: release.HTMLURL, Existing: existing}
+	result.Uploaded, err = p.Uploads.Upload(ctx, r.Assets, func(ctx context.Context, i int) error {
+		if err := p.upload(ctx, release.UploadURL, r.Assets[i]); err != nil {
+			return fmt.Errorf("github: upload %s: %w", r.Assets[i], err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// moveTag force-updates the tag reference to commit, or creates it when
+// it is missing.
+func (p *Publisher) moveTag(ctx context.Context, tag, commit string) error {
+	ref := "git/refs/tags/" + url.PathEscape(tag)
+	err := p.do(ctx, http.MethodGet, p.reposURL(ref), "application/json", nil, nil)
+	if errors.Is(err, errNotFound) {
+		payload, err := json.Marshal(map[string]string{"ref": "refs/tags/" + tag, "sha": commit})
+		if err != nil {
+			return err
+		}
+		return p.do(ctx, http.MethodPost, p.reposURL("git/refs"), "application/json", bytes.NewReader(payload), nil)
+	}
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]any{"sha": commit, "force": true})
+	if err != nil {
+		return err
+	}
+	return p.do(ctx, http.MethodPatch, p.reposURL(ref), "application/json", bytes.NewReader(payload), nil)
+}
+
+// DeleteAssets deletes the assets named names from the published release
+// of tag.
+func (p *Publisher) DeleteAssets(ctx context.Context, tag string, names []string) error {
+	if p.DryRun {
+		for _, n := range names {
+			dryrun.Printf(p.Log, "would delete asset %s of GitHub release %s", n, tag)
+		}
+		return nil
+	}
+	release, found, err := p.find(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("github: find release %s: %w", tag, err)
+	}
+	if !found {
+		return nil
+	}
+	return p.deleteAssets(ctx, release, names)
+}
+
+// deleteAssets deletes the assets of release named names.
+func (p *Publisher) deleteAssets(ctx context.Context, release releaseResponse, names []string) error {
+	for _, a := range release.Assets {
+		if !slices.Contains(names, a.Name) {
+			continue
+		}
+		if err := p.do(ctx, http.MethodDelete, a.URL, "application/json", nil, nil); err != nil {
+			return fmt.Errorf("github: delete asset %s: %w", a.Name, err)
+		}
+	}
+	return nil
+}
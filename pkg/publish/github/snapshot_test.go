@@ -0,0 +1,135 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+func TestSnapshot(t *testing.T) {
+	var (
+		requests []string
+		exists   bool
+		hasRef   bool
+	)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		release := `{"id": 5, "tag_name": "nightly", "html_url": "https://github.com/octo/app/releases/tag/nightly", "upload_url": "` + server.URL + `/uploads/5/assets{?name,label}", ` +
+			`"assets": [{"name": "app_linux.tar.gz", "url": "` + server.URL + `/repos/octo/app/releases/assets/51"}, {"name": "app_old.tar.gz", "url": "` + server.URL + `/repos/octo/app/releases/assets/52"}]}`
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/releases/tags/nightly":
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			io.WriteString(w, release)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/git/refs/tags/nightly":
+			if !hasRef {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			io.WriteString(w, `{"ref": "refs/tags/nightly"}`)
+		default:
+			requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+			if strings.Contains(r.URL.Path, "/releases") && r.Method != http.MethodDelete {
+				io.WriteString(w, release)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	asset := filepath.Join(t.TempDir(), "app_linux.tar.gz")
+	if err := os.WriteFile(asset, []byte("binary"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := New("octo", "app", "secret")
+	p.BaseURL = server.URL
+	r := publish.Release{Tag: "nightly", Name: "nightly 1.4.0-dev.20240601.abc1234", Body: "notes", Assets: []string{asset}}
+
+	result, err := p.Snapshot(context.Background(), r, "abc1234def")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ID != "5" || result.Existing || result.URL != "https://github.com/octo/app/releases/tag/nightly" {
+		t.Errorf("Unexpected result %#v", result)
+	}
+	create := `{"tag_name":"nightly","target_commitish":"abc1234def","name":"nightly 1.4.0-dev.20240601.abc1234","body":"notes","prerelease":true}`
+	expected := []string{
+		"POST /repos/octo/app/releases " + create,
+		"POST /uploads/5/assets?name=app_linux.tar.gz binary",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected %q, got %q", expected, requests)
+	}
+
+	// The existing release is updated, its tag moved and the asset of the
+	// same name replaced.
+	requests, exists, hasRef = nil, true, true
+	if result, err = p.Snapshot(context.Background(), r, "abc1234def"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Existing {
+		t.Errorf("Expected the release reused, got %#v", result)
+	}
+	expected = []string{
+		`PATCH /repos/octo/app/git/refs/tags/nightly {"force":true,"sha":"abc1234def"}`,
+		"PATCH /repos/octo/app/releases/5 " + create,
+		"DELETE /repos/octo/app/releases/assets/51 ",
+		"POST /uploads/5/assets?name=app_linux.tar.gz binary",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected %q, got %q", expected, requests)
+	}
+
+	// A release whose tag went missing gets it back.
+	requests, hasRef = nil, false
+	r.Assets = nil
+	if _, err = p.Snapshot(context.Background(), r, "abc1234def"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requests) == 0 || requests[0] != `POST /repos/octo/app/git/refs {"ref":"refs/tags/nightly","sha":"abc1234def"}` {
+		t.Errorf("Expected the tag created, got %q", requests)
+	}
+
+	requests = nil
+	if err := p.DeleteAssets(context.Background(), "nightly", []string{"app_old.tar.gz", "app_gone.tar.gz"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"DELETE /repos/octo/app/releases/assets/52 "}; !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected %q, got %q", expected, requests)
+	}
+}
+
+func TestSnapshotDryRun(t *testing.T) {
+	var log bytes.Buffer
+	p := New("octo", "app", "secret")
+	p.BaseURL, p.DryRun, p.Log = "http://127.0.0.1:0", true, &log
+
+	if _, err := p.Snapshot(context.Background(), publish.Release{Tag: "nightly", Assets: []string{"dist/app.tar.gz"}}, "abc1234"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.DeleteAssets(context.Background(), "nightly", []string{"app_old.tar.gz"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`would point GitHub prerelease nightly ("Release nightly") on octo/app at abc1234`,
+		"would upload asset dist/app.tar.gz",
+		"would delete asset app_old.tar.gz of GitHub release nightly",
+	} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("Expected %q in %q", want, log.String())
+		}
+	}
+}
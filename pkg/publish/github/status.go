@@ -0,0 +1,88 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.StatusChecker = (*Publisher)(nil)
+
+type combinedStatusResponse struct {
+	TotalCount int `json:"total_count"`
+	Statuses   []struct {
+		Context string `json:"context"`
+		State   string `json:"state"`
+	} `json:"statuses"`
+}
+
+type checkRunsResponse struct {
+	TotalCount int `json:"total_count"`
+	CheckRuns  []struct {
+		Name       string `json:"name"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+	} `json:"check_runs"`
+}
+
+// failedConclusions are the check run conclusions that fail a commit;
+// success, neutral and skipped pass it.
+var failedConclusions = []string{"failure", "cancelled", "timed_out", "action_required", "startup_failure", "stale"}
+
+// CIStatus combines the commit statuses and the check runs, which GitHub
+// Actions reports, of ref. Any failure fails the commit; otherwise any
+// unfinished check leaves it pending.
+func (p *Publisher) CIStatus(ctx context.Context, ref string) (publish.CIStatus, error) {
+	var statuses combinedStatusResponse
+	if err := p.do(ctx, http.MethodGet, p.reposURL("commits/"+url.PathEscape(ref)+"/status"), "application/json", nil, &statuses); err != nil {
+		return publish.CIStatus{}, fmt.Errorf("github: CI status of %s: %w", ref, err)
+	}
+	var runs checkRunsResponse
+	if err := p.do(ctx, http.MethodGet, p.reposURL("commits/"+url.PathEscape(ref)+"/check-runs?per_page=100"), "application/json", nil, &runs); err != nil {
+		return publish.CIStatus{}, fmt.Errorf("github: CI status of %s: %w", ref, err)
+	}
+
+	var failing, pending []string
+	for _, s := range statuses.Statuses {
+		switch s.State {
+		case "failure", "error":
+			failing = append(failing, s.Context)
+		case "pending":
+			pending = append(pending, s.Context)
+		}
+	}
+	for _, r := range runs.CheckRuns {
+		switch {
+		case r.Status != "completed":
+			pending = append(pending, r.Name)
+		case slices.Contains(failedConclusions, r.Conclusion):
+			failing = append(failing, r.Name)
+		}
+	}
+
+	s := publish.CIStatus{State: publish.CISuccess, URL: fmt.Sprintf("%s/%s/%s/commit/%s", p.webURL(), p.Owner, p.Repo, ref)}
+	switch {
+	case len(failing) > 0:
+		s.State, s.Checks = publish.CIFailure, failing
+	case len(pending) > 0:
+		s.State, s.Checks = publish.CIPending, pending
+	case statuses.TotalCount == 0 && runs.TotalCount == 0:
+		s.State = publish.CINone
+	}
+	return s, nil
+}
+
+// webURL derives the web root from the API base URL: github.com for the
+// public API, the host without /api/v3 for GitHub Enterprise Server.
+func (p *Publisher) webURL() string {
+	base := strings.TrimRight(p.BaseURL, "/")
+	if base == "" || base == DefaultBaseURL {
+		return "https://github.com"
+	}
+	return strings.TrimSuffix(base, "/api/v3")
+}
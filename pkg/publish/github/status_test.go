@@ -0,0 +1,65 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+func TestCIStatus(t *testing.T) {
+	tests := []struct {
+		statuses, runs string
+		expected       publish.CIState
+		checks         []string
+	}{
+		{
+			`{"total_count": 1, "statuses": [{"context": "ci/jenkins", "state": "success"}]}`,
+			`{"total_count": 1, "check_runs": [{"name": "test", "status": "completed", "conclusion": "skipped"}]}`,
+			publish.CISuccess, nil,
+		},
+		{
+			`{"total_count": 1, "statuses": [{"context": "ci/jenkins", "state": "error"}]}`,
+			`{"total_count": 2, "check_runs": [{"name": "lint", "status": "in_progress"}, {"name": "test", "status": "completed", "conclusion": "failure"}]}`,
+			publish.CIFailure, []string{"ci/jenkins", "test"},
+		},
+		{
+			`{"total_count": 0, "statuses": []}`,
+			`{"total_count": 1, "check_runs": [{"name": "lint", "status": "queued"}]}`,
+			publish.CIPending, []string{"lint"},
+		},
+		{`{"total_count": 0, "statuses": []}`, `{"total_count": 0, "check_runs": []}`, publish.CINone, nil},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/repos/octo/app/commits/abc123/status":
+				io.WriteString(w, tt.statuses)
+			case "/repos/octo/app/commits/abc123/check-runs":
+				io.WriteString(w, tt.runs)
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		p := New("octo", "app", "secret")
+		p.BaseURL = server.URL
+
+		s, err := p.CIStatus(context.Background(), "abc123")
+		server.Close()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.State != tt.expected || !slices.Equal(s.Checks, tt.checks) {
+			t.Errorf("Expected %s %v, got %s %v", tt.expected, tt.checks, s.State, s.Checks)
+		}
+		if s.URL != server.URL+"/octo/app/commit/abc123" {
+			t.Errorf("Expected the commit URL, got %q", s.URL)
+		}
+	}
+}
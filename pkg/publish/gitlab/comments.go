@@ -0,0 +1,79 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Commenter = (*Publisher)(nil)
+
+type noteResponse struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	// System is true for the notes GitLab writes itself, such as "added 1
+	// commit".
+	System bool `json:"system"`
+}
+
+// notesPerPage is the page size of note listings, GitLab's maximum.
+const notesPerPage = 100
+
+// UpsertComment comments on merge request number, its IID, as a note.
+func (p *Publisher) UpsertComment(ctx context.Context, number int, marker, body string) (publish.Comment, error) {
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would comment on merge request !%d of %s", number, p.Project)
+		return publish.Comment{}, nil
+	}
+
+	mr := "merge_requests/" + strconv.Itoa(number) + "/notes"
+	existing, err := p.findNote(ctx, mr, marker)
+	if err != nil {
+		return publish.Comment{}, fmt.Errorf("gitlab: notes of !%d: %w", number, err)
+	}
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return publish.Comment{}, err
+	}
+	var n noteResponse
+	if existing != nil {
+		err = p.do(ctx, http.MethodPut, p.projectURL(mr+"/"+strconv.FormatInt(existing.ID, 10)), "application/json", bytes.NewReader(payload), &n)
+	} else {
+		err = p.do(ctx, http.MethodPost, p.projectURL(mr), "application/json", bytes.NewReader(payload), &n)
+	}
+	if err != nil {
+		return publish.Comment{}, fmt.Errorf("gitlab: comment on !%d: %w", number, err)
+	}
+	return publish.Comment{
+		URL:     fmt.Sprintf("%s/%s/-/merge_requests/%d#note_%d", p.webURL(), p.Project, number, n.ID),
+		Updated: existing != nil,
+	}, nil
+}
+
+// findNote returns the first user note of the merge request notes listed
+// at mr containing marker, or nil.
+func (p *Publisher) findNote(ctx context.Context, mr, marker string) (*noteResponse, error) {
+	for page := 1; ; page++ {
+		var notes []noteResponse
+		query := url.Values{"sort": {"asc"}, "per_page": {strconv.Itoa(notesPerPage)}, "page": {strconv.Itoa(page)}}
+		if err := p.do(ctx, http.MethodGet, p.projectURL(mr+"?"+query.Encode()), "application/json", nil, &notes); err != nil {
+			return nil, err
+		}
+		for i, n := range notes {
+			if !n.System && strings.Contains(n.Body, marker) {
+				return &notes[i], nil
+			}
+		}
+		if len(notes) < notesPerPage {
+			return nil, nil
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpsertComment(t *testing.T) {
+	notes := []noteResponse{{ID: 1, Body: "added 1 commit <!-- preview -->", System: true}}
+	var writes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct{ Body string }
+		json.NewDecoder(r.Body).Decode(&in)
+		const mr = "/api/v4/projects/group%2Fapp/merge_requests/3/notes"
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == mr:
+			if q := r.URL.Query(); q.Get("sort") != "asc" || q.Get("page") != "1" {
+				t.Errorf("Unexpected query %s", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode(notes)
+		case r.Method == http.MethodPost && r.URL.EscapedPath() == mr:
+			writes = append(writes, "POST")
+			n := noteResponse{ID: 9, Body: in.Body}
+			notes = append(notes, n)
+			json.NewEncoder(w).Encode(n)
+		case r.Method == http.MethodPut && r.URL.EscapedPath() == mr+"/9":
+			writes = append(writes, "PUT")
+			notes[1].Body = in.Body
+			json.NewEncoder(w).Encode(notes[1])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+
+	c, err := p.UpsertComment(context.Background(), 3, "<!-- preview -->", "<!-- preview -->\nv1.1.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Updated || c.URL != server.URL+"/group/app/-/merge_requests/3#note_9" {
+		t.Errorf("Expected a new note, ignoring the system note, got %+v", c)
+	}
+	c, err = p.UpsertComment(context.Background(), 3, "<!-- preview -->", "<!-- preview -->\nv2.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.Updated || notes[1].Body != "<!-- preview -->\nv2.0.0" || len(writes) != 2 || writes[1] != "PUT" {
+		t.Errorf("Expected the note updated, got %+v after %v", c, writes)
+	}
+}
+
+func TestUpsertCommentDryRun(t *testing.T) {
+	var log bytes.Buffer
+	p := New("group/app", "secret")
+	p.DryRun, p.Log = true, &log
+
+	if _, err := p.UpsertComment(context.Background(), 3, "m", "m"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "[dry-run] would comment on merge request !3 of group/app\n"; log.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, log.String())
+	}
+}
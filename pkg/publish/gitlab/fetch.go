@@ -0,0 +1,41 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Fetcher = (*Publisher)(nil)
+
+// Fetch returns the release of tag with its asset links and the commit
+// GitLab reports for the tag. GitLab does not know the size of linked
+// files, so asset sizes are 0.
+func (p *Publisher) Fetch(ctx context.Context, tag string) (publish.Published, bool, error) {
+	var release releaseResponse
+	err := p.do(ctx, http.MethodGet, p.releaseURL(tag, ""), "application/json", nil, &release)
+	if errors.Is(err, errNotFound) {
+		return publish.Published{}, false, nil
+	}
+	if err != nil {
+		return publish.Published{}, false, fmt.Errorf("gitlab: find release %s: %w", tag, err)
+	}
+	r := publish.Published{Tag: release.TagName, Name: release.Name, URL: release.Links.Self, Commit: release.Commit.ID}
+	for _, l := range release.Assets.Links {
+		r.Assets = append(r.Assets, publish.RemoteAsset{Name: l.Name, URL: l.URL})
+	}
+	return r, true, nil
+}
+
+// Download writes the content of the linked file a to w. The token is
+// only sent when the link points at the GitLab instance.
+func (p *Publisher) Download(ctx context.Context, a publish.RemoteAsset, w io.Writer) error {
+	if err := p.do(ctx, http.MethodGet, a.URL, "", nil, w); err != nil {
+		return fmt.Errorf("gitlab: download %s: %w", a.Name, err)
+	}
+	return nil
+}
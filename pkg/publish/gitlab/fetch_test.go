@@ -0,0 +1,69 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+func TestFetch(t *testing.T) {
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "" {
+			t.Errorf("Expected no token sent to another host")
+		}
+		io.WriteString(w, "mirrored")
+	}))
+	defer external.Close()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/projects/group/app/releases/v1.0.0":
+			io.WriteString(w, `{"tag_name": "v1.0.0", "name": "App 1.0", "commit": {"id": "abc123"}, "_links": {"self": "https://gitlab.com/group/app/-/releases/v1.0.0"},
+				"assets": {"links": [{"name": "app.tar.gz", "url": "`+server.URL+`/group/app/uploads/1/app.tar.gz"}, {"name": "mirror.zip", "url": "`+external.URL+`/mirror.zip"}]}}`)
+		case "/group/app/uploads/1/app.tar.gz":
+			if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+				t.Errorf("Expected the token sent to the instance")
+			}
+			io.WriteString(w, "content")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+
+	r, found, err := p.Fetch(context.Background(), "v1.0.0")
+	if err != nil || !found {
+		t.Fatalf("Expected the release, got %v, %v", found, err)
+	}
+	expected := publish.Published{
+		Tag:    "v1.0.0",
+		Name:   "App 1.0",
+		URL:    "https://gitlab.com/group/app/-/releases/v1.0.0",
+		Commit: "abc123",
+		Assets: []publish.RemoteAsset{
+			{Name: "app.tar.gz", URL: server.URL + "/group/app/uploads/1/app.tar.gz"},
+			{Name: "mirror.zip", URL: external.URL + "/mirror.zip"},
+		},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, r)
+	}
+	for i, want := range []string{"content", "mirrored"} {
+		var content bytes.Buffer
+		if err := p.Download(context.Background(), r.Assets[i], &content); err != nil || content.String() != want {
+			t.Errorf("Expected %q, got %q and %v", want, content.String(), err)
+		}
+	}
+
+	if _, found, err := p.Fetch(context.Background(), "v2.0.0"); found || err != nil {
+		t.Errorf("Expected no release for v2.0.0, got %v, %v", found, err)
+	}
+}
@@ -0,0 +1,393 @@
+// Package gitlab publishes releases through the GitLab Releases API
+// (https://docs.gitlab.com/ee/api/releases/).
+//
+// GitLab releases do not host files themselves: each asset is uploaded to the
+// project's uploads area and attached to the release as an asset link.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/providerapi"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/ratelimit"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+const (
+	// DefaultBaseURL is the gitlab.com API v4 endpoint.
+	DefaultBaseURL = "https://gitlab.com/api/v4"
+
+	// TokenEnv is the environment variable read by NewFromEnv for a personal,
+	// project or group access token. CI_JOB_TOKEN is accepted as a fallback
+	// inside GitLab CI.
+	TokenEnv = "GITLAB_TOKEN"
+)
+
+// ErrNoToken is returned by NewFromEnv when no token is configured.
+var ErrNoToken = errors.New("gitlab: no token in " + TokenEnv + " or CI_JOB_TOKEN")
+
+// Publisher creates GitLab releases for a single project.
+type Publisher struct {
+	// Project is the numeric ID or "group/project" path of the project.
+	Project string
+	Token   string
+	// JobToken selects the JOB-TOKEN header used by CI_JOB_TOKEN instead of
+	// PRIVATE-TOKEN.
+	JobToken bool
+	// OAuth sends Token as an OAuth 2.0 bearer token, in the Authorization
+	// header, instead of PRIVATE-TOKEN.
+	OAuth bool
+
+	// BaseURL defaults to DefaultBaseURL; see APIBaseURL for self-hosted
+	// instances.
+	BaseURL    string
+	HTTPClient *http.Client
+	// Retry governs retries of requests failing with 429, 5xx or a network
+	// error. The zero value sends each request once.
+	Retry retry.Policy
+	// RateLimit paces requests by the budget responses report; nil sends
+	// them unpaced.
+	RateLimit *ratelimit.Limiter
+	// Uploads sets how many assets are uploaded at once and reports their
+	// progress.
+	Uploads publish.Uploader
+	// Lookups batches the issues looked up at once into requests listing
+	// them.
+	Lookups providerapi.Batches
+
+	// DryRun describes the requests that would be made on Log instead of
+	// sending them (see package dryrun).
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every API request; nil discards.
+	Logger *slog.Logger
+
+	// issues remembers the lookups of Issues.
+	issues providerapi.Cache[int, references.Reference]
+}
+
+var _ publish.Publisher = (*Publisher)(nil)
+
+// New returns a Publisher for project authenticated with token.
+func New(project, token string) *Publisher {
+	return &Publisher{
+		Project:    project,
+		Token:      token,
+		BaseURL:    DefaultBaseURL,
+		HTTPClient: http.DefaultClient,
+		Retry:      retry.Default(),
+		RateLimit:  ratelimit.New(),
+		Log:        io.Discard,
+	}
+}
+
+// NewFromEnv returns a Publisher for project, reading the token from the
+// environment. Inside GitLab CI, CI_API_V4_URL overrides the base URL.
+func NewFromEnv(project string) (*Publisher, error) {
+	return NewFromSecrets(project, os.Getenv)
+}
+
+// NewFromSecrets is NewFromEnv with the token looked up by secret, which is
+// asked for TokenEnv, then CI_JOB_TOKEN.
+func NewFromSecrets(project string, secret func(name string) string) (*Publisher, error) {
+	if project == "" {
+		return nil, errors.New("gitlab: no project configured")
+	}
+
+	p := New(project, secret(TokenEnv))
+	if p.Token == "" {
+		p.Token, p.JobToken = secret("CI_JOB_TOKEN"), true
+	}
+	if p.Token == "" {
+		return nil, ErrNoToken
+	}
+	if base := os.Getenv("CI_API_V4_URL"); base != "" {
+		p.BaseURL = base
+	}
+	return p, nil
+}
+
+// APIBaseURL returns the API v4 endpoint of the GitLab instance at base:
+// base itself when it ends in /api/v4, as "https://gitlab.example.com/api/v4"
+// does, or base with /api/v4 appended, which also serves an instance under
+// a relative URL such as "https://example.com/gitlab". It returns
+// DefaultBaseURL for an empty base.
+func APIBaseURL(base string) string {
+	base = strings.TrimRight(base, "/")
+	switch {
+	case base == "":
+		return DefaultBaseURL
+	case strings.HasSuffix(base, "/api/v4"):
+		return base
+	}
+	return base + "/api/v4"
+}
+
+type assetLink struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	LinkType string `json:"link_type,omitempty"`
+}
+
+type releaseRequest struct {
+	Name        string   `json:"name"`
+	TagName     string   `json:"tag_name"`
+	Description string   `json:"description"`
+	Milestones  []string `json:"milestones,omitempty"`
+	Assets      struct {
+		Links []assetLink `json:"links,omitempty"`
+	} `json:"assets"`
+}
+
+type releaseResponse struct {
+	TagName   string    `json:"tag_name"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Commit    struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+	Links struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	Assets struct {
+		Links []assetLink `json:"links"`
+	} `json:"assets"`
+}
+
+// errNotFound is reported by do for 404 responses.
+var errNotFound = errors.New("404 Not Found")
+
+type uploadResponse struct {
+	URL      string `json:"url"`
+	FullPath string `json:"full_path"`
+}
+
+// Publish uploads the assets, then creates the release with links to them
+// and the requested milestones. GitLab has no draft or prerelease flags;
+// upcoming releases are expressed through released_at, which is not set here.
+//
+// When the release already exists it is resumed: assets without a link of
+// the same name are uploaded and linked, and its notes and milestones are
+// left untouched.
+func (p *Publisher) Publish(ctx context.Context, r publish.Release) (publish.Result, error) {
+	name := r.Title()
+
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would create GitLab release %s (%q) on %s", r.Tag, name, p.Project)
+		for _, a := range r.Assets {
+			dryrun.Printf(p.Log, "would upload asset %s", a)
+		}
+		for _, m := range r.Milestones {
+			dryrun.Printf(p.Log, "would associate milestone %s", m)
+		}
+		return publish.Result{}, nil
+	}
+
+	var existing releaseResponse
+	err := p.do(ctx, http.MethodGet, p.releaseURL(r.Tag, ""), "application/json", nil, &existing)
+	switch {
+	case err == nil:
+		return p.resume(ctx, existing, r.Assets)
+	case !errors.Is(err, errNotFound):
+		return publish.Result{}, fmt.Errorf("gitlab: find release %s: %w", r.Tag, err)
+	}
+
+	req := releaseRequest{
+		Name:        name,
+		TagName:     r.Tag,
+		Description: r.Body,
+		Milestones:  r.Milestones,
+	}
+	links := make([]assetLink, len(r.Assets))
+	if _, err := p.Uploads.Upload(ctx, r.Assets, func(ctx context.Context, i int) (err error) {
+		if links[i], err = p.upload(ctx, r.Assets[i]); err != nil {
+			return fmt.Errorf("gitlab: upload %s: %w", r.Assets[i], err)
+		}
+		return nil
+	}); err != nil {
+		return publish.Result{}, err
+	}
+	req.Assets.Links = links
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return publish.Result{}, err
+	}
+
+	var created releaseResponse
+	if err := p.do(ctx, http.MethodPost, p.projectURL("releases"), "application/json", bytes.NewReader(payload), &created); err != nil {
+		return publish.Result{}, fmt.Errorf("gitlab: create release %s: %w", r.Tag, err)
+	}
+
+	return publish.Result{ID: created.TagName, URL: created.Links.Self, Uploaded: r.Assets}, nil
+}
+
+// resume uploads and links the assets missing from an existing release.
+func (p *Publisher) resume(ctx context.Context, release releaseResponse, assets []string) (publish.Result, error) {
+	result := publish.Result{ID: release.TagName, URL: release.Links.Self, Existing: true}
+
+	var attached []string
+	for _, l := range release.Assets.Links {
+		attached = append(attached, l.Name)
+	}
+	missing := publish.MissingAssets(assets, attached)
+	var err error
+	result.Uploaded, err = p.Uploads.Upload(ctx, missing, func(ctx context.Context, i int) error {
+		a := missing[i]
+		link, err := p.upload(ctx, a)
+		if err != nil {
+			return fmt.Errorf("gitlab: upload %s: %w", a, err)
+		}
+		payload, err := json.Marshal(link)
+		if err != nil {
+			return err
+		}
+		if err := p.do(ctx, http.MethodPost, p.releaseURL(release.TagName, "/assets/links"), "application/json", bytes.NewReader(payload), nil); err != nil {
+			return fmt.Errorf("gitlab: link %s: %w", a, err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (p *Publisher) releaseURL(tag, suffix string) string {
+	return p.projectURL("releases/" + url.PathEscape(tag) + suffix)
+}
+
+func (p *Publisher) upload(ctx context.Context, path string) (assetLink, error) {
+	a, err := publish.OpenAsset(ctx, path)
+	if err != nil {
+		return assetLink{}, err
+	}
+	defer a.Close()
+	body, contentType, err := a.Multipart("file")
+	if err != nil {
+		return assetLink{}, err
+	}
+
+	var uploaded uploadResponse
+	if err := p.do(ctx, http.MethodPost, p.projectURL("uploads"), contentType, body, &uploaded); err != nil {
+		return assetLink{}, err
+	}
+
+	return assetLink{
+		Name:     a.Name,
+		URL:      p.webURL() + uploaded.FullPath,
+		LinkType: "package",
+	}, nil
+}
+
+func (p *Publisher) projectURL(resource string) string {
+	return fmt.Sprintf("%s/projects/%s/%s", strings.TrimRight(p.BaseURL, "/"), url.PathEscape(p.Project), resource)
+}
+
+// webURL derives the instance's web root from the API base URL.
+func (p *Publisher) webURL() string {
+	return strings.TrimSuffix(strings.TrimRight(p.BaseURL, "/"), "/api/v4")
+}
+
+// do sends a request, retrying it according to p.Retry. A body that is
+// not an io.Seeker cannot be replayed, so such requests are sent once.
+func (p *Publisher) do(ctx context.Context, method, endpoint, contentType string, body io.Reader, out any) error {
+	policy := p.Retry
+	seeker, ok := body.(io.Seeker)
+	if body != nil && !ok {
+		policy = retry.Policy{}
+	}
+	return policy.Do(ctx, func(ctx context.Context) error {
+		if seeker != nil {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		return p.send(ctx, method, endpoint, contentType, body, out)
+	})
+}
+
+func (p *Publisher) send(ctx context.Context, method, endpoint, contentType string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	// Asset links may point anywhere; only the instance gets the token.
+	switch base, _ := url.Parse(p.BaseURL); {
+	case base == nil || req.URL.Host != base.Host:
+	case p.JobToken:
+		req.Header.Set("JOB-TOKEN", p.Token)
+	case p.OAuth:
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	default:
+		req.Header.Set("PRIVATE-TOKEN", p.Token)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if sr, ok := body.(*io.SectionReader); ok {
+		req.ContentLength = sr.Size()
+	}
+
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if p.RateLimit != nil {
+		if err := p.RateLimit.Wait(ctx, method); err != nil {
+			return err
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if p.RateLimit != nil {
+		p.RateLimit.Update(resp.Header, ratelimit.GitLab)
+	}
+	attrs := []any{"method", method, "url", endpoint, "status", resp.StatusCode}
+	if remaining := resp.Header.Get(ratelimit.GitLab + "Remaining"); remaining != "" {
+		attrs = append(attrs, "rate_limit_remaining", remaining)
+	}
+	log.Or(p.Logger).Debug("gitlab API request", attrs...)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, endpoint, errNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		err := fmt.Errorf("%s %s: %s: %s", method, endpoint, resp.Status, strings.TrimSpace(string(msg)))
+		if retry.HTTPStatus(resp.StatusCode) {
+			now := time.Now()
+			return retry.After(err, max(retry.RetryAfter(resp.Header.Get("Retry-After"), now),
+				retry.RateLimitReset(resp.Header.Get("RateLimit-Remaining"), resp.Header.Get("RateLimit-Reset"), now)))
+		}
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if w, ok := out.(io.Writer); ok {
+		// Part of the content may be written already, so a failure to
+		// read the rest is not retried.
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%s %s: %v", method, endpoint, err)
+		}
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,301 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+func TestPublish(t *testing.T) {
+	var (
+		created  releaseRequest
+		uploaded string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			t.Errorf("Expected private token, got %q", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fapp/releases/v1.0.0":
+			w.WriteHeader(http.StatusNotFound)
+		case "/api/v4/projects/group%2Fapp/uploads":
+			f, h, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			b, _ := io.ReadAll(f)
+			uploaded = h.Filename + ":" + string(b)
+			io.WriteString(w, `{"url": "/uploads/abc/app.tar.gz", "full_path": "/-/project/1/uploads/abc/app.tar.gz"}`)
+		case "/api/v4/projects/group%2Fapp/releases":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"tag_name": "v1.0.0", "_links": {"self": "https://gitlab.example.com/group/app/-/releases/v1.0.0"}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	asset := filepath.Join(t.TempDir(), "app.tar.gz")
+	if err := os.WriteFile(asset, []byte("binary"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+
+	result, err := p.Publish(context.Background(), publish.Release{
+		Tag:        "v1.0.0",
+		Body:       "notes",
+		Assets:     []string{asset},
+		Milestones: []string{"1.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.URL != "https://gitlab.example.com/group/app/-/releases/v1.0.0" || result.ID != "v1.0.0" {
+		t.Errorf("Unexpected result %#v", result)
+	}
+	if uploaded != "app.tar.gz:binary" {
+		t.Errorf("Expected asset upload, got %q", uploaded)
+	}
+	if created.Name != "Release v1.0.0" || created.TagName != "v1.0.0" || created.Description != "notes" {
+		t.Errorf("Unexpected release request %#v", created)
+	}
+	if !reflect.DeepEqual(created.Milestones, []string{"1.0"}) {
+		t.Errorf("Expected milestones [1.0], got %v", created.Milestones)
+	}
+	expectedLink := assetLink{Name: "app.tar.gz", URL: server.URL + "/-/project/1/uploads/abc/app.tar.gz", LinkType: "package"}
+	if len(created.Assets.Links) != 1 || created.Assets.Links[0] != expectedLink {
+		t.Errorf("Expected link %#v, got %#v", expectedLink, created.Assets.Links)
+	}
+}
+
+func TestPublishJobToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("JOB-TOKEN") != "job" || r.Header.Get("PRIVATE-TOKEN") != "" {
+			t.Errorf("Expected job token header only, got %v", r.Header)
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	p := New("1", "job")
+	p.JobToken = true
+	p.BaseURL = server.URL
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPublishOAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer oauth" || r.Header.Get("PRIVATE-TOKEN") != "" {
+			t.Errorf("Expected a bearer token only, got %v", r.Header)
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	p := New("1", "oauth")
+	p.OAuth = true
+	p.BaseURL = server.URL
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAPIBaseURL(t *testing.T) {
+	tests := map[string]string{
+		"":                                   DefaultBaseURL,
+		"https://gitlab.example.com":         "https://gitlab.example.com/api/v4",
+		"https://gitlab.example.com/api/v4/": "https://gitlab.example.com/api/v4",
+		"https://example.com/gitlab":         "https://example.com/gitlab/api/v4",
+	}
+	for base, expected := range tests {
+		if got := APIBaseURL(base); got != expected {
+			t.Errorf("APIBaseURL(%q): Expected %q, got %q", base, expected, got)
+		}
+	}
+}
+
+func TestPublishAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusConflict)
+		io.WriteString(w, `{"message": "Release already exists"}`)
+	}))
+	defer server.Close()
+
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL
+
+	_, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"})
+	if err == nil || !strings.Contains(err.Error(), "Release already exists") {
+		t.Errorf("Expected conflict error, got %v", err)
+	}
+}
+
+func TestPublishRetryGivesUp(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `{"message": "maintenance"}`)
+	}))
+	defer server.Close()
+
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+	p.Retry = retry.Policy{MaxAttempts: 3, Initial: time.Millisecond}
+
+	_, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"})
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("Expected the 503 after 3 attempts, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 requests, got %d", attempts)
+	}
+}
+
+func TestPublishRateLimitBudget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "2000")
+		w.Header().Set("RateLimit-Remaining", "1500")
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"tag_name": "v1.0.0"}`)
+	}))
+	defer server.Close()
+
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, ok := p.RateLimit.Budget(); !ok || b.Limit != 2000 || b.Remaining != 1500 {
+		t.Errorf("Expected the RateLimit headers to be recorded, got %+v", b)
+	}
+}
+
+func TestPublishResume(t *testing.T) {
+	var uploads, links []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /api/v4/projects/group%2Fapp/releases/pkg%2Fv1.0.0":
+			io.WriteString(w, `{"tag_name": "pkg/v1.0.0", "_links": {"self": "https://gitlab.example.com/r"}, "assets": {"links": [{"name": "app_linux", "url": "x"}]}}`)
+		case "POST /api/v4/projects/group%2Fapp/uploads":
+			_, h, _ := r.FormFile("file")
+			uploads = append(uploads, h.Filename)
+			io.WriteString(w, `{"full_path": "/-/project/1/uploads/abc/`+h.Filename+`"}`)
+		case "POST /api/v4/projects/group%2Fapp/releases/pkg%2Fv1.0.0/assets/links":
+			var l assetLink
+			json.NewDecoder(r.Body).Decode(&l)
+			links = append(links, l.Name+" "+l.URL)
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	var assets []string
+	for _, name := range []string{"app_linux", "app_darwin"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(name), 0o644)
+		assets = append(assets, path)
+	}
+
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+
+	result, err := p.Publish(context.Background(), publish.Release{Tag: "pkg/v1.0.0", Assets: assets, Milestones: []string{"1.0"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Existing || result.URL != "https://gitlab.example.com/r" {
+		t.Errorf("Expected the existing release to be resumed, got %#v", result)
+	}
+	if !reflect.DeepEqual(uploads, []string{"app_darwin"}) {
+		t.Errorf("Expected only app_darwin to be uploaded, got %v", uploads)
+	}
+	expected := []string{"app_darwin " + server.URL + "/-/project/1/uploads/abc/app_darwin"}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected %v, got %v", expected, links)
+	}
+}
+
+func TestPublishDryRun(t *testing.T) {
+	var log bytes.Buffer
+	p := New("group/app", "secret")
+	p.DryRun = true
+	p.Log = &log
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.0.0", Assets: []string{"dist/app"}, Milestones: []string{"1.0"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "[dry-run] would create GitLab release v1.0.0 (\"Release v1.0.0\") on group/app\n" +
+		"[dry-run] would upload asset dist/app\n" +
+		"[dry-run] would associate milestone 1.0\n"
+	if log.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, log.String())
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	t.Setenv("CI_JOB_TOKEN", "")
+	t.Setenv("CI_API_V4_URL", "")
+
+	if _, err := NewFromEnv("group/app"); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Expected ErrNoToken, got %v", err)
+	}
+
+	t.Setenv("CI_JOB_TOKEN", "job")
+	t.Setenv("CI_API_V4_URL", "https://gitlab.example.com/api/v4")
+	p, err := NewFromEnv("group/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !p.JobToken || p.Token != "job" || p.BaseURL != "https://gitlab.example.com/api/v4" {
+		t.Errorf("Unexpected publisher %#v", p)
+	}
+
+	t.Setenv(TokenEnv, "pat")
+	p, _ = NewFromEnv("group/app")
+	if p.JobToken || p.Token != "pat" {
+		t.Errorf("Expected GITLAB_TOKEN to take precedence, got %#v", p)
+	}
+}
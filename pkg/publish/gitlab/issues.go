@@ -0,0 +1,71 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+// issuesPerPage is the most issues GitLab lists per request.
+const issuesPerPage = 100
+
+var _ references.BatchFinder = (*Publisher)(nil)
+
+type issueResponse struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	WebURL string `json:"web_url"`
+	Author struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	Labels []string `json:"labels"`
+}
+
+// Issue returns the issue number. "#" references name issues on GitLab;
+// merge requests are referenced as "!number" and never looked up.
+func (p *Publisher) Issue(ctx context.Context, number int) (references.Reference, error) {
+	var i issueResponse
+	if err := p.do(ctx, http.MethodGet, p.projectURL("issues/"+strconv.Itoa(number)), "application/json", nil, &i); err != nil {
+		return references.Reference{}, fmt.Errorf("gitlab: issue #%d: %w", number, err)
+	}
+	return i.reference(), nil
+}
+
+func (i issueResponse) reference() references.Reference {
+	return references.Reference{Number: i.IID, Title: i.Title, Author: i.Author.Username, Labels: i.Labels, URL: i.WebURL}
+}
+
+// Issues returns the issues of numbers, each batch of p.Lookups, of at
+// most 100, listed by one request. Numbers naming no issue are left out.
+func (p *Publisher) Issues(ctx context.Context, numbers []int) (map[int]references.Reference, error) {
+	b := p.Lookups
+	b.Size = min(b.Size, issuesPerPage)
+	if b.Budget == nil && p.RateLimit != nil {
+		b.Budget = p.RateLimit
+	}
+	refs, err := p.issues.Get(ctx, b, numbers, p.issueBatch)
+	if err != nil {
+		return refs, fmt.Errorf("gitlab: issues: %w", err)
+	}
+	return refs, nil
+}
+
+func (p *Publisher) issueBatch(ctx context.Context, numbers []int) (map[int]references.Reference, error) {
+	query := url.Values{"scope": {"all"}, "per_page": {strconv.Itoa(issuesPerPage)}}
+	for _, n := range numbers {
+		query.Add("iids[]", strconv.Itoa(n))
+	}
+	var issues []issueResponse
+	if err := p.do(ctx, http.MethodGet, p.projectURL("issues?"+query.Encode()), "application/json", nil, &issues); err != nil {
+		return nil, err
+	}
+	refs := make(map[int]references.Reference, len(issues))
+	for _, i := range issues {
+		refs[i.IID] = i.reference()
+	}
+	return refs, nil
+}
@@ -0,0 +1,76 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/providerapi"
+	"github.com/gbrennon/release_automation_golang/pkg/references"
+)
+
+func TestIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fapp/issues/7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, `{"iid": 7, "title": "Crash", "web_url": "https://gitlab.com/group/app/-/issues/7", "author": {"username": "ada"}, "labels": ["bug"]}`)
+	}))
+	defer server.Close()
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+
+	got, err := p.Issue(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := references.Reference{Number: 7, Title: "Crash", Author: "ada", Labels: []string{"bug"}, URL: "https://gitlab.com/group/app/-/issues/7"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if _, err := p.Issue(context.Background(), 8); err == nil {
+		t.Error("Expected an error for a missing issue")
+	}
+}
+
+func TestIssues(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fapp/issues" || r.URL.Query().Get("scope") != "all" {
+			t.Errorf("Expected the issues of the project, got %s", r.URL)
+		}
+		requests = append(requests, r.URL.RawQuery)
+		var issues []string
+		for _, iid := range r.URL.Query()["iids[]"] {
+			if iid != "404" {
+				issues = append(issues, fmt.Sprintf(`{"iid": %s, "title": "Issue %s", "web_url": "https://gitlab.com/group/app/-/issues/%s", "author": {"username": "ada"}, "labels": ["bug"]}`, iid, iid, iid))
+			}
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(issues, ", "))
+	}))
+	defer server.Close()
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+	p.Lookups = providerapi.Batches{Size: 2, Concurrency: 1}
+
+	got, err := p.Issues(context.Background(), []int{7, 404, 9})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[int]references.Reference{
+		7: {Number: 7, Title: "Issue 7", Author: "ada", Labels: []string{"bug"}, URL: "https://gitlab.com/group/app/-/issues/7"},
+		9: {Number: 9, Title: "Issue 9", Author: "ada", Labels: []string{"bug"}, URL: "https://gitlab.com/group/app/-/issues/9"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if len(requests) != 2 || !strings.Contains(requests[0], "iids%5B%5D=7&iids%5B%5D=404") {
+		t.Errorf("Expected 2 requests of 2 issues at most, got %v", requests)
+	}
+}
@@ -0,0 +1,52 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Lister = (*Publisher)(nil)
+
+// releasesPerPage is the page size of release listings, GitLab's maximum.
+const releasesPerPage = 100
+
+// Releases returns every release of the project, newest first. GitLab has
+// no draft or prerelease flags: prereleases are told by their tag.
+func (p *Publisher) Releases(ctx context.Context) ([]publish.Listed, error) {
+	var listed []publish.Listed
+	for page := 1; ; page++ {
+		var releases []releaseResponse
+		query := url.Values{"per_page": {strconv.Itoa(releasesPerPage)}, "page": {strconv.Itoa(page)}}
+		if err := p.do(ctx, http.MethodGet, p.projectURL("releases?"+query.Encode()), "application/json", nil, &releases); err != nil {
+			return nil, fmt.Errorf("gitlab: list releases: %w", err)
+		}
+		for _, r := range releases {
+			l := publish.Listed{ID: r.TagName, Tag: r.TagName, Name: r.Name, URL: r.Links.Self, Created: r.CreatedAt}
+			for _, a := range r.Assets.Links {
+				l.Assets = append(l.Assets, publish.RemoteAsset{Name: a.Name, URL: a.URL})
+			}
+			listed = append(listed, l)
+		}
+		if len(releases) < releasesPerPage {
+			return listed, nil
+		}
+	}
+}
+
+// DeleteRelease deletes the release of r.Tag with its asset links.
+func (p *Publisher) DeleteRelease(ctx context.Context, r publish.Listed) error {
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would delete GitLab release %s on %s", r.Tag, p.Project)
+		return nil
+	}
+	if err := p.do(ctx, http.MethodDelete, p.releaseURL(r.Tag, ""), "application/json", nil, nil); err != nil {
+		return fmt.Errorf("gitlab: delete release %s: %w", r.Tag, err)
+	}
+	return nil
+}
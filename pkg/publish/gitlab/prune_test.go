@@ -0,0 +1,49 @@
+package gitlab
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReleases(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath()+"?"+r.URL.RawQuery)
+		if r.Method == http.MethodDelete {
+			io.WriteString(w, `{"tag_name": "pkg/v1.0.0-rc.1"}`)
+			return
+		}
+		io.WriteString(w, `[{"tag_name": "pkg/v1.0.0-rc.1", "name": "RC", "created_at": "2024-06-01T10:00:00.000Z", `+
+			`"_links": {"self": "https://gitlab.com/group/app/-/releases/pkg%2Fv1.0.0-rc.1"}, "assets": {"links": [{"name": "app.tar.gz", "url": "https://gitlab.com/uploads/app.tar.gz"}]}}]`)
+	}))
+	defer server.Close()
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+
+	releases, err := p.Releases(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(releases) != 1 {
+		t.Fatalf("Expected 1 release, got %#v", releases)
+	}
+	r := releases[0]
+	if r.Tag != "pkg/v1.0.0-rc.1" || r.Name != "RC" || r.Draft || !r.Created.Equal(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)) || len(r.Assets) != 1 {
+		t.Errorf("Unexpected release %#v", r)
+	}
+
+	if err := p.DeleteRelease(context.Background(), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"GET /api/v4/projects/group%2Fapp/releases?page=1&per_page=100",
+		"DELETE /api/v4/projects/group%2Fapp/releases/pkg%2Fv1.0.0-rc.1?",
+	}
+	if len(requests) != 2 || requests[0] != expected[0] || requests[1] != expected[1] {
+		t.Errorf("Expected %q, got %q", expected, requests)
+	}
+}
@@ -0,0 +1,33 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Retractor = (*Publisher)(nil)
+
+// Retract deletes the release of tag; its asset links go with it, the tag
+// stays. GitLab has no draft releases, so draft is refused.
+func (p *Publisher) Retract(ctx context.Context, tag string, draft bool) (bool, error) {
+	if draft {
+		return false, fmt.Errorf("gitlab: release %s cannot be turned into a draft: GitLab has no draft releases", tag)
+	}
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would delete GitLab release %s on %s", tag, p.Project)
+		return true, nil
+	}
+	err := p.do(ctx, http.MethodDelete, p.releaseURL(tag, ""), "application/json", nil, nil)
+	switch {
+	case errors.Is(err, errNotFound):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("gitlab: delete release %s: %w", tag, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,36 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRetract(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.EscapedPath() != "/api/v4/projects/group%2Fapp/releases/pkg%2Fv1.0.0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		deleted = append(deleted, r.URL.EscapedPath())
+		w.Write([]byte(`{"tag_name": "pkg/v1.0.0"}`))
+	}))
+	defer server.Close()
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+
+	if found, err := p.Retract(context.Background(), "pkg/v1.0.0", false); !found || err != nil {
+		t.Fatalf("Expected the release deleted, got %v, %v", found, err)
+	}
+	if len(deleted) != 1 {
+		t.Errorf("Expected one DELETE request, got %v", deleted)
+	}
+	if found, err := p.Retract(context.Background(), "v2.0.0", false); found || err != nil {
+		t.Errorf("Expected no release for v2.0.0, got %v, %v", found, err)
+	}
+	if _, err := p.Retract(context.Background(), "pkg/v1.0.0", true); err == nil {
+		t.Error("Expected drafts to be refused")
+	}
+}
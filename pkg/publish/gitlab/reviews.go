@@ -0,0 +1,58 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.Reviewer = (*Publisher)(nil)
+
+type mergeRequestResponse struct {
+	IID   int    `json:"iid"`
+	State string `json:"state"`
+}
+
+type approvalsResponse struct {
+	ApprovedBy []struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"approved_by"`
+}
+
+// CommitPullRequest returns the IID of the merged merge request commit is
+// part of, the first GitLab lists.
+func (p *Publisher) CommitPullRequest(ctx context.Context, commit string) (int, bool, error) {
+	var mrs []mergeRequestResponse
+	if err := p.do(ctx, http.MethodGet, p.projectURL("repository/commits/"+url.PathEscape(commit)+"/merge_requests"), "application/json", nil, &mrs); err != nil {
+		return 0, false, fmt.Errorf("gitlab: merge requests of %s: %w", commit, err)
+	}
+	for _, mr := range mrs {
+		if mr.State == "merged" {
+			return mr.IID, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// Approvers returns the users approving merge request number, its IID,
+// sorted. GitLab takes approvals back itself when commits are pushed, if
+// the project is set up to.
+func (p *Publisher) Approvers(ctx context.Context, number int) ([]string, error) {
+	var a approvalsResponse
+	if err := p.do(ctx, http.MethodGet, p.projectURL("merge_requests/"+strconv.Itoa(number)+"/approvals"), "application/json", nil, &a); err != nil {
+		return nil, fmt.Errorf("gitlab: approvals of !%d: %w", number, err)
+	}
+	var approvers []string
+	for _, u := range a.ApprovedBy {
+		approvers = append(approvers, u.User.Username)
+	}
+	slices.Sort(approvers)
+	return approvers, nil
+}
@@ -0,0 +1,41 @@
+package gitlab
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestReviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fapp/repository/commits/abc123/merge_requests":
+			io.WriteString(w, `[{"iid": 3, "state": "closed"}, {"iid": 4, "state": "merged"}]`)
+		case "/api/v4/projects/group%2Fapp/merge_requests/4/approvals":
+			io.WriteString(w, `{"approved_by": [{"user": {"username": "bob"}}, {"user": {"username": "ada"}}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := New("group/app", "secret")
+	p.BaseURL = server.URL + "/api/v4"
+
+	n, found, err := p.CommitPullRequest(context.Background(), "abc123")
+	if err != nil || !found || n != 4 {
+		t.Errorf("Expected the merged merge request !4, got %d, %v, %v", n, found, err)
+	}
+	approvers, err := p.Approvers(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"ada", "bob"}; !reflect.DeepEqual(approvers, expected) {
+		t.Errorf("Expected %v, got %v", expected, approvers)
+	}
+	if _, err := p.Approvers(context.Background(), 5); err == nil {
+		t.Error("Expected an error for a missing merge request")
+	}
+}
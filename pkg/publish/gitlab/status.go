@@ -0,0 +1,56 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+var _ publish.StatusChecker = (*Publisher)(nil)
+
+type pipelineResponse struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+	WebURL string `json:"web_url"`
+}
+
+type jobResponse struct {
+	Name string `json:"name"`
+}
+
+// CIStatus reports the latest pipeline for the commit ref. Failed
+// pipelines name their failed jobs.
+func (p *Publisher) CIStatus(ctx context.Context, ref string) (publish.CIStatus, error) {
+	var pipelines []pipelineResponse
+	endpoint := p.projectURL("pipelines?per_page=1&sha=" + url.QueryEscape(ref))
+	if err := p.do(ctx, http.MethodGet, endpoint, "application/json", nil, &pipelines); err != nil {
+		return publish.CIStatus{}, fmt.Errorf("gitlab: CI status of %s: %w", ref, err)
+	}
+	if len(pipelines) == 0 {
+		return publish.CIStatus{State: publish.CINone}, nil
+	}
+
+	pl := pipelines[0]
+	s := publish.CIStatus{URL: pl.WebURL}
+	switch pl.Status {
+	case "success", "skipped":
+		s.State = publish.CISuccess
+	case "failed", "canceled":
+		s.State = publish.CIFailure
+		var jobs []jobResponse
+		endpoint := p.projectURL(fmt.Sprintf("pipelines/%d/jobs?scope[]=failed&scope[]=canceled", pl.ID))
+		if err := p.do(ctx, http.MethodGet, endpoint, "application/json", nil, &jobs); err != nil {
+			return publish.CIStatus{}, fmt.Errorf("gitlab: CI status of %s: %w", ref, err)
+		}
+		for _, j := range jobs {
+			s.Checks = append(s.Checks, j.Name)
+		}
+	default:
+		s.State = publish.CIPending
+		s.Checks = []string{fmt.Sprintf("pipeline %d (%s)", pl.ID, pl.Status)}
+	}
+	return s, nil
+}
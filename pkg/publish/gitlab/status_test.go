@@ -0,0 +1,53 @@
+package gitlab
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+func TestCIStatus(t *testing.T) {
+	tests := []struct {
+		pipelines string
+		expected  publish.CIState
+		checks    []string
+	}{
+		{`[{"id": 9, "status": "success", "web_url": "https://gitlab.com/g/p/-/pipelines/9"}]`, publish.CISuccess, nil},
+		{`[{"id": 9, "status": "failed", "web_url": "https://gitlab.com/g/p/-/pipelines/9"}]`, publish.CIFailure, []string{"test", "lint"}},
+		{`[{"id": 9, "status": "running", "web_url": "https://gitlab.com/g/p/-/pipelines/9"}]`, publish.CIPending, []string{"pipeline 9 (running)"}},
+		{`[]`, publish.CINone, nil},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.EscapedPath() {
+			case "/projects/g%2Fp/pipelines":
+				if r.URL.Query().Get("sha") != "abc123" {
+					t.Errorf("Expected pipelines for abc123, got %s", r.URL)
+				}
+				io.WriteString(w, tt.pipelines)
+			case "/projects/g%2Fp/pipelines/9/jobs":
+				io.WriteString(w, `[{"name": "test"}, {"name": "lint"}]`)
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		p := New("g/p", "secret")
+		p.BaseURL = server.URL
+
+		s, err := p.CIStatus(context.Background(), "abc123")
+		server.Close()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.State != tt.expected || !slices.Equal(s.Checks, tt.checks) {
+			t.Errorf("Expected %s %v, got %s %v", tt.expected, tt.checks, s.State, s.Checks)
+		}
+	}
+}
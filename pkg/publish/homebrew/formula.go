@@ -0,0 +1,245 @@
+package homebrew
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Formula describes a formula installing a prebuilt binary per platform.
+type Formula struct {
+	// Name is the formula name, e.g. "app"; the file is Formula/<name>.rb.
+	Name     string
+	Desc     string
+	Homepage string
+	License  string
+	Version  string
+	// Binary is the command installed into bin; empty means Name.
+	Binary string
+	// Test is the body of the formula's test block, e.g.
+	// `system "#{bin}/app", "--version"`; empty omits the block.
+	Test   string
+	Assets []Asset
+}
+
+// Asset is a binary for one platform.
+type Asset struct {
+	// OS is "darwin" or "linux" and Arch "amd64" or "arm64".
+	OS, Arch string
+	URL      string
+	SHA256   string
+	// Filename is the file installed into bin: the downloaded file or, for
+	// an archive, which Homebrew extracts, the binary inside it.
+	Filename string
+}
+
+// Class returns the Ruby class name of the formula: "my-app" is MyApp.
+func (f Formula) Class() string {
+	var b strings.Builder
+	upper := true
+	for _, r := range f.Name {
+		switch {
+		case r == '-' || r == '_' || r == '.':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Command returns f.Binary, or f.Name when it is empty.
+func (f Formula) Command() string {
+	if f.Binary != "" {
+		return f.Binary
+	}
+	return f.Name
+}
+
+// MacOS returns the darwin assets.
+func (f Formula) MacOS() []Asset { return f.platform("darwin") }
+
+// Linux returns the linux assets.
+func (f Formula) Linux() []Asset { return f.platform("linux") }
+
+func (f Formula) platform(os string) []Asset {
+	var assets []Asset
+	for _, a := range f.Assets {
+		if a.OS == os {
+			assets = append(assets, a)
+		}
+	}
+	return assets
+}
+
+// CPU returns the Homebrew name of a's architecture: "arm" or "intel".
+func (a Asset) CPU() string {
+	if a.Arch == "arm64" {
+		return "arm"
+	}
+	return "intel"
+}
+
+// DefaultTemplate renders a formula with an on_macos and an on_linux block,
+// each holding an on_arm and an on_intel block for the available assets.
+const DefaultTemplate = `# typed: false
+# frozen_string_literal: true
+
+# This file was generated by release. DO NOT EDIT.
+class {{ .Class }} < Formula
+  desc {{ printf "%q" .Desc }}
+  homepage {{ printf "%q" .Homepage }}
+  version {{ printf "%q" .Version }}
+{{- with .License }}
+  license {{ printf "%q" . }}
+{{- end }}
+{{- with .MacOS }}
+
+  on_macos do
+{{- range . }}
+    on_{{ .CPU }} do
+      url {{ printf "%q" .URL }}
+      sha256 {{ printf "%q" .SHA256 }}
+
+      def install
+        bin.install {{ printf "%q" .Filename }} => {{ printf "%q" $.Command }}
+      end
+    end
+{{- end }}
+  end
+{{- end }}
+{{- with .Linux }}
+
+  on_linux do
+{{- range . }}
+    on_{{ .CPU }} do
+      url {{ printf "%q" .URL }}
+      sha256 {{ printf "%q" .SHA256 }}
+
+      def install
+        bin.install {{ printf "%q" .Filename }} => {{ printf "%q" $.Command }}
+      end
+    end
+{{- end }}
+  end
+{{- end }}
+{{- with .Test }}
+
+  test do
+    {{ . }}
+  end
+{{- end }}
+end
+`
+
+// Render executes tmpl, or DefaultTemplate when it is empty, with f.
+func Render(tmpl string, f Formula) ([]byte, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	t, err := template.New("formula").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("homebrew: template: %w", err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, f); err != nil {
+		return nil, fmt.Errorf("homebrew: template: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+var (
+	// platformOS and platformArch map the OS and architecture spellings
+	// found in asset names to GOOS and GOARCH.
+	platformOS   = map[string]string{"darwin": "darwin", "macos": "darwin", "osx": "darwin", "linux": "linux"}
+	platformArch = map[string]string{"amd64": "amd64", "x86_64": "amd64", "x64": "amd64", "arm64": "arm64", "aarch64": "arm64"}
+
+	// nameWord matches the words of asset names; x86_64 is one word.
+	nameWord = regexp.MustCompile(`x86_64|[^_.\-]+`)
+	// skipped are suffixes of files that are never binaries.
+	skipped = []string{"SHA256SUMS", ".sig", ".minisig", ".pem", ".sha256", ".txt", ".json", ".sbom"}
+	// archives are extracted by Homebrew before installing.
+	archives = []string{".tar.gz", ".tgz", ".tar.xz", ".zip"}
+)
+
+// Assets returns an Asset for each of paths naming a darwin or linux
+// binary, or an archive of one, such as "app_1.2.0_darwin_arm64", hashing
+// the file. Archives are expected to hold the binary called command. Other
+// files, like checksums, signatures and Windows binaries, are skipped. url
+// returns the download URL of a file name. Two files for the same platform
+// are an error.
+func Assets(paths []string, command string, url func(name string) (string, error)) ([]Asset, error) {
+	var assets []Asset
+	seen := make(map[string]string)
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if hasSuffix(name, skipped) {
+			continue
+		}
+		var goos, goarch string
+		for _, w := range nameWord.FindAllString(strings.ToLower(name), -1) {
+			if os, ok := platformOS[w]; ok {
+				goos = os
+			}
+			if arch, ok := platformArch[w]; ok {
+				goarch = arch
+			}
+		}
+		if goos == "" || goarch == "" {
+			continue
+		}
+		platform := goos + "/" + goarch
+		if other, ok := seen[platform]; ok {
+			return nil, fmt.Errorf("homebrew: %s and %s are both %s binaries", other, name, platform)
+		}
+		seen[platform] = name
+
+		sum, err := hashFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("homebrew: %w", err)
+		}
+		u, err := url(name)
+		if err != nil {
+			return nil, err
+		}
+		a := Asset{OS: goos, Arch: goarch, URL: u, SHA256: sum, Filename: name}
+		if hasSuffix(name, archives) {
+			a.Filename = command
+		}
+		assets = append(assets, a)
+	}
+	return assets, nil
+}
+
+func hasSuffix(name string, suffixes []string) bool {
+	for _, s := range suffixes {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,128 @@
+package homebrew
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClass(t *testing.T) {
+	tests := map[string]string{
+		"app":         "App",
+		"my-app":      "MyApp",
+		"release_cli": "ReleaseCli",
+		"app.go":      "AppGo",
+	}
+	for name, want := range tests {
+		if got := (Formula{Name: name}).Class(); got != want {
+			t.Errorf("Class of %q: Expected %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	f := Formula{
+		Name:     "my-app",
+		Desc:     `Cuts "releases"`,
+		Homepage: "https://github.com/octo/my-app",
+		License:  "MIT",
+		Version:  "1.2.0",
+		Binary:   "app",
+		Test:     `system "#{bin}/app", "--version"`,
+		Assets: []Asset{
+			{OS: "darwin", Arch: "arm64", URL: "https://x/app_darwin_arm64", SHA256: "aa", Filename: "app_darwin_arm64"},
+			{OS: "linux", Arch: "amd64", URL: "https://x/app_linux_amd64.tar.gz", SHA256: "bb", Filename: "app"},
+		},
+	}
+	out, err := Render("", f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `# typed: false
+# frozen_string_literal: true
+
+# This file was generated by release. DO NOT EDIT.
+class MyApp < Formula
+  desc "Cuts \"releases\""
+  homepage "https://github.com/octo/my-app"
+  version "1.2.0"
+  license "MIT"
+
+  on_macos do
+    on_arm do
+      url "https://x/app_darwin_arm64"
+      sha256 "aa"
+
+      def install
+        bin.install "app_darwin_arm64" => "app"
+      end
+    end
+  end
+
+  on_linux do
+    on_intel do
+      url "https://x/app_linux_amd64.tar.gz"
+      sha256 "bb"
+
+      def install
+        bin.install "app" => "app"
+      end
+    end
+  end
+
+  test do
+    system "#{bin}/app", "--version"
+  end
+end
+`
+	if string(out) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	if _, err := Render("{{ .Nope }}", f); err == nil {
+		t.Errorf("Expected an error for an unknown field")
+	}
+}
+
+func TestAssets(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{
+		"app_1.2.0_darwin_arm64",
+		"app_1.2.0_Linux_x86_64.tar.gz",
+		"app_1.2.0_windows_amd64.exe",
+		"app_1.2.0_linux_arm64.sig",
+		"SHA256SUMS",
+		"README.md",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	assets, err := Assets(paths, "app", func(name string) (string, error) { return "https://x/" + name, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	const sum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	expected := []Asset{
+		{OS: "darwin", Arch: "arm64", URL: "https://x/app_1.2.0_darwin_arm64", SHA256: sum, Filename: "app_1.2.0_darwin_arm64"},
+		{OS: "linux", Arch: "amd64", URL: "https://x/app_1.2.0_Linux_x86_64.tar.gz", SHA256: sum, Filename: "app"},
+	}
+	if len(assets) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, assets)
+	}
+	for i := range expected {
+		if assets[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], assets[i])
+		}
+	}
+
+	_, err = Assets([]string{paths[0], paths[0] + ".zip"}, "app", func(name string) (string, error) { return name, nil })
+	if err == nil || !strings.Contains(err.Error(), "both darwin/arm64") {
+		t.Errorf("Expected a duplicate platform error, got %v", err)
+	}
+}
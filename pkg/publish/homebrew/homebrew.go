@@ -0,0 +1,165 @@
+// Package homebrew publishes a release to a Homebrew tap: after the
+// binaries are uploaded elsewhere, it renders a formula pointing at their
+// download URLs, with the SHA-256 of each, and commits it to the tap
+// repository, either directly or through a pull request.
+//
+// Taps are GitHub repositories conventionally named homebrew-<tap>; Tap
+// is satisfied by *github.Publisher.
+package homebrew
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"text/template"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// TokenEnv is the environment variable holding a token allowed to push to
+// the tap. It is needed in GitHub Actions, whose GITHUB_TOKEN cannot write
+// to other repositories.
+const TokenEnv = "HOMEBREW_TAP_TOKEN"
+
+// ErrNoAssets is returned when a release has no darwin or linux binaries.
+var ErrNoAssets = errors.New("homebrew: no darwin or linux assets")
+
+// Tap is the repository holding the formulae.
+type Tap interface {
+	DefaultBranch(ctx context.Context) (string, error)
+	// File returns the content and blob SHA of path on ref; the SHA of a
+	// missing file is empty.
+	File(ctx context.Context, path, ref string) ([]byte, string, error)
+	// PutFile commits content to path on branch, replacing the blob sha,
+	// and returns the web page of the commit.
+	PutFile(ctx context.Context, path, branch, message string, content []byte, sha string) (string, error)
+	// CreateBranch creates branch name from branch from, unless it exists.
+	CreateBranch(ctx context.Context, name, from string) error
+	// PullRequest returns the open pull request from head into base,
+	// opening one when there is none.
+	PullRequest(ctx context.Context, head, base, title, body string) (string, error)
+}
+
+// Publisher updates a formula in a tap for each release.
+type Publisher struct {
+	Tap Tap
+	// Formula holds the name, description and other fixed fields of the
+	// formula; Version and Assets are filled in from each release.
+	Formula Formula
+	// Template is a text/template for the formula; empty means
+	// DefaultTemplate.
+	Template string
+	// URL is a text/template for the download URL of an asset, with .Tag,
+	// .Version and .Name, the file name, e.g.
+	// "https://github.com/org/app/releases/download/{{ .Tag }}/{{ .Name }}".
+	URL string
+	// Path is the formula file in the tap; empty means
+	// "Formula/<name>.rb".
+	Path string
+	// Branch is the tap branch updated; empty means its default branch.
+	Branch string
+	// PullRequest commits the formula to a new branch and opens a pull
+	// request into Branch instead of pushing to Branch directly.
+	PullRequest bool
+
+	// DryRun describes the update on Log instead of making it.
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every formula update; nil
+	// discards.
+	Logger *slog.Logger
+}
+
+var _ publish.Publisher = (*Publisher)(nil)
+
+// Publish renders the formula for the darwin and linux assets of r and
+// commits it to the tap. An unchanged formula is not committed again, so a
+// failed release can be published again. The result's URL is the commit or
+// the pull request, and its ID the formula path.
+func (p *Publisher) Publish(ctx context.Context, r publish.Release) (publish.Result, error) {
+	f := p.Formula
+	f.Version = r.Version
+	if f.Version == "" {
+		f.Version = strings.TrimPrefix(r.Tag, "v")
+	}
+	if f.Name == "" {
+		return publish.Result{}, errors.New("homebrew: no formula name")
+	}
+	if p.URL == "" {
+		return publish.Result{}, errors.New("homebrew: no download URL template")
+	}
+	path := p.Path
+	if path == "" {
+		path = "Formula/" + f.Name + ".rb"
+	}
+	message := fmt.Sprintf("%s %s", f.Name, f.Version)
+	if p.DryRun {
+		// The assets may not have been built, so they are not hashed.
+		how := "push"
+		if p.PullRequest {
+			how = "open a pull request for"
+		}
+		dryrun.Printf(p.Log, "would %s %s (%s) in the tap", how, path, message)
+		return publish.Result{}, nil
+	}
+
+	urlTmpl, err := template.New("url").Option("missingkey=error").Parse(p.URL)
+	if err != nil {
+		return publish.Result{}, fmt.Errorf("homebrew: url: %w", err)
+	}
+	f.Assets, err = Assets(r.Assets, f.Command(), func(name string) (string, error) {
+		var b strings.Builder
+		if err := urlTmpl.Execute(&b, map[string]string{"Tag": r.Tag, "Version": f.Version, "Name": name}); err != nil {
+			return "", fmt.Errorf("homebrew: url: %w", err)
+		}
+		return b.String(), nil
+	})
+	if err != nil {
+		return publish.Result{}, err
+	}
+	if len(f.Assets) == 0 {
+		return publish.Result{}, ErrNoAssets
+	}
+	content, err := Render(p.Template, f)
+	if err != nil {
+		return publish.Result{}, err
+	}
+
+	base := p.Branch
+	if base == "" {
+		if base, err = p.Tap.DefaultBranch(ctx); err != nil {
+			return publish.Result{}, err
+		}
+	}
+	branch := base
+	if p.PullRequest {
+		branch = fmt.Sprintf("%s-%s", f.Name, f.Version)
+		if err := p.Tap.CreateBranch(ctx, branch, base); err != nil {
+			return publish.Result{}, err
+		}
+	}
+	old, sha, err := p.Tap.File(ctx, path, branch)
+	if err != nil {
+		return publish.Result{}, err
+	}
+	result := publish.Result{ID: path}
+	if !bytes.Equal(old, content) {
+		log.Or(p.Logger).Debug("updating formula", "path", path, "branch", branch, "version", f.Version)
+		if result.URL, err = p.Tap.PutFile(ctx, path, branch, message, content, sha); err != nil {
+			return publish.Result{}, err
+		}
+	}
+	if p.PullRequest {
+		body := fmt.Sprintf("Update %s to %s (%s).", f.Name, f.Version, r.Tag)
+		if result.URL, err = p.Tap.PullRequest(ctx, branch, base, message, body); err != nil {
+			return publish.Result{}, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package homebrew
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/publish/github"
+)
+
+var _ Tap = (*github.Publisher)(nil)
+
+// fakeTap records the calls made to a tap holding files.
+type fakeTap struct {
+	files map[string]string
+	calls []string
+}
+
+func (f *fakeTap) DefaultBranch(context.Context) (string, error) { return "main", nil }
+
+func (f *fakeTap) File(_ context.Context, path, ref string) ([]byte, string, error) {
+	content, ok := f.files[ref+":"+path]
+	if !ok {
+		return nil, "", nil
+	}
+	return []byte(content), "sha-" + ref, nil
+}
+
+func (f *fakeTap) PutFile(_ context.Context, path, branch, message string, content []byte, sha string) (string, error) {
+	f.calls = append(f.calls, "put "+branch+":"+path+" "+message+" "+sha)
+	if f.files == nil {
+		f.files = make(map[string]string)
+	}
+	f.files[branch+":"+path] = string(content)
+	return "https://github.com/octo/homebrew-tap/commit/1", nil
+}
+
+func (f *fakeTap) CreateBranch(_ context.Context, name, from string) error {
+	f.calls = append(f.calls, "branch "+name+" "+from)
+	return nil
+}
+
+func (f *fakeTap) PullRequest(_ context.Context, head, base, title, body string) (string, error) {
+	f.calls = append(f.calls, "pull "+head+" "+base+" "+title)
+	return "https://github.com/octo/homebrew-tap/pull/7", nil
+}
+
+func testRelease(t *testing.T) publish.Release {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app_1.2.0_darwin_arm64")
+	if err := os.WriteFile(path, []byte("binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return publish.Release{Tag: "v1.2.0", Version: "1.2.0", Assets: []string{path}}
+}
+
+func TestPublish(t *testing.T) {
+	tap := &fakeTap{}
+	p := &Publisher{
+		Tap:     tap,
+		Formula: Formula{Name: "app", Desc: "An app", Homepage: "https://example.com"},
+		URL:     "https://github.com/octo/app/releases/download/{{ .Tag }}/{{ .Name }}",
+	}
+	result, err := p.Publish(context.Background(), testRelease(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"put main:Formula/app.rb app 1.2.0 "}; !slices.Equal(tap.calls, want) {
+		t.Errorf("Expected calls %q, got %q", want, tap.calls)
+	}
+	if result.ID != "Formula/app.rb" || result.URL != "https://github.com/octo/homebrew-tap/commit/1" {
+		t.Errorf("Expected the formula and its commit, got %+v", result)
+	}
+	formula := tap.files["main:Formula/app.rb"]
+	for _, want := range []string{
+		`version "1.2.0"`,
+		`url "https://github.com/octo/app/releases/download/v1.2.0/app_1.2.0_darwin_arm64"`,
+		`bin.install "app_1.2.0_darwin_arm64" => "app"`,
+	} {
+		if !strings.Contains(formula, want) {
+			t.Errorf("Expected %q in:\n%s", want, formula)
+		}
+	}
+
+	// Publishing again leaves the unchanged formula alone.
+	tap.calls = nil
+	result, err = p.Publish(context.Background(), testRelease(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tap.calls) != 0 || result.URL != "" {
+		t.Errorf("Expected no commit, got %q and %+v", tap.calls, result)
+	}
+}
+
+func TestPublishPullRequest(t *testing.T) {
+	tap := &fakeTap{files: map[string]string{"app-1.2.0:Formula/app.rb": "old"}}
+	p := &Publisher{
+		Tap:         tap,
+		Formula:     Formula{Name: "app"},
+		URL:         "https://x/{{ .Name }}",
+		Branch:      "master",
+		PullRequest: true,
+	}
+	result, err := p.Publish(context.Background(), testRelease(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"branch app-1.2.0 master",
+		"put app-1.2.0:Formula/app.rb app 1.2.0 sha-app-1.2.0",
+		"pull app-1.2.0 master app 1.2.0",
+	}
+	if !slices.Equal(tap.calls, want) {
+		t.Errorf("Expected calls %q, got %q", want, tap.calls)
+	}
+	if result.URL != "https://github.com/octo/homebrew-tap/pull/7" {
+		t.Errorf("Expected the pull request URL, got %q", result.URL)
+	}
+}
+
+func TestPublishNoAssets(t *testing.T) {
+	p := &Publisher{Tap: &fakeTap{}, Formula: Formula{Name: "app"}, URL: "https://x/{{ .Name }}"}
+	_, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.0", Assets: []string{"dist/SHA256SUMS"}})
+	if !errors.Is(err, ErrNoAssets) {
+		t.Errorf("Expected ErrNoAssets, got %v", err)
+	}
+}
+
+func TestPublishDryRun(t *testing.T) {
+	tap := &fakeTap{}
+	var log bytes.Buffer
+	p := &Publisher{Tap: tap, Formula: Formula{Name: "app"}, URL: "https://x/{{ .Name }}", PullRequest: true, DryRun: true, Log: &log}
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.0", Assets: []string{"dist/missing_darwin_arm64"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "would open a pull request for Formula/app.rb (app 1.2.0) in the tap"; !strings.Contains(log.String(), want) {
+		t.Errorf("Expected %q in %q", want, log.String())
+	}
+	if len(tap.calls) != 0 {
+		t.Errorf("Expected no calls, got %q", tap.calls)
+	}
+}
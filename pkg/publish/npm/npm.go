@@ -0,0 +1,276 @@
+// Package npm publishes the npm package of a release with "npm publish".
+//
+// The version of package.json is set to the release version for the
+// duration of the publish and restored afterwards, so that a wrapper
+// package ships the version computed for the rest of the repository without
+// a version bump commit of its own.
+//
+// The registry token comes from NPM_TOKEN, which the publisher hands to npm
+// through a temporary npm configuration; without it npm authenticates with
+// its own configuration (~/.npmrc).
+package npm
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/bumpfiles"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+const (
+	// TokenEnv is the environment variable read by NewFromEnv for an npm
+	// access token.
+	TokenEnv = "NPM_TOKEN"
+
+	// DefaultRegistry is the public npm registry.
+	DefaultRegistry = "https://registry.npmjs.org/"
+)
+
+// Publisher publishes the package in a directory for each release.
+type Publisher struct {
+	// Dir is the directory of package.json (default ".").
+	Dir string
+	// Name, when set, is the name package.json must have, so that a
+	// misplaced Dir does not publish another package.
+	Name string
+	// Registry is the registry published to (default DefaultRegistry).
+	Registry string
+	// Token authenticates with Registry; empty uses npm's configuration.
+	Token string
+	// Tag is the dist-tag of the release. Empty means "latest", or the
+	// channel of a prerelease, e.g. "rc" for 1.2.0-rc.1, which keeps
+	// prereleases from becoming what "npm install" picks.
+	Tag string
+	// Access is "public" or "restricted"; empty keeps npm's default, or
+	// publishConfig.access.
+	Access string
+	// Provenance publishes a provenance statement linking the package to
+	// the CI run; on GitHub Actions the job needs "id-token: write".
+	Provenance bool
+
+	// Retry governs retries of publishes failing with a network error. The
+	// zero value publishes once.
+	Retry retry.Policy
+	// Stderr receives npm's output; nil discards it.
+	Stderr io.Writer
+	// DryRun describes the publish on Log instead of running it.
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every npm command; nil discards.
+	Logger *slog.Logger
+}
+
+var _ publish.Publisher = (*Publisher)(nil)
+
+// NewFromEnv returns a Publisher for the package name in dir authenticated
+// with the token in TokenEnv, when it is set. An empty name publishes
+// whichever package dir holds.
+func NewFromEnv(name, dir string) *Publisher {
+	return NewFromSecrets(name, dir, os.Getenv)
+}
+
+// NewFromSecrets is NewFromEnv with the token looked up by secret.
+func NewFromSecrets(name, dir string, secret func(name string) string) *Publisher {
+	return &Publisher{Name: name, Dir: dir, Token: secret(TokenEnv)}
+}
+
+// manifest is the part of package.json the publisher reads.
+type manifest struct {
+	Name    string `json:"name"`
+	Private bool   `json:"private"`
+}
+
+// Publish publishes the package at the version of r. A version already on
+// the registry is reported as published, so that a failed release can be
+// published again. The release's assets are ignored.
+func (p *Publisher) Publish(ctx context.Context, r publish.Release) (_ publish.Result, err error) {
+	ver := cmp.Or(r.Version, strings.TrimPrefix(r.Tag, "v"))
+	path := filepath.Join(cmp.Or(p.Dir, "."), "package.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return publish.Result{}, fmt.Errorf("npm: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return publish.Result{}, fmt.Errorf("npm: %s: %w", path, err)
+	}
+	switch {
+	case m.Name == "":
+		return publish.Result{}, fmt.Errorf("npm: %s has no name", path)
+	case p.Name != "" && m.Name != p.Name:
+		return publish.Result{}, fmt.Errorf("npm: %s is package %s, not %s", path, m.Name, p.Name)
+	case m.Private:
+		return publish.Result{}, fmt.Errorf("npm: %s is private; npm refuses to publish it", path)
+	}
+	id := m.Name + "@" + ver
+	result := publish.Result{ID: id, URL: p.packageURL(m.Name, ver)}
+	args := p.args(ver)
+	if p.DryRun {
+		dryrun.Printf(p.Log, "would set the version of %s to %s", path, ver)
+		dryrun.Printf(p.Log, "would run npm %s in %s", strings.Join(args, " "), cmp.Or(p.Dir, "."))
+		return publish.Result{}, nil
+	}
+
+	edited, _, err := bumpfiles.Edit(bumpfiles.File{Path: "package.json", Key: "version"}, data, ver)
+	if err != nil {
+		return publish.Result{}, fmt.Errorf("npm: %w", err)
+	}
+	if err := os.WriteFile(path, edited, 0o644); err != nil {
+		return publish.Result{}, fmt.Errorf("npm: %w", err)
+	}
+	defer func() {
+		if werr := os.WriteFile(path, data, 0o644); werr != nil {
+			err = errors.Join(err, fmt.Errorf("npm: restore %s: %w", path, werr))
+		}
+	}()
+
+	var env []string
+	if p.Token != "" {
+		config, err := os.MkdirTemp("", "release-npm-")
+		if err != nil {
+			return publish.Result{}, fmt.Errorf("npm: %w", err)
+		}
+		defer os.RemoveAll(config)
+		rc := filepath.Join(config, ".npmrc")
+		// npm expands ${NPM_TOKEN} itself, so the token is never written.
+		if err := os.WriteFile(rc, []byte(authKey(p.registry())+"=${"+TokenEnv+"}\n"), 0o600); err != nil {
+			return publish.Result{}, fmt.Errorf("npm: %w", err)
+		}
+		args = append(args, "--userconfig", rc)
+		env = append(os.Environ(), TokenEnv+"="+p.Token)
+	}
+
+	err = p.Retry.Do(ctx, func(ctx context.Context) error {
+		err := p.run(ctx, env, args...)
+		if err != nil && transient(err) {
+			return retry.Retryable(err)
+		}
+		return err
+	})
+	if err != nil && published(err) {
+		log.Or(p.Logger).Warn(fmt.Sprintf("npm: %s is already published; skipped", id))
+		return result, nil
+	}
+	if err != nil {
+		return publish.Result{}, err
+	}
+	return result, nil
+}
+
+// args returns the arguments of "npm publish" for version ver.
+func (p *Publisher) args(ver string) []string {
+	args := []string{"publish", "--tag", p.distTag(ver), "--registry", p.registry()}
+	if p.Access != "" {
+		args = append(args, "--access", p.Access)
+	}
+	if p.Provenance {
+		args = append(args, "--provenance")
+	}
+	return args
+}
+
+// distTag returns the dist-tag of version ver; see Tag.
+func (p *Publisher) distTag(ver string) string {
+	if p.Tag != "" {
+		return p.Tag
+	}
+	v, err := version.Parse(ver)
+	if err != nil || len(v.Prerelease) == 0 {
+		return "latest"
+	}
+	// A dist-tag must not parse as a version range, as "0" would.
+	if channel := v.Prerelease[0]; strings.Trim(channel, "0123456789") != "" {
+		return channel
+	}
+	return "next"
+}
+
+func (p *Publisher) registry() string {
+	return strings.TrimRight(cmp.Or(p.Registry, DefaultRegistry), "/") + "/"
+}
+
+// packageURL returns the web page of the package version on the public
+// registry, or "" for other registries, which have no common layout.
+func (p *Publisher) packageURL(name, ver string) string {
+	if p.registry() != DefaultRegistry {
+		return ""
+	}
+	return "https://www.npmjs.com/package/" + name + "/v/" + ver
+}
+
+// authKey returns the npm configuration key of the token for registry,
+// e.g. "//registry.npmjs.org/:_authToken".
+func authKey(registry string) string {
+	_, rest, ok := strings.Cut(registry, "://")
+	if !ok {
+		rest = registry
+	}
+	return "//" + rest + ":_authToken"
+}
+
+// published reports whether npm failed because the version is already on
+// the registry.
+func published(err error) bool {
+	return strings.Contains(err.Error(), "EPUBLISHCONFLICT") ||
+		strings.Contains(err.Error(), "cannot publish over the previously published versions")
+}
+
+// transientErrors are fragments of npm's messages for registry failures
+// that may succeed when tried again.
+var transientErrors = []string{
+	"ECONNRESET",
+	"ECONNREFUSED",
+	"ETIMEDOUT",
+	"EAI_AGAIN",
+	"socket hang up",
+	"429 Too Many Requests",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+}
+
+func transient(err error) bool {
+	for _, s := range transientErrors {
+		if strings.Contains(err.Error(), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs npm with args in the package directory, with env as its
+// environment when it is not nil.
+func (p *Publisher) run(ctx context.Context, env []string, args ...string) error {
+	log.Or(p.Logger).Debug("npm", "args", strings.Join(args, " "))
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "npm", args...)
+	cmd.Dir = cmp.Or(p.Dir, ".")
+	cmd.Env = env
+	cmd.Stderr = &stderr
+	if p.Stderr != nil {
+		cmd.Stdout = p.Stderr
+		cmd.Stderr = io.MultiWriter(&stderr, p.Stderr)
+	}
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("npm %s: %w", args[0], ctx.Err())
+		}
+		return fmt.Errorf("npm %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
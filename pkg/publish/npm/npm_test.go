@@ -0,0 +1,137 @@
+package npm
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// stubNPM puts an npm script on PATH that records its arguments, the
+// package.json it runs with, the token and the npm configuration it is
+// given. It fails with output when the file fail exists in its directory.
+func stubNPM(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\n" +
+		"if [ -f " + dir + "/fail ]; then cat " + dir + "/fail >&2; exit 1; fi\n" +
+		"echo \"$@\" >> " + calls + "\n" +
+		"cat package.json >> " + calls + "\n" +
+		"echo \"token=$NPM_TOKEN\" >> " + calls + "\n" +
+		"for a in \"$@\"; do if [ -n \"$rc\" ]; then cat \"$a\" >> " + calls + "; fi; rc=; [ \"$a\" = --userconfig ] && rc=1; done\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "npm"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return calls
+}
+
+func writePackage(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+const packageJSON = "{\n  \"name\": \"@octo/app\",\n  \"version\": \"0.0.0\"\n}\n"
+
+func TestPublish(t *testing.T) {
+	calls := stubNPM(t)
+	dir := writePackage(t, packageJSON)
+	p := &Publisher{Dir: dir, Token: "s3cret", Access: "public", Provenance: true}
+
+	res, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.0", Version: "1.2.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ID != "@octo/app@1.2.0" || res.URL != "https://www.npmjs.com/package/@octo/app/v/1.2.0" {
+		t.Errorf("Expected the package version, got %+v", res)
+	}
+	data, _ := os.ReadFile(calls)
+	got := string(data)
+	for _, want := range []string{
+		"publish --tag latest --registry https://registry.npmjs.org/ --access public --provenance --userconfig ",
+		"\"version\": \"1.2.0\"",
+		"token=s3cret",
+		"//registry.npmjs.org/:_authToken=${NPM_TOKEN}",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in %q", want, got)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "package.json")); string(content) != packageJSON {
+		t.Errorf("Expected package.json restored, got %q", content)
+	}
+}
+
+func TestPublishPrerelease(t *testing.T) {
+	calls := stubNPM(t)
+	p := &Publisher{Dir: writePackage(t, packageJSON), Registry: "https://npm.example.com"}
+	res, err := p.Publish(context.Background(), publish.Release{Tag: "v2.0.0-rc.1", Prerelease: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(calls)
+	if !strings.HasPrefix(string(data), "publish --tag rc --registry https://npm.example.com/\n") || !strings.Contains(string(data), "token=\n") {
+		t.Errorf("Expected the rc dist-tag without a token, got %q", data)
+	}
+	if res.ID != "@octo/app@2.0.0-rc.1" || res.URL != "" {
+		t.Errorf("Expected no URL on a private registry, got %+v", res)
+	}
+
+	for ver, tag := range map[string]string{"1.0.0-0": "next", "1.0.0-beta.2": "beta", "1.0.0": "latest"} {
+		if got := (&Publisher{}).distTag(ver); got != tag {
+			t.Errorf("Expected %s for %s, got %s", tag, ver, got)
+		}
+	}
+}
+
+func TestPublishFailures(t *testing.T) {
+	calls := stubNPM(t)
+	fail := filepath.Join(filepath.Dir(calls), "fail")
+	dir := writePackage(t, packageJSON)
+	os.WriteFile(fail, []byte("npm error code E403\nnpm error 403 You cannot publish over the previously published versions: 1.2.0."), 0o644)
+	res, err := (&Publisher{Dir: dir}).Publish(context.Background(), publish.Release{Tag: "v1.2.0"})
+	if err != nil || res.ID != "@octo/app@1.2.0" {
+		t.Errorf("Expected an already published version to be reported as published, got %+v and %v", res, err)
+	}
+
+	os.WriteFile(fail, []byte("npm error code E401\nnpm error Unable to authenticate"), 0o644)
+	if _, err := (&Publisher{Dir: dir}).Publish(context.Background(), publish.Release{Tag: "v1.2.0"}); err == nil || !strings.Contains(err.Error(), "Unable to authenticate") {
+		t.Errorf("Expected npm's error, got %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "package.json")); string(content) != packageJSON {
+		t.Errorf("Expected package.json restored after a failure, got %q", content)
+	}
+
+	private := writePackage(t, `{"name": "app", "private": true}`)
+	if _, err := (&Publisher{Dir: private}).Publish(context.Background(), publish.Release{Tag: "v1.2.0"}); err == nil || !strings.Contains(err.Error(), "is private") {
+		t.Errorf("Expected a private package to be refused, got %v", err)
+	}
+	if _, err := NewFromSecrets("@octo/cli", dir, func(string) string { return "" }).Publish(context.Background(), publish.Release{Tag: "v1.2.0"}); err == nil || !strings.Contains(err.Error(), "not @octo/cli") {
+		t.Errorf("Expected another package to be refused, got %v", err)
+	}
+}
+
+func TestPublishDryRun(t *testing.T) {
+	calls := stubNPM(t)
+	var log bytes.Buffer
+	dir := writePackage(t, packageJSON)
+	p := &Publisher{Dir: dir, DryRun: true, Log: &log}
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(calls); !os.IsNotExist(err) {
+		t.Errorf("Expected npm not to run, got %v", err)
+	}
+	if !strings.Contains(log.String(), "would run npm publish --tag latest --registry https://registry.npmjs.org/ in "+dir) {
+		t.Errorf("Expected the publish described, got %q", log.String())
+	}
+}
@@ -0,0 +1,159 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// Listed is a release as the listing of a repository's releases reports
+// it, drafts included.
+type Listed struct {
+	// ID identifies the release to DeleteRelease.
+	ID   string
+	Tag  string
+	Name string
+	// URL is the web page of the release.
+	URL        string
+	Draft      bool
+	Prerelease bool
+	// Created is when the release was created, or the zero time when the
+	// provider does not say.
+	Created time.Time
+	Assets  []RemoteAsset
+}
+
+// Lister is implemented by providers that can list and delete the releases
+// of a repository, as release prune does.
+type Lister interface {
+	// Releases returns every release of the repository, drafts included.
+	Releases(ctx context.Context) ([]Listed, error)
+	// DeleteRelease deletes the listed release r. Its tag is left alone.
+	DeleteRelease(ctx context.Context, r Listed) error
+}
+
+// Kinds of releases a Retention keeps apart.
+const (
+	KindDraft      = "draft"
+	KindPrerelease = "prerelease"
+	KindStable     = "stable"
+)
+
+// Retention says which releases to keep. Drafts, prereleases and, with
+// Stable, stable releases are each kept apart: the Keep newest of a kind
+// are kept, and so are the others younger than MaxAge.
+type Retention struct {
+	// Keep is the number of releases of each kind kept whatever their age.
+	Keep int
+	// MaxAge keeps the releases beyond Keep younger than it; 0 keeps none
+	// beyond Keep.
+	MaxAge time.Duration
+	// Stable prunes stable releases too; by default every one is kept.
+	Stable bool
+	// Superseded prunes the prereleases of versions released as stable,
+	// such as 1.2.0-rc.1 once 1.2.0 is out, before Keep and MaxAge are
+	// applied.
+	Superseded bool
+	// Version reads the version of a tag. Releases whose tag it cannot
+	// read, such as those of other modules, are kept. Nil reads none and
+	// tells prereleases by the provider's flag only.
+	Version func(tag string) (version.Version, bool)
+	// Exclude lists tags never pruned, such as the rolling snapshot
+	// release.
+	Exclude []string
+	// Now is the time ages are measured from.
+	Now time.Time
+}
+
+// Pruned is a release a Retention does not keep.
+type Pruned struct {
+	Listed
+	// Kind is KindDraft, KindPrerelease or KindStable.
+	Kind string
+	// Reason says why the release is not kept.
+	Reason string
+}
+
+// Prune returns the releases r does not keep, newest first. Releases are
+// ordered by creation, then by version for those created at the same
+// time or whose provider does not say when.
+func (r Retention) Prune(releases []Listed) []Pruned {
+	type listed struct {
+		Listed
+		kind    string
+		version version.Version
+		parsed  bool
+	}
+	var candidates []listed
+	stable := make(map[string]bool)
+	for _, l := range releases {
+		if slices.Contains(r.Exclude, l.Tag) {
+			continue
+		}
+		c := listed{Listed: l}
+		if r.Version != nil {
+			if c.version, c.parsed = r.Version(l.Tag); !c.parsed {
+				continue
+			}
+		}
+		switch {
+		case l.Draft:
+			c.kind = KindDraft
+		case l.Prerelease || c.parsed && c.version.IsPrerelease():
+			c.kind = KindPrerelease
+		default:
+			c.kind = KindStable
+			if c.parsed {
+				stable[c.version.Core().String()] = true
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	slices.SortStableFunc(candidates, func(a, b listed) int {
+		if c := b.Created.Compare(a.Created); c != 0 {
+			return c
+		}
+		if a.parsed && b.parsed {
+			return b.version.Compare(a.version)
+		}
+		return 0
+	})
+
+	var pruned []Pruned
+	kept := make(map[string]int)
+	for _, c := range candidates {
+		if c.kind == KindStable && !r.Stable {
+			continue
+		}
+		if r.Superseded && c.kind == KindPrerelease && c.parsed && stable[c.version.Core().String()] {
+			pruned = append(pruned, Pruned{Listed: c.Listed, Kind: c.kind, Reason: "superseded by " + c.version.Core().String()})
+			continue
+		}
+		if kept[c.kind] < r.Keep {
+			kept[c.kind]++
+			continue
+		}
+		if r.MaxAge > 0 && (c.Created.IsZero() || r.Now.Sub(c.Created) < r.MaxAge) {
+			continue
+		}
+		reason := fmt.Sprintf("beyond the %d newest %s release(s)", r.Keep, c.kind)
+		if r.MaxAge > 0 {
+			reason += " and older than " + age(r.MaxAge)
+		}
+		pruned = append(pruned, Pruned{Listed: c.Listed, Kind: c.kind, Reason: reason})
+	}
+	return pruned
+}
+
+// age formats d in whole days when it is a number of days, such as "30d",
+// and as time.Duration does otherwise.
+func age(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
@@ -0,0 +1,80 @@
+package publish
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+func TestRetentionPrune(t *testing.T) {
+	now := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+	releases := []Listed{
+		{Tag: "nightly", Prerelease: true, Created: day(40)},
+		{Tag: "v1.3.0-rc.2", Prerelease: true, Created: day(1)},
+		{Tag: "v1.3.0-rc.1", Prerelease: true, Created: day(3)},
+		{Tag: "v1.2.0", Created: day(10)},
+		{Tag: "v1.2.0-rc.2", Created: day(12)},
+		{Tag: "v1.2.0-rc.1", Prerelease: true, Created: day(45)},
+		{Tag: "v1.1.0", Created: day(60)},
+		{Tag: "v1.4.0", Draft: true, Created: day(2)},
+		{Tag: "v1.3.9", Draft: true, Created: day(50)},
+		{Tag: "tools/v0.1.0-rc.1", Prerelease: true, Created: day(90)},
+	}
+	r := Retention{
+		Keep:    1,
+		Exclude: []string{"nightly"},
+		Now:     now,
+		Version: func(tag string) (version.Version, bool) {
+			v, err := version.Parse(strings.TrimPrefix(tag, "v"))
+			return v, err == nil && strings.HasPrefix(tag, "v")
+		},
+	}
+	tags := func(pruned []Pruned) []string {
+		var tags []string
+		for _, p := range pruned {
+			tags = append(tags, p.Kind+" "+p.Tag)
+		}
+		return tags
+	}
+
+	// The newest of each kind is kept, the stable releases all; a tag
+	// without the prerelease flag is told a prerelease by its version.
+	expected := []string{"prerelease v1.3.0-rc.1", "prerelease v1.2.0-rc.2", "prerelease v1.2.0-rc.1", "draft v1.3.9"}
+	if got := tags(r.Prune(releases)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// MaxAge keeps the younger ones beyond Keep.
+	r.MaxAge = 30 * 24 * time.Hour
+	pruned := r.Prune(releases)
+	expected = []string{"prerelease v1.2.0-rc.1", "draft v1.3.9"}
+	if got := tags(pruned); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if expected := "beyond the 1 newest prerelease release(s) and older than 30d"; pruned[0].Reason != expected {
+		t.Errorf("Expected %q, got %q", expected, pruned[0].Reason)
+	}
+
+	// Superseded prereleases go whatever their age, and do not count
+	// towards Keep; Stable prunes stable releases too.
+	r.Superseded, r.Stable = true, true
+	pruned = r.Prune(releases)
+	expected = []string{"prerelease v1.2.0-rc.2", "prerelease v1.2.0-rc.1", "draft v1.3.9", "stable v1.1.0"}
+	if got := tags(pruned); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if pruned[0].Reason != "superseded by 1.2.0" {
+		t.Errorf("Expected the superseding release in the reason, got %q", pruned[0].Reason)
+	}
+
+	// Without Version every release counts, by the provider's flags.
+	r = Retention{Keep: 2, Now: now}
+	expected = []string{"prerelease nightly", "prerelease v1.2.0-rc.1", "prerelease tools/v0.1.0-rc.1"}
+	if got := tags(r.Prune(releases)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
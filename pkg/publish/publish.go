@@ -0,0 +1,70 @@
+// Package publish defines the provider-agnostic release publishing API.
+// Provider implementations live in sub-packages (publish/github,
+// publish/gitlab, publish/docker, publish/homebrew) and all satisfy
+// Publisher.
+//
+// Publishing is idempotent: when a release for the tag already exists, for
+// instance because an earlier run failed halfway through its uploads,
+// publishers reuse it and only upload the assets it is missing.
+package publish
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+)
+
+// Release describes a release to create on a provider.
+type Release struct {
+	Tag string
+	// Version is Tag without its prefix, e.g. "1.2.3" for "v1.2.3".
+	Version    string
+	Name       string
+	Body       string
+	Draft      bool
+	Prerelease bool
+	// Assets are paths of files to attach to the release.
+	Assets []string
+	// Milestones are associated with the release on providers that support
+	// them (GitLab); other providers ignore them.
+	Milestones []string
+}
+
+// Title returns r.Name, or "Release <tag>" when no name is set.
+func (r Release) Title() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return "Release " + r.Tag
+}
+
+// Result describes a published release.
+type Result struct {
+	// ID is the provider's identifier for the release.
+	ID string
+	// URL is the web page of the release.
+	URL string
+	// Existing is true when the release already existed and was resumed
+	// rather than created.
+	Existing bool
+	// Uploaded lists the assets uploaded by this call. On a resumed release
+	// it excludes the assets that were already attached.
+	Uploaded []string
+}
+
+// Publisher creates releases on a hosting provider.
+type Publisher interface {
+	Publish(ctx context.Context, r Release) (Result, error)
+}
+
+// MissingAssets returns the assets whose file names are not among attached,
+// the names of the files already on a release, keeping their order.
+func MissingAssets(assets, attached []string) []string {
+	var missing []string
+	for _, a := range assets {
+		if !slices.Contains(attached, filepath.Base(a)) {
+			missing = append(missing, a)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,29 @@
+package publish
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissingAssets(t *testing.T) {
+	assets := []string{"dist/app_linux", "dist/app_darwin", "SHA256SUMS"}
+
+	got := MissingAssets(assets, []string{"app_linux", "other"})
+	expected := []string{"dist/app_darwin", "SHA256SUMS"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if got := MissingAssets(assets, []string{"app_linux", "app_darwin", "SHA256SUMS"}); got != nil {
+		t.Errorf("Expected no missing assets, got %v", got)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	if got := (Release{Tag: "v1.0.0"}).Title(); got != "Release v1.0.0" {
+		t.Errorf("Expected %q, got %q", "Release v1.0.0", got)
+	}
+	if got := (Release{Tag: "v1.0.0", Name: "One"}).Title(); got != "One" {
+		t.Errorf("Expected %q, got %q", "One", got)
+	}
+}
@@ -0,0 +1,465 @@
+// Package pypi uploads the Python distributions of a release to PyPI, or to
+// another index implementing its upload API, as "twine upload" does
+// (https://docs.pypi.org/api/upload/).
+//
+// The wheels and source distributions are picked from a directory by the
+// release version in its PEP 440 form, 1.2.0rc1 for 1.2.0-rc.1, so that a
+// build stamped with another version fails the release instead of being
+// uploaded. Each is sent with the core metadata it carries, as twine sends
+// it.
+//
+// Credentials are those of twine, TWINE_USERNAME and TWINE_PASSWORD, or an
+// API token in PYPI_TOKEN, sent with the username __token__.
+package pypi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+const (
+	// DefaultURL is the upload endpoint of PyPI.
+	DefaultURL = "https://upload.pypi.org/legacy/"
+
+	// TokenEnv is the environment variable read by NewFromEnv for a PyPI
+	// API token.
+	TokenEnv = "PYPI_TOKEN"
+	// UsernameEnv and PasswordEnv are twine's credentials, used when
+	// TokenEnv is not set.
+	UsernameEnv = "TWINE_USERNAME"
+	PasswordEnv = "TWINE_PASSWORD"
+
+	// TokenUsername is the username of API tokens.
+	TokenUsername = "__token__"
+)
+
+// ErrNoToken is returned by NewFromEnv when no credentials are configured.
+var ErrNoToken = errors.New("pypi: no token in " + TokenEnv + " or " + PasswordEnv)
+
+// ErrNoDistributions is returned by Publish when the directory holds no
+// distribution of the release version.
+var ErrNoDistributions = errors.New("pypi: no distributions")
+
+// Publisher uploads the distributions of a project.
+type Publisher struct {
+	// Project, when set, is the project whose distributions are uploaded;
+	// those of other projects in Dir are ignored.
+	Project string
+	// Dir holds the distributions (default "dist").
+	Dir string
+	// URL is the upload endpoint (default DefaultURL), e.g.
+	// "https://test.pypi.org/legacy/".
+	URL      string
+	Username string
+	Password string
+
+	HTTPClient *http.Client
+	// Retry governs retries of uploads failing with 429, 5xx or a network
+	// error. The zero value sends each upload once.
+	Retry retry.Policy
+	// Uploads sets how many distributions are uploaded at once and
+	// reports their progress.
+	Uploads publish.Uploader
+
+	// DryRun describes the uploads on Log instead of sending them.
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every upload; nil discards.
+	Logger *slog.Logger
+}
+
+var _ publish.Publisher = (*Publisher)(nil)
+
+// New returns a Publisher for the distributions of project in dir
+// authenticated with username and password.
+func New(project, dir, username, password string) *Publisher {
+	return &Publisher{Project: project, Dir: dir, Username: username, Password: password}
+}
+
+// NewFromEnv returns a Publisher for the distributions of project in dir
+// authenticated with the token in TokenEnv, or the credentials in
+// UsernameEnv and PasswordEnv.
+func NewFromEnv(project, dir string) (*Publisher, error) {
+	return NewFromSecrets(project, dir, os.Getenv)
+}
+
+// NewFromSecrets is NewFromEnv with the credentials looked up by secret.
+func NewFromSecrets(project, dir string, secret func(name string) string) (*Publisher, error) {
+	if token := secret(TokenEnv); token != "" {
+		return New(project, dir, TokenUsername, token), nil
+	}
+	if password := secret(PasswordEnv); password != "" {
+		return New(project, dir, cmp.Or(secret(UsernameEnv), TokenUsername), password), nil
+	}
+	return nil, ErrNoToken
+}
+
+// Publish uploads the distributions of the version of r. A file already on
+// the index is reported as uploaded, so that a failed release can be
+// published again. The release's assets are ignored.
+func (p *Publisher) Publish(ctx context.Context, r publish.Release) (publish.Result, error) {
+	ver, err := Version(cmp.Or(r.Version, strings.TrimPrefix(r.Tag, "v")))
+	if err != nil {
+		return publish.Result{}, err
+	}
+	dists, err := Distributions(cmp.Or(p.Dir, "dist"), p.Project, ver)
+	if err != nil {
+		return publish.Result{}, err
+	}
+	project := cmp.Or(p.Project, dists[0].Project)
+	result := publish.Result{ID: project + "==" + ver, URL: p.projectURL(project, ver)}
+	if p.DryRun {
+		for _, d := range dists {
+			dryrun.Printf(p.Log, "would upload %s to %s", d.Path, p.url())
+		}
+		return publish.Result{}, nil
+	}
+
+	paths := make([]string, len(dists))
+	for i, d := range dists {
+		paths[i] = d.Path
+	}
+	result.Uploaded, err = p.Uploads.Upload(ctx, paths, func(ctx context.Context, i int) error {
+		if err := p.upload(ctx, dists[i]); err != nil {
+			return fmt.Errorf("pypi: upload %s: %w", filepath.Base(dists[i].Path), err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (p *Publisher) url() string {
+	return cmp.Or(p.URL, DefaultURL)
+}
+
+// projectURL returns the page of the release on PyPI, or "" for other
+// indexes, which have no common layout.
+func (p *Publisher) projectURL(project, ver string) string {
+	if p.url() != DefaultURL {
+		return ""
+	}
+	return "https://pypi.org/project/" + project + "/" + ver + "/"
+}
+
+// Distribution is a wheel or source distribution to upload.
+type Distribution struct {
+	Path    string
+	Project string
+	Version string
+	// Type is "bdist_wheel" or "sdist", and PyVersion the Python tag of a
+	// wheel, e.g. "py3", or "source".
+	Type      string
+	PyVersion string
+}
+
+// Distributions returns the distributions in dir of version ver, a PEP
+// 440 version, and of project when it is not empty. Project names compare
+// as PEP 503 normalizes them: "My.App" is "my-app".
+func Distributions(dir, project, ver string) ([]Distribution, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("pypi: %w", err)
+	}
+	var dists []Distribution
+	for _, e := range entries {
+		d, ok := parseFilename(e.Name())
+		if !ok || e.IsDir() || !strings.EqualFold(d.Version, ver) {
+			continue
+		}
+		if project != "" && normalize(d.Project) != normalize(project) {
+			continue
+		}
+		d.Path = filepath.Join(dir, e.Name())
+		dists = append(dists, d)
+	}
+	if len(dists) == 0 {
+		return nil, fmt.Errorf("%w of %s in %s", ErrNoDistributions, strings.TrimSpace(project+" "+ver), dir)
+	}
+	return dists, nil
+}
+
+// parseFilename parses the name of a wheel,
+// {name}-{version}(-{build})?-{python}-{abi}-{platform}.whl, or of a
+// source distribution, {name}-{version}.tar.gz.
+func parseFilename(name string) (Distribution, bool) {
+	if base, ok := strings.CutSuffix(name, ".whl"); ok {
+		parts := strings.Split(base, "-")
+		if len(parts) != 5 && len(parts) != 6 {
+			return Distribution{}, false
+		}
+		return Distribution{Project: parts[0], Version: parts[1], Type: "bdist_wheel", PyVersion: parts[len(parts)-3]}, true
+	}
+	if base, ok := strings.CutSuffix(name, ".tar.gz"); ok {
+		i := strings.LastIndexByte(base, '-')
+		if i <= 0 {
+			return Distribution{}, false
+		}
+		return Distribution{Project: base[:i], Version: base[i+1:], Type: "sdist", PyVersion: "source"}, true
+	}
+	return Distribution{}, false
+}
+
+var separators = regexp.MustCompile(`[-_.]+`)
+
+// normalize returns the PEP 503 form of a project name.
+func normalize(name string) string {
+	return strings.ToLower(separators.ReplaceAllString(name, "-"))
+}
+
+// preReleases maps the prerelease channels of semantic versions to the
+// PEP 440 release segments they read as.
+var preReleases = map[string]string{
+	"alpha": "a", "a": "a",
+	"beta": "b", "b": "b",
+	"rc": "rc", "c": "rc", "pre": "rc", "preview": "rc",
+	"dev":  ".dev",
+	"post": ".post",
+}
+
+// Version returns the PEP 440 form of the semantic version v: 1.2.0-rc.1
+// is 1.2.0rc1, 1.2.0-beta is 1.2.0b0, and build metadata becomes a local
+// version label. Prereleases of other channels have no PEP 440 form.
+func Version(v string) (string, error) {
+	sv, err := version.Parse(v)
+	if err != nil {
+		return "", err
+	}
+	s := sv.Core().String()
+	ids := sv.Prerelease
+	for len(ids) > 0 {
+		label := strings.TrimRight(ids[0], "0123456789")
+		segment, ok := preReleases[strings.ToLower(label)]
+		if !ok || label == "" {
+			return "", fmt.Errorf("pypi: prerelease %s of %s has no PEP 440 form; use an alpha, beta, rc or dev channel", strings.Join(sv.Prerelease, "."), v)
+		}
+		n := strings.TrimPrefix(ids[0], label)
+		ids = ids[1:]
+		if n == "" && len(ids) > 0 && strings.Trim(ids[0], "0123456789") == "" {
+			n, ids = ids[0], ids[1:]
+		}
+		s += segment + cmp.Or(strings.TrimLeft(n, "0"), "0")
+	}
+	if len(sv.Build) > 0 {
+		s += "+" + strings.Join(sv.Build, ".")
+	}
+	return s, nil
+}
+
+// upload sends d with its metadata, as a multipart form whose fields come
+// before the file.
+func (p *Publisher) upload(ctx context.Context, d Distribution) error {
+	fields, err := formFields(d)
+	if err != nil {
+		return err
+	}
+	a, err := publish.OpenAsset(ctx, d.Path)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	body, contentType, err := a.Multipart("content", fields...)
+	if err != nil {
+		return err
+	}
+
+	return p.Retry.Do(ctx, func(ctx context.Context) error {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url(), body)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(p.Username, p.Password)
+		req.Header.Set("Content-Type", contentType)
+		req.ContentLength = body.Size()
+
+		client := p.HTTPClient
+		if client == nil {
+			client = http.DefaultClient
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		log.Or(p.Logger).Debug("pypi upload", "url", p.url(), "file", a.Name, "status", resp.StatusCode)
+		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return nil
+		}
+
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		// PyPI refuses files it has with 400, other indexes with 409.
+		if text := string(msg) + resp.Status; (resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusConflict) && strings.Contains(strings.ToLower(text), "already exist") {
+			log.Or(p.Logger).Warn(fmt.Sprintf("pypi: %s is already uploaded; skipped", a.Name))
+			return nil
+		}
+		err = fmt.Errorf("POST %s: %s: %s", p.url(), resp.Status, strings.TrimSpace(string(msg)))
+		if retry.HTTPStatus(resp.StatusCode) {
+			return retry.After(err, retry.RetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+		}
+		return err
+	})
+}
+
+// formFields returns the fields of the upload of d: the action, its core
+// metadata named as twine names them, and its digests.
+func formFields(d Distribution) ([]publish.FormField, error) {
+	meta, description, err := readMetadata(d)
+	if err != nil {
+		return nil, err
+	}
+	fields := []publish.FormField{
+		{Name: ":action", Value: "file_upload"},
+		{Name: "protocol_version", Value: "1"},
+		{Name: "filetype", Value: d.Type},
+		{Name: "pyversion", Value: d.PyVersion},
+	}
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		name := strings.ReplaceAll(strings.ToLower(k), "-", "_")
+		name = cmp.Or(pluralFields[name], name)
+		for _, v := range meta[k] {
+			fields = append(fields, publish.FormField{Name: name, Value: v})
+		}
+	}
+	if description != "" {
+		fields = append(fields, publish.FormField{Name: "description", Value: description})
+	}
+
+	md5sum, sha256sum, err := digests(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	return append(fields,
+		publish.FormField{Name: "md5_digest", Value: md5sum},
+		publish.FormField{Name: "sha256_digest", Value: sha256sum},
+	), nil
+}
+
+// pluralFields are the form fields of metadata fields that repeat.
+var pluralFields = map[string]string{
+	"classifier":     "classifiers",
+	"project_url":    "project_urls",
+	"provides_extra": "provides_extras",
+}
+
+func digests(path string) (md5sum, sha256sum string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+	m, s := md5.New(), sha256.New()
+	if _, err := io.Copy(io.MultiWriter(m, s), f); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(m.Sum(nil)), hex.EncodeToString(s.Sum(nil)), nil
+}
+
+// readMetadata returns the core metadata of d, from the METADATA file of a
+// wheel or the PKG-INFO file of a source distribution, and the description
+// in its body.
+func readMetadata(d Distribution) (textproto.MIMEHeader, string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if d.Type == "bdist_wheel" {
+		data, err = wheelMetadata(d.Path)
+	} else {
+		data, err = sdistMetadata(d.Path)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", filepath.Base(d.Path), err)
+	}
+	r := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+	meta, err := r.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", fmt.Errorf("%s: metadata: %w", filepath.Base(d.Path), err)
+	}
+	if meta.Get("Name") == "" || meta.Get("Version") == "" {
+		return nil, "", fmt.Errorf("%s: metadata has no name or version", filepath.Base(d.Path))
+	}
+	body, _ := io.ReadAll(r.R)
+	return meta, strings.TrimSpace(string(body)), nil
+}
+
+// wheelMetadata reads the METADATA of the .dist-info directory at the top
+// of the wheel at path.
+func wheelMetadata(file string) ([]byte, error) {
+	z, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	for _, f := range z.File {
+		if dir, name := path.Split(f.Name); name == "METADATA" && strings.HasSuffix(dir, ".dist-info/") && strings.Count(dir, "/") == 1 {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+	}
+	return nil, errors.New("no .dist-info/METADATA")
+}
+
+// sdistMetadata reads the PKG-INFO at the top of the source distribution
+// at path.
+func sdistMetadata(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("no PKG-INFO")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if dir, name := path.Split(path.Clean(h.Name)); name == "PKG-INFO" && strings.Count(dir, "/") == 1 {
+			return io.ReadAll(tr)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package pypi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+)
+
+const metadata = "Metadata-Version: 2.1\nName: my-app\nVersion: 1.2.0rc1\nSummary: An app\nClassifier: A\nClassifier: B\n\n# My app\n"
+
+// writeDists writes a wheel and a source distribution of my-app 1.2.0rc1
+// and an older wheel to a new directory.
+func writeDists(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	var whl bytes.Buffer
+	zw := zip.NewWriter(&whl)
+	w, _ := zw.Create("my_app-1.2.0rc1.dist-info/METADATA")
+	io.WriteString(w, metadata)
+	zw.Close()
+	os.WriteFile(filepath.Join(dir, "my_app-1.2.0rc1-py3-none-any.whl"), whl.Bytes(), 0o644)
+	os.WriteFile(filepath.Join(dir, "my_app-1.1.0-py3-none-any.whl"), whl.Bytes(), 0o644)
+
+	var sdist bytes.Buffer
+	gz := gzip.NewWriter(&sdist)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "my_app-1.2.0rc1/PKG-INFO", Mode: 0o644, Size: int64(len(metadata))})
+	io.WriteString(tw, metadata)
+	tw.Close()
+	gz.Close()
+	os.WriteFile(filepath.Join(dir, "my_app-1.2.0rc1.tar.gz"), sdist.Bytes(), 0o644)
+	return dir
+}
+
+type upload struct {
+	user, password string
+	fields         map[string][]string
+	file           string
+}
+
+func uploadServer(t *testing.T, handle func(w http.ResponseWriter, u upload) bool) (*httptest.Server, func() []upload) {
+	var (
+		mu      sync.Mutex
+		uploads []upload
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		u := upload{user: user, password: password, fields: r.MultipartForm.Value}
+		if fh := r.MultipartForm.File["content"]; len(fh) == 1 {
+			u.file = fh[0].Filename
+		}
+		if handle != nil && !handle(w, u) {
+			return
+		}
+		mu.Lock()
+		uploads = append(uploads, u)
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []upload {
+		mu.Lock()
+		defer mu.Unlock()
+		return uploads
+	}
+}
+
+func TestPublish(t *testing.T) {
+	srv, uploads := uploadServer(t, nil)
+	p, err := NewFromSecrets("My.App", writeDists(t), func(name string) string {
+		return map[string]string{TokenEnv: "pypi-s3cret"}[name]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.URL = srv.URL
+
+	res, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.0-rc.1", Version: "1.2.0-rc.1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ID != "My.App==1.2.0rc1" || res.URL != "" || len(res.Uploaded) != 2 {
+		t.Errorf("Expected the two distributions of the release, got %+v", res)
+	}
+	got := uploads()
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 uploads, got %+v", got)
+	}
+	for _, u := range got {
+		if u.user != TokenUsername || u.password != "pypi-s3cret" {
+			t.Errorf("Expected the token, got %q %q", u.user, u.password)
+		}
+		f := u.fields
+		if f[":action"][0] != "file_upload" || f["name"][0] != "my-app" || f["version"][0] != "1.2.0rc1" || f["metadata_version"][0] != "2.1" {
+			t.Errorf("Expected the metadata, got %v", f)
+		}
+		if len(f["classifiers"]) != 2 || f["description"][0] != "# My app" || len(f["sha256_digest"][0]) != 64 {
+			t.Errorf("Expected the classifiers, description and digest, got %v", f)
+		}
+		switch u.file {
+		case "my_app-1.2.0rc1-py3-none-any.whl":
+			if f["filetype"][0] != "bdist_wheel" || f["pyversion"][0] != "py3" {
+				t.Errorf("Expected a wheel, got %v", f)
+			}
+		case "my_app-1.2.0rc1.tar.gz":
+			if f["filetype"][0] != "sdist" || f["pyversion"][0] != "source" {
+				t.Errorf("Expected a source distribution, got %v", f)
+			}
+		default:
+			t.Errorf("Expected a distribution of 1.2.0rc1, got %s", u.file)
+		}
+	}
+}
+
+func TestPublishRetriesAndSkipsExisting(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		tries = map[string]int{}
+	)
+	srv, _ := uploadServer(t, func(w http.ResponseWriter, u upload) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		tries[u.file]++
+		switch {
+		case strings.HasSuffix(u.file, ".whl") && tries[u.file] == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return false
+		case strings.HasSuffix(u.file, ".tar.gz"):
+			http.Error(w, "400 File already exists. See https://pypi.org/help/#file-name-reuse", http.StatusBadRequest)
+			return false
+		}
+		return true
+	})
+	p := New("", writeDists(t), "user", "pass")
+	p.URL, p.Retry = srv.URL, retry.Policy{MaxAttempts: 3}
+	res, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.0-rc.1"})
+	if err != nil || len(res.Uploaded) != 2 {
+		t.Errorf("Expected both distributions uploaded, got %+v and %v", res, err)
+	}
+	if tries["my_app-1.2.0rc1-py3-none-any.whl"] != 2 {
+		t.Errorf("Expected the wheel retried once, got %v", tries)
+	}
+}
+
+func TestPublishFailures(t *testing.T) {
+	srv, _ := uploadServer(t, func(w http.ResponseWriter, u upload) bool {
+		http.Error(w, "403 Invalid or non-existent authentication information.", http.StatusForbidden)
+		return false
+	})
+	dir := writeDists(t)
+	p := New("my-app", dir, TokenUsername, "bad")
+	p.URL = srv.URL
+	_, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.0-rc.1"})
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden") || !strings.Contains(err.Error(), "upload my_app-1.2.0rc1") {
+		t.Errorf("Expected the upload refused, got %v", err)
+	}
+
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.3.0"}); !errors.Is(err, ErrNoDistributions) {
+		t.Errorf("Expected ErrNoDistributions, got %v", err)
+	}
+	if _, err := New("other", dir, "", "").Publish(context.Background(), publish.Release{Tag: "v1.2.0-rc.1"}); !errors.Is(err, ErrNoDistributions) {
+		t.Errorf("Expected the distributions of another project ignored, got %v", err)
+	}
+	if _, err := NewFromSecrets("", dir, func(string) string { return "" }); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Expected ErrNoToken, got %v", err)
+	}
+}
+
+func TestPublishDryRun(t *testing.T) {
+	var log bytes.Buffer
+	dir := writeDists(t)
+	p := &Publisher{Dir: dir, DryRun: true, Log: &log}
+	if _, err := p.Publish(context.Background(), publish.Release{Tag: "v1.2.0-rc.1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "would upload " + filepath.Join(dir, "my_app-1.2.0rc1-py3-none-any.whl") + " to " + DefaultURL
+	if !strings.Contains(log.String(), want) || strings.Contains(log.String(), "1.1.0") {
+		t.Errorf("Expected the uploads of the version described, got %q", log.String())
+	}
+	if got := p.projectURL("my-app", "1.2.0rc1"); got != "https://pypi.org/project/my-app/1.2.0rc1/" {
+		t.Errorf("Expected the PyPI page, got %q", got)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	for in, want := range map[string]string{
+		"1.2.0":            "1.2.0",
+		"1.2.0-rc.1":       "1.2.0rc1",
+		"1.2.0-rc1":        "1.2.0rc1",
+		"1.2.0-alpha.2":    "1.2.0a2",
+		"1.2.0-beta":       "1.2.0b0",
+		"1.2.0-rc.1.dev.3": "1.2.0rc1.dev3",
+		"1.2.0+linux.1":    "1.2.0+linux.1",
+	} {
+		if got, err := Version(in); err != nil || got != want {
+			t.Errorf("Version(%q): expected %q, got %q and %v", in, want, got, err)
+		}
+	}
+	for _, in := range []string{"1.2.0-next.1", "1.2.0-1", "1.2"} {
+		if _, err := Version(in); err == nil {
+			t.Errorf("Version(%q): expected an error", in)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package publish
+
+import "context"
+
+// Retractor is implemented by providers that can take back the release of
+// a tag, as release rollback does.
+type Retractor interface {
+	// Retract deletes the release of tag or, with draft, turns it back into
+	// a draft. It reports false when tag has no release.
+	Retract(ctx context.Context, tag string, draft bool) (bool, error)
+}
@@ -0,0 +1,15 @@
+package publish
+
+import "context"
+
+// Reviewer is implemented by providers that tell who approved a pull
+// request, a merge request on GitLab, for release approve to require the
+// approval of given users.
+type Reviewer interface {
+	// CommitPullRequest returns the number of the merged pull request that
+	// brought commit. It reports false when there is none.
+	CommitPullRequest(ctx context.Context, commit string) (int, bool, error)
+	// Approvers returns the logins of the users approving pull request
+	// number, as of their latest reviews.
+	Approvers(ctx context.Context, number int) ([]string, error)
+}
@@ -0,0 +1,75 @@
+package publish
+
+import (
+	"context"
+	"regexp"
+	"slices"
+
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// Snapshotter is implemented by providers that keep a rolling snapshot
+// release, such as "nightly", which release snapshot overwrites with every
+// build instead of creating a release per version.
+type Snapshotter interface {
+	// Snapshot points the prerelease of r.Tag at commit, creating it or
+	// replacing the name and notes of the existing one, and uploads
+	// r.Assets in place of the assets of the same names. The assets of
+	// earlier builds are left to DeleteAssets.
+	Snapshot(ctx context.Context, r Release, commit string) (Result, error)
+	// DeleteAssets deletes the assets named names from the release of
+	// tag. Names the release has no asset of are ignored.
+	DeleteAssets(ctx context.Context, tag string, names []string) error
+}
+
+// StaleSnapshots returns the names of the assets of the snapshot builds on
+// channel older than the keep newest, for the rolling release holding them
+// to be pruned. The build an asset belongs to is told by the snapshot
+// version in its name, such as "app_1.4.0-dev.20240601.abc1234_linux.tar.gz"
+// (see version.Snapshot); assets naming none, such as a checksum file
+// replaced by every build, are kept. current, the build just published,
+// counts as the newest: builds of the same day cannot be told apart by
+// age. keep below 1 keeps every build.
+func StaleSnapshots(names []string, channel string, current version.Version, keep int) []string {
+	if keep < 1 {
+		return nil
+	}
+	pattern := regexp.MustCompile(`(\d+\.\d+\.\d+-` + regexp.QuoteMeta(channel) + `\.\d{8}\.(?:[0-9a-f]{7}|g\d{7}))(?:[^0-9A-Za-z]|$)`)
+	builds := make(map[string]version.Version)
+	of := make(map[string]string)
+	for _, name := range names {
+		m := pattern.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		v, err := version.Parse(m[1])
+		if err != nil {
+			continue
+		}
+		builds[v.String()], of[name] = v, v.String()
+	}
+	order := make([]version.Version, 0, len(builds))
+	for _, v := range builds {
+		order = append(order, v)
+	}
+	slices.SortFunc(order, func(a, b version.Version) int {
+		switch {
+		case a.Equal(current):
+			return -1
+		case b.Equal(current):
+			return 1
+		}
+		return b.Compare(a)
+	})
+	kept := make(map[string]bool)
+	for _, v := range order[:min(keep, len(order))] {
+		kept[v.String()] = true
+	}
+	var stale []string
+	for _, name := range names {
+		if b, ok := of[name]; ok && !kept[b] {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
@@ -0,0 +1,44 @@
+package publish
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+func TestStaleSnapshots(t *testing.T) {
+	names := []string{
+		"SHA256SUMS",
+		"app_1.4.0-dev.20240601.abc1234_linux_amd64.tar.gz",
+		"app_1.4.0-dev.20240601.abc1234_darwin_arm64.tar.gz",
+		"app_1.4.0-dev.20240529.g0123456_linux_amd64.tar.gz",
+		"app_1.3.1-dev.20240520.fff0000.tar.gz",
+		"app_1.4.0-dev.20240603.g0000000_linux_amd64.tar.gz",
+		"app_1.4.0-nightly.20240520.fff0000.tar.gz",
+		"app_1.4.0-dev.20240602.abc1234extra",
+	}
+	current := version.MustParse("1.4.0-dev.20240603.g0000000")
+
+	got := StaleSnapshots(names, "dev", current, 2)
+	expected := []string{
+		"app_1.4.0-dev.20240529.g0123456_linux_amd64.tar.gz",
+		"app_1.3.1-dev.20240520.fff0000.tar.gz",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// A build of the same day as current is older than it, whatever its
+	// commit.
+	current = version.MustParse("1.4.0-dev.20240601.g0000001")
+	got = StaleSnapshots([]string{names[1], names[2], "app_1.4.0-dev.20240601.g0000001.tar.gz"}, "dev", current, 1)
+	expected = names[1:3]
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if got := StaleSnapshots(names, "dev", current, 0); got != nil {
+		t.Errorf("Expected every build kept, got %v", got)
+	}
+}
@@ -0,0 +1,30 @@
+package publish
+
+import "context"
+
+// CIState is the combined outcome of the CI checks of a commit.
+type CIState string
+
+const (
+	CISuccess CIState = "success"
+	CIPending CIState = "pending"
+	CIFailure CIState = "failure"
+	// CINone means no checks have reported on the commit.
+	CINone CIState = "none"
+)
+
+// CIStatus is the CI status of a commit.
+type CIStatus struct {
+	State CIState
+	// Checks names the checks behind State: the failing ones for
+	// CIFailure, the unfinished ones for CIPending.
+	Checks []string
+	// URL is a web page showing the checks, when the provider has one.
+	URL string
+}
+
+// StatusChecker is implemented by providers that report the CI status of
+// commits.
+type StatusChecker interface {
+	CIStatus(ctx context.Context, ref string) (CIStatus, error)
+}
@@ -0,0 +1,130 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultConcurrency is the number of assets an Uploader uploads at once
+// when its Concurrency is not set.
+const DefaultConcurrency = 4
+
+// Progress reports an asset whose upload finished.
+type Progress struct {
+	Asset string
+	// Size is the size of the file in bytes, or -1 if it cannot be read.
+	Size    int64
+	Elapsed time.Duration
+	// Err is nil when the upload succeeded.
+	Err error
+	// Done counts the finished uploads, including this one, out of Total.
+	Done, Total int
+}
+
+// Transfer reports the bytes of an asset sent so far, while it uploads.
+type Transfer struct {
+	Asset      string
+	Sent, Size int64
+}
+
+// TransferInterval is how often at most an Uploader reports the transfer
+// of each asset.
+var TransferInterval = 5 * time.Second
+
+// Uploader uploads the assets of a release in parallel. The zero value
+// uploads DefaultConcurrency assets at a time and reports no progress.
+type Uploader struct {
+	// Concurrency bounds the uploads in flight; 1 uploads one at a time.
+	Concurrency int
+	// Progress is called as each upload finishes. Calls are serialised, so
+	// it may write to a shared io.Writer.
+	Progress func(Progress)
+	// Transfer is called every Interval while an asset opened with
+	// OpenAsset uploads, for large files. Calls are serialised with those
+	// of Progress.
+	Transfer func(Transfer)
+	// Interval spaces the calls of Transfer for each asset; 0 means
+	// TransferInterval.
+	Interval time.Duration
+}
+
+// transferKey is the context key of the callback OpenAsset reports the
+// bytes sent through.
+type transferKey struct{}
+
+// Upload calls upload(ctx, i) for each of assets, to upload assets[i].
+// Every asset is attempted even when others fail, so that resuming the
+// release has as little left to upload as possible. Upload returns the
+// assets uploaded, in their original order, and the failures joined.
+func (u Uploader) Upload(ctx context.Context, assets []string, upload func(ctx context.Context, i int) error) ([]string, error) {
+	limit := u.Concurrency
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+	interval := u.Interval
+	if interval <= 0 {
+		interval = TransferInterval
+	}
+
+	var (
+		g    errgroup.Group
+		mu   sync.Mutex
+		done int
+		errs = make([]error, len(assets))
+	)
+	g.SetLimit(limit)
+	for i, a := range assets {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return nil
+			}
+			start := time.Now()
+			uctx := ctx
+			if u.Transfer != nil {
+				last := start
+				uctx = context.WithValue(ctx, transferKey{}, func(sent int64) {
+					now := time.Now()
+					if now.Sub(last) < interval {
+						return
+					}
+					last = now
+					mu.Lock()
+					defer mu.Unlock()
+					u.Transfer(Transfer{Asset: a, Sent: sent, Size: size(a)})
+				})
+			}
+			errs[i] = upload(uctx, i)
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if u.Progress != nil {
+				u.Progress(Progress{Asset: a, Size: size(a), Elapsed: time.Since(start), Err: errs[i], Done: done, Total: len(assets)})
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	var uploaded []string
+	for i, a := range assets {
+		if errs[i] == nil {
+			uploaded = append(uploaded, a)
+		}
+	}
+	return uploaded, errors.Join(errs...)
+}
+
+func size(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
@@ -0,0 +1,90 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUploaderConcurrency(t *testing.T) {
+	assets := []string{"a", "b", "c", "d", "e", "f"}
+	var running, peak atomic.Int32
+	u := Uploader{Concurrency: 2}
+
+	uploaded, err := u.Upload(context.Background(), assets, func(ctx context.Context, i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(uploaded, assets) {
+		t.Errorf("Expected %v, got %v", assets, uploaded)
+	}
+	if peak.Load() != 2 {
+		t.Errorf("Expected 2 uploads in flight, got %d", peak.Load())
+	}
+}
+
+func TestUploaderErrors(t *testing.T) {
+	assets := []string{"a", "b", "c", "d"}
+	var progress []Progress
+	u := Uploader{Progress: func(p Progress) { progress = append(progress, p) }}
+
+	uploaded, err := u.Upload(context.Background(), assets, func(ctx context.Context, i int) error {
+		if i%2 == 1 {
+			return fmt.Errorf("upload %s: 502 Bad Gateway", assets[i])
+		}
+		return nil
+	})
+	if !reflect.DeepEqual(uploaded, []string{"a", "c"}) {
+		t.Errorf("Expected [a c], got %v", uploaded)
+	}
+	for _, want := range []string{"upload b: 502", "upload d: 502"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %v", want, err)
+		}
+	}
+
+	if len(progress) != len(assets) {
+		t.Fatalf("Expected %d progress reports, got %d", len(assets), len(progress))
+	}
+	failed := 0
+	for i, p := range progress {
+		if p.Done != i+1 || p.Total != len(assets) || p.Size != -1 {
+			t.Errorf("Expected report %d/%d of an unreadable file, got %+v", i+1, len(assets), p)
+		}
+		if p.Err != nil {
+			failed++
+		}
+	}
+	if failed != 2 {
+		t.Errorf("Expected 2 failed reports, got %d", failed)
+	}
+}
+
+func TestUploaderCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	_, err := Uploader{}.Upload(ctx, []string{"a"}, func(context.Context, int) error {
+		called = true
+		return nil
+	})
+	if called || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected no upload and context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+// Package ratelimit paces requests to provider APIs by the budget their
+// responses report, so that long releases, such as those of every module
+// of a monorepo, do not run out of requests halfway through publishing.
+//
+// GitHub reports its budget in X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset, GitLab in the same headers without the X- prefix. A
+// Limiter records them after each response and, before each request, waits
+// when the budget is running low: requests are spread over the time left
+// until the reset once less than a tenth of the limit remains, and stop
+// until the reset once only Reserve remain. GitHub also enforces secondary
+// limits on bursts of content-creating requests, which MinInterval keeps
+// clear of by spacing them out.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+)
+
+// Header prefixes of the providers.
+const (
+	GitHub = "X-RateLimit-"
+	GitLab = "RateLimit-"
+)
+
+// DefaultReserve is the Reserve of New.
+const DefaultReserve = 10
+
+// Budget is the request budget reported by a provider.
+type Budget struct {
+	Limit     int
+	Remaining int
+	// Reset is when Remaining goes back to Limit.
+	Reset time.Time
+}
+
+// ParseBudget reads the budget from h, whose header names start with
+// prefix. The boolean result is false when h does not report one.
+func ParseBudget(h http.Header, prefix string) (Budget, bool) {
+	remaining, err := strconv.Atoi(h.Get(prefix + "Remaining"))
+	if err != nil {
+		return Budget{}, false
+	}
+	b := Budget{Remaining: remaining}
+	b.Limit, _ = strconv.Atoi(h.Get(prefix + "Limit"))
+	if s, err := strconv.ParseInt(h.Get(prefix+"Reset"), 10, 64); err == nil {
+		b.Reset = time.Unix(s, 0)
+	}
+	return b, true
+}
+
+// Limiter paces the requests of one API client. The zero value only waits
+// when the budget is exhausted. A Limiter is safe for concurrent use.
+type Limiter struct {
+	// Reserve is the number of requests kept in hand: once no more remain,
+	// Wait blocks until the budget resets.
+	Reserve int
+	// MinInterval is the least time between the starts of two requests
+	// that are not GET or HEAD.
+	MinInterval time.Duration
+	// Logger receives a warning whenever Wait stops for the reset; nil
+	// discards.
+	Logger *slog.Logger
+
+	mu     sync.Mutex
+	budget Budget
+	known  bool
+	// next is when the next request may start, and nextWrite the next one
+	// that is not GET or HEAD.
+	next, nextWrite time.Time
+
+	// now and sleep are replaced by tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New returns a Limiter keeping DefaultReserve requests in hand.
+func New() *Limiter {
+	return &Limiter{Reserve: DefaultReserve}
+}
+
+// Budget returns the budget last reported, less the requests started
+// since. The boolean result is false before any response reported one.
+func (l *Limiter) Budget() (Budget, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.budget, l.known
+}
+
+// Update records the budget reported by the response headers h, whose
+// names start with prefix.
+func (l *Limiter) Update(h http.Header, prefix string) {
+	b, ok := ParseBudget(h, prefix)
+	if !ok {
+		return
+	}
+	l.mu.Lock()
+	l.budget, l.known = b, true
+	l.mu.Unlock()
+}
+
+// Wait blocks until a request with method may be sent, or ctx is done.
+func (l *Limiter) Wait(ctx context.Context, method string) error {
+	write := method != http.MethodGet && method != http.MethodHead
+
+	l.mu.Lock()
+	now := l.clock()
+	spacing, reset := l.pace(now)
+	at := laterOf(laterOf(l.next, now), reset)
+	if write {
+		at = laterOf(at, l.nextWrite)
+		l.nextWrite = at.Add(max(l.MinInterval, 0))
+	}
+	l.next = at.Add(spacing)
+	// Count the request against the budget until its response tells, so
+	// that concurrent callers do not overdraw it.
+	if l.known && l.budget.Remaining > 0 {
+		l.budget.Remaining--
+	}
+	l.mu.Unlock()
+
+	d := at.Sub(now)
+	if d <= 0 {
+		return nil
+	}
+	if !reset.IsZero() {
+		log.Or(l.Logger).Warn("rate limit nearly exhausted, waiting for it to reset", "wait", d.Round(time.Second).String())
+	}
+	sleep := l.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	return sleep(ctx, d)
+}
+
+// pace returns the spacing the budget asks between requests and, when it
+// is nearly exhausted, the time it resets. l.mu must be held.
+func (l *Limiter) pace(now time.Time) (time.Duration, time.Time) {
+	b := l.budget
+	if !l.known || !b.Reset.After(now) {
+		return 0, time.Time{}
+	}
+	reserve := max(l.Reserve, 0)
+	switch {
+	case b.Remaining <= reserve:
+		return 0, b.Reset
+	case b.Limit > 0 && b.Remaining*10 < b.Limit:
+		return b.Reset.Sub(now) / time.Duration(b.Remaining-reserve), time.Time{}
+	}
+	return 0, time.Time{}
+}
+
+func (l *Limiter) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
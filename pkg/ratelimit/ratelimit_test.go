@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// testLimiter returns l with a fixed clock that its sleeps advance, and
+// the record of those sleeps.
+func testLimiter(l *Limiter) *[]time.Duration {
+	clock := now
+	var slept []time.Duration
+	l.now = func() time.Time { return clock }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		clock = clock.Add(d)
+		return nil
+	}
+	return &slept
+}
+
+func header(prefix string, limit, remaining int, reset time.Time) http.Header {
+	h := http.Header{}
+	h.Set(prefix+"Limit", strconv.Itoa(limit))
+	h.Set(prefix+"Remaining", strconv.Itoa(remaining))
+	h.Set(prefix+"Reset", strconv.FormatInt(reset.Unix(), 10))
+	return h
+}
+
+func TestParseBudget(t *testing.T) {
+	b, ok := ParseBudget(header(GitLab, 2000, 1999, now), GitLab)
+	expected := Budget{Limit: 2000, Remaining: 1999, Reset: time.Unix(now.Unix(), 0)}
+	if !ok || b != expected {
+		t.Errorf("Expected %+v, got %+v (%v)", expected, b, ok)
+	}
+	if _, ok := ParseBudget(header(GitLab, 1, 1, now), GitHub); ok {
+		t.Errorf("Expected no budget under another prefix")
+	}
+}
+
+func TestWaitPlentyOfBudget(t *testing.T) {
+	l := New()
+	slept := testLimiter(l)
+	l.Update(header(GitHub, 5000, 4000, now.Add(time.Hour)), GitHub)
+
+	for range 3 {
+		if err := l.Wait(context.Background(), http.MethodGet); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(*slept) != 0 {
+		t.Errorf("Expected no waits, got %v", *slept)
+	}
+	if b, _ := l.Budget(); b.Remaining != 3997 {
+		t.Errorf("Expected the started requests to be counted, got %d remaining", b.Remaining)
+	}
+}
+
+func TestWaitSpreadsLowBudget(t *testing.T) {
+	l := &Limiter{Reserve: 10}
+	slept := testLimiter(l)
+	l.Update(header(GitHub, 5000, 70, now.Add(time.Hour)), GitHub)
+
+	for range 3 {
+		if err := l.Wait(context.Background(), http.MethodGet); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// 60 requests to spare over an hour: one a minute, and slightly more
+	// as the budget shrinks.
+	if len(*slept) != 2 || (*slept)[0] != time.Minute || (*slept)[1] <= time.Minute || (*slept)[1] > 62*time.Second {
+		t.Errorf("Expected two waits of about a minute, got %v", *slept)
+	}
+}
+
+func TestWaitForReset(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Limiter{Reserve: 1, Logger: log.New(&buf, log.Options{Level: slog.LevelDebug})}
+	slept := testLimiter(l)
+	l.Update(header(GitHub, 5000, 2, now.Add(10*time.Minute)), GitHub)
+
+	for range 3 {
+		if err := l.Wait(context.Background(), http.MethodPost); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(*slept) != 1 || (*slept)[0] != 10*time.Minute {
+		t.Errorf("Expected one wait for the reset, got %v", *slept)
+	}
+	expected := "warning: rate limit nearly exhausted, waiting for it to reset wait=10m0s\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWaitMinInterval(t *testing.T) {
+	l := &Limiter{MinInterval: time.Second}
+	slept := testLimiter(l)
+
+	for _, method := range []string{http.MethodPost, http.MethodGet, http.MethodPost, http.MethodPatch} {
+		if err := l.Wait(context.Background(), method); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(*slept) != 2 || (*slept)[0] != time.Second || (*slept)[1] != time.Second {
+		t.Errorf("Expected the writes a second apart, got %v", *slept)
+	}
+}
+
+func TestWaitCancelled(t *testing.T) {
+	l := New()
+	l.now = func() time.Time { return now }
+	l.Update(header(GitHub, 5000, 0, now.Add(time.Hour)), GitHub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx, http.MethodGet); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestWaitWithoutBudget(t *testing.T) {
+	var l Limiter
+	slept := testLimiter(&l)
+	if err := l.Wait(context.Background(), http.MethodPost); err != nil || len(*slept) != 0 {
+		t.Errorf("Expected no wait, got %v (%v)", *slept, err)
+	}
+	if _, ok := l.Budget(); ok {
+		t.Errorf("Expected no budget before a response")
+	}
+}
@@ -0,0 +1,149 @@
+// Package references finds the issues and pull requests commits mention —
+// "(#123)" in a description, "Fixes #456" in a footer — and resolves them
+// through a provider to their titles, authors and labels.
+package references
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+)
+
+// Reference is an issue or pull request.
+type Reference struct {
+	Number int
+	Title  string
+	// Author is the login of the user who opened it.
+	Author string
+	Labels []string
+	URL    string
+	// PullRequest is true for pull and merge requests.
+	PullRequest bool
+}
+
+// Finder looks up issues and pull requests by number.
+type Finder interface {
+	Issue(ctx context.Context, number int) (Reference, error)
+}
+
+// BatchFinder is a Finder that also looks up many numbers at once, in
+// fewer requests than one per number.
+type BatchFinder interface {
+	Finder
+	// Issues returns the issues and pull requests of numbers by number,
+	// leaving out the numbers it has none for.
+	Issues(ctx context.Context, numbers []int) (map[int]Reference, error)
+}
+
+// ErrUnavailable is returned by a Finder that cannot tell about a number
+// without failing, such as an offline cache that never recorded it.
+// Resolve leaves such numbers unresolved and carries on.
+var ErrUnavailable = errors.New("reference unavailable")
+
+var (
+	// mentionPattern matches "#123" outside of words, URLs and HTML
+	// entities such as "&#39;".
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w&/#])#(\d+)\b`)
+	numberPattern  = regexp.MustCompile(`#?(\d+)`)
+)
+
+// footerTokens are the footers naming the issues a commit closes or
+// relates to, compared ignoring case.
+var footerTokens = []string{"fixes", "fix", "fixed", "closes", "close", "closed", "resolves", "resolve", "resolved", "refs", "references", "see", "part-of"}
+
+// Numbers returns the issue and pull request numbers c mentions in its
+// description, body and reference footers, in that order, each once.
+func Numbers(c commits.Commit) []int {
+	var ns []int
+	add := func(s string) {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 && !slices.Contains(ns, n) {
+			ns = append(ns, n)
+		}
+	}
+	for _, text := range []string{c.Description, c.Body} {
+		for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+			add(m[1])
+		}
+	}
+	for _, f := range c.Footers {
+		if !slices.Contains(footerTokens, strings.ToLower(f.Token)) {
+			continue
+		}
+		for _, m := range numberPattern.FindAllStringSubmatch(f.Value, -1) {
+			add(m[1])
+		}
+	}
+	return ns
+}
+
+// Resolve looks up the references of cs with f, asking once per number, and
+// returns them by commit hash. Commits without a hash are skipped, as are
+// numbers f fails with ErrUnavailable for. A BatchFinder is asked for every
+// number at once, and the numbers it has nothing for are skipped too. On
+// error, the references resolved so far are returned with it.
+func Resolve(ctx context.Context, cs []commits.Commit, f Finder) (map[string][]Reference, error) {
+	if bf, ok := f.(BatchFinder); ok {
+		return resolveBatch(ctx, cs, bf)
+	}
+	refs := make(map[string][]Reference)
+	found := make(map[int]Reference)
+	unavailable := make(map[int]bool)
+	for _, c := range cs {
+		if c.Hash == "" {
+			continue
+		}
+		for _, n := range Numbers(c) {
+			r, ok := found[n]
+			if !ok {
+				var err error
+				r, err = f.Issue(ctx, n)
+				if errors.Is(err, ErrUnavailable) {
+					unavailable[n] = true
+				} else if err != nil {
+					return refs, err
+				}
+				found[n] = r
+			}
+			if !unavailable[n] {
+				refs[c.Hash] = append(refs[c.Hash], r)
+			}
+		}
+	}
+	return refs, nil
+}
+
+func resolveBatch(ctx context.Context, cs []commits.Commit, f BatchFinder) (map[string][]Reference, error) {
+	var numbers []int
+	seen := make(map[int]bool)
+	for _, c := range cs {
+		if c.Hash == "" {
+			continue
+		}
+		for _, n := range Numbers(c) {
+			if !seen[n] {
+				numbers, seen[n] = append(numbers, n), true
+			}
+		}
+	}
+	refs := make(map[string][]Reference)
+	if len(numbers) == 0 {
+		return refs, nil
+	}
+	found, err := f.Issues(ctx, numbers)
+	for _, c := range cs {
+		if c.Hash == "" {
+			continue
+		}
+		for _, n := range Numbers(c) {
+			if r, ok := found[n]; ok {
+				refs[c.Hash] = append(refs[c.Hash], r)
+			}
+		}
+	}
+	return refs, err
+}
@@ -0,0 +1,115 @@
+package references
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+)
+
+func TestNumbers(t *testing.T) {
+	c, err := commits.Parse("fix(api): handle empty pages (#12)\n\nSee #3 and https://x.test/#7 or &#39;.\n\nFixes #45, #46\nCloses: #12\nReviewed-by: #99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := Numbers(c), []int{12, 3, 45, 46}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+type fakeFinder struct {
+	asked []int
+}
+
+func (f *fakeFinder) Issue(_ context.Context, n int) (Reference, error) {
+	f.asked = append(f.asked, n)
+	if n == 404 {
+		return Reference{}, fmt.Errorf("no issue #%d", n)
+	}
+	if n == 410 {
+		return Reference{}, fmt.Errorf("issue #%d: %w", n, ErrUnavailable)
+	}
+	return Reference{Number: n, Title: fmt.Sprintf("issue %d", n)}, nil
+}
+
+func TestResolve(t *testing.T) {
+	cs := []commits.Commit{
+		{Hash: "a1", Description: "feat: x (#1)"},
+		{Hash: "b2", Description: "fix: y", Footers: []commits.Footer{{Token: "Fixes", Value: "1"}, {Token: "Refs", Value: "#2"}}},
+		{Description: "no hash (#3)"},
+	}
+	f := &fakeFinder{}
+	got, err := Resolve(context.Background(), cs, f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]Reference{
+		"a1": {{Number: 1, Title: "issue 1"}},
+		"b2": {{Number: 1, Title: "issue 1"}, {Number: 2, Title: "issue 2"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if !reflect.DeepEqual(f.asked, []int{1, 2}) {
+		t.Errorf("Expected each number looked up once, got %v", f.asked)
+	}
+
+	f = &fakeFinder{}
+	got, err = Resolve(context.Background(), append(cs, commits.Commit{Hash: "d4", Description: "fix: w (#410)"}, commits.Commit{Hash: "e5", Description: "fix: v (#410, #2)"}), f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := got["d4"]; ok || len(got["e5"]) != 1 || got["e5"][0].Number != 2 {
+		t.Errorf("Expected the unavailable reference left out, got %+v", got)
+	}
+	if !reflect.DeepEqual(f.asked, []int{1, 2, 410}) {
+		t.Errorf("Expected each number looked up once, got %v", f.asked)
+	}
+
+	cs = append(cs, commits.Commit{Hash: "c3", Description: "docs: z (#404)"})
+	got, err = Resolve(context.Background(), cs, &fakeFinder{})
+	if err == nil || len(got) != 2 {
+		t.Errorf("Expected the error with the references resolved so far, got %+v, %v", got, err)
+	}
+}
+
+type batchFinder struct {
+	fakeFinder
+	batches [][]int
+}
+
+func (f *batchFinder) Issues(_ context.Context, ns []int) (map[int]Reference, error) {
+	f.batches = append(f.batches, ns)
+	m := make(map[int]Reference)
+	for _, n := range ns {
+		if n != 404 {
+			m[n] = Reference{Number: n, Title: fmt.Sprintf("issue %d", n)}
+		}
+	}
+	return m, nil
+}
+
+func TestResolveBatch(t *testing.T) {
+	cs := []commits.Commit{
+		{Hash: "a1", Description: "feat: x (#1)"},
+		{Hash: "b2", Description: "fix: y (#404)", Footers: []commits.Footer{{Token: "Fixes", Value: "1"}, {Token: "Refs", Value: "#2"}}},
+		{Description: "no hash (#3)"},
+	}
+	f := &batchFinder{}
+	got, err := Resolve(context.Background(), cs, f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]Reference{
+		"a1": {{Number: 1, Title: "issue 1"}},
+		"b2": {{Number: 1, Title: "issue 1"}, {Number: 2, Title: "issue 2"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if !reflect.DeepEqual(f.batches, [][]int{{1, 404, 2}}) || len(f.asked) != 0 {
+		t.Errorf("Expected every number asked in one batch, got %v and %v", f.batches, f.asked)
+	}
+}
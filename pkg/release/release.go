@@ -0,0 +1,260 @@
+// Package release runs a whole release from Go, for programs that embed the
+// pipeline of the release command instead of shelling out to it.
+//
+// A Release plans the next version of a module from the Conventional
+// Commits since its latest tag, renders the changelog section and release
+// notes, optionally prepends the section to a changelog file and commits
+// it, creates the tag, pushes it, and publishes the release with each
+// publish.Publisher. Hooks of a hooks.Engine run at the usual stages.
+//
+//	r := release.New(repo,
+//		release.WithChangelogFile("CHANGELOG.md"),
+//		release.WithPublishers(github.New("octo", "app", token)),
+//		release.WithProgress(progress),
+//	)
+//	res, err := r.Run(ctx)
+//
+// Settings are given as Options, field by field with the With* functions
+// or at once with WithOptions. Configuration files are not read: callers
+// build the module, publishers and hooks themselves, with pkg/config and
+// the provider packages when they want the CLI's behaviour.
+package release
+
+import (
+	"io"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+	"github.com/gbrennon/release_automation_golang/pkg/notes"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// Options configures a Release. Every field is optional: the zero value
+// releases the whole repository with "v" tags, bumping by the commits,
+// and pushes the tag to origin without writing a changelog file or
+// publishing anywhere.
+type Options struct {
+	// Module is the module released; nil is workspace.Repository("v").
+	Module *workspace.Module
+	// Level overrides the bump level derived from the commits when
+	// HasLevel is set.
+	Level    version.Level
+	HasLevel bool
+	// Channel is the prerelease channel, or "" for a stable release.
+	Channel string
+	// Scheme computes the next version; nil is version.SemVer.
+	Scheme version.Scheme
+	// Classifier overrides the bump levels and changelog sections of
+	// commits; see commits.Classifier.
+	Classifier commits.Classifier
+
+	// ChangelogFile, when set, gets the changelog section prepended and is
+	// committed as "chore(release): changelog for <tag>" before tagging.
+	ChangelogFile string
+	// Changelog renders the changelog section; nil is
+	// changelog.DefaultRenderer.
+	Changelog changelog.Renderer
+	// Notes renders the release notes; nil is notes.Default with Links.
+	Notes *notes.Template
+	// Links are the repository links of the release notes.
+	Links notes.Links
+
+	// Message is the tag message; "" is "chore(release): <tag>".
+	Message string
+	// Signing signs the tag when set.
+	Signing *gitrepo.Signing
+	// Remotes are the git remotes the tag, and the changelog commit, are
+	// pushed to; nil is origin, and an empty slice pushes nowhere.
+	Remotes []string
+	// Branch is the branch the changelog commit is pushed to, as
+	// HEAD:refs/heads/<Branch>; "" is the current branch. Set it to
+	// release from a detached HEAD, as CI checkouts often are.
+	Branch string
+
+	// Publishers create the release, in order.
+	Publishers []publish.Publisher
+	// Assets are attached to every release.
+	Assets []string
+	// Draft creates draft releases.
+	Draft bool
+
+	// Hooks run at the lifecycle stages; nil runs none.
+	Hooks *hooks.Engine
+	// Progress is told about each step of Run; nil reports nothing.
+	Progress Progress
+
+	// DryRun describes the steps changing the repository or a provider on
+	// Log instead of performing them; publishers are not called.
+	DryRun bool
+	// Log receives the dry-run descriptions; nil discards them.
+	Log io.Writer
+	// Now returns the release date; nil is time.Now.
+	Now func() time.Time
+}
+
+// Option sets one or more Options.
+type Option func(*Options)
+
+// WithOptions replaces every setting with those of o.
+func WithOptions(o Options) Option {
+	return func(opts *Options) { *opts = o }
+}
+
+// WithModule releases m instead of the whole repository.
+func WithModule(m workspace.Module) Option {
+	return func(o *Options) { o.Module = &m }
+}
+
+// WithLevel bumps the version by l instead of the level the commits call
+// for.
+func WithLevel(l version.Level) Option {
+	return func(o *Options) { o.Level, o.HasLevel = l, true }
+}
+
+// WithChannel releases a prerelease on channel, e.g. "rc".
+func WithChannel(channel string) Option {
+	return func(o *Options) { o.Channel = channel }
+}
+
+// WithScheme computes the next version with s.
+func WithScheme(s version.Scheme) Option {
+	return func(o *Options) { o.Scheme = s }
+}
+
+// WithClassifier classifies the commits with cl.
+func WithClassifier(cl commits.Classifier) Option {
+	return func(o *Options) { o.Classifier = cl }
+}
+
+// WithChangelogFile prepends the changelog section to path and commits it
+// before tagging.
+func WithChangelogFile(path string) Option {
+	return func(o *Options) { o.ChangelogFile = path }
+}
+
+// WithChangelogRenderer renders the changelog section with r.
+func WithChangelogRenderer(r changelog.Renderer) Option {
+	return func(o *Options) { o.Changelog = r }
+}
+
+// WithNotes renders the release notes with t, linking to links.
+func WithNotes(t *notes.Template, links notes.Links) Option {
+	return func(o *Options) { o.Notes, o.Links = t, links }
+}
+
+// WithSigning signs the tag as s says.
+func WithSigning(s gitrepo.Signing) Option {
+	return func(o *Options) { o.Signing = &s }
+}
+
+// WithRemotes pushes to remotes instead of origin; no remotes disables
+// pushing.
+func WithRemotes(remotes ...string) Option {
+	return func(o *Options) { o.Remotes = append([]string{}, remotes...) }
+}
+
+// WithBranch pushes the changelog commit to branch, so that a detached
+// HEAD can be released.
+func WithBranch(branch string) Option {
+	return func(o *Options) { o.Branch = branch }
+}
+
+// WithPublishers publishes the release with ps, in order.
+func WithPublishers(ps ...publish.Publisher) Option {
+	return func(o *Options) { o.Publishers = append(o.Publishers, ps...) }
+}
+
+// WithAssets attaches paths to every release.
+func WithAssets(paths ...string) Option {
+	return func(o *Options) { o.Assets = append(o.Assets, paths...) }
+}
+
+// WithHooks runs the hooks of e.
+func WithHooks(e *hooks.Engine) Option {
+	return func(o *Options) { o.Hooks = e }
+}
+
+// WithProgress reports the steps of Run to p.
+func WithProgress(p Progress) Option {
+	return func(o *Options) { o.Progress = p }
+}
+
+// WithDryRun describes the mutating steps on log instead of performing
+// them.
+func WithDryRun(log io.Writer) Option {
+	return func(o *Options) { o.DryRun, o.Log = true, log }
+}
+
+// WithClock dates the release with now.
+func WithClock(now func() time.Time) Option {
+	return func(o *Options) { o.Now = now }
+}
+
+// Step is a stage of Run.
+type Step string
+
+// The steps of Run, in order. Steps with nothing to do, such as
+// StepPublish without publishers, are still reported.
+const (
+	StepPlan      Step = "plan"
+	StepChangelog Step = "changelog"
+	StepTag       Step = "tag"
+	StepPush      Step = "push"
+	StepPublish   Step = "publish"
+)
+
+// Progress is told as Run starts and finishes each step. Calls come from
+// the goroutine calling Run.
+type Progress interface {
+	StepStarted(s Step)
+	// StepFinished is called with the step's error, nil on success. A
+	// failed step is the last one reported.
+	StepFinished(s Step, err error)
+}
+
+// Result is what Run did. Fields are filled as the steps run, so a failed
+// Run returns those of the steps before the failure.
+type Result struct {
+	// Plan is the analysis of the release.
+	Plan workspace.Plan
+	// Tag is the tag of the release.
+	Tag string
+	// Changelog is the rendered changelog section and Notes the release
+	// notes, the body of the releases.
+	Changelog string
+	Notes     string
+	// Pushed lists the remotes the tag was pushed to.
+	Pushed []string
+	// Releases are the results of the publishers, in order.
+	Releases []publish.Result
+	// Warnings are the failures that did not stop the release, those of
+	// post-* hooks.
+	Warnings []error
+}
+
+// Release runs the release pipeline on a repository. A Release holds no
+// state between runs and may be run again, say once a failed provider
+// recovers, as publishing resumes existing releases.
+type Release struct {
+	repo gitrepo.Repository
+	opts Options
+}
+
+// New returns a Release of repo configured by opts, applied in order.
+func New(repo gitrepo.Repository, opts ...Option) *Release {
+	r := &Release{repo: repo}
+	for _, opt := range opts {
+		opt(&r.opts)
+	}
+	return r
+}
+
+// Options returns the settings of r.
+func (r *Release) Options() Options {
+	return r.opts
+}
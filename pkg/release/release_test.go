@@ -0,0 +1,281 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+type fakeRepo struct {
+	gitrepo.Repository
+	tags      []gitrepo.Tag
+	commits   []gitrepo.Commit
+	created   []string
+	committed []string
+	pushed    []string
+	pushErr   map[string]error
+}
+
+func (f *fakeRepo) Tags(context.Context) ([]gitrepo.Tag, error) { return f.tags, nil }
+
+func (f *fakeRepo) CommitsSince(context.Context, string, ...string) ([]gitrepo.Commit, error) {
+	return f.commits, nil
+}
+
+func (f *fakeRepo) Head(context.Context) (string, error) { return "abc", nil }
+
+func (f *fakeRepo) CurrentBranch(context.Context) (string, error) { return "main", nil }
+
+func (f *fakeRepo) CreateTag(_ context.Context, name, message string) error {
+	f.created = append(f.created, name+": "+message)
+	return nil
+}
+
+func (f *fakeRepo) CommitFiles(_ context.Context, message string, paths ...string) error {
+	f.committed = append(f.committed, message)
+	return nil
+}
+
+func (f *fakeRepo) Push(_ context.Context, remote, ref string) error {
+	if err := f.pushErr[remote]; err != nil {
+		return err
+	}
+	f.pushed = append(f.pushed, remote+" "+ref)
+	return nil
+}
+
+type publisherFunc func(context.Context, publish.Release) (publish.Result, error)
+
+func (f publisherFunc) Publish(ctx context.Context, r publish.Release) (publish.Result, error) {
+	return f(ctx, r)
+}
+
+// steps records the progress of a run as "started step" and "step: err".
+type steps []string
+
+func (s *steps) StepStarted(step Step) { *s = append(*s, "started "+string(step)) }
+
+func (s *steps) StepFinished(step Step, err error) {
+	*s = append(*s, string(step)+": "+errString(err))
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	return err.Error()
+}
+
+func newRepo() *fakeRepo {
+	return &fakeRepo{
+		tags: []gitrepo.Tag{{Name: "v1.0.0", Commit: "old", Annotated: true}},
+		commits: []gitrepo.Commit{
+			{Hash: "c2", AuthorName: "Ada", Message: "feat: add export"},
+			{Hash: "c1", AuthorName: "Ada", Message: "fix: handle nil"},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	repo := newRepo()
+	file := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	var published publish.Release
+	var progress steps
+	var stages []string
+	e := hooks.New()
+	for _, stage := range hooks.Stages {
+		e.Register(stage, hooks.Func(func(_ context.Context, env hooks.Env) error {
+			stages = append(stages, env[hooks.EnvStage]+" "+env[hooks.EnvTag])
+			return nil
+		}))
+	}
+
+	r := New(repo,
+		WithChangelogFile(file),
+		WithHooks(e),
+		WithProgress(&progress),
+		WithClock(func() time.Time { return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC) }),
+		WithPublishers(publisherFunc(func(_ context.Context, rel publish.Release) (publish.Result, error) {
+			published = rel
+			return publish.Result{URL: "https://example.com/v1.1.0"}, nil
+		})),
+	)
+	res, err := r.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Tag != "v1.1.0" || res.Plan.Level != version.Minor {
+		t.Errorf("Expected a minor release v1.1.0, got %s %s", res.Plan.Level, res.Tag)
+	}
+	if !reflect.DeepEqual(repo.committed, []string{"chore(release): changelog for v1.1.0"}) {
+		t.Errorf("Expected the changelog committed, got %v", repo.committed)
+	}
+	if !reflect.DeepEqual(repo.created, []string{"v1.1.0: chore(release): v1.1.0"}) {
+		t.Errorf("Expected the tag created, got %v", repo.created)
+	}
+	if !reflect.DeepEqual(repo.pushed, []string{"origin main", "origin v1.1.0"}) || !reflect.DeepEqual(res.Pushed, []string{"origin"}) {
+		t.Errorf("Expected main and v1.1.0 pushed to origin, got %v and %v", repo.pushed, res.Pushed)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "## [1.1.0] - 2026-03-01") || !strings.Contains(string(content), "- add export (c2)") {
+		t.Errorf("Expected the section in the changelog, got %q", content)
+	}
+	if published.Tag != "v1.1.0" || published.Body != res.Notes || !strings.Contains(res.Notes, "### Features") {
+		t.Errorf("Expected the notes published, got %+v", published)
+	}
+	if len(res.Releases) != 1 || res.Releases[0].URL != "https://example.com/v1.1.0" {
+		t.Errorf("Expected the release result, got %+v", res.Releases)
+	}
+
+	expected := steps{
+		"started plan", "plan: ok",
+		"started changelog", "changelog: ok",
+		"started tag", "tag: ok",
+		"started push", "push: ok",
+		"started publish", "publish: ok",
+	}
+	if !reflect.DeepEqual(progress, expected) {
+		t.Errorf("Expected %v, got %v", expected, progress)
+	}
+	wantStages := []string{"pre-bump v1.1.0", "post-changelog v1.1.0", "pre-publish v1.1.0", "post-publish v1.1.0"}
+	if !reflect.DeepEqual(stages, wantStages) {
+		t.Errorf("Expected %v, got %v", wantStages, stages)
+	}
+}
+
+func TestRunNothingToRelease(t *testing.T) {
+	repo := newRepo()
+	repo.commits = []gitrepo.Commit{{Hash: "c1", Message: "docs: typo"}}
+	var progress steps
+
+	res, err := New(repo, WithProgress(&progress)).Run(context.Background())
+	if !errors.Is(err, relerr.ErrNoCommitsSinceTag) {
+		t.Fatalf("Expected ErrNoCommitsSinceTag, got %v", err)
+	}
+	if res.Tag != "v1.0.0" || len(repo.created) != 0 {
+		t.Errorf("Expected no release, got %q and %v", res.Tag, repo.created)
+	}
+	expected := steps{"started plan", "plan: " + err.Error()}
+	if !reflect.DeepEqual(progress, expected) {
+		t.Errorf("Expected %v, got %v", expected, progress)
+	}
+}
+
+func TestRunOptions(t *testing.T) {
+	repo := newRepo()
+	var published publish.Release
+	r := New(repo,
+		WithOptions(Options{Message: "release", Draft: true}),
+		WithLevel(version.Major),
+		WithChannel("rc"),
+		WithRemotes(),
+		WithAssets("dist/app.tar.gz"),
+		WithPublishers(publisherFunc(func(_ context.Context, rel publish.Release) (publish.Result, error) {
+			published = rel
+			return publish.Result{}, nil
+		})),
+	)
+	res, err := r.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Tag != "v2.0.0-rc.1" || !reflect.DeepEqual(repo.created, []string{"v2.0.0-rc.1: release"}) {
+		t.Errorf("Expected v2.0.0-rc.1 tagged, got %q and %v", res.Tag, repo.created)
+	}
+	if len(repo.pushed) != 0 || len(repo.committed) != 0 {
+		t.Errorf("Expected no push nor commit, got %v and %v", repo.pushed, repo.committed)
+	}
+	if !published.Draft || !published.Prerelease || !reflect.DeepEqual(published.Assets, []string{"dist/app.tar.gz"}) {
+		t.Errorf("Expected a draft prerelease with its asset, got %+v", published)
+	}
+}
+
+func TestRunBranch(t *testing.T) {
+	repo := newRepo()
+	r := New(repo, WithChangelogFile(filepath.Join(t.TempDir(), "CHANGELOG.md")), WithBranch("release/1.x"))
+	if _, err := r.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"origin HEAD:refs/heads/release/1.x", "origin v1.1.0"}; !reflect.DeepEqual(repo.pushed, expected) {
+		t.Errorf("Expected %v, got %v", expected, repo.pushed)
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	repo := newRepo()
+	file := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	var log bytes.Buffer
+	called := false
+	r := New(repo,
+		WithChangelogFile(file),
+		WithDryRun(&log),
+		WithPublishers(publisherFunc(func(context.Context, publish.Release) (publish.Result, error) {
+			called = true
+			return publish.Result{}, nil
+		})),
+	)
+	if _, err := r.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if called || len(repo.created) != 0 || len(repo.pushed) != 0 || len(repo.committed) != 0 {
+		t.Errorf("Expected nothing done, got %v, %v and %v", repo.created, repo.pushed, repo.committed)
+	}
+	if _, err := os.Stat(file); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no changelog written, got %v", err)
+	}
+	for _, want := range []string{"would prepend the 1.1.0 section to " + file, "would create tag v1.1.0", "would publish v1.1.0 with publisher 1"} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("Expected %q in %q", want, log.String())
+		}
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	repo := newRepo()
+	repo.pushErr = map[string]error{"mirror": errors.New("connection refused")}
+	called := false
+	r := New(repo,
+		WithRemotes("mirror", "origin"),
+		WithPublishers(publisherFunc(func(context.Context, publish.Release) (publish.Result, error) {
+			called = true
+			return publish.Result{}, nil
+		})),
+	)
+	res, err := r.Run(context.Background())
+	if err == nil || err.Error() != "push to mirror: connection refused" {
+		t.Fatalf("Expected the failed remote named, got %v", err)
+	}
+	if called || !reflect.DeepEqual(res.Pushed, []string{"origin"}) {
+		t.Errorf("Expected origin pushed and nothing published, got %v and %v", res.Pushed, called)
+	}
+
+	repo = newRepo()
+	_, err = New(repo, WithPublishers(publisherFunc(func(context.Context, publish.Release) (publish.Result, error) {
+		return publish.Result{}, errors.New("401 Unauthorized")
+	}))).Run(context.Background())
+	if relerr.KindOf(err) != relerr.Provider {
+		t.Errorf("Expected a provider error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := New(newRepo()).Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled run, got %v", err)
+	}
+}
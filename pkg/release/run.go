@@ -0,0 +1,302 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/hooks"
+	"github.com/gbrennon/release_automation_golang/pkg/notes"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// Run releases the next version, running the steps in order and stopping
+// at the first failure, which is returned with the Result so far. Without
+// releasable commits it fails with errors.ErrNoCommitsSinceTag after the
+// plan step. Errors carry the Kind of pkg/errors the CLI maps to its exit
+// statuses: Hook for a failing pre-* hook, Conflict for an existing tag,
+// Provider for a failed publisher. A cancelled ctx stops the step running.
+func (r *Release) Run(ctx context.Context) (Result, error) {
+	var (
+		res    Result
+		env    hooks.Env
+		commit bool
+	)
+	repo := r.repo
+	if r.opts.DryRun {
+		repo = gitrepo.DryRun(repo, r.log())
+	}
+	steps := []struct {
+		step Step
+		run  func(ctx context.Context) error
+	}{
+		{StepPlan, func(ctx context.Context) error {
+			p, err := r.plan(ctx)
+			res.Plan, res.Tag = p, p.Tag()
+			env = r.env(p)
+			return err
+		}},
+		{StepChangelog, func(ctx context.Context) error {
+			if err := r.runHooks(ctx, hooks.PreBump, env, &res); err != nil {
+				return err
+			}
+			var err error
+			commit, err = r.changelog(ctx, repo, &res)
+			if err != nil {
+				return err
+			}
+			return r.runHooks(ctx, hooks.PostChangelog, env, &res)
+		}},
+		{StepTag, func(ctx context.Context) error {
+			return r.tag(ctx, repo, res.Tag, commit)
+		}},
+		{StepPush, func(ctx context.Context) error {
+			if err := r.runHooks(ctx, hooks.PrePublish, env, &res); err != nil {
+				return err
+			}
+			return r.push(ctx, repo, res.Tag, commit, &res)
+		}},
+		{StepPublish, func(ctx context.Context) error {
+			if err := r.publish(ctx, &res); err != nil {
+				return err
+			}
+			return r.runHooks(ctx, hooks.PostPublish, env, &res)
+		}},
+	}
+	for _, s := range steps {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		if p := r.opts.Progress; p != nil {
+			p.StepStarted(s.step)
+		}
+		err := s.run(ctx)
+		if p := r.opts.Progress; p != nil {
+			p.StepFinished(s.step, err)
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// plan computes the next version of the module.
+func (r *Release) plan(ctx context.Context) (workspace.Plan, error) {
+	m := workspace.Repository("v")
+	if r.opts.Module != nil {
+		m = *r.opts.Module
+	}
+	p, err := workspace.NewPlan(ctx, r.repo, m)
+	if err != nil {
+		return p, err
+	}
+	p = p.WithClassifier(r.opts.Classifier).WithScheme(r.opts.Scheme, r.now().UTC()).OnChannel(r.opts.Channel)
+	if r.opts.HasLevel {
+		p = p.WithLevel(r.opts.Level)
+	}
+	if p.Level == version.None {
+		return p, relerr.ErrNoCommitsSinceTag
+	}
+	return p, nil
+}
+
+// env returns the hook environment describing p.
+func (r *Release) env(p workspace.Plan) hooks.Env {
+	env := hooks.Env{
+		hooks.EnvNextVersion: p.Next.String(),
+		hooks.EnvTag:         p.Tag(),
+		hooks.EnvBump:        p.Level.String(),
+	}
+	if p.HasPrevious {
+		env[hooks.EnvPrevVersion] = p.Previous.String()
+	}
+	if p.Channel != "" {
+		env[hooks.EnvChannel] = p.Channel
+	}
+	if r.opts.ChangelogFile != "" {
+		env[hooks.EnvChangelog] = r.opts.ChangelogFile
+	}
+	return env
+}
+
+// changelog renders the changelog section and the release notes into res,
+// and prepends the section to the changelog file when there is one. It
+// reports whether it committed the file.
+func (r *Release) changelog(ctx context.Context, repo gitrepo.Repository, res *Result) (bool, error) {
+	p := res.Plan
+	date := r.now()
+	renderer := r.opts.Changelog
+	if renderer == nil {
+		renderer = changelog.DefaultRenderer()
+	}
+	var section strings.Builder
+	rel := changelog.New(p.Next.String(), date, p.Commits, changelog.Options{Classifier: p.Classifier})
+	if err := renderer.Render(&section, rel); err != nil {
+		return false, err
+	}
+	res.Changelog = section.String()
+
+	t := r.opts.Notes
+	if t == nil {
+		t = notes.Default(r.opts.Links)
+	}
+	data := notes.NewData(notes.Metadata{Version: p.Next, Tag: p.Tag(), PreviousTag: p.PreviousTag, Date: date}, p.Raw, r.opts.Links)
+	if len(p.Classifier.Rules) > 0 {
+		data.Groups = rel.Groups
+	}
+	data.Contributors = contributors.List(p.Raw)
+	body, err := t.RenderString(data)
+	if err != nil {
+		return false, err
+	}
+	res.Notes = body
+
+	path := r.opts.ChangelogFile
+	if path == "" {
+		return false, nil
+	}
+	if r.opts.DryRun {
+		dryrun.Printf(r.log(), "would prepend the %s section to %s", p.Next, path)
+	} else if err := changelog.PrependFile(path, []byte(res.Changelog)); err != nil {
+		return false, err
+	}
+	if err := repo.CommitFiles(ctx, "chore(release): changelog for "+res.Tag, path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// tag creates the release tag, refusing to move an existing one. With
+// commit, the tag goes on the changelog commit just made.
+func (r *Release) tag(ctx context.Context, repo gitrepo.Repository, tag string, commit bool) error {
+	head := ""
+	if !commit {
+		var err error
+		if head, err = r.repo.Head(ctx); err != nil {
+			return err
+		}
+	}
+	local, err := r.repo.Tags(ctx)
+	if err != nil {
+		return err
+	}
+	if err := gitrepo.CheckTag(tag, head, local, nil, ""); err != nil {
+		return err
+	}
+	msg := r.opts.Message
+	if msg == "" {
+		msg = "chore(release): " + tag
+	}
+	if r.opts.Signing != nil {
+		return repo.CreateSignedTag(ctx, tag, msg, *r.opts.Signing)
+	}
+	return repo.CreateTag(ctx, tag, msg)
+}
+
+// push pushes tag, and the release branch first when it carries the
+// changelog commit, to every remote. Every remote is tried; the failures
+// are returned together.
+func (r *Release) push(ctx context.Context, repo gitrepo.Repository, tag string, commit bool, res *Result) error {
+	refs := []string{tag}
+	if commit && r.opts.Branch != "" {
+		refs = []string{"HEAD:refs/heads/" + r.opts.Branch, tag}
+	} else if commit {
+		branch, err := r.repo.CurrentBranch(ctx)
+		if err != nil {
+			return fmt.Errorf("push the changelog commit: %w; set the branch to push it to with WithBranch", err)
+		}
+		refs = []string{branch, tag}
+	}
+	remotes := r.opts.Remotes
+	if remotes == nil {
+		remotes = []string{"origin"}
+	}
+	var errs []error
+	for _, remote := range remotes {
+		var err error
+		for _, ref := range refs {
+			if err = repo.Push(ctx, remote, ref); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("push to %s: %w", remote, err))
+			continue
+		}
+		if !r.opts.DryRun {
+			res.Pushed = append(res.Pushed, remote)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// publish creates the release with each publisher, with the release notes
+// as its body. In dry-run mode the publishers are only listed.
+func (r *Release) publish(ctx context.Context, res *Result) error {
+	rel := publish.Release{
+		Tag:        res.Tag,
+		Version:    res.Plan.Next.String(),
+		Body:       res.Notes,
+		Draft:      r.opts.Draft,
+		Prerelease: res.Plan.Next.IsPrerelease(),
+		Assets:     slices.Clone(r.opts.Assets),
+	}
+	for i, p := range r.opts.Publishers {
+		if r.opts.DryRun {
+			dryrun.Printf(r.log(), "would publish %s with publisher %d (%T)", res.Tag, i+1, p)
+			continue
+		}
+		out, err := p.Publish(ctx, rel)
+		if err != nil {
+			return relerr.Wrap(relerr.Provider, err)
+		}
+		res.Releases = append(res.Releases, out)
+	}
+	return nil
+}
+
+// runHooks runs the hooks of stage. Failures of post-* hooks are added to
+// the warnings of res; those of pre-* hooks stop the release.
+func (r *Release) runHooks(ctx context.Context, stage hooks.Stage, env hooks.Env, res *Result) error {
+	if r.opts.Hooks == nil {
+		return nil
+	}
+	e := r.opts.Hooks
+	if r.opts.DryRun {
+		dry := *e
+		dry.DryRun, dry.Log = true, r.log()
+		e = &dry
+	}
+	err := e.Run(ctx, stage, env)
+	if errors.Is(err, hooks.ErrPostHook) {
+		res.Warnings = append(res.Warnings, err)
+		return nil
+	}
+	return relerr.Wrap(relerr.Hook, err)
+}
+
+func (r *Release) log() io.Writer {
+	if r.opts.Log == nil {
+		return io.Discard
+	}
+	return r.opts.Log
+}
+
+func (r *Release) now() time.Time {
+	if r.opts.Now == nil {
+		return time.Now()
+	}
+	return r.opts.Now()
+}
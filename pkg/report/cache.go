@@ -0,0 +1,109 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache keeps the release pages found through the provider APIs in a JSON
+// file, by source and tag.
+type Cache struct {
+	// Path is the cache file; an empty path keeps nothing.
+	Path string
+	// MaxAge is how long an entry is used before it is fetched again; zero
+	// uses it forever.
+	MaxAge time.Duration
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	entries map[string]map[string]entry
+}
+
+// entry is a page found at a time.
+type entry struct {
+	URL string    `json:"url"`
+	At  time.Time `json:"at"`
+}
+
+// LoadCache reads the cache at path. A missing file is an empty cache.
+func LoadCache(path string, maxAge time.Duration) (*Cache, error) {
+	c := &Cache{Path: path, MaxAge: maxAge}
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("report: %s: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// Get returns the page of tag on source, unless it is older than MaxAge.
+func (c *Cache) Get(source, tag string) (string, bool) {
+	e, ok := c.entries[source][tag]
+	if !ok || (c.MaxAge > 0 && c.now().Sub(e.At) > c.MaxAge) {
+		return "", false
+	}
+	return e.URL, true
+}
+
+// Set records the page of tag on source.
+func (c *Cache) Set(source, tag, url string) {
+	if c.entries == nil {
+		c.entries = make(map[string]map[string]entry)
+	}
+	if c.entries[source] == nil {
+		c.entries[source] = make(map[string]entry)
+	}
+	c.entries[source][tag] = entry{URL: url, At: c.now().UTC()}
+}
+
+// Save writes the cache to Path through a temporary file, so that an
+// interruption never leaves a truncated cache behind.
+func (c *Cache) Save() error {
+	if c.Path == "" {
+		return nil
+	}
+	dir := filepath.Dir(c.Path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("report: %w", err)
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(c.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("report: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("report: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("report: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.Path); err != nil {
+		return fmt.Errorf("report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".release", "report-cache.json")
+	now := time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC)
+
+	c, err := LoadCache(path, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Expected a missing cache to be empty, got %v", err)
+	}
+	c.Now = func() time.Time { return now }
+	c.Set("github octo/app", "v1.2.0", "https://example.com/v1.2.0")
+	if err := c.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := LoadCache(path, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded.Now = func() time.Time { return now.Add(time.Hour) }
+	if url, ok := loaded.Get("github octo/app", "v1.2.0"); !ok || url != "https://example.com/v1.2.0" {
+		t.Errorf("Expected the saved link, got %q", url)
+	}
+	if _, ok := loaded.Get("gitlab octo/app", "v1.2.0"); ok {
+		t.Errorf("Expected no link of another source")
+	}
+	loaded.Now = func() time.Time { return now.Add(25 * time.Hour) }
+	if _, ok := loaded.Get("github octo/app", "v1.2.0"); ok {
+		t.Errorf("Expected an entry older than MaxAge to be fetched again")
+	}
+
+	os.WriteFile(path, []byte("{"), 0o644)
+	if _, err := LoadCache(path, 0); err == nil {
+		t.Errorf("Expected an error for a corrupt cache")
+	}
+	if err := (&Cache{}).Save(); err != nil {
+		t.Errorf("Expected a cache without a path to save nothing, got %v", err)
+	}
+}
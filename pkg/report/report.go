@@ -0,0 +1,197 @@
+// Package report renders a static HTML page summarizing the recent releases
+// of a repository: their versions, dates, commit counts, contributors and
+// links to their pages on the providers.
+//
+// The page carries its style inline and loads nothing, so it can be
+// published as is; a fragment, the summary without the page around it, can
+// be embedded in a docs site instead. The links are looked up through the
+// provider APIs and kept in a Cache, so that regenerating the report only
+// looks up the releases it has not linked yet.
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/contributors"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+// Report is the data of the page.
+type Report struct {
+	Title string
+	// Generated is when the report was made.
+	Generated time.Time
+	// Releases are newest first.
+	Releases []Release
+}
+
+// Release is a release of the report.
+type Release struct {
+	Tag     string
+	Version string
+	// Date is the date of the tagged commit.
+	Date time.Time
+	// Commits counts the commits the release introduced.
+	Commits      int
+	Contributors []contributors.Contributor
+	// Links are the pages of the release on the providers, in the order of
+	// the sources.
+	Links []Link
+}
+
+// Link is the page of a release on a provider.
+type Link struct {
+	// Name is the name of the source, e.g. "github octo/app".
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// New returns the report of the newest limit releases of history, oldest
+// first as workspace.History returns them, or of every release when limit
+// is not positive. Contributors matching exclude are left out, as
+// contributors.List does.
+func New(title string, history []workspace.Release, limit int, exclude ...string) Report {
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	r := Report{Title: title}
+	for _, h := range slices.Backward(history) {
+		r.Releases = append(r.Releases, Release{
+			Tag:          h.Tag,
+			Version:      h.Version.String(),
+			Date:         h.Date,
+			Commits:      len(h.Raw),
+			Contributors: contributors.List(h.Raw, exclude...),
+		})
+	}
+	return r
+}
+
+// Source is a provider the releases are linked to.
+type Source struct {
+	Name    string
+	Fetcher publish.Fetcher
+}
+
+// AddLinks links the releases of r to their pages on each of sources. The
+// pages in cache are used as they are; the others are fetched and added to
+// cache. A release a source has no page for is fetched again next time,
+// as it may be published later. A failed lookup leaves the release without
+// the link; the failures are returned joined, after every lookup. A nil
+// cache fetches every page.
+func AddLinks(ctx context.Context, r *Report, sources []Source, cache *Cache) error {
+	if cache == nil {
+		cache = &Cache{}
+	}
+	var errs []error
+	for i := range r.Releases {
+		rel := &r.Releases[i]
+		for _, s := range sources {
+			url, ok := cache.Get(s.Name, rel.Tag)
+			if !ok {
+				p, found, err := s.Fetcher.Fetch(ctx, rel.Tag)
+				if err != nil {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					errs = append(errs, fmt.Errorf("report: %s on %s: %w", rel.Tag, s.Name, err))
+					continue
+				}
+				if !found || p.URL == "" {
+					continue
+				}
+				url = p.URL
+				cache.Set(s.Name, rel.Tag, url)
+			}
+			rel.Links = append(rel.Links, Link{Name: s.Name, URL: url})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HTMLTemplate is the default template of the page. Its "releases"
+// template is the fragment: a section listing the releases, styled through
+// the classes it defines so that a docs site can restyle it.
+const HTMLTemplate = `{{ define "releases" }}<section class="releases">
+<table>
+<thead><tr><th>Version</th><th>Date</th><th>Commits</th><th>Contributors</th><th>Links</th></tr></thead>
+<tbody>
+{{ range .Releases }}<tr id="{{ .Tag }}">
+<td class="version">{{ .Version }}</td>
+<td class="date">{{ if not .Date.IsZero }}<time datetime="{{ .Date.Format "2006-01-02" }}">{{ .Date.Format "2006-01-02" }}</time>{{ end }}</td>
+<td class="commits">{{ .Commits }}</td>
+<td class="contributors">{{ range $i, $c := .Contributors }}{{ if $i }}, {{ end }}{{ if .Login }}<a href="{{ .URL }}">{{ .Handle }}</a>{{ else }}{{ .Name }}{{ end }}{{ end }}</td>
+<td class="links">{{ range $i, $l := .Links }}{{ if $i }} · {{ end }}<a href="{{ .URL }}">{{ .Name }}</a>{{ end }}</td>
+</tr>
+{{ else }}<tr><td colspan="5">No releases yet.</td></tr>
+{{ end }}</tbody>
+</table>
+</section>
+{{ end }}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #1f2328; }
+.releases table { border-collapse: collapse; width: 100%; }
+.releases th, .releases td { border-bottom: 1px solid #d1d9e0; padding: .5rem; text-align: left; vertical-align: top; }
+.releases .version { font-weight: 600; white-space: nowrap; }
+.releases .commits { text-align: right; }
+footer { color: #59636e; font-size: .875rem; margin-top: 1rem; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+{{ template "releases" . }}{{ if not .Generated.IsZero }}<footer>Generated on {{ .Generated.Format "2006-01-02 15:04 MST" }}.</footer>
+{{ end }}</body>
+</html>
+`
+
+// Renderer renders reports with an html/template, which escapes the names
+// and URLs of the releases.
+type Renderer struct {
+	tmpl *htmltemplate.Template
+}
+
+// NewRenderer returns a Renderer for the template text, or HTMLTemplate
+// when text is empty. A template of its own defines "releases" to be
+// rendered as a fragment.
+func NewRenderer(text string) (*Renderer, error) {
+	if text == "" {
+		text = HTMLTemplate
+	}
+	tmpl, err := htmltemplate.New("report").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	return &Renderer{tmpl: tmpl}, nil
+}
+
+// Render writes the page of r to w.
+func (rr *Renderer) Render(w io.Writer, r Report) error {
+	if err := rr.tmpl.Execute(w, r); err != nil {
+		return fmt.Errorf("report: %w", err)
+	}
+	return nil
+}
+
+// RenderFragment writes the "releases" template alone, to be embedded in
+// another page.
+func (rr *Renderer) RenderFragment(w io.Writer, r Report) error {
+	if rr.tmpl.Lookup("releases") == nil {
+		return errors.New(`report: the template defines no "releases" fragment`)
+	}
+	if err := rr.tmpl.ExecuteTemplate(w, "releases", r); err != nil {
+		return fmt.Errorf("report: %w", err)
+	}
+	return nil
+}
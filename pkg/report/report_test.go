@@ -0,0 +1,137 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+	"github.com/gbrennon/release_automation_golang/pkg/workspace"
+)
+
+type fakeFetcher struct {
+	urls    map[string]string
+	fetched []string
+	err     error
+}
+
+func (f *fakeFetcher) Fetch(_ context.Context, tag string) (publish.Published, bool, error) {
+	f.fetched = append(f.fetched, tag)
+	url, ok := f.urls[tag]
+	return publish.Published{Tag: tag, URL: url}, ok, f.err
+}
+
+func (f *fakeFetcher) Download(context.Context, publish.RemoteAsset, io.Writer) error {
+	return nil
+}
+
+func history() []workspace.Release {
+	ada := gitrepo.Commit{AuthorName: "Ada", AuthorEmail: "ada@example.com"}
+	bob := gitrepo.Commit{AuthorName: "Bob <b>", AuthorEmail: "12+bob@users.noreply.github.com"}
+	return []workspace.Release{
+		{Version: version.MustParse("1.0.0"), Tag: "v1.0.0", Date: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), Raw: []gitrepo.Commit{ada}},
+		{Version: version.MustParse("1.1.0"), Tag: "v1.1.0", Date: time.Date(2026, 2, 9, 0, 0, 0, 0, time.UTC), Raw: []gitrepo.Commit{ada, bob, ada}},
+		{Version: version.MustParse("1.2.0"), Tag: "v1.2.0", Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Raw: []gitrepo.Commit{bob}},
+	}
+}
+
+func TestNew(t *testing.T) {
+	r := New("App releases", history(), 2, "ada@example.com")
+	if len(r.Releases) != 2 || r.Releases[0].Tag != "v1.2.0" || r.Releases[1].Tag != "v1.1.0" {
+		t.Fatalf("Expected the two newest releases, newest first, got %+v", r.Releases)
+	}
+	if got := r.Releases[1]; got.Commits != 3 || len(got.Contributors) != 1 || got.Contributors[0].Login != "bob" {
+		t.Errorf("Expected 3 commits by bob, got %+v", got)
+	}
+	if all := New("", history(), 0); len(all.Releases) != 3 {
+		t.Errorf("Expected every release, got %d", len(all.Releases))
+	}
+}
+
+func TestAddLinks(t *testing.T) {
+	r := New("", history(), 0)
+	gh := &fakeFetcher{urls: map[string]string{"v1.1.0": "https://github.com/octo/app/releases/tag/v1.1.0", "v1.2.0": "https://github.com/octo/app/releases/tag/v1.2.0"}}
+	gl := &fakeFetcher{err: errors.New("503 Service Unavailable")}
+	cache := &Cache{Path: filepath.Join(t.TempDir(), "cache.json")}
+	cache.Set("github octo/app", "v1.2.0", "https://cached.example.com/v1.2.0")
+
+	err := AddLinks(context.Background(), &r, []Source{{"github octo/app", gh}, {"gitlab octo/app", gl}}, cache)
+	if err == nil || !strings.Contains(err.Error(), "v1.0.0 on gitlab octo/app: 503") {
+		t.Errorf("Expected the failed lookups, got %v", err)
+	}
+	if got := strings.Join(gh.fetched, " "); got != "v1.1.0 v1.0.0" {
+		t.Errorf("Expected the cached release not fetched, got %q", got)
+	}
+	if l := r.Releases[0].Links; len(l) != 1 || l[0].URL != "https://cached.example.com/v1.2.0" {
+		t.Errorf("Expected the cached link, got %+v", l)
+	}
+	if l := r.Releases[1].Links; len(l) != 1 || l[0].Name != "github octo/app" {
+		t.Errorf("Expected the fetched link, got %+v", l)
+	}
+	if len(r.Releases[2].Links) != 0 {
+		t.Errorf("Expected no link for a release without a page, got %+v", r.Releases[2].Links)
+	}
+	if url, ok := cache.Get("github octo/app", "v1.1.0"); !ok || url != "https://github.com/octo/app/releases/tag/v1.1.0" {
+		t.Errorf("Expected the fetched link cached, got %q", url)
+	}
+	if _, ok := cache.Get("github octo/app", "v1.0.0"); ok {
+		t.Errorf("Expected a release without a page not to be cached")
+	}
+}
+
+func TestRender(t *testing.T) {
+	r := New(`App <releases>`, history(), 0)
+	r.Generated = time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC)
+	r.Releases[0].Links = []Link{{Name: "github octo/app", URL: "https://github.com/octo/app/releases/tag/v1.2.0"}}
+	rr, err := NewRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var page bytes.Buffer
+	if err := rr.Render(&page, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>App &lt;releases&gt;</title>",
+		`<tr id="v1.2.0">`,
+		`<time datetime="2026-03-02">2026-03-02</time>`,
+		`<td class="commits">3</td>`,
+		`<a href="https://github.com/bob">@bob</a>`,
+		"Ada",
+		`<a href="https://github.com/octo/app/releases/tag/v1.2.0">github octo/app</a>`,
+		"Generated on 2026-03-03 10:00 UTC.",
+	} {
+		if !strings.Contains(page.String(), want) {
+			t.Errorf("Expected the page to contain %q, got:\n%s", want, page.String())
+		}
+	}
+
+	var fragment bytes.Buffer
+	if err := rr.RenderFragment(&fragment, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := fragment.String(); !strings.HasPrefix(s, `<section class="releases">`) || strings.Contains(s, "<html") {
+		t.Errorf("Expected the section alone, got:\n%s", s)
+	}
+
+	custom, err := NewRenderer("{{ range .Releases }}{{ .Version }} {{ end }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page.Reset()
+	if err := custom.Render(&page, r); err != nil || page.String() != "1.2.0 1.1.0 1.0.0 " {
+		t.Errorf("Expected the custom template, got %q and %v", page.String(), err)
+	}
+	if err := custom.RenderFragment(&page, r); err == nil {
+		t.Errorf("Expected an error for a template without a fragment")
+	}
+}
@@ -0,0 +1,211 @@
+// Package retry runs operations that talk to remote services again when
+// they fail transiently, waiting with exponential backoff and jitter in
+// between.
+//
+// Only errors classified by IsRetryable are retried: errors marked with
+// Retryable or After (used for HTTP 429 and 5xx responses, see HTTPStatus),
+// network timeouts and dropped connections. Everything else, including
+// context cancellation, is returned at once.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Policy describes how often and how patiently an operation is retried.
+// The zero value runs the operation once.
+type Policy struct {
+	// MaxAttempts is the total number of tries, including the first.
+	// Values below 1 mean 1.
+	MaxAttempts int
+	// Initial is the delay before the second attempt; each further delay
+	// is Multiplier times the previous one, capped at Max.
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	// Jitter is the fraction of each delay, between 0 and 1, that is
+	// randomised so concurrent clients do not retry in lockstep.
+	Jitter float64
+	// OnRetry, when set, is called before waiting to retry after err.
+	OnRetry func(attempt int, err error, delay time.Duration)
+
+	// sleep waits for d or until ctx is done; tests replace it.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Default returns the policy used for provider API calls and git pushes:
+// four attempts, 1s, 2s and 4s apart, with 20% jitter.
+func Default() Policy {
+	return Policy{MaxAttempts: 4, Initial: time.Second, Max: 30 * time.Second, Multiplier: 2, Jitter: 0.2}
+}
+
+// Do calls op until it succeeds, fails with an error that is not
+// retryable, or MaxAttempts is reached. The last error is returned,
+// annotated with the number of attempts when there were several.
+func (p Policy) Do(ctx context.Context, op func(ctx context.Context) error) error {
+	attempts := max(p.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= attempts || !IsRetryable(err) || ctx.Err() != nil {
+			if attempt > 1 {
+				return fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return err
+		}
+
+		delay := max(p.Delay(attempt), after(err))
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+		sleep := p.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if serr := sleep(ctx, delay); serr != nil {
+			return fmt.Errorf("%w (retry abandoned: %w)", err, serr)
+		}
+	}
+}
+
+// Delay returns the wait after the given failed attempt (1 for the first),
+// including jitter.
+func (p Policy) Delay(attempt int) time.Duration {
+	d := float64(p.Initial)
+	for i := 1; i < attempt; i++ {
+		d *= max(p.Multiplier, 1)
+		if p.Max > 0 && d >= float64(p.Max) {
+			break
+		}
+	}
+	if p.Max > 0 {
+		d = min(d, float64(p.Max))
+	}
+	if j := min(max(p.Jitter, 0), 1); j > 0 {
+		d -= d * j * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryableError marks an error as transient.
+type retryableError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// Retryable marks err as transient so Do tries again. It returns nil for a
+// nil err.
+func Retryable(err error) error {
+	return After(err, 0)
+}
+
+// After marks err as transient and asks Do to wait at least d before the
+// next attempt, as a Retry-After header does.
+func After(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err, after: d}
+}
+
+func after(err error) time.Duration {
+	var re *retryableError
+	if errors.As(err, &re) {
+		return re.after
+	}
+	return 0
+}
+
+// IsRetryable reports whether err is worth another attempt.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var re *retryableError
+	if errors.As(err, &re) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	// A server closing the connection mid-request surfaces as EOF from the
+	// HTTP transport; EOF elsewhere (say, decoding a body) is not transient.
+	var ue *url.Error
+	return errors.As(err, &ue) && (errors.Is(ue.Err, io.EOF) || errors.Is(ue.Err, io.ErrUnexpectedEOF))
+}
+
+// HTTPStatus reports whether a response with status code should be
+// retried: 408, 429 and 5xx other than 501 Not Implemented.
+func HTTPStatus(code int) bool {
+	switch {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return true
+	case code == http.StatusNotImplemented:
+		return false
+	}
+	return code >= 500 && code <= 599
+}
+
+// RetryAfter parses a Retry-After header value, either a number of seconds
+// or an HTTP date relative to now. It returns 0 when h is empty or invalid.
+func RetryAfter(h string, now time.Time) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(h); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// RateLimitReset returns the wait until a rate limit resets, given the
+// remaining requests and the reset time in Unix seconds, as GitHub sends
+// in X-RateLimit-Remaining and X-RateLimit-Reset and GitLab in
+// RateLimit-Remaining and RateLimit-Reset. It returns 0 unless remaining
+// is "0" and reset is in the future.
+func RateLimitReset(remaining, reset string, now time.Time) time.Duration {
+	if remaining != "0" {
+		return 0
+	}
+	s, err := strconv.ParseInt(reset, 10, 64)
+	if err != nil {
+		return 0
+	}
+	if t := time.Unix(s, 0); t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
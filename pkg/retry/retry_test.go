@@ -0,0 +1,206 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// record replaces the sleep of p with one that records the delays.
+func record(p *Policy) *[]time.Duration {
+	var delays []time.Duration
+	p.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return &delays
+}
+
+func TestDo(t *testing.T) {
+	p := Policy{MaxAttempts: 4, Initial: time.Second, Max: 3 * time.Second, Multiplier: 2}
+	delays := record(&p)
+	var retried []int
+	p.OnRetry = func(attempt int, err error, d time.Duration) { retried = append(retried, attempt) }
+
+	calls := 0
+	err := p.Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 4 {
+			return Retryable(errors.New("503"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected 4 calls, got %d", calls)
+	}
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+	if fmt.Sprint(retried) != "[1 2 3]" {
+		t.Errorf("Expected OnRetry for attempts 1-3, got %v", retried)
+	}
+}
+
+func TestDoGivesUp(t *testing.T) {
+	p := Policy{MaxAttempts: 3, Initial: time.Millisecond}
+	record(&p)
+	base := errors.New("502 Bad Gateway")
+
+	calls := 0
+	err := p.Do(context.Background(), func(context.Context) error {
+		calls++
+		return Retryable(base)
+	})
+	if calls != 3 || !errors.Is(err, base) || !strings.HasSuffix(err.Error(), "(after 3 attempts)") {
+		t.Errorf("Expected 3 attempts ending in %v, got %d: %v", base, calls, err)
+	}
+}
+
+func TestDoPermanent(t *testing.T) {
+	p := Default()
+	delays := record(&p)
+	base := errors.New("422 Unprocessable Entity")
+
+	calls := 0
+	err := p.Do(context.Background(), func(context.Context) error {
+		calls++
+		return base
+	})
+	if calls != 1 || err != base || len(*delays) != 0 {
+		t.Errorf("Expected a single attempt returning %v, got %d: %v", base, calls, err)
+	}
+}
+
+func TestDoZeroPolicy(t *testing.T) {
+	calls := 0
+	(Policy{}).Do(context.Background(), func(context.Context) error {
+		calls++
+		return Retryable(errors.New("503"))
+	})
+	if calls != 1 {
+		t.Errorf("Expected the zero policy to try once, got %d", calls)
+	}
+}
+
+func TestDoRetryAfter(t *testing.T) {
+	p := Policy{MaxAttempts: 2, Initial: time.Second}
+	delays := record(&p)
+
+	p.Do(context.Background(), func(context.Context) error {
+		return After(errors.New("429"), 5*time.Second)
+	})
+	if len(*delays) != 1 || (*delays)[0] != 5*time.Second {
+		t.Errorf("Expected to honour Retry-After, got %v", *delays)
+	}
+}
+
+func TestDoCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := Policy{MaxAttempts: 5, Initial: time.Hour}
+
+	calls := 0
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	err := p.Do(ctx, func(context.Context) error {
+		calls++
+		return Retryable(errors.New("503"))
+	})
+	if calls != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancellation during the wait, got %d calls: %v", calls, err)
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	p := Policy{Initial: time.Second, Multiplier: 2, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		d := p.Delay(2)
+		if d < time.Second || d > 2*time.Second {
+			t.Fatalf("Expected a delay between 1s and 2s, got %s", d)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("boom"), false},
+		{Retryable(errors.New("503")), true},
+		{fmt.Errorf("wrapped: %w", Retryable(errors.New("503"))), true},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+		{&url.Error{Op: "Post", URL: "https://x", Err: os.ErrDeadlineExceeded}, true},
+		{&url.Error{Op: "Post", URL: "https://x", Err: io.EOF}, true},
+		{io.EOF, false},
+		{fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.expected {
+			t.Errorf("IsRetryable(%v): Expected %v, got %v", tt.err, tt.expected, got)
+		}
+	}
+	if Retryable(nil) != nil {
+		t.Errorf("Expected Retryable(nil) to be nil")
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for code, expected := range map[int]bool{200: false, 404: false, 408: true, 422: false, 429: true, 500: true, 501: false, 502: true, 503: true, 504: true} {
+		if got := HTTPStatus(code); got != expected {
+			t.Errorf("HTTPStatus(%d): Expected %v, got %v", code, expected, got)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"7":                             7 * time.Second,
+		"soon":                          0,
+		"Sun, 01 Mar 2026 12:00:30 GMT": 30 * time.Second,
+		"Sun, 01 Mar 2026 11:00:00 GMT": 0,
+	}
+	for h, expected := range tests {
+		if got := RetryAfter(h, now); got != expected {
+			t.Errorf("RetryAfter(%q): Expected %s, got %s", h, expected, got)
+		}
+	}
+}
+
+func TestRateLimitReset(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	reset := strconv.FormatInt(now.Add(90*time.Second).Unix(), 10)
+	tests := []struct {
+		remaining, reset string
+		expected         time.Duration
+	}{
+		{"0", reset, 90 * time.Second},
+		{"12", reset, 0},
+		{"", reset, 0},
+		{"0", "soon", 0},
+		{"0", strconv.FormatInt(now.Add(-time.Minute).Unix(), 10), 0},
+	}
+	for _, tt := range tests {
+		if got := RateLimitReset(tt.remaining, tt.reset, now); got != tt.expected {
+			t.Errorf("RateLimitReset(%q, %q): Expected %s, got %s", tt.remaining, tt.reset, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CycloneDXVersion is the specification version of CycloneDX documents.
+const CycloneDXVersion = "1.5"
+
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp,omitempty"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type"`
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Hashes     []cdxHash     `json:"hashes,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+func (d Document) encodeCycloneDX(w io.Writer) error {
+	main := cdxComponent{
+		Type:    "application",
+		BOMRef:  d.Main.PURL(),
+		Name:    d.Main.Path,
+		Version: d.Main.Version,
+		PURL:    d.Main.PURL(),
+	}
+	if d.Digest != "" {
+		main.Hashes = []cdxHash{{Alg: "SHA-256", Content: d.Digest}}
+	}
+	for _, p := range []cdxProperty{{"cdx:go:binary", d.Binary}, {"cdx:go:version", d.GoVersion}, {"cdx:go:goos", d.OS}, {"cdx:go:goarch", d.Arch}} {
+		if p.Value != "" {
+			main.Properties = append(main.Properties, p)
+		}
+	}
+
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  CycloneDXVersion,
+		SerialNumber: "urn:uuid:" + d.uuid(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: Tool}}},
+			Component: main,
+		},
+		Components: []cdxComponent{},
+	}
+	if !d.Created.IsZero() {
+		bom.Metadata.Timestamp = d.Created.UTC().Format(time.RFC3339)
+	}
+	deps := cdxDependency{Ref: main.BOMRef}
+	for _, m := range d.Modules {
+		bom.Components = append(bom.Components, cdxComponent{
+			Type:    "library",
+			BOMRef:  m.PURL(),
+			Name:    m.Path,
+			Version: m.Version,
+			PURL:    m.PURL(),
+		})
+		deps.DependsOn = append(deps.DependsOn, m.PURL())
+		bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: m.PURL()})
+	}
+	bom.Dependencies = append([]cdxDependency{deps}, bom.Dependencies...)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(bom); err != nil {
+		return fmt.Errorf("sbom: encode CycloneDX: %w", err)
+	}
+	return nil
+}
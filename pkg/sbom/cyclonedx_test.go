@@ -0,0 +1,60 @@
+package sbom
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeCycloneDX(t *testing.T) {
+	var b strings.Builder
+	if err := testDocument().Encode(&b, CycloneDX); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var bom cdxBOM
+	if err := json.Unmarshal([]byte(b.String()), &bom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" || bom.Version != 1 || !strings.HasPrefix(bom.SerialNumber, "urn:uuid:") {
+		t.Errorf("Unexpected header %+v", bom)
+	}
+	if bom.Metadata.Timestamp != "2026-03-01T12:00:00Z" || bom.Metadata.Tools.Components[0].Name != "release" {
+		t.Errorf("Unexpected metadata %+v", bom.Metadata)
+	}
+	main := cdxComponent{
+		Type:    "application",
+		BOMRef:  "pkg:golang/github.com/octo/app@v1.2.3",
+		Name:    "github.com/octo/app",
+		Version: "v1.2.3",
+		PURL:    "pkg:golang/github.com/octo/app@v1.2.3",
+		Hashes:  []cdxHash{{Alg: "SHA-256", Content: "abc123"}},
+		Properties: []cdxProperty{
+			{"cdx:go:binary", "app_1.2.3_linux_amd64"},
+			{"cdx:go:version", "go1.25.7"},
+			{"cdx:go:goos", "linux"},
+			{"cdx:go:goarch", "amd64"},
+		},
+	}
+	if !reflect.DeepEqual(bom.Metadata.Component, main) {
+		t.Errorf("Expected %+v, got %+v", main, bom.Metadata.Component)
+	}
+	if len(bom.Components) != 2 || bom.Components[0].PURL != "pkg:golang/golang.org/x/text@v0.3.0" || bom.Components[0].Type != "library" {
+		t.Errorf("Unexpected components %+v", bom.Components)
+	}
+	deps := []string{"pkg:golang/golang.org/x/text@v0.3.0", "pkg:golang/github.com/fork/yaml@v3.0.1"}
+	if len(bom.Dependencies) != 3 || bom.Dependencies[0].Ref != main.BOMRef || !reflect.DeepEqual(bom.Dependencies[0].DependsOn, deps) {
+		t.Errorf("Unexpected dependencies %+v", bom.Dependencies)
+	}
+}
+
+func TestEncodeCycloneDXEmpty(t *testing.T) {
+	var b strings.Builder
+	if err := (Document{Main: Module{Path: "example.com/m"}}).Encode(&b, CycloneDX); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(b.String(), `"components": []`) || strings.Contains(b.String(), "timestamp") {
+		t.Errorf("Expected no components nor timestamp, got %s", b.String())
+	}
+}
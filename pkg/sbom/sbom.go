@@ -0,0 +1,166 @@
+// Package sbom writes software bills of materials of Go binaries in the
+// CycloneDX or SPDX JSON formats. The modules are read from the build info
+// the Go toolchain embeds in every binary, so the SBOM lists exactly what
+// was linked, for any GOOS.
+package sbom
+
+import (
+	"crypto/sha256"
+	"debug/buildinfo"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Format is an SBOM format.
+type Format string
+
+// SBOM formats.
+const (
+	CycloneDX Format = "cyclonedx"
+	SPDX      Format = "spdx"
+)
+
+// Formats lists the formats accepted by ParseFormat.
+var Formats = []Format{CycloneDX, SPDX}
+
+// ParseFormat parses s as a Format.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("sbom: unknown format %q: must be cyclonedx or spdx", s)
+}
+
+// Ext returns the extension of files in format f, such as ".cdx.json".
+func (f Format) Ext() string {
+	if f == SPDX {
+		return ".spdx.json"
+	}
+	return ".cdx.json"
+}
+
+// Tool names the program creating SBOMs in their metadata.
+const Tool = "release"
+
+// Module is a Go module linked into a binary.
+type Module struct {
+	Path    string
+	Version string
+	// Sum is the go.sum hash of the module, e.g. "h1:...".
+	Sum string
+}
+
+// PURL returns the package URL of m, e.g.
+// "pkg:golang/golang.org/x/text@v0.3.0".
+func (m Module) PURL() string {
+	p := "pkg:golang/" + m.Path
+	if m.Version != "" {
+		p += "@" + m.Version
+	}
+	return p
+}
+
+// Document describes a binary and the modules it was built from.
+type Document struct {
+	// Main is the main module; its Version is usually set to the released
+	// version, as binaries built from a checkout report "(devel)".
+	Main Module
+	// Binary is the file name of the binary and Digest its hex-encoded
+	// SHA-256 digest.
+	Binary string
+	Digest string
+	// GoVersion, OS and Arch describe the build.
+	GoVersion string
+	OS        string
+	Arch      string
+	// Modules are the dependencies, in the order of the build info.
+	Modules []Module
+	// Created is when the document was written.
+	Created time.Time
+}
+
+// FromBuildInfo returns the document of a binary with build info info,
+// leaving Binary, Digest and Created empty.
+func FromBuildInfo(info *debug.BuildInfo) Document {
+	d := Document{
+		Main:      Module{Path: info.Main.Path, Version: info.Main.Version, Sum: info.Main.Sum},
+		GoVersion: info.GoVersion,
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "GOOS":
+			d.OS = s.Value
+		case "GOARCH":
+			d.Arch = s.Value
+		}
+	}
+	for _, dep := range info.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		d.Modules = append(d.Modules, Module{Path: dep.Path, Version: dep.Version, Sum: dep.Sum})
+	}
+	return d
+}
+
+// ReadBinary reads the build info and digest of the Go binary at path.
+func ReadBinary(path string) (Document, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("sbom: %w", err)
+	}
+	d := FromBuildInfo(info)
+	d.Binary = filepath.Base(path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("sbom: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return Document{}, fmt.Errorf("sbom: digest %s: %w", path, err)
+	}
+	d.Digest = hex.EncodeToString(h.Sum(nil))
+	return d, nil
+}
+
+// Encode writes d to w in format f.
+func (d Document) Encode(w io.Writer, f Format) error {
+	switch f {
+	case CycloneDX:
+		return d.encodeCycloneDX(w)
+	case SPDX:
+		return d.encodeSPDX(w)
+	}
+	return fmt.Errorf("sbom: unknown format %q", f)
+}
+
+// Write writes d to path in format f.
+func Write(path string, f Format, d Document) error {
+	var b strings.Builder
+	if err := d.Encode(&b, f); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("sbom: %w", err)
+	}
+	return nil
+}
+
+// uuid returns a UUID derived from the binary, so writing the SBOM of the
+// same build twice gives the same serial number and namespace.
+func (d Document) uuid() string {
+	h := sha256.Sum256([]byte(d.Main.PURL() + "\x00" + d.Binary + "\x00" + d.Digest))
+	h[6] = h[6]&0x0f | 0x80 // version 8: custom
+	h[8] = h[8]&0x3f | 0x80 // RFC 9562 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
@@ -0,0 +1,116 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDocument is the document of a binary with one replaced dependency.
+func testDocument() Document {
+	d := FromBuildInfo(&debug.BuildInfo{
+		GoVersion: "go1.25.7",
+		Main:      debug.Module{Path: "github.com/octo/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/text", Version: "v0.3.0", Sum: "h1:text"},
+			{Path: "gopkg.in/yaml.v3", Version: "v3.0.0", Replace: &debug.Module{Path: "github.com/fork/yaml", Version: "v3.0.1", Sum: "h1:fork"}},
+		},
+		Settings: []debug.BuildSetting{{Key: "GOOS", Value: "linux"}, {Key: "GOARCH", Value: "amd64"}, {Key: "CGO_ENABLED", Value: "0"}},
+	})
+	d.Main.Version = "v1.2.3"
+	d.Binary = "app_1.2.3_linux_amd64"
+	d.Digest = "abc123"
+	d.Created = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return d
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		if got, err := ParseFormat(string(f)); err != nil || got != f {
+			t.Errorf("Expected %s, got %s (%v)", f, got, err)
+		}
+	}
+	if _, err := ParseFormat("swid"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	if CycloneDX.Ext() != ".cdx.json" || SPDX.Ext() != ".spdx.json" {
+		t.Errorf("Unexpected extensions %s and %s", CycloneDX.Ext(), SPDX.Ext())
+	}
+}
+
+func TestFromBuildInfo(t *testing.T) {
+	d := testDocument()
+	if d.GoVersion != "go1.25.7" || d.OS != "linux" || d.Arch != "amd64" {
+		t.Errorf("Unexpected build %s %s/%s", d.GoVersion, d.OS, d.Arch)
+	}
+	expected := []Module{
+		{Path: "golang.org/x/text", Version: "v0.3.0", Sum: "h1:text"},
+		{Path: "github.com/fork/yaml", Version: "v3.0.1", Sum: "h1:fork"},
+	}
+	if !reflect.DeepEqual(d.Modules, expected) {
+		t.Errorf("Expected %v, got %v", expected, d.Modules)
+	}
+}
+
+func TestPURL(t *testing.T) {
+	if got := (Module{Path: "golang.org/x/text", Version: "v0.3.0"}).PURL(); got != "pkg:golang/golang.org/x/text@v0.3.0" {
+		t.Errorf("Expected pkg:golang/golang.org/x/text@v0.3.0, got %s", got)
+	}
+	if got := (Module{Path: "example.com/m"}).PURL(); got != "pkg:golang/example.com/m" {
+		t.Errorf("Expected pkg:golang/example.com/m, got %s", got)
+	}
+}
+
+func TestReadBinary(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	d, err := ReadBinary(exe)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Binary != filepath.Base(exe) || len(d.Digest) != 64 || d.GoVersion != runtime.Version() {
+		t.Errorf("Unexpected document %+v", d)
+	}
+
+	notGo := filepath.Join(t.TempDir(), "script")
+	os.WriteFile(notGo, []byte("#!/bin/sh\n"), 0o755)
+	if _, err := ReadBinary(notGo); err == nil {
+		t.Error("Expected an error for a file that is not a Go binary")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	d := testDocument()
+	dir := t.TempDir()
+	for _, f := range Formats {
+		path := filepath.Join(dir, d.Binary+f.Ext())
+		if err := Write(path, f, d); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if !strings.Contains(string(data), "pkg:golang/github.com/fork/yaml@v3.0.1") {
+			t.Errorf("Expected the %s SBOM to list the replacement, got %s", f, data)
+		}
+	}
+	if err := d.Encode(&strings.Builder{}, "swid"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+
+	// The same build always gets the same identifier.
+	other := d
+	other.Created = time.Now()
+	if d.uuid() != other.uuid() {
+		t.Errorf("Expected a stable UUID, got %s and %s", d.uuid(), other.uuid())
+	}
+	other.Digest = "def456"
+	if d.uuid() == other.uuid() {
+		t.Error("Expected another UUID for another binary")
+	}
+}
@@ -0,0 +1,113 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// SPDXVersion is the specification version of SPDX documents.
+const SPDXVersion = "SPDX-2.3"
+
+// SPDXNamespace prefixes the documentNamespace of SPDX documents.
+const SPDXNamespace = "https://spdx.org/spdxdocs/"
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	PackageFileName  string            `json:"packageFileName,omitempty"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func spdxPackageOf(id string, m Module) spdxPackage {
+	return spdxPackage{
+		Name:             m.Path,
+		SPDXID:           id,
+		VersionInfo:      m.Version,
+		DownloadLocation: "NOASSERTION",
+		ExternalRefs:     []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: m.PURL()}},
+	}
+}
+
+func (d Document) encodeSPDX(w io.Writer) error {
+	const mainID = "SPDXRef-Package-main"
+	name := d.Binary
+	if name == "" {
+		name = d.Main.Path
+	}
+	created := d.Created
+	if created.IsZero() {
+		created = time.Unix(0, 0)
+	}
+
+	main := spdxPackageOf(mainID, d.Main)
+	main.PackageFileName = d.Binary
+	if d.Digest != "" {
+		main.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: d.Digest}}
+	}
+	doc := spdxDocument{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: SPDXNamespace + name + "-" + d.uuid(),
+		CreationInfo: spdxCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + Tool},
+		},
+		Packages:      []spdxPackage{main},
+		Relationships: []spdxRelationship{{"SPDXRef-DOCUMENT", "DESCRIBES", mainID}},
+	}
+	for i, m := range d.Modules {
+		id := "SPDXRef-Package-" + strconv.Itoa(i+1)
+		doc.Packages = append(doc.Packages, spdxPackageOf(id, m))
+		doc.Relationships = append(doc.Relationships, spdxRelationship{mainID, "DEPENDS_ON", id})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("sbom: encode SPDX: %w", err)
+	}
+	return nil
+}
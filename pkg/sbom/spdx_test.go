@@ -0,0 +1,53 @@
+package sbom
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeSPDX(t *testing.T) {
+	d := testDocument()
+	var b strings.Builder
+	if err := d.Encode(&b, SPDX); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc spdxDocument
+	if err := json.Unmarshal([]byte(b.String()), &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.DataLicense != "CC0-1.0" || doc.Name != "app_1.2.3_linux_amd64" {
+		t.Errorf("Unexpected header %+v", doc)
+	}
+	if want := "https://spdx.org/spdxdocs/app_1.2.3_linux_amd64-" + d.uuid(); doc.DocumentNamespace != want {
+		t.Errorf("Expected %s, got %s", want, doc.DocumentNamespace)
+	}
+	if want := (spdxCreationInfo{Created: "2026-03-01T12:00:00Z", Creators: []string{"Tool: release"}}); !reflect.DeepEqual(doc.CreationInfo, want) {
+		t.Errorf("Expected %+v, got %+v", want, doc.CreationInfo)
+	}
+	main := spdxPackage{
+		Name:             "github.com/octo/app",
+		SPDXID:           "SPDXRef-Package-main",
+		VersionInfo:      "v1.2.3",
+		DownloadLocation: "NOASSERTION",
+		PackageFileName:  "app_1.2.3_linux_amd64",
+		Checksums:        []spdxChecksum{{"SHA256", "abc123"}},
+		ExternalRefs:     []spdxExternalRef{{"PACKAGE-MANAGER", "purl", "pkg:golang/github.com/octo/app@v1.2.3"}},
+	}
+	if len(doc.Packages) != 3 || !reflect.DeepEqual(doc.Packages[0], main) {
+		t.Fatalf("Expected %+v first, got %+v", main, doc.Packages)
+	}
+	if doc.Packages[2].Name != "github.com/fork/yaml" || doc.Packages[2].SPDXID != "SPDXRef-Package-2" {
+		t.Errorf("Unexpected package %+v", doc.Packages[2])
+	}
+	relationships := []spdxRelationship{
+		{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Package-main"},
+		{"SPDXRef-Package-main", "DEPENDS_ON", "SPDXRef-Package-1"},
+		{"SPDXRef-Package-main", "DEPENDS_ON", "SPDXRef-Package-2"},
+	}
+	if !reflect.DeepEqual(doc.Relationships, relationships) {
+		t.Errorf("Expected %v, got %v", relationships, doc.Relationships)
+	}
+}
@@ -0,0 +1,169 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AWS reads secrets from a secret of AWS Secrets Manager holding a JSON
+// object, whose keys are the secret names. The secret is fetched once, by
+// the first lookup that succeeds.
+type AWS struct {
+	Region string
+	// SecretID is the name or ARN of the secret.
+	SecretID string
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials
+	// signing the request; SessionToken is only set for temporary ones.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Endpoint defaults to the regional Secrets Manager endpoint.
+	Endpoint   string
+	HTTPClient *http.Client
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	// mu guards data, set once a fetch succeeds: a failed fetch is
+	// retried by the next lookup.
+	mu      sync.Mutex
+	fetched bool
+	data    map[string]string
+}
+
+func (a *AWS) Lookup(ctx context.Context, name string) (string, bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.fetched {
+		data, err := a.fetch(ctx)
+		if err != nil {
+			return "", false, err
+		}
+		a.data, a.fetched = data, true
+	}
+	s, ok := a.data[name]
+	return s, ok, nil
+}
+
+func (a *AWS) fetch(ctx context.Context) (map[string]string, error) {
+	if a.AccessKeyID == "" || a.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws: no credentials in AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + a.Region + ".amazonaws.com"
+	}
+	payload, err := json.Marshal(map[string]string{"SecretId": a.SecretID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	a.sign(req, payload, "secretsmanager", now().UTC())
+
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return nil, fmt.Errorf("aws: GetSecretValue %s: %s: %s", a.SecretID, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var body struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("aws: decode %s: %w", a.SecretID, err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(body.SecretString), &values); err != nil {
+		return nil, fmt.Errorf("aws: secret %s is not a JSON object: %w", a.SecretID, err)
+	}
+	return stringValues(values), nil
+}
+
+// sign adds the Signature Version 4 headers of req, whose body is payload,
+// for service at t (see
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html).
+func (a *AWS) sign(req *http.Request, payload []byte, service string, t time.Time) {
+	stamp, day := t.Format("20060102T150405Z"), t.Format("20060102")
+	req.Header.Set("X-Amz-Date", stamp)
+	if a.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, vs := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(vs, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, k := range names {
+		canonical.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	request := strings.Join([]string{req.Method, path, canonicalQuery(req.URL.RawQuery), canonical.String(), signed, hexSHA256(payload)}, "\n")
+	scope := day + "/" + a.Region + "/" + service + "/aws4_request"
+	toSign := strings.Join([]string{"AWS4-HMAC-SHA256", stamp, scope, hexSHA256([]byte(request))}, "\n")
+
+	key := []byte("AWS4" + a.SecretAccessKey)
+	for _, part := range []string{day, a.Region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.AccessKeyID, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+// canonicalQuery sorts the parameters of the encoded query raw.
+func canonicalQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	params := strings.Split(raw, "&")
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAWSSign(t *testing.T) {
+	// The example request of the AWS Signature Version 4 documentation.
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	a := &AWS{Region: "us-east-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+	a.sign(req, nil, "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestAWS(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			t.Errorf("Expected GetSecretValue, got %q", r.Header.Get("X-Amz-Target"))
+		}
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20260301/eu-west-1/secretsmanager/aws4_request") {
+			t.Errorf("Expected a signed request, got %q", auth)
+		}
+		if r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("Expected the session token, got %q", r.Header.Get("X-Amz-Security-Token"))
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["SecretId"] != "ci/release" {
+			t.Errorf("Expected SecretId ci/release, got %v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"GITHUB_TOKEN":"ghp_from_aws","PORT":8}`})
+	}))
+	defer srv.Close()
+
+	a := &AWS{
+		Region: "eu-west-1", SecretID: "ci/release",
+		AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session",
+		Endpoint: srv.URL,
+		Now:      func() time.Time { return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC) },
+	}
+	v, ok, err := a.Lookup(context.Background(), "GITHUB_TOKEN")
+	if err != nil || !ok || v != "ghp_from_aws" {
+		t.Errorf("Expected ghp_from_aws, got %q, %v, %v", v, ok, err)
+	}
+	if _, ok, _ := a.Lookup(context.Background(), "PORT"); ok {
+		t.Errorf("Expected non-string values to be skipped")
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request, got %d", requests)
+	}
+}
+
+func TestAWSNoCredentials(t *testing.T) {
+	_, _, err := (&AWS{Region: "eu-west-1", SecretID: "x"}).Lookup(context.Background(), "GITHUB_TOKEN")
+	if err == nil || !strings.Contains(err.Error(), "no credentials") {
+		t.Errorf("Expected a credentials error, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package secrets looks up the credentials of publishers and notifiers,
+// such as GITHUB_TOKEN, in the environment, in files and in external
+// stores (HashiCorp Vault, AWS Secrets Manager), so they never need to be
+// passed on the command line.
+//
+// A Store tries its providers in order and reports every value it finds to
+// OnResolve, which the CLI uses to redact them from its logs.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Provider is a source of secrets, keyed by name, e.g. "GITHUB_TOKEN".
+type Provider interface {
+	// Lookup returns the secret name, and false when the provider does not
+	// hold it.
+	Lookup(ctx context.Context, name string) (string, bool, error)
+}
+
+// Store looks secrets up in Providers, in order, remembering the values it
+// found.
+type Store struct {
+	Providers []Provider
+	// OnResolve, when set, is called once with every value found, e.g. to
+	// redact it from logs.
+	OnResolve func(value string)
+
+	mu    sync.Mutex
+	found map[string]string
+}
+
+// Lookup returns the secret name from the first provider holding it. An
+// error of a provider stops the lookup.
+func (s *Store) Lookup(ctx context.Context, name string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.found[name]; ok {
+		return v, true, nil
+	}
+	for _, p := range s.Providers {
+		v, ok, err := p.Lookup(ctx, name)
+		if err != nil {
+			return "", false, fmt.Errorf("secrets: %s: %w", name, err)
+		}
+		if !ok || v == "" {
+			continue
+		}
+		if s.found == nil {
+			s.found = map[string]string{}
+		}
+		s.found[name] = v
+		if s.OnResolve != nil {
+			s.OnResolve(v)
+		}
+		return v, true, nil
+	}
+	return "", false, nil
+}
+
+// Env reads secrets from environment variables. A variable named after the
+// secret with a "_FILE" suffix, e.g. GITHUB_TOKEN_FILE, names a file
+// holding it instead.
+type Env struct {
+	// Getenv defaults to os.Getenv.
+	Getenv func(string) string
+}
+
+func (e Env) Lookup(ctx context.Context, name string) (string, bool, error) {
+	getenv := e.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if v := getenv(name); v != "" {
+		return v, true, nil
+	}
+	path := getenv(name + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+// Dir reads secrets from the files of a directory, one per secret named
+// after it or its lower-case form, as Docker and Kubernetes mount them
+// (e.g. /run/secrets/github_token).
+type Dir struct {
+	Path string
+}
+
+func (d Dir) Lookup(ctx context.Context, name string) (string, bool, error) {
+	for _, file := range []string{name, strings.ToLower(name)} {
+		data, err := os.ReadFile(filepath.Join(d.Path, file))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			return "", false, err
+		}
+		return strings.TrimSpace(string(data)), true, nil
+	}
+	return "", false, nil
+}
@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type mapProvider map[string]string
+
+func (m mapProvider) Lookup(ctx context.Context, name string) (string, bool, error) {
+	v, ok := m[name]
+	return v, ok, nil
+}
+
+type failingProvider struct{}
+
+func (failingProvider) Lookup(ctx context.Context, name string) (string, bool, error) {
+	return "", false, errors.New("store unavailable")
+}
+
+func TestStore(t *testing.T) {
+	var resolved []string
+	s := &Store{
+		Providers: []Provider{mapProvider{"GITLAB_TOKEN": ""}, mapProvider{"GITLAB_TOKEN": "glpat-x", "GITHUB_TOKEN": "ghp_x"}},
+		OnResolve: func(v string) { resolved = append(resolved, v) },
+	}
+
+	for range 2 {
+		v, ok, err := s.Lookup(context.Background(), "GITLAB_TOKEN")
+		if err != nil || !ok || v != "glpat-x" {
+			t.Errorf("Expected glpat-x, got %q, %v, %v", v, ok, err)
+		}
+	}
+	if _, ok, _ := s.Lookup(context.Background(), "NPM_TOKEN"); ok {
+		t.Errorf("Expected NPM_TOKEN to be missing")
+	}
+	if len(resolved) != 1 || resolved[0] != "glpat-x" {
+		t.Errorf("Expected [glpat-x] to be reported once, got %v", resolved)
+	}
+}
+
+func TestStoreError(t *testing.T) {
+	s := &Store{Providers: []Provider{failingProvider{}, mapProvider{"GITHUB_TOKEN": "ghp_x"}}}
+	if _, _, err := s.Lookup(context.Background(), "GITHUB_TOKEN"); err == nil {
+		t.Errorf("Expected the provider error")
+	}
+}
+
+func TestEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("ghp_file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := Env{Getenv: func(k string) string {
+		return map[string]string{"GITLAB_TOKEN": "glpat-x", "GITHUB_TOKEN_FILE": path}[k]
+	}}
+
+	tests := []struct {
+		name  string
+		value string
+		found bool
+	}{
+		{"GITLAB_TOKEN", "glpat-x", true},
+		{"GITHUB_TOKEN", "ghp_file", true},
+		{"NPM_TOKEN", "", false},
+	}
+	for _, tt := range tests {
+		v, ok, err := env.Lookup(context.Background(), tt.name)
+		if err != nil || ok != tt.found || v != tt.value {
+			t.Errorf("%s: expected %q, %v, got %q, %v, %v", tt.name, tt.value, tt.found, v, ok, err)
+		}
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "github_token"), []byte("ghp_mounted\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, ok, err := Dir{Path: dir}.Lookup(context.Background(), "GITHUB_TOKEN")
+	if err != nil || !ok || v != "ghp_mounted" {
+		t.Errorf("Expected ghp_mounted, got %q, %v, %v", v, ok, err)
+	}
+	if _, ok, err := (Dir{Path: dir}).Lookup(context.Background(), "GITLAB_TOKEN"); ok || err != nil {
+		t.Errorf("Expected GITLAB_TOKEN to be missing, got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Vault reads secrets from a key/value (version 2) secret of HashiCorp
+// Vault, whose keys are the secret names. The secret is fetched once, by
+// the first lookup that succeeds.
+type Vault struct {
+	// Address is the Vault server, e.g. "https://vault.example.com:8200".
+	Address string
+	// Token authenticates the requests.
+	Token string
+	// Mount is the path of the secrets engine; empty means "secret".
+	Mount string
+	// Path is the secret within the engine, e.g. "ci/release".
+	Path       string
+	HTTPClient *http.Client
+
+	// mu guards data, set once a fetch succeeds: a failed fetch is
+	// retried by the next lookup.
+	mu      sync.Mutex
+	fetched bool
+	data    map[string]string
+}
+
+func (v *Vault) Lookup(ctx context.Context, name string) (string, bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.fetched {
+		data, err := v.fetch(ctx)
+		if err != nil {
+			return "", false, err
+		}
+		v.data, v.fetched = data, true
+	}
+	s, ok := v.data[name]
+	return s, ok, nil
+}
+
+func (v *Vault) fetch(ctx context.Context) (map[string]string, error) {
+	mount := v.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	url := strings.TrimRight(v.Address, "/") + "/v1/" + strings.Trim(mount, "/") + "/data/" + strings.Trim(v.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return nil, fmt.Errorf("vault: GET %s: %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("vault: decode %s: %w", url, err)
+	}
+	return stringValues(body.Data.Data), nil
+}
+
+// stringValues keeps the string values of m.
+func stringValues(m map[string]any) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVault(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v1/kv/data/ci/release" {
+			t.Errorf("Expected /v1/kv/data/ci/release, got %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			t.Errorf("Expected the Vault token, got %q", r.Header.Get("X-Vault-Token"))
+		}
+		w.Write([]byte(`{"data":{"data":{"GITHUB_TOKEN":"ghp_from_vault"},"metadata":{"version":3}}}`))
+	}))
+	defer srv.Close()
+
+	v := &Vault{Address: srv.URL, Token: "s.token", Mount: "kv", Path: "ci/release"}
+	s, ok, err := v.Lookup(context.Background(), "GITHUB_TOKEN")
+	if err != nil || !ok || s != "ghp_from_vault" {
+		t.Errorf("Expected ghp_from_vault, got %q, %v, %v", s, ok, err)
+	}
+	if _, ok, err := v.Lookup(context.Background(), "GITLAB_TOKEN"); ok || err != nil {
+		t.Errorf("Expected GITLAB_TOKEN to be missing, got %v, %v", ok, err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request, got %d", requests)
+	}
+}
+
+func TestVaultError(t *testing.T) {
+	denied := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if denied {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"GITHUB_TOKEN":"ghp_from_vault"}}}`))
+	}))
+	defer srv.Close()
+
+	v := &Vault{Address: srv.URL, Path: "ci/release"}
+	_, _, err := v.Lookup(context.Background(), "GITHUB_TOKEN")
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected a permission error, got %v", err)
+	}
+
+	denied = false
+	if s, ok, err := v.Lookup(context.Background(), "GITHUB_TOKEN"); err != nil || !ok || s != "ghp_from_vault" {
+		t.Errorf("Expected the failed fetch to be retried, got %q, %v, %v", s, ok, err)
+	}
+}
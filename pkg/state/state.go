@@ -0,0 +1,210 @@
+// Package state records the progress of a release in a local state file, so
+// that an interrupted release can be inspected and resumed.
+//
+// A release moves through the states pending → versioned → tagged → built
+// → published → announced; built is skipped when no artifacts are built.
+// A release made in two phases is drafted before it is published, until it
+// is approved. A release rolled back from any state ends in rolled_back.
+// Every transition is appended to the release's history and to an audit
+// log of JSON lines in the same directory.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// State is a stage of a release.
+type State string
+
+const (
+	Pending   State = "pending"
+	Versioned State = "versioned"
+	Tagged    State = "tagged"
+	Built     State = "built"
+	// Drafted is a release whose provider releases are drafts awaiting
+	// approval.
+	Drafted   State = "drafted"
+	Published State = "published"
+	Announced State = "announced"
+	// RolledBack is a release undone by release rollback.
+	RolledBack State = "rolled_back"
+)
+
+// States lists the states in the order a release goes through them.
+var States = []State{Pending, Versioned, Tagged, Built, Drafted, Published, Announced, RolledBack}
+
+// transitions lists the states each state may move to.
+var transitions = map[State][]State{
+	Pending:   {Versioned, RolledBack},
+	Versioned: {Tagged, RolledBack},
+	Tagged:    {Built, Drafted, Published, RolledBack},
+	Built:     {Drafted, Published, RolledBack},
+	Drafted:   {Published, RolledBack},
+	Published: {Announced, RolledBack},
+	Announced: {RolledBack},
+}
+
+// ErrTransition is returned by Advance for a transition the state machine
+// does not allow, such as publishing a release that was never tagged.
+var ErrTransition = errors.New("invalid state transition")
+
+// before reports whether s comes before t in States.
+func (s State) before(t State) bool {
+	return slices.Index(States, s) < slices.Index(States, t)
+}
+
+// Transition is a change of state, as recorded in the history and the
+// audit log.
+type Transition struct {
+	Tag  string    `json:"tag"`
+	From State     `json:"from"`
+	To   State     `json:"to"`
+	At   time.Time `json:"at"`
+	// Command is the CLI command that made the transition.
+	Command string `json:"command,omitempty"`
+}
+
+// Release is the recorded state of the latest release.
+type Release struct {
+	Tag   string `json:"tag"`
+	State State  `json:"state"`
+	// URLs are the provider releases, once published, or their drafts.
+	URLs []string `json:"urls,omitempty"`
+	// Assets are the files attached to the drafts, for the targets without
+	// drafts to be published with on approval.
+	Assets  []string     `json:"assets,omitempty"`
+	History []Transition `json:"history"`
+}
+
+// Event asks Advance to move the release of Tag to State To.
+type Event struct {
+	Tag     string
+	To      State
+	Command string
+	// URLs replace the recorded release URLs when not empty.
+	URLs []string
+	// Assets replace the recorded assets when not empty.
+	Assets []string
+}
+
+// File names within a Store's Dir.
+const (
+	StateFile = "state.json"
+	AuditFile = "audit.log"
+)
+
+// Store persists the release state in a directory.
+type Store struct {
+	Dir string
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// Load returns the recorded release, or nil when none was recorded.
+func (s *Store) Load() (*Release, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, StateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("state: %w", err)
+	}
+	var r Release
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("state: %s: %w", StateFile, err)
+	}
+	return &r, nil
+}
+
+// Advance applies e and returns the updated release. An event for a tag
+// other than the recorded one starts a new release, which may enter at any
+// state, as when publishing a tag created by hand. For the recorded tag,
+// an event for the current or an earlier state, as when a step is run
+// again, changes nothing, and an event skipping ahead returns
+// ErrTransition.
+func (s *Store) Advance(e Event) (*Release, error) {
+	r, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	if r == nil || r.Tag != e.Tag {
+		r = &Release{Tag: e.Tag, State: Pending}
+	} else if !r.State.before(e.To) {
+		return r, nil
+	} else if !slices.Contains(transitions[r.State], e.To) {
+		return r, fmt.Errorf("%w: %s is %s, cannot become %s", ErrTransition, r.Tag, r.State, e.To)
+	}
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	t := Transition{Tag: e.Tag, From: r.State, To: e.To, At: now().UTC(), Command: e.Command}
+	r.State = e.To
+	r.History = append(r.History, t)
+	if len(e.URLs) > 0 {
+		r.URLs = e.URLs
+	}
+	if len(e.Assets) > 0 {
+		r.Assets = e.Assets
+	}
+
+	if err := s.save(r); err != nil {
+		return nil, err
+	}
+	if err := s.audit(t); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// save writes r to the state file through a temporary file, so that an
+// interruption never leaves a truncated state behind.
+func (s *Store) save(r *Release) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("state: %w", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, StateFile+".*")
+	if err != nil {
+		return fmt.Errorf("state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.Dir, StateFile)); err != nil {
+		return fmt.Errorf("state: %w", err)
+	}
+	return nil
+}
+
+// audit appends t to the audit log.
+func (s *Store) audit(t Transition) error {
+	f, err := os.OpenFile(filepath.Join(s.Dir, AuditFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("state: %w", err)
+	}
+	if err := json.NewEncoder(f).Encode(t); err != nil {
+		f.Close()
+		return fmt.Errorf("state: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &Store{
+		Dir: filepath.Join(t.TempDir(), ".release"),
+		Now: func() time.Time { clock = clock.Add(time.Minute); return clock },
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	s := newStore(t)
+	if r, err := s.Load(); r != nil || err != nil {
+		t.Fatalf("Expected no release, got %v, %v", r, err)
+	}
+
+	for _, to := range []State{Versioned, Tagged, Published} {
+		if _, err := s.Advance(Event{Tag: "v1.0.0", To: to, Command: "test"}); err != nil {
+			t.Fatalf("%s: unexpected error: %v", to, err)
+		}
+	}
+	r, err := s.Advance(Event{Tag: "v1.0.0", To: Announced, URLs: []string{"https://x/r/1"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, r) {
+		t.Errorf("Expected the saved release %+v, got %+v", r, loaded)
+	}
+	if r.State != Announced || !reflect.DeepEqual(r.URLs, []string{"https://x/r/1"}) {
+		t.Errorf("Expected an announced release with its URL, got %+v", r)
+	}
+	var path []State
+	for _, tr := range r.History {
+		path = append(path, tr.From)
+	}
+	if expected := []State{Pending, Versioned, Tagged, Published}; !reflect.DeepEqual(path, expected) {
+		t.Errorf("Expected transitions from %v, got %v", expected, path)
+	}
+	if at := r.History[0].At; !at.Equal(time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC)) {
+		t.Errorf("Expected the first transition at 12:01, got %v", at)
+	}
+}
+
+func TestAdvanceRepeatedAndInvalid(t *testing.T) {
+	s := newStore(t)
+	s.Advance(Event{Tag: "v1.0.0", To: Versioned})
+	s.Advance(Event{Tag: "v1.0.0", To: Tagged})
+
+	r, err := s.Advance(Event{Tag: "v1.0.0", To: Versioned})
+	if err != nil || r.State != Tagged || len(r.History) != 2 {
+		t.Errorf("Expected an earlier state to change nothing, got %+v, %v", r, err)
+	}
+	if _, err := s.Advance(Event{Tag: "v1.0.0", To: Announced}); !errors.Is(err, ErrTransition) {
+		t.Errorf("Expected ErrTransition, got %v", err)
+	}
+
+	r, err = s.Advance(Event{Tag: "v1.0.0", To: RolledBack})
+	if err != nil || r.State != RolledBack {
+		t.Errorf("Expected a tagged release to be rolled back, got %+v, %v", r, err)
+	}
+	if r, _ := s.Advance(Event{Tag: "v1.0.0", To: Published}); r.State != RolledBack {
+		t.Errorf("Expected a rolled back release to stay rolled back, got %+v", r)
+	}
+
+	r, err = s.Advance(Event{Tag: "v1.1.0", To: Published})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.History) != 1 || r.History[0].From != Pending {
+		t.Errorf("Expected a new release to start from pending, got %+v", r.History)
+	}
+}
+
+func TestAdvanceDrafted(t *testing.T) {
+	s := newStore(t)
+	s.Advance(Event{Tag: "v1.0.0", To: Tagged})
+	r, err := s.Advance(Event{Tag: "v1.0.0", To: Drafted, URLs: []string{"https://example.com/draft"}, Assets: []string{"dist/app.tar.gz"}})
+	if err != nil || r.State != Drafted || r.URLs[0] != "https://example.com/draft" || r.Assets[0] != "dist/app.tar.gz" {
+		t.Fatalf("Expected a tagged release to be drafted, got %+v, %v", r, err)
+	}
+	if r, _ := s.Advance(Event{Tag: "v1.0.0", To: Built}); r.State != Drafted {
+		t.Errorf("Expected a drafted release to stay drafted when built again, got %+v", r)
+	}
+	if _, err := s.Advance(Event{Tag: "v1.0.0", To: Announced}); !errors.Is(err, ErrTransition) {
+		t.Errorf("Expected a draft to be published before it is announced, got %v", err)
+	}
+	r, err = s.Advance(Event{Tag: "v1.0.0", To: Published, URLs: []string{"https://example.com/v1.0.0"}})
+	if err != nil || r.State != Published || r.URLs[0] != "https://example.com/v1.0.0" {
+		t.Errorf("Expected an approved draft to be published, got %+v, %v", r, err)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	s := newStore(t)
+	s.Advance(Event{Tag: "v1.0.0", To: Versioned, Command: "tag"})
+	s.Advance(Event{Tag: "v1.0.0", To: Tagged, Command: "tag"})
+	s.Advance(Event{Tag: "v1.0.0", To: Tagged, Command: "tag"})
+	s.Advance(Event{Tag: "v1.1.0", To: Tagged, Command: "tag"})
+
+	f, err := os.Open(filepath.Join(s.Dir, AuditFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+	var got []Transition
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var tr Transition
+		if err := json.Unmarshal(sc.Bytes(), &tr); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, tr)
+	}
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 audit records, got %d", len(got))
+	}
+	if got[2].Tag != "v1.1.0" || got[2].To != Tagged || got[2].Command != "tag" {
+		t.Errorf("Expected the new release in the last record, got %+v", got[2])
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	s := newStore(t)
+	os.MkdirAll(s.Dir, 0o755)
+	os.WriteFile(filepath.Join(s.Dir, StateFile), []byte("{"), 0o644)
+	if _, err := s.Load(); err == nil {
+		t.Errorf("Expected an error for a corrupt state file")
+	}
+}
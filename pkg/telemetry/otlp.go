@@ -0,0 +1,212 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The standard OpenTelemetry environment variables configuring exports.
+const (
+	EnvEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvHeaders  = "OTEL_EXPORTER_OTLP_HEADERS"
+	EnvService  = "OTEL_SERVICE_NAME"
+)
+
+// Exporter sends the metrics and spans of a Recorder to an OpenTelemetry
+// collector with the JSON encoding of OTLP/HTTP.
+type Exporter struct {
+	// Endpoint is the base URL of the collector, such as
+	// http://localhost:4318, to which /v1/metrics and /v1/traces are
+	// appended.
+	Endpoint string
+	// Header is sent with every request, e.g. for an API key.
+	Header http.Header
+	// HTTPClient defaults to http.DefaultClient. It should not be a
+	// Client of the Recorder exported, whose requests would be traced in
+	// turn.
+	HTTPClient *http.Client
+}
+
+// ParseHeaders parses the "key=value,key2=value2" list of EnvHeaders,
+// whose values are URL-encoded.
+func ParseHeaders(s string) (http.Header, error) {
+	h := make(http.Header)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("telemetry: header %q is not key=value", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("telemetry: header %s: %w", strings.TrimSpace(k), err)
+		}
+		h.Add(strings.TrimSpace(k), value)
+	}
+	return h, nil
+}
+
+// Export sends the metrics of r and the spans ended since the last export.
+// Spans that fail to send are not retried.
+func (e *Exporter) Export(ctx context.Context, r *Recorder) error {
+	now := r.now()
+	resource := otlpResource{Attributes: otlpAttrs([]Attr{String("service.name", r.Service)})}
+	scope := otlpScope{Name: "github.com/gbrennon/release_automation_golang/pkg/telemetry"}
+
+	var errs []error
+	if metrics := otlpMetrics(r.snapshot(), r.start, now); len(metrics) > 0 {
+		body := map[string]any{"resourceMetrics": []any{map[string]any{
+			"resource":     resource,
+			"scopeMetrics": []any{map[string]any{"scope": scope, "metrics": metrics}},
+		}}}
+		errs = append(errs, e.post(ctx, "/v1/metrics", body))
+	}
+	if spans := r.TakeSpans(); len(spans) > 0 {
+		body := map[string]any{"resourceSpans": []any{map[string]any{
+			"resource":   resource,
+			"scopeSpans": []any{map[string]any{"scope": scope, "spans": otlpSpans(spans)}},
+		}}}
+		errs = append(errs, e.post(ctx, "/v1/traces", body))
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Exporter) post(ctx context.Context, path string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimSuffix(e.Endpoint, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	for k, vs := range e.Header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := e.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telemetry: export to %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("telemetry: export to %s: %s: %s", endpoint, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// The JSON mapping of OTLP encodes 64-bit integers as strings and trace
+// and span IDs in hexadecimal.
+
+type otlpResource struct {
+	Attributes []otlpAttr `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpAttr struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+func otlpAttrs(attrs []Attr) []otlpAttr {
+	out := make([]otlpAttr, len(attrs))
+	for i, a := range attrs {
+		out[i].Key, out[i].Value.StringValue = a.Key, a.Value
+	}
+	return out
+}
+
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// aggregationCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const aggregationCumulative = 2
+
+func otlpMetrics(snaps []snapshot, start, now time.Time) []any {
+	var out []any
+	for _, m := range snaps {
+		var points []any
+		for _, s := range m.series {
+			p := map[string]any{
+				"attributes":        otlpAttrs(s.attrs),
+				"startTimeUnixNano": nanos(start),
+				"timeUnixNano":      nanos(now),
+			}
+			if !m.histogram {
+				p["asDouble"] = s.value
+				points = append(points, p)
+				continue
+			}
+			counts := make([]string, len(s.counts))
+			for i, n := range s.counts {
+				counts[i] = strconv.FormatUint(n, 10)
+			}
+			p["count"] = strconv.FormatUint(s.count, 10)
+			p["sum"] = s.value
+			p["bucketCounts"] = counts
+			p["explicitBounds"] = m.bounds
+			points = append(points, p)
+		}
+		data := map[string]any{"aggregationTemporality": aggregationCumulative, "dataPoints": points}
+		if m.histogram {
+			out = append(out, map[string]any{"name": m.name, "histogram": data})
+		} else {
+			data["isMonotonic"] = true
+			out = append(out, map[string]any{"name": m.name, "sum": data})
+		}
+	}
+	return out
+}
+
+// OTLP enum values: SPAN_KIND_INTERNAL and STATUS_CODE_ERROR.
+const (
+	spanKindInternal = 1
+	statusError      = 2
+)
+
+func otlpSpans(spans []SpanData) []any {
+	out := make([]any, len(spans))
+	for i, s := range spans {
+		span := map[string]any{
+			"traceId":           hex.EncodeToString(s.TraceID[:]),
+			"spanId":            hex.EncodeToString(s.SpanID[:]),
+			"name":              s.Name,
+			"kind":              spanKindInternal,
+			"startTimeUnixNano": nanos(s.Start),
+			"endTimeUnixNano":   nanos(s.End),
+			"attributes":        otlpAttrs(s.Attrs),
+		}
+		if s.ParentID != [8]byte{} {
+			span["parentSpanId"] = hex.EncodeToString(s.ParentID[:])
+		}
+		if s.Err != "" {
+			span["status"] = map[string]any{"code": statusError, "message": s.Err}
+		}
+		out[i] = span
+	}
+	return out
+}
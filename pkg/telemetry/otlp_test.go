@@ -0,0 +1,113 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHeaders(t *testing.T) {
+	h, err := ParseHeaders("api-key=abc%3D, x-team = release ,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.Get("Api-Key") != "abc=" || h.Get("X-Team") != "release" {
+		t.Errorf("Unexpected headers %v", h)
+	}
+	if _, err := ParseHeaders("novalue"); err == nil {
+		t.Errorf("Expected an error for a header without a value")
+	}
+}
+
+func TestExport(t *testing.T) {
+	bodies := make(map[string]map[string]any)
+	var key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Api-Key")
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		bodies[r.URL.Path] = body
+	}))
+	defer srv.Close()
+
+	r := NewRecorder("release")
+	r.now = func() time.Time { return time.Unix(10, 0) }
+	r.start = time.Unix(5, 0)
+	r.Buckets = []float64{1}
+	r.Add(Failures, 1, String("stage", "tag"))
+	r.Observe(APIDuration, 0.5, String("host", "api.github.com"))
+	ctx, parent := r.Start(context.Background(), "release tag")
+	_, child := r.Start(ctx, "HTTP GET")
+	child.End(nil)
+	parent.End(errors.New("canceled"))
+
+	e := &Exporter{Endpoint: srv.URL + "/", Header: http.Header{"Api-Key": {"abc"}}}
+	if err := e.Export(context.Background(), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "abc" {
+		t.Errorf("Expected the header to be sent, got %q", key)
+	}
+
+	metrics, _ := json.Marshal(bodies["/v1/metrics"])
+	for _, want := range []string{
+		`"service.name"`,
+		`"histogram":{"aggregationTemporality":2`,
+		`"name":"release_api_request_duration_seconds"`,
+		`"bucketCounts":["1","0"]`,
+		`"count":"1"`,
+		`"explicitBounds":[1]`,
+		`"startTimeUnixNano":"5000000000"`,
+		`"name":"release_failures_total","sum":{"aggregationTemporality":2`,
+		`"asDouble":1`,
+		`"isMonotonic":true`,
+	} {
+		if !strings.Contains(string(metrics), want) {
+			t.Errorf("Expected %s in the metrics, got %s", want, metrics)
+		}
+	}
+
+	traces, _ := json.Marshal(bodies["/v1/traces"])
+	for _, want := range []string{
+		`"name":"HTTP GET","parentSpanId":"`,
+		`"status":{"code":2,"message":"canceled"}`,
+		`"endTimeUnixNano":"10000000000"`,
+	} {
+		if !strings.Contains(string(traces), want) {
+			t.Errorf("Expected %s in the traces, got %s", want, traces)
+		}
+	}
+
+	bodies = make(map[string]map[string]any)
+	if err := e.Export(context.Background(), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := bodies["/v1/traces"]; ok {
+		t.Errorf("Expected the spans to be exported once")
+	}
+	if _, ok := bodies["/v1/metrics"]; !ok {
+		t.Errorf("Expected the cumulative metrics to be exported again")
+	}
+}
+
+func TestExportError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	r := NewRecorder("release")
+	r.Add(Failures, 1)
+	err := (&Exporter{Endpoint: srv.URL}).Export(context.Background(), r)
+	if err == nil || !strings.Contains(err.Error(), "429 Too Many Requests: quota exceeded") {
+		t.Errorf("Expected the collector's error, got %v", err)
+	}
+}
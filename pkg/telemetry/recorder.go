@@ -0,0 +1,277 @@
+package telemetry
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds of histograms, in seconds, from API
+// calls of a few milliseconds to releases of several minutes.
+var DefaultBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// DefaultMaxSpans is the number of unexported spans a Recorder keeps.
+const DefaultMaxSpans = 1000
+
+// SpanData is a finished span.
+type SpanData struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	// ParentID is zero for the root span of a trace.
+	ParentID [8]byte
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Attrs    []Attr
+	// Err is the error the span ended with, or "".
+	Err string
+}
+
+// Recorder is a Telemetry keeping metrics and spans in memory. Metrics are
+// cumulative since the Recorder was created; spans are kept until taken by
+// an Exporter, the oldest dropped beyond MaxSpans.
+type Recorder struct {
+	// Service is the service.name of the exported resources.
+	Service string
+	// Buckets are the upper bounds of the histograms created from now on;
+	// nil means DefaultBuckets.
+	Buckets []float64
+	// MaxSpans bounds the spans kept; 0 means DefaultMaxSpans.
+	MaxSpans int
+
+	now     func() time.Time
+	start   time.Time
+	mu      sync.Mutex
+	metrics map[string]*metric
+	spans   []SpanData
+}
+
+type metric struct {
+	histogram bool
+	bounds    []float64
+	series    map[string]*series
+}
+
+// series is a metric with one set of attributes. For histograms, value is
+// the sum of the observations and counts holds those of each bucket, the
+// last one past every bound.
+type series struct {
+	attrs  []Attr
+	value  float64
+	count  uint64
+	counts []uint64
+}
+
+// NewRecorder returns an empty Recorder of service.
+func NewRecorder(service string) *Recorder {
+	r := &Recorder{Service: service, now: time.Now, metrics: make(map[string]*metric)}
+	r.start = r.now()
+	return r
+}
+
+// Add adds n to the counter name.
+func (r *Recorder) Add(name string, n float64, attrs ...Attr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s := r.series(name, false, attrs); s != nil {
+		s.value += n
+	}
+}
+
+// Observe records v in the histogram name.
+func (r *Recorder) Observe(name string, v float64, attrs ...Attr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.series(name, true, attrs)
+	if s == nil {
+		return
+	}
+	i, _ := slices.BinarySearch(r.metrics[name].bounds, v)
+	s.counts[i]++
+	s.count++
+	s.value += v
+}
+
+// series returns the series of name with attrs, creating it as needed. It
+// returns nil when name is already a metric of the other kind. r.mu must
+// be held.
+func (r *Recorder) series(name string, histogram bool, attrs []Attr) *series {
+	m := r.metrics[name]
+	if m == nil {
+		m = &metric{histogram: histogram, series: make(map[string]*series)}
+		if histogram {
+			m.bounds = DefaultBuckets
+			if r.Buckets != nil {
+				m.bounds = slices.Sorted(slices.Values(r.Buckets))
+			}
+		}
+		r.metrics[name] = m
+	}
+	if m.histogram != histogram {
+		return nil
+	}
+	attrs = sorted(attrs)
+	key := seriesKey(attrs)
+	s := m.series[key]
+	if s == nil {
+		s = &series{attrs: attrs}
+		if histogram {
+			s.counts = make([]uint64, len(m.bounds)+1)
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+func seriesKey(attrs []Attr) string {
+	var b strings.Builder
+	for _, a := range attrs {
+		b.WriteString(strconv.Quote(a.Key) + "=" + strconv.Quote(a.Value) + ",")
+	}
+	return b.String()
+}
+
+type spanKey struct{}
+
+type span struct {
+	r    *Recorder
+	mu   sync.Mutex
+	data SpanData
+}
+
+// Start starts a span, in the trace of the span of ctx if any.
+func (r *Recorder) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span) {
+	s := &span{r: r, data: SpanData{Name: name, Start: r.now(), Attrs: slices.Clone(attrs)}}
+	rand.Read(s.data.SpanID[:])
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok {
+		s.data.TraceID, s.data.ParentID = parent.data.TraceID, parent.data.SpanID
+	} else {
+		rand.Read(s.data.TraceID[:])
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (s *span) SetAttrs(attrs ...Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attrs = append(s.data.Attrs, attrs...)
+}
+
+func (s *span) End(err error) {
+	s.mu.Lock()
+	d := s.data
+	s.mu.Unlock()
+	d.End = s.r.now()
+	if err != nil {
+		d.Err = err.Error()
+	}
+
+	r := s.r
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, d)
+	if limit := cmp.Or(r.MaxSpans, DefaultMaxSpans); len(r.spans) > limit {
+		r.spans = slices.Delete(r.spans, 0, len(r.spans)-limit)
+	}
+}
+
+// TakeSpans returns the spans ended since the last call and forgets them.
+func (r *Recorder) TakeSpans() []SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := r.spans
+	r.spans = nil
+	return spans
+}
+
+// snapshot is a copy of a metric for rendering outside of r.mu.
+type snapshot struct {
+	name      string
+	histogram bool
+	bounds    []float64
+	series    []series
+}
+
+// snapshot copies the metrics, sorted by name and their series by
+// attributes.
+func (r *Recorder) snapshot() []snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []snapshot
+	for _, name := range slices.Sorted(maps.Keys(r.metrics)) {
+		m := r.metrics[name]
+		snap := snapshot{name: name, histogram: m.histogram, bounds: m.bounds}
+		for _, key := range slices.Sorted(maps.Keys(m.series)) {
+			s := *m.series[key]
+			s.counts = slices.Clone(s.counts)
+			snap.series = append(snap.series, s)
+		}
+		out = append(out, snap)
+	}
+	return out
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format.
+func (r *Recorder) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, m := range r.snapshot() {
+		if !m.histogram {
+			fmt.Fprintf(bw, "# TYPE %s counter\n", m.name)
+			for _, s := range m.series {
+				fmt.Fprintf(bw, "%s%s %s\n", m.name, labels(s.attrs), formatFloat(s.value))
+			}
+			continue
+		}
+		fmt.Fprintf(bw, "# TYPE %s histogram\n", m.name)
+		for _, s := range m.series {
+			var cumulative uint64
+			for i, n := range s.counts {
+				cumulative += n
+				le := "+Inf"
+				if i < len(m.bounds) {
+					le = formatFloat(m.bounds[i])
+				}
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", m.name, labels(append(slices.Clone(s.attrs), String("le", le))), cumulative)
+			}
+			fmt.Fprintf(bw, "%s_sum%s %s\n", m.name, labels(s.attrs), formatFloat(s.value))
+			fmt.Fprintf(bw, "%s_count%s %d\n", m.name, labels(s.attrs), s.count)
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler serves the metrics to Prometheus.
+func (r *Recorder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WritePrometheus(w)
+	})
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(attrs []Attr) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		parts[i] = a.Key + `="` + labelEscaper.Replace(a.Value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNop(t *testing.T) {
+	ctx := context.Background()
+	result, span := Nop.Start(ctx, "tag")
+	span.SetAttrs(String("k", "v"))
+	span.End(errors.New("boom"))
+	Nop.Add(Failures, 1)
+	Nop.Observe(CommandDuration, 1)
+	if result != ctx {
+		t.Errorf("Expected the context unchanged")
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRecorder("release")
+	r.Buckets = []float64{1, 0.5}
+	r.Observe(CommandDuration, 0.2, String("command", "tag"), String("outcome", "success"))
+	r.Observe(CommandDuration, 0.5, String("outcome", "success"), String("command", "tag"))
+	r.Observe(CommandDuration, 3, String("command", "tag"), String("outcome", "success"))
+	r.Add(Failures, 1, String("stage", "publish"), String("kind", "provider"))
+	r.Add(Failures, 2, String("stage", "publish"), String("kind", "provider"))
+	r.Add(Failures, 1, String("stage", `say "hi"`))
+	r.Observe(Failures, 1)
+
+	var b strings.Builder
+	if err := r.WritePrometheus(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `# TYPE release_command_duration_seconds histogram
+release_command_duration_seconds_bucket{command="tag",outcome="success",le="0.5"} 2
+release_command_duration_seconds_bucket{command="tag",outcome="success",le="1"} 2
+release_command_duration_seconds_bucket{command="tag",outcome="success",le="+Inf"} 3
+release_command_duration_seconds_sum{command="tag",outcome="success"} 3.7
+release_command_duration_seconds_count{command="tag",outcome="success"} 3
+# TYPE release_failures_total counter
+release_failures_total{kind="provider",stage="publish"} 3
+release_failures_total{stage="say \"hi\""} 1
+`
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Body.String() != expected || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Expected the metrics served as text, got %q: %s", rec.Header().Get("Content-Type"), rec.Body)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	r := NewRecorder("release")
+	clock := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	ctx, root := r.Start(context.Background(), "release publish", String("command", "publish"))
+	_, child := r.Start(ctx, "HTTP POST")
+	child.SetAttrs(String("http.response.status_code", "500"))
+	child.End(errors.New("server error"))
+	root.End(nil)
+
+	spans := r.TakeSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.Name != "HTTP POST" || c.Err != "server error" || len(c.Attrs) != 1 {
+		t.Errorf("Unexpected child span %+v", c)
+	}
+	if c.TraceID != p.TraceID || c.ParentID != p.SpanID || p.ParentID != [8]byte{} {
+		t.Errorf("Expected the child in the trace of its parent, got %+v and %+v", c, p)
+	}
+	if p.Err != "" || p.End.Sub(p.Start) != 3*time.Second {
+		t.Errorf("Unexpected parent span %+v", p)
+	}
+	if spans := r.TakeSpans(); len(spans) != 0 {
+		t.Errorf("Expected the spans to be taken, got %d", len(spans))
+	}
+
+	r.MaxSpans = 2
+	for _, name := range []string{"a", "b", "c"} {
+		_, s := r.Start(context.Background(), name)
+		s.End(nil)
+	}
+	if spans := r.TakeSpans(); len(spans) != 2 || spans[0].Name != "b" {
+		t.Errorf("Expected the oldest span dropped, got %+v", spans)
+	}
+}
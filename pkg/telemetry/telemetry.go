@@ -0,0 +1,93 @@
+// Package telemetry records the metrics and traces of releases: how long
+// commands and asset uploads take, the latency of API calls and the
+// failures of each stage.
+//
+// Code reports through the Telemetry interface, whose Nop default records
+// nothing, so library users pay nothing unless they enable it. A Recorder
+// aggregates metrics in memory, serves them in the Prometheus text format
+// and keeps finished spans, which an Exporter sends with the metrics to an
+// OpenTelemetry collector over OTLP/HTTP.
+package telemetry
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// Metrics recorded by the release CLI.
+const (
+	// CommandDuration is a histogram of the duration of commands, by
+	// command and outcome: "success" or the kind of the error.
+	CommandDuration = "release_command_duration_seconds"
+	// UploadDuration is a histogram of the duration of asset uploads, by
+	// outcome: "success" or "failure".
+	UploadDuration = "release_asset_upload_duration_seconds"
+	// APIDuration is a histogram of the latency of API calls, by host,
+	// method and status code, "error" when no response came back.
+	APIDuration = "release_api_request_duration_seconds"
+	// Failures counts the failed commands, by stage, the command that
+	// failed, and by kind of error.
+	Failures = "release_failures_total"
+)
+
+// Attr is an attribute of a span or metric, a label in Prometheus.
+type Attr struct {
+	Key   string
+	Value string
+}
+
+// String returns the Attr key=value.
+func String(key, value string) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Telemetry records metrics and traces. Implementations are safe for
+// concurrent use.
+type Telemetry interface {
+	// Start starts a span named name, a child of the span of ctx if any,
+	// and returns a context carrying it.
+	Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span)
+	// Add adds n to the counter name.
+	Add(name string, n float64, attrs ...Attr)
+	// Observe records v, in seconds for durations, in the histogram name.
+	Observe(name string, v float64, attrs ...Attr)
+}
+
+// Span is an operation being traced.
+type Span interface {
+	// SetAttrs adds attributes known once the operation is under way.
+	SetAttrs(attrs ...Attr)
+	// End finishes the span, as failed when err is not nil.
+	End(err error)
+}
+
+// Nop records nothing.
+var Nop Telemetry = nop{}
+
+type nop struct{}
+
+func (nop) Start(ctx context.Context, _ string, _ ...Attr) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+func (nop) Add(string, float64, ...Attr)     {}
+func (nop) Observe(string, float64, ...Attr) {}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttrs(...Attr) {}
+func (nopSpan) End(error)        {}
+
+// sorted returns a copy of attrs sorted by key, the later of duplicate keys
+// winning.
+func sorted(attrs []Attr) []Attr {
+	out := slices.Clone(attrs)
+	slices.SortStableFunc(out, func(a, b Attr) int { return strings.Compare(a.Key, b.Key) })
+	for i := len(out) - 1; i > 0; i-- {
+		if out[i-1].Key == out[i].Key {
+			out = slices.Delete(out, i-1, i)
+		}
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package telemetry
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Transport is an http.RoundTripper recording the latency of each request
+// as APIDuration, by host, method and status, in a span of its own.
+type Transport struct {
+	Telemetry Telemetry
+	// Base sends the requests; nil means http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// Client returns an http.Client whose requests are recorded by t.
+func Client(t Telemetry) *http.Client {
+	return &http.Client{Transport: &Transport{Telemetry: t}}
+}
+
+// RoundTrip sends req with Base and records it.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx, span := t.Telemetry.Start(req.Context(), "HTTP "+req.Method,
+		String("http.request.method", req.Method),
+		String("server.address", req.URL.Host),
+		String("url.path", req.URL.Path))
+	start := time.Now()
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+		span.SetAttrs(String("http.response.status_code", status))
+	}
+	t.Telemetry.Observe(APIDuration, time.Since(start).Seconds(),
+		String("host", req.URL.Host), String("method", req.Method), String("status", status))
+	span.End(err)
+	return resp, err
+}
@@ -0,0 +1,54 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	r := NewRecorder("release")
+	ctx, parent := r.Start(context.Background(), "release publish")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/repos/o/r/releases", nil)
+	resp, err := Client(r).Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	parent.End(nil)
+
+	var b strings.Builder
+	r.WritePrometheus(&b)
+	host := strings.TrimPrefix(srv.URL, "http://")
+	want := `release_api_request_duration_seconds_count{host="` + host + `",method="POST",status="201"} 1`
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("Expected %s, got:\n%s", want, b.String())
+	}
+
+	spans := r.TakeSpans()
+	if len(spans) != 2 || spans[0].Name != "HTTP POST" || spans[0].ParentID != spans[1].SpanID {
+		t.Fatalf("Expected the request traced under its caller, got %+v", spans)
+	}
+	if a := spans[0].Attrs[len(spans[0].Attrs)-1]; a != String("http.response.status_code", "201") {
+		t.Errorf("Expected the status on the span, got %v", a)
+	}
+
+	if _, err := Client(r).Get("http://127.0.0.1:0/"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	b.Reset()
+	r.WritePrometheus(&b)
+	if !strings.Contains(b.String(), `method="GET",status="error"} 1`) {
+		t.Errorf("Expected the failed request recorded, got:\n%s", b.String())
+	}
+	if spans := r.TakeSpans(); len(spans) != 1 || spans[0].Err == "" {
+		t.Errorf("Expected a failed span, got %+v", spans)
+	}
+}
@@ -0,0 +1,149 @@
+// Package timeline keeps the provenance of releases inside the repository:
+// the version computed, the commits released, the provider releases made
+// and when each step happened, as a git note on the tagged commit.
+//
+// The note of a commit is a JSON Note holding one Record per tag, as the
+// modules of a monorepo released together share a commit. Notes live in
+// their own ref, DefaultRef unless configured, so they travel with the
+// repository when that ref is pushed and fetched.
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultRef is the notes ref the records are kept in.
+const DefaultRef = "refs/notes/release"
+
+// Steps of a release, as recorded in its events.
+const (
+	Tagged    = "tagged"
+	Drafted   = "drafted"
+	Published = "published"
+)
+
+// Note is the content of the git note of a commit.
+type Note struct {
+	Releases []Record `json:"releases"`
+}
+
+// Record is the metadata of the release of one tag. Its fields are only
+// ever added, so older notes keep parsing.
+type Record struct {
+	Tag     string `json:"tag"`
+	Version string `json:"version,omitempty"`
+	Module  string `json:"module,omitempty"`
+	// Commit is the tagged commit the note is attached to.
+	Commit      string `json:"commit"`
+	PreviousTag string `json:"previous_tag,omitempty"`
+	// Range is the revision range of the commits released, such as
+	// "v1.2.0..v1.3.0", or the tag alone for a first release.
+	Range   string `json:"range,omitempty"`
+	Commits int    `json:"commits,omitempty"`
+	Bump    string `json:"bump,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	// Releases are the provider releases, the latest outcome per provider
+	// and repository.
+	Releases []Release `json:"releases,omitempty"`
+	// Events are the steps of the release, oldest first.
+	Events []Event `json:"events"`
+}
+
+// Release is the outcome of publishing on one provider.
+type Release struct {
+	Provider string   `json:"provider"`
+	Repo     string   `json:"repo,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Draft    bool     `json:"draft,omitempty"`
+	Assets   []string `json:"assets,omitempty"`
+}
+
+// Event is a step of a release.
+type Event struct {
+	Step string    `json:"step"`
+	At   time.Time `json:"at"`
+	// Command is the CLI command that made the step.
+	Command string `json:"command,omitempty"`
+}
+
+// Parse reads a note. Empty data is an empty note.
+func Parse(data []byte) (Note, error) {
+	var n Note
+	if len(bytes.TrimSpace(data)) == 0 {
+		return n, nil
+	}
+	if err := json.Unmarshal(data, &n); err != nil {
+		return Note{}, fmt.Errorf("timeline: %w", err)
+	}
+	return n, nil
+}
+
+// Marshal returns the note as indented JSON, ending with a newline.
+func (n Note) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Find returns the record of tag, or nil when the note has none.
+func (n *Note) Find(tag string) *Record {
+	for i := range n.Releases {
+		if n.Releases[i].Tag == tag {
+			return &n.Releases[i]
+		}
+	}
+	return nil
+}
+
+// Record returns the record of tag, adding an empty one when the note has
+// none.
+func (n *Note) Record(tag string) *Record {
+	if r := n.Find(tag); r != nil {
+		return r
+	}
+	n.Releases = append(n.Releases, Record{Tag: tag})
+	return &n.Releases[len(n.Releases)-1]
+}
+
+// Add records that step happened at at.
+func (r *Record) Add(step, command string, at time.Time) {
+	r.Events = append(r.Events, Event{Step: step, At: at.UTC(), Command: command})
+}
+
+// SetRelease records the outcome of publishing on rel.Provider and
+// rel.Repo, replacing an earlier one, such as the draft of a release
+// since approved.
+func (r *Record) SetRelease(rel Release) {
+	i := slices.IndexFunc(r.Releases, func(o Release) bool { return o.Provider == rel.Provider && o.Repo == rel.Repo })
+	if i < 0 {
+		r.Releases = append(r.Releases, rel)
+		return
+	}
+	r.Releases[i] = rel
+}
+
+// At returns when step last happened, and false when it never did.
+func (r *Record) At(step string) (time.Time, bool) {
+	for _, e := range slices.Backward(r.Events) {
+		if e.Step == step {
+			return e.At, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Started returns when the first step of r happened, or the zero time
+// without events.
+func (r *Record) Started() time.Time {
+	if len(r.Events) == 0 {
+		return time.Time{}
+	}
+	return r.Events[0].At
+}
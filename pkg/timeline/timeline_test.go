@@ -0,0 +1,67 @@
+package timeline
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNoteRoundTrip(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	var n Note
+	r := n.Record("v1.3.0")
+	r.Version, r.Range, r.Commits = "1.3.0", "v1.2.0..v1.3.0", 4
+	r.Add(Tagged, "tag", at)
+	r.SetRelease(Release{Provider: "github", Repo: "octo/app", URL: "https://github.com/octo/app/releases/tag/v1.3.0", Draft: true})
+	r.SetRelease(Release{Provider: "github", Repo: "octo/app", URL: "https://github.com/octo/app/releases/tag/v1.3.0"})
+	r.Add(Published, "approve", at.Add(time.Hour))
+	n.Record("app/v0.2.0").Add(Tagged, "tag", at)
+
+	data, err := n.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(string(data), "}\n") || !strings.Contains(string(data), `"at": "2026-03-01T11:00:00Z"`) {
+		t.Errorf("Expected indented JSON in UTC, got %s", data)
+	}
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Releases) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(got.Releases))
+	}
+	rec := got.Find("v1.3.0")
+	if rec == nil || rec.Range != "v1.2.0..v1.3.0" || rec.Commits != 4 {
+		t.Fatalf("Expected the record of v1.3.0, got %+v", rec)
+	}
+	if len(rec.Releases) != 1 || rec.Releases[0].Draft {
+		t.Errorf("Expected the draft replaced by the published release, got %+v", rec.Releases)
+	}
+	if when, ok := rec.At(Published); !ok || !when.Equal(at.Add(time.Hour)) {
+		t.Errorf("Expected published at %v, got %v (%v)", at.Add(time.Hour), when, ok)
+	}
+	if _, ok := rec.At(Drafted); ok {
+		t.Errorf("Expected no drafted step")
+	}
+	if !rec.Started().Equal(at) {
+		t.Errorf("Expected started at %v, got %v", at, rec.Started())
+	}
+	if got.Find("v9.9.9") != nil {
+		t.Errorf("Expected no record of an unknown tag")
+	}
+}
+
+func TestParse(t *testing.T) {
+	n, err := Parse([]byte("\n"))
+	if err != nil || len(n.Releases) != 0 {
+		t.Errorf("Expected an empty note, got %+v and %v", n, err)
+	}
+	if _, err := Parse([]byte("released v1.0.0 by hand")); err == nil {
+		t.Errorf("Expected an error for a note that is not JSON")
+	}
+	var empty Record
+	if !empty.Started().IsZero() {
+		t.Errorf("Expected no start time without events")
+	}
+}
@@ -0,0 +1,307 @@
+// Package jira looks up issues through the Jira REST API
+// (https://developer.atlassian.com/server/jira/platform/rest/v10000/) and
+// marks them released: their fix version set to the release, and their
+// workflow moved on to a released status.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/dryrun"
+	"github.com/gbrennon/release_automation_golang/pkg/log"
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+	"github.com/gbrennon/release_automation_golang/pkg/tracker"
+)
+
+const (
+	// UserEnv is the secret NewFromSecrets reads the account of an API
+	// token from, its email on Jira Cloud. Without it, the token is sent
+	// as a personal access token of Jira Server or Data Center.
+	UserEnv = "JIRA_USER"
+	// TokenEnv is the secret NewFromSecrets reads the token from.
+	TokenEnv = "JIRA_TOKEN"
+)
+
+// ErrNoToken is returned by NewFromSecrets when no token is configured.
+var ErrNoToken = errors.New("jira: no token in " + TokenEnv)
+
+// Client talks to a Jira instance.
+type Client struct {
+	// BaseURL is the root of the instance, e.g.
+	// "https://example.atlassian.net".
+	BaseURL string
+	// User, when set, sends Token with basic authentication, as Jira Cloud
+	// takes API tokens. Otherwise Token is sent as a bearer token.
+	User       string
+	Token      string
+	HTTPClient *http.Client
+	// Retry governs retries of requests failing with 429, 5xx or a network
+	// error. The zero value sends each request once.
+	Retry retry.Policy
+
+	// Transition names the workflow transition Release moves issues
+	// through, or the status it leads to, e.g. "Released"; empty leaves
+	// the status alone.
+	Transition string
+	// FixVersion has Release add the release to the fix versions of the
+	// issues, creating the version in their projects first.
+	FixVersion bool
+
+	// DryRun describes the changes Release would make on Log instead of
+	// making them (see package dryrun). Issues are still looked up.
+	DryRun bool
+	Log    io.Writer
+	// Logger receives a debug record for every API request; nil discards.
+	Logger *slog.Logger
+}
+
+var (
+	_ tracker.Tracker  = (*Client)(nil)
+	_ tracker.Releaser = (*Client)(nil)
+)
+
+// New returns a Client for the instance at baseURL authenticated with
+// token, sent by user when user is not empty.
+func New(baseURL, user, token string) *Client {
+	return &Client{
+		BaseURL:    baseURL,
+		User:       user,
+		Token:      token,
+		HTTPClient: http.DefaultClient,
+		Retry:      retry.Default(),
+		Log:        io.Discard,
+	}
+}
+
+// NewFromSecrets returns a Client for the instance at baseURL with the
+// credentials looked up by secret, which is asked for UserEnv and TokenEnv.
+func NewFromSecrets(baseURL string, secret func(name string) string) (*Client, error) {
+	if baseURL == "" {
+		return nil, errors.New("jira: no base URL configured")
+	}
+	c := New(baseURL, secret(UserEnv), secret(TokenEnv))
+	if c.Token == "" {
+		return nil, ErrNoToken
+	}
+	return c, nil
+}
+
+type issueResponse struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary string `json:"summary"`
+		Status  struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		IssueType struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
+	} `json:"fields"`
+}
+
+// Issue looks up the issue key. It fails with tracker.ErrNotFound for a
+// key Jira has no issue for, or none the credentials may browse.
+func (c *Client) Issue(ctx context.Context, key string) (tracker.Issue, error) {
+	var resp issueResponse
+	if err := c.do(ctx, http.MethodGet, c.issueURL(key, "?fields=summary,status,issuetype"), nil, &resp); err != nil {
+		return tracker.Issue{}, fmt.Errorf("jira: issue %s: %w", key, err)
+	}
+	return tracker.Issue{
+		Key:     resp.Key,
+		Summary: resp.Fields.Summary,
+		Status:  resp.Fields.Status.Name,
+		Type:    resp.Fields.IssueType.Name,
+		URL:     strings.TrimRight(c.BaseURL, "/") + "/browse/" + resp.Key,
+	}, nil
+}
+
+// Release marks the issues keys released in version: version is added to
+// their fix versions when c.FixVersion is set, and they are moved through
+// c.Transition when it is set. An issue already in the status of the
+// transition is left there. Every issue is tried; the errors are joined.
+func (c *Client) Release(ctx context.Context, version string, keys []string) error {
+	var errs []error
+	if c.FixVersion {
+		done := make(map[string]bool)
+		for _, key := range keys {
+			project, _, _ := strings.Cut(key, "-")
+			if done[project] {
+				continue
+			}
+			done[project] = true
+			if err := c.ensureVersion(ctx, project, version); err != nil {
+				errs = append(errs, fmt.Errorf("jira: version %s of %s: %w", version, project, err))
+			}
+		}
+	}
+	for _, key := range keys {
+		if c.FixVersion {
+			if err := c.addFixVersion(ctx, key, version); err != nil {
+				errs = append(errs, fmt.Errorf("jira: fix version of %s: %w", key, err))
+			}
+		}
+		if c.Transition != "" {
+			if err := c.transition(ctx, key); err != nil {
+				errs = append(errs, fmt.Errorf("jira: transition %s to %s: %w", key, c.Transition, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type version struct {
+	Name    string `json:"name"`
+	Project string `json:"project,omitempty"`
+}
+
+// ensureVersion creates version in project unless it has it already.
+func (c *Client) ensureVersion(ctx context.Context, project, name string) error {
+	var versions []version
+	endpoint := c.url("/rest/api/2/project/" + url.PathEscape(project) + "/versions")
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &versions); err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v.Name == name {
+			return nil
+		}
+	}
+	if c.DryRun {
+		dryrun.Printf(c.Log, "would create Jira version %s in %s", name, project)
+		return nil
+	}
+	return c.do(ctx, http.MethodPost, c.url("/rest/api/2/version"), version{Name: name, Project: project}, nil)
+}
+
+func (c *Client) addFixVersion(ctx context.Context, key, name string) error {
+	if c.DryRun {
+		dryrun.Printf(c.Log, "would add fix version %s to %s", name, key)
+		return nil
+	}
+	update := map[string]any{"update": map[string]any{
+		"fixVersions": []any{map[string]any{"add": map[string]string{"name": name}}},
+	}}
+	return c.do(ctx, http.MethodPut, c.issueURL(key, ""), update, nil)
+}
+
+type transitionsResponse struct {
+	Transitions []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		To   struct {
+			Name string `json:"name"`
+		} `json:"to"`
+	} `json:"transitions"`
+}
+
+// transition moves key through the transition named c.Transition, or
+// leading to the status of that name.
+func (c *Client) transition(ctx context.Context, key string) error {
+	issue, err := c.Issue(ctx, key)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(issue.Status, c.Transition) {
+		return nil
+	}
+	var resp transitionsResponse
+	if err := c.do(ctx, http.MethodGet, c.issueURL(key, "/transitions"), nil, &resp); err != nil {
+		return err
+	}
+	var id string
+	for _, t := range resp.Transitions {
+		if strings.EqualFold(t.Name, c.Transition) || strings.EqualFold(t.To.Name, c.Transition) {
+			id = t.ID
+			break
+		}
+	}
+	if id == "" {
+		return fmt.Errorf("no such transition from %s", issue.Status)
+	}
+	if c.DryRun {
+		dryrun.Printf(c.Log, "would move %s from %s to %s", key, issue.Status, c.Transition)
+		return nil
+	}
+	body := map[string]any{"transition": map[string]string{"id": id}}
+	return c.do(ctx, http.MethodPost, c.issueURL(key, "/transitions"), body, nil)
+}
+
+func (c *Client) issueURL(key, suffix string) string {
+	return c.url("/rest/api/2/issue/" + url.PathEscape(key) + suffix)
+}
+
+func (c *Client) url(path string) string {
+	return strings.TrimRight(c.BaseURL, "/") + path
+}
+
+// do sends a request with in as its JSON body, retrying it according to
+// c.Retry, and decodes the response into out unless it is nil.
+func (c *Client) do(ctx context.Context, method, endpoint string, in, out any) error {
+	var payload []byte
+	if in != nil {
+		var err error
+		if payload, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	return c.Retry.Do(ctx, func(ctx context.Context) error {
+		return c.send(ctx, method, endpoint, payload, out)
+	})
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte, out any) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	log.Or(c.Logger).Debug("jira API request", "method", method, "url", endpoint, "status", resp.StatusCode)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, endpoint, tracker.ErrNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		err := fmt.Errorf("%s %s: %s: %s", method, endpoint, resp.Status, strings.TrimSpace(string(msg)))
+		if retry.HTTPStatus(resp.StatusCode) {
+			return retry.After(err, retry.RetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+		}
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,204 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/retry"
+	"github.com/gbrennon/release_automation_golang/pkg/tracker"
+)
+
+// fakeJira serves the issues of project PROJ, which has the versions
+// listed, and records the changes requested.
+type fakeJira struct {
+	statuses map[string]string
+	versions []string
+	requests []string
+}
+
+func (f *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	path := strings.TrimPrefix(r.URL.Path, "/rest/api/2/")
+	switch {
+	case r.Method == http.MethodGet && path == "project/PROJ/versions":
+		var vs []version
+		for _, v := range f.versions {
+			vs = append(vs, version{Name: v})
+		}
+		json.NewEncoder(w).Encode(vs)
+		return
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/transitions"):
+		io.WriteString(w, `{"transitions": [{"id": "11", "name": "Start", "to": {"name": "In Progress"}}, {"id": "31", "name": "Ship", "to": {"name": "Released"}}]}`)
+		return
+	case r.Method == http.MethodGet:
+		key := strings.TrimPrefix(path, "issue/")
+		status, ok := f.statuses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"key": key, "fields": map[string]any{
+			"summary":   "Summary of " + key,
+			"status":    map[string]string{"name": status},
+			"issuetype": map[string]string{"name": "Bug"},
+		}})
+		return
+	}
+	f.requests = append(f.requests, r.Method+" "+path+" "+strings.TrimSpace(string(body)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestIssue(t *testing.T) {
+	var auth string
+	f := &fakeJira{statuses: map[string]string{"PROJ-1": "Done"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.URL.Query().Get("fields") != "summary,status,issuetype" {
+			t.Errorf("Expected the fields asked for, got %q", r.URL.RawQuery)
+		}
+		f.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/", "", "pat")
+	issue, err := c.Issue(context.Background(), "PROJ-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := tracker.Issue{Key: "PROJ-1", Summary: "Summary of PROJ-1", Status: "Done", Type: "Bug", URL: server.URL + "/browse/PROJ-1"}
+	if issue != want {
+		t.Errorf("Expected %+v, got %+v", want, issue)
+	}
+	if auth != "Bearer pat" {
+		t.Errorf("Expected a bearer token, got %q", auth)
+	}
+
+	c.User = "me@example.com"
+	if _, err := c.Issue(context.Background(), "PROJ-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(auth, "Basic ") {
+		t.Errorf("Expected basic authentication, got %q", auth)
+	}
+
+	if _, err := c.Issue(context.Background(), "PROJ-2"); !errors.Is(err, tracker.ErrNotFound) {
+		t.Errorf("Expected tracker.ErrNotFound, got %v", err)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	f := &fakeJira{statuses: map[string]string{"PROJ-1": "Done", "PROJ-2": "Released"}, versions: []string{"1.0.0"}}
+	server := httptest.NewServer(f)
+	defer server.Close()
+
+	c := New(server.URL, "", "pat")
+	c.Transition, c.FixVersion = "released", true
+	if err := c.Release(context.Background(), "1.1.0", []string{"PROJ-1", "PROJ-2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		`POST version {"name":"1.1.0","project":"PROJ"}`,
+		`PUT issue/PROJ-1 {"update":{"fixVersions":[{"add":{"name":"1.1.0"}}]}}`,
+		`POST issue/PROJ-1/transitions {"transition":{"id":"31"}}`,
+		`PUT issue/PROJ-2 {"update":{"fixVersions":[{"add":{"name":"1.1.0"}}]}}`,
+	}
+	if !reflect.DeepEqual(f.requests, want) {
+		t.Errorf("Expected %q, got %q", want, f.requests)
+	}
+
+	// The version exists now; only the transition is asked for.
+	f.requests, f.versions = nil, []string{"1.1.0"}
+	c.Transition, c.FixVersion = "Ship", false
+	if err := c.Release(context.Background(), "1.1.0", []string{"PROJ-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = []string{`POST issue/PROJ-1/transitions {"transition":{"id":"31"}}`}
+	if !reflect.DeepEqual(f.requests, want) {
+		t.Errorf("Expected %q, got %q", want, f.requests)
+	}
+}
+
+func TestReleaseErrors(t *testing.T) {
+	f := &fakeJira{statuses: map[string]string{"PROJ-1": "Done"}}
+	server := httptest.NewServer(f)
+	defer server.Close()
+
+	c := New(server.URL, "", "pat")
+	c.Transition = "Archived"
+	err := c.Release(context.Background(), "1.1.0", []string{"PROJ-9", "PROJ-1"})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, s := range []string{"transition PROJ-9 to Archived", "transition PROJ-1 to Archived: no such transition from Done"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Expected %q in %q", s, err)
+		}
+	}
+}
+
+func TestReleaseDryRun(t *testing.T) {
+	f := &fakeJira{statuses: map[string]string{"PROJ-1": "Done"}}
+	server := httptest.NewServer(f)
+	defer server.Close()
+
+	var out bytes.Buffer
+	c := New(server.URL, "", "pat")
+	c.Transition, c.FixVersion, c.DryRun, c.Log = "Released", true, true, &out
+	if err := c.Release(context.Background(), "1.1.0", []string{"PROJ-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.requests) != 0 {
+		t.Errorf("Expected no changes, got %q", f.requests)
+	}
+	for _, s := range []string{"would create Jira version 1.1.0 in PROJ", "would add fix version 1.1.0 to PROJ-1", "would move PROJ-1 from Done to Released"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("Expected %q in %q", s, out.String())
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"key": "PROJ-1", "fields": {"summary": "s"}}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "", "pat")
+	c.Retry = retry.Policy{MaxAttempts: 2}
+	if _, err := c.Issue(context.Background(), "PROJ-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+}
+
+func TestNewFromSecrets(t *testing.T) {
+	secrets := map[string]string{UserEnv: "me@example.com", TokenEnv: "tok"}
+	c, err := NewFromSecrets("https://example.atlassian.net", func(name string) string { return secrets[name] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.User != "me@example.com" || c.Token != "tok" {
+		t.Errorf("Unexpected credentials %q %q", c.User, c.Token)
+	}
+	if _, err := NewFromSecrets("https://example.atlassian.net", func(string) string { return "" }); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Expected ErrNoToken, got %v", err)
+	}
+	if _, err := NewFromSecrets("", func(name string) string { return secrets[name] }); err == nil {
+		t.Error("Expected an error without a base URL")
+	}
+}
@@ -0,0 +1,95 @@
+// Package tracker finds the issue keys commits mention — "PROJ-123" in a
+// description, scope, body or footer — and resolves them through an issue
+// tracker such as Jira to their summaries and statuses, for release notes
+// to list the issues a release resolves.
+package tracker
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+)
+
+// Issue is an issue of the tracker.
+type Issue struct {
+	// Key is the project key and number, e.g. "PROJ-123".
+	Key     string
+	Summary string
+	Status  string
+	// Type is the kind of issue, e.g. "Bug" or "Story".
+	Type string
+	URL  string
+}
+
+// Tracker looks up issues by key.
+type Tracker interface {
+	Issue(ctx context.Context, key string) (Issue, error)
+}
+
+// Releaser records on the tracker that issues shipped in a release, such
+// as by setting their fix version or moving them to a released status.
+type Releaser interface {
+	Release(ctx context.Context, version string, keys []string) error
+}
+
+// ErrNotFound is returned by a Tracker for a key it has no issue for, or
+// none the credentials may read. Resolve skips such keys: a commit may
+// mention a key of another tracker, or a mistyped one.
+var ErrNotFound = errors.New("issue not found")
+
+// keyPattern matches "PROJ-123" outside of words.
+var keyPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9_]+)-([1-9][0-9]*)\b`)
+
+// Keys returns the issue keys of projects c mentions in its scope,
+// description, body and footers, in that order, each once. Only keys of
+// projects count, so that "UTF-8" or "SHA-256" are not taken for issues.
+func Keys(c commits.Commit, projects []string) []string {
+	var keys []string
+	texts := []string{c.Scope, c.Description, c.Body}
+	for _, f := range c.Footers {
+		texts = append(texts, f.Value)
+	}
+	for _, text := range texts {
+		for _, m := range keyPattern.FindAllStringSubmatch(text, -1) {
+			if slices.Contains(projects, m[1]) && !slices.Contains(keys, m[0]) {
+				keys = append(keys, m[0])
+			}
+		}
+	}
+	return keys
+}
+
+// AllKeys returns the keys of projects cs mention, in the order of cs,
+// each once.
+func AllKeys(cs []commits.Commit, projects []string) []string {
+	var keys []string
+	for _, c := range cs {
+		for _, k := range Keys(c, projects) {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+// Resolve looks up the issues of projects cs mention with t, in the order
+// of AllKeys. Keys t fails with ErrNotFound for are skipped. On error, the
+// issues resolved so far are returned with it.
+func Resolve(ctx context.Context, cs []commits.Commit, projects []string, t Tracker) ([]Issue, error) {
+	var issues []Issue
+	for _, key := range AllKeys(cs, projects) {
+		issue, err := t.Issue(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return issues, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
@@ -0,0 +1,92 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+)
+
+func TestKeys(t *testing.T) {
+	projects := []string{"PROJ", "OPS"}
+	cases := []struct {
+		name   string
+		commit commits.Commit
+		want   []string
+	}{
+		{"description", commits.Commit{Description: "fix login (PROJ-12)"}, []string{"PROJ-12"}},
+		{"scope first", commits.Commit{Scope: "OPS-3", Description: "tune PROJ-4"}, []string{"OPS-3", "PROJ-4"}},
+		{"body and footers", commits.Commit{
+			Description: "add cache",
+			Body:        "As PROJ-7 asks.",
+			Footers:     []commits.Footer{{Token: "Refs", Value: "PROJ-8, PROJ-7"}},
+		}, []string{"PROJ-7", "PROJ-8"}},
+		{"other projects", commits.Commit{Description: "decode UTF-8 and SHA-256 for ABC-1"}, nil},
+		{"inside words", commits.Commit{Description: "xPROJ-1 and PROJ-1x and PROJ-0"}, nil},
+		{"lowercase", commits.Commit{Description: "proj-1"}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Keys(c.commit, projects); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestAllKeys(t *testing.T) {
+	cs := []commits.Commit{
+		{Description: "PROJ-2"},
+		{Description: "PROJ-1 and PROJ-2"},
+	}
+	want := []string{"PROJ-2", "PROJ-1"}
+	if got := AllKeys(cs, []string{"PROJ"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+type fakeTracker map[string]Issue
+
+func (f fakeTracker) Issue(_ context.Context, key string) (Issue, error) {
+	if key == "PROJ-500" {
+		return Issue{}, errors.New("500 Internal Server Error")
+	}
+	issue, ok := f[key]
+	if !ok {
+		return Issue{}, fmt.Errorf("GET %s: %w", key, ErrNotFound)
+	}
+	return issue, nil
+}
+
+func TestResolve(t *testing.T) {
+	tr := fakeTracker{
+		"PROJ-1": {Key: "PROJ-1", Summary: "Login fails", Status: "Done"},
+		"PROJ-2": {Key: "PROJ-2", Summary: "Slow search", Status: "In Review"},
+	}
+	cs := []commits.Commit{
+		{Description: "fix login PROJ-1"},
+		{Description: "typo PROJ-99"},
+		{Description: "index PROJ-2, see PROJ-1"},
+	}
+
+	issues, err := Resolve(context.Background(), cs, []string{"PROJ"}, tr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Issue{tr["PROJ-1"], tr["PROJ-2"]}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("Expected %v, got %v", want, issues)
+	}
+
+	cs = append(cs, commits.Commit{Description: "PROJ-500"})
+	issues, err = Resolve(context.Background(), cs, []string{"PROJ"}, tr)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("Expected the issues resolved before the error, got %v", issues)
+	}
+}
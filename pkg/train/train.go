@@ -0,0 +1,120 @@
+// Package train schedules release trains: releases cut at a fixed cadence,
+// whatever they carry, rather than whenever a change calls for one.
+//
+// A Train departs at Start and every Every after it. Cadences of whole days
+// step by calendar days in the time zone of Start, so that a fortnightly
+// train leaves at the same local time on either side of a daylight saving
+// change. A departure is still caught until Window after it, so that a
+// scheduled job starting late cuts it all the same.
+package train
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultWindow is the Window of a Train when none is given.
+const DefaultWindow = 24 * time.Hour
+
+// Train is the schedule of a release train.
+type Train struct {
+	// Start is the first departure.
+	Start time.Time
+	// Every is the cadence of the departures.
+	Every time.Duration
+	// Window is how long after a departure it is due.
+	Window time.Duration
+}
+
+// New returns the train departing at start and every every after it, due
+// for window after each departure: DefaultWindow when 0, and at most every.
+func New(start time.Time, every, window time.Duration) (Train, error) {
+	if every <= 0 {
+		return Train{}, fmt.Errorf("train: cadence %s must be positive", every)
+	}
+	if window < 0 {
+		return Train{}, fmt.Errorf("train: window %s must not be negative", window)
+	}
+	if window == 0 {
+		window = DefaultWindow
+	}
+	return Train{Start: start, Every: every, Window: min(window, every)}, nil
+}
+
+var layouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", time.DateOnly}
+
+// ParseStart parses the first departure s, a date ("2026-01-06", at
+// midnight) or time ("2026-01-06T10:00", or RFC 3339) in loc (UTC when
+// nil).
+func ParseStart(s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("train: %q is not a date (2006-01-02) or time (2006-01-02T15:04)", s)
+}
+
+// days returns the cadence in calendar days, or 0 when it is not a whole
+// number of days.
+func (t Train) days() int {
+	const day = 24 * time.Hour
+	if t.Every%day != 0 {
+		return 0
+	}
+	return int(t.Every / day)
+}
+
+// departure returns the kth departure, the first being the 0th.
+func (t Train) departure(k int) time.Time {
+	if n := t.days(); n > 0 {
+		return t.Start.AddDate(0, 0, k*n)
+	}
+	return t.Start.Add(time.Duration(k) * t.Every)
+}
+
+// index returns the number of the latest departure at or before now, or -1
+// before the first.
+func (t Train) index(now time.Time) int {
+	if now.Before(t.Start) {
+		return -1
+	}
+	// Calendar days drift from the estimate by the hours daylight saving
+	// adds or takes away.
+	k := int(now.Sub(t.Start) / t.Every)
+	for k > 0 && t.departure(k).After(now) {
+		k--
+	}
+	for !t.departure(k + 1).After(now) {
+		k++
+	}
+	return k
+}
+
+// Last returns the latest departure at or before now, and false before the
+// first.
+func (t Train) Last(now time.Time) (time.Time, bool) {
+	k := t.index(now)
+	if k < 0 {
+		return time.Time{}, false
+	}
+	return t.departure(k), true
+}
+
+// Next returns the first departure after now.
+func (t Train) Next(now time.Time) time.Time {
+	return t.departure(t.index(now) + 1)
+}
+
+// Due returns the departure now catches: the latest one, while now is
+// within Window of it.
+func (t Train) Due(now time.Time) (time.Time, bool) {
+	last, ok := t.Last(now)
+	if !ok || !now.Before(last.Add(t.Window)) {
+		return time.Time{}, false
+	}
+	return last, true
+}
@@ -0,0 +1,95 @@
+package train
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrain(t *testing.T) {
+	start := time.Date(2026, 1, 6, 10, 0, 0, 0, time.UTC) // a Tuesday
+	tr, err := New(start, 14*24*time.Hour, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.Window != DefaultWindow {
+		t.Errorf("Expected the default window, got %s", tr.Window)
+	}
+
+	tests := []struct {
+		now        time.Time
+		last, next time.Time
+		due        bool
+	}{
+		{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}, start, false},
+		{start, start, time.Date(2026, 1, 20, 10, 0, 0, 0, time.UTC), true},
+		{time.Date(2026, 1, 7, 9, 59, 0, 0, time.UTC), start, time.Date(2026, 1, 20, 10, 0, 0, 0, time.UTC), true},
+		{time.Date(2026, 1, 7, 10, 0, 0, 0, time.UTC), start, time.Date(2026, 1, 20, 10, 0, 0, 0, time.UTC), false},
+		{time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC), time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC), time.Date(2026, 3, 17, 10, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		last, _ := tr.Last(tt.now)
+		if !last.Equal(tt.last) {
+			t.Errorf("%s: Expected the last departure %s, got %s", tt.now, tt.last, last)
+		}
+		if next := tr.Next(tt.now); !next.Equal(tt.next) {
+			t.Errorf("%s: Expected the next departure %s, got %s", tt.now, tt.next, next)
+		}
+		if _, due := tr.Due(tt.now); due != tt.due {
+			t.Errorf("%s: Expected due %v, got %v", tt.now, tt.due, due)
+		}
+	}
+}
+
+func TestTrainDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	tr, _ := New(time.Date(2026, 3, 24, 10, 0, 0, 0, berlin), 7*24*time.Hour, 0)
+
+	// Clocks moved forward on 29 March.
+	next := tr.Next(time.Date(2026, 3, 25, 0, 0, 0, 0, berlin))
+	if expected := time.Date(2026, 3, 31, 10, 0, 0, 0, berlin); !next.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, next)
+	}
+	if last, _ := tr.Last(time.Date(2026, 3, 31, 9, 30, 0, 0, berlin)); !last.Equal(tr.Start) {
+		t.Errorf("Expected %s, got %s", tr.Start, last)
+	}
+}
+
+func TestTrainHours(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr, err := New(start, 12*time.Hour, 48*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.Window != 12*time.Hour {
+		t.Errorf("Expected the window capped at the cadence, got %s", tr.Window)
+	}
+	if last, _ := tr.Last(time.Date(2026, 1, 3, 13, 0, 0, 0, time.UTC)); !last.Equal(time.Date(2026, 1, 3, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the departure at noon, got %s", last)
+	}
+
+	if _, err := New(start, 0, 0); err == nil {
+		t.Errorf("Expected an error for a zero cadence")
+	}
+	if _, err := New(start, time.Hour, -time.Hour); err == nil {
+		t.Errorf("Expected an error for a negative window")
+	}
+}
+
+func TestParseStart(t *testing.T) {
+	for s, expected := range map[string]time.Time{
+		"2026-01-06":           time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC),
+		"2026-01-06T10:00":     time.Date(2026, 1, 6, 10, 0, 0, 0, time.UTC),
+		"2026-01-06T10:00:00Z": time.Date(2026, 1, 6, 10, 0, 0, 0, time.UTC),
+	} {
+		got, err := ParseStart(s, nil)
+		if err != nil || !got.Equal(expected) {
+			t.Errorf("%s: Expected %s, got %s (err=%v)", s, expected, got, err)
+		}
+	}
+	if _, err := ParseStart("next tuesday", nil); err == nil {
+		t.Errorf("Expected an error for an invalid start")
+	}
+}
@@ -0,0 +1,49 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// LangPlaceholder is replaced by the target language in the arguments of
+// a Command.
+const LangPlaceholder = "{lang}"
+
+// Command translates with an external program, such as a machine
+// translation CLI: the text is written to its standard input and its
+// standard output is the translation. LangPlaceholder in Args is replaced
+// by the language, which is also in the RELEASE_TRANSLATE_LANG variable.
+type Command struct {
+	Args []string
+}
+
+// NewCommand returns the Command running args.
+func NewCommand(args []string) (*Command, error) {
+	if len(args) == 0 {
+		return nil, errors.New("translate: the command backend needs a command")
+	}
+	return &Command{Args: args}, nil
+}
+
+func (c *Command) Translate(ctx context.Context, text, lang string) (string, error) {
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		args[i] = strings.ReplaceAll(a, LangPlaceholder, lang)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(cmd.Environ(), "RELEASE_TRANSLATE_LANG="+lang)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("translate: %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("translate: %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
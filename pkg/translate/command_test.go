@@ -0,0 +1,31 @@
+package translate
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	c, err := NewCommand([]string{"sh", "-c", `tr a-z A-Z; printf ' %s/%s' "$1" "$RELEASE_TRANSLATE_LANG"`, "sh", "{lang}"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := c.Translate(context.Background(), "- fix parser", "de")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "- FIX PARSER de/de"; out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+
+	c.Args = []string{"sh", "-c", "echo quota exceeded >&2; exit 3"}
+	_, err = c.Translate(context.Background(), "- fix parser", "de")
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("Expected the error output in the error, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/changelog"
+)
+
+// Sync returns localized, the translation into lang of the changelog
+// canonical, holding a section for every release section of canonical, in
+// its order, and the versions it translated. Sections localized has
+// already are kept as they are, so translations edited by hand survive;
+// the others are translated below their heading, which is kept, so the
+// version of each section stays recognisable. An empty localized starts
+// from the translated header of canonical. Sections of localized that
+// canonical lacks are kept after the others.
+func Sync(ctx context.Context, t Translator, canonical, localized []byte, lang string) ([]byte, []string, error) {
+	header, sections := changelog.Sections(canonical)
+	existing := map[string][]byte{}
+	var extra [][]byte
+	if len(bytes.TrimSpace(localized)) > 0 {
+		var local [][]byte
+		header, local = changelog.Sections(localized)
+		for _, s := range local {
+			existing[changelog.SectionVersion(s)] = s
+		}
+		known := map[string]bool{}
+		for _, s := range sections {
+			known[changelog.SectionVersion(s)] = true
+		}
+		for _, s := range local {
+			if !known[changelog.SectionVersion(s)] {
+				extra = append(extra, s)
+			}
+		}
+	} else if len(bytes.TrimSpace(header)) > 0 {
+		translated, err := translate(ctx, t, string(header), lang)
+		if err != nil {
+			return nil, nil, fmt.Errorf("translate: header into %s: %w", lang, err)
+		}
+		header = []byte(translated + "\n")
+	}
+
+	out := make([][]byte, 0, len(sections)+len(extra))
+	var translated []string
+	for _, s := range sections {
+		v := changelog.SectionVersion(s)
+		if l, ok := existing[v]; ok {
+			out = append(out, l)
+			continue
+		}
+		heading, body, _ := bytes.Cut(s, []byte("\n"))
+		section := string(heading) + "\n"
+		if len(bytes.TrimSpace(body)) > 0 {
+			text, err := translate(ctx, t, string(body), lang)
+			if err != nil {
+				return nil, nil, fmt.Errorf("translate: section %s into %s: %w", v, lang, err)
+			}
+			section += text + "\n"
+		}
+		out = append(out, []byte(section))
+		translated = append(translated, v)
+	}
+	return changelog.Rebuild(header, append(out, extra...)), translated, nil
+}
+
+// translate translates text with t, keeping the blank lines before it.
+func translate(ctx context.Context, t Translator, text, lang string) (string, error) {
+	body := strings.TrimLeft(text, "\n")
+	lead := text[:len(text)-len(body)]
+	out, err := t.Translate(ctx, strings.TrimRight(body, "\n"), lang)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out) == "" {
+		return "", ErrEmpty
+	}
+	return lead + strings.Trim(out, "\n"), nil
+}
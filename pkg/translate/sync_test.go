@@ -0,0 +1,86 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// upper "translates" by upper-casing, recording what it was given.
+type upper struct{ texts []string }
+
+func (u *upper) Translate(_ context.Context, text, lang string) (string, error) {
+	u.texts = append(u.texts, text)
+	return strings.ToUpper(text) + " [" + lang + "]", nil
+}
+
+const canonical = "# Changelog\n\nAll notable changes.\n\n" +
+	"## [1.1.0] - 2026-02-01\n\n### Features\n\n- add export\n\n" +
+	"## [1.0.0] - 2026-01-01\n\n- first\n"
+
+func TestSyncNew(t *testing.T) {
+	u := &upper{}
+	out, translated, err := Sync(context.Background(), u, []byte(canonical), nil, "de")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "# CHANGELOG\n\nALL NOTABLE CHANGES. [de]\n\n" +
+		"## [1.1.0] - 2026-02-01\n\n### FEATURES\n\n- ADD EXPORT [de]\n\n" +
+		"## [1.0.0] - 2026-01-01\n\n- FIRST [de]\n"
+	if string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+	if want := []string{"1.1.0", "1.0.0"}; !reflect.DeepEqual(translated, want) {
+		t.Errorf("Expected %v translated, got %v", want, translated)
+	}
+	if u.texts[1] != "### Features\n\n- add export" {
+		t.Errorf("Expected the section body without its heading, got %q", u.texts[1])
+	}
+}
+
+func TestSyncExisting(t *testing.T) {
+	localized := "# Änderungen\n\n## [1.0.0] - 2026-01-01\n\n- erste\n\n## [0.9.0] - 2025-12-01\n\n- alt\n"
+	u := &upper{}
+	out, translated, err := Sync(context.Background(), u, []byte(canonical), []byte(localized), "de")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "# Änderungen\n\n" +
+		"## [1.1.0] - 2026-02-01\n\n### FEATURES\n\n- ADD EXPORT [de]\n\n" +
+		"## [1.0.0] - 2026-01-01\n\n- erste\n\n" +
+		"## [0.9.0] - 2025-12-01\n\n- alt\n"
+	if string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+	if want := []string{"1.1.0"}; !reflect.DeepEqual(translated, want) {
+		t.Errorf("Expected %v translated, got %v", want, translated)
+	}
+
+	// An up-to-date translation is left alone.
+	u.texts = nil
+	again, translated, err := Sync(context.Background(), u, []byte(canonical), out, "de")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(again) != string(out) || len(translated) != 0 || len(u.texts) != 0 {
+		t.Errorf("Expected nothing translated, got %v and %q", translated, again)
+	}
+}
+
+type failing struct{ err error }
+
+func (f failing) Translate(context.Context, string, string) (string, error) { return "", f.err }
+
+func TestSyncError(t *testing.T) {
+	_, _, err := Sync(context.Background(), failing{errors.New("quota exceeded")}, []byte(canonical), []byte("# Änderungen\n"), "de")
+	if err == nil || !strings.Contains(err.Error(), "section 1.1.0 into de: quota exceeded") {
+		t.Errorf("Expected the failing section in the error, got %v", err)
+	}
+	// A backend translating into nothing fails rather than empty the file.
+	_, _, err = Sync(context.Background(), failing{}, []byte(canonical), nil, "de")
+	if !errors.Is(err, ErrEmpty) {
+		t.Errorf("Expected ErrEmpty, got %v", err)
+	}
+}
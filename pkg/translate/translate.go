@@ -0,0 +1,89 @@
+// Package translate keeps translations of a changelog in sync with it. The
+// changelog is generated in one canonical language; each translation is a
+// CHANGELOG.<lang>.md next to it, which Sync completes with the release
+// sections it lacks, translated by a Translator backend.
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Translator translates Markdown text into a language.
+type Translator interface {
+	// Translate returns text translated into lang, a BCP 47 tag such as
+	// pt-BR.
+	Translate(ctx context.Context, text, lang string) (string, error)
+}
+
+// Copy is the Translator that copies text untranslated, for people to
+// translate in the file by hand.
+type Copy struct{}
+
+func (Copy) Translate(_ context.Context, text, _ string) (string, error) { return text, nil }
+
+// Options configure a backend.
+type Options struct {
+	// Command is the command of the command backend.
+	Command []string
+}
+
+// Factory builds the Translator of a backend.
+type Factory func(o Options) (Translator, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{
+		"copy":    func(Options) (Translator, error) { return Copy{}, nil },
+		"command": func(o Options) (Translator, error) { return NewCommand(o.Command) },
+	}
+)
+
+// Register makes a backend available to New. Registering a backend twice
+// replaces the previous factory.
+func Register(backend string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[backend] = f
+}
+
+// Backends returns the registered backends, sorted.
+func Backends() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	backends := make([]string, 0, len(factories))
+	for b := range factories {
+		backends = append(backends, b)
+	}
+	sort.Strings(backends)
+	return backends
+}
+
+// New builds the Translator of backend; empty means copy.
+func New(backend string, o Options) (Translator, error) {
+	if backend == "" {
+		backend = "copy"
+	}
+	mu.RLock()
+	f, ok := factories[backend]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("translate: unknown backend %q", backend)
+	}
+	return f(o)
+}
+
+// Path returns the translation into lang of the changelog at path:
+// CHANGELOG.md becomes CHANGELOG.pt-BR.md.
+func Path(path, lang string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + lang + ext
+}
+
+// ErrEmpty is returned when a backend translates text into nothing.
+var ErrEmpty = errors.New("translate: empty translation")
@@ -0,0 +1,52 @@
+package translate
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tr, err := New("", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := tr.Translate(context.Background(), "- fix parser", "de")
+	if err != nil || out != "- fix parser" {
+		t.Errorf("Expected the text copied, got %q, %v", out, err)
+	}
+	if _, err := New("command", Options{}); err == nil {
+		t.Error("Expected an error for the command backend without a command")
+	}
+	if _, err := New("deepl", Options{}); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("upper", func(Options) (Translator, error) { return Copy{}, nil })
+	defer func() {
+		mu.Lock()
+		delete(factories, "upper")
+		mu.Unlock()
+	}()
+	if expected := []string{"command", "copy", "upper"}; !reflect.DeepEqual(Backends(), expected) {
+		t.Errorf("Expected %v, got %v", expected, Backends())
+	}
+	if _, err := New("upper", Options{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := map[string]string{
+		"CHANGELOG.md":          "CHANGELOG.pt-BR.md",
+		"docs/CHANGES.markdown": "docs/CHANGES.pt-BR.markdown",
+		"CHANGELOG":             "CHANGELOG.pt-BR",
+	}
+	for path, expected := range tests {
+		if got := Path(path, "pt-BR"); got != expected {
+			t.Errorf("Path(%q): expected %q, got %q", path, expected, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/artifacts"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/preflight"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// ErrNotReproducible is wrapped by the failures of the binaries that
+// differ from their rebuild.
+var ErrNotReproducible = relerr.ErrNotReproducible
+
+// Reproduction compares the binaries of the release of Tag published with
+// a provider to Rebuilt, the same binaries built again from the tagged
+// commit. Its checks share the assets downloaded by the first one.
+type Reproduction struct {
+	// Label names the provider in the names of the checks.
+	Label   string
+	Fetcher publish.Fetcher
+	Tag     string
+	// Dir receives the downloaded assets.
+	Dir string
+	// Rebuilt are the binaries of the rebuild, named as published.
+	Rebuilt []artifacts.Artifact
+
+	release Release
+}
+
+// Checks returns the checks of r, to be run in order: the download of the
+// release, then one per rebuilt binary.
+func (r *Reproduction) Checks() []preflight.Check {
+	r.release = Release{Label: r.Label, Fetcher: r.Fetcher, Tag: r.Tag, Dir: r.Dir}
+	checks := r.release.Checks()
+	for _, a := range r.Rebuilt {
+		name := filepath.Base(a.Path)
+		checks = append(checks, preflight.Check{
+			Name: fmt.Sprintf("reproduce %s on %s", name, r.Label),
+			Run:  func(context.Context) error { return r.compare(name, a.Path) },
+		})
+	}
+	return checks
+}
+
+func (r *Reproduction) compare(name, rebuilt string) error {
+	if r.release.fetched != nil {
+		return errNotFetched
+	}
+	published, ok := r.release.files[name]
+	if !ok {
+		return fmt.Errorf("%w: %s is not published with the release", ErrDrift, name)
+	}
+	want, err := artifacts.Checksum(published)
+	if err != nil {
+		return err
+	}
+	got, err := artifacts.Checksum(rebuilt)
+	if err != nil {
+		return err
+	}
+	if want == got {
+		return nil
+	}
+	diffs, err := artifacts.Differences(published, rebuilt)
+	switch {
+	case err != nil:
+		return fmt.Errorf("%w: %s differs from its rebuild, and has no Go build information to tell why", ErrNotReproducible, name)
+	case len(diffs) == 0:
+		return fmt.Errorf("%w: %s differs from its rebuild, though their build information matches; compare the two binaries, e.g. with diffoscope", ErrNotReproducible, name)
+	}
+	return fmt.Errorf("%w: %s differs from its rebuild: %s", ErrNotReproducible, name, strings.Join(diffs, "; "))
+}
@@ -0,0 +1,49 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/artifacts"
+)
+
+func TestReproduction(t *testing.T) {
+	dir := t.TempDir()
+	var rebuilt []artifacts.Artifact
+	for name, content := range map[string]string{"app_linux": "binary", "app_darwin": "other", "app_windows.exe": "win"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		rebuilt = append(rebuilt, artifacts.Artifact{Path: path})
+	}
+	f := newFetcher(map[string]string{"app_linux": "binary", "app_darwin": "tampered"})
+	r := &Reproduction{Label: "github", Fetcher: f, Tag: "v1.2.0", Dir: t.TempDir(), Rebuilt: rebuilt}
+
+	var out bytes.Buffer
+	err := Run(context.Background(), &out, r.Checks())
+	if !errors.Is(err, ErrNotReproducible) || !errors.Is(err, ErrDrift) {
+		t.Fatalf("Expected a failed reproduction, got %v", err)
+	}
+	for _, want := range []string{
+		"✓ release v1.2.0 on github\n",
+		"✓ reproduce app_linux on github\n",
+		"app_darwin differs from its rebuild, and has no Go build information to tell why",
+		"app_windows.exe is not published with the release",
+	} {
+		if !strings.Contains(out.String()+err.Error(), want) {
+			t.Errorf("Expected %q in %q and %v", want, out.String(), err)
+		}
+	}
+
+	r = &Reproduction{Label: "github", Fetcher: &fakeFetcher{missing: true}, Tag: "v1.2.0", Dir: t.TempDir(), Rebuilt: rebuilt[:1]}
+	err = Run(context.Background(), nil, r.Checks())
+	if err == nil || !strings.Contains(err.Error(), "skipped: the release could not be downloaded") {
+		t.Errorf("Expected the comparison skipped without a release, got %v", err)
+	}
+}
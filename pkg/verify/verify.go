@@ -0,0 +1,333 @@
+// Package verify audits a release after it was published: the tag points at
+// the expected commit locally, on the remote and at the provider, the
+// assets match the SHA256SUMS published with them, and the signatures of
+// the checksums and provenance hold.
+//
+// The audits are preflight checks, and Run reports every failure together,
+// so that a single run lists all the drift. Each failure wraps one of the
+// sentinel errors below.
+package verify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/artifacts"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/preflight"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+// The sentinels are those of pkg/errors.
+var (
+	// ErrFailed is wrapped by the error Run returns when any check fails.
+	ErrFailed = relerr.ErrVerifyFailed
+
+	ErrDrift        = relerr.ErrDrift
+	ErrBadSignature = relerr.ErrBadAssetSignature
+)
+
+// Error lists the checks that failed.
+type Error struct {
+	Failed []error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(ErrFailed.Error() + ":")
+	for _, err := range e.Failed {
+		b.WriteString("\n  - " + err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the failures and ErrFailed, so errors.Is matches all of
+// them. The failures come first for the kind of the error to be that of
+// the first failure: a bad signature exits as such rather than as drift.
+func (e *Error) Unwrap() []error {
+	return append(slices.Clone(e.Failed), ErrFailed)
+}
+
+// Run runs every check as preflight.Run does, writing a line per check to
+// w when it is not nil. It returns an *Error listing the failures, or nil
+// when all checks pass.
+func Run(ctx context.Context, w io.Writer, checks []preflight.Check) error {
+	err := preflight.Run(ctx, w, checks)
+	var pe *preflight.Error
+	if errors.As(err, &pe) {
+		return &Error{Failed: pe.Failed}
+	}
+	return err
+}
+
+// Tag requires tag to point at commit in repo and on remote. An empty
+// commit expects the commit of the local tag, which must then exist; an
+// empty remote checks the local tag only.
+func Tag(repo gitrepo.Repository, remote, tag, commit string) preflight.Check {
+	return preflight.Check{Name: "tag " + tag, Run: func(ctx context.Context) error {
+		local, err := repo.Tags(ctx)
+		if err != nil {
+			return err
+		}
+		want := commit
+		var drift []string
+		switch t, ok := findTag(local, tag); {
+		case ok && want == "":
+			want = t.Commit
+		case ok && t.Commit != want:
+			drift = append(drift, fmt.Sprintf("points at %s locally", short(t.Commit)))
+		case !ok && want == "":
+			return fmt.Errorf("%w: %s is not a local tag; fetch the tags or give the commit it must point at", ErrDrift, tag)
+		}
+
+		if remote != "" {
+			tags, err := repo.RemoteTags(ctx, remote)
+			if err != nil {
+				return err
+			}
+			switch t, ok := findTag(tags, tag); {
+			case !ok:
+				drift = append(drift, "is missing from "+remote)
+			case t.Commit != want:
+				drift = append(drift, fmt.Sprintf("points at %s on %s", short(t.Commit), remote))
+			}
+		}
+		if len(drift) > 0 {
+			return fmt.Errorf("%w: %s should point at %s but %s", ErrDrift, tag, short(want), strings.Join(drift, " and "))
+		}
+		return nil
+	}}
+}
+
+// Release audits the release of Tag published with a provider. Its checks
+// share the assets downloaded by the first one.
+type Release struct {
+	// Label names the provider in the names of the checks, e.g. "github
+	// octo/app".
+	Label   string
+	Fetcher publish.Fetcher
+	Tag     string
+	// Commit is the commit the provider must say the tag points at, when
+	// it says.
+	Commit string
+	// Dir receives the downloaded assets.
+	Dir string
+	// Checksums checks the assets against the SHA256SUMS among them.
+	Checksums bool
+	// Verifier, when not nil, checks the signatures of SHA256SUMS and the
+	// provenance.
+	Verifier artifacts.Verifier
+
+	files   map[string]string
+	fetched error
+}
+
+// errNotFetched fails the checks that need the assets when the first check
+// could not download them.
+var errNotFetched = errors.New("skipped: the release could not be downloaded")
+
+// Checks returns the checks of r, to be run in order.
+func (r *Release) Checks() []preflight.Check {
+	checks := []preflight.Check{{Name: fmt.Sprintf("release %s on %s", r.Tag, r.Label), Run: r.fetch}}
+	if r.Checksums {
+		checks = append(checks, preflight.Check{Name: "checksums on " + r.Label, Run: r.checksums})
+	}
+	if r.Verifier != nil {
+		checks = append(checks, preflight.Check{Name: "signatures on " + r.Label, Run: r.signatures})
+	}
+	return checks
+}
+
+func (r *Release) fetch(ctx context.Context) error {
+	r.fetched = r.download(ctx)
+	return r.fetched
+}
+
+func (r *Release) download(ctx context.Context) error {
+	p, ok, err := r.Fetcher.Fetch(ctx, r.Tag)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s has no release", ErrDrift, r.Tag)
+	}
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return err
+	}
+	r.files = make(map[string]string)
+	for _, a := range p.Assets {
+		path := filepath.Join(r.Dir, filepath.Base(a.Name))
+		n, err := downloadFile(ctx, r.Fetcher, a, path)
+		if err != nil {
+			return fmt.Errorf("download %s: %w", a.Name, err)
+		}
+		if a.Size > 0 && n != a.Size {
+			return fmt.Errorf("%w: %s is %d bytes but %d were downloaded", ErrDrift, a.Name, a.Size, n)
+		}
+		r.files[a.Name] = path
+	}
+	if r.Commit != "" && p.Commit != "" && p.Commit != r.Commit {
+		return fmt.Errorf("%w: the release says %s points at %s, not %s", ErrDrift, r.Tag, short(p.Commit), short(r.Commit))
+	}
+	return nil
+}
+
+func downloadFile(ctx context.Context, f publish.Fetcher, a publish.RemoteAsset, path string) (int64, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	cw := &countingWriter{w: out}
+	err = f.Download(ctx, a, cw)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+func (r *Release) checksums(context.Context) error {
+	if r.fetched != nil {
+		return errNotFetched
+	}
+	path, ok := r.files[artifacts.ChecksumsFile]
+	if !ok {
+		return fmt.Errorf("%w: the release has no %s to check the assets against", ErrDrift, artifacts.ChecksumsFile)
+	}
+	sums, err := parseChecksums(path)
+	if err != nil {
+		return err
+	}
+
+	var missing, mismatched []string
+	for _, s := range sums {
+		file, ok := r.files[s.name]
+		if !ok {
+			missing = append(missing, s.name)
+			continue
+		}
+		sum, err := artifacts.Checksum(file)
+		if err != nil {
+			return err
+		}
+		if sum != s.digest {
+			mismatched = append(mismatched, s.name)
+		}
+	}
+	var drift []string
+	if len(mismatched) > 0 {
+		drift = append(drift, artifacts.ChecksumsFile+" does not match "+strings.Join(mismatched, ", "))
+	}
+	if len(missing) > 0 {
+		drift = append(drift, artifacts.ChecksumsFile+" lists unpublished "+strings.Join(missing, ", "))
+	}
+	if len(drift) > 0 {
+		return fmt.Errorf("%w: %s", ErrDrift, strings.Join(drift, "; "))
+	}
+	return nil
+}
+
+type checksum struct{ digest, name string }
+
+// parseChecksums reads a checksum file in the format of sha256sum, whose
+// binary mode marks names with "*".
+func parseChecksums(path string) ([]checksum, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sums []checksum
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		digest, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("%w: unexpected %s line %q", ErrDrift, artifacts.ChecksumsFile, line)
+		}
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		sums = append(sums, checksum{digest: strings.ToLower(digest), name: name})
+	}
+	return sums, sc.Err()
+}
+
+func (r *Release) signatures(ctx context.Context) error {
+	if r.fetched != nil {
+		return errNotFetched
+	}
+	var signed int
+	var failed []string
+	for _, name := range []string{artifacts.ChecksumsFile, artifacts.ProvenanceFile} {
+		path, ok := r.files[name]
+		if !ok {
+			continue
+		}
+		signed++
+		sig, ok := r.signature(name)
+		if !ok {
+			failed = append(failed, name+" is not signed")
+			continue
+		}
+		if err := r.Verifier.Verify(ctx, path, sig); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if signed == 0 {
+		return fmt.Errorf("%w: the release has neither %s nor %s to verify", ErrBadSignature, artifacts.ChecksumsFile, artifacts.ProvenanceFile)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", ErrBadSignature, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// signature returns the downloaded signature of the asset name.
+func (r *Release) signature(name string) (string, bool) {
+	for _, suffix := range artifacts.SignatureSuffixes {
+		if path, ok := r.files[name+suffix]; ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func findTag(tags []gitrepo.Tag, name string) (gitrepo.Tag, bool) {
+	for _, t := range tags {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return gitrepo.Tag{}, false
+}
+
+func short(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
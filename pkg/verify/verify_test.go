@@ -0,0 +1,165 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/artifacts"
+	relerr "github.com/gbrennon/release_automation_golang/pkg/errors"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/preflight"
+	"github.com/gbrennon/release_automation_golang/pkg/publish"
+)
+
+type fakeRepo struct {
+	gitrepo.Repository
+	tags, remote []gitrepo.Tag
+}
+
+func (f *fakeRepo) Tags(context.Context) ([]gitrepo.Tag, error) { return f.tags, nil }
+
+func (f *fakeRepo) RemoteTags(context.Context, string) ([]gitrepo.Tag, error) {
+	return f.remote, nil
+}
+
+type fakeFetcher struct {
+	release publish.Published
+	files   map[string]string
+	missing bool
+}
+
+func (f *fakeFetcher) Fetch(context.Context, string) (publish.Published, bool, error) {
+	return f.release, !f.missing, nil
+}
+
+func (f *fakeFetcher) Download(_ context.Context, a publish.RemoteAsset, w io.Writer) error {
+	_, err := io.WriteString(w, f.files[a.Name])
+	return err
+}
+
+// newFetcher publishes files, with a SHA256SUMS of app.tar.gz and
+// app.zip.
+func newFetcher(files map[string]string) *fakeFetcher {
+	f := &fakeFetcher{
+		release: publish.Published{Tag: "v1.2.0", Commit: "abc1234def"},
+		files:   map[string]string{artifacts.ChecksumsFile: sum("app") + "  app.tar.gz\n" + sum("zip") + " *app.zip\n"},
+	}
+	for name, content := range files {
+		f.files[name] = content
+	}
+	for name := range f.files {
+		f.release.Assets = append(f.release.Assets, publish.RemoteAsset{Name: name})
+	}
+	return f
+}
+
+func sum(content string) string {
+	h := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(h[:])
+}
+
+type verifierFunc func(path, sig string) error
+
+func (f verifierFunc) Verify(_ context.Context, path, sig string) error { return f(path, sig) }
+
+func TestTag(t *testing.T) {
+	tag := gitrepo.Tag{Name: "v1.2.0", Commit: "abc1234def"}
+	moved := gitrepo.Tag{Name: "v1.2.0", Commit: "fff0000aaa"}
+	cases := []struct {
+		name          string
+		local, remote []gitrepo.Tag
+		commit        string
+		want          string
+	}{
+		{name: "in sync", local: []gitrepo.Tag{tag}, remote: []gitrepo.Tag{tag}},
+		{name: "expected commit", remote: []gitrepo.Tag{tag}, commit: "abc1234def"},
+		{name: "moved on the remote", local: []gitrepo.Tag{tag}, remote: []gitrepo.Tag{moved}, want: "v1.2.0 should point at abc1234 but points at fff0000 on origin"},
+		{name: "missing from the remote", local: []gitrepo.Tag{tag}, want: "is missing from origin"},
+		{name: "moved locally", local: []gitrepo.Tag{moved}, remote: []gitrepo.Tag{tag}, commit: "abc1234def", want: "points at fff0000 locally"},
+		{name: "unknown", remote: []gitrepo.Tag{tag}, want: "v1.2.0 is not a local tag"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Tag(&fakeRepo{tags: c.local, remote: c.remote}, "origin", "v1.2.0", c.commit).Run(context.Background())
+			if c.want == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrDrift) || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("Expected drift %q, got %v", c.want, err)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	good := verifierFunc(func(path, sig string) error {
+		if filepath.Base(path) != artifacts.ChecksumsFile || filepath.Base(sig) != "SHA256SUMS.sig" {
+			return errors.New("wrong signature")
+		}
+		return nil
+	})
+	bad := verifierFunc(func(string, string) error { return errors.New("invalid signature") })
+	cases := []struct {
+		name     string
+		fetcher  *fakeFetcher
+		commit   string
+		verifier artifacts.Verifier
+		want     []string
+		kind     relerr.Kind
+	}{
+		{name: "verified", fetcher: newFetcher(map[string]string{"app.tar.gz": "app", "app.zip": "zip", "SHA256SUMS.sig": "signed"}), commit: "abc1234def", verifier: good},
+		{name: "tampered asset", fetcher: newFetcher(map[string]string{"app.tar.gz": "evil", "app.zip": "zip"}), want: []string{"SHA256SUMS does not match app.tar.gz"}, kind: relerr.Conflict},
+		{name: "missing asset", fetcher: newFetcher(map[string]string{"app.tar.gz": "app"}), want: []string{"SHA256SUMS lists unpublished app.zip"}, kind: relerr.Conflict},
+		{name: "moved tag", fetcher: newFetcher(map[string]string{"app.tar.gz": "app", "app.zip": "zip"}), commit: "fff0000aaa", want: []string{"points at abc1234, not fff0000"}, kind: relerr.Conflict},
+		{name: "bad signature", fetcher: newFetcher(map[string]string{"app.tar.gz": "app", "app.zip": "zip", "SHA256SUMS.sig": "x"}), verifier: bad, want: []string{"SHA256SUMS: invalid signature"}, kind: relerr.Signature},
+		{name: "unsigned", fetcher: newFetcher(map[string]string{"app.tar.gz": "app", "app.zip": "zip"}), verifier: good, want: []string{"SHA256SUMS is not signed"}, kind: relerr.Signature},
+		{name: "no release", fetcher: &fakeFetcher{missing: true}, verifier: good, want: []string{"v1.2.0 has no release", "checksums on github: skipped", "signatures on github: skipped"}, kind: relerr.Conflict},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := &Release{Label: "github", Fetcher: c.fetcher, Tag: "v1.2.0", Commit: c.commit, Dir: t.TempDir(), Checksums: true, Verifier: c.verifier}
+			var out bytes.Buffer
+			err := Run(context.Background(), &out, r.Checks())
+			if len(c.want) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				if out.String() != "✓ release v1.2.0 on github\n✓ checksums on github\n✓ signatures on github\n" {
+					t.Errorf("Expected every check to pass, got %q", out.String())
+				}
+				return
+			}
+			var ve *Error
+			if !errors.As(err, &ve) || !errors.Is(err, ErrFailed) {
+				t.Fatalf("Expected an *Error, got %v", err)
+			}
+			for _, want := range c.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected %q in %v", want, err)
+				}
+			}
+			if kind := relerr.KindOf(err); kind != c.kind {
+				t.Errorf("Expected %v, got %v", c.kind, kind)
+			}
+		})
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Run(ctx, nil, []preflight.Check{{Name: "never", Run: func(context.Context) error { return nil }}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled run, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package version
+
+import "fmt"
+
+// Level is the size of a version increment.
+type Level int
+
+const (
+	None Level = iota
+	Patch
+	Minor
+	Major
+)
+
+// ParseLevel converts "patch", "minor", "major" or "none" into a Level.
+func ParseLevel(s string) (Level, error) {
+	switch s {
+	case "none":
+		return None, nil
+	case "patch":
+		return Patch, nil
+	case "minor":
+		return Minor, nil
+	case "major":
+		return Major, nil
+	}
+	return None, fmt.Errorf("invalid bump level %q: must be one of none, patch, minor, major", s)
+}
+
+func (l Level) String() string {
+	switch l {
+	case None:
+		return "none"
+	case Patch:
+		return "patch"
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Bump returns the next version for the given level. Prerelease and build
+// metadata are always dropped.
+//
+// A prerelease is promoted rather than incremented when it already
+// anticipates the requested level: 1.3.0-rc.1 bumped by Minor is 1.3.0, not
+// 1.4.0. Bumping by None returns the core version unchanged.
+func (v Version) Bump(l Level) Version {
+	pre := v.IsPrerelease()
+	next := v.Core()
+
+	switch l {
+	case Major:
+		if pre && v.Minor == 0 && v.Patch == 0 {
+			return next
+		}
+		return Version{Major: v.Major + 1}
+	case Minor:
+		if pre && v.Patch == 0 {
+			return next
+		}
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	case Patch:
+		if pre {
+			return next
+		}
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+	return next
+}
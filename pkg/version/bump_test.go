@@ -0,0 +1,49 @@
+package version
+
+import "testing"
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		input    string
+		level    Level
+		expected string
+	}{
+		{"1.2.3", None, "1.2.3"},
+		{"1.2.3", Patch, "1.2.4"},
+		{"1.2.3", Minor, "1.3.0"},
+		{"1.2.3", Major, "2.0.0"},
+		{"0.0.0", Patch, "0.0.1"},
+		{"1.2.3+build.1", Patch, "1.2.4"},
+		{"1.2.4-rc1", Patch, "1.2.4"},
+		{"1.2.4-rc1", Minor, "1.3.0"},
+		{"1.2.4-rc1", Major, "2.0.0"},
+		{"1.3.0-rc.1", Patch, "1.3.0"},
+		{"1.3.0-rc.1", Minor, "1.3.0"},
+		{"1.3.0-rc.1", Major, "2.0.0"},
+		{"2.0.0-beta.3", Major, "2.0.0"},
+		{"2.0.0-beta.3", None, "2.0.0"},
+	}
+
+	for _, tt := range tests {
+		result := MustParse(tt.input).Bump(tt.level).String()
+		if result != tt.expected {
+			t.Errorf("Bump(%s, %s): expected %s, got %s", tt.input, tt.level, tt.expected, result)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, l := range []Level{None, Patch, Minor, Major} {
+		result, err := ParseLevel(l.String())
+		if err != nil {
+			t.Errorf("ParseLevel(%q): unexpected error: %v", l, err)
+		}
+		if result != l {
+			t.Errorf("Expected %s, got %s", l, result)
+		}
+	}
+
+	if _, err := ParseLevel("huge"); err == nil {
+		t.Errorf("Expected error for invalid level, got nil")
+	}
+}
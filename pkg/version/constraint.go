@@ -0,0 +1,314 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a set of version ranges, such as "^1.2", ">=1.0 <2.0" or
+// "~1.4.x || >=2.1". Ranges are separated by "||" and match when all of
+// their comparators do, which are separated by spaces or commas:
+//
+//   - "=1.2.3", ">1.2.3", ">=1.2.3", "<1.2.3" and "<=1.2.3" compare with a
+//     version, or a partial one: ">1.2" is ">=1.3.0", "<=1.2" is "<1.3.0";
+//   - "1.2", "1.2.x" and "1.x" match the versions they leave open, and "*"
+//     or "x" every version;
+//   - "^1.2.3" allows changes that do not modify the left-most non-zero
+//     number, ">=1.2.3 <2.0.0", and "^0.2.3" is ">=0.2.3 <0.3.0";
+//   - "~1.2.3" allows patch changes, ">=1.2.3 <1.3.0", and "~1" minor ones;
+//     "~>" is the same;
+//   - "1.2 - 1.4" is ">=1.2.0 <1.5.0".
+//
+// As in npm, a prerelease only satisfies a range with a comparator naming a
+// prerelease of the same MAJOR.MINOR.PATCH, so ">=1.2.0-rc.1" matches
+// 1.2.0-rc.2 but not 1.3.0-rc.1, and "^1.2" no prerelease at all.
+type Constraint struct {
+	raw    string
+	ranges [][]comparator
+}
+
+type comparator struct {
+	op string // "=", ">", ">=", "<" or "<="
+	v  Version
+}
+
+// ParseConstraint parses s as a Constraint.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: strings.TrimSpace(s)}
+	for _, part := range strings.Split(s, "||") {
+		r, err := parseRange(part)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("invalid version constraint %q: %v", s, err)
+		}
+		c.ranges = append(c.ranges, r)
+	}
+	return c, nil
+}
+
+// MustParseConstraint is like ParseConstraint but panics on error. Intended
+// for constants and tests.
+func MustParseConstraint(s string) Constraint {
+	c, err := ParseConstraint(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// String returns the constraint as it was parsed.
+func (c Constraint) String() string {
+	return c.raw
+}
+
+// Check reports whether v satisfies c.
+func (c Constraint) Check(v Version) bool {
+	for _, r := range c.ranges {
+		if matches(r, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxSatisfying returns the highest version among tags satisfying c, and
+// the tag naming it. Tags that are not versions, once a leading "v" is
+// dropped, are ignored. The boolean result is false if no tag satisfies c.
+func MaxSatisfying(tags []string, c Constraint) (Version, string, bool) {
+	var (
+		best    Version
+		bestTag string
+		found   bool
+	)
+	for _, t := range tags {
+		v, err := Parse(t)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		if !found || v.GreaterThan(best) {
+			best, bestTag, found = v, t, true
+		}
+	}
+	return best, bestTag, found
+}
+
+func matches(r []comparator, v Version) bool {
+	for _, cmp := range r {
+		if !cmp.matches(v) {
+			return false
+		}
+	}
+	if !v.IsPrerelease() {
+		return true
+	}
+	for _, cmp := range r {
+		if cmp.v.IsPrerelease() && cmp.v.Core().Equal(v.Core()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) matches(v Version) bool {
+	n := v.Compare(c.v)
+	switch c.op {
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	}
+	return n == 0
+}
+
+// parseRange parses the comparators of one range, expanding the operators
+// on partial versions into bounds.
+func parseRange(s string) ([]comparator, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '\t' || r == ',' })
+	if len(fields) == 3 && fields[1] == "-" {
+		lo, err := parsePartial(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		hi, err := parsePartial(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		return append(lo.atLeast(), hi.atMost()...), nil
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty range")
+	}
+
+	var r []comparator
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		op := operator(f)
+		rest := f[len(op):]
+		// Allow a space after the operator, as in ">= 1.2".
+		if rest == "" && op != "" && i+1 < len(fields) {
+			i++
+			rest = fields[i]
+		}
+		p, err := parsePartial(rest)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "^":
+			r = append(r, p.caret()...)
+		case "~", "~>":
+			r = append(r, p.tilde()...)
+		case ">":
+			r = append(r, p.above()...)
+		case ">=":
+			r = append(r, p.atLeast()...)
+		case "<":
+			r = append(r, p.below()...)
+		case "<=":
+			r = append(r, p.atMost()...)
+		default:
+			r = append(r, p.exactly()...)
+		}
+	}
+	return r, nil
+}
+
+func operator(s string) string {
+	for _, op := range []string{">=", "<=", "~>", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// partial is a version with up to three numbers given; n counts them.
+type partial struct {
+	v Version
+	n int
+}
+
+func parsePartial(s string) (partial, error) {
+	raw := strings.TrimPrefix(s, "v")
+	if raw == "" {
+		return partial{}, fmt.Errorf("missing version")
+	}
+	core, suffix := raw, ""
+	if i := strings.IndexAny(raw, "-+"); i >= 0 {
+		core, suffix = raw[:i], raw[i:]
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return partial{}, fmt.Errorf("%q: expected at most MAJOR.MINOR.PATCH", s)
+	}
+	var p partial
+	nums := []*uint64{&p.v.Major, &p.v.Minor, &p.v.Patch}
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := parseNumeric(part)
+		if err != nil {
+			return partial{}, fmt.Errorf("%q: %v", s, err)
+		}
+		*nums[i] = n
+		p.n++
+	}
+	for _, part := range parts[p.n:] {
+		if part != "x" && part != "X" && part != "*" {
+			return partial{}, fmt.Errorf("%q: a number follows a wildcard", s)
+		}
+	}
+	if suffix != "" {
+		if p.n < 3 {
+			return partial{}, fmt.Errorf("%q: prerelease or build metadata on a partial version", s)
+		}
+		full, err := Parse(core + suffix)
+		if err != nil {
+			return partial{}, err
+		}
+		p.v = full
+	}
+	return p, nil
+}
+
+// next returns the first version past every version p leaves open, e.g.
+// 1.3.0 for 1.2.
+func (p partial) next() Version {
+	switch p.n {
+	case 1:
+		return Version{Major: p.v.Major + 1}
+	case 2:
+		return Version{Major: p.v.Major, Minor: p.v.Minor + 1}
+	}
+	return Version{Major: p.v.Major, Minor: p.v.Minor, Patch: p.v.Patch + 1}
+}
+
+// floor returns the lowest version p leaves open.
+func (p partial) floor() Version {
+	if p.n == 3 {
+		return p.v
+	}
+	return Version{Major: p.v.Major, Minor: p.v.Minor}
+}
+
+func (p partial) exactly() []comparator {
+	switch p.n {
+	case 0:
+		return []comparator{{">=", Version{}}}
+	case 3:
+		return []comparator{{"=", p.v}}
+	}
+	return []comparator{{">=", p.floor()}, {"<", p.next()}}
+}
+
+func (p partial) atLeast() []comparator {
+	return []comparator{{">=", p.floor()}}
+}
+
+func (p partial) above() []comparator {
+	switch p.n {
+	case 0:
+		return []comparator{{"<", Version{}}}
+	case 3:
+		return []comparator{{">", p.v}}
+	}
+	return []comparator{{">=", p.next()}}
+}
+
+func (p partial) below() []comparator {
+	return []comparator{{"<", p.floor()}}
+}
+
+func (p partial) atMost() []comparator {
+	switch p.n {
+	case 0:
+		return []comparator{{">=", Version{}}}
+	case 3:
+		return []comparator{{"<=", p.v}}
+	}
+	return []comparator{{"<", p.next()}}
+}
+
+func (p partial) tilde() []comparator {
+	if p.n == 0 {
+		return p.exactly()
+	}
+	upper := partial{v: p.v, n: min(p.n, 2)}
+	return []comparator{{">=", p.floor()}, {"<", upper.next()}}
+}
+
+func (p partial) caret() []comparator {
+	switch {
+	case p.n == 0:
+		return p.exactly()
+	case p.v.Major > 0 || p.n == 1:
+		return []comparator{{">=", p.floor()}, {"<", partial{v: p.v, n: 1}.next()}}
+	case p.v.Minor > 0 || p.n == 2:
+		return []comparator{{">=", p.floor()}, {"<", partial{v: p.v, n: 2}.next()}}
+	}
+	return []comparator{{">=", p.floor()}, {"<", partial{v: p.v, n: 3}.next()}}
+}
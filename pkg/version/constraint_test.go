@@ -0,0 +1,80 @@
+package version
+
+import "testing"
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		in, out    []string
+	}{
+		{"^1.2", []string{"1.2.0", "1.9.3"}, []string{"1.1.9", "2.0.0", "1.3.0-rc.1"}},
+		{"^1.2.3", []string{"1.2.3", "1.8.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^0", []string{"0.0.1", "0.9.0"}, []string{"1.0.0"}},
+		{">=1.0 <2.0", []string{"1.0.0", "1.99.0"}, []string{"0.9.9", "2.0.0", "2.0.0-rc.1"}},
+		{">=1.0, <2.0", []string{"1.5.0"}, []string{"2.0.0"}},
+		{">= 1.0 < 2.0", []string{"1.5.0"}, []string{"2.1.0"}},
+		{"~1.4.x", []string{"1.4.0", "1.4.12"}, []string{"1.3.9", "1.5.0"}},
+		{"~1.4.2", []string{"1.4.2", "1.4.3"}, []string{"1.4.1", "1.5.0"}},
+		{"~>1.4.2", []string{"1.4.9"}, []string{"1.5.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"1.2.x", []string{"1.2.0", "1.2.7"}, []string{"1.3.0"}},
+		{"1.x", []string{"1.0.0", "1.9.0"}, []string{"2.0.0", "0.9.0"}},
+		{"1.2", []string{"1.2.5"}, []string{"1.3.0"}},
+		{"=1.2.3", []string{"1.2.3", "v1.2.3+build.1"}, []string{"1.2.4"}},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{">1.2.3", []string{"1.2.4"}, []string{"1.2.3"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"<1.2", []string{"1.1.9"}, []string{"1.2.0"}},
+		{"*", []string{"0.0.0", "3.0.0"}, []string{"1.0.0-rc.1"}},
+		{"1.2 - 1.4", []string{"1.2.0", "1.4.9"}, []string{"1.1.0", "1.5.0"}},
+		{"^1.2 || >=3", []string{"1.5.0", "3.1.0"}, []string{"2.0.0"}},
+		{">=1.2.0-rc.1", []string{"1.2.0-rc.2", "1.2.0", "1.3.0"}, []string{"1.2.0-beta.1", "1.3.0-rc.1"}},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): unexpected error: %v", tt.constraint, err)
+		}
+		for _, v := range tt.in {
+			if !c.Check(MustParse(v)) {
+				t.Errorf("Expected %s to satisfy %q", v, tt.constraint)
+			}
+		}
+		for _, v := range tt.out {
+			if c.Check(MustParse(v)) {
+				t.Errorf("Expected %s not to satisfy %q", v, tt.constraint)
+			}
+		}
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, s := range []string{"", "^", ">=1.0 ||", "1.2.3.4", "1.x.3", "a.b", "~1.2-rc.1", ">=01.0"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q): expected an error", s)
+		}
+	}
+}
+
+func TestConstraintString(t *testing.T) {
+	if s := MustParseConstraint(" ^1.2 ").String(); s != "^1.2" {
+		t.Errorf("Expected %q, got %q", "^1.2", s)
+	}
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	tags := []string{"v1.2.0", "v1.4.1", "v1.10.0", "v2.0.0", "v1.11.0-rc.1", "nightly"}
+
+	v, tag, ok := MaxSatisfying(tags, MustParseConstraint("^1.2"))
+	if !ok || tag != "v1.10.0" || v.String() != "1.10.0" {
+		t.Errorf("Expected v1.10.0, got %q (%s, %v)", tag, v, ok)
+	}
+	if _, tag, _ := MaxSatisfying(tags, MustParseConstraint("~1.4.x")); tag != "v1.4.1" {
+		t.Errorf("Expected v1.4.1, got %q", tag)
+	}
+	if _, tag, ok := MaxSatisfying(tags, MustParseConstraint(">=3")); ok {
+		t.Errorf("Expected no match, got %q", tag)
+	}
+}
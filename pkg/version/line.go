@@ -0,0 +1,54 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Line is a maintenance release line such as "1.x", every version of major
+// version 1, or "1.4.x", the patches of 1.4.
+type Line struct {
+	Major uint64
+	Minor uint64
+	// AnyMinor is true for a line of a whole major version.
+	AnyMinor bool
+}
+
+// ParseLine parses s as "MAJOR.x" or "MAJOR.MINOR.x". A single leading "v"
+// is ignored, so branch names such as "release/v1.x" can end in a line.
+func ParseLine(s string) (Line, error) {
+	raw, ok := strings.CutSuffix(strings.TrimPrefix(s, "v"), ".x")
+	if !ok {
+		return Line{}, fmt.Errorf("invalid release line %q: expected MAJOR.x or MAJOR.MINOR.x", s)
+	}
+	parts := strings.Split(raw, ".")
+	if len(parts) > 2 {
+		return Line{}, fmt.Errorf("invalid release line %q: expected MAJOR.x or MAJOR.MINOR.x", s)
+	}
+	nums := make([]uint64, len(parts))
+	for i, p := range parts {
+		n, err := parseNumeric(p)
+		if err != nil {
+			return Line{}, fmt.Errorf("invalid release line %q: %v", s, err)
+		}
+		nums[i] = n
+	}
+	l := Line{Major: nums[0], AnyMinor: len(nums) == 1}
+	if !l.AnyMinor {
+		l.Minor = nums[1]
+	}
+	return l, nil
+}
+
+// Contains reports whether v belongs to the line.
+func (l Line) Contains(v Version) bool {
+	return v.Major == l.Major && (l.AnyMinor || v.Minor == l.Minor)
+}
+
+func (l Line) String() string {
+	if l.AnyMinor {
+		return strconv.FormatUint(l.Major, 10) + ".x"
+	}
+	return strconv.FormatUint(l.Major, 10) + "." + strconv.FormatUint(l.Minor, 10) + ".x"
+}
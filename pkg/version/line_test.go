@@ -0,0 +1,40 @@
+package version
+
+import "testing"
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+		in, out  []string
+	}{
+		{"1.x", "1.x", []string{"1.0.0", "1.9.3", "1.2.0-rc.1"}, []string{"0.9.0", "2.0.0"}},
+		{"v1.4.x", "1.4.x", []string{"1.4.0", "1.4.12"}, []string{"1.3.9", "1.5.0", "2.4.0"}},
+		{"0.x", "0.x", []string{"0.1.0"}, []string{"1.0.0"}},
+	}
+	for _, tt := range tests {
+		l, err := ParseLine(tt.line)
+		if err != nil {
+			t.Fatalf("ParseLine(%q): unexpected error: %v", tt.line, err)
+		}
+		if l.String() != tt.expected {
+			t.Errorf("ParseLine(%q): Expected %s, got %s", tt.line, tt.expected, l)
+		}
+		for _, v := range tt.in {
+			if !l.Contains(MustParse(v)) {
+				t.Errorf("Expected %s to contain %s", l, v)
+			}
+		}
+		for _, v := range tt.out {
+			if l.Contains(MustParse(v)) {
+				t.Errorf("Expected %s not to contain %s", l, v)
+			}
+		}
+	}
+
+	for _, s := range []string{"", "1", "1.4", "x", "1.2.3.x", "01.x", "a.x", "1.x.x"} {
+		if _, err := ParseLine(s); err == nil {
+			t.Errorf("ParseLine(%q): expected an error", s)
+		}
+	}
+}
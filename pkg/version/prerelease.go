@@ -0,0 +1,55 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NextPrerelease returns the next build of core on a prerelease channel such
+// as "alpha", "beta" or "rc": core with the prerelease "<channel>.<n>",
+// where n is one more than the highest counter already used for that core
+// and channel in existing. The first build on a channel is "<channel>.1".
+func NextPrerelease(core Version, channel string, existing []Version) (Version, error) {
+	core = core.Core()
+	var n uint64
+	for _, v := range existing {
+		if c, ok := prereleaseCounter(v, core, channel); ok && c > n {
+			n = c
+		}
+	}
+	return core.WithPrerelease(channel, strconv.FormatUint(n+1, 10))
+}
+
+// prereleaseCounter returns n when v is "<core>-<channel>.<n>".
+func prereleaseCounter(v, core Version, channel string) (uint64, bool) {
+	if !v.Core().Equal(core) || len(v.Prerelease) != 2 || v.Prerelease[0] != channel {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(v.Prerelease[1], 10, 64)
+	return n, err == nil
+}
+
+// Snapshot returns the version of a snapshot build of core, made on day t
+// from commit: core with the prerelease "<channel>.<yyyymmdd>.<commit>",
+// such as "1.4.0-dev.20240601.abc1234", for nightly builds between
+// releases. The day is taken in UTC, and commit is abbreviated to its
+// first seven characters. An abbreviation of digits only gets a "g"
+// prefix, as git describe gives it: as a number, it would sort by value
+// and could not start with 0.
+func Snapshot(core Version, channel string, t time.Time, commit string) (Version, error) {
+	commit = strings.ToLower(commit)
+	if !commitPattern.MatchString(commit) {
+		return Version{}, fmt.Errorf("%w: snapshot: %q is not a commit hash", ErrInvalid, commit)
+	}
+	short := commit[:7]
+	if isDigits(short) {
+		short = "g" + short
+	}
+	return core.Core().WithPrerelease(channel, t.UTC().Format("20060102"), short)
+}
+
+// commitPattern matches a commit hash, possibly abbreviated.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{7,64}$`)
@@ -0,0 +1,77 @@
+package version
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextPrerelease(t *testing.T) {
+	existing := []Version{
+		MustParse("1.2.0"),
+		MustParse("1.3.0-rc.1"),
+		MustParse("1.3.0-rc.2"),
+		MustParse("1.3.0-beta.7"),
+		MustParse("1.3.0-rc.final"),
+		MustParse("1.4.0-rc.9"),
+	}
+
+	tests := []struct {
+		core, channel, expected string
+	}{
+		{"1.3.0", "rc", "1.3.0-rc.3"},
+		{"1.3.0", "beta", "1.3.0-beta.8"},
+		{"1.3.0", "alpha", "1.3.0-alpha.1"},
+		{"2.0.0", "rc", "2.0.0-rc.1"},
+		{"1.3.0-rc.2+build.5", "rc", "1.3.0-rc.3"},
+	}
+	for _, tt := range tests {
+		got, err := NextPrerelease(MustParse(tt.core), tt.channel, existing)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.String() != tt.expected {
+			t.Errorf("NextPrerelease(%s, %s): Expected %s, got %s", tt.core, tt.channel, tt.expected, got)
+		}
+	}
+}
+
+func TestNextPrereleaseInvalidChannel(t *testing.T) {
+	if _, err := NextPrerelease(MustParse("1.0.0"), "r c", nil); err == nil {
+		t.Error("Expected an error for an invalid channel name")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	day := time.Date(2024, 6, 1, 23, 30, 0, 0, time.FixedZone("PDT", -7*3600))
+	tests := []struct {
+		core, commit, expected string
+	}{
+		{"1.4.0", "abc1234def5678", "1.4.0-dev.20240602.abc1234"},
+		{"1.4.0-rc.2+build.5", "ABC1234", "1.4.0-dev.20240602.abc1234"},
+		{"2.0.0", "0123456789abcdef", "2.0.0-dev.20240602.g0123456"},
+	}
+	for _, tt := range tests {
+		got, err := Snapshot(MustParse(tt.core), "dev", day, tt.commit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.String() != tt.expected {
+			t.Errorf("Snapshot(%s, %s): Expected %s, got %s", tt.core, tt.commit, tt.expected, got)
+		}
+		if _, err := Parse(got.String()); err != nil {
+			t.Errorf("Expected %s to parse, got %v", got, err)
+		}
+	}
+
+	older, _ := Snapshot(MustParse("1.4.0"), "dev", day.AddDate(0, 0, -1), "fff0000")
+	newer, _ := Snapshot(MustParse("1.4.0"), "dev", day, "0000000")
+	if !older.LessThan(newer) || !newer.LessThan(MustParse("1.4.0-rc.1")) {
+		t.Errorf("Expected %s < %s < 1.4.0-rc.1", older, newer)
+	}
+
+	for _, commit := range []string{"abc12", "not-a-hash", ""} {
+		if _, err := Snapshot(MustParse("1.4.0"), "dev", day, commit); err == nil {
+			t.Errorf("Expected an error for commit %q", commit)
+		}
+	}
+}
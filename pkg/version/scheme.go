@@ -0,0 +1,105 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scheme computes the version released after the latest one.
+type Scheme interface {
+	// Next returns the version following latest for changes of level l
+	// released at t. Level None returns the core of latest unchanged.
+	Next(latest Version, l Level, t time.Time) Version
+}
+
+// Schemes lists the names accepted by ParseScheme.
+var Schemes = []string{"semver", "calver"}
+
+// ParseScheme returns the scheme called name. layout is the CalVer layout
+// and must be empty for SemVer.
+func ParseScheme(name, layout string) (Scheme, error) {
+	switch name {
+	case "", "semver":
+		if layout != "" {
+			return nil, fmt.Errorf("invalid version scheme: layout %q needs calver", layout)
+		}
+		return SemVer{}, nil
+	case "calver":
+		return ParseCalVer(layout)
+	}
+	return nil, fmt.Errorf("invalid version scheme %q: must be one of %s", name, strings.Join(Schemes, ", "))
+}
+
+// SemVer is the default scheme: the latest version is bumped by the level
+// derived from the commits.
+type SemVer struct{}
+
+// Next returns latest.Bump(l).
+func (SemVer) Next(latest Version, l Level, _ time.Time) Version {
+	return latest.Bump(l)
+}
+
+// DefaultCalVerLayout is used by ParseCalVer for an empty layout.
+const DefaultCalVerLayout = "YYYY.MM.MICRO"
+
+// CalVer is calendar versioning (https://calver.org): the major and minor
+// numbers come from the release date and the patch number, MICRO, counts
+// releases within that period from 0.
+//
+// Layouts are three dot-separated segments: a year (YYYY for 2026, YY for
+// 26), a period (MM for the month, WW for the ISO week) and MICRO. The
+// zero-padded tokens 0M and 0W are not supported, as "2026.03.0" is not a
+// valid semantic version.
+type CalVer struct {
+	year, period string
+}
+
+// ParseCalVer parses a CalVer layout such as "YYYY.MM.MICRO".
+func ParseCalVer(layout string) (CalVer, error) {
+	if layout == "" {
+		layout = DefaultCalVerLayout
+	}
+	parts := strings.Split(layout, ".")
+	if len(parts) != 3 || (parts[0] != "YYYY" && parts[0] != "YY") || (parts[1] != "MM" && parts[1] != "WW") || parts[2] != "MICRO" {
+		return CalVer{}, fmt.Errorf("invalid calver layout %q: must be YYYY or YY, then MM or WW, then MICRO, e.g. %s", layout, DefaultCalVerLayout)
+	}
+	return CalVer{year: parts[0], period: parts[1]}, nil
+}
+
+// String returns the layout of c.
+func (c CalVer) String() string {
+	if c.year == "" {
+		return DefaultCalVerLayout
+	}
+	return c.year + "." + c.period + ".MICRO"
+}
+
+// Next returns the version for the period containing t. MICRO continues
+// from latest when it is in the same or a later period, as after a clock
+// change, and starts at 0 otherwise. The level only matters when it is
+// None.
+func (c CalVer) Next(latest Version, l Level, t time.Time) Version {
+	latest = latest.Core()
+	if l == None {
+		return latest
+	}
+	next := c.date(t)
+	if latest.Major > next.Major || (latest.Major == next.Major && latest.Minor >= next.Minor) {
+		latest.Patch++
+		return latest
+	}
+	return next
+}
+
+// date returns the version of the first release in the period containing t.
+func (c CalVer) date(t time.Time) Version {
+	year, period := t.Year(), int(t.Month())
+	if c.period == "WW" {
+		year, period = t.ISOWeek()
+	}
+	if c.year == "YY" {
+		year -= 2000
+	}
+	return Version{Major: uint64(year), Minor: uint64(period)}
+}
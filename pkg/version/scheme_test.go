@@ -0,0 +1,58 @@
+package version
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheme(t *testing.T) {
+	if s, err := ParseScheme("", ""); err != nil || s != (SemVer{}) {
+		t.Errorf("Expected SemVer by default, got %v %v", s, err)
+	}
+	if s, err := ParseScheme("calver", ""); err != nil || s.(CalVer).String() != DefaultCalVerLayout {
+		t.Errorf("Expected the default CalVer layout, got %v %v", s, err)
+	}
+
+	for _, tt := range [][2]string{{"semver", "YYYY.MM.MICRO"}, {"romver", ""}, {"calver", "YYYY.0M.MICRO"}, {"calver", "YYYY.MM.DD"}, {"calver", "MICRO.MM.YYYY"}} {
+		if _, err := ParseScheme(tt[0], tt[1]); err == nil {
+			t.Errorf("ParseScheme(%q, %q): Expected an error", tt[0], tt[1])
+		}
+	}
+}
+
+func TestSemVerNext(t *testing.T) {
+	if got := (SemVer{}).Next(MustParse("1.2.3"), Minor, time.Time{}); got.String() != "1.3.0" {
+		t.Errorf("Expected 1.3.0, got %s", got)
+	}
+}
+
+func TestCalVerNext(t *testing.T) {
+	march := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		layout   string
+		latest   string
+		level    Level
+		at       time.Time
+		expected string
+	}{
+		{"YYYY.MM.MICRO", "0.0.0", Patch, march, "2026.3.0"},
+		{"YYYY.MM.MICRO", "2026.2.4", Minor, march, "2026.3.0"},
+		{"YYYY.MM.MICRO", "2026.3.0", Patch, march, "2026.3.1"},
+		{"YYYY.MM.MICRO", "2026.3.1", Major, march, "2026.3.2"},
+		{"YYYY.MM.MICRO", "2026.3.1", None, march, "2026.3.1"},
+		{"YYYY.MM.MICRO", "2026.4.0", Patch, march, "2026.4.1"},
+		{"YYYY.MM.MICRO", "2025.12.7", Patch, march, "2026.3.0"},
+		{"YY.MM.MICRO", "25.12.7", Patch, march, "26.3.0"},
+		{"YYYY.WW.MICRO", "2026.11.0", Patch, march, "2026.11.1"},
+		{"YY.WW.MICRO", "0.0.0", Patch, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), "26.53.0"},
+	}
+	for _, tt := range tests {
+		c, err := ParseCalVer(tt.layout)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := c.Next(MustParse(tt.latest), tt.level, tt.at); got.String() != tt.expected {
+			t.Errorf("%s.Next(%s, %s): Expected %s, got %s", tt.layout, tt.latest, tt.level, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package version
+
+import "sort"
+
+// Collection is a list of versions that sorts in ascending precedence.
+type Collection []Version
+
+func (c Collection) Len() int           { return len(c) }
+func (c Collection) Less(i, j int) bool { return c[i].LessThan(c[j]) }
+func (c Collection) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// Sort sorts vs in ascending order of precedence. The sort is stable so
+// versions that differ only in build metadata keep their input order.
+func Sort(vs []Version) {
+	sort.Stable(Collection(vs))
+}
+
+// Latest returns the highest version in vs. When stable is true, prerelease
+// versions are skipped. The boolean result is false if no version qualifies.
+func Latest(vs []Version, stable bool) (Version, bool) {
+	var (
+		best  Version
+		found bool
+	)
+	for _, v := range vs {
+		if stable && v.IsPrerelease() {
+			continue
+		}
+		if !found || v.GreaterThan(best) {
+			best, found = v, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,39 @@
+package version
+
+import "testing"
+
+func TestSort(t *testing.T) {
+	input := []string{"1.10.0", "1.2.0", "1.2.0-rc.2", "0.9.9", "1.2.0-rc.10", "2.0.0-alpha"}
+	expected := []string{"0.9.9", "1.2.0-rc.2", "1.2.0-rc.10", "1.2.0", "1.10.0", "2.0.0-alpha"}
+
+	vs := make([]Version, len(input))
+	for i, s := range input {
+		vs[i] = MustParse(s)
+	}
+
+	Sort(vs)
+
+	for i, v := range vs {
+		if v.String() != expected[i] {
+			t.Errorf("index %d: expected %s, got %s", i, expected[i], v)
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	vs := []Version{MustParse("1.0.0"), MustParse("1.1.0-rc.1"), MustParse("0.9.0")}
+
+	latest, ok := Latest(vs, false)
+	if !ok || latest.String() != "1.1.0-rc.1" {
+		t.Errorf("Expected 1.1.0-rc.1, got %s (ok=%v)", latest, ok)
+	}
+
+	stable, ok := Latest(vs, true)
+	if !ok || stable.String() != "1.0.0" {
+		t.Errorf("Expected 1.0.0, got %s (ok=%v)", stable, ok)
+	}
+
+	if _, ok := Latest(nil, false); ok {
+		t.Errorf("Expected no latest version for empty input")
+	}
+}
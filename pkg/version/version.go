@@ -0,0 +1,266 @@
+// Package version implements Semantic Versioning 2.0.0 (https://semver.org).
+//
+// Versions are parsed from strings such as "1.2.3", "v1.2.3-rc.1" or
+// "1.2.3+build.5". A leading "v" is accepted on input so git tags can be
+// parsed directly, but is never included by String.
+//
+// A Scheme decides which version follows a release: SemVer bumps it by the
+// level derived from commits, CalVer derives it from the release date.
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is returned (wrapped) when a string is not a valid semantic version.
+var ErrInvalid = errors.New("invalid semantic version")
+
+// Version is a parsed semantic version.
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// Parse parses s as a semantic version. A single leading "v" is ignored.
+func Parse(s string) (Version, error) {
+	var v Version
+
+	raw := strings.TrimPrefix(s, "v")
+	if raw == "" {
+		return v, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+
+	if i := strings.IndexByte(raw, '+'); i >= 0 {
+		build, err := parseIdentifiers(raw[i+1:], false)
+		if err != nil {
+			return v, fmt.Errorf("%w: %q: build metadata: %v", ErrInvalid, s, err)
+		}
+		v.Build = build
+		raw = raw[:i]
+	}
+
+	if i := strings.IndexByte(raw, '-'); i >= 0 {
+		pre, err := parseIdentifiers(raw[i+1:], true)
+		if err != nil {
+			return v, fmt.Errorf("%w: %q: prerelease: %v", ErrInvalid, s, err)
+		}
+		v.Prerelease = pre
+		raw = raw[:i]
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("%w: %q: expected MAJOR.MINOR.PATCH", ErrInvalid, s)
+	}
+
+	nums := make([]uint64, 3)
+	for i, p := range parts {
+		n, err := parseNumeric(p)
+		if err != nil {
+			return v, fmt.Errorf("%w: %q: %v", ErrInvalid, s, err)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	return v, nil
+}
+
+// MustParse is like Parse but panics on error. Intended for constants and tests.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// String returns the canonical form of v, without a "v" prefix.
+func (v Version) String() string {
+	var b strings.Builder
+	b.WriteString(strconv.FormatUint(v.Major, 10))
+	b.WriteByte('.')
+	b.WriteString(strconv.FormatUint(v.Minor, 10))
+	b.WriteByte('.')
+	b.WriteString(strconv.FormatUint(v.Patch, 10))
+	if len(v.Prerelease) > 0 {
+		b.WriteByte('-')
+		b.WriteString(strings.Join(v.Prerelease, "."))
+	}
+	if len(v.Build) > 0 {
+		b.WriteByte('+')
+		b.WriteString(strings.Join(v.Build, "."))
+	}
+	return b.String()
+}
+
+// IsPrerelease reports whether v carries prerelease identifiers.
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Core returns v with prerelease and build metadata removed.
+func (v Version) Core() Version {
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// WithPrerelease returns a copy of v with the given prerelease identifiers.
+func (v Version) WithPrerelease(ids ...string) (Version, error) {
+	for _, id := range ids {
+		if err := validIdentifier(id, true); err != nil {
+			return v, fmt.Errorf("%w: prerelease: %v", ErrInvalid, err)
+		}
+	}
+	v.Prerelease = append([]string(nil), ids...)
+	return v, nil
+}
+
+// WithBuild returns a copy of v with the given build metadata identifiers.
+func (v Version) WithBuild(ids ...string) (Version, error) {
+	for _, id := range ids {
+		if err := validIdentifier(id, false); err != nil {
+			return v, fmt.Errorf("%w: build metadata: %v", ErrInvalid, err)
+		}
+	}
+	v.Build = append([]string(nil), ids...)
+	return v, nil
+}
+
+// Compare returns -1, 0 or +1 depending on whether v sorts before, equal to
+// or after o. Build metadata is ignored, as required by the specification.
+func (v Version) Compare(o Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// Equal reports whether v and o have the same precedence.
+func (v Version) Equal(o Version) bool {
+	return v.Compare(o) == 0
+}
+
+// LessThan reports whether v has lower precedence than o.
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// GreaterThan reports whether v has higher precedence than o.
+func (v Version) GreaterThan(o Version) bool {
+	return v.Compare(o) > 0
+}
+
+// Compare is a function form of Version.Compare, suitable for slices.SortFunc.
+func Compare(a, b Version) int {
+	return a.Compare(b)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease implements precedence rule 11 of the specification:
+// a version without prerelease identifiers has higher precedence, numeric
+// identifiers compare numerically and sort before alphanumeric ones, and a
+// shorter set of identifiers sorts first when all preceding ones are equal.
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(a)), uint64(len(b)))
+}
+
+func compareIdentifier(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	aNum, bNum := aErr == nil && isDigits(a), bErr == nil && isDigits(b)
+
+	switch {
+	case aNum && bNum:
+		return compareUint(an, bn)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func parseNumeric(s string) (uint64, error) {
+	if s == "" {
+		return 0, errors.New("empty numeric component")
+	}
+	if !isDigits(s) {
+		return 0, fmt.Errorf("non-numeric component %q", s)
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("leading zero in %q", s)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func parseIdentifiers(s string, prerelease bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+	for _, id := range ids {
+		if err := validIdentifier(id, prerelease); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func validIdentifier(id string, prerelease bool) error {
+	if id == "" {
+		return errors.New("empty identifier")
+	}
+	for _, r := range id {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+			return fmt.Errorf("invalid character %q in identifier %q", r, id)
+		}
+	}
+	if prerelease && len(id) > 1 && id[0] == '0' && isDigits(id) {
+		return fmt.Errorf("leading zero in numeric identifier %q", id)
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
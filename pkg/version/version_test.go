@@ -0,0 +1,162 @@
+package version
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Version
+	}{
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"v1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"0.0.0", Version{}},
+		{"1.2.3-rc1", Version{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"rc1"}}},
+		{"1.2.3-rc.1", Version{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"rc", "1"}}},
+		{"1.2.3+build.5", Version{Major: 1, Minor: 2, Patch: 3, Build: []string{"build", "5"}}},
+		{"1.2.3-beta.2+sha.abc123", Version{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"beta", "2"}, Build: []string{"sha", "abc123"}}},
+		{"1.0.0-x-y-z.--", Version{Major: 1, Prerelease: []string{"x-y-z", "--"}}},
+		{"1.0.0+001", Version{Major: 1, Build: []string{"001"}}},
+	}
+
+	for _, tt := range tests {
+		result, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q): unexpected error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("Parse(%q): expected %#v, got %#v", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	inputs := []string{
+		"",
+		"v",
+		"1",
+		"1.2",
+		"1.2.3.4",
+		"01.2.3",
+		"1.02.3",
+		"1.2.x",
+		"1.2.3-",
+		"1.2.3-01",
+		"1.2.3-rc..1",
+		"1.2.3+",
+		"1.2.3-rc_1",
+		"vv1.2.3",
+	}
+
+	for _, input := range inputs {
+		_, err := Parse(input)
+		if err == nil {
+			t.Errorf("Parse(%q): expected error, got nil", input)
+			continue
+		}
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q): expected ErrInvalid, got %v", input, err)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	inputs := []string{"1.2.3", "0.1.0-rc.1", "1.0.0-alpha+001", "2.0.0+build.1.2"}
+
+	for _, input := range inputs {
+		result := MustParse(input).String()
+		if result != input {
+			t.Errorf("Expected %q, got %q", input, result)
+		}
+	}
+
+	result := MustParse("v3.4.5").String()
+	if result != "3.4.5" {
+		t.Errorf("Expected %q, got %q", "3.4.5", result)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Ordered according to the example in section 11 of the specification.
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			a, b := MustParse(ordered[i]), MustParse(ordered[j])
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			if result := a.Compare(b); result != expected {
+				t.Errorf("Compare(%s, %s): expected %d, got %d", a, b, expected, result)
+			}
+		}
+	}
+}
+
+func TestCompareIgnoresBuild(t *testing.T) {
+	a := MustParse("1.0.0+build.1")
+	b := MustParse("1.0.0+build.2")
+
+	if !a.Equal(b) {
+		t.Errorf("Expected %s to equal %s", a, b)
+	}
+}
+
+func TestWithPrerelease(t *testing.T) {
+	v, err := MustParse("1.2.3").WithPrerelease("rc", "2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.String() != "1.2.3-rc.2" {
+		t.Errorf("Expected %q, got %q", "1.2.3-rc.2", v.String())
+	}
+
+	if _, err := v.WithPrerelease("01"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestWithBuild(t *testing.T) {
+	v, err := MustParse("1.2.3-rc.1").WithBuild("sha", "0abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.String() != "1.2.3-rc.1+sha.0abc" {
+		t.Errorf("Expected %q, got %q", "1.2.3-rc.1+sha.0abc", v.String())
+	}
+
+	if _, err := v.WithBuild("a b"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestCore(t *testing.T) {
+	v := MustParse("1.2.3-rc.1+build.9")
+
+	result := v.Core().String()
+	if result != "1.2.3" {
+		t.Errorf("Expected %q, got %q", "1.2.3", result)
+	}
+	if !v.IsPrerelease() {
+		t.Errorf("Expected %s to be a prerelease", v)
+	}
+}
@@ -0,0 +1,122 @@
+package workspace
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+// Filter selects the commits of a Module by path, by scope, or both.
+type Filter string
+
+const (
+	// FilterPath selects the commits touching the module's directory. It
+	// is the default.
+	FilterPath Filter = "path"
+	// FilterScope selects the commits with one of the module's scopes,
+	// wherever they touch.
+	FilterScope Filter = "scope"
+	// FilterPathOrScope selects the commits of either filter.
+	FilterPathOrScope Filter = "path_or_scope"
+	// FilterPathAndScope selects the commits touching the directory with
+	// one of the module's scopes; unscoped commits are left out.
+	FilterPathAndScope Filter = "path_and_scope"
+)
+
+// byScope reports whether the scopes of m take part in selecting its
+// commits.
+func (m Module) byScope() bool {
+	return m.Filter != "" && m.Filter != FilterPath && len(m.Scopes) > 0
+}
+
+// byPath reports whether the paths of m take part in selecting its commits.
+func (m Module) byPath() bool {
+	return m.Filter != FilterScope || !m.byScope()
+}
+
+// InScope reports whether a commit with scope belongs to m by scope.
+func (m Module) InScope(scope string) bool {
+	return scope != "" && slices.Contains(m.Scopes, scope)
+}
+
+// Select returns the commits of m among raw, in order, and their
+// conventional commits. inPath reports whether a commit touches the paths
+// of m. Under FilterPath every commit in path is kept whole; otherwise
+// only the conventional commits in one of m's scopes are, except that
+// FilterPathOrScope keeps the commits in path whole too.
+func (m Module) Select(raw []gitrepo.Commit, inPath func(hash string) bool) ([]gitrepo.Commit, []commits.Commit) {
+	if !m.byScope() {
+		var kept []gitrepo.Commit
+		for _, c := range raw {
+			if inPath(c.Hash) {
+				kept = append(kept, c)
+			}
+		}
+		return kept, Parse(kept)
+	}
+
+	var kept []gitrepo.Commit
+	var cs []commits.Commit
+	for _, rc := range raw {
+		path := inPath(rc.Hash)
+		if m.Filter == FilterPathAndScope && !path {
+			continue
+		}
+		whole := m.Filter == FilterPathOrScope && path
+		n := len(cs)
+		for _, c := range Parse([]gitrepo.Commit{rc}) {
+			if whole || m.InScope(c.Scope) {
+				cs = append(cs, c)
+			}
+		}
+		if whole || len(cs) > n {
+			kept = append(kept, rc)
+		}
+	}
+	return kept, cs
+}
+
+// commitsSince returns the commits of m since ref, and their conventional
+// commits, without those reverted since ref (see DropReverted).
+func (m Module) commitsSince(ctx context.Context, repo gitrepo.Repository, ref string) ([]gitrepo.Commit, []commits.Commit, error) {
+	if !m.byScope() {
+		raw, err := repo.CommitsSince(ctx, ref, m.Paths()...)
+		if err != nil {
+			return nil, nil, err
+		}
+		raw = DropReverted(raw)
+		return raw, Parse(raw), nil
+	}
+	raw, err := repo.CommitsSince(ctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	inPath, err := m.pathFilter(ctx, repo, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	kept, cs := m.Select(DropReverted(raw), inPath)
+	return kept, cs, nil
+}
+
+// pathFilter reports which commits since ref touch the paths of m.
+func (m Module) pathFilter(ctx context.Context, repo gitrepo.Repository, ref string) (func(string) bool, error) {
+	paths := m.Paths()
+	if !m.byPath() {
+		return func(string) bool { return false }, nil
+	}
+	if len(paths) == 0 {
+		return func(string) bool { return true }, nil
+	}
+	scoped, err := repo.CommitsSince(ctx, ref, paths...)
+	if err != nil {
+		return nil, err
+	}
+	in := make(map[string]bool, len(scoped))
+	for _, c := range scoped {
+		in[c.Hash] = true
+	}
+	return func(h string) bool { return in[h] }, nil
+}
@@ -0,0 +1,96 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+// pathRepo returns the commits touching the directory when asked for paths.
+type pathRepo struct {
+	fakeRepo
+	inPath []gitrepo.Commit
+}
+
+func (f *pathRepo) CommitsSince(ctx context.Context, ref string, paths ...string) ([]gitrepo.Commit, error) {
+	if len(paths) > 0 {
+		return f.inPath, nil
+	}
+	return f.fakeRepo.CommitsSince(ctx, ref)
+}
+
+func TestSelect(t *testing.T) {
+	raw := []gitrepo.Commit{
+		{Hash: "a", Message: "feat(api): endpoint"},
+		{Hash: "b", Message: "fix(web): button"},
+		{Hash: "c", Message: "docs: readme"},
+		{Hash: "d", Message: "Squashed fixes (#7)\n\n* fix(api): timeout\n* fix(web): layout"},
+	}
+	inPath := map[string]bool{"b": true, "c": true, "d": true}
+
+	tests := []struct {
+		filter   Filter
+		expected string
+		types    int
+	}{
+		{FilterPath, "bcd", 4},
+		{FilterScope, "ad", 2},
+		{FilterPathOrScope, "abcd", 5},
+		{FilterPathAndScope, "d", 1},
+	}
+	for _, tt := range tests {
+		m := Module{Dir: "services/api", Scopes: []string{"api"}, Filter: tt.filter}
+		kept, cs := m.Select(raw, func(h string) bool { return inPath[h] })
+		if got := hashes(kept); got != tt.expected {
+			t.Errorf("%s: Expected %v, got %v", tt.filter, tt.expected, got)
+		}
+		if len(cs) != tt.types {
+			t.Errorf("%s: Expected %d conventional commits, got %d: %+v", tt.filter, tt.types, len(cs), cs)
+		}
+	}
+}
+
+func TestNewPlanScope(t *testing.T) {
+	repo := &pathRepo{
+		fakeRepo: fakeRepo{
+			tags: []gitrepo.Tag{{Name: "services/api/v1.0.0"}},
+			commits: []gitrepo.Commit{
+				{Hash: "a", Message: "feat(web): new page"},
+				{Hash: "b", Message: "fix(api): timeout"},
+			},
+		},
+		inPath: []gitrepo.Commit{{Hash: "a", Message: "feat(web): new page"}},
+	}
+	m := Module{Dir: "services/api", TagPrefix: "services/api/v", Scopes: []string{"api"}, Filter: FilterScope}
+
+	p, err := NewPlan(context.Background(), repo, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := hashes(p.Raw); got != "b" {
+		t.Errorf("Expected b, got %q", got)
+	}
+	if p.Next.String() != "1.0.1" {
+		t.Errorf("Expected 1.0.1, got %s", p.Next)
+	}
+}
+
+func TestHistoryScope(t *testing.T) {
+	repo := &pathRepo{fakeRepo: fakeRepo{
+		tags: []gitrepo.Tag{{Name: "v1.0.0", Commit: "b"}},
+		commits: []gitrepo.Commit{
+			{Hash: "b", Parents: []string{"a"}, Message: "feat(api): endpoint"},
+			{Hash: "a", Message: "feat(web): page"},
+		},
+	}}
+	m := Module{TagPrefix: "v", Scopes: []string{"api"}, Filter: FilterPathAndScope}
+
+	releases, err := History(context.Background(), repo, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(releases) != 1 || hashes(releases[0].Raw) != "b" {
+		t.Errorf("Expected v1.0.0 with b, got %+v", releases)
+	}
+}
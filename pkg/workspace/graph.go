@@ -0,0 +1,93 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gomod"
+)
+
+// ErrCycle is returned by Order when modules require each other.
+var ErrCycle = errors.New("workspace: modules require each other")
+
+// Requires returns the names of the modules among ms that each module of
+// ms requires in its go.mod, by module name. Modules without a go.mod
+// require none.
+func Requires(root string, ms []Module) (map[string][]string, error) {
+	names := make(map[string]bool, len(ms))
+	for _, m := range ms {
+		names[m.Name] = true
+	}
+	requires := make(map[string][]string)
+	for _, m := range ms {
+		if m.Dir == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(m.Dir), "go.mod"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("workspace: %w", err)
+		}
+		reqs, err := gomod.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("workspace: %s/%w", m.Dir, err)
+		}
+		for _, r := range reqs {
+			if names[r.Path] && r.Path != m.Name {
+				requires[m.Name] = append(requires[m.Name], r.Path)
+			}
+		}
+	}
+	return requires, nil
+}
+
+// Order groups ms in dependency order, as given by requires: the modules
+// of a group only require modules of earlier groups, so that each group
+// can be released once the previous ones are, and the modules of a group
+// independently of each other. Within a group, modules keep the order of
+// ms. Requirements of modules outside ms are ignored.
+func Order(ms []Module, requires map[string][]string) ([][]Module, error) {
+	left := slices.Clone(ms)
+	done := make(map[string]bool, len(ms))
+	pending := make(map[string]bool, len(ms))
+	for _, m := range ms {
+		pending[m.Name] = true
+	}
+
+	var groups [][]Module
+	for len(left) > 0 {
+		var group, rest []Module
+		for _, m := range left {
+			ready := true
+			for _, r := range requires[m.Name] {
+				if pending[r] && !done[r] {
+					ready = false
+				}
+			}
+			if ready {
+				group = append(group, m)
+			} else {
+				rest = append(rest, m)
+			}
+		}
+		if len(group) == 0 {
+			names := make([]string, len(rest))
+			for i, m := range rest {
+				names[i] = m.Name
+			}
+			return nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(names, ", "))
+		}
+		for _, m := range group {
+			done[m.Name] = true
+		}
+		groups = append(groups, group)
+		left = rest
+	}
+	return groups, nil
+}
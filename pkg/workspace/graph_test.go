@@ -0,0 +1,60 @@
+package workspace
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRequires(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "api/go.mod", "module example.com/api\n")
+	writeFile(t, root, "core/go.mod", "module example.com/core\n\nrequire (\n\texample.com/api v1.0.0\n\tgolang.org/x/sync v0.21.0\n)\n")
+	writeFile(t, root, "app/go.mod", "module example.com/app\n\nrequire example.com/core v0.3.0\nrequire example.com/api v1.0.0 // indirect\n")
+
+	ms := []Module{
+		{Name: "example.com/api", Dir: "api"},
+		{Name: "example.com/app", Dir: "app"},
+		{Name: "example.com/core", Dir: "core"},
+		{Name: "docs", Dir: "docs"},
+	}
+	got, err := Requires(root, ms)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]string{
+		"example.com/core": {"example.com/api"},
+		"example.com/app":  {"example.com/core", "example.com/api"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	api, core, app, cli := Module{Name: "api"}, Module{Name: "core"}, Module{Name: "app"}, Module{Name: "cli"}
+	requires := map[string][]string{
+		"core": {"api"},
+		"app":  {"core", "api"},
+		"cli":  {"core", "outside"},
+	}
+	got, err := Order([]Module{app, cli, core, api}, requires)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := [][]Module{{api}, {core}, {app, cli}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Without api, core has nothing left to wait for.
+	got, _ = Order([]Module{app, core}, requires)
+	if !reflect.DeepEqual(got, [][]Module{{core}, {app}}) {
+		t.Errorf("Expected core then app, got %v", got)
+	}
+
+	requires["api"] = []string{"app"}
+	if _, err := Order([]Module{app, cli, core, api}, requires); !errors.Is(err, ErrCycle) || err.Error() != "workspace: modules require each other: app, cli, core, api" {
+		t.Errorf("Expected ErrCycle naming the modules, got %v", err)
+	}
+}
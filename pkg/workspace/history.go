@@ -0,0 +1,94 @@
+package workspace
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// Release is a past release of a module: a stable version tag and the
+// commits it introduced.
+type Release struct {
+	Version version.Version
+	Tag     string
+	// Date is the date of the tagged commit.
+	Date time.Time
+	// Raw and Commits are as in Plan, newest first.
+	Raw     []gitrepo.Commit
+	Commits []commits.Commit
+}
+
+// History splits the history of HEAD into the stable releases of m, oldest
+// first. A release holds the commits reachable from its tag that no earlier
+// release holds, restricted to the module's paths or scopes, less the
+// changes reverted within the release (see DropReverted). Tags not reachable from
+// HEAD and prerelease tags are skipped; commits since the latest tag are
+// not included.
+func History(ctx context.Context, repo gitrepo.Repository, m Module) ([]Release, error) {
+	tags, err := repo.Tags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all, err := repo.CommitsSince(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	inPath, err := m.pathFilter(ctx, repo, "")
+	if err != nil {
+		return nil, err
+	}
+
+	byHash := make(map[string]gitrepo.Commit, len(all))
+	for _, c := range all {
+		byHash[c.Hash] = c
+	}
+	var releases []Release
+	commitOf := make(map[string]string)
+	for _, t := range tags {
+		v, ok := m.Version(t.Name)
+		if !ok || v.IsPrerelease() {
+			continue
+		}
+		if _, ok := byHash[t.Commit]; !ok {
+			continue
+		}
+		releases = append(releases, Release{Version: v, Tag: t.Name})
+		commitOf[t.Name] = t.Commit
+	}
+	slices.SortFunc(releases, func(a, b Release) int { return a.Version.Compare(b.Version) })
+
+	// Commits are released by the first tag that reaches them. The walk
+	// stops at assigned commits, whose ancestors are assigned too.
+	assigned := make(map[string]bool)
+	for i := range releases {
+		r := &releases[i]
+		head := byHash[commitOf[r.Tag]]
+		r.Date = head.Date
+
+		reached := make(map[string]bool)
+		stack := []string{head.Hash}
+		for len(stack) > 0 {
+			h := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			c, ok := byHash[h]
+			if !ok || assigned[h] || reached[h] {
+				continue
+			}
+			reached[h] = true
+			stack = append(stack, c.Parents...)
+		}
+		var released []gitrepo.Commit
+		for _, c := range all {
+			if reached[c.Hash] {
+				assigned[c.Hash] = true
+				released = append(released, c)
+			}
+		}
+		r.Raw, r.Commits = m.Select(DropReverted(released), inPath)
+	}
+	return releases, nil
+}
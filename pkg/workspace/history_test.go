@@ -0,0 +1,66 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+func TestHistory(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	repo := &fakeRepo{
+		tags: []gitrepo.Tag{
+			{Name: "v1.1.0", Commit: "e"},
+			{Name: "v1.0.0", Commit: "b"},
+			{Name: "v1.1.0-rc.1", Commit: "c"},
+			{Name: "v0.9.0", Commit: "elsewhere"},
+			{Name: "pkg/foo/v1.0.0", Commit: "a"},
+		},
+		// Newest first; d merges the side branch c into the line from b.
+		commits: []gitrepo.Commit{
+			{Hash: "f", Parents: []string{"e"}, Message: "feat: unreleased", Date: day(6)},
+			{Hash: "e", Parents: []string{"d"}, Message: "chore(release): v1.1.0", Date: day(5)},
+			{Hash: "d", Parents: []string{"b", "c"}, Message: "Merge branch 'topic'", Date: day(4)},
+			{Hash: "c", Parents: []string{"a"}, Message: "feat: topic", Date: day(3)},
+			{Hash: "b", Parents: []string{"a"}, Message: "fix: first bug", Date: day(2)},
+			{Hash: "a", Message: "feat: initial", Date: day(1)},
+		},
+	}
+
+	releases, err := History(context.Background(), repo, Repository("v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("Expected 2 releases, got %#v", releases)
+	}
+
+	first, second := releases[0], releases[1]
+	if first.Tag != "v1.0.0" || !first.Date.Equal(day(2)) || hashes(first.Raw) != "ba" {
+		t.Errorf("Expected v1.0.0 on day 2 with b and a, got %s %s %s", first.Tag, first.Date, hashes(first.Raw))
+	}
+	if second.Tag != "v1.1.0" || !second.Date.Equal(day(5)) || hashes(second.Raw) != "edc" {
+		t.Errorf("Expected v1.1.0 on day 5 with e, d and c, got %s %s %s", second.Tag, second.Date, hashes(second.Raw))
+	}
+	if len(second.Commits) != 2 || second.Commits[1].Description != "topic" {
+		t.Errorf("Expected the conventional commits of v1.1.0, got %#v", second.Commits)
+	}
+}
+
+func TestHistoryEmpty(t *testing.T) {
+	repo := &fakeRepo{commits: []gitrepo.Commit{{Hash: "a", Message: "feat: x"}}}
+	releases, err := History(context.Background(), repo, Repository("v"))
+	if err != nil || len(releases) != 0 {
+		t.Errorf("Expected no releases, got %#v %v", releases, err)
+	}
+}
+
+func hashes(cs []gitrepo.Commit) string {
+	var s string
+	for _, c := range cs {
+		s += c.Hash
+	}
+	return s
+}
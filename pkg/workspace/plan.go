@@ -0,0 +1,228 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// Plan is the release analysis of one module: its latest stable version,
+// the conventional commits since then and the resulting next version.
+//
+// On a prerelease Channel, Next is the bumped stable version with a
+// "<channel>.<n>" prerelease, n counting up from the channel's existing tags
+// for that version. Prerelease tags never serve as the base, so merging to
+// a stable branch releases every change since the last stable version.
+//
+// On a maintenance Line, Previous is the latest stable version of the line
+// reachable from HEAD; see NewLinePlan.
+type Plan struct {
+	Module Module
+	// Channel is the prerelease channel, or "" for stable releases.
+	Channel string
+	// Line is the maintenance line released, or nil outside of one.
+	Line *version.Line
+	// Scheme computes Next from Previous; nil means version.SemVer. Date is
+	// the release date given to it.
+	Scheme version.Scheme
+	Date   time.Time
+
+	// Previous is the latest stable version; HasPrevious is false when the
+	// module has no stable version tags yet, in which case Previous is 0.0.0
+	// or the base given to WithBase.
+	Previous    version.Version
+	HasPrevious bool
+	PreviousTag string
+
+	// Raw holds every commit read from git, including non-conventional
+	// ones; Commits holds those that parsed.
+	Raw     []gitrepo.Commit
+	Commits []commits.Commit
+	Level   version.Level
+	Next    version.Version
+	// Classifier derived Level from Commits; see WithClassifier.
+	Classifier commits.Classifier
+
+	// versions are all versions tagged for the module, prereleases included.
+	versions []version.Version
+	// initial is the version of the first release, set by WithInitial.
+	initial    version.Version
+	hasInitial bool
+}
+
+// Tag returns the tag name for the next version.
+func (p Plan) Tag() string {
+	return p.Module.Tag(p.Next.String())
+}
+
+// WithLevel returns p with an explicit bump level instead of the one
+// derived from commits.
+func (p Plan) WithLevel(l version.Level) Plan {
+	p.Level = l
+	s := p.Scheme
+	if s == nil {
+		s = version.SemVer{}
+	}
+	p.Next = s.Next(p.Previous, l, p.Date)
+	if p.hasInitial && !p.HasPrevious && l != version.None {
+		p.Next = p.initial
+	}
+	if p.Channel != "" && l != version.None {
+		// Channel names are validated by config; an invalid one keeps the
+		// stable version, as WithPrerelease leaves it unchanged.
+		p.Next, _ = version.NextPrerelease(p.Next, p.Channel, p.versions)
+	}
+	return p
+}
+
+// WithInitial returns p releasing v first when the module has no stable
+// version tag yet, whatever the level of its commits. Once a version is
+// tagged, it has no effect.
+func (p Plan) WithInitial(v version.Version) Plan {
+	p.initial, p.hasInitial = v, true
+	return p.WithLevel(p.Level)
+}
+
+// WithBase returns p bumping v when the module has no stable version tag
+// yet, as for a project released before its versions were tagged. Once a
+// version is tagged, it has no effect.
+func (p Plan) WithBase(v version.Version) Plan {
+	if !p.HasPrevious {
+		p.Previous = v
+	}
+	return p.WithLevel(p.Level)
+}
+
+// WithClassifier returns p with the level cl derives from the commits
+// instead of the Conventional Commits defaults.
+func (p Plan) WithClassifier(cl commits.Classifier) Plan {
+	p.Classifier = cl
+	return p.WithLevel(cl.Classify(p.Commits))
+}
+
+// WithScheme returns p with Next computed by s for a release at date.
+func (p Plan) WithScheme(s version.Scheme, date time.Time) Plan {
+	p.Scheme, p.Date = s, date
+	return p.WithLevel(p.Level)
+}
+
+// OnChannel returns p releasing to the prerelease channel, or to stable
+// when channel is "".
+func (p Plan) OnChannel(channel string) Plan {
+	p.Channel = channel
+	return p.WithLevel(p.Level)
+}
+
+// NewPlan analyses m in repo. The level is derived from the commits; when
+// it is None, Next equals Previous.
+func NewPlan(ctx context.Context, repo gitrepo.Repository, m Module) (Plan, error) {
+	tags, err := repo.Tags(ctx)
+	if err != nil {
+		return Plan{Module: m}, err
+	}
+	return newPlan(ctx, repo, m, tags, tags)
+}
+
+// NewLinePlan is NewPlan on a maintenance branch of line. Only the stable
+// tags of the line reachable from HEAD serve as the base, so a newer
+// release cut elsewhere, such as 2.0.0 on main, does not hide 1.4.2, and
+// the commits are those since the line's own latest release. It is an
+// error for the line to have no release yet.
+func NewLinePlan(ctx context.Context, repo gitrepo.Repository, m Module, line version.Line) (Plan, error) {
+	p := Plan{Module: m}
+	tags, err := repo.Tags(ctx)
+	if err != nil {
+		return p, err
+	}
+	all, err := repo.CommitsSince(ctx, "")
+	if err != nil {
+		return p, err
+	}
+	reachable := make(map[string]bool, len(all))
+	for _, c := range all {
+		reachable[c.Hash] = true
+	}
+	var base []gitrepo.Tag
+	for _, t := range tags {
+		if v, ok := m.Version(t.Name); ok && line.Contains(v) && reachable[t.Commit] {
+			base = append(base, t)
+		}
+	}
+
+	p, err = newPlan(ctx, repo, m, tags, base)
+	if err != nil {
+		return p, err
+	}
+	if !p.HasPrevious {
+		return p, fmt.Errorf("no release of line %s is reachable from HEAD", m.Tag(line.String()))
+	}
+	p.Line = &line
+	return p, nil
+}
+
+// newPlan plans m on top of the latest stable version among base. Tags
+// holds every tag, from which prerelease counters are taken.
+func newPlan(ctx context.Context, repo gitrepo.Repository, m Module, tags, base []gitrepo.Tag) (Plan, error) {
+	p := Plan{Module: m}
+	p.versions, _ = Versions(tags, m)
+	p.Previous, p.PreviousTag, p.HasPrevious = LatestTag(base, m, true)
+
+	var err error
+	p.Raw, p.Commits, err = m.commitsSince(ctx, repo, p.PreviousTag)
+	if err != nil {
+		return p, err
+	}
+
+	return p.WithLevel(commits.Classify(p.Commits)), nil
+}
+
+// Versions returns the versions of the tags of m, and the tag name of each
+// version keyed by its string form. Tags in other formats, or whose version
+// is not a semantic version, are ignored.
+func Versions(tags []gitrepo.Tag, m Module) ([]version.Version, map[string]string) {
+	var versions []version.Version
+	byVersion := make(map[string]string)
+	for _, t := range tags {
+		v, ok := m.Version(t.Name)
+		if !ok {
+			continue
+		}
+		versions = append(versions, v)
+		byVersion[v.String()] = t.Name
+	}
+	return versions, byVersion
+}
+
+// LatestTag returns the highest version among the tags of m, and the name
+// of that tag. When stable is true, prerelease tags are skipped.
+func LatestTag(tags []gitrepo.Tag, m Module, stable bool) (version.Version, string, bool) {
+	versions, byVersion := Versions(tags, m)
+	latest, ok := version.Latest(versions, stable)
+	if !ok {
+		return version.Version{}, "", false
+	}
+	return latest, byVersion[latest.String()], true
+}
+
+// Parse converts raw git commits into conventional commits, dropping those
+// that do not follow the specification. Merge and squash-merge commits are
+// read as the pull request title or the squashed commits they hold (see
+// commits.Messages), which then share the hash of the raw commit.
+func Parse(raw []gitrepo.Commit) []commits.Commit {
+	var cs []commits.Commit
+	for _, rc := range raw {
+		for _, m := range commits.Messages(rc.Message) {
+			c, err := commits.Parse(m)
+			if err != nil {
+				continue
+			}
+			c.Hash = rc.Hash
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
@@ -0,0 +1,282 @@
+package workspace
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+type fakeRepo struct {
+	gitrepo.Repository
+	tags    []gitrepo.Tag
+	commits []gitrepo.Commit
+	since   string
+	paths   []string
+}
+
+func (f *fakeRepo) Tags(context.Context) ([]gitrepo.Tag, error) { return f.tags, nil }
+
+func (f *fakeRepo) CommitsSince(_ context.Context, ref string, paths ...string) ([]gitrepo.Commit, error) {
+	f.since, f.paths = ref, paths
+	return f.commits, nil
+}
+
+func TestNewPlan(t *testing.T) {
+	repo := &fakeRepo{
+		tags: []gitrepo.Tag{{Name: "v2.0.0"}, {Name: "pkg/foo/v1.2.0"}, {Name: "pkg/foo/v1.10.0"}, {Name: "pkg/foo/vnext"}},
+		commits: []gitrepo.Commit{
+			{Hash: "a", Message: "fix(foo): bug"},
+			{Hash: "b", Message: "feat(foo): thing"},
+			{Hash: "c", Message: "not conventional"},
+		},
+	}
+	m := Module{Dir: "pkg/foo", TagPrefix: "pkg/foo/v", Exclude: []string{"pkg/foo/inner"}}
+
+	p, err := NewPlan(context.Background(), repo, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.PreviousTag != "pkg/foo/v1.10.0" || !p.HasPrevious {
+		t.Errorf("Expected previous tag pkg/foo/v1.10.0, got %q", p.PreviousTag)
+	}
+	if repo.since != "pkg/foo/v1.10.0" {
+		t.Errorf("Expected commits since pkg/foo/v1.10.0, got %q", repo.since)
+	}
+	if !reflect.DeepEqual(repo.paths, []string{"pkg/foo", ":(exclude)pkg/foo/inner"}) {
+		t.Errorf("Expected scoped pathspecs, got %v", repo.paths)
+	}
+	if len(p.Commits) != 2 || p.Commits[0].Hash != "a" {
+		t.Errorf("Expected 2 conventional commits, got %#v", p.Commits)
+	}
+	if p.Level != version.Minor || p.Tag() != "pkg/foo/v1.11.0" {
+		t.Errorf("Expected minor bump to pkg/foo/v1.11.0, got %s %s", p.Level, p.Tag())
+	}
+}
+
+func TestNewPlanTagTemplate(t *testing.T) {
+	repo := &fakeRepo{
+		tags:    []gitrepo.Tag{{Name: "v3.0.0"}, {Name: "release-1.2.0"}, {Name: "release-1.4.0"}, {Name: "release-1.5.0-rc.1"}, {Name: "release-candidate"}},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "fix: bug"}},
+	}
+	m, err := Repository("v").WithTemplate("release-{{.Version}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, err := NewPlan(context.Background(), repo, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.PreviousTag != "release-1.4.0" || p.Tag() != "release-1.4.1" {
+		t.Errorf("Expected release-1.4.1 after release-1.4.0, got %s after %s", p.Tag(), p.PreviousTag)
+	}
+}
+
+func TestNewPlanNoTags(t *testing.T) {
+	repo := &fakeRepo{commits: []gitrepo.Commit{{Hash: "a", Message: "docs: readme"}}}
+
+	p, err := NewPlan(context.Background(), repo, Repository("v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.HasPrevious || repo.since != "" || repo.paths != nil {
+		t.Errorf("Expected an unscoped full-history plan, got %#v", p)
+	}
+	if p.Level != version.None || p.Next.String() != "0.0.0" {
+		t.Errorf("Expected no bump, got %s %s", p.Level, p.Next)
+	}
+
+	p = p.WithLevel(version.Major)
+	if p.Tag() != "v1.0.0" {
+		t.Errorf("Expected v1.0.0, got %s", p.Tag())
+	}
+}
+
+func TestPlanBootstrap(t *testing.T) {
+	repo := &fakeRepo{commits: []gitrepo.Commit{{Hash: "a", Message: "fix: first"}}}
+	p, err := NewPlan(context.Background(), repo, Repository("v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	initial := p.WithInitial(version.MustParse("1.0.0"))
+	if initial.Tag() != "v1.0.0" {
+		t.Errorf("Expected the initial version, got %s", initial.Tag())
+	}
+	if got := initial.OnChannel("rc").Tag(); got != "v1.0.0-rc.1" {
+		t.Errorf("Expected v1.0.0-rc.1, got %s", got)
+	}
+	if got := initial.WithLevel(version.None).Next.String(); got != "0.0.0" {
+		t.Errorf("Expected nothing to release without changes, got %s", got)
+	}
+
+	base := p.WithBase(version.MustParse("2.3.1"))
+	if base.Tag() != "v2.3.2" || base.HasPrevious || base.PreviousTag != "" {
+		t.Errorf("Expected v2.3.2 bumped from the untagged base, got %s", base.Tag())
+	}
+
+	repo.tags = []gitrepo.Tag{{Name: "v0.4.0"}}
+	p, err = NewPlan(context.Background(), repo, Repository("v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := p.WithInitial(version.MustParse("1.0.0")).WithBase(version.MustParse("2.3.1")).Tag(); got != "v0.4.1" {
+		t.Errorf("Expected the tags to win over the bootstrap, got %s", got)
+	}
+}
+
+func TestNewPlanChannel(t *testing.T) {
+	repo := &fakeRepo{
+		tags: []gitrepo.Tag{{Name: "v1.2.0"}, {Name: "v1.3.0-rc.1"}, {Name: "v1.3.0-rc.2"}, {Name: "v1.3.0-beta.1"}},
+		commits: []gitrepo.Commit{
+			{Hash: "a", Message: "feat: new thing"},
+			{Hash: "b", Message: "Merge branch 'release/1.3'"},
+		},
+	}
+
+	p, err := NewPlan(context.Background(), repo, Repository("v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.PreviousTag != "v1.2.0" || repo.since != "v1.2.0" {
+		t.Errorf("Expected the latest stable tag as base, got %q since %q", p.PreviousTag, repo.since)
+	}
+	if p.Tag() != "v1.3.0" {
+		t.Errorf("Expected promotion to v1.3.0 on the stable channel, got %s", p.Tag())
+	}
+
+	if got := p.OnChannel("rc").Tag(); got != "v1.3.0-rc.3" {
+		t.Errorf("Expected v1.3.0-rc.3, got %s", got)
+	}
+	if got := p.OnChannel("alpha").Tag(); got != "v1.3.0-alpha.1" {
+		t.Errorf("Expected v1.3.0-alpha.1, got %s", got)
+	}
+	if got := p.OnChannel("rc").WithLevel(version.Major).Tag(); got != "v2.0.0-rc.1" {
+		t.Errorf("Expected v2.0.0-rc.1, got %s", got)
+	}
+	if got := p.OnChannel("rc").OnChannel("").Tag(); got != "v1.3.0" {
+		t.Errorf("Expected v1.3.0 back on stable, got %s", got)
+	}
+}
+
+func TestPlanWithClassifier(t *testing.T) {
+	repo := &fakeRepo{
+		tags:    []gitrepo.Tag{{Name: "v1.2.0"}},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "perf: faster"}, {Hash: "b", Message: "docs: typo"}},
+	}
+	p, err := NewPlan(context.Background(), repo, Repository("v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Level != version.None {
+		t.Errorf("Expected no release by default, got %s", p.Level)
+	}
+
+	p = p.WithClassifier(commits.Classifier{Rules: []commits.ClassRule{
+		{Pattern: regexp.MustCompile(`^perf:`), Level: version.Minor, HasLevel: true},
+	}})
+	if p.Level != version.Minor || p.Tag() != "v1.3.0" {
+		t.Errorf("Expected minor bump to v1.3.0, got %s %s", p.Level, p.Tag())
+	}
+}
+
+func TestNewPlanCalVer(t *testing.T) {
+	repo := &fakeRepo{
+		tags:    []gitrepo.Tag{{Name: "v1.4.0"}, {Name: "v2026.3.0"}, {Name: "v2026.3.1-rc.1"}},
+		commits: []gitrepo.Commit{{Hash: "a", Message: "fix: bug"}},
+	}
+	p, err := NewPlan(context.Background(), repo, Repository("v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	calver, _ := version.ParseCalVer("YYYY.MM.MICRO")
+
+	march := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	if got := p.WithScheme(calver, march).Tag(); got != "v2026.3.1" {
+		t.Errorf("Expected v2026.3.1, got %s", got)
+	}
+	april := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	if got := p.WithScheme(calver, april).Tag(); got != "v2026.4.0" {
+		t.Errorf("Expected v2026.4.0, got %s", got)
+	}
+	if got := p.WithScheme(calver, march).OnChannel("rc").Tag(); got != "v2026.3.1-rc.2" {
+		t.Errorf("Expected v2026.3.1-rc.2, got %s", got)
+	}
+	if got := p.WithScheme(calver, march).WithLevel(version.None).Tag(); got != "v2026.3.0" {
+		t.Errorf("Expected no release to keep v2026.3.0, got %s", got)
+	}
+}
+
+func TestLatestTag(t *testing.T) {
+	tags := []gitrepo.Tag{{Name: "v1.2.0"}, {Name: "v1.3.0-rc.1"}, {Name: "x1.9.0"}}
+
+	if _, tag, _ := LatestTag(tags, Repository("v"), false); tag != "v1.3.0-rc.1" {
+		t.Errorf("Expected v1.3.0-rc.1, got %s", tag)
+	}
+	if _, tag, _ := LatestTag(tags, Repository("v"), true); tag != "v1.2.0" {
+		t.Errorf("Expected v1.2.0, got %s", tag)
+	}
+	if _, _, ok := LatestTag(tags, Repository("pkg/v"), true); ok {
+		t.Error("Expected no tag for an unused prefix")
+	}
+}
+
+func TestParseMerges(t *testing.T) {
+	raw := []gitrepo.Commit{
+		{Hash: "a", Message: "Merge pull request #3 from octo/batch\n\nfeat: add batch processing"},
+		{Hash: "b", Message: "Batch fixes (#4)\n\n* fix: handle nil\n\n* fix(api): reject empty batches"},
+		{Hash: "c", Message: "Merge branch 'main' into batch"},
+	}
+	var got []string
+	for _, c := range Parse(raw) {
+		got = append(got, c.Hash+" "+c.Header())
+	}
+	expected := []string{"a feat: add batch processing", "b fix: handle nil", "b fix(api): reject empty batches"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestNewLinePlan(t *testing.T) {
+	repo := &fakeRepo{
+		tags: []gitrepo.Tag{
+			{Name: "v1.3.0", Commit: "a"},
+			{Name: "v1.4.1", Commit: "b"},
+			{Name: "v1.4.2-rc.1", Commit: "c"},
+			{Name: "v1.5.0", Commit: "elsewhere"},
+			{Name: "v2.0.0", Commit: "main"},
+		},
+		commits: []gitrepo.Commit{
+			{Hash: "c", Message: "fix: backport"},
+			{Hash: "b", Message: "fix: bug"},
+			{Hash: "a", Message: "feat: thing"},
+		},
+	}
+	line, _ := version.ParseLine("1.x")
+
+	p, err := NewLinePlan(context.Background(), repo, Repository("v"), line)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.PreviousTag != "v1.4.1" || repo.since != "v1.4.1" {
+		t.Errorf("Expected the line's latest reachable release v1.4.1, got %q since %q", p.PreviousTag, repo.since)
+	}
+	if p.Line == nil || *p.Line != line {
+		t.Errorf("Expected line %s, got %v", line, p.Line)
+	}
+	if p = p.WithLevel(version.Patch).OnChannel("rc"); p.Tag() != "v1.4.2-rc.2" {
+		t.Errorf("Expected v1.4.2-rc.2, got %s", p.Tag())
+	}
+
+	line, _ = version.ParseLine("3.x")
+	if _, err := NewLinePlan(context.Background(), repo, Repository("v"), line); err == nil || err.Error() != "no release of line v3.x is reachable from HEAD" {
+		t.Errorf("Expected an error for a line without releases, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package workspace
+
+import (
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/commits"
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+// DropReverted returns raw without the commits reverted within raw, and
+// without the reverts of those commits: a change made and undone within a
+// release is no change of it. raw is newest first, as git log lists it.
+// Reverts are matched to the commits they name by hash, or else by subject
+// (see commits.Reverts). A revert undone by a later revert is dropped with
+// it, leaving the change it reverted in effect, so a chain of reverts ends
+// with the change in or out as its last revert has it. A revert of a
+// commit outside raw, released before, is kept.
+func DropReverted(raw []gitrepo.Commit) []gitrepo.Commit {
+	dropped := make(map[int]bool)
+	for i, c := range raw {
+		if dropped[i] {
+			continue
+		}
+		r, ok := commits.Reverts(c.Message)
+		if !ok {
+			continue
+		}
+		// The reverted commits are older, listed after the revert.
+		var undone []int
+		for _, h := range r.Hashes {
+			for j := i + 1; j < len(raw); j++ {
+				if !dropped[j] && strings.HasPrefix(raw[j].Hash, h) {
+					undone = append(undone, j)
+					break
+				}
+			}
+		}
+		if len(undone) == 0 && r.Subject != "" {
+			for j := i + 1; j < len(raw); j++ {
+				if !dropped[j] && subject(raw[j].Message) == r.Subject {
+					undone = append(undone, j)
+					break
+				}
+			}
+		}
+		if len(undone) == 0 {
+			continue
+		}
+		dropped[i] = true
+		for _, j := range undone {
+			dropped[j] = true
+		}
+	}
+	if len(dropped) == 0 {
+		return raw
+	}
+	kept := make([]gitrepo.Commit, 0, len(raw)-len(dropped))
+	for i, c := range raw {
+		if !dropped[i] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// subject returns the first line of message.
+func subject(message string) string {
+	s, _, _ := strings.Cut(strings.TrimLeft(strings.ReplaceAll(message, "\r\n", "\n"), "\n"), "\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,103 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/gitrepo"
+)
+
+func TestDropReverted(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      []gitrepo.Commit
+		expected string
+	}{
+		{
+			"git revert",
+			[]gitrepo.Commit{
+				{Hash: "r1", Message: "Revert \"feat: x\"\n\nThis reverts commit a000000."},
+				{Hash: "b000000", Message: "fix: y"},
+				{Hash: "a000000", Message: "feat: x"},
+			},
+			"b000000",
+		},
+		{
+			"reverts trailer",
+			[]gitrepo.Commit{
+				{Hash: "r1", Message: "revert: feat: x\n\nReverts: a000000, b000000"},
+				{Hash: "b000000", Message: "fix: y"},
+				{Hash: "a000000", Message: "feat: x"},
+				{Hash: "c000000", Message: "docs: z"},
+			},
+			"c000000",
+		},
+		{
+			"subject only",
+			[]gitrepo.Commit{
+				{Hash: "r1", Message: "Revert \"feat: x\""},
+				{Hash: "a000001", Message: "feat: x"},
+				{Hash: "a000000", Message: "feat: x"},
+			},
+			"a000000",
+		},
+		{
+			"chain reapplies",
+			[]gitrepo.Commit{
+				{Hash: "f200000", Message: "Revert \"Revert \"feat: x\"\"\n\nThis reverts commit f100000."},
+				{Hash: "f100000", Message: "Revert \"feat: x\"\n\nThis reverts commit a000000."},
+				{Hash: "a000000", Message: "feat: x"},
+			},
+			"a000000",
+		},
+		{
+			"chain reverts again",
+			[]gitrepo.Commit{
+				{Hash: "f300000", Message: "Revert \"Revert \"Revert \"feat: x\"\"\"\n\nThis reverts commit f200000."},
+				{Hash: "f200000", Message: "Revert \"Revert \"feat: x\"\"\n\nThis reverts commit f100000."},
+				{Hash: "f100000", Message: "Revert \"feat: x\"\n\nThis reverts commit a000000."},
+				{Hash: "a000000", Message: "feat: x"},
+				{Hash: "b000000", Message: "fix: y"},
+			},
+			"b000000",
+		},
+		{
+			"released before",
+			[]gitrepo.Commit{
+				{Hash: "r1", Message: "Revert \"feat: x\"\n\nThis reverts commit a000000."},
+				{Hash: "b000000", Message: "fix: y"},
+			},
+			"r1b000000",
+		},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, c := range DropReverted(tt.raw) {
+			got += c.Hash
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestNewPlanDropsReverted(t *testing.T) {
+	repo := &fakeRepo{
+		tags: []gitrepo.Tag{{Name: "v1.0.0"}},
+		commits: []gitrepo.Commit{
+			{Hash: "c000000", Message: "Revert \"feat: thing\"\n\nThis reverts commit b000000."},
+			{Hash: "b000000", Message: "feat: thing"},
+			{Hash: "a000000", Message: "fix: bug"},
+		},
+	}
+	p, err := NewPlan(context.Background(), repo, Module{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Raw) != 1 || len(p.Commits) != 1 || p.Commits[0].Hash != "a000000" {
+		t.Errorf("Expected only the fix, got %+v", p.Commits)
+	}
+	if p.Next.String() != "1.0.1" {
+		t.Errorf("Expected 1.0.1, got %s", p.Next)
+	}
+}
@@ -0,0 +1,258 @@
+// Package workspace supports repositories containing several independently
+// versioned modules.
+//
+// Each Module owns a directory and a tag format, a prefix and an optional
+// suffix around the version rendered from a tag template. Its version is the
+// latest tag in that format, and its next version is computed only from commits
+// that touch its directory, excluding any module nested beneath it. A
+// single-module repository is a workspace with one Module at ".". Modules
+// may select their commits by Conventional Commit scope instead, or as well;
+// see Filter.
+package workspace
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+	"github.com/gbrennon/release_automation_golang/pkg/version"
+)
+
+// Module is an independently versioned part of the repository.
+type Module struct {
+	// Name identifies the module: the module path from go.mod, or the
+	// configured project name.
+	Name string
+	// Dir is the slash-separated directory relative to the repository root;
+	// "." is the root itself. An empty Dir stands for the whole repository,
+	// with no path scoping at all.
+	Dir string
+	// TagPrefix is prepended to versions to form tag names, e.g. "pkg/foo/v",
+	// and TagSuffix appended.
+	TagPrefix string
+	TagSuffix string
+	// Exclude lists directories of modules nested inside Dir, whose commits
+	// do not belong to this module.
+	Exclude []string
+	// Scopes are the Conventional Commit scopes of the module, and Filter
+	// how they combine with Dir to select its commits; see Select.
+	Scopes []string
+	Filter Filter
+}
+
+// Paths returns the git pathspecs selecting the commits of m, or nil when m
+// is the whole repository.
+func (m Module) Paths() []string {
+	if m.Dir == "" {
+		return nil
+	}
+	paths := []string{m.Dir}
+	for _, e := range m.Exclude {
+		paths = append(paths, ":(exclude)"+e)
+	}
+	return paths
+}
+
+// Tag returns the tag name of version v for m.
+func (m Module) Tag(v string) string {
+	return m.TagPrefix + v + m.TagSuffix
+}
+
+// Version parses the version tag names for m. It reports false for the
+// tags of other modules, or in other formats.
+func (m Module) Version(tag string) (version.Version, bool) {
+	rest, ok := strings.CutPrefix(tag, m.TagPrefix)
+	if !ok {
+		return version.Version{}, false
+	}
+	rest, ok = strings.CutSuffix(rest, m.TagSuffix)
+	if !ok {
+		return version.Version{}, false
+	}
+	v, err := version.Parse(rest)
+	return v, err == nil
+}
+
+// WithTemplate returns m tagged according to the tag template text (see
+// config.TagConfig.Template). An empty text leaves m as it is.
+func (m Module) WithTemplate(text string) (Module, error) {
+	if text == "" {
+		return m, nil
+	}
+	dir := m.Dir
+	if dir == "." {
+		dir = ""
+	}
+	prefix, suffix, err := config.TagAffixes(text, dir, m.Name)
+	if err != nil {
+		return m, fmt.Errorf("workspace: tag template %q: %w", text, err)
+	}
+	m.TagPrefix, m.TagSuffix = prefix, suffix
+	return m, nil
+}
+
+// Repository returns the unscoped module covering the whole repository,
+// tagged with prefix. It is what single-module repositories release.
+func Repository(prefix string) Module {
+	return Module{TagPrefix: prefix}
+}
+
+// Resolve returns the modules of the repository at root: the projects listed
+// in cfg when there are any, otherwise the Go modules found by Detect. Tags
+// follow the project's tag template, or its tag prefix, or tag.template, or
+// tag.prefix, the first that is set. Like tag.prefix, a tag.template that
+// does not use .Module is prefixed with "<dir>/" for nested modules.
+func Resolve(root string, cfg *config.Config) ([]Module, error) {
+	var ms []Module
+	if len(cfg.Projects) > 0 {
+		ms = FromConfig(cfg.Projects, cfg.Tag.Prefix)
+	} else {
+		var err error
+		if ms, err = Detect(root, cfg.Tag.Prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, m := range ms {
+		text, global := cfg.Tag.Template, true
+		for _, p := range cfg.Projects {
+			if path.Clean(filepath.ToSlash(p.Path)) != m.Dir {
+				continue
+			}
+			if p.TagTemplate != "" {
+				text, global = p.TagTemplate, false
+			} else if p.TagPrefix != "" {
+				text = ""
+			}
+		}
+		t, err := m.WithTemplate(text)
+		if err != nil {
+			return nil, err
+		}
+		if text != "" && global && m.Dir != "." && !strings.Contains(text, ".Module") {
+			t.TagPrefix = m.Dir + "/" + t.TagPrefix
+		}
+		ms[i] = t
+	}
+	return ms, nil
+}
+
+// FromConfig converts configured projects into modules.
+func FromConfig(projects []config.ProjectConfig, prefix string) []Module {
+	var ms []Module
+	for _, p := range projects {
+		dir := path.Clean(filepath.ToSlash(p.Path))
+		m := Module{Name: p.Name, Dir: dir, TagPrefix: p.TagPrefix, Scopes: p.Scopes, Filter: Filter(p.Filter)}
+		if m.Name == "" {
+			m.Name = dir
+		}
+		if m.TagPrefix == "" {
+			m.TagPrefix = tagPrefix(dir, prefix)
+		}
+		ms = append(ms, m)
+	}
+	return withExcludes(ms)
+}
+
+// Detect walks root for go.mod files and returns one Module per Go module,
+// sorted by directory. The root module, if any, is tagged with prefix; nested
+// modules use "<dir>/<prefix>" following the Go convention for multi-module
+// repositories (e.g. pkg/foo/v1.2.3). Hidden, vendor and testdata
+// directories are skipped.
+func Detect(root, prefix string) ([]Module, error) {
+	var ms []Module
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+
+		name, err := modulePath(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		dir := filepath.ToSlash(rel)
+		ms = append(ms, Module{Name: name, Dir: dir, TagPrefix: tagPrefix(dir, prefix)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("workspace: detect modules in %s: %w", root, err)
+	}
+	return withExcludes(ms), nil
+}
+
+// Find returns the module with the given directory or name.
+func Find(ms []Module, key string) (Module, bool) {
+	clean := path.Clean(filepath.ToSlash(key))
+	for _, m := range ms {
+		if m.Dir == clean || m.Name == key {
+			return m, true
+		}
+	}
+	return Module{}, false
+}
+
+func tagPrefix(dir, prefix string) string {
+	if dir == "." {
+		return prefix
+	}
+	return dir + "/" + prefix
+}
+
+// withExcludes sorts ms by directory and records, for each module, the
+// directories of the modules nested inside it.
+func withExcludes(ms []Module) []Module {
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Dir < ms[j].Dir })
+	for i := range ms {
+		ms[i].Exclude = nil
+		for j := range ms {
+			if i != j && contains(ms[i].Dir, ms[j].Dir) {
+				ms[i].Exclude = append(ms[i].Exclude, ms[j].Dir)
+			}
+		}
+	}
+	return ms
+}
+
+// contains reports whether child is strictly inside parent.
+func contains(parent, child string) bool {
+	if parent == "." {
+		return child != "."
+	}
+	return strings.HasPrefix(child, parent+"/")
+}
+
+func modulePath(gomod string) (string, error) {
+	data, err := os.ReadFile(gomod)
+	if err != nil {
+		return "", err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("%s: no module directive", gomod)
+}
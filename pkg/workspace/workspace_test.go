@@ -0,0 +1,154 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gbrennon/release_automation_golang/pkg/config"
+)
+
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/mono\n\ngo 1.22\n")
+	writeFile(t, root, "pkg/foo/go.mod", "// comment\nmodule example.com/mono/pkg/foo\n")
+	writeFile(t, root, "pkg/foo/inner/go.mod", "module \"example.com/mono/pkg/foo/inner\"\n")
+	writeFile(t, root, "tools/go.mod", "module example.com/mono/tools\n")
+	writeFile(t, root, "vendor/x/go.mod", "module x\n")
+	writeFile(t, root, ".cache/go.mod", "module cache\n")
+	writeFile(t, root, "pkg/foo/testdata/go.mod", "module fixture\n")
+
+	ms, err := Detect(root, "v")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Module{
+		{Name: "example.com/mono", Dir: ".", TagPrefix: "v", Exclude: []string{"pkg/foo", "pkg/foo/inner", "tools"}},
+		{Name: "example.com/mono/pkg/foo", Dir: "pkg/foo", TagPrefix: "pkg/foo/v", Exclude: []string{"pkg/foo/inner"}},
+		{Name: "example.com/mono/pkg/foo/inner", Dir: "pkg/foo/inner", TagPrefix: "pkg/foo/inner/v"},
+		{Name: "example.com/mono/tools", Dir: "tools", TagPrefix: "tools/v"},
+	}
+	if !reflect.DeepEqual(ms, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, ms)
+	}
+}
+
+func TestDetectMissingModuleDirective(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "go 1.22\n")
+
+	if _, err := Detect(root, "v"); err == nil {
+		t.Errorf("Expected error for go.mod without a module directive")
+	}
+}
+
+func TestResolveFromConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.Projects = []config.ProjectConfig{
+		{Path: "services/api/"},
+		{Path: "web", Name: "frontend", TagPrefix: "web@"},
+	}
+
+	ms, err := Resolve(t.TempDir(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Module{
+		{Name: "services/api", Dir: "services/api", TagPrefix: "services/api/v"},
+		{Name: "frontend", Dir: "web", TagPrefix: "web@"},
+	}
+	if !reflect.DeepEqual(ms, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, ms)
+	}
+}
+
+func TestResolveTagTemplates(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tag.Template = "release-{{.Version}}"
+	cfg.Projects = []config.ProjectConfig{
+		{Path: "."},
+		{Path: "api"},
+		{Path: "web", TagPrefix: "web@"},
+		{Path: "cli", Name: "tool", TagTemplate: "{{.Name}}/v{{.Version}}-stable"},
+	}
+
+	ms, err := Resolve(t.TempDir(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tags := make(map[string]string)
+	for _, m := range ms {
+		tags[m.Dir] = m.Tag("1.2.0")
+	}
+	expected := map[string]string{".": "release-1.2.0", "api": "api/release-1.2.0", "web": "web@1.2.0", "cli": "tool/v1.2.0-stable"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected %v, got %v", expected, tags)
+	}
+
+	cfg.Tag.Template = "{{.Module}}/v{{.Version}}"
+	cfg.Projects = nil
+	m, err := Module{Dir: "pkg/foo"}.WithTemplate(cfg.Tag.Template)
+	if err != nil || m.Tag("1.2.0") != "pkg/foo/v1.2.0" {
+		t.Errorf("Expected the Go convention, got %q (%v)", m.Tag("1.2.0"), err)
+	}
+	if m, _ := Repository("").WithTemplate(cfg.Tag.Template); m.Tag("1.2.0") != "v1.2.0" {
+		t.Errorf("Expected the root module tagged v1.2.0, got %q", m.Tag("1.2.0"))
+	}
+	if _, err := Repository("").WithTemplate("{{.Missing}}"); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+}
+
+func TestModuleVersion(t *testing.T) {
+	m := Module{TagPrefix: "app@", TagSuffix: "-final"}
+	for tag, expected := range map[string]string{"app@1.2.0-final": "1.2.0", "app@1.3.0-rc.1-final": "1.3.0-rc.1"} {
+		if v, ok := m.Version(tag); !ok || v.String() != expected {
+			t.Errorf("Expected %s from %s, got %s (%v)", expected, tag, v, ok)
+		}
+	}
+	for _, tag := range []string{"app@1.2.0", "v1.2.0-final", "app@next-final"} {
+		if _, ok := m.Version(tag); ok {
+			t.Errorf("Expected %s not to be a tag of the module", tag)
+		}
+	}
+}
+
+func TestPaths(t *testing.T) {
+	m := Module{Dir: ".", Exclude: []string{"pkg/foo"}}
+	expected := []string{".", ":(exclude)pkg/foo"}
+	if !reflect.DeepEqual(m.Paths(), expected) {
+		t.Errorf("Expected %v, got %v", expected, m.Paths())
+	}
+
+	if Repository("v").Paths() != nil {
+		t.Errorf("Expected no pathspecs for the whole repository")
+	}
+}
+
+func TestFind(t *testing.T) {
+	ms := []Module{{Name: "example.com/mono/pkg/foo", Dir: "pkg/foo"}}
+
+	if _, ok := Find(ms, "pkg/foo/"); !ok {
+		t.Errorf("Expected to find the module by directory")
+	}
+	if _, ok := Find(ms, "example.com/mono/pkg/foo"); !ok {
+		t.Errorf("Expected to find the module by name")
+	}
+	if _, ok := Find(ms, "pkg/bar"); ok {
+		t.Errorf("Did not expect to find pkg/bar")
+	}
+}